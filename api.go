@@ -22,6 +22,9 @@ type API struct {
 	interceptorRegistry *interceptor.Registry
 
 	interceptor interceptor.Interceptor // Generated per PeerConnection
+	twccSender  *twccSenderInterceptor  // Part of interceptor if ConfigureTWCCSender was used
+
+	registry *peerConnectionRegistry
 }
 
 // NewAPI Creates a new API object for keeping semi-global settings to WebRTC objects
@@ -32,6 +35,7 @@ func NewAPI(options ...func(*API)) *API {
 	a := &API{
 		interceptor:   &interceptor.NoOp{},
 		settingEngine: &SettingEngine{},
+		registry:      newPeerConnectionRegistry(),
 	}
 
 	for _, o := range options {
@@ -63,6 +67,25 @@ func NewAPI(options ...func(*API)) *API {
 	return a
 }
 
+// GetSenderCapabilities returns the codecs and header extensions of kind
+// that RTPSenders can send, as registered in the MediaEngine. The codecs and
+// header extensions of a negotiation are a subset of them. It returns nil
+// for an unknown kind.
+//
+// https://w3c.github.io/webrtc-pc/#dom-rtcrtpsender-getcapabilities
+func (api *API) GetSenderCapabilities(kind RTPCodecType) *RTPCapabilities {
+	return api.mediaEngine.getCapabilities(kind, RTPTransceiverDirectionSendonly)
+}
+
+// GetReceiverCapabilities returns the codecs and header extensions of kind
+// that RTPReceivers can receive, as registered in the MediaEngine. It
+// returns nil for an unknown kind.
+//
+// https://w3c.github.io/webrtc-pc/#dom-rtcrtpreceiver-getcapabilities
+func (api *API) GetReceiverCapabilities(kind RTPCodecType) *RTPCapabilities {
+	return api.mediaEngine.getCapabilities(kind, RTPTransceiverDirectionRecvonly)
+}
+
 // WithMediaEngine allows providing a MediaEngine to the API.
 // Settings can be changed after passing the engine to an API.
 // When a PeerConnection is created the MediaEngine is copied
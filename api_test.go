@@ -8,7 +8,9 @@ package webrtc
 
 import (
 	"testing"
+	"time"
 
+	"github.com/pion/interceptor"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,3 +60,83 @@ func TestNewAPI_OptionsDefaultize(t *testing.T) {
 	assert.NotNil(t, api.mediaEngine)
 	assert.NotNil(t, api.interceptorRegistry)
 }
+
+func TestAPI_PeerConnections(t *testing.T) {
+	api := NewAPI()
+	assert.Empty(t, api.PeerConnections())
+
+	pcA, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcB, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	infos := api.PeerConnections()
+	assert.Len(t, infos, 2)
+	assert.Equal(t, pcA, infos[0].PeerConnection)
+	assert.Equal(t, pcB, infos[1].PeerConnection)
+	assert.Equal(t, PeerConnectionStateNew, infos[0].ConnectionState)
+	assert.Equal(t, SignalingStateStable, infos[0].SignalingState)
+	assert.Equal(t, pcA.statsID, infos[0].ID)
+
+	closed, err := api.CloseIdlePeerConnections(time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, closed)
+
+	assert.NoError(t, pcA.Close())
+	infos = api.PeerConnections()
+	assert.Len(t, infos, 1)
+	assert.Equal(t, pcB, infos[0].PeerConnection)
+
+	closed, err = api.CloseIdlePeerConnections(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*PeerConnection{pcB}, closed)
+	assert.Equal(t, PeerConnectionStateClosed, pcB.ConnectionState())
+	assert.Empty(t, api.PeerConnections())
+}
+
+func TestAPI_GetCapabilities(t *testing.T) {
+	m := &MediaEngine{}
+	assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil},
+		PayloadType:        96,
+	}, RTPCodecTypeVideo))
+	assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil},
+		PayloadType:        100,
+	}, RTPCodecTypeVideo))
+	assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeOpus, 48000, 2, "minptime=10;useinbandfec=1", nil},
+		PayloadType:        111,
+	}, RTPCodecTypeAudio))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: "urn:ietf:params:rtp-hdrext:sdes:mid"}, RTPCodecTypeVideo))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: "urn:test:send"}, RTPCodecTypeVideo, RTPTransceiverDirectionSendonly))
+
+	// The default interceptors would add their feedback and header extensions
+	api := NewAPI(WithMediaEngine(m), WithInterceptorRegistry(&interceptor.Registry{}))
+
+	sender := api.GetSenderCapabilities(RTPCodecTypeVideo)
+	assert.Equal(t, []RTPCodecCapability{{MimeTypeVP8, 90000, 0, "", nil}}, sender.Codecs)
+	assert.Equal(t, []RTPHeaderExtensionCapability{
+		{URI: "urn:ietf:params:rtp-hdrext:sdes:mid"},
+		{URI: "urn:test:send"},
+	}, sender.HeaderExtensions)
+
+	receiver := api.GetReceiverCapabilities(RTPCodecTypeVideo)
+	assert.Equal(t, []RTPHeaderExtensionCapability{{URI: "urn:ietf:params:rtp-hdrext:sdes:mid"}}, receiver.HeaderExtensions)
+
+	audio := api.GetReceiverCapabilities(RTPCodecTypeAudio)
+	assert.Equal(t, []RTPCodecCapability{{MimeTypeOpus, 48000, 2, "minptime=10;useinbandfec=1", nil}}, audio.Codecs)
+	assert.Empty(t, audio.HeaderExtensions)
+
+	assert.Nil(t, api.GetSenderCapabilities(RTPCodecTypeUnknown))
+
+	// Senders and receivers report the capabilities of their PeerConnection
+	pc, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	transceiver, err := pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	assert.Equal(t, receiver, transceiver.Receiver().GetCapabilities(RTPCodecTypeVideo))
+
+	assert.NoError(t, pc.Close())
+}
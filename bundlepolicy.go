@@ -11,6 +11,10 @@ import (
 // endpoint is not bundle-aware, and what ICE candidates are gathered. If the
 // remote endpoint is bundle-aware, all media tracks and data channels are
 // bundled onto the same transport.
+//
+// Every media section shares a single ICE and DTLS transport. When the remote
+// isn't BUNDLE-aware only its first media section is therefore accepted,
+// whatever the policy.
 type BundlePolicy int
 
 const (
@@ -7,8 +7,11 @@
 package webrtc
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -48,7 +51,14 @@ func NewCertificate(key crypto.PrivateKey, tpl x509.Certificate) (*Certificate,
 		}
 	case *ecdsa.PrivateKey:
 		pk := sk.Public()
-		tpl.SignatureAlgorithm = x509.ECDSAWithSHA256
+		tpl.SignatureAlgorithm = ecdsaSignatureAlgorithm(sk.Curve)
+		certDER, err = x509.CreateCertificate(rand.Reader, &tpl, &tpl, pk, sk)
+		if err != nil {
+			return nil, &rtcerr.UnknownError{Err: err}
+		}
+	case ed25519.PrivateKey:
+		pk := sk.Public()
+		tpl.SignatureAlgorithm = x509.PureEd25519
 		certDER, err = x509.CreateCertificate(rand.Reader, &tpl, &tpl, pk, sk)
 		if err != nil {
 			return nil, &rtcerr.UnknownError{Err: err}
@@ -65,6 +75,19 @@ func NewCertificate(key crypto.PrivateKey, tpl x509.Certificate) (*Certificate,
 	return &Certificate{privateKey: key, x509Cert: cert, statsID: fmt.Sprintf("certificate-%d", time.Now().UnixNano())}, nil
 }
 
+// ecdsaSignatureAlgorithm returns the signature algorithm matching the
+// strength of curve, as recommended by RFC 5480
+func ecdsaSignatureAlgorithm(curve elliptic.Curve) x509.SignatureAlgorithm {
+	switch curve {
+	case elliptic.P384():
+		return x509.ECDSAWithSHA384
+	case elliptic.P521():
+		return x509.ECDSAWithSHA512
+	default:
+		return x509.ECDSAWithSHA256
+	}
+}
+
 // Equals determines if two certificates are identical by comparing both the
 // secretKeys and x509Certificates.
 func (c Certificate) Equals(o Certificate) bool {
@@ -85,6 +108,14 @@ func (c Certificate) Equals(o Certificate) bool {
 			return c.x509Cert.Equal(o.x509Cert)
 		}
 		return false
+	case ed25519.PrivateKey:
+		if oSK, ok := o.privateKey.(ed25519.PrivateKey); ok {
+			if !bytes.Equal(cSK, oSK) {
+				return false
+			}
+			return c.x509Cert.Equal(o.x509Cert)
+		}
+		return false
 	default:
 		return false
 	}
@@ -100,12 +131,16 @@ func (c Certificate) Expires() time.Time {
 
 // GetFingerprints returns the list of certificate fingerprints, one of which
 // is computed with the digest algorithm used in the certificate signature.
+// The SHA-256 fingerprint, supported by every WebRTC implementation, comes
+// first.
 func (c Certificate) GetFingerprints() ([]DTLSFingerprint, error) {
 	fingerprintAlgorithms := []crypto.Hash{crypto.SHA256}
+	if algo := signatureHash(c.x509Cert.SignatureAlgorithm); algo == crypto.SHA384 || algo == crypto.SHA512 {
+		fingerprintAlgorithms = append(fingerprintAlgorithms, algo)
+	}
 	res := make([]DTLSFingerprint, len(fingerprintAlgorithms))
 
-	i := 0
-	for _, algo := range fingerprintAlgorithms {
+	for i, algo := range fingerprintAlgorithms {
 		name, err := fingerprint.StringFromHash(algo)
 		if err != nil {
 			// nolint
@@ -122,11 +157,28 @@ func (c Certificate) GetFingerprints() ([]DTLSFingerprint, error) {
 		}
 	}
 
-	return res[:i+1], nil
+	return res, nil
+}
+
+// signatureHash returns the digest algorithm of a certificate signature, 0 if
+// it isn't known. Ed25519 signatures hash with SHA-512.
+func signatureHash(algo x509.SignatureAlgorithm) crypto.Hash {
+	switch algo {
+	case x509.SHA256WithRSA, x509.SHA256WithRSAPSS, x509.ECDSAWithSHA256:
+		return crypto.SHA256
+	case x509.SHA384WithRSA, x509.SHA384WithRSAPSS, x509.ECDSAWithSHA384:
+		return crypto.SHA384
+	case x509.SHA512WithRSA, x509.SHA512WithRSAPSS, x509.ECDSAWithSHA512, x509.PureEd25519:
+		return crypto.SHA512
+	default:
+		return 0
+	}
 }
 
 // GenerateCertificate causes the creation of an X.509 certificate and
-// corresponding private key.
+// corresponding private key. secretKey is a *rsa.PrivateKey, a
+// *ecdsa.PrivateKey of the P-256, P-384 or P-521 curve, or an
+// ed25519.PrivateKey.
 func GenerateCertificate(secretKey crypto.PrivateKey) (*Certificate, error) {
 	// Max random value, a 130-bits integer, i.e 2^130 - 1
 	maxBigInt := new(big.Int)
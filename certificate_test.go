@@ -7,7 +7,9 @@
 package webrtc
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -17,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -136,3 +139,77 @@ func TestPEM(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, pem, pem2)
 }
+
+func TestGenerateCertificateKeyTypes(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	assert.NoError(t, err)
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		key                        crypto.PrivateKey
+		expectedSignatureAlgorithm x509.SignatureAlgorithm
+		expectedFingerprints       []string
+	}{
+		{p384, x509.ECDSAWithSHA384, []string{"sha-256", "sha-384"}},
+		{p521, x509.ECDSAWithSHA512, []string{"sha-256", "sha-512"}},
+		{ed, x509.PureEd25519, []string{"sha-256", "sha-512"}},
+	}
+
+	for i, testCase := range testCases {
+		cert, err := GenerateCertificate(testCase.key)
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedSignatureAlgorithm, cert.x509Cert.SignatureAlgorithm, "testCase: %d %v", i, testCase)
+		assert.True(t, cert.Equals(*cert), "testCase: %d %v", i, testCase)
+
+		fingerprints, err := cert.GetFingerprints()
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		algorithms := []string{}
+		for _, fingerprint := range fingerprints {
+			assert.NotEmpty(t, fingerprint.Value, "testCase: %d %v", i, testCase)
+			algorithms = append(algorithms, fingerprint.Algorithm)
+		}
+		assert.Equal(t, testCase.expectedFingerprints, algorithms, "testCase: %d %v", i, testCase)
+
+		// The certificate survives a PEM round trip
+		pemCert, err := cert.PEM()
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		decoded, err := CertificateFromPEM(pemCert)
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		assert.True(t, cert.Equals(*decoded), "testCase: %d %v", i, testCase)
+	}
+}
+
+// Assert that PeerConnections connect with Ed25519 and P-384 certificates
+func TestPeerConnection_CertificateKeyTypes(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	for _, key := range []crypto.PrivateKey{p384, ed} {
+		cert, err := GenerateCertificate(key)
+		assert.NoError(t, err)
+
+		pcOffer, err := NewPeerConnection(Configuration{Certificates: []Certificate{*cert}})
+		assert.NoError(t, err)
+		pcAnswer, err := NewPeerConnection(Configuration{Certificates: []Certificate{*cert}})
+		assert.NoError(t, err)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		_, err = pcOffer.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		closePairNow(t, pcOffer, pcAnswer)
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/rtpmath"
+)
+
+const (
+	// clockDriftWindow is how long the minimum transit time is tracked for
+	// before a measurement point is taken. Taking the minimum filters out
+	// network and scheduling jitter.
+	clockDriftWindow = 5 * time.Second
+
+	// clockDriftMinBaseline is the time between the first and last
+	// measurement points required before the drift is reported.
+	clockDriftMinBaseline = 20 * time.Second
+
+	// clockDriftMaxJump is the change in transit time after which the
+	// remote clock is considered to have been reset, and the estimation
+	// starts over.
+	clockDriftMaxJump = 5 * time.Second
+)
+
+type clockDriftPoint struct {
+	local, transit float64 // in seconds
+}
+
+// clockDriftEstimator measures how fast the RTP clock of a remote sender
+// runs compared to the local monotonic clock. It is fed with the RTP
+// timestamps of received packets and Sender Reports along with their arrival
+// time, and compares the lowest transit times seen at the start and the end
+// of the measured period.
+type clockDriftEstimator struct {
+	mu sync.Mutex
+
+	clockRate uint32
+	start     time.Time
+
+	lastTimestamp     uint32
+	extendedTimestamp int64
+	lastTransit       float64
+
+	windowStart float64
+	windowMin   *clockDriftPoint
+	first, last *clockDriftPoint
+}
+
+func (e *clockDriftEstimator) reset() {
+	e.clockRate = 0
+	e.windowMin, e.first, e.last = nil, nil, nil
+}
+
+// addSample records a RTP timestamp of the remote clock observed at arrival
+func (e *clockDriftEstimator) addSample(timestamp uint32, clockRate uint32, arrival time.Time) {
+	if clockRate == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.clockRate != clockRate {
+		e.reset()
+		e.clockRate = clockRate
+		e.start = arrival
+		e.lastTimestamp = timestamp
+		e.extendedTimestamp = 0
+	}
+
+	e.extendedTimestamp += rtpmath.TimestampDiff(timestamp, e.lastTimestamp)
+	e.lastTimestamp = timestamp
+
+	local := arrival.Sub(e.start).Seconds()
+	transit := local - float64(e.extendedTimestamp)/float64(clockRate)
+
+	if e.windowMin != nil && math.Abs(transit-e.lastTransit) > clockDriftMaxJump.Seconds() {
+		e.reset()
+		e.clockRate = clockRate
+	}
+	e.lastTransit = transit
+
+	if e.windowMin == nil {
+		e.windowStart = local
+		e.windowMin = &clockDriftPoint{local: local, transit: transit}
+	} else if transit < e.windowMin.transit {
+		e.windowMin = &clockDriftPoint{local: local, transit: transit}
+	}
+
+	if local-e.windowStart < clockDriftWindow.Seconds() {
+		return
+	}
+
+	if e.first == nil {
+		e.first = e.windowMin
+	} else {
+		e.last = e.windowMin
+	}
+	e.windowStart = local
+	e.windowMin = &clockDriftPoint{local: local, transit: transit}
+}
+
+// drift returns the drift of the remote clock in parts per million, positive
+// if it runs faster than the local clock. ok is false until enough samples
+// were received to estimate it.
+func (e *clockDriftEstimator) drift() (ppm float64, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.first == nil || e.last == nil {
+		return 0, false
+	}
+
+	elapsed := e.last.local - e.first.local
+	if elapsed < clockDriftMinBaseline.Seconds() {
+		return 0, false
+	}
+
+	return -(e.last.transit - e.first.transit) / elapsed * 1e6, true
+}
+
+// ClockDriftCompensator rewrites the RTP timestamps of a forwarded stream,
+// so they progress at the rate of the local clock instead of the clock of the
+// remote sender. This keeps long running recordings and mixes of forwarded
+// streams in sync. The drift is the one measured on the TrackRemote the
+// packets are read from.
+type ClockDriftCompensator struct {
+	track *TrackRemote
+
+	started       bool
+	lastTimestamp uint32
+	outTimestamp  float64
+}
+
+// NewClockDriftCompensator creates a ClockDriftCompensator for packets read
+// from track.
+func NewClockDriftCompensator(track *TrackRemote) *ClockDriftCompensator {
+	return &ClockDriftCompensator{track: track}
+}
+
+// Compensate rewrites the timestamp of a packet read from the track. Packets
+// must be passed in the order they were read. Until the drift is known the
+// timestamps are left unchanged.
+func (c *ClockDriftCompensator) Compensate(p *rtp.Packet) {
+	if !c.started {
+		c.started = true
+		c.lastTimestamp = p.Timestamp
+		c.outTimestamp = float64(p.Timestamp)
+		return
+	}
+
+	delta := float64(rtpmath.TimestampDiff(p.Timestamp, c.lastTimestamp))
+	c.lastTimestamp = p.Timestamp
+
+	// Scale the progression instead of the absolute timestamp, so updated
+	// estimates don't make the timestamps jump
+	if ppm, ok := c.track.ClockDrift(); ok {
+		delta /= 1 + ppm/1e6
+	}
+
+	c.outTimestamp += delta
+	p.Timestamp = uint32(int64(c.outTimestamp))
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+// feedClockDrift feeds a 90kHz stream at 30fps from a sender whose clock runs
+// ppm fast, with up to 30ms of random network delay
+func feedClockDrift(e *clockDriftEstimator, start time.Time, timestamp uint32, duration time.Duration, ppm float64) time.Time {
+	r := rand.New(rand.NewSource(1)) // nolint:gosec
+	frame := time.Second / 30
+
+	arrival := start
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += frame {
+		ticks := uint32(elapsed.Seconds() * 90000 * (1 + ppm/1e6))
+		arrival = start.Add(elapsed + time.Duration(r.Int63n(int64(30*time.Millisecond))))
+		e.addSample(timestamp+ticks, 90000, arrival)
+	}
+	return arrival
+}
+
+func TestClockDriftEstimator(t *testing.T) {
+	e := &clockDriftEstimator{}
+	start := time.Now()
+
+	feedClockDrift(e, start, 0xFFFF0000, 10*time.Second, 100)
+	_, ok := e.drift()
+	assert.False(t, ok, "drift is reported before the minimum baseline")
+
+	e = &clockDriftEstimator{}
+	end := feedClockDrift(e, start, 0xFFFF0000, 5*time.Minute, 100)
+	ppm, ok := e.drift()
+	assert.True(t, ok)
+	assert.InDelta(t, 100, ppm, 5)
+
+	// A jump of the remote clock starts the estimation over
+	e.addSample(0, 90000, end.Add(time.Hour))
+	_, ok = e.drift()
+	assert.False(t, ok)
+
+	e = &clockDriftEstimator{}
+	feedClockDrift(e, start, 1234, 5*time.Minute, -50)
+	ppm, ok = e.drift()
+	assert.True(t, ok)
+	assert.InDelta(t, -50, ppm, 5)
+}
+
+func TestClockDriftCompensator(t *testing.T) {
+	track := &TrackRemote{}
+	c := NewClockDriftCompensator(track)
+
+	// Unchanged until the drift is known
+	for _, timestamp := range []uint32{1000, 4000} {
+		p := &rtp.Packet{Header: rtp.Header{Timestamp: timestamp}}
+		c.Compensate(p)
+		assert.Equal(t, timestamp, p.Timestamp)
+	}
+
+	feedClockDrift(&track.clockDrift, time.Now(), 0, 5*time.Minute, 1000)
+
+	// 1000000 ticks of a 1000ppm fast clock are 999000 ticks of the local clock
+	p := &rtp.Packet{Header: rtp.Header{Timestamp: 4000 + 1000000}}
+	c.Compensate(p)
+	assert.InDelta(t, 4000+999000, float64(p.Timestamp), 50)
+}
+
+func TestRTPReceiver_HandleSenderReports(t *testing.T) {
+	track := &TrackRemote{ssrc: 5000, codec: RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{ClockRate: 90000}}}
+	r := &RTPReceiver{tracks: []trackStreams{{track: track}}}
+
+	b, err := rtcp.Marshal([]rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 1},
+		&rtcp.SenderReport{SSRC: 4000, RTPTime: 1},
+		&rtcp.SenderReport{SSRC: 5000, RTPTime: 1234},
+	})
+	assert.NoError(t, err)
+
+	r.handleSenderReports(b, time.Now())
+	assert.Equal(t, uint32(1234), track.clockDrift.lastTimestamp)
+	assert.Equal(t, uint32(90000), track.clockDrift.clockRate)
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// clockSyncOffsets is how many Sender Reports the offset between the
+	// sender's wallclock and the local clock is estimated from. Taking the
+	// minimum over several of them filters out network and scheduling jitter.
+	clockSyncOffsets = 16
+
+	// Seconds between the NTP epoch (1900) and the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+)
+
+// ntpToTime converts a 64 bit NTP timestamp to a time.Time
+func ntpToTime(ntp uint64) time.Time {
+	seconds := int64(ntp>>32) - ntpEpochOffset
+	nanoseconds := (int64(ntp&0xFFFFFFFF) * int64(time.Second)) >> 32
+
+	return time.Unix(seconds, nanoseconds)
+}
+
+// trackClockSync maps the RTP timestamps of a remote track to the local clock
+// using the Sender Reports of the sender. A Sender Report relates a RTP
+// timestamp to the sender's wallclock, and its arrival time relates the
+// sender's wallclock to the local clock.
+type trackClockSync struct {
+	mu sync.Mutex
+
+	clockRate uint32
+
+	// Latest Sender Report
+	reportWallclock time.Time
+	reportTimestamp uint32
+
+	// Differences between the arrival and the wallclock of the latest Sender
+	// Reports, the lowest one is the offset between both clocks plus the
+	// minimum network delay
+	offsets    [clockSyncOffsets]time.Duration
+	offsetsLen int
+	offsetsPos int
+}
+
+func (c *trackClockSync) addSenderReport(ntpTime uint64, rtpTime uint32, clockRate uint32, arrival time.Time) {
+	if clockRate == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.clockRate != clockRate {
+		c.clockRate = clockRate
+		c.offsetsLen, c.offsetsPos = 0, 0
+	}
+
+	c.reportWallclock = ntpToTime(ntpTime)
+	c.reportTimestamp = rtpTime
+
+	c.offsets[c.offsetsPos] = arrival.Sub(c.reportWallclock)
+	c.offsetsPos = (c.offsetsPos + 1) % clockSyncOffsets
+	if c.offsetsLen < clockSyncOffsets {
+		c.offsetsLen++
+	}
+}
+
+// offset returns the offset from the sender's wallclock to the local clock.
+// c.mu must be held.
+func (c *trackClockSync) offset() time.Duration {
+	offset := c.offsets[0]
+	for _, o := range c.offsets[1:c.offsetsLen] {
+		if o < offset {
+			offset = o
+		}
+	}
+
+	return offset
+}
+
+func (c *trackClockSync) localTime(rtpTimestamp uint32) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.offsetsLen == 0 {
+		return time.Time{}, false
+	}
+
+	elapsed := time.Duration(int64(int32(rtpTimestamp-c.reportTimestamp)) * int64(time.Second) / int64(c.clockRate))
+
+	return c.reportWallclock.Add(elapsed + c.offset()), true
+}
+
+func (c *trackClockSync) rtpTimestamp(at time.Time) (uint32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.offsetsLen == 0 {
+		return 0, false
+	}
+
+	elapsed := at.Sub(c.reportWallclock) - c.offset()
+
+	return c.reportTimestamp + uint32(int64(elapsed.Seconds()*float64(c.clockRate))), true
+}
+
+// ClockSynchronizer aligns remote tracks, which may be received on different
+// PeerConnections, on the local clock, so they can be mixed or composited.
+// The alignment is based on the RTCP Sender Reports of every track, tracks
+// are only aligned once a Sender Report was received for them.
+type ClockSynchronizer struct {
+	mu     sync.Mutex
+	tracks map[*TrackRemote]struct{}
+}
+
+// NewClockSynchronizer creates an empty ClockSynchronizer
+func NewClockSynchronizer() *ClockSynchronizer {
+	return &ClockSynchronizer{tracks: map[*TrackRemote]struct{}{}}
+}
+
+// AddTrack adds a track to be aligned
+func (s *ClockSynchronizer) AddTrack(track *TrackRemote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tracks[track] = struct{}{}
+}
+
+// RemoveTrack stops aligning a track
+func (s *ClockSynchronizer) RemoveTrack(track *TrackRemote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tracks, track)
+}
+
+// Align returns, for every track which can be aligned, the RTP timestamp of
+// the track which corresponds to the local time at. A mixer producing its
+// output for the instant at uses the samples up to these timestamps.
+func (s *ClockSynchronizer) Align(at time.Time) map[*TrackRemote]uint32 {
+	s.mu.Lock()
+	tracks := make([]*TrackRemote, 0, len(s.tracks))
+	for track := range s.tracks {
+		tracks = append(tracks, track)
+	}
+	s.mu.Unlock()
+
+	timestamps := make(map[*TrackRemote]uint32, len(tracks))
+	for _, track := range tracks {
+		if timestamp, ok := track.RTPTimestampAt(at); ok {
+			timestamps[track] = timestamp
+		}
+	}
+
+	return timestamps
+}
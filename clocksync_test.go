@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func timeToNTP(t time.Time) uint64 {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+
+	return seconds<<32 | fraction
+}
+
+func TestNTPToTime(t *testing.T) {
+	now := time.Unix(1700000000, 500000000)
+	assert.WithinDuration(t, now, ntpToTime(timeToNTP(now)), time.Microsecond)
+}
+
+func TestTrackClockSync(t *testing.T) {
+	var c trackClockSync
+
+	_, ok := c.localTime(0)
+	assert.False(t, ok)
+
+	// The sender's wallclock is 1 hour behind the local clock, and Sender
+	// Reports take between 20ms and 50ms to arrive
+	local := time.Unix(1700000000, 0)
+	sender := local.Add(-time.Hour)
+	c.addSenderReport(timeToNTP(sender), 90000, 90000, local.Add(50*time.Millisecond))
+	c.addSenderReport(timeToNTP(sender.Add(time.Second)), 180000, 90000, local.Add(time.Second+20*time.Millisecond))
+	c.addSenderReport(timeToNTP(sender.Add(2*time.Second)), 270000, 90000, local.Add(2*time.Second+40*time.Millisecond))
+
+	// The lowest delay is used
+	at, ok := c.localTime(270000 + 45000)
+	assert.True(t, ok)
+	assert.WithinDuration(t, local.Add(2500*time.Millisecond+20*time.Millisecond), at, time.Microsecond)
+
+	timestamp, ok := c.rtpTimestamp(at)
+	assert.True(t, ok)
+	assert.InDelta(t, 270000+45000, timestamp, 1)
+
+	// Timestamps before the latest Sender Report and wrapping around
+	at, ok = c.localTime(0)
+	assert.True(t, ok)
+	assert.WithinDuration(t, local.Add(20*time.Millisecond-time.Second), at, time.Microsecond)
+}
+
+func TestClockSynchronizer(t *testing.T) {
+	local := time.Unix(1700000000, 0)
+
+	// Two participants with unrelated wallclocks and RTP timestamps
+	audio := newTrackRemote(RTPCodecTypeAudio, 1, 0, "", nil)
+	audio.clockSync.addSenderReport(timeToNTP(local.Add(-time.Hour)), 1000, 48000, local.Add(30*time.Millisecond))
+
+	video := newTrackRemote(RTPCodecTypeVideo, 2, 0, "", nil)
+	video.clockSync.addSenderReport(timeToNTP(local.Add(time.Minute)), 5000, 90000, local.Add(10*time.Millisecond))
+
+	silent := newTrackRemote(RTPCodecTypeAudio, 3, 0, "", nil)
+
+	s := NewClockSynchronizer()
+	s.AddTrack(audio)
+	s.AddTrack(video)
+	s.AddTrack(silent)
+
+	timestamps := s.Align(local.Add(time.Second + 30*time.Millisecond))
+	assert.Len(t, timestamps, 2)
+	assert.InDelta(t, 1000+48000, timestamps[audio], 1)
+	assert.InDelta(t, 5000+90000+1800, timestamps[video], 1)
+
+	s.RemoveTrack(video)
+	assert.Len(t, s.Align(local), 1)
+}
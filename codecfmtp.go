@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pion/webrtc/v4/internal/fmtp"
+)
+
+// FMTP is the parsed fmtp line of a codec. Match decides if the codecs of the
+// local and the remote descriptions are compatible, and Parameter returns
+// the value of a parameter, like apt for RTX.
+type FMTP = fmtp.FMTP
+
+// RegisterFMTPParser registers parse for the fmtp lines of the codecs of
+// mimeType, so applications adding proprietary or new codecs decide how they
+// are matched. It takes precedence over the builtin parsing of Pion, like for
+// H264, and when parse returns nil the builtin parsing is used. A nil parse
+// removes the registered one. Parsers are only used by the PeerConnections
+// of the MediaEngine.
+// RegisterFMTPParser is not safe for concurrent use.
+func (m *MediaEngine) RegisterFMTPParser(mimeType string, parse func(line string) FMTP) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The parsers are copied on write, so they can be used without holding
+	// the lock once they were read
+	parsers := fmtp.Parsers{}
+	for registered, parser := range m.fmtpParsers {
+		parsers[registered] = parser
+	}
+
+	if parse == nil {
+		delete(parsers, strings.ToLower(mimeType))
+	} else {
+		parsers[strings.ToLower(mimeType)] = fmtp.Parser(parse)
+	}
+	m.fmtpParsers = parsers
+}
+
+// getFMTPParsers returns the parsers registered with RegisterFMTPParser,
+// they must not be modified
+func (m *MediaEngine) getFMTPParsers() fmtp.Parsers {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.fmtpParsers
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// versionFMTP is the fmtp of a codec whose descriptions are compatible when
+// their major versions are the same
+type versionFMTP struct {
+	version string
+}
+
+func (v *versionFMTP) MimeType() string {
+	return "video/x-custom"
+}
+
+func (v *versionFMTP) Match(f FMTP) bool {
+	other, ok := f.(*versionFMTP)
+	return ok && strings.Split(v.version, ".")[0] == strings.Split(other.version, ".")[0]
+}
+
+func (v *versionFMTP) Parameter(key string) (string, bool) {
+	if key == "version" {
+		return v.version, true
+	}
+
+	return "", false
+}
+
+func TestRegisterFMTPParser(t *testing.T) {
+	const remoteDescription = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 9 UDP/TLS/RTP/SAVPF 100 101
+a=rtpmap:100 x-custom/90000
+a=fmtp:100 version=2.0
+a=rtpmap:101 x-custom/90000
+a=fmtp:101 version=1.7
+`
+
+	newMediaEngine := func() *MediaEngine {
+		m := &MediaEngine{}
+		assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{"video/x-custom", 90000, 0, "version=1.2", nil},
+			PayloadType:        96,
+		}, RTPCodecTypeVideo))
+		return m
+	}
+
+	s := sdp.SessionDescription{}
+	assert.NoError(t, s.Unmarshal([]byte(remoteDescription)))
+
+	m := newMediaEngine()
+	m.RegisterFMTPParser("video/x-custom", func(line string) FMTP {
+		return &versionFMTP{version: strings.TrimPrefix(line, "version=")}
+	})
+	assert.NoError(t, m.updateFromRemoteDescription(s))
+
+	// Only the description of the same major version is an exact match
+	_, _, err := m.getCodecByPayload(100)
+	assert.ErrorIs(t, err, ErrCodecNotFound)
+
+	codec, _, err := m.getCodecByPayload(101)
+	assert.NoError(t, err)
+	assert.Equal(t, "version=1.7", codec.SDPFmtpLine)
+
+	// The parser isn't used by other MediaEngines, whose generic matching
+	// rejects the different versions
+	other := newMediaEngine()
+	assert.NoError(t, other.updateFromRemoteDescription(s))
+
+	codec, _, err = other.getCodecByPayload(100)
+	assert.NoError(t, err)
+	assert.Equal(t, "version=2.0", codec.SDPFmtpLine)
+
+	// Removing the parser restores the builtin parsing
+	m.RegisterFMTPParser("video/x-custom", nil)
+	assert.Nil(t, m.getFMTPParsers()["video/x-custom"])
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pion/webrtc/v4/internal/fmtp"
+)
+
+// CodecMatchMode decides how the remote codecs of a MIME type are matched
+// with the registered ones
+type CodecMatchMode int
+
+const (
+	// CodecMatchModeFuzzy matches a remote codec whose fmtp is compatible
+	// with a registered one, and falls back to a remote codec of the same MIME
+	// type when none of the kind is compatible. This is the default.
+	CodecMatchModeFuzzy CodecMatchMode = iota
+
+	// CodecMatchModeExact only matches a remote codec whose fmtp is
+	// compatible with a registered one
+	CodecMatchModeExact
+)
+
+// This is done this way because of a linter.
+const (
+	codecMatchModeFuzzyStr = "fuzzy"
+	codecMatchModeExactStr = "exact"
+)
+
+func (c CodecMatchMode) String() string {
+	switch c {
+	case CodecMatchModeFuzzy:
+		return codecMatchModeFuzzyStr
+	case CodecMatchModeExact:
+		return codecMatchModeExactStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// CodecRejectionReason is why a remote codec wasn't negotiated
+type CodecRejectionReason int
+
+const (
+	// CodecRejectionReasonNotRegistered means no codec of the MIME type is
+	// registered
+	CodecRejectionReasonNotRegistered CodecRejectionReason = iota + 1
+
+	// CodecRejectionReasonFmtpMismatch means the fmtp isn't compatible with
+	// the registered codecs of the MIME type, whose CodecMatchMode is exact
+	CodecRejectionReasonFmtpMismatch
+
+	// CodecRejectionReasonSuperseded means the fmtp isn't compatible with the
+	// registered codecs of the MIME type, and other remote codecs of the kind
+	// are, so only those are negotiated
+	CodecRejectionReasonSuperseded
+
+	// CodecRejectionReasonAptNotNegotiated means the codec, like RTX, applies
+	// to the codec of its apt parameter, which wasn't negotiated
+	CodecRejectionReasonAptNotNegotiated
+)
+
+// This is done this way because of a linter.
+const (
+	codecRejectionReasonNotRegisteredStr    = "not-registered"
+	codecRejectionReasonFmtpMismatchStr     = "fmtp-mismatch"
+	codecRejectionReasonSupersededStr       = "superseded"
+	codecRejectionReasonAptNotNegotiatedStr = "apt-not-negotiated"
+)
+
+func (c CodecRejectionReason) String() string {
+	switch c {
+	case CodecRejectionReasonNotRegistered:
+		return codecRejectionReasonNotRegisteredStr
+	case CodecRejectionReasonFmtpMismatch:
+		return codecRejectionReasonFmtpMismatchStr
+	case CodecRejectionReasonSuperseded:
+		return codecRejectionReasonSupersededStr
+	case CodecRejectionReasonAptNotNegotiated:
+		return codecRejectionReasonAptNotNegotiatedStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// RejectedCodec is a codec of a remote description which wasn't negotiated
+type RejectedCodec struct {
+	Kind RTPCodecType
+
+	// Codec is the codec of the remote, with its payload type
+	Codec  RTPCodecParameters
+	Reason CodecRejectionReason
+}
+
+// SetCodecMatchMode sets how the remote codecs of mimeType are matched with
+// the registered ones. The default is CodecMatchModeFuzzy.
+// SetCodecMatchMode is not safe for concurrent use.
+func (m *MediaEngine) SetCodecMatchMode(mimeType string, mode CodecMatchMode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.codecMatchModes == nil {
+		m.codecMatchModes = map[string]CodecMatchMode{}
+	}
+	m.codecMatchModes[strings.ToLower(mimeType)] = mode
+}
+
+// RejectedCodecs returns the codecs of the media sections negotiated by the
+// last remote descriptions that weren't negotiated, and why. Codecs of the
+// kinds negotiated with earlier remote descriptions are kept.
+func (m *MediaEngine) RejectedCodecs() []RejectedCodec {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]RejectedCodec{}, m.rejectedCodecs...)
+}
+
+// codecMatchMode returns the CodecMatchMode of mimeType. m.mu must be held.
+func (m *MediaEngine) codecMatchMode(mimeType string) CodecMatchMode {
+	return m.codecMatchModes[strings.ToLower(mimeType)]
+}
+
+// codecRejectionReason returns why remoteCodec didn't match any registered
+// codec
+func codecRejectionReason(parsers fmtp.Parsers, remoteCodec RTPCodecParameters, codecs []RTPCodecParameters) CodecRejectionReason {
+	registered := false
+	for _, codec := range codecs {
+		registered = registered || strings.EqualFold(codec.MimeType, remoteCodec.MimeType)
+	}
+
+	switch _, hasApt := parsers.Parse(remoteCodec.MimeType, remoteCodec.SDPFmtpLine).Parameter("apt"); {
+	case !registered:
+		return CodecRejectionReasonNotRegistered
+	case hasApt:
+		return CodecRejectionReasonAptNotNegotiated
+	default:
+		return CodecRejectionReasonFmtpMismatch
+	}
+}
+
+// RejectedCodecs returns the remote codecs that weren't negotiated, and why,
+// see MediaEngine.RejectedCodecs
+func (pc *PeerConnection) RejectedCodecs() []RejectedCodec {
+	return pc.api.mediaEngine.RejectedCodecs()
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecMatchMode_String(t *testing.T) {
+	testCases := []struct {
+		mode           CodecMatchMode
+		expectedString string
+	}{
+		{CodecMatchModeFuzzy, "fuzzy"},
+		{CodecMatchModeExact, "exact"},
+		{CodecMatchMode(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.mode.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestCodecRejectionReason_String(t *testing.T) {
+	testCases := []struct {
+		reason         CodecRejectionReason
+		expectedString string
+	}{
+		{CodecRejectionReasonNotRegistered, "not-registered"},
+		{CodecRejectionReasonFmtpMismatch, "fmtp-mismatch"},
+		{CodecRejectionReasonSuperseded, "superseded"},
+		{CodecRejectionReasonAptNotNegotiated, "apt-not-negotiated"},
+		{CodecRejectionReason(0), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.reason.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestMediaEngine_CodecMatchMode(t *testing.T) {
+	const remoteDescription = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 9 UDP/TLS/RTP/SAVPF 100 101 102
+a=rtpmap:100 H264/90000
+a=fmtp:100 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032
+a=rtpmap:101 rtx/90000
+a=fmtp:101 apt=100
+a=rtpmap:102 x-unknown/90000
+`
+
+	type expectedRejection struct {
+		payloadType PayloadType
+		reason      CodecRejectionReason
+	}
+
+	testCases := []struct {
+		mode               CodecMatchMode
+		expectedNegotiated bool
+		expectedRejections []expectedRejection
+	}{
+		{CodecMatchModeFuzzy, true, []expectedRejection{{102, CodecRejectionReasonNotRegistered}}},
+		{CodecMatchModeExact, false, []expectedRejection{
+			{100, CodecRejectionReasonFmtpMismatch},
+			{101, CodecRejectionReasonAptNotNegotiated},
+			{102, CodecRejectionReasonNotRegistered},
+		}},
+	}
+
+	for i, testCase := range testCases {
+		m := &MediaEngine{}
+		assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", nil},
+			PayloadType:        102,
+		}, RTPCodecTypeVideo))
+		assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{"video/rtx", 90000, 0, "apt=102", nil},
+			PayloadType:        103,
+		}, RTPCodecTypeVideo))
+		m.SetCodecMatchMode("video/h264", testCase.mode)
+
+		s := sdp.SessionDescription{}
+		assert.NoError(t, s.Unmarshal([]byte(remoteDescription)))
+		assert.NoError(t, m.updateFromRemoteDescription(s))
+
+		_, _, err := m.getCodecByPayload(100)
+		assert.Equal(t, testCase.expectedNegotiated, err == nil, "testCase: %d %v", i, testCase)
+
+		rejected := m.RejectedCodecs()
+		assert.Equal(t, len(testCase.expectedRejections), len(rejected), "testCase: %d %v", i, testCase)
+		for j, expected := range testCase.expectedRejections {
+			if j >= len(rejected) {
+				break
+			}
+			assert.Equal(t, RTPCodecTypeVideo, rejected[j].Kind, "testCase: %d %v", i, testCase)
+			assert.Equal(t, expected.payloadType, rejected[j].Codec.PayloadType, "testCase: %d %v", i, testCase)
+			assert.Equal(t, expected.reason, rejected[j].Reason, "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestMediaEngine_RejectedCodecsSuperseded(t *testing.T) {
+	const remoteDescription = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 9 UDP/TLS/RTP/SAVPF 96 100
+a=rtpmap:96 VP8/90000
+a=rtpmap:100 H264/90000
+a=fmtp:100 packetization-mode=1;profile-level-id=f40032
+`
+
+	m := &MediaEngine{}
+	assert.NoError(t, m.RegisterDefaultCodecs())
+
+	s := sdp.SessionDescription{}
+	assert.NoError(t, s.Unmarshal([]byte(remoteDescription)))
+	assert.NoError(t, m.updateFromRemoteDescription(s))
+
+	// VP8 is an exact match, so the partial match of H264 isn't negotiated
+	assert.Equal(t, []RejectedCodec{{
+		Kind: RTPCodecTypeVideo,
+		Codec: RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "packetization-mode=1;profile-level-id=f40032", []RTCPFeedback{}},
+			PayloadType:        100,
+		},
+		Reason: CodecRejectionReasonSuperseded,
+	}}, m.RejectedCodecs())
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// CodecNegotiationFailurePolicy decides what happens when a remote offer
+// contains a media section that shares no codec with the local MediaEngine.
+type CodecNegotiationFailurePolicy int
+
+const (
+	// CodecNegotiationFailurePolicyFail makes the whole negotiation fail.
+	// This is the default.
+	CodecNegotiationFailurePolicyFail CodecNegotiationFailurePolicy = iota
+
+	// CodecNegotiationFailurePolicyReject rejects only the offending media
+	// section by answering it with port 0. The other media sections are
+	// negotiated as usual and a CodecNegotiationWarning is emitted through
+	// PeerConnection.OnCodecNegotiationWarning.
+	CodecNegotiationFailurePolicyReject
+)
+
+// This is done this way because of a linter.
+const (
+	codecNegotiationFailurePolicyFailStr   = "fail"
+	codecNegotiationFailurePolicyRejectStr = "reject"
+)
+
+func (p CodecNegotiationFailurePolicy) String() string {
+	switch p {
+	case CodecNegotiationFailurePolicyFail:
+		return codecNegotiationFailurePolicyFailStr
+	case CodecNegotiationFailurePolicyReject:
+		return codecNegotiationFailurePolicyRejectStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// CodecNegotiationWarning describes a remote media section that was rejected
+// because none of its codecs are supported locally.
+type CodecNegotiationWarning struct {
+	// Mid is the mid of the rejected media section
+	Mid string
+
+	// Kind is the media kind of the rejected media section
+	Kind RTPCodecType
+
+	// RemoteCodecs are the codecs the remote offered for the media section.
+	// It is empty if the codecs could not be parsed.
+	RemoteCodecs []RTPCodecParameters
+
+	// Err is the reason the media section was rejected
+	Err error
+}
@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecNegotiationFailurePolicy_String(t *testing.T) {
+	testCases := []struct {
+		policy         CodecNegotiationFailurePolicy
+		expectedString string
+	}{
+		{CodecNegotiationFailurePolicyFail, "fail"},
+		{CodecNegotiationFailurePolicyReject, "reject"},
+		{CodecNegotiationFailurePolicy(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.policy.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -0,0 +1,315 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConnectionQualityInterval = 2 * time.Second
+
+	// Round trip times up to connectionQualityRTTBaseline don't lower the score
+	connectionQualityRTTBaseline    = 100 * time.Millisecond
+	connectionQualityMaxRTTPenalty  = 40
+	connectionQualityMaxLossPenalty = 50
+	connectionQualityMaxRatePenalty = 20
+)
+
+// ConnectionQuality is a coarse rating of a PeerConnection's network
+// conditions, meant to be displayed to users.
+type ConnectionQuality int
+
+const (
+	// ConnectionQualityUnknown means no measurement is available yet.
+	ConnectionQualityUnknown ConnectionQuality = iota
+
+	// ConnectionQualityExcellent means the connection has low latency and no
+	// noticeable loss.
+	ConnectionQualityExcellent
+
+	// ConnectionQualityGood means the connection is degraded but media should
+	// be unaffected.
+	ConnectionQualityGood
+
+	// ConnectionQualityPoor means users are likely to notice artifacts.
+	ConnectionQualityPoor
+
+	// ConnectionQualityBad means the connection is barely usable.
+	ConnectionQualityBad
+)
+
+// This is done this way because of a linter.
+const (
+	connectionQualityUnknownStr   = "unknown"
+	connectionQualityExcellentStr = "excellent"
+	connectionQualityGoodStr      = "good"
+	connectionQualityPoorStr      = "poor"
+	connectionQualityBadStr       = "bad"
+)
+
+func (q ConnectionQuality) String() string {
+	switch q {
+	case ConnectionQualityUnknown:
+		return connectionQualityUnknownStr
+	case ConnectionQualityExcellent:
+		return connectionQualityExcellentStr
+	case ConnectionQualityGood:
+		return connectionQualityGoodStr
+	case ConnectionQualityPoor:
+		return connectionQualityPoorStr
+	case ConnectionQualityBad:
+		return connectionQualityBadStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// ConnectionQualityThresholds map a quality score to a ConnectionQuality.
+type ConnectionQualityThresholds struct {
+	// Excellent, Good and Poor are the minimum scores of the respective
+	// quality levels. Scores below Poor are rated ConnectionQualityBad.
+	Excellent float64
+	Good      float64
+	Poor      float64
+
+	// TargetBitrate is the available outgoing bitrate, in bits per second,
+	// below which the score is lowered. Leave it 0 to ignore the bitrate.
+	TargetBitrate float64
+}
+
+// DefaultConnectionQualityThresholds are used unless
+// SettingEngine.SetConnectionQualityThresholds is called.
+var DefaultConnectionQualityThresholds = ConnectionQualityThresholds{ //nolint:gochecknoglobals
+	Excellent:     80,
+	Good:          60,
+	Poor:          40,
+	TargetBitrate: 300_000,
+}
+
+// ConnectionQualityReport is emitted periodically by
+// PeerConnection.OnConnectionQuality.
+type ConnectionQualityReport struct {
+	// Timestamp is the time the report was computed
+	Timestamp time.Time
+
+	// Quality is Score rated against the configured thresholds
+	Quality ConnectionQuality
+
+	// Score goes from 0 (unusable) to 100 (perfect)
+	Score float64
+
+	// RoundTripTime is the highest round trip time reported by RTCP Receiver
+	// Reports, or the one of the selected ICE candidate pair if there is none
+	RoundTripTime time.Duration
+
+	// PacketLoss is the highest fraction of packets lost since the previous
+	// report, in either direction
+	PacketLoss float64
+
+	// AvailableOutgoingBitrate is the outgoing bitrate estimated by the
+	// congestion controller set up with ConfigureCongestionController, 0
+	// without one
+	AvailableOutgoingBitrate float64
+}
+
+// connectionQualityScore computes a score from 0 to 100. Round trip time
+// above connectionQualityRTTBaseline costs a point per 10ms, each percent of
+// packet loss costs 4 points and missing bitrate costs up to 20 points.
+func connectionQualityScore(rtt time.Duration, loss, bitrate float64, thresholds ConnectionQualityThresholds) float64 {
+	score := 100.0
+
+	if rtt > connectionQualityRTTBaseline {
+		score -= math.Min(float64((rtt-connectionQualityRTTBaseline)/(10*time.Millisecond)), connectionQualityMaxRTTPenalty)
+	}
+
+	score -= math.Min(loss*400, connectionQualityMaxLossPenalty)
+
+	if thresholds.TargetBitrate > 0 && bitrate > 0 && bitrate < thresholds.TargetBitrate {
+		score -= connectionQualityMaxRatePenalty * (1 - bitrate/thresholds.TargetBitrate)
+	}
+
+	return math.Max(score, 0)
+}
+
+func (t ConnectionQualityThresholds) rate(score float64) ConnectionQuality {
+	switch {
+	case score >= t.Excellent:
+		return ConnectionQualityExcellent
+	case score >= t.Good:
+		return ConnectionQualityGood
+	case score >= t.Poor:
+		return ConnectionQualityPoor
+	default:
+		return ConnectionQualityBad
+	}
+}
+
+// connectionQualityMonitor periodically turns the stats of a PeerConnection
+// into ConnectionQualityReports
+type connectionQualityMonitor struct {
+	pc         *PeerConnection
+	statsID    string
+	interval   time.Duration
+	thresholds ConnectionQualityThresholds
+
+	// Inbound packet counters of the previous sample, used to compute the
+	// loss over the last interval
+	lastReceived uint64
+	lastLost     int64
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newConnectionQualityMonitor(pc *PeerConnection) *connectionQualityMonitor {
+	interval := pc.api.settingEngine.connectionQualityInterval
+	if interval <= 0 {
+		interval = defaultConnectionQualityInterval
+	}
+
+	thresholds := DefaultConnectionQualityThresholds
+	if pc.api.settingEngine.connectionQualityThresholds != nil {
+		thresholds = *pc.api.settingEngine.connectionQualityThresholds
+	}
+
+	m := &connectionQualityMonitor{
+		pc:         pc,
+		statsID:    pc.statsID,
+		interval:   interval,
+		thresholds: thresholds,
+		done:       make(chan struct{}),
+	}
+	go m.run()
+
+	return m
+}
+
+func (m *connectionQualityMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		if m.pc.ConnectionState() != PeerConnectionStateConnected {
+			continue
+		}
+
+		if report, ok := m.sample(m.pc.GetStats()); ok {
+			m.pc.onConnectionQuality(report)
+		}
+	}
+}
+
+// sample builds a report from a StatsReport, returns false if the stats
+// contain no measurement at all
+func (m *connectionQualityMonitor) sample(stats StatsReport) (ConnectionQualityReport, bool) {
+	var (
+		rtt, pairRTT     time.Duration
+		remoteLoss       float64
+		bitrate          float64
+		received         uint64
+		lost             int64
+		haveMeasurements bool
+	)
+
+	for _, s := range stats {
+		switch s := s.(type) {
+		case RemoteInboundRTPStreamStats:
+			haveMeasurements = true
+			if d := time.Duration(s.RoundTripTime * float64(time.Second)); d > rtt {
+				rtt = d
+			}
+			remoteLoss = math.Max(remoteLoss, s.FractionLost)
+		case InboundRTPStreamStats:
+			received += uint64(s.PacketsReceived)
+			lost += int64(s.PacketsLost)
+		case ICECandidatePairStats:
+			if !s.Nominated {
+				continue
+			}
+			if s.CurrentRoundTripTime > 0 {
+				haveMeasurements = true
+				pairRTT = time.Duration(s.CurrentRoundTripTime * float64(time.Second))
+			}
+			bitrate = math.Max(bitrate, s.AvailableOutgoingBitrate)
+		}
+	}
+
+	if rtt == 0 {
+		rtt = pairRTT
+	}
+
+	if estimator, ok := lookupBandwidthEstimator(m.statsID); ok {
+		bitrate = math.Max(bitrate, float64(estimator.GetTargetBitrate()))
+	}
+
+	var localLoss float64
+	deltaReceived := int64(received) - int64(m.lastReceived)
+	deltaLost := lost - m.lastLost
+	if deltaReceived+deltaLost > 0 {
+		haveMeasurements = true
+		localLoss = math.Max(float64(deltaLost)/float64(deltaReceived+deltaLost), 0)
+	}
+	m.lastReceived, m.lastLost = received, lost
+
+	if !haveMeasurements {
+		return ConnectionQualityReport{}, false
+	}
+
+	loss := math.Max(remoteLoss, localLoss)
+	score := connectionQualityScore(rtt, loss, bitrate, m.thresholds)
+
+	return ConnectionQualityReport{
+		Timestamp:                time.Now(),
+		Quality:                  m.thresholds.rate(score),
+		Score:                    score,
+		RoundTripTime:            rtt,
+		PacketLoss:               loss,
+		AvailableOutgoingBitrate: bitrate,
+	}, true
+}
+
+func (m *connectionQualityMonitor) close() {
+	if m == nil {
+		return
+	}
+
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+// OnConnectionQuality sets an event handler which is invoked with a
+// ConnectionQualityReport every SettingEngine.SetConnectionQualityInterval
+// while the PeerConnection is connected. The report aggregates the round
+// trip time and packet loss from RTCP Receiver Reports and the ICE candidate
+// pair with the bandwidth estimated by the congestion controller of
+// ConfigureCongestionController into a single score, so applications can warn
+// about a poor connection without processing the raw stats.
+func (pc *PeerConnection) OnConnectionQuality(f func(ConnectionQualityReport)) {
+	pc.onConnectionQualityHandler.Store(f)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.connectionQualityMonitor == nil && !pc.isClosed.get() {
+		pc.connectionQualityMonitor = newConnectionQualityMonitor(pc)
+	}
+}
+
+func (pc *PeerConnection) onConnectionQuality(report ConnectionQualityReport) {
+	if handler, ok := pc.onConnectionQualityHandler.Load().(func(ConnectionQualityReport)); ok && handler != nil {
+		handler(report)
+	}
+}
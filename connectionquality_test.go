@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionQuality_String(t *testing.T) {
+	testCases := []struct {
+		quality        ConnectionQuality
+		expectedString string
+	}{
+		{ConnectionQualityUnknown, "unknown"},
+		{ConnectionQualityExcellent, "excellent"},
+		{ConnectionQualityGood, "good"},
+		{ConnectionQualityPoor, "poor"},
+		{ConnectionQualityBad, "bad"},
+		{ConnectionQuality(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.quality.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestConnectionQualityScore(t *testing.T) {
+	thresholds := DefaultConnectionQualityThresholds
+
+	testCases := []struct {
+		rtt             time.Duration
+		loss            float64
+		bitrate         float64
+		expectedScore   float64
+		expectedQuality ConnectionQuality
+	}{
+		{50 * time.Millisecond, 0, 0, 100, ConnectionQualityExcellent},
+		{300 * time.Millisecond, 0, 0, 80, ConnectionQualityExcellent},
+		{100 * time.Millisecond, 0.05, 0, 80, ConnectionQualityExcellent},
+		{200 * time.Millisecond, 0.05, 150_000, 60, ConnectionQualityGood},
+		{400 * time.Millisecond, 0.05, 0, 50, ConnectionQualityPoor},
+		{2 * time.Second, 0.5, 1000, 0, ConnectionQualityBad},
+	}
+
+	for i, testCase := range testCases {
+		score := connectionQualityScore(testCase.rtt, testCase.loss, testCase.bitrate, thresholds)
+		assert.InDelta(t, testCase.expectedScore, score, 0.001, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedQuality, thresholds.rate(score), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestConnectionQualityMonitor_Sample(t *testing.T) {
+	m := &connectionQualityMonitor{thresholds: DefaultConnectionQualityThresholds}
+
+	_, ok := m.sample(StatsReport{})
+	assert.False(t, ok)
+
+	report, ok := m.sample(StatsReport{
+		"pair": ICECandidatePairStats{Nominated: true, CurrentRoundTripTime: 0.2},
+		"inbound": InboundRTPStreamStats{
+			PacketsReceived: 90,
+			PacketsLost:     10,
+		},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 200*time.Millisecond, report.RoundTripTime)
+	assert.InDelta(t, 0.1, report.PacketLoss, 0.001)
+	assert.InDelta(t, 50, report.Score, 0.001)
+	assert.Equal(t, ConnectionQualityPoor, report.Quality)
+
+	// Loss is computed over the last interval only, and RTT from Receiver
+	// Reports takes precedence over the candidate pair
+	report, ok = m.sample(StatsReport{
+		"pair":          ICECandidatePairStats{Nominated: true, CurrentRoundTripTime: 0.2},
+		"remoteInbound": RemoteInboundRTPStreamStats{RoundTripTime: 0.05},
+		"inbound": InboundRTPStreamStats{
+			PacketsReceived: 190,
+			PacketsLost:     10,
+		},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 50*time.Millisecond, report.RoundTripTime)
+	assert.Equal(t, 0.0, report.PacketLoss)
+	assert.Equal(t, ConnectionQualityExcellent, report.Quality)
+}
+
+type fixedBandwidthEstimator struct {
+	bitrate int
+}
+
+func (e *fixedBandwidthEstimator) AddStream(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	return writer
+}
+
+func (e *fixedBandwidthEstimator) WriteRTCP([]rtcp.Packet, interceptor.Attributes) error { return nil }
+func (e *fixedBandwidthEstimator) GetTargetBitrate() int                                 { return e.bitrate }
+func (e *fixedBandwidthEstimator) OnTargetBitrateChange(func(bitrate int))               {}
+func (e *fixedBandwidthEstimator) GetStats() map[string]interface{}                      { return nil }
+func (e *fixedBandwidthEstimator) Close() error                                          { return nil }
+
+func TestConnectionQualityMonitor_SampleBandwidthEstimator(t *testing.T) {
+	bandwidthEstimators.Store("quality", &fixedBandwidthEstimator{bitrate: 150_000})
+	defer cleanupStats("quality")
+
+	m := &connectionQualityMonitor{statsID: "quality", thresholds: DefaultConnectionQualityThresholds}
+
+	report, ok := m.sample(StatsReport{
+		"pair": ICECandidatePairStats{Nominated: true, CurrentRoundTripTime: 0.05},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 150_000.0, report.AvailableOutgoingBitrate)
+	assert.InDelta(t, 90, report.Score, 0.001)
+}
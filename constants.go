@@ -24,8 +24,27 @@ const (
 
 	sdpAttributeSimulcast = "simulcast"
 
+	sdpAttributeBundleOnly = "bundle-only"
+
+	sdpAttributeRTCPMuxOnly = "rtcp-mux-only"
+
+	sdpAttributeMaxMessageSize = "max-message-size"
+
+	// sctpMaxMessageSizeUnsetValue is the max-message-size assumed when a
+	// description has none, RFC 8841 Section 6.1
+	sctpMaxMessageSizeUnsetValue = 65536
+
 	rtpOutboundMTU = 1200
 
+	// Same as the ICE username fragments generated by pion/ice
+	iceUsernameFragmentRandomLength = 16
+	icePasswordRandomLength         = 32
+	iceUsernameFragmentRunes        = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	// iceTCPReadBufferSize is how many packets are buffered for each ICE-TCP
+	// connection of the TCPMux created by SettingEngine.EnableICETCP
+	iceTCPReadBufferSize = 8
+
 	rtpPayloadTypeBitmask = 0x7F
 
 	incomingUnhandledRTPSsrc = "Incoming unhandled RTP ssrc(%d), OnTrack will not be fired. %v"
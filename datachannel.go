@@ -7,6 +7,7 @@
 package webrtc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,7 +21,18 @@ import (
 	"github.com/pion/webrtc/v4/pkg/rtcerr"
 )
 
-const dataChannelBufferSize = math.MaxUint16 // message size limit for Chromium
+const (
+	dataChannelBufferSize = math.MaxUint16 // message size limit for Chromium
+
+	// dataChannelBackpressurePollInterval is how often a blocked Send checks
+	// if the BufferedAmount went below the high-water mark
+	dataChannelBackpressurePollInterval = 5 * time.Millisecond
+
+	// dataChannelClosingTimeout bounds how long a DataChannel closed by the
+	// remote stays closing while its buffered messages are sent
+	dataChannelClosingTimeout = 5 * time.Second
+)
+
 var errSCTPNotEstablished = errors.New("SCTP not established")
 
 // DataChannel represents a WebRTC DataChannel
@@ -34,12 +46,17 @@ type DataChannel struct {
 	ordered                    bool
 	maxPacketLifeTime          *uint16
 	maxRetransmits             *uint16
+	priority                   PriorityType
 	protocol                   string
 	negotiated                 bool
 	id                         *uint16
 	readyState                 atomic.Value // DataChannelState
 	bufferedAmountLowThreshold uint64
 	detachCalled               bool
+	awaitingAuthentication     bool
+	// remoteClosing is set while the queued messages are sent after the
+	// remote closed the DataChannel
+	remoteClosing atomicBool
 
 	// The binaryType represents attribute MUST, on getting, return the value to
 	// which it was last set. On setting, if the new value is either the string
@@ -54,6 +71,7 @@ type DataChannel struct {
 	onOpenHandler       func()
 	dialHandlerOnce     sync.Once
 	onDialHandler       func()
+	onClosingHandler    func()
 	onCloseHandler      func()
 	onBufferedAmountLow func()
 	onErrorHandler      func(error)
@@ -91,6 +109,11 @@ func (api *API) newDataChannel(params *DataChannelParameters, sctpTransport *SCT
 		return nil, &rtcerr.TypeError{Err: ErrStringSizeLimit}
 	}
 
+	priority := params.Priority
+	if priority == PriorityTypeUnknown {
+		priority = PriorityTypeLow
+	}
+
 	d := &DataChannel{
 		sctpTransport:     sctpTransport,
 		statsID:           fmt.Sprintf("DataChannel-%d", time.Now().UnixNano()),
@@ -101,6 +124,7 @@ func (api *API) newDataChannel(params *DataChannelParameters, sctpTransport *SCT
 		ordered:           params.Ordered,
 		maxPacketLifeTime: params.MaxPacketLifeTime,
 		maxRetransmits:    params.MaxRetransmits,
+		priority:          priority,
 		api:               api,
 		log:               log,
 	}
@@ -151,7 +175,7 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 
 	cfg := &datachannel.Config{
 		ChannelType:          channelType,
-		Priority:             datachannel.ChannelPriorityNormal,
+		Priority:             d.priority.channelPriority(),
 		ReliabilityParameter: reliabilityParameter,
 		Label:                d.label,
 		Protocol:             d.protocol,
@@ -178,7 +202,7 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 
 	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
 	dc.SetBufferedAmountLowThreshold(d.bufferedAmountLowThreshold)
-	dc.OnBufferedAmountLow(d.onBufferedAmountLow)
+	dc.OnBufferedAmountLow(d.handleBufferedAmountLow)
 	d.mu.Unlock()
 
 	d.onDial()
@@ -211,9 +235,10 @@ func (d *DataChannel) OnOpen(f func()) {
 	d.mu.Lock()
 	d.openHandlerOnce = sync.Once{}
 	d.onOpenHandler = f
+	awaitingAuthentication := d.awaitingAuthentication
 	d.mu.Unlock()
 
-	if d.ReadyState() == DataChannelStateOpen {
+	if d.ReadyState() == DataChannelStateOpen && !awaitingAuthentication {
 		// If the data channel is already open, call the handler immediately.
 		go d.openHandlerOnce.Do(func() {
 			f()
@@ -259,6 +284,51 @@ func (d *DataChannel) onDial() {
 	}
 }
 
+// OnClosing sets an event handler which is invoked when the remote started
+// to close the DataChannel by resetting its stream. The ReadyState is then
+// DataChannelStateClosing until the messages still buffered were sent, and
+// OnClose is invoked afterwards. It isn't invoked when the DataChannel is
+// closed with Close, nor for detached DataChannels.
+func (d *DataChannel) OnClosing(f func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onClosingHandler = f
+}
+
+func (d *DataChannel) onClosing() {
+	d.mu.RLock()
+	handler := d.onClosingHandler
+	d.mu.RUnlock()
+
+	if handler != nil {
+		handler()
+	}
+}
+
+// handleRemoteClosing moves the DataChannel to closing after the remote reset
+// its stream, and waits until the messages queued by the scheduler were
+// handed to SCTP or dataChannelClosingTimeout passed. SCTP delivers the
+// messages it was handed before resetting the stream.
+func (d *DataChannel) handleRemoteClosing() {
+	d.remoteClosing.set(true)
+	defer d.remoteClosing.set(false)
+
+	d.setReadyState(DataChannelStateClosing)
+	d.onClosing()
+
+	d.mu.RLock()
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+	if sctpTransport == nil {
+		return
+	}
+
+	deadline := time.Now().Add(dataChannelClosingTimeout)
+	for sctpTransport.scheduler.queuedAmount(d) > 0 && time.Now().Before(deadline) {
+		time.Sleep(dataChannelBackpressurePollInterval)
+	}
+}
+
 // OnClose sets an event handler which is invoked when
 // the underlying data transport has been closed.
 func (d *DataChannel) OnClose(f func()) {
@@ -301,10 +371,12 @@ func (d *DataChannel) onMessage(msg DataChannelMessage) {
 }
 
 func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlreadyNegotiated bool) {
+	authenticate := d.authenticationEnabled()
+
 	d.mu.Lock()
 	d.dataChannel = dc
+	d.awaitingAuthentication = authenticate
 	bufferedAmountLowThreshold := d.bufferedAmountLowThreshold
-	onBufferedAmountLow := d.onBufferedAmountLow
 	d.mu.Unlock()
 	d.setReadyState(DataChannelStateOpen)
 
@@ -312,17 +384,24 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlread
 	// * detached datachannels have no read loop, the user needs to read and query themselves
 	// * remote datachannels should fire OnOpened. This isn't spec compliant, but we can't break behavior yet
 	// * already negotiated datachannels should fire OnOpened
+	// * authenticated datachannels fire OnOpened once the remote authenticator is verified
 	if d.api.settingEngine.detach.DataChannels || isRemote || isAlreadyNegotiated {
 		// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
 		d.dataChannel.SetBufferedAmountLowThreshold(bufferedAmountLowThreshold)
-		d.dataChannel.OnBufferedAmountLow(onBufferedAmountLow)
-		d.onOpen()
-	} else {
+		d.dataChannel.OnBufferedAmountLow(d.handleBufferedAmountLow)
+		if !authenticate {
+			d.onOpen()
+		}
+	} else if !authenticate {
 		dc.OnOpen(func() {
 			d.onOpen()
 		})
 	}
 
+	if authenticate {
+		d.sendAuthenticator()
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -351,16 +430,38 @@ func (d *DataChannel) onError(err error) {
 
 // See https://github.com/pion/webrtc/issues/1516
 // nolint:gochecknoglobals
-var rlBufPool = sync.Pool{New: func() interface{} {
-	return make([]byte, dataChannelBufferSize)
-}}
+var rlBufPool = newDataChannelReadBufferPool(dataChannelBufferSize)
+
+// newDataChannelReadBufferPool returns a pool of buffers which fit messages of
+// maxMessageSize, and at least the messages Chromium sends
+func newDataChannelReadBufferPool(maxMessageSize uint32) *sync.Pool {
+	size := int(maxMessageSize)
+	if size < dataChannelBufferSize {
+		size = dataChannelBufferSize
+	}
+
+	return &sync.Pool{New: func() interface{} {
+		return make([]byte, size)
+	}}
+}
 
 func (d *DataChannel) readLoop() {
+	// The buffers fit the max-message-size advertised by the SCTPTransport
+	bufPool := rlBufPool
+	d.mu.RLock()
+	if d.sctpTransport != nil {
+		bufPool = d.sctpTransport.readBufferPool
+	}
+	d.mu.RUnlock()
+
 	for {
-		buffer := rlBufPool.Get().([]byte) //nolint:forcetypeassert
+		buffer := bufPool.Get().([]byte) //nolint:forcetypeassert
 		n, isString, err := d.dataChannel.ReadDataChannel(buffer)
 		if err != nil {
-			rlBufPool.Put(buffer) // nolint:staticcheck
+			bufPool.Put(buffer) // nolint:staticcheck
+			if errors.Is(err, io.EOF) && d.ReadyState() == DataChannelStateOpen {
+				d.handleRemoteClosing()
+			}
 			d.setReadyState(DataChannelStateClosed)
 			if !errors.Is(err, io.EOF) {
 				d.onError(err)
@@ -372,7 +473,18 @@ func (d *DataChannel) readLoop() {
 		m := DataChannelMessage{Data: make([]byte, n), IsString: isString}
 		copy(m.Data, buffer[:n])
 		// The 'staticcheck' pragma is a false positive on the part of the CI linter.
-		rlBufPool.Put(buffer) // nolint:staticcheck
+		bufPool.Put(buffer) // nolint:staticcheck
+
+		d.mu.RLock()
+		awaitingAuthentication := d.awaitingAuthentication
+		d.mu.RUnlock()
+		if awaitingAuthentication {
+			// Nothing is delivered before the remote is authenticated
+			if d.ReadyState() == DataChannelStateOpen {
+				d.verifyAuthenticator(m)
+			}
+			continue
+		}
 
 		// NB: Why was DataChannelMessage not passed as a pointer value?
 		d.onMessage(m) // nolint:staticcheck
@@ -381,23 +493,77 @@ func (d *DataChannel) readLoop() {
 
 // Send sends the binary message to the DataChannel peer
 func (d *DataChannel) Send(data []byte) error {
-	err := d.ensureOpen()
-	if err != nil {
-		return err
-	}
+	return d.send(context.Background(), data, false)
+}
 
-	_, err = d.dataChannel.WriteDataChannel(data, false)
-	return err
+// SendContext sends the binary message to the DataChannel peer. When Send
+// blocks because of SettingEngine.SetDataChannelHighWaterMark, it returns
+// ctx.Err() if ctx is done before the message could be buffered.
+func (d *DataChannel) SendContext(ctx context.Context, data []byte) error {
+	return d.send(ctx, data, false)
 }
 
 // SendText sends the text message to the DataChannel peer
 func (d *DataChannel) SendText(s string) error {
+	return d.send(context.Background(), []byte(s), true)
+}
+
+// SendTextContext sends the text message to the DataChannel peer, see
+// SendContext.
+func (d *DataChannel) SendTextContext(ctx context.Context, s string) error {
+	return d.send(ctx, []byte(s), true)
+}
+
+func (d *DataChannel) send(ctx context.Context, data []byte, isString bool) error {
 	err := d.ensureOpen()
 	if err != nil {
 		return err
 	}
 
-	_, err = d.dataChannel.WriteDataChannel([]byte(s), true)
+	if err = d.waitBufferedAmount(ctx); err != nil {
+		return err
+	}
+
+	return d.sctpTransport.scheduler.send(d, data, isString)
+}
+
+// waitBufferedAmount applies the high-water mark of the SettingEngine. The
+// BufferedAmount is polled since there is no event for every decrease.
+func (d *DataChannel) waitBufferedAmount(ctx context.Context) error {
+	backpressure := d.api.settingEngine.dataChannelBackpressure
+	if backpressure.highWaterMark == 0 || d.BufferedAmount() <= backpressure.highWaterMark {
+		return nil
+	}
+
+	if !backpressure.block {
+		return ErrDataChannelBufferFull
+	}
+
+	ticker := time.NewTicker(dataChannelBackpressurePollInterval)
+	defer ticker.Stop()
+
+	for d.BufferedAmount() > backpressure.highWaterMark {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := d.ensureOpen(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write hands a message to SCTP, bypassing the scheduler
+func (d *DataChannel) write(data []byte, isString bool) error {
+	d.mu.RLock()
+	dc := d.dataChannel
+	d.mu.RUnlock()
+
+	_, err := dc.WriteDataChannel(data, isString)
 	return err
 }
 
@@ -419,6 +585,15 @@ func (d *DataChannel) ensureOpen() error {
 // pion/datachannel documentation for the correct way to handle the
 // resulting DataChannel object.
 func (d *DataChannel) Detach() (datachannel.ReadWriteCloser, error) {
+	dc, err := d.detach()
+	if err != nil {
+		return nil, err
+	}
+
+	return dc, nil
+}
+
+func (d *DataChannel) detach() (*datachannel.DataChannel, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -491,6 +666,15 @@ func (d *DataChannel) MaxRetransmits() *uint16 {
 	return d.maxRetransmits
 }
 
+// Priority represents the priority of this DataChannel. Messages of higher
+// priority DataChannels on the same SCTPTransport are sent first.
+func (d *DataChannel) Priority() PriorityType {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.priority
+}
+
 // Protocol represents the name of the sub-protocol used with this
 // DataChannel.
 func (d *DataChannel) Protocol() string {
@@ -539,15 +723,18 @@ func (d *DataChannel) ReadyState() DataChannelState {
 // system or network hardware. The value of BufferedAmount slot will only
 // increase with each call to the send() method as long as the ReadyState is
 // open; however, BufferedAmount does not reset to zero once the channel
-// closes.
+// closes. It includes the messages held back by the SCTPTransport for
+// higher priority DataChannels.
 func (d *DataChannel) BufferedAmount() uint64 {
 	d.mu.RLock()
-	defer d.mu.RUnlock()
+	dc := d.dataChannel
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
 
-	if d.dataChannel == nil {
+	if dc == nil {
 		return 0
 	}
-	return d.dataChannel.BufferedAmount()
+	return dc.BufferedAmount() + sctpTransport.scheduler.queuedAmount(d)
 }
 
 // BufferedAmountLowThreshold represents the threshold at which the
@@ -587,8 +774,23 @@ func (d *DataChannel) OnBufferedAmountLow(f func()) {
 	defer d.mu.Unlock()
 
 	d.onBufferedAmountLow = f
-	if d.dataChannel != nil {
-		d.dataChannel.OnBufferedAmountLow(f)
+}
+
+// handleBufferedAmountLow is the OnBufferedAmountLow handler of the
+// underlying datachannel. Lower priority DataChannels may be waiting for this
+// one to drain, so the scheduler is kicked before the user's handler is called.
+func (d *DataChannel) handleBufferedAmountLow() {
+	d.mu.RLock()
+	handler := d.onBufferedAmountLow
+	sctpTransport := d.sctpTransport
+	d.mu.RUnlock()
+
+	if sctpTransport != nil {
+		go sctpTransport.scheduler.drain()
+	}
+
+	if handler != nil {
+		handler()
 	}
 }
 
@@ -598,20 +800,18 @@ func (d *DataChannel) getStatsID() string {
 	return d.statsID
 }
 
-func (d *DataChannel) collectStats(collector *statsReportCollector) {
-	collector.Collecting()
-
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
+// GetStats returns the statistics of the DataChannel, the same as its entry
+// in the StatsReport of the PeerConnection
+func (d *DataChannel) GetStats() DataChannelStats {
+	d.mu.RLock()
 	stats := DataChannelStats{
-		Timestamp: statsTimestampNow(),
-		Type:      StatsTypeDataChannel,
-		ID:        d.statsID,
-		Label:     d.label,
-		Protocol:  d.protocol,
-		// TransportID string `json:"transportId"`
-		State: d.ReadyState(),
+		Timestamp:   statsTimestampNow(),
+		Type:        StatsTypeDataChannel,
+		ID:          d.statsID,
+		Label:       d.label,
+		Protocol:    d.protocol,
+		TransportID: sctpTransportStatsID,
+		State:       d.ReadyState(),
 	}
 
 	if d.id != nil {
@@ -624,7 +824,17 @@ func (d *DataChannel) collectStats(collector *statsReportCollector) {
 		stats.MessagesReceived = d.dataChannel.MessagesReceived()
 		stats.BytesReceived = d.dataChannel.BytesReceived()
 	}
+	d.mu.RUnlock()
+
+	stats.BufferedAmount = d.BufferedAmount()
+
+	return stats
+}
+
+func (d *DataChannel) collectStats(collector *statsReportCollector) {
+	collector.Collecting()
 
+	stats := d.GetStats()
 	collector.Collect(stats.ID, stats)
 }
 
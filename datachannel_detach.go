@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/datachannel"
+)
+
+// DetachedDataChannel is a detached DataChannel which supports deadlines, like
+// a net.Conn. It is returned by DataChannel.DetachWithDeadline.
+type DetachedDataChannel interface {
+	datachannel.ReadWriteCloser
+
+	// SetDeadline sets the read and write deadlines
+	SetDeadline(t time.Time) error
+
+	// SetReadDeadline sets the deadline for pending and future reads
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline sets the deadline for future writes. Writes only block
+	// while the BufferedAmount is above the high-water mark set with
+	// SettingEngine.SetDataChannelHighWaterMark.
+	SetWriteDeadline(t time.Time) error
+}
+
+// dataChannelTimeoutError is returned by reads and writes of a
+// DetachedDataChannel once their deadline passed
+type dataChannelTimeoutError struct{}
+
+func (dataChannelTimeoutError) Error() string   { return "i/o timeout" }
+func (dataChannelTimeoutError) Timeout() bool   { return true }
+func (dataChannelTimeoutError) Temporary() bool { return true }
+
+type detachedDataChannel struct {
+	*datachannel.DataChannel
+
+	parent        *DataChannel
+	writeDeadline atomic.Value // time.Time
+}
+
+// DetachWithDeadline detaches the underlying datachannel like Detach, and
+// returns it with support for read and write deadlines.
+func (d *DataChannel) DetachWithDeadline() (DetachedDataChannel, error) {
+	dc, err := d.detach()
+	if err != nil {
+		return nil, err
+	}
+
+	return &detachedDataChannel{DataChannel: dc, parent: d}, nil
+}
+
+func (c *detachedDataChannel) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+
+	return c.SetWriteDeadline(t)
+}
+
+func (c *detachedDataChannel) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.Store(t)
+	return nil
+}
+
+func (c *detachedDataChannel) Write(p []byte) (int, error) {
+	return c.WriteDataChannel(p, false)
+}
+
+func (c *detachedDataChannel) WriteDataChannel(p []byte, isString bool) (int, error) {
+	ctx := context.Background()
+	if deadline, ok := c.writeDeadline.Load().(time.Time); ok && !deadline.IsZero() {
+		if !time.Now().Before(deadline) {
+			return 0, dataChannelTimeoutError{}
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	if err := c.parent.waitBufferedAmount(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, dataChannelTimeoutError{}
+		}
+
+		return 0, err
+	}
+
+	return c.DataChannel.WriteDataChannel(p, isString)
+}
@@ -8,11 +8,15 @@ package webrtc
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"reflect"
 	"regexp"
 	"strings"
@@ -566,6 +570,57 @@ func TestEOF(t *testing.T) {
 	})
 }
 
+func TestDataChannel_OnClosing(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	closing := make(chan struct{})
+	closed := make(chan struct{})
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+
+		d.OnClosing(func() {
+			assert.Equal(t, DataChannelStateClosing, d.ReadyState())
+			assert.Error(t, d.SendText("closing"))
+			close(closing)
+		})
+		d.OnClose(func() {
+			select {
+			case <-closing:
+			default:
+				t.Error("OnClose was invoked before OnClosing")
+			}
+			assert.Equal(t, DataChannelStateClosed, d.ReadyState())
+			close(closed)
+		})
+	})
+
+	d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	d.OnClosing(func() {
+		t.Error("OnClosing is only invoked when the remote closes")
+	})
+	d.OnOpen(func() {
+		assert.NoError(t, d.Close())
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	<-closing
+	<-closed
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 // Assert that a Session Description that doesn't follow
 // draft-ietf-mmusic-sctp-sdp is still accepted
 func TestDataChannel_NonStandardSessionDescription(t *testing.T) {
@@ -692,3 +747,425 @@ func TestDataChannel_Dial(t *testing.T) {
 		closePair(t, offerPC, answerPC, done)
 	})
 }
+
+func TestDataChannel_Authentication(t *testing.T) {
+	newAuthenticatedPair := func(offerToken, answerToken string) (*PeerConnection, *PeerConnection) {
+		offerSettings := SettingEngine{}
+		offerSettings.SetDataChannelAuthenticationToken([]byte(offerToken))
+		offerPC, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		answerSettings := SettingEngine{}
+		answerSettings.SetDataChannelAuthenticationToken([]byte(answerToken))
+		answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		return offerPC, answerPC
+	}
+
+	t.Run("matching token", func(t *testing.T) {
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC := newAuthenticatedPair("session-token", "session-token")
+
+		done := make(chan bool)
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			opened := &atomicBool{}
+			d.OnOpen(func() {
+				opened.set(true)
+			})
+			d.OnMessage(func(msg DataChannelMessage) {
+				assert.True(t, opened.get(), "OnMessage fired before OnOpen")
+				assert.Equal(t, "hello", string(msg.Data))
+				done <- true
+			})
+		})
+
+		d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+		d.OnOpen(func() {
+			assert.NoError(t, d.SendText("hello"))
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+
+		closePair(t, offerPC, answerPC, done)
+	})
+
+	t.Run("mismatched token", func(t *testing.T) {
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC := newAuthenticatedPair("session-token", "other-token")
+
+		failed := make(chan error, 2)
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			d.OnOpen(func() {
+				t.Error("OnOpen fired without authentication")
+			})
+			d.OnError(func(err error) {
+				failed <- err
+			})
+		})
+
+		d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+		d.OnOpen(func() {
+			t.Error("OnOpen fired without authentication")
+		})
+		d.OnError(func(err error) {
+			failed <- err
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+
+		assert.ErrorIs(t, <-failed, ErrDataChannelAuthenticationFailed)
+		closePairNow(t, offerPC, answerPC)
+	})
+}
+
+// Assert that authenticators are bound to the connection and the direction, so
+// a remote without the token can't reflect the authenticator it received
+func TestDataChannelAuthenticator(t *testing.T) {
+	token, keyingMaterial := []byte("session-token"), []byte("keying-material")
+
+	client := dataChannelAuthenticator(token, keyingMaterial, DTLSRoleClient, expectedLabel)
+	assert.Equal(t, client, dataChannelAuthenticator(token, keyingMaterial, DTLSRoleClient, expectedLabel))
+	assert.NotEqual(t, client, dataChannelAuthenticator(token, keyingMaterial, DTLSRoleServer, expectedLabel))
+	assert.NotEqual(t, client, dataChannelAuthenticator(token, []byte("other-keying-material"), DTLSRoleClient, expectedLabel))
+	assert.NotEqual(t, client, dataChannelAuthenticator([]byte("other-token"), keyingMaterial, DTLSRoleClient, expectedLabel))
+	assert.NotEqual(t, client, dataChannelAuthenticator(token, keyingMaterial, DTLSRoleClient, "other-label"))
+}
+
+func TestDataChannel_Priority(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	const (
+		bulkMessages    = 64
+		bulkMessageSize = 32 * 1024
+	)
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	var (
+		receivedMu   sync.Mutex
+		bulkReceived int
+		controlAfter = -1
+	)
+	done := make(chan bool, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		switch d.Label() {
+		case "control":
+			assert.Equal(t, PriorityTypeHigh, d.Priority())
+			d.OnMessage(func(DataChannelMessage) {
+				receivedMu.Lock()
+				controlAfter = bulkReceived
+				receivedMu.Unlock()
+			})
+		case "bulk":
+			assert.Equal(t, PriorityTypeLow, d.Priority())
+			d.OnMessage(func(DataChannelMessage) {
+				receivedMu.Lock()
+				bulkReceived++
+				if bulkReceived == bulkMessages {
+					done <- true
+				}
+				receivedMu.Unlock()
+			})
+		}
+	})
+
+	high := PriorityTypeHigh
+	control, err := offerPC.CreateDataChannel("control", &DataChannelInit{Priority: &high})
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityTypeHigh, control.Priority())
+
+	bulk, err := offerPC.CreateDataChannel("bulk", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityTypeLow, bulk.Priority())
+
+	opened := make(chan struct{}, 2)
+	control.OnOpen(func() { opened <- struct{}{} })
+	bulk.OnOpen(func() { opened <- struct{}{} })
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+	<-opened
+
+	// Most of the bulk transfer is held back by the scheduler, so the control
+	// message doesn't wait behind it
+	for i := 0; i < bulkMessages; i++ {
+		assert.NoError(t, bulk.Send(make([]byte, bulkMessageSize)))
+	}
+	assert.Greater(t, bulk.BufferedAmount(), uint64(dataChannelSchedulerMaxBufferedAmount))
+	assert.NoError(t, control.SendText("ping"))
+
+	closePair(t, offerPC, answerPC, done)
+
+	receivedMu.Lock()
+	defer receivedMu.Unlock()
+	assert.GreaterOrEqual(t, controlAfter, 0, "control message not received")
+	assert.Less(t, controlAfter, bulkMessages)
+}
+
+func TestPriorityType_ChannelPriority(t *testing.T) {
+	for _, priority := range []PriorityType{PriorityTypeVeryLow, PriorityTypeLow, PriorityTypeMedium, PriorityTypeHigh} {
+		assert.Equal(t, priority, priorityTypeFromChannelPriority(priority.channelPriority()))
+	}
+
+	assert.Equal(t, PriorityTypeLow, priorityTypeFromChannelPriority(PriorityTypeUnknown.channelPriority()))
+	assert.Equal(t, PriorityTypeVeryLow, priorityTypeFromChannelPriority(0))
+	assert.Equal(t, PriorityTypeHigh, priorityTypeFromChannelPriority(0xFFFF))
+}
+
+func TestDataChannel_SCTPZeroChecksum(t *testing.T) {
+	for _, offerEnabled := range []bool{true, false} {
+		offerEnabled := offerEnabled
+		t.Run(fmt.Sprintf("offerer enabled %t", offerEnabled), func(t *testing.T) {
+			report := test.CheckRoutines(t)
+			defer report()
+
+			offerSettings := SettingEngine{}
+			offerSettings.EnableSCTPZeroChecksum(offerEnabled)
+			offerPC, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+			assert.NoError(t, err)
+
+			answerPC, err := NewPeerConnection(Configuration{})
+			assert.NoError(t, err)
+
+			done := make(chan bool)
+			answerPC.OnDataChannel(func(d *DataChannel) {
+				d.OnMessage(func(msg DataChannelMessage) {
+					assert.Equal(t, "hello", string(msg.Data))
+					done <- true
+				})
+			})
+
+			d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+			assert.NoError(t, err)
+			d.OnOpen(func() {
+				assert.NoError(t, d.SendText("hello"))
+			})
+
+			assert.NoError(t, signalPair(offerPC, answerPC))
+
+			closePair(t, offerPC, answerPC, done)
+		})
+	}
+}
+
+func TestDataChannel_HighWaterMark(t *testing.T) {
+	const (
+		highWaterMark = 1024
+		messageSize   = 16 * 1024
+		messageCount  = 32
+	)
+
+	newBackpressurePair := func(block bool, onDataChannel func(*DataChannel)) (*PeerConnection, *PeerConnection, *DataChannel, chan struct{}) {
+		s := SettingEngine{}
+		s.SetDataChannelHighWaterMark(highWaterMark, block)
+		offerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		answerPC, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		if onDataChannel != nil {
+			answerPC.OnDataChannel(onDataChannel)
+		}
+
+		d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+
+		opened := make(chan struct{})
+		d.OnOpen(func() {
+			close(opened)
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+
+		return offerPC, answerPC, d, opened
+	}
+
+	t.Run("error", func(t *testing.T) {
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC, d, opened := newBackpressurePair(false, nil)
+		<-opened
+
+		var err error
+		for i := 0; i < messageCount && err == nil; i++ {
+			err = d.Send(make([]byte, messageSize))
+		}
+		assert.ErrorIs(t, err, ErrDataChannelBufferFull)
+
+		closePairNow(t, offerPC, answerPC)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		report := test.CheckRoutines(t)
+		defer report()
+
+		done := make(chan bool)
+		var received int32
+		offerPC, answerPC, d, opened := newBackpressurePair(true, func(d *DataChannel) {
+			d.OnMessage(func(DataChannelMessage) {
+				if atomic.AddInt32(&received, 1) == messageCount {
+					done <- true
+				}
+			})
+		})
+		<-opened
+
+		for i := 0; i < messageCount; i++ {
+			assert.NoError(t, d.Send(make([]byte, messageSize)))
+			assert.LessOrEqual(t, d.BufferedAmount(), uint64(highWaterMark+messageSize))
+		}
+
+		closePair(t, offerPC, answerPC, done)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		report := test.CheckRoutines(t)
+		defer report()
+
+		offerPC, answerPC, d, opened := newBackpressurePair(true, nil)
+		<-opened
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var err error
+		for i := 0; i < messageCount && err == nil; i++ {
+			err = d.SendContext(ctx, make([]byte, messageSize))
+		}
+		assert.ErrorIs(t, err, context.Canceled)
+
+		closePairNow(t, offerPC, answerPC)
+	})
+}
+
+func TestDataChannel_DetachWithDeadline(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.DetachDataChannels()
+	api := NewAPI(WithSettingEngine(s))
+
+	offerPC, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerPC, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	received := make(chan []byte)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+
+		d.OnOpen(func() {
+			detached, detachErr := d.DetachWithDeadline()
+			assert.NoError(t, detachErr)
+
+			go func() {
+				buf := make([]byte, 32)
+				n, readErr := detached.Read(buf)
+				assert.NoError(t, readErr)
+				received <- buf[:n]
+			}()
+		})
+	})
+
+	d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	opened := make(chan struct{})
+	d.OnOpen(func() {
+		close(opened)
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+
+	detached, err := d.DetachWithDeadline()
+	assert.NoError(t, err)
+
+	// Nothing is sent to the offerer, the read times out
+	assert.NoError(t, detached.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = detached.Read(make([]byte, 32))
+	var netErr net.Error
+	if assert.True(t, errors.As(err, &netErr)) {
+		assert.True(t, netErr.Timeout())
+	}
+
+	assert.NoError(t, detached.SetWriteDeadline(time.Now().Add(-time.Second)))
+	_, err = detached.Write([]byte("late"))
+	if assert.True(t, errors.As(err, &netErr)) {
+		assert.True(t, netErr.Timeout())
+	}
+
+	assert.NoError(t, detached.SetDeadline(time.Time{}))
+	_, err = detached.Write([]byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), <-received)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_LargerMaxMessageSize(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxMessageSize = 256 * 1024
+
+	message := make([]byte, 100*1024)
+	_, err := rand.Read(message)
+	assert.NoError(t, err)
+
+	settings := SettingEngine{}
+	settings.SetSCTPMaxMessageSize(maxMessageSize)
+	api := NewAPI(WithSettingEngine(settings))
+
+	offerPC, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerPC, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+
+		d.OnMessage(func(msg DataChannelMessage) {
+			assert.True(t, bytes.Equal(message, msg.Data))
+			done <- true
+		})
+	})
+
+	d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+	d.OnOpen(func() {
+		assert.Equal(t, float64(maxMessageSize), offerPC.SCTP().MaxMessageSize())
+		assert.NoError(t, d.Send(message))
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	closePair(t, offerPC, answerPC, done)
+}
@@ -112,6 +112,10 @@ func (d *DataChannel) SendText(s string) (err error) {
 
 // Detach allows you to detach the underlying datachannel. This provides
 // an idiomatic API to work with, however it disables the OnMessage callback.
+// The returned ReadWriteCloser is bridged over the RTCDataChannel of the
+// browser: Read returns io.EOF once the DataChannel closed, and Write blocks
+// while the browser buffers too much data, until the bufferedamountlow event
+// fires. The BufferedAmountLowThreshold is changed for this.
 // Before calling Detach you have to enable this behavior by calling
 // webrtc.DetachDataChannels(). Combining detached and normal data channels
 // is not supported.
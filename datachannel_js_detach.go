@@ -7,32 +7,128 @@
 package webrtc
 
 import (
-	"errors"
+	"io"
+	"sync"
+	"syscall/js"
 )
 
+const (
+	// detachedDataChannelHighWaterMark is the BufferedAmount above which Write
+	// blocks until the bufferedamountlow event fires
+	detachedDataChannelHighWaterMark = 1024 * 1024
+
+	// detachedDataChannelLowWaterMark is the BufferedAmountLowThreshold of a
+	// detached DataChannel, blocked writes resume once the BufferedAmount
+	// drops to it
+	detachedDataChannelLowWaterMark = dataChannelBufferSize * 16
+)
+
+// detachedDataChannel bridges the datachannel.ReadWriteCloser interface over a
+// RTCDataChannel of the browser, so code written against a detached
+// DataChannel runs both natively and in WASM
 type detachedDataChannel struct {
 	dc *DataChannel
 
-	read chan DataChannelMessage
-	done chan struct{}
+	mu       sync.Mutex
+	messages []DataChannelMessage
+	closed   bool
+
+	// readable and writable are signaled when a message was queued and when
+	// the BufferedAmount dropped to the low water mark
+	readable chan struct{}
+	writable chan struct{}
+
+	writeMu sync.Mutex
+
+	closeOnce sync.Once
+	done      chan struct{}
+
+	onMessage, onBufferedAmountLow, onClose js.Func
 }
 
 func newDetachedDataChannel(dc *DataChannel) *detachedDataChannel {
-	read := make(chan DataChannelMessage)
-	done := make(chan struct{})
+	c := &detachedDataChannel{
+		dc:       dc,
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
 
-	// Wire up callbacks
-	dc.OnMessage(func(msg DataChannelMessage) {
-		read <- msg // pion/webrtc/projects/15
+	// Messages are converted on the event loop, so they are queued in the
+	// order they arrived. ArrayBuffers can be converted synchronously, unlike
+	// Blobs.
+	dc.underlying.Set("binaryType", "arraybuffer")
+	dc.SetBufferedAmountLowThreshold(detachedDataChannelLowWaterMark)
+
+	// Listeners are added instead of setting the on* attributes, so they
+	// don't replace the handlers of the DataChannel
+	c.onMessage = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		c.push(valueToDataChannelMessage(args[0].Get("data")))
+		return js.Undefined()
+	})
+	c.onBufferedAmountLow = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		signalDetached(c.writable)
+		return js.Undefined()
 	})
+	c.onClose = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go c.close()
+		return js.Undefined()
+	})
+	dc.underlying.Call("addEventListener", "message", c.onMessage)
+	dc.underlying.Call("addEventListener", "bufferedamountlow", c.onBufferedAmountLow)
+	dc.underlying.Call("addEventListener", "close", c.onClose)
 
-	// pion/webrtc/projects/15
+	return c
+}
 
-	return &detachedDataChannel{
-		dc:   dc,
-		read: read,
-		done: done,
+// signalDetached wakes up a goroutine waiting on ch without blocking
+func signalDetached(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (c *detachedDataChannel) push(msg DataChannelMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
 	}
+	c.messages = append(c.messages, msg)
+	signalDetached(c.readable)
+}
+
+// peek returns the oldest queued message without removing it, and false if
+// there is none
+func (c *detachedDataChannel) peek() (DataChannelMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.messages) == 0 {
+		return DataChannelMessage{}, false
+	}
+
+	return c.messages[0], true
+}
+
+// pop returns the oldest queued message, and false if there is none
+func (c *detachedDataChannel) pop() (DataChannelMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.messages) == 0 {
+		return DataChannelMessage{}, false
+	}
+	msg := c.messages[0]
+	c.messages[0] = DataChannelMessage{}
+	c.messages = c.messages[1:]
+	if len(c.messages) != 0 {
+		signalDetached(c.readable)
+	}
+
+	return msg, true
 }
 
 func (c *detachedDataChannel) Read(p []byte) (int, error) {
@@ -40,16 +136,26 @@ func (c *detachedDataChannel) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// ReadDataChannel reads the next message into p. Messages received before the
+// DataChannel closed are still returned, io.EOF is returned after that.
 func (c *detachedDataChannel) ReadDataChannel(p []byte) (int, bool, error) {
-	select {
-	case <-c.done:
-		return 0, false, errors.New("Reader closed")
-	case msg := <-c.read:
-		n := copy(p, msg.Data)
-		if n < len(msg.Data) {
-			return n, msg.IsString, errors.New("Read buffer to small")
+	for {
+		if msg, ok := c.pop(); ok {
+			n := copy(p, msg.Data)
+			if n < len(msg.Data) {
+				return n, msg.IsString, io.ErrShortBuffer
+			}
+			return n, msg.IsString, nil
+		}
+
+		select {
+		case <-c.readable:
+		case <-c.done:
+			// Drain the messages queued before the close
+			if _, ok := c.peek(); !ok {
+				return 0, false, io.EOF
+			}
 		}
-		return n, msg.IsString, nil
 	}
 }
 
@@ -57,19 +163,58 @@ func (c *detachedDataChannel) Write(p []byte) (n int, err error) {
 	return c.WriteDataChannel(p, false)
 }
 
+// WriteDataChannel sends p as one message. It blocks while more than
+// detachedDataChannelHighWaterMark bytes are buffered by the browser, until
+// the bufferedamountlow event fires or the DataChannel closes.
 func (c *detachedDataChannel) WriteDataChannel(p []byte, isString bool) (n int, err error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	for c.dc.BufferedAmount() > detachedDataChannelHighWaterMark {
+		select {
+		case <-c.writable:
+		case <-c.done:
+			return 0, io.ErrClosedPipe
+		}
+	}
+
+	select {
+	case <-c.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
 	if isString {
 		err = c.dc.SendText(string(p))
-		return len(p), err
+	} else {
+		err = c.dc.Send(p)
+	}
+	if err != nil {
+		return 0, err
 	}
 
-	err = c.dc.Send(p)
+	return len(p), nil
+}
 
-	return len(p), err
+// close unblocks pending reads and writes and releases the listeners
+func (c *detachedDataChannel) close() {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closed = true
+		c.mu.Unlock()
+		close(c.done)
+
+		c.dc.underlying.Call("removeEventListener", "message", c.onMessage)
+		c.dc.underlying.Call("removeEventListener", "bufferedamountlow", c.onBufferedAmountLow)
+		c.dc.underlying.Call("removeEventListener", "close", c.onClose)
+		c.onMessage.Release()
+		c.onBufferedAmountLow.Release()
+		c.onClose.Release()
+	})
 }
 
 func (c *detachedDataChannel) Close() error {
-	close(c.done)
+	c.close()
 
 	return c.dc.Close()
 }
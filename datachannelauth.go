@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+const (
+	// dataChannelAuthContext separates the authenticators of DataChannels from
+	// other uses of the session token
+	dataChannelAuthContext = "pion-datachannel-auth:"
+
+	// dataChannelAuthExporterLabel is the label of the DTLS keying material
+	// the authenticators are bound to
+	dataChannelAuthExporterLabel  = "EXPORTER-pion-datachannel-auth"
+	dataChannelAuthExporterLength = 32
+)
+
+// dataChannelAuthenticator computes the first message a DataChannel sends when
+// authentication is enabled, the HMAC-SHA256 keyed with the session token of
+// its label, the keying material exported from the DTLS connection and the
+// DTLS role of the sender. The keying material binds it to the connection,
+// and the role to the direction, so the remote can't reflect the
+// authenticator it received.
+func dataChannelAuthenticator(token, keyingMaterial []byte, senderRole DTLSRole, label string) []byte {
+	mac := hmac.New(sha256.New, token)
+	_, _ = mac.Write([]byte(dataChannelAuthContext))
+	_, _ = mac.Write(keyingMaterial)
+	_, _ = mac.Write([]byte(":" + senderRole.String() + ":" + label))
+	return mac.Sum(nil)
+}
+
+// authenticator returns the authenticator sent by the local side of the
+// DataChannel, or the one expected from the remote if remote is set
+func (d *DataChannel) authenticator(remote bool) ([]byte, error) {
+	sctpTransport := d.Transport()
+	if sctpTransport == nil {
+		return nil, errDtlsTransportNotStarted
+	}
+
+	dtlsTransport := sctpTransport.Transport()
+	keyingMaterial, err := dtlsTransport.ExportKeyingMaterial(dataChannelAuthExporterLabel, nil, dataChannelAuthExporterLength)
+	if err != nil {
+		return nil, err
+	}
+
+	role := dtlsTransport.role()
+	if remote {
+		role = DTLSRoleClient
+		if dtlsTransport.role() == DTLSRoleClient {
+			role = DTLSRoleServer
+		}
+	}
+
+	return dataChannelAuthenticator(d.api.settingEngine.dataChannelAuthToken, keyingMaterial, role, d.label), nil
+}
+
+func (d *DataChannel) authenticationEnabled() bool {
+	return d.api.settingEngine.dataChannelAuthToken != nil && !d.api.settingEngine.detach.DataChannels
+}
+
+func (d *DataChannel) sendAuthenticator() {
+	authenticator, err := d.authenticator(false)
+	if err != nil {
+		d.onError(err)
+		return
+	}
+
+	if _, err := d.dataChannel.WriteDataChannel(authenticator, false); err != nil {
+		d.onError(err)
+	}
+}
+
+// verifyAuthenticator checks the first message received from the remote. The
+// DataChannel is opened for the application on success, otherwise it is closed.
+func (d *DataChannel) verifyAuthenticator(msg DataChannelMessage) {
+	expected, err := d.authenticator(true)
+	if err != nil || msg.IsString || !hmac.Equal(expected, msg.Data) {
+		d.log.Warnf("Closing DataChannel %q: %s", d.label, ErrDataChannelAuthenticationFailed)
+		d.onError(ErrDataChannelAuthenticationFailed)
+		if err := d.Close(); err != nil {
+			d.log.Warnf("Failed to close DataChannel %q: %s", d.label, err)
+		}
+		return
+	}
+
+	d.mu.Lock()
+	d.awaitingAuthentication = false
+	d.mu.Unlock()
+	d.onOpen()
+}
@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+)
+
+const (
+	// Every chunk starts with one byte of flags followed by the size of the
+	// whole message as an uint32
+	dataChannelChunkHeaderSize = 5
+
+	dataChannelChunkFlagFirst  = 1 << 0
+	dataChannelChunkFlagLast   = 1 << 1
+	dataChannelChunkFlagString = 1 << 2
+
+	// dataChannelChunkMaxSize bounds the size of a chunk even when the remote
+	// accepts larger messages, so other DataChannels can interleave their
+	// messages and progress is reported regularly
+	dataChannelChunkMaxSize = 64 * 1024
+
+	// dataChannelChunkerDefaultMaxReceiveSize is the largest message a
+	// DataChannelChunker reassembles by default
+	dataChannelChunkerDefaultMaxReceiveSize = 16 * 1024 * 1024
+)
+
+// DataChannelChunker sends and receives messages larger than the
+// max-message-size negotiated for a DataChannel. Messages are split into
+// chunks which fit in a SCTP message, and are reassembled by the
+// DataChannelChunker of the remote. Both sides of the DataChannel have to use
+// a DataChannelChunker, and the DataChannel has to be ordered and reliable.
+type DataChannelChunker struct {
+	dataChannel *DataChannel
+
+	sendMu sync.Mutex
+
+	mu                       sync.Mutex
+	maxReceiveSize           uint32
+	onMessageHandler         func(DataChannelMessage)
+	onSendProgressHandler    func(sent, total uint64)
+	onReceiveProgressHandler func(received, total uint64)
+
+	// The message being reassembled
+	receiving       bool
+	receiveIsString bool
+	receiveSize     uint32
+	receiveBuffer   []byte
+}
+
+// NewDataChannelChunker creates a DataChannelChunker for d. It replaces the
+// OnMessage handler of d, messages are received with the OnMessage handler of
+// the DataChannelChunker instead.
+func NewDataChannelChunker(d *DataChannel) (*DataChannelChunker, error) {
+	if !d.Ordered() || d.MaxRetransmits() != nil || d.MaxPacketLifeTime() != nil {
+		return nil, ErrDataChannelChunkerNotReliable
+	}
+
+	c := &DataChannelChunker{
+		dataChannel:    d,
+		maxReceiveSize: dataChannelChunkerDefaultMaxReceiveSize,
+	}
+	d.OnMessage(c.handleChunk)
+
+	return c, nil
+}
+
+// SetMaxReceiveSize sets the size of the largest message which is reassembled.
+// Larger messages are dropped and reported to the OnError handler of the
+// DataChannel. The default is 16 MiB.
+func (c *DataChannelChunker) SetMaxReceiveSize(size uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxReceiveSize = size
+}
+
+// OnMessage sets an event handler which is invoked on a reassembled message
+func (c *DataChannelChunker) OnMessage(f func(msg DataChannelMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onMessageHandler = f
+}
+
+// OnSendProgress sets an event handler which is invoked from Send and
+// SendText after every chunk of a message was sent
+func (c *DataChannelChunker) OnSendProgress(f func(sent, total uint64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onSendProgressHandler = f
+}
+
+// OnReceiveProgress sets an event handler which is invoked after every chunk
+// of a message was received
+func (c *DataChannelChunker) OnReceiveProgress(f func(received, total uint64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onReceiveProgressHandler = f
+}
+
+// Send sends the binary message data in as many chunks as needed
+func (c *DataChannelChunker) Send(data []byte) error {
+	return c.send(data, false)
+}
+
+// SendText sends the text message s in as many chunks as needed
+func (c *DataChannelChunker) SendText(s string) error {
+	return c.send([]byte(s), true)
+}
+
+// chunkSize returns the largest payload of a chunk
+func (c *DataChannelChunker) chunkSize() int {
+	maxMessageSize := float64(sctpMaxMessageSizeUnsetValue)
+	if transport := c.dataChannel.Transport(); transport != nil {
+		maxMessageSize = transport.MaxMessageSize()
+	}
+
+	if maxMessageSize > dataChannelChunkMaxSize {
+		maxMessageSize = dataChannelChunkMaxSize
+	}
+
+	return int(maxMessageSize) - dataChannelChunkHeaderSize
+}
+
+func (c *DataChannelChunker) send(data []byte, isString bool) error {
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes", ErrDataChannelMessageTooLarge, len(data))
+	}
+
+	chunkSize := c.chunkSize()
+	if chunkSize <= 0 {
+		return fmt.Errorf("%w: max-message-size is too small for chunks", ErrDataChannelMessageTooLarge)
+	}
+
+	// The chunks of concurrent messages must not interleave
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.mu.Lock()
+	onSendProgress := c.onSendProgressHandler
+	c.mu.Unlock()
+
+	total := uint64(len(data))
+	sent := 0
+	for {
+		payload := data[sent:]
+		if len(payload) > chunkSize {
+			payload = payload[:chunkSize]
+		}
+
+		var flags byte
+		if sent == 0 {
+			flags |= dataChannelChunkFlagFirst
+		}
+		if sent+len(payload) == len(data) {
+			flags |= dataChannelChunkFlagLast
+		}
+		if isString {
+			flags |= dataChannelChunkFlagString
+		}
+
+		chunk := make([]byte, dataChannelChunkHeaderSize+len(payload))
+		chunk[0] = flags
+		binary.BigEndian.PutUint32(chunk[1:], uint32(len(data)))
+		copy(chunk[dataChannelChunkHeaderSize:], payload)
+
+		if err := c.dataChannel.Send(chunk); err != nil {
+			return err
+		}
+
+		sent += len(payload)
+		if onSendProgress != nil {
+			onSendProgress(uint64(sent), total)
+		}
+
+		if flags&dataChannelChunkFlagLast != 0 {
+			return nil
+		}
+	}
+}
+
+func (c *DataChannelChunker) handleChunk(msg DataChannelMessage) {
+	c.mu.Lock()
+	message, progress, err := c.reassemble(msg.Data)
+	onMessage := c.onMessageHandler
+	onReceiveProgress := c.onReceiveProgressHandler
+	received, total := uint64(len(c.receiveBuffer)), uint64(c.receiveSize)
+	c.mu.Unlock()
+
+	if err != nil {
+		c.dataChannel.log.Warnf("Dropping chunked message on DataChannel %s: %v", c.dataChannel.Label(), err)
+		c.dataChannel.onError(err)
+		return
+	}
+
+	if progress && onReceiveProgress != nil {
+		if message != nil {
+			// The reassembly state was already reset
+			received, total = uint64(len(message.Data)), uint64(len(message.Data))
+		}
+		onReceiveProgress(received, total)
+	}
+
+	if message != nil && onMessage != nil {
+		onMessage(*message)
+	}
+}
+
+// reassemble adds a chunk to the message being received, and returns the
+// message once it is complete. c.mu must be held.
+func (c *DataChannelChunker) reassemble(chunk []byte) (message *DataChannelMessage, progress bool, err error) {
+	if len(chunk) < dataChannelChunkHeaderSize {
+		c.resetReceive()
+		return nil, false, fmt.Errorf("%w: chunk of %d bytes", errDataChannelChunkInvalid, len(chunk))
+	}
+
+	flags := chunk[0]
+	size := binary.BigEndian.Uint32(chunk[1:])
+	payload := chunk[dataChannelChunkHeaderSize:]
+
+	if flags&dataChannelChunkFlagFirst != 0 {
+		if size > c.maxReceiveSize {
+			c.resetReceive()
+			return nil, false, fmt.Errorf("%w: %d bytes, limit is %d", ErrDataChannelMessageTooLarge, size, c.maxReceiveSize)
+		}
+
+		c.receiving = true
+		c.receiveIsString = flags&dataChannelChunkFlagString != 0
+		c.receiveSize = size
+		// The declared size is only a limit, the buffer grows with the chunks
+		// which are actually received
+		c.receiveBuffer = make([]byte, 0, len(payload))
+	} else if !c.receiving || size != c.receiveSize {
+		c.resetReceive()
+		return nil, false, fmt.Errorf("%w: unexpected continuation", errDataChannelChunkInvalid)
+	}
+
+	if uint64(len(c.receiveBuffer))+uint64(len(payload)) > uint64(c.receiveSize) {
+		c.resetReceive()
+		return nil, false, fmt.Errorf("%w: chunks exceed the message size", errDataChannelChunkInvalid)
+	}
+	c.receiveBuffer = append(c.receiveBuffer, payload...)
+
+	if flags&dataChannelChunkFlagLast == 0 {
+		return nil, true, nil
+	}
+
+	if len(c.receiveBuffer) != int(c.receiveSize) {
+		c.resetReceive()
+		return nil, false, fmt.Errorf("%w: message is truncated", errDataChannelChunkInvalid)
+	}
+
+	message = &DataChannelMessage{IsString: c.receiveIsString, Data: c.receiveBuffer}
+	c.resetReceive()
+
+	return message, true, nil
+}
+
+// resetReceive drops the message being reassembled. c.mu must be held.
+func (c *DataChannelChunker) resetReceive() {
+	c.receiving = false
+	c.receiveIsString = false
+	c.receiveSize = 0
+	c.receiveBuffer = nil
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataChannelChunker(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxMessageSize = 4096
+
+	message := make([]byte, 100*1024)
+	_, err := rand.Read(message)
+	assert.NoError(t, err)
+
+	offerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerSettings := SettingEngine{}
+	answerSettings.SetSCTPMaxMessageSize(maxMessageSize)
+	answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	done := make(chan bool)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		chunker, chunkerErr := NewDataChannelChunker(d)
+		assert.NoError(t, chunkerErr)
+
+		var lastReceived uint64
+		chunker.OnReceiveProgress(func(received, total uint64) {
+			assert.Greater(t, received, lastReceived)
+			assert.Equal(t, uint64(len(message)), total)
+			lastReceived = received
+		})
+		chunker.OnMessage(func(msg DataChannelMessage) {
+			assert.False(t, msg.IsString)
+			assert.True(t, bytes.Equal(message, msg.Data))
+			assert.Equal(t, uint64(len(message)), lastReceived)
+			done <- true
+		})
+	})
+
+	d, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	chunker, err := NewDataChannelChunker(d)
+	assert.NoError(t, err)
+
+	var progressCalls int
+	chunker.OnSendProgress(func(sent, total uint64) {
+		progressCalls++
+		assert.Equal(t, uint64(len(message)), total)
+	})
+
+	d.OnOpen(func() {
+		assert.Equal(t, float64(maxMessageSize), offerPC.SCTP().MaxMessageSize())
+		assert.NoError(t, chunker.Send(message))
+		assert.Equal(t, (len(message)+maxMessageSize-dataChannelChunkHeaderSize-1)/(maxMessageSize-dataChannelChunkHeaderSize), progressCalls)
+	})
+
+	offer, err := offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(offer.SDP, "a=max-message-size:65536"))
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	closePair(t, offerPC, answerPC, done)
+}
+
+func TestDataChannelChunker_NotReliable(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	ordered := false
+	d, err := pc.CreateDataChannel(expectedLabel, &DataChannelInit{Ordered: &ordered})
+	assert.NoError(t, err)
+
+	_, err = NewDataChannelChunker(d)
+	assert.ErrorIs(t, err, ErrDataChannelChunkerNotReliable)
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestDataChannelChunker_Reassemble(t *testing.T) {
+	chunk := func(flags byte, size uint32, payload string) []byte {
+		return append([]byte{flags, byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}, payload...)
+	}
+
+	for i, testCase := range []struct {
+		chunks  [][]byte
+		message string
+		err     error
+	}{
+		{[][]byte{chunk(dataChannelChunkFlagFirst|dataChannelChunkFlagLast|dataChannelChunkFlagString, 5, "hello")}, "hello", nil},
+		{[][]byte{chunk(dataChannelChunkFlagFirst, 5, "he"), chunk(0, 5, "ll"), chunk(dataChannelChunkFlagLast, 5, "o")}, "hello", nil},
+		{[][]byte{{dataChannelChunkFlagFirst}}, "", errDataChannelChunkInvalid},
+		{[][]byte{chunk(dataChannelChunkFlagLast, 5, "hello")}, "", errDataChannelChunkInvalid},
+		{[][]byte{chunk(dataChannelChunkFlagFirst, 3, "hello")}, "", errDataChannelChunkInvalid},
+		{[][]byte{chunk(dataChannelChunkFlagFirst, 5, "he"), chunk(dataChannelChunkFlagLast, 5, "ll")}, "", errDataChannelChunkInvalid},
+		{[][]byte{chunk(dataChannelChunkFlagFirst, 1024, "he")}, "", ErrDataChannelMessageTooLarge},
+	} {
+		c := &DataChannelChunker{maxReceiveSize: 512}
+
+		var (
+			message *DataChannelMessage
+			err     error
+		)
+		for _, data := range testCase.chunks {
+			if message, _, err = c.reassemble(data); err != nil {
+				break
+			}
+		}
+
+		if testCase.err != nil {
+			assert.ErrorIs(t, err, testCase.err, "testCase: %d %v", i, testCase)
+			assert.False(t, c.receiving, "testCase: %d %v", i, testCase)
+			continue
+		}
+
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		if assert.NotNil(t, message, "testCase: %d %v", i, testCase) {
+			assert.Equal(t, testCase.message, string(message.Data), "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestDataChannelChunker_ReassembleDoesNotPreallocate(t *testing.T) {
+	c := &DataChannelChunker{maxReceiveSize: dataChannelChunkerDefaultMaxReceiveSize}
+
+	// A first chunk may declare the largest size accepted, the buffer must
+	// only grow with the chunks which are actually received
+	_, progress, err := c.reassemble([]byte{dataChannelChunkFlagFirst, 0x01, 0x00, 0x00, 0x00, 'a'})
+	assert.NoError(t, err)
+	assert.True(t, progress)
+	assert.True(t, c.receiving)
+	assert.Less(t, cap(c.receiveBuffer), 1024)
+}
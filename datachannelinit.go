@@ -33,4 +33,8 @@ type DataChannelInit struct {
 
 	// ID overrides the default selection of ID for this channel.
 	ID *uint16
+
+	// Priority of the channel, PriorityTypeLow by default. Messages of higher
+	// priority channels sharing the SCTP association are sent first.
+	Priority *PriorityType
 }
@@ -12,4 +12,7 @@ type DataChannelParameters struct {
 	MaxPacketLifeTime *uint16 `json:"maxPacketLifeTime"`
 	MaxRetransmits    *uint16 `json:"maxRetransmits"`
 	Negotiated        bool    `json:"negotiated"`
+
+	// Priority defaults to PriorityTypeLow
+	Priority PriorityType `json:"priority"`
 }
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pion/datachannel"
+)
+
+// The priorities carried in the DATA_CHANNEL_OPEN message, RFC 8832 Section 5.1
+func (p PriorityType) channelPriority() uint16 {
+	switch p {
+	case PriorityTypeVeryLow:
+		return datachannel.ChannelPriorityBelowNormal
+	case PriorityTypeMedium:
+		return datachannel.ChannelPriorityHigh
+	case PriorityTypeHigh:
+		return datachannel.ChannelPriorityExtraHigh
+	default:
+		return datachannel.ChannelPriorityNormal
+	}
+}
+
+// priorityTypeFromChannelPriority maps the priority of a DATA_CHANNEL_OPEN
+// message to the closest PriorityType
+func priorityTypeFromChannelPriority(priority uint16) PriorityType {
+	switch {
+	case priority <= datachannel.ChannelPriorityBelowNormal:
+		return PriorityTypeVeryLow
+	case priority <= datachannel.ChannelPriorityNormal:
+		return PriorityTypeLow
+	case priority <= datachannel.ChannelPriorityHigh:
+		return PriorityTypeMedium
+	default:
+		return PriorityTypeHigh
+	}
+}
+
+type scheduledMessage struct {
+	data     []byte
+	isString bool
+}
+
+// dataChannelSchedulerMaxBufferedAmount is how much data a DataChannel may
+// have buffered in SCTP while a higher priority DataChannel is open. SCTP sends
+// the buffered messages of all streams in order, so this bounds how long a
+// higher priority message waits behind lower priority ones.
+const dataChannelSchedulerMaxBufferedAmount = 256 * 1024
+
+// dataChannelScheduler implements the priority stream scheduler of RFC 8260
+// Section 3.2 on top of an SCTP association. A DataChannel's messages are
+// held back while a DataChannel of a higher priority has more than its
+// BufferedAmountLowThreshold buffered, or while it has more than
+// dataChannelSchedulerMaxBufferedAmount buffered itself and a higher priority
+// DataChannel is open. Held back messages are queued here and sent once the
+// DataChannels they wait for drained, so a bulk transfer can't delay a control
+// channel by filling the association's send buffer. DataChannels which all
+// have the same priority are never held back.
+type dataChannelScheduler struct {
+	mu     sync.Mutex
+	queues map[*DataChannel][]scheduledMessage
+
+	transport *SCTPTransport
+}
+
+func newDataChannelScheduler(transport *SCTPTransport) *dataChannelScheduler {
+	return &dataChannelScheduler{
+		queues:    map[*DataChannel][]scheduledMessage{},
+		transport: transport,
+	}
+}
+
+// send writes the message right away if d isn't held back, and queues it
+// otherwise
+func (s *dataChannelScheduler) send(d *DataChannel, data []byte, isString bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queues[d]) == 0 && !s.blocked(d) {
+		return d.write(data, isString)
+	}
+
+	// The message may be sent after send returned
+	s.queues[d] = append(s.queues[d], scheduledMessage{append([]byte{}, data...), isString})
+	s.drainLocked()
+
+	return nil
+}
+
+// drain sends the queued messages which aren't held back anymore, highest
+// priority first
+func (s *dataChannelScheduler) drain() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.drainLocked()
+}
+
+func (s *dataChannelScheduler) drainLocked() {
+	queued := make([]*DataChannel, 0, len(s.queues))
+	for d := range s.queues {
+		if d.ReadyState() != DataChannelStateOpen && !d.remoteClosing.get() {
+			delete(s.queues, d)
+			continue
+		}
+		queued = append(queued, d)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].Priority() > queued[j].Priority()
+	})
+
+	for _, d := range queued {
+		for len(s.queues[d]) > 0 && !s.blocked(d) {
+			msg := s.queues[d][0]
+			s.queues[d] = s.queues[d][1:]
+			if err := d.write(msg.data, msg.isString); err != nil {
+				d.log.Errorf("Failed to send scheduled message on DataChannel %s: %v", d.Label(), err)
+				d.onError(err)
+			}
+		}
+		if len(s.queues[d]) == 0 {
+			delete(s.queues, d)
+		}
+	}
+}
+
+// queuedAmount returns the number of bytes queued for d
+func (s *dataChannelScheduler) queuedAmount(d *DataChannel) (amount uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, msg := range s.queues[d] {
+		amount += uint64(len(msg.data))
+	}
+
+	return amount
+}
+
+// blocked returns true if the messages of d have to be held back. s.mu must
+// be held.
+func (s *dataChannelScheduler) blocked(d *DataChannel) bool {
+	priority := d.Priority()
+
+	s.transport.lock.RLock()
+	dataChannels := append([]*DataChannel{}, s.transport.dataChannels...)
+	s.transport.lock.RUnlock()
+
+	higherPriorityOpen := false
+	for _, other := range dataChannels {
+		if other == d || other.Priority() <= priority || other.ReadyState() != DataChannelStateOpen {
+			continue
+		}
+		higherPriorityOpen = true
+
+		if len(s.queues[other]) > 0 || bufferedAboveThreshold(other, 0) {
+			return true
+		}
+	}
+
+	return higherPriorityOpen && bufferedAboveThreshold(d, dataChannelSchedulerMaxBufferedAmount)
+}
+
+// bufferedAboveThreshold returns true if more than the BufferedAmountLowThreshold
+// of d, and at least minThreshold, is buffered in SCTP. Staying at or above
+// the BufferedAmountLowThreshold guarantees the OnBufferedAmountLow event
+// which drains the scheduler fires once d is unblocked.
+func bufferedAboveThreshold(d *DataChannel, minThreshold uint64) bool {
+	d.mu.RLock()
+	dc := d.dataChannel
+	d.mu.RUnlock()
+	if dc == nil {
+		return false
+	}
+
+	threshold := dc.BufferedAmountLowThreshold()
+	if threshold < minThreshold {
+		threshold = minThreshold
+	}
+
+	return dc.BufferedAmount() > threshold
+}
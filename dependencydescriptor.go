@@ -0,0 +1,782 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// DependencyDescriptorURI is the URI of the dependency descriptor RTP
+	// header extension, see ConfigureDependencyDescriptor
+	DependencyDescriptorURI = "https://aomediacodec.github.io/av1-rtp-spec/#dependency-descriptor-rtp-header-extension"
+
+	dependencyDescriptorMandatorySize   = 3
+	dependencyDescriptorMaxTemplates    = 64
+	dependencyDescriptorMaxDecodeTarget = 32
+	dependencyDescriptorMaxTemplateDiff = 16
+	dependencyDescriptorMaxFrameDiff    = 1 << 12
+	dependencyDescriptorMaxChainDiff    = 255
+
+	// av1OBUSequenceHeader is the type of the sequence header OBU
+	av1OBUSequenceHeader = 1
+)
+
+// DecodeTargetIndication tells how a frame is used by a decode target of a
+// DependencyDescriptor
+type DecodeTargetIndication int
+
+const (
+	// DecodeTargetNotPresent means the frame isn't part of the decode target
+	DecodeTargetNotPresent DecodeTargetIndication = iota
+
+	// DecodeTargetDiscardable means no frame of the decode target depends on
+	// the frame
+	DecodeTargetDiscardable
+
+	// DecodeTargetSwitch means the decode target can be switched to at the
+	// frame
+	DecodeTargetSwitch
+
+	// DecodeTargetRequired means the frame is needed to decode the decode
+	// target
+	DecodeTargetRequired
+)
+
+// This is done this way because of a linter.
+const (
+	decodeTargetNotPresentStr   = "not-present"
+	decodeTargetDiscardableStr  = "discardable"
+	decodeTargetSwitchStr       = "switch"
+	decodeTargetRequiredStr     = "required"
+	decodeTargetIndicationCount = 4
+)
+
+func (i DecodeTargetIndication) String() string {
+	switch i {
+	case DecodeTargetNotPresent:
+		return decodeTargetNotPresentStr
+	case DecodeTargetDiscardable:
+		return decodeTargetDiscardableStr
+	case DecodeTargetSwitch:
+		return decodeTargetSwitchStr
+	case DecodeTargetRequired:
+		return decodeTargetRequiredStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// FrameDependencyTemplate describes the layer of a frame and its dependencies.
+// It is a template of a FrameDependencyStructure, or the resolved
+// dependencies of the frame of a DependencyDescriptor.
+type FrameDependencyTemplate struct {
+	SpatialID  int
+	TemporalID int
+
+	// DecodeTargetIndications has one indication per decode target
+	DecodeTargetIndications []DecodeTargetIndication
+
+	// FrameDiffs are the differences between the frame number of the frame
+	// and the frame numbers of the frames it depends on
+	FrameDiffs []int
+
+	// ChainDiffs has one difference per chain, between the frame number of
+	// the frame and the previous frame of the chain
+	ChainDiffs []int
+}
+
+// RenderResolution is the resolution of a spatial layer
+type RenderResolution struct {
+	Width  int
+	Height int
+}
+
+// FrameDependencyStructure describes the decode targets, chains and frame
+// templates a DependencyDescriptor refers to. It is attached to the
+// descriptors of keyframes.
+type FrameDependencyStructure struct {
+	// StructureID is the template ID of the first template
+	StructureID      int
+	NumDecodeTargets int
+	NumChains        int
+
+	// DecodeTargetProtectedByChain has the chain protecting each decode
+	// target if NumChains isn't 0
+	DecodeTargetProtectedByChain []int
+
+	// Resolutions has the resolution of every spatial layer, or is empty
+	Resolutions []RenderResolution
+
+	// Templates are sorted by spatial ID and then temporal ID
+	Templates []FrameDependencyTemplate
+}
+
+// DependencyDescriptor is the content of the dependency descriptor RTP header
+// extension, which lets SFUs select the layers of a SVC or simulcast stream,
+// like AV1, without parsing its payload.
+// https://aomediacodec.github.io/av1-rtp-spec/#dependency-descriptor-rtp-header-extension
+type DependencyDescriptor struct {
+	FirstPacketInFrame bool
+	LastPacketInFrame  bool
+	FrameNumber        uint16
+
+	// FrameDependencies are the layer and dependencies of the frame, resolved
+	// from its template
+	FrameDependencies FrameDependencyTemplate
+
+	// Resolution is the resolution of the spatial layer of the frame, if the
+	// structure has resolutions
+	Resolution *RenderResolution
+
+	// AttachedStructure is the structure attached to the packet, it applies
+	// to the following packets
+	AttachedStructure *FrameDependencyStructure
+
+	// ActiveDecodeTargetsBitmask has a bit set for every decode target the
+	// sender sends, it is nil when it wasn't sent
+	ActiveDecodeTargetsBitmask *uint32
+}
+
+// dependencyDescriptorReader reads the bit fields of a dependency descriptor
+type dependencyDescriptorReader struct {
+	buf       []byte
+	pos       int
+	truncated bool
+}
+
+func (r *dependencyDescriptorReader) read(bits int) uint32 {
+	var value uint32
+	for i := 0; i < bits; i++ {
+		if r.pos >= len(r.buf)*8 {
+			r.truncated = true
+			return 0
+		}
+		value = value<<1 | uint32(r.buf[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+
+	return value
+}
+
+func (r *dependencyDescriptorReader) readBool() bool {
+	return r.read(1) == 1
+}
+
+// readNonSymmetric reads a value in [0, n) coded with ns(n)
+func (r *dependencyDescriptorReader) readNonSymmetric(n uint32) uint32 {
+	w := 0
+	for x := n; x != 0; x >>= 1 {
+		w++
+	}
+	m := uint32(1)<<w - n
+
+	v := r.read(w - 1)
+	if v < m {
+		return v
+	}
+
+	return v<<1 - m + r.read(1)
+}
+
+// dependencyDescriptorWriter writes the bit fields of a dependency descriptor
+type dependencyDescriptorWriter struct {
+	buf []byte
+	pos int
+}
+
+func (w *dependencyDescriptorWriter) write(value uint32, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.pos%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[w.pos/8] |= byte(value>>i&1) << (7 - w.pos%8)
+		w.pos++
+	}
+}
+
+func (w *dependencyDescriptorWriter) writeBool(value bool) {
+	if value {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+}
+
+// writeNonSymmetric writes a value in [0, n) coded with ns(n)
+func (w *dependencyDescriptorWriter) writeNonSymmetric(value, n uint32) {
+	bits := 0
+	for x := n; x != 0; x >>= 1 {
+		bits++
+	}
+	m := uint32(1)<<bits - n
+
+	if value < m {
+		w.write(value, bits-1)
+		return
+	}
+	w.write((value+m)>>1, bits-1)
+	w.write((value+m)&1, 1)
+}
+
+// maxSpatialID returns the highest spatial ID of the templates
+func (s *FrameDependencyStructure) maxSpatialID() int {
+	maxSpatialID := 0
+	for _, template := range s.Templates {
+		if template.SpatialID > maxSpatialID {
+			maxSpatialID = template.SpatialID
+		}
+	}
+
+	return maxSpatialID
+}
+
+func (s *FrameDependencyStructure) validate() error { //nolint:cyclop
+	switch {
+	case s.StructureID < 0 || s.StructureID >= dependencyDescriptorMaxTemplates:
+		return fmt.Errorf("%w: structure ID is out of range", errDependencyDescriptorInvalid)
+	case s.NumDecodeTargets < 1 || s.NumDecodeTargets > dependencyDescriptorMaxDecodeTarget:
+		return fmt.Errorf("%w: number of decode targets is out of range", errDependencyDescriptorInvalid)
+	case s.NumChains < 0 || s.NumChains > s.NumDecodeTargets:
+		return fmt.Errorf("%w: number of chains is out of range", errDependencyDescriptorInvalid)
+	case s.NumChains != 0 && len(s.DecodeTargetProtectedByChain) != s.NumDecodeTargets:
+		return fmt.Errorf("%w: every decode target must be protected by a chain", errDependencyDescriptorInvalid)
+	case len(s.Templates) == 0 || len(s.Templates) > dependencyDescriptorMaxTemplates:
+		return fmt.Errorf("%w: number of templates is out of range", errDependencyDescriptorInvalid)
+	case len(s.Resolutions) != 0 && len(s.Resolutions) != s.maxSpatialID()+1:
+		return fmt.Errorf("%w: every spatial layer must have a resolution", errDependencyDescriptorInvalid)
+	}
+
+	for _, chain := range s.DecodeTargetProtectedByChain {
+		if chain < 0 || chain >= s.NumChains {
+			return fmt.Errorf("%w: protecting chain is out of range", errDependencyDescriptorInvalid)
+		}
+	}
+
+	for _, resolution := range s.Resolutions {
+		if resolution.Width < 1 || resolution.Width > 1<<16 || resolution.Height < 1 || resolution.Height > 1<<16 {
+			return fmt.Errorf("%w: resolution is out of range", errDependencyDescriptorInvalid)
+		}
+	}
+
+	for i, template := range s.Templates {
+		if err := s.validateFrame(template, dependencyDescriptorMaxTemplateDiff, 15); err != nil {
+			return fmt.Errorf("%w of template %d", err, i)
+		}
+
+		if i == 0 {
+			if template.SpatialID != 0 || template.TemporalID != 0 {
+				return fmt.Errorf("%w: first template must be of the base layer", errDependencyDescriptorInvalid)
+			}
+			continue
+		}
+
+		previous := s.Templates[i-1]
+		sameLayer := template.SpatialID == previous.SpatialID && template.TemporalID == previous.TemporalID
+		nextTemporal := template.SpatialID == previous.SpatialID && template.TemporalID == previous.TemporalID+1
+		nextSpatial := template.SpatialID == previous.SpatialID+1 && template.TemporalID == 0
+		if !sameLayer && !nextTemporal && !nextSpatial {
+			return fmt.Errorf("%w: templates aren't sorted by layer", errDependencyDescriptorInvalid)
+		}
+	}
+
+	return nil
+}
+
+// validateFrame checks that the dependencies of a frame or template match
+// the structure
+func (s *FrameDependencyStructure) validateFrame(frame FrameDependencyTemplate, maxFrameDiff, maxChainDiff int) error {
+	if len(frame.DecodeTargetIndications) != s.NumDecodeTargets || len(frame.ChainDiffs) != s.NumChains {
+		return fmt.Errorf("%w: decode target indications or chain diffs don't match the structure", errDependencyDescriptorInvalid)
+	}
+
+	for _, indication := range frame.DecodeTargetIndications {
+		if indication < 0 || indication >= decodeTargetIndicationCount {
+			return fmt.Errorf("%w: decode target indication is out of range", errDependencyDescriptorInvalid)
+		}
+	}
+
+	for _, diff := range frame.FrameDiffs {
+		if diff < 1 || diff > maxFrameDiff {
+			return fmt.Errorf("%w: frame diff is out of range", errDependencyDescriptorInvalid)
+		}
+	}
+
+	for _, diff := range frame.ChainDiffs {
+		if diff < 0 || diff > maxChainDiff {
+			return fmt.Errorf("%w: chain diff is out of range", errDependencyDescriptorInvalid)
+		}
+	}
+
+	return nil
+}
+
+// templateIndex returns the index of the template of frame, and the parts of
+// frame which differ from it
+func (s *FrameDependencyStructure) templateIndex(frame FrameDependencyTemplate) (index int, customDTIs, customFrameDiffs, customChains bool, err error) {
+	index = -1
+	for i, template := range s.Templates {
+		if template.SpatialID != frame.SpatialID || template.TemporalID != frame.TemporalID {
+			continue
+		}
+
+		dtis := equalDecodeTargetIndications(template.DecodeTargetIndications, frame.DecodeTargetIndications)
+		frameDiffs := equalInts(template.FrameDiffs, frame.FrameDiffs)
+		chains := equalInts(template.ChainDiffs, frame.ChainDiffs)
+		if dtis && frameDiffs && chains {
+			return i, false, false, false, nil
+		}
+
+		if index == -1 {
+			index, customDTIs, customFrameDiffs, customChains = i, !dtis, !frameDiffs, !chains
+		}
+	}
+
+	if index == -1 {
+		return 0, false, false, false, fmt.Errorf("%w: no template of the layer of the frame", errDependencyDescriptorInvalid)
+	}
+
+	return index, customDTIs, customFrameDiffs, customChains, nil
+}
+
+func equalDecodeTargetIndications(a, b []DecodeTargetIndication) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Marshal serializes the descriptor as the payload of the header extension.
+// structure is the structure which was last attached, it is ignored if the
+// descriptor has an AttachedStructure.
+func (d DependencyDescriptor) Marshal(structure *FrameDependencyStructure) ([]byte, error) { //nolint:cyclop
+	if d.AttachedStructure != nil {
+		structure = d.AttachedStructure
+		if err := structure.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if structure == nil {
+		return nil, errDependencyDescriptorNoStructure
+	}
+
+	frame := d.FrameDependencies
+	if err := structure.validateFrame(frame, dependencyDescriptorMaxFrameDiff, dependencyDescriptorMaxChainDiff); err != nil {
+		return nil, err
+	}
+
+	index, customDTIs, customFrameDiffs, customChains, err := structure.templateIndex(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &dependencyDescriptorWriter{}
+	w.writeBool(d.FirstPacketInFrame)
+	w.writeBool(d.LastPacketInFrame)
+	w.write(uint32((structure.StructureID+index)%dependencyDescriptorMaxTemplates), 6)
+	w.write(uint32(d.FrameNumber), 16)
+
+	if d.AttachedStructure == nil && d.ActiveDecodeTargetsBitmask == nil && !customDTIs && !customFrameDiffs && !customChains {
+		return w.buf, nil
+	}
+
+	w.writeBool(d.AttachedStructure != nil)
+	w.writeBool(d.ActiveDecodeTargetsBitmask != nil)
+	w.writeBool(customDTIs)
+	w.writeBool(customFrameDiffs)
+	w.writeBool(customChains)
+	if d.AttachedStructure != nil {
+		writeFrameDependencyStructure(w, structure)
+	}
+	if d.ActiveDecodeTargetsBitmask != nil {
+		w.write(*d.ActiveDecodeTargetsBitmask, structure.NumDecodeTargets)
+	}
+
+	if customDTIs {
+		for _, indication := range frame.DecodeTargetIndications {
+			w.write(uint32(indication), 2)
+		}
+	}
+	if customFrameDiffs {
+		for _, diff := range frame.FrameDiffs {
+			// The size of the diff in nibbles
+			size := 1
+			for (diff-1)>>(4*size) != 0 {
+				size++
+			}
+			w.write(uint32(size), 2)
+			w.write(uint32(diff-1), 4*size)
+		}
+		w.write(0, 2)
+	}
+	if customChains {
+		for _, diff := range frame.ChainDiffs {
+			w.write(uint32(diff), 8)
+		}
+	}
+
+	return w.buf, nil
+}
+
+func writeFrameDependencyStructure(w *dependencyDescriptorWriter, s *FrameDependencyStructure) {
+	w.write(uint32(s.StructureID), 6)
+	w.write(uint32(s.NumDecodeTargets-1), 5)
+
+	for i := 1; i <= len(s.Templates); i++ {
+		switch {
+		case i == len(s.Templates):
+			w.write(3, 2)
+		case s.Templates[i].SpatialID != s.Templates[i-1].SpatialID:
+			w.write(2, 2)
+		case s.Templates[i].TemporalID != s.Templates[i-1].TemporalID:
+			w.write(1, 2)
+		default:
+			w.write(0, 2)
+		}
+	}
+
+	for _, template := range s.Templates {
+		for _, indication := range template.DecodeTargetIndications {
+			w.write(uint32(indication), 2)
+		}
+	}
+
+	for _, template := range s.Templates {
+		for _, diff := range template.FrameDiffs {
+			w.write(1, 1)
+			w.write(uint32(diff-1), 4)
+		}
+		w.write(0, 1)
+	}
+
+	w.writeNonSymmetric(uint32(s.NumChains), uint32(s.NumDecodeTargets+1))
+	if s.NumChains != 0 {
+		for _, chain := range s.DecodeTargetProtectedByChain {
+			w.writeNonSymmetric(uint32(chain), uint32(s.NumChains))
+		}
+		for _, template := range s.Templates {
+			for _, diff := range template.ChainDiffs {
+				w.write(uint32(diff), 4)
+			}
+		}
+	}
+
+	w.writeBool(len(s.Resolutions) != 0)
+	for _, resolution := range s.Resolutions {
+		w.write(uint32(resolution.Width-1), 16)
+		w.write(uint32(resolution.Height-1), 16)
+	}
+}
+
+// Unmarshal parses the payload of the header extension. structure is the
+// structure which was last attached, it is only needed if the descriptor has
+// no AttachedStructure.
+func (d *DependencyDescriptor) Unmarshal(buf []byte, structure *FrameDependencyStructure) error { //nolint:cyclop,gocognit
+	*d = DependencyDescriptor{}
+	if len(buf) < dependencyDescriptorMandatorySize {
+		return errDependencyDescriptorTooShort
+	}
+
+	r := &dependencyDescriptorReader{buf: buf}
+	d.FirstPacketInFrame = r.readBool()
+	d.LastPacketInFrame = r.readBool()
+	templateID := int(r.read(6))
+	d.FrameNumber = uint16(r.read(16))
+
+	var customDTIs, customFrameDiffs, customChains bool
+	if len(buf) > dependencyDescriptorMandatorySize {
+		structurePresent := r.readBool()
+		activeDecodeTargetsPresent := r.readBool()
+		customDTIs = r.readBool()
+		customFrameDiffs = r.readBool()
+		customChains = r.readBool()
+
+		if structurePresent {
+			attached := readFrameDependencyStructure(r)
+			if r.truncated {
+				return errDependencyDescriptorTooShort
+			}
+			d.AttachedStructure, structure = attached, attached
+
+			bitmask := uint32(1)<<attached.NumDecodeTargets - 1
+			d.ActiveDecodeTargetsBitmask = &bitmask
+		}
+
+		if activeDecodeTargetsPresent {
+			if structure == nil {
+				return errDependencyDescriptorNoStructure
+			}
+			bitmask := r.read(structure.NumDecodeTargets)
+			d.ActiveDecodeTargetsBitmask = &bitmask
+		}
+	}
+
+	if structure == nil {
+		return errDependencyDescriptorNoStructure
+	}
+
+	index := (templateID + dependencyDescriptorMaxTemplates - structure.StructureID) % dependencyDescriptorMaxTemplates
+	if index >= len(structure.Templates) {
+		return fmt.Errorf("%w: unknown template ID %d", errDependencyDescriptorInvalid, templateID)
+	}
+	template := structure.Templates[index]
+
+	frame := FrameDependencyTemplate{SpatialID: template.SpatialID, TemporalID: template.TemporalID}
+	if customDTIs {
+		frame.DecodeTargetIndications = make([]DecodeTargetIndication, structure.NumDecodeTargets)
+		for i := range frame.DecodeTargetIndications {
+			frame.DecodeTargetIndications[i] = DecodeTargetIndication(r.read(2))
+		}
+	} else {
+		frame.DecodeTargetIndications = append([]DecodeTargetIndication{}, template.DecodeTargetIndications...)
+	}
+
+	if customFrameDiffs {
+		frame.FrameDiffs = []int{}
+		for size := int(r.read(2)); size != 0 && !r.truncated; size = int(r.read(2)) {
+			frame.FrameDiffs = append(frame.FrameDiffs, int(r.read(4*size))+1)
+		}
+	} else {
+		frame.FrameDiffs = append([]int{}, template.FrameDiffs...)
+	}
+
+	if customChains {
+		frame.ChainDiffs = make([]int, structure.NumChains)
+		for i := range frame.ChainDiffs {
+			frame.ChainDiffs[i] = int(r.read(8))
+		}
+	} else {
+		frame.ChainDiffs = append([]int{}, template.ChainDiffs...)
+	}
+
+	if r.truncated {
+		return errDependencyDescriptorTooShort
+	}
+	d.FrameDependencies = frame
+
+	if frame.SpatialID < len(structure.Resolutions) {
+		resolution := structure.Resolutions[frame.SpatialID]
+		d.Resolution = &resolution
+	}
+
+	return nil
+}
+
+func readFrameDependencyStructure(r *dependencyDescriptorReader) *FrameDependencyStructure {
+	s := &FrameDependencyStructure{
+		StructureID:      int(r.read(6)),
+		NumDecodeTargets: int(r.read(5)) + 1,
+	}
+
+	spatialID, temporalID := 0, 0
+	for !r.truncated && len(s.Templates) < dependencyDescriptorMaxTemplates {
+		s.Templates = append(s.Templates, FrameDependencyTemplate{SpatialID: spatialID, TemporalID: temporalID})
+
+		nextLayer := r.read(2)
+		if nextLayer == 3 {
+			break
+		}
+		switch nextLayer {
+		case 1:
+			temporalID++
+		case 2:
+			spatialID, temporalID = spatialID+1, 0
+		}
+	}
+
+	for i := range s.Templates {
+		s.Templates[i].DecodeTargetIndications = make([]DecodeTargetIndication, s.NumDecodeTargets)
+		for j := range s.Templates[i].DecodeTargetIndications {
+			s.Templates[i].DecodeTargetIndications[j] = DecodeTargetIndication(r.read(2))
+		}
+	}
+
+	for i := range s.Templates {
+		s.Templates[i].FrameDiffs = []int{}
+		for !r.truncated && r.readBool() {
+			s.Templates[i].FrameDiffs = append(s.Templates[i].FrameDiffs, int(r.read(4))+1)
+		}
+	}
+
+	s.NumChains = int(r.readNonSymmetric(uint32(s.NumDecodeTargets + 1)))
+	for i := range s.Templates {
+		s.Templates[i].ChainDiffs = []int{}
+	}
+	if s.NumChains != 0 {
+		s.DecodeTargetProtectedByChain = make([]int, s.NumDecodeTargets)
+		for i := range s.DecodeTargetProtectedByChain {
+			s.DecodeTargetProtectedByChain[i] = int(r.readNonSymmetric(uint32(s.NumChains)))
+		}
+		for i := range s.Templates {
+			s.Templates[i].ChainDiffs = make([]int, s.NumChains)
+			for j := range s.Templates[i].ChainDiffs {
+				s.Templates[i].ChainDiffs[j] = int(r.read(4))
+			}
+		}
+	}
+
+	if r.readBool() {
+		s.Resolutions = make([]RenderResolution, s.maxSpatialID()+1)
+		for i := range s.Resolutions {
+			s.Resolutions[i].Width = int(r.read(16)) + 1
+			s.Resolutions[i].Height = int(r.read(16)) + 1
+		}
+	}
+
+	return s
+}
+
+// DependencyDescriptor returns the dependency descriptor header extension of
+// a packet of the track, and false if it has none or it wasn't negotiated.
+// The structure attached to the descriptors of keyframes is kept by the
+// track to parse the descriptors of the following packets, so they must be
+// passed in the order they were read.
+func (t *TrackRemote) DependencyDescriptor(header *rtp.Header) (DependencyDescriptor, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	descriptor := DependencyDescriptor{}
+	id := headerExtensionID(t.params.HeaderExtensions, DependencyDescriptorURI)
+	if id == 0 {
+		return descriptor, false, nil
+	}
+
+	payload := header.GetExtension(uint8(id))
+	if payload == nil {
+		return descriptor, false, nil
+	}
+
+	if err := descriptor.Unmarshal(payload, t.dependencyStructure); err != nil {
+		return descriptor, false, err
+	}
+	if descriptor.AttachedStructure != nil {
+		t.dependencyStructure = descriptor.AttachedStructure
+	}
+
+	return descriptor, true, nil
+}
+
+// av1DependencyDescriptorSender writes the dependency descriptors of an AV1
+// stream without layers, sent by a TrackLocalStaticSample. Keyframes are the
+// temporal units starting with a sequence header, like encoders emit them.
+type av1DependencyDescriptorSender struct {
+	frameNumber uint16
+	started     bool
+}
+
+// av1SingleLayerStructure is the structure of an AV1 stream without layers,
+// with a template for keyframes and one for the other frames
+func av1SingleLayerStructure() *FrameDependencyStructure {
+	return &FrameDependencyStructure{
+		NumDecodeTargets:             1,
+		NumChains:                    1,
+		DecodeTargetProtectedByChain: []int{0},
+		Templates: []FrameDependencyTemplate{
+			{
+				DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetSwitch},
+				FrameDiffs:              []int{},
+				ChainDiffs:              []int{0},
+			},
+			{
+				DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetSwitch},
+				FrameDiffs:              []int{1},
+				ChainDiffs:              []int{1},
+			},
+		},
+	}
+}
+
+// descriptors returns the payloads of the dependency descriptors of the
+// packets of a temporal unit
+func (s *av1DependencyDescriptorSender) descriptors(temporalUnit []byte, packets int) [][]byte {
+	keyframe := isAV1Keyframe(temporalUnit)
+	if !s.started && !keyframe {
+		// The first descriptor must carry the structure
+		return nil
+	}
+	if s.started {
+		s.frameNumber++
+	}
+	s.started = true
+
+	structure := av1SingleLayerStructure()
+	frame := structure.Templates[1]
+	if keyframe {
+		frame = structure.Templates[0]
+	}
+
+	payloads := make([][]byte, packets)
+	for i := range payloads {
+		descriptor := DependencyDescriptor{
+			FirstPacketInFrame: i == 0,
+			LastPacketInFrame:  i == packets-1,
+			FrameNumber:        s.frameNumber,
+			FrameDependencies:  frame,
+		}
+		if keyframe && i == 0 {
+			descriptor.AttachedStructure = structure
+		}
+
+		payload, err := descriptor.Marshal(structure)
+		if err != nil {
+			return nil
+		}
+		payloads[i] = payload
+	}
+
+	return payloads
+}
+
+// isAV1Keyframe returns if a temporal unit in the low overhead bitstream
+// format contains a sequence header OBU
+func isAV1Keyframe(temporalUnit []byte) bool {
+	for offset := 0; offset < len(temporalUnit); {
+		header := temporalUnit[offset]
+		if header>>3&0x0F == av1OBUSequenceHeader {
+			return true
+		}
+
+		headerSize := 1
+		if header&0x04 != 0 {
+			// obu_extension_flag
+			headerSize++
+		}
+		if header&0x02 == 0 || offset+headerSize > len(temporalUnit) {
+			// Without obu_has_size_field the OBU extends to the end
+			return false
+		}
+
+		size, n := readLEB128(temporalUnit[offset+headerSize:])
+		if n == 0 || size > uint64(len(temporalUnit)) {
+			return false
+		}
+		offset += headerSize + n + int(size)
+	}
+
+	return false
+}
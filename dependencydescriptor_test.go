@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTargetIndication_String(t *testing.T) {
+	testCases := []struct {
+		indication     DecodeTargetIndication
+		expectedString string
+	}{
+		{DecodeTargetNotPresent, "not-present"},
+		{DecodeTargetDiscardable, "discardable"},
+		{DecodeTargetSwitch, "switch"},
+		{DecodeTargetRequired, "required"},
+		{DecodeTargetIndication(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.indication.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+// l1t2Structure is the structure of a stream with two temporal layers
+func l1t2Structure() *FrameDependencyStructure {
+	return &FrameDependencyStructure{
+		StructureID:                  10,
+		NumDecodeTargets:             2,
+		NumChains:                    1,
+		DecodeTargetProtectedByChain: []int{0, 0},
+		Resolutions:                  []RenderResolution{{Width: 640, Height: 360}},
+		Templates: []FrameDependencyTemplate{
+			{
+				DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetSwitch, DecodeTargetSwitch},
+				FrameDiffs:              []int{},
+				ChainDiffs:              []int{0},
+			},
+			{
+				DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetSwitch, DecodeTargetSwitch},
+				FrameDiffs:              []int{2},
+				ChainDiffs:              []int{2},
+			},
+			{
+				TemporalID:              1,
+				DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetNotPresent, DecodeTargetDiscardable},
+				FrameDiffs:              []int{1},
+				ChainDiffs:              []int{1},
+			},
+		},
+	}
+}
+
+func TestDependencyDescriptor_Marshal(t *testing.T) {
+	structure := l1t2Structure()
+	payload, err := DependencyDescriptor{
+		FirstPacketInFrame: true,
+		FrameNumber:        0x1234,
+		FrameDependencies:  structure.Templates[0],
+	}.Marshal(structure)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x80 | 10, 0x12, 0x34}, payload, "a frame matching a template only has the mandatory fields")
+
+	_, err = DependencyDescriptor{FrameDependencies: structure.Templates[0]}.Marshal(nil)
+	assert.ErrorIs(t, err, errDependencyDescriptorNoStructure)
+
+	_, err = DependencyDescriptor{
+		FrameDependencies: FrameDependencyTemplate{
+			SpatialID:               1,
+			DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetSwitch, DecodeTargetSwitch},
+			ChainDiffs:              []int{0},
+		},
+	}.Marshal(structure)
+	assert.ErrorIs(t, err, errDependencyDescriptorInvalid, "no template of the layer")
+
+	unsorted := l1t2Structure()
+	unsorted.Templates[0], unsorted.Templates[2] = unsorted.Templates[2], unsorted.Templates[0]
+	_, err = DependencyDescriptor{FrameDependencies: unsorted.Templates[0], AttachedStructure: unsorted}.Marshal(nil)
+	assert.ErrorIs(t, err, errDependencyDescriptorInvalid, "unsorted templates")
+}
+
+func TestDependencyDescriptor_RoundTrip(t *testing.T) {
+	activeBaseLayer := uint32(1)
+
+	testCases := []struct {
+		descriptor DependencyDescriptor
+		attach     bool
+	}{
+		{DependencyDescriptor{FirstPacketInFrame: true, LastPacketInFrame: true, FrameDependencies: l1t2Structure().Templates[0]}, true},
+		{DependencyDescriptor{LastPacketInFrame: true, FrameNumber: 65535, FrameDependencies: l1t2Structure().Templates[2]}, false},
+		{DependencyDescriptor{FrameNumber: 7, FrameDependencies: l1t2Structure().Templates[1], ActiveDecodeTargetsBitmask: &activeBaseLayer}, false},
+		{DependencyDescriptor{FrameNumber: 8, FrameDependencies: FrameDependencyTemplate{
+			TemporalID:              1,
+			DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetRequired, DecodeTargetDiscardable},
+			FrameDiffs:              []int{1, 300, 4096},
+			ChainDiffs:              []int{200},
+		}}, false},
+	}
+
+	for i, testCase := range testCases {
+		structure := l1t2Structure()
+		descriptor := testCase.descriptor
+		if testCase.attach {
+			descriptor.AttachedStructure = structure
+		}
+
+		payload, err := descriptor.Marshal(structure)
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+
+		var receiverStructure *FrameDependencyStructure
+		if !testCase.attach {
+			receiverStructure = l1t2Structure()
+		}
+		parsed := DependencyDescriptor{}
+		assert.NoError(t, parsed.Unmarshal(payload, receiverStructure), "testCase: %d %v", i, testCase)
+
+		assert.Equal(t, descriptor.FirstPacketInFrame, parsed.FirstPacketInFrame, "testCase: %d %v", i, testCase)
+		assert.Equal(t, descriptor.LastPacketInFrame, parsed.LastPacketInFrame, "testCase: %d %v", i, testCase)
+		assert.Equal(t, descriptor.FrameNumber, parsed.FrameNumber, "testCase: %d %v", i, testCase)
+		assert.Equal(t, descriptor.FrameDependencies, parsed.FrameDependencies, "testCase: %d %v", i, testCase)
+		assert.Equal(t, &RenderResolution{Width: 640, Height: 360}, parsed.Resolution, "testCase: %d %v", i, testCase)
+
+		if testCase.attach {
+			assert.Equal(t, structure, parsed.AttachedStructure, "testCase: %d %v", i, testCase)
+			assert.Equal(t, uint32(3), *parsed.ActiveDecodeTargetsBitmask, "testCase: %d %v", i, testCase)
+		} else {
+			assert.Nil(t, parsed.AttachedStructure, "testCase: %d %v", i, testCase)
+			assert.Equal(t, descriptor.ActiveDecodeTargetsBitmask, parsed.ActiveDecodeTargetsBitmask, "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestDependencyDescriptor_Unmarshal(t *testing.T) {
+	parsed := DependencyDescriptor{}
+	assert.ErrorIs(t, parsed.Unmarshal([]byte{0x80, 0x00}, l1t2Structure()), errDependencyDescriptorTooShort)
+	assert.ErrorIs(t, parsed.Unmarshal([]byte{0x80, 0x00, 0x01}, nil), errDependencyDescriptorNoStructure)
+	assert.ErrorIs(t, parsed.Unmarshal([]byte{0x80, 0x00, 0x01}, l1t2Structure()), errDependencyDescriptorInvalid, "template ID before the structure ID")
+
+	// The structure is truncated
+	payload, err := DependencyDescriptor{FrameDependencies: l1t2Structure().Templates[0], AttachedStructure: l1t2Structure()}.Marshal(nil)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, parsed.Unmarshal(payload[:5], nil), errDependencyDescriptorTooShort)
+}
+
+func TestIsAV1Keyframe(t *testing.T) {
+	testCases := []struct {
+		temporalUnit []byte
+		keyframe     bool
+	}{
+		// Temporal delimiter, sequence header and frame OBUs
+		{[]byte{0x12, 0x00, 0x0A, 0x02, 0xAA, 0xBB, 0x32, 0x01, 0xCC}, true},
+		// Temporal delimiter and frame OBUs
+		{[]byte{0x12, 0x00, 0x32, 0x01, 0xCC}, false},
+		// OBU with an extension header
+		{[]byte{0x36, 0x00, 0x01, 0xCC, 0x0A, 0x00}, true},
+		// OBU without size field
+		{[]byte{0x30, 0xCC, 0x0A}, false},
+		{[]byte{0x32, 0x7F}, false},
+		{[]byte{}, false},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.keyframe, isAV1Keyframe(testCase.temporalUnit), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestAV1DependencyDescriptorSender(t *testing.T) {
+	keyframe := []byte{0x0A, 0x00}
+	delta := []byte{0x32, 0x00}
+
+	s := &av1DependencyDescriptorSender{}
+	assert.Nil(t, s.descriptors(delta, 1), "nothing is sent before the first keyframe")
+
+	var structure *FrameDependencyStructure
+	for frame, temporalUnit := range [][]byte{keyframe, delta, delta} {
+		payloads := s.descriptors(temporalUnit, 2)
+		assert.Equal(t, 2, len(payloads))
+
+		for i, payload := range payloads {
+			parsed := DependencyDescriptor{}
+			assert.NoError(t, parsed.Unmarshal(payload, structure))
+			if parsed.AttachedStructure != nil {
+				structure = parsed.AttachedStructure
+			}
+
+			assert.Equal(t, uint16(frame), parsed.FrameNumber)
+			assert.Equal(t, i == 0, parsed.FirstPacketInFrame)
+			assert.Equal(t, i == 1, parsed.LastPacketInFrame)
+			assert.Equal(t, frame == 0 && i == 0, parsed.AttachedStructure != nil)
+			if frame == 0 {
+				assert.Equal(t, []int{}, parsed.FrameDependencies.FrameDiffs)
+			} else {
+				assert.Equal(t, []int{1}, parsed.FrameDependencies.FrameDiffs)
+			}
+		}
+	}
+}
+
+// Assert that the dependency descriptors of an AV1 TrackLocalStaticSample
+// are negotiated, sent and parsed by the TrackRemote
+func TestPeerConnection_AV1DependencyDescriptor(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newAPI := func() *API {
+		mediaEngine := &MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		assert.NoError(t, ConfigureDependencyDescriptor(mediaEngine))
+
+		return NewAPI(WithMediaEngine(mediaEngine))
+	}
+
+	pcOffer, err := newAPI().NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := newAPI().NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeAV1}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	parsed := make(chan struct{})
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		assert.Equal(t, MimeTypeAV1, remote.Codec().MimeType)
+
+		var parsedOnce sync.Once
+		for {
+			packet, _, readErr := remote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			// Packets sent before the first keyframe was received can't be
+			// parsed
+			descriptor, ok, descriptorErr := remote.DependencyDescriptor(&packet.Header)
+			if errors.Is(descriptorErr, errDependencyDescriptorNoStructure) {
+				continue
+			}
+			assert.NoError(t, descriptorErr)
+
+			if ok && descriptor.AttachedStructure == nil {
+				parsedOnce.Do(func() { close(parsed) })
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// A keyframe with the structure every 10 frames, and delta frames parsed
+	// with it
+	keyframe := []byte{0x12, 0x00, 0x0A, 0x01, 0x00, 0x32, 0x01, 0x00}
+	delta := []byte{0x12, 0x00, 0x32, 0x01, 0x00}
+	for sent := 0; ; sent++ {
+		temporalUnit := delta
+		if sent%10 == 0 {
+			temporalUnit = keyframe
+		}
+		assert.NoError(t, track.WriteSample(media.Sample{Data: temporalUnit, Duration: time.Second}))
+
+		select {
+		case <-time.After(20 * time.Millisecond):
+			continue
+		case <-parsed:
+		}
+		break
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
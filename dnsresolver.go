@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pion/transport/v3"
+)
+
+// dnsResolveTimeout bounds the lookups of a DNSResolver
+const dnsResolveTimeout = 5 * time.Second
+
+// DNSResolver resolves host names to IP addresses. *net.Resolver implements
+// it. See SettingEngine.SetDNSResolver.
+type DNSResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// SetDNSResolver sets the resolver of the host names of STUN and TURN
+// servers, and of the mDNS host names of remote candidates, which are
+// resolved by it before falling back to multicast DNS. This is needed with
+// split-horizon DNS or where the default resolver is unavailable.
+func (e *SettingEngine) SetDNSResolver(resolver DNSResolver) {
+	e.dnsResolver = resolver
+}
+
+// dnsResolverNet is a transport.Net which resolves host names with a
+// DNSResolver
+type dnsResolverNet struct {
+	transport.Net
+	resolver DNSResolver
+}
+
+// newDNSResolverNet returns base resolving host names with resolver, or base
+// if resolver is nil
+func newDNSResolverNet(base transport.Net, resolver DNSResolver) transport.Net {
+	if resolver == nil {
+		return base
+	}
+
+	return &dnsResolverNet{Net: base, resolver: resolver}
+}
+
+func (n *dnsResolverNet) ResolveIPAddr(network, address string) (*net.IPAddr, error) {
+	if net.ParseIP(address) != nil {
+		return n.Net.ResolveIPAddr(network, address)
+	}
+
+	ip, err := lookupIP(n.resolver, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &net.IPAddr{IP: ip}, nil
+}
+
+func (n *dnsResolverNet) ResolveUDPAddr(network, address string) (*net.UDPAddr, error) {
+	ip, port, err := n.resolveHostPort(network, address)
+	if err != nil || ip == nil {
+		if err == nil {
+			return n.Net.ResolveUDPAddr(network, address)
+		}
+		return nil, err
+	}
+
+	return &net.UDPAddr{IP: ip, Port: port}, nil
+}
+
+func (n *dnsResolverNet) ResolveTCPAddr(network, address string) (*net.TCPAddr, error) {
+	ip, port, err := n.resolveHostPort(network, address)
+	if err != nil || ip == nil {
+		if err == nil {
+			return n.Net.ResolveTCPAddr(network, address)
+		}
+		return nil, err
+	}
+
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// resolveHostPort resolves the host name of address, it returns a nil IP if
+// the host isn't a name
+func (n *dnsResolverNet) resolveHostPort(network, address string) (net.IP, int, error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, 0, err
+	}
+	if host == "" || net.ParseIP(host) != nil {
+		return nil, 0, nil
+	}
+
+	port, err := net.LookupPort(strings.TrimRight(network, "46"), portString)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ip, err := lookupIP(n.resolver, network, host)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ip, port, nil
+}
+
+// lookupIP resolves host with resolver, and returns its first address of the
+// family of network
+func lookupIP(resolver DNSResolver, network, host string) (net.IP, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsResolveTimeout)
+	defer cancel()
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		isIPv4 := addr.IP.To4() != nil
+		switch {
+		case strings.HasSuffix(network, "4") && !isIPv4:
+		case strings.HasSuffix(network, "6") && isIPv4:
+		default:
+			return addr.IP, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errDNSNoAddress, host)
+}
+
+// resolveMulticastDNSCandidate resolves the mDNS host name of a remote
+// candidate with the DNSResolver of the SettingEngine, if any, and adds the
+// candidate to the agent once resolved. It returns false if the candidate
+// has to be added as is.
+func (t *ICETransport) resolveMulticastDNSCandidate(candidate ICECandidate) bool {
+	resolver := t.gatherer.api.settingEngine.dnsResolver
+	if resolver == nil || !strings.HasSuffix(candidate.Address, ".local") {
+		return false
+	}
+
+	go func() {
+		if ip, err := lookupIP(resolver, "ip", candidate.Address); err == nil {
+			candidate.Address = ip.String()
+		} else {
+			t.log.Debugf("Failed to resolve %s with DNSResolver, falling back to multicast DNS: %v", candidate.Address, err)
+		}
+
+		c, err := candidate.toICE()
+		if err != nil {
+			t.log.Warnf("Failed to convert remote candidate: %v", err)
+			return
+		}
+
+		agent := t.gatherer.getAgent()
+		if agent == nil {
+			return
+		}
+
+		if err = agent.AddRemoteCandidate(c); err != nil {
+			t.log.Warnf("Failed to add remote candidate %s: %v", candidate.Address, err)
+		}
+	}()
+
+	return true
+}
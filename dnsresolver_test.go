@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pion/transport/v3/stdnet"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticDNSResolver map[string][]net.IPAddr
+
+func (r staticDNSResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if addrs, ok := r[host]; ok {
+		return addrs, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestDNSResolverNet(t *testing.T) {
+	base, err := stdnet.NewNet()
+	assert.NoError(t, err)
+	assert.Equal(t, base, newDNSResolverNet(base, nil))
+
+	n := newDNSResolverNet(base, staticDNSResolver{
+		"stun.internal": {{IP: net.ParseIP("2001:db8::1")}, {IP: net.IPv4(10, 0, 0, 1)}},
+	})
+
+	for i, testCase := range []struct {
+		network, address string
+		expected         string
+		err              bool
+	}{
+		{"udp", "stun.internal:3478", "[2001:db8::1]:3478", false},
+		{"udp4", "stun.internal:3478", "10.0.0.1:3478", false},
+		{"udp6", "stun.internal:3478", "[2001:db8::1]:3478", false},
+		{"udp4", "192.0.2.1:3478", "192.0.2.1:3478", false},
+		{"udp4", "unknown.internal:3478", "", true},
+	} {
+		udpAddr, err := n.ResolveUDPAddr(testCase.network, testCase.address)
+		if testCase.err {
+			assert.Error(t, err, "testCase: %d %v", i, testCase)
+			continue
+		}
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expected, udpAddr.String(), "testCase: %d %v", i, testCase)
+	}
+
+	tcpAddr, err := n.ResolveTCPAddr("tcp4", "stun.internal:443")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:443", tcpAddr.String())
+
+	_, err = n.ResolveUDPAddr("udp6", "ipv4only.internal:3478")
+	assert.Error(t, err)
+}
+
+func TestDNSResolver_NoAddressOfFamily(t *testing.T) {
+	_, err := lookupIP(staticDNSResolver{
+		"ipv4only.internal": {{IP: net.IPv4(10, 0, 0, 1)}},
+	}, "udp6", "ipv4only.internal")
+	assert.ErrorIs(t, err, errDNSNoAddress)
+}
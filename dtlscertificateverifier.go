@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"crypto/x509"
+)
+
+// DTLSRemoteCertificateVerifier verifies the certificate chain of the remote
+// DTLSTransport, leaf first, against the fingerprints of the remote
+// description. Returning an error aborts the DTLS handshake.
+type DTLSRemoteCertificateVerifier func(certificates []*x509.Certificate, fingerprints []DTLSFingerprint) error
+
+// SetDTLSRemoteCertificateVerifier sets a function invoked during the DTLS
+// handshake, before the connection is accepted, with the certificate chain of
+// the remote and the fingerprints of its description. It allows binding the
+// certificate to an identity or pinning it beyond the fingerprint check, which
+// still happens after the handshake unless
+// DisableCertificateFingerprintVerification is set.
+func (e *SettingEngine) SetDTLSRemoteCertificateVerifier(verifier DTLSRemoteCertificateVerifier) {
+	e.dtls.remoteCertificateVerifier = verifier
+}
+
+// verifyPeerCertificate returns the VerifyPeerCertificate of pion/dtls calling
+// verifier with the remote fingerprints
+func verifyPeerCertificate(verifier DTLSRemoteCertificateVerifier, fingerprints []DTLSFingerprint) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		certificates := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, rawCert := range rawCerts {
+			certificate, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				return err
+			}
+			certificates = append(certificates, certificate)
+		}
+
+		return verifier(certificates, fingerprints)
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingEngine_SetDTLSRemoteCertificateVerifier(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	errRejected := errors.New("rejected")
+
+	for _, reject := range []bool{false, true} {
+		sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		offerCertificate, err := GenerateCertificate(sk)
+		assert.NoError(t, err)
+
+		verified := make(chan struct{}, 1)
+		s := SettingEngine{}
+		s.SetDTLSRemoteCertificateVerifier(func(certificates []*x509.Certificate, fingerprints []DTLSFingerprint) error {
+			assert.Len(t, certificates, 1)
+			assert.True(t, certificates[0].Equal(offerCertificate.x509Cert))
+
+			// The fingerprint of the remote description
+			expectedFingerprints, err := offerCertificate.GetFingerprints()
+			assert.NoError(t, err)
+			if assert.Len(t, fingerprints, 1) {
+				assert.Equal(t, expectedFingerprints[0].Algorithm, fingerprints[0].Algorithm)
+				assert.True(t, strings.EqualFold(expectedFingerprints[0].Value, fingerprints[0].Value))
+			}
+
+			verified <- struct{}{}
+			if reject {
+				return errRejected
+			}
+			return nil
+		})
+
+		pcOffer, err := NewPeerConnection(Configuration{Certificates: []Certificate{*offerCertificate}})
+		assert.NoError(t, err)
+		pcAnswer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		// A rejected handshake closes the PeerConnection, which may happen
+		// before it reports the failed state, so the DTLSTransport is checked
+		connected := untilConnectionState(PeerConnectionStateConnected, pcAnswer)
+		dtlsFailed := make(chan struct{}, 1)
+		pcAnswer.dtlsTransport.OnStateChange(func(state DTLSTransportState) {
+			if state == DTLSTransportStateFailed {
+				select {
+				case dtlsFailed <- struct{}{}:
+				default:
+				}
+			}
+		})
+
+		_, err = pcOffer.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		<-verified
+		if reject {
+			<-dtlsFailed
+		} else {
+			connected.Wait()
+		}
+
+		closePairNow(t, pcOffer, pcAnswer)
+	}
+}
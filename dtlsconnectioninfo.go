@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/gob"
+
+	"github.com/pion/dtls/v2"
+)
+
+// dtlsVersion is the only DTLS version negotiated by the DTLSTransport
+const dtlsVersion = "DTLS 1.2"
+
+// DTLSConnectionInfo describes the parameters negotiated by the DTLS
+// handshake of a DTLSTransport
+type DTLSConnectionInfo struct {
+	// Version is the negotiated protocol version, like "DTLS 1.2"
+	Version string
+
+	// CipherSuite is the negotiated cipher suite and CipherSuiteName its
+	// IANA name, like "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"
+	CipherSuite     dtls.CipherSuiteID
+	CipherSuiteName string
+
+	// SRTPProtectionProfile is the negotiated SRTP protection profile
+	SRTPProtectionProfile dtls.SRTPProtectionProfile
+
+	// RemoteCertificate is the certificate presented by the remote
+	RemoteCertificate *x509.Certificate
+}
+
+// GetConnectionInfo returns the version, cipher suite, SRTP protection
+// profile and remote certificate negotiated by the DTLS handshake. It returns
+// an error until the handshake completed.
+func (t *DTLSTransport) GetConnectionInfo() (DTLSConnectionInfo, error) {
+	t.lock.RLock()
+	conn := t.conn
+	remoteCertificate := t.remoteCertificate
+	srtpProtectionProfile := t.selectedSRTPProtectionProfile
+	cipherSuite := t.cipherSuite
+	t.lock.RUnlock()
+
+	if conn == nil {
+		return DTLSConnectionInfo{}, errDtlsTransportNotStarted
+	}
+
+	var err error
+	info := DTLSConnectionInfo{
+		Version:               dtlsVersion,
+		CipherSuite:           cipherSuite,
+		CipherSuiteName:       dtls.CipherSuiteName(cipherSuite),
+		SRTPProtectionProfile: srtpProtectionProfile,
+	}
+
+	if len(remoteCertificate) != 0 {
+		if info.RemoteCertificate, err = x509.ParseCertificate(remoteCertificate); err != nil {
+			return DTLSConnectionInfo{}, err
+		}
+	}
+
+	return info, nil
+}
+
+// recordConnectionState is the VerifyConnection of the DTLS handshake, it
+// records the negotiated cipher suite once the remote is verified. It never
+// aborts the handshake.
+func (t *DTLSTransport) recordConnectionState(state *dtls.State) error {
+	cipherSuite, err := negotiatedCipherSuite(state)
+	if err != nil {
+		t.log.Warnf("Failed to record the DTLS cipher suite: %v", err)
+		return nil
+	}
+
+	t.lock.Lock()
+	t.cipherSuite = cipherSuite
+	t.lock.Unlock()
+
+	return nil
+}
+
+// negotiatedCipherSuite returns the cipher suite of state. The pinned dtls
+// doesn't expose it, but includes it in the serialized state.
+func negotiatedCipherSuite(state *dtls.State) (dtls.CipherSuiteID, error) {
+	raw, err := state.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	var serialized struct {
+		CipherSuiteID uint16
+	}
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&serialized); err != nil {
+		return 0, err
+	}
+
+	return dtls.CipherSuiteID(serialized.CipherSuiteID), nil
+}
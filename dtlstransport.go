@@ -44,11 +44,30 @@ type DTLSTransport struct {
 	state                 DTLSTransportState
 	srtpProtectionProfile srtp.ProtectionProfile
 
+	// selectedSRTPProtectionProfile is the profile negotiated by DTLS-SRTP,
+	// it is valid once hasSelectedSRTPProtectionProfile is set
+	selectedSRTPProtectionProfile    dtls.SRTPProtectionProfile
+	hasSelectedSRTPProtectionProfile bool
+
+	// cipherSuite is the cipher suite recorded by the DTLS handshake
+	cipherSuite dtls.CipherSuiteID
+
 	onStateChangeHandler   func(DTLSTransportState)
 	internalOnCloseHandler func()
 
+	internalOnBufferWatermarkHandler func(ssrc SSRC, bufferedBytes int, high bool)
+	internalOnBufferDropHandler      func(ssrc SSRC, dropped, totalDropped uint64)
+
+	packetMirror *packetMirror
+
+	srtpBuffers srtpBufferTracker
+
 	conn *dtls.Conn
 
+	// applicationDataMux is set if SCTP shares the DTLS connection with a
+	// custom protocol
+	applicationDataMux *dtlsApplicationDataMux
+
 	srtpSession, srtcpSession   atomic.Value
 	srtpEndpoint, srtcpEndpoint *mux.Endpoint
 	simulcastStreams            []*srtp.ReadStreamSRTP
@@ -93,6 +112,14 @@ func (api *API) NewDTLSTransport(transport *ICETransport, certificates []Certifi
 		t.certificates = []Certificate{*certificate}
 	}
 
+	if mirror := api.settingEngine.packetMirror; mirror != nil {
+		packetMirror, err := newPacketMirror(*mirror, api.settingEngine.net)
+		if err != nil {
+			return nil, err
+		}
+		t.packetMirror = packetMirror
+	}
+
 	return t, nil
 }
 
@@ -128,6 +155,16 @@ func (t *DTLSTransport) State() DTLSTransportState {
 	return t.state
 }
 
+// SelectedSRTPProtectionProfile returns the SRTP protection profile
+// negotiated with the remote during the DTLS handshake, and false before the
+// handshake completed. See SettingEngine.SetSRTPProtectionProfiles.
+func (t *DTLSTransport) SelectedSRTPProtectionProfile() (dtls.SRTPProtectionProfile, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.selectedSRTPProtectionProfile, t.hasSelectedSRTPProtectionProfile
+}
+
 // WriteRTCP sends a user provided RTCP packet to the connected peer. If no peer is connected the
 // packet is discarded.
 func (t *DTLSTransport) WriteRTCP(pkts []rtcp.Packet) (int, error) {
@@ -177,10 +214,28 @@ func (t *DTLSTransport) GetRemoteCertificate() []byte {
 	return t.remoteCertificate
 }
 
+// ExportKeyingMaterial derives length bytes of keying material from the
+// established DTLS connection, as defined by RFC 5705, so that applications
+// can derive keys bound to the transport. The label must not be one of the
+// labels reserved by TLS. An exporter context isn't supported and context must
+// be empty.
+func (t *DTLSTransport) ExportKeyingMaterial(label string, context []byte, length int) ([]byte, error) {
+	t.lock.RLock()
+	conn := t.conn
+	t.lock.RUnlock()
+
+	if conn == nil {
+		return nil, errDtlsTransportNotStarted
+	}
+
+	state := conn.ConnectionState()
+	return state.ExportKeyingMaterial(label, context, length)
+}
+
 func (t *DTLSTransport) startSRTP() error {
 	srtpConfig := &srtp.Config{
 		Profile:       t.srtpProtectionProfile,
-		BufferFactory: t.api.settingEngine.BufferFactory,
+		BufferFactory: t.srtpBuffers.factory(t.packetMirror.bufferFactory(t.api.settingEngine.srtpBufferFactory(t.internalOnBufferWatermarkHandler, t.internalOnBufferDropHandler))),
 		LoggerFactory: t.api.settingEngine.LoggerFactory,
 	}
 	if t.api.settingEngine.replayProtection.SRTP != nil {
@@ -218,7 +273,7 @@ func (t *DTLSTransport) startSRTP() error {
 		return fmt.Errorf("%w: %v", errDtlsKeyExtractionFailed, err)
 	}
 
-	srtpSession, err := srtp.NewSessionSRTP(t.srtpEndpoint, srtpConfig)
+	srtpSession, err := srtp.NewSessionSRTP(t.api.twccSender.srtpConn(t.packetMirror.srtpConn(t.srtpEndpoint)), srtpConfig)
 	if err != nil {
 		// nolint
 		return fmt.Errorf("%w: %v", errFailedToStartSRTP, err)
@@ -253,6 +308,15 @@ func (t *DTLSTransport) getSRTCPSession() (*srtp.SessionSRTCP, error) {
 }
 
 func (t *DTLSTransport) role() DTLSRole {
+	// A forced role wins over the role of the remote
+	switch t.api.settingEngine.forcedDTLSRole {
+	case DTLSRoleServer:
+		return DTLSRoleServer
+	case DTLSRoleClient:
+		return DTLSRoleClient
+	default:
+	}
+
 	// If remote has an explicit role use the inverse
 	switch t.remoteParameters.Role {
 	case DTLSRoleClient:
@@ -339,6 +403,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	}
 
 	dtlsConfig.FlightInterval = t.api.settingEngine.dtls.retransmissionInterval
+	dtlsConfig.MTU = t.api.settingEngine.dtls.mtu
 	dtlsConfig.InsecureSkipVerifyHello = t.api.settingEngine.dtls.insecureSkipHelloVerify
 	dtlsConfig.EllipticCurves = t.api.settingEngine.dtls.ellipticCurves
 	dtlsConfig.ConnectContextMaker = t.api.settingEngine.dtls.connectContextMaker
@@ -346,6 +411,10 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	dtlsConfig.ClientCAs = t.api.settingEngine.dtls.clientCAs
 	dtlsConfig.RootCAs = t.api.settingEngine.dtls.rootCAs
 	dtlsConfig.KeyLogWriter = t.api.settingEngine.dtls.keyLogWriter
+	if verifier := t.api.settingEngine.dtls.remoteCertificateVerifier; verifier != nil {
+		dtlsConfig.VerifyPeerCertificate = verifyPeerCertificate(verifier, remoteParameters.Fingerprints)
+	}
+	dtlsConfig.VerifyConnection = t.recordConnectionState
 
 	// Connect as DTLS Client/Server, function is blocking and we
 	// must not hold the DTLSTransport lock
@@ -381,6 +450,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 		t.onStateChange(DTLSTransportStateFailed)
 		return ErrNoSRTPProtectionProfile
 	}
+	t.selectedSRTPProtectionProfile, t.hasSelectedSRTPProtectionProfile = srtpProfile, true
 
 	// Check the fingerprint if a certificate was exchanged
 	remoteCerts := dtlsConn.ConnectionState().PeerCertificates
@@ -412,11 +482,29 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	}
 
 	t.conn = dtlsConn
+	if match := t.api.settingEngine.dtls.customDataMatcher; match != nil {
+		t.applicationDataMux = newDTLSApplicationDataMux(dtlsConn, match, t.log)
+	}
 	t.onStateChange(DTLSTransportStateConnected)
 
 	return t.startSRTP()
 }
 
+// writeSRTP writes a packet which is already SRTP protected to the transport,
+// bypassing the SRTP session
+func (t *DTLSTransport) writeSRTP(packet []byte) (int, error) {
+	t.lock.RLock()
+	endpoint := t.srtpEndpoint
+	t.lock.RUnlock()
+
+	if endpoint == nil {
+		return 0, errDtlsTransportNotStarted
+	}
+	t.packetMirror.mirror(true, PacketMirrorPointSRTP, packet)
+
+	return endpoint.Write(packet)
+}
+
 // Stop stops and closes the DTLSTransport object.
 func (t *DTLSTransport) Stop() error {
 	t.lock.Lock()
@@ -443,6 +531,8 @@ func (t *DTLSTransport) Stop() error {
 			closeErrs = append(closeErrs, err)
 		}
 	}
+	closeErrs = append(closeErrs, t.packetMirror.close())
+
 	t.onStateChange(DTLSTransportStateClosed)
 	return util.FlattenErrs(closeErrs)
 }
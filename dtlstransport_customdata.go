@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3/packetio"
+)
+
+const (
+	// dtlsApplicationDataMTU is the largest payload of a DTLS record
+	dtlsApplicationDataMTU = 1 << 14
+
+	// dtlsApplicationDataBufferSize is the amount of data which is buffered
+	// for each of SCTP and the custom protocol before dropping records
+	dtlsApplicationDataBufferSize = 1000 * 1000
+)
+
+// dtlsApplicationDataMux routes the application data of a DTLS connection
+// either to SCTP or to a custom protocol, depending on a matcher set with
+// SettingEngine.SetDTLSCustomDataMatcher
+type dtlsApplicationDataMux struct {
+	conn  net.Conn
+	match func([]byte) bool
+	log   logging.LeveledLogger
+
+	sctp, custom *dtlsApplicationDataConn
+}
+
+func newDTLSApplicationDataMux(conn net.Conn, match func([]byte) bool, log logging.LeveledLogger) *dtlsApplicationDataMux {
+	m := &dtlsApplicationDataMux{
+		conn:  conn,
+		match: match,
+		log:   log,
+	}
+	// SCTP owns the DTLS connection, closing it closes the DTLS connection
+	// like when SCTP uses the DTLS connection directly
+	m.sctp = m.newConn(true)
+	m.custom = m.newConn(false)
+
+	go m.readLoop()
+
+	return m
+}
+
+func (m *dtlsApplicationDataMux) newConn(ownsConn bool) *dtlsApplicationDataConn {
+	buffer := packetio.NewBuffer()
+	buffer.SetLimitSize(dtlsApplicationDataBufferSize)
+
+	return &dtlsApplicationDataConn{mux: m, buffer: buffer, ownsConn: ownsConn}
+}
+
+func (m *dtlsApplicationDataMux) readLoop() {
+	defer func() {
+		for _, c := range []*dtlsApplicationDataConn{m.sctp, m.custom} {
+			if err := c.buffer.Close(); err != nil {
+				m.log.Warnf("Failed to close DTLS application data buffer: %v", err)
+			}
+		}
+	}()
+
+	buf := make([]byte, dtlsApplicationDataMTU)
+	for {
+		n, err := m.conn.Read(buf)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				m.log.Tracef("Stopped reading DTLS application data: %v", err)
+			}
+			return
+		}
+
+		c := m.sctp
+		if m.match(buf[:n]) {
+			c = m.custom
+		}
+
+		if _, err = c.buffer.Write(buf[:n]); errors.Is(err, packetio.ErrFull) {
+			m.log.Infof("DTLS application data buffer is full, dropping record")
+		} else if err != nil {
+			return
+		}
+	}
+}
+
+// dtlsApplicationDataConn is the net.Conn of either SCTP or the custom
+// protocol sharing a DTLS connection
+type dtlsApplicationDataConn struct {
+	mux      *dtlsApplicationDataMux
+	buffer   *packetio.Buffer
+	ownsConn bool
+}
+
+func (c *dtlsApplicationDataConn) Read(p []byte) (int, error) {
+	return c.buffer.Read(p)
+}
+
+func (c *dtlsApplicationDataConn) Write(p []byte) (int, error) {
+	return c.mux.conn.Write(p)
+}
+
+func (c *dtlsApplicationDataConn) Close() error {
+	if err := c.buffer.Close(); err != nil {
+		return err
+	}
+	if c.ownsConn {
+		return c.mux.conn.Close()
+	}
+
+	return nil
+}
+
+func (c *dtlsApplicationDataConn) LocalAddr() net.Addr {
+	return c.mux.conn.LocalAddr()
+}
+
+func (c *dtlsApplicationDataConn) RemoteAddr() net.Addr {
+	return c.mux.conn.RemoteAddr()
+}
+
+func (c *dtlsApplicationDataConn) SetDeadline(t time.Time) error {
+	return c.buffer.SetReadDeadline(t)
+}
+
+func (c *dtlsApplicationDataConn) SetReadDeadline(t time.Time) error {
+	return c.buffer.SetReadDeadline(t)
+}
+
+// SetWriteDeadline is a stub, the DTLS connection is shared
+func (c *dtlsApplicationDataConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// CustomDataConn returns the connection of the custom protocol sharing the
+// DTLS connection with SCTP. It receives the DTLS application data matched by
+// the matcher set with SettingEngine.SetDTLSCustomDataMatcher, and what is
+// written to it is sent as DTLS application data. Closing it doesn't close
+// the DTLSTransport.
+func (t *DTLSTransport) CustomDataConn() (net.Conn, error) {
+	if t.api.settingEngine.dtls.customDataMatcher == nil {
+		return nil, ErrDTLSCustomDataNotEnabled
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.applicationDataMux == nil {
+		return nil, errDtlsTransportNotStarted
+	}
+
+	return t.applicationDataMux.custom, nil
+}
+
+// sctpConn returns the connection SCTP runs over
+func (t *DTLSTransport) sctpConn() net.Conn {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.applicationDataMux != nil {
+		return t.applicationDataMux.sctp
+	}
+	if t.conn == nil {
+		return nil
+	}
+
+	return t.conn
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/dtls/v2"
 	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -138,3 +139,236 @@ func TestPeerConnection_DTLSRoleSettingEngine(t *testing.T) {
 		runTest(DTLSRoleClient)
 	})
 }
+
+func TestDTLSTransport_CustomDataConn(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetDTLSCustomDataMatcher(func(b []byte) bool {
+		return len(b) > 0 && b[0] == 'X'
+	})
+	api := NewAPI(WithSettingEngine(s))
+
+	pcOffer, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.SCTP().Transport().CustomDataConn()
+	assert.Error(t, err)
+
+	messageReceived := make(chan bool)
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			assert.Equal(t, "ping", string(msg.Data))
+			messageReceived <- true
+		})
+	})
+
+	d, err := pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	opened := make(chan struct{})
+	d.OnOpen(func() {
+		close(opened)
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-opened
+
+	offerConn, err := pcOffer.SCTP().Transport().CustomDataConn()
+	assert.NoError(t, err)
+	answerConn, err := pcAnswer.SCTP().Transport().CustomDataConn()
+	assert.NoError(t, err)
+
+	_, err = offerConn.Write([]byte("Xhello"))
+	assert.NoError(t, err)
+	assert.NoError(t, d.SendText("ping"))
+
+	buf := make([]byte, 16)
+	n, err := answerConn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "Xhello", string(buf[:n]))
+	<-messageReceived
+
+	assert.NoError(t, offerConn.Close())
+	closePairNow(t, pcOffer, pcAnswer)
+
+	_, err = answerConn.Read(buf)
+	assert.Error(t, err)
+}
+
+func TestDTLSTransport_CustomDataConnNotEnabled(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.SCTP().Transport().CustomDataConn()
+	assert.ErrorIs(t, err, ErrDTLSCustomDataNotEnabled)
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestDTLSTransport_SelectedSRTPProtectionProfile(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	for i, profile := range []dtls.SRTPProtectionProfile{
+		dtls.SRTP_AEAD_AES_256_GCM,
+		dtls.SRTP_AEAD_AES_128_GCM,
+		dtls.SRTP_AES128_CM_HMAC_SHA1_80,
+	} {
+		s := SettingEngine{}
+		s.SetSRTPProtectionProfiles(profile)
+
+		offerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		answerPC, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		_, ok := offerPC.SCTP().Transport().SelectedSRTPProtectionProfile()
+		assert.False(t, ok, "testCase: %d %v", i, profile)
+
+		connectionComplete := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		connectionComplete.Wait()
+
+		for _, pc := range []*PeerConnection{offerPC, answerPC} {
+			selected, ok := pc.SCTP().Transport().SelectedSRTPProtectionProfile()
+			assert.True(t, ok, "testCase: %d %v", i, profile)
+			assert.Equal(t, profile, selected, "testCase: %d %v", i, profile)
+		}
+
+		closePairNow(t, offerPC, answerPC)
+	}
+}
+
+func TestPeerConnection_ForcedDTLSRole(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	assert.ErrorIs(t, (&SettingEngine{}).SetForcedDTLSRole(DTLSRoleUnknown), errSettingEngineSetForcedDTLSRole)
+
+	for i, testCase := range []struct {
+		role  DTLSRole
+		setup string
+	}{
+		{DTLSRoleServer, "a=setup:passive"},
+		{DTLSRoleClient, "a=setup:active"},
+	} {
+		s := SettingEngine{}
+		assert.NoError(t, s.SetForcedDTLSRole(testCase.role))
+
+		offerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		answerPC, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		_, err = offerPC.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+
+		offer, err := offerPC.CreateOffer(nil)
+		assert.NoError(t, err)
+		assert.Contains(t, offer.SDP, testCase.setup, "testCase: %d %v", i, testCase)
+		assert.NotContains(t, offer.SDP, "a=setup:actpass", "testCase: %d %v", i, testCase)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		connected.Wait()
+
+		assert.Equal(t, testCase.role, offerPC.dtlsTransport.role(), "testCase: %d %v", i, testCase)
+
+		closePairNow(t, offerPC, answerPC)
+	}
+}
+
+func TestDTLSTransport_ExportKeyingMaterial(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", nil, 32)
+	assert.ErrorIs(t, err, errDtlsTransportNotStarted)
+
+	connectionComplete := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connectionComplete.Wait()
+
+	// Both ends derive the same keying material
+	offerKey, err := offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", nil, 32)
+	assert.NoError(t, err)
+	assert.Len(t, offerKey, 32)
+	answerKey, err := answerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", nil, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, offerKey, answerKey)
+
+	otherKey, err := offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-other", nil, 32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, offerKey, otherKey)
+
+	_, err = offerPC.SCTP().Transport().ExportKeyingMaterial("master secret", nil, 32)
+	assert.Error(t, err)
+	_, err = offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", []byte{1}, 32)
+	assert.Error(t, err)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDTLSTransport_GetConnectionInfo(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerPC.SCTP().Transport().GetConnectionInfo()
+	assert.ErrorIs(t, err, errDtlsTransportNotStarted)
+
+	connectionComplete := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connectionComplete.Wait()
+
+	offerInfo, err := offerPC.SCTP().Transport().GetConnectionInfo()
+	assert.NoError(t, err)
+	answerInfo, err := answerPC.SCTP().Transport().GetConnectionInfo()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "DTLS 1.2", offerInfo.Version)
+	assert.Equal(t, dtls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, offerInfo.CipherSuite)
+	assert.Equal(t, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", offerInfo.CipherSuiteName)
+	assert.Equal(t, dtls.SRTP_AEAD_AES_256_GCM, offerInfo.SRTPProtectionProfile)
+
+	// Both ends negotiated the same parameters
+	assert.Equal(t, offerInfo.Version, answerInfo.Version)
+	assert.Equal(t, offerInfo.CipherSuite, answerInfo.CipherSuite)
+	assert.Equal(t, offerInfo.SRTPProtectionProfile, answerInfo.SRTPProtectionProfile)
+
+	// Each end reports the certificate of the other
+	assert.True(t, offerInfo.RemoteCertificate.Equal(answerPC.configuration.Certificates[0].x509Cert))
+	assert.True(t, answerInfo.RemoteCertificate.Equal(offerPC.configuration.Certificates[0].x509Cert))
+
+	closePairNow(t, offerPC, answerPC)
+}
@@ -19,6 +19,36 @@ var (
 	// channel is not (yet) open.
 	ErrDataChannelNotOpen = errors.New("data channel not open")
 
+	// ErrDataChannelAuthenticationFailed indicates that the first message of
+	// a DataChannel didn't authenticate the remote with the session token.
+	ErrDataChannelAuthenticationFailed = errors.New("data channel authentication failed")
+
+	// ErrDataChannelBufferFull indicates that a message wasn't sent because
+	// the BufferedAmount of the DataChannel is above its high-water mark.
+	// Sending can be retried once the BufferedAmount went down.
+	ErrDataChannelBufferFull = errors.New("data channel buffered amount above high-water mark")
+
+	// ErrDataChannelChunkerNotReliable indicates that a DataChannelChunker was
+	// created for a DataChannel which is unordered or unreliable.
+	ErrDataChannelChunkerNotReliable = errors.New("chunked messages require an ordered and reliable data channel")
+
+	// ErrDataChannelMessageTooLarge indicates that a chunked message is larger
+	// than can be sent or than the receiver accepts.
+	ErrDataChannelMessageTooLarge = errors.New("data channel message too large")
+
+	// ErrTrackLocalWriteNotConnected indicates that a RTP packet was dropped
+	// because the DTLS transport of its RTPSender isn't connected yet.
+	ErrTrackLocalWriteNotConnected = errors.New("packet dropped, DTLS transport not connected")
+
+	// ErrOfferTemplateMismatch indicates that an OfferTemplate was used for a
+	// PeerConnection whose transceivers, DataChannels or configuration differ
+	// from the PeerConnection it was created from, or which already negotiated.
+	ErrOfferTemplateMismatch = errors.New("PeerConnection doesn't match the offer template")
+
+	// ErrSamplePacing indicates that the samples written to a
+	// TrackLocalStaticSample aren't paced in real time.
+	ErrSamplePacing = errors.New("samples aren't written in real time")
+
 	// ErrCertificateExpired indicates that an x509 certificate has expired.
 	ErrCertificateExpired = errors.New("x509Cert expired")
 
@@ -65,6 +95,14 @@ var (
 	// specified for a data channel has been exceeded.
 	ErrMaxDataChannelID = errors.New("maximum number ID for datachannel specified")
 
+	// ErrDataChannelIDInUse indicates that a DataChannel ID is already used
+	// by another DataChannel of the SCTPTransport.
+	ErrDataChannelIDInUse = errors.New("data channel ID is already in use")
+
+	// ErrDataChannelIDParity indicates that a DataChannel ID doesn't have the
+	// parity of the local DTLS role, even for the client and odd for the server.
+	ErrDataChannelIDParity = errors.New("data channel ID parity doesn't match the DTLS role")
+
 	// ErrNegotiatedWithoutID indicates that an attempt to create a data channel
 	// was made while setting the negotiated option to true without providing
 	// the negotiated channel ID.
@@ -126,6 +164,19 @@ var (
 	// contains multiple conflicting ice-pwd values
 	ErrSessionDescriptionConflictingIcePwd = errors.New("SetRemoteDescription called with multiple conflicting ice-pwd values")
 
+	// ErrSessionDescriptionInvalidCodec indicates SetRemoteDescription was called with a SessionDescription that
+	// has a codec that can't be parsed.
+	ErrSessionDescriptionInvalidCodec = errors.New("SetRemoteDescription called with an invalid codec")
+
+	// ErrSessionDescriptionMissingRTCPMux indicates SetRemoteDescription was called with a SessionDescription that
+	// doesn't multiplex RTP and RTCP while SettingEngine.SetRTCPMuxOnly is enabled.
+	ErrSessionDescriptionMissingRTCPMux = errors.New("SetRemoteDescription called without rtcp-mux")
+
+	// ErrDTLSCustomDataNotEnabled indicates that DTLSTransport.CustomDataConn
+	// was called without a matcher set with
+	// SettingEngine.SetDTLSCustomDataMatcher
+	ErrDTLSCustomDataNotEnabled = errors.New("no DTLS custom data matcher set")
+
 	// ErrNoSRTPProtectionProfile indicates that the DTLS handshake completed and no SRTP Protection Profile was chosen
 	ErrNoSRTPProtectionProfile = errors.New("DTLS Handshake completed and no SRTP Protection Profile was chosen")
 
@@ -142,12 +193,35 @@ var (
 	// least one configured codec.
 	ErrSenderWithNoCodecs = errors.New("unable to populate media section, RTPSender created with no codecs")
 
+	// ErrNoCommonCodec indicates that a remote media section doesn't contain any codec supported by the MediaEngine
+	ErrNoCommonCodec = errors.New("no codec in media section is supported locally")
+
+	// ErrSDPTooLarge indicates that a remote description is larger than
+	// SDPLimits.MaxSize
+	ErrSDPTooLarge = errors.New("session description too large")
+
+	// ErrSDPTooManyMediaSections indicates that a remote description has more
+	// m-lines than SDPLimits.MaxMediaSections
+	ErrSDPTooManyMediaSections = errors.New("session description has too many media sections")
+
+	// ErrSDPTooManyAttributes indicates that a section of a remote description
+	// has more attributes than SDPLimits.MaxAttributesPerSection
+	ErrSDPTooManyAttributes = errors.New("session description section has too many attributes")
+
+	// ErrSDPTooManyCandidates indicates that a remote description has more
+	// candidates than SDPLimits.MaxCandidates
+	ErrSDPTooManyCandidates = errors.New("session description has too many candidates")
+
 	// ErrRTPSenderNewTrackHasIncorrectKind indicates that the new track is of a different kind than the previous/original
 	ErrRTPSenderNewTrackHasIncorrectKind = errors.New("new track must be of the same kind as previous")
 
 	// ErrRTPSenderNewTrackHasIncorrectEnvelope indicates that the new track has a different envelope than the previous/original
 	ErrRTPSenderNewTrackHasIncorrectEnvelope = errors.New("new track must have the same envelope as previous")
 
+	// ErrUnsupportedScalabilityMode indicates that a scalability mode isn't
+	// one of WebRTC-SVC or isn't supported by the codecs of a RTPSender
+	ErrUnsupportedScalabilityMode = errors.New("scalability mode is not supported")
+
 	// ErrUnbindFailed indicates that a TrackLocal was not able to be unbind
 	ErrUnbindFailed = errors.New("failed to unbind TrackLocal from PeerConnection")
 
@@ -179,6 +253,7 @@ var (
 	errICERoleUnknown                 = errors.New("unknown ICE Role")
 	errICEProtocolUnknown             = errors.New("unknown protocol")
 	errICEGathererNotStarted          = errors.New("gatherer not started")
+	errICEStaticCandidatesNoUDPMux    = errors.New("static host candidates require an ICE UDPMux")
 
 	errNetworkTypeUnknown = errors.New("unknown network type")
 
@@ -218,6 +293,8 @@ var (
 	errRTPSenderBaseEncodingMismatch = errors.New("Sender cannot add encoding as provided track does not match base track")
 	errRTPSenderRIDCollision         = errors.New("Sender cannot encoding due to RID collision")
 	errRTPSenderNoTrackForRID        = errors.New("Sender does not have track for RID")
+	errRTPSenderSRTPInvalid          = errors.New("Sender cannot write packet which isn't SRTP")
+	errRTPSenderSendNotCalled        = errors.New("Send has not been called")
 
 	errRTPTransceiverCannotChangeMid        = errors.New("errRTPSenderTrackNil")
 	errRTPTransceiverSetSendingInvalidState = errors.New("invalid state change in RTPTransceiver.setSending")
@@ -246,4 +323,31 @@ var (
 	errRTPTooShort = errors.New("not long enough to be a RTP Packet")
 
 	errExcessiveRetries = errors.New("excessive retries in CreateOffer")
+
+	errDataChannelChunkInvalid = errors.New("invalid data channel chunk")
+
+	errDNSNoAddress = errors.New("no address of the requested family")
+
+	errICEProxySchemeUnsupported = errors.New("unsupported proxy scheme")
+	errICEProxyConnectFailed     = errors.New("proxy refused CONNECT")
+
+	errVideoLayersAllocationInvalid  = errors.New("invalid video layers allocation")
+	errVideoLayersAllocationTooShort = errors.New("video layers allocation is too short")
+
+	errIPFilterCIDRInvalid = errors.New("invalid IP filter CIDR")
+
+	errICESocketNotConnected = errors.New("ICE socket isn't connected")
+
+	errSettingEngineSetForcedDTLSRole = errors.New("SetForcedDTLSRole must DTLSRoleClient, DTLSRoleServer or DTLSRoleAuto")
+
+	errSettingEngineRTCPReportIntervals = errors.New("RTCP report intervals must not be negative, and the bandwidth fraction must be in [0, 1]")
+
+	errPacketMirrorNoDestination = errors.New("packet mirror needs a Destination or a Handler")
+	errPacketMirrorNoDirection   = errors.New("packet mirror needs Incoming or Outgoing packets")
+
+	errDependencyDescriptorTooShort    = errors.New("dependency descriptor is too short")
+	errDependencyDescriptorInvalid     = errors.New("invalid dependency descriptor")
+	errDependencyDescriptorNoStructure = errors.New("dependency descriptor has no frame dependency structure")
+
+	errHeaderExtensionNeedsTwoByte = errors.New("header extension needs two-byte header extensions, which the remote doesn't allow")
 )
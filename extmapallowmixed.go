@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"github.com/pion/rtp"
+)
+
+const (
+	// Profiles of RTP header extensions, https://datatracker.ietf.org/doc/html/rfc8285#section-4
+	rtpExtensionProfileOneByte = 0xBEDE
+	rtpExtensionProfileTwoByte = 0x1000
+
+	maxOneByteHeaderExtensionID      = 14
+	maxOneByteHeaderExtensionPayload = 16
+	maxTwoByteHeaderExtensionID      = 255
+	maxTwoByteHeaderExtensionPayload = 255
+)
+
+// twoByteHeaderExtensionWriters are the URIs of the header extensions Pion
+// writes with setHeaderExtension. Other extensions may be written by
+// interceptors, which only support IDs of one-byte header extensions.
+var twoByteHeaderExtensionWriters = map[string]bool{ //nolint:gochecknoglobals
+	DependencyDescriptorURI:  true,
+	VideoLayersAllocationURI: true,
+}
+
+// setHeaderExtension sets the header extension id of header to payload. One-byte
+// header extensions are used when they can hold every extension of header,
+// otherwise they are converted to two-byte header extensions if allowMixed is
+// set, which is only the case when the remote signaled a=extmap-allow-mixed.
+// https://datatracker.ietf.org/doc/html/rfc8285#section-6
+func setHeaderExtension(header *rtp.Header, id uint8, payload []byte, allowMixed bool) error {
+	needsTwoByte := id > maxOneByteHeaderExtensionID || len(payload) > maxOneByteHeaderExtensionPayload
+	if needsTwoByte && !allowMixed {
+		return errHeaderExtensionNeedsTwoByte
+	}
+	if needsTwoByte && len(payload) <= maxTwoByteHeaderExtensionPayload {
+		switch {
+		case !header.Extension:
+			header.Extension = true
+			header.ExtensionProfile = rtpExtensionProfileTwoByte
+			header.Extensions = nil
+		case header.ExtensionProfile == rtpExtensionProfileOneByte:
+			// Extensions are stored independently of their encoding
+			header.ExtensionProfile = rtpExtensionProfileTwoByte
+		}
+	}
+
+	return header.SetExtension(id, payload)
+}
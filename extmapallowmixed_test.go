@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetHeaderExtension(t *testing.T) {
+	small, large := []byte{0x01}, make([]byte, 20)
+
+	testCases := []struct {
+		existing        map[uint8][]byte
+		id              uint8
+		payload         []byte
+		allowMixed      bool
+		expectedErr     error
+		expectedProfile uint16
+	}{
+		{nil, 1, small, false, nil, rtpExtensionProfileOneByte},
+		{nil, 1, small, true, nil, rtpExtensionProfileOneByte},
+		{nil, 15, small, true, nil, rtpExtensionProfileTwoByte},
+		{nil, 1, large, true, nil, rtpExtensionProfileTwoByte},
+		{map[uint8][]byte{1: small}, 2, small, true, nil, rtpExtensionProfileOneByte},
+		{map[uint8][]byte{1: small}, 200, small, true, nil, rtpExtensionProfileTwoByte},
+		{map[uint8][]byte{1: small}, 2, large, true, nil, rtpExtensionProfileTwoByte},
+		// Two-byte header extensions need a=extmap-allow-mixed
+		{nil, 15, small, false, errHeaderExtensionNeedsTwoByte, 0},
+		{map[uint8][]byte{1: small}, 2, large, false, errHeaderExtensionNeedsTwoByte, rtpExtensionProfileOneByte},
+	}
+
+	for i, testCase := range testCases {
+		header := &rtp.Header{Version: 2}
+		for id, payload := range testCase.existing {
+			assert.NoError(t, header.SetExtension(id, payload), "testCase: %d %v", i, testCase)
+		}
+
+		err := setHeaderExtension(header, testCase.id, testCase.payload, testCase.allowMixed)
+		assert.ErrorIs(t, err, testCase.expectedErr, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedProfile, header.ExtensionProfile, "testCase: %d %v", i, testCase)
+		if err != nil {
+			assert.Nil(t, header.GetExtension(testCase.id), "testCase: %d %v", i, testCase)
+			continue
+		}
+
+		// pion/rtp only unmarshals extensions followed by a payload
+		raw, err := (&rtp.Packet{Header: *header, Payload: []byte{0x00}}).Marshal()
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+
+		packet := &rtp.Packet{}
+		assert.NoError(t, packet.Unmarshal(raw), "testCase: %d %v", i, testCase)
+		unmarshaled := &packet.Header
+		assert.Equal(t, testCase.payload, unmarshaled.GetExtension(testCase.id), "testCase: %d %v", i, testCase)
+		assert.Len(t, unmarshaled.GetExtensionIDs(), len(testCase.existing)+1, "testCase: %d %v", i, testCase)
+		for id, payload := range testCase.existing {
+			assert.Equal(t, payload, unmarshaled.GetExtension(id), "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestIsExtMapAllowMixedSet(t *testing.T) {
+	allowMixed := sdp.Attribute{Key: sdp.AttrKeyExtMapAllowMixed}
+
+	testCases := []struct {
+		desc     *sdp.SessionDescription
+		expected bool
+	}{
+		{&sdp.SessionDescription{}, false},
+		{&sdp.SessionDescription{Attributes: []sdp.Attribute{allowMixed}}, true},
+		{&sdp.SessionDescription{MediaDescriptions: []*sdp.MediaDescription{{}, {Attributes: []sdp.Attribute{allowMixed}}}}, true},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expected, isExtMapAllowMixedSet(testCase.desc), "testCase: %d %v", i, testCase)
+	}
+}
+
+// Assert that IDs of two-byte header extensions are only allocated once the
+// remote allowed them, and only to extensions no interceptor writes
+func TestMediaEngine_TwoByteHeaderExtensionIDs(t *testing.T) {
+	testCases := []struct {
+		allowMixed  bool
+		directions  []RTPTransceiverDirection
+		expectedIDs int
+	}{
+		{false, nil, maxOneByteHeaderExtensionID},
+		{false, []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly}, maxOneByteHeaderExtensionID},
+		{true, nil, maxOneByteHeaderExtensionID},
+		{true, []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly}, 20},
+	}
+
+	for i, testCase := range testCases {
+		m := &MediaEngine{}
+		assert.NoError(t, m.RegisterDefaultCodecs())
+		for j := 0; j < 20; j++ {
+			extension := RTPHeaderExtensionCapability{URI: fmt.Sprintf("urn:test:%d", j)}
+			assert.NoError(t, m.RegisterHeaderExtension(extension, RTPCodecTypeVideo, testCase.directions...))
+		}
+		m.negotiatedExtMapAllowMixed = testCase.allowMixed
+
+		params := m.getRTPParametersByKind(RTPCodecTypeVideo, []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly})
+		assert.Len(t, params.HeaderExtensions, testCase.expectedIDs, "testCase: %d %v", i, testCase)
+
+		ids := map[int]bool{}
+		for _, e := range params.HeaderExtensions {
+			assert.True(t, e.ID >= 1 && e.ID <= maxTwoByteHeaderExtensionID, "testCase: %d %v", i, testCase)
+			ids[e.ID] = true
+		}
+		assert.Len(t, ids, testCase.expectedIDs, "testCase: %d %v", i, testCase)
+	}
+
+	// Extensions written by Pion itself can use two-byte IDs
+	m := &MediaEngine{negotiatedExtMapAllowMixed: true}
+	assert.Equal(t, maxTwoByteHeaderExtensionID, m.maxHeaderExtensionID(mediaEngineHeaderExtension{
+		uri:               DependencyDescriptorURI,
+		allowedDirections: []RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
+	}))
+}
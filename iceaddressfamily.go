@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+)
+
+// ICEAddressFamilyPolicy sets how the priorities of ICE candidates are
+// ordered across the IPv4 and IPv6 address families.
+type ICEAddressFamilyPolicy int
+
+const (
+	// ICEAddressFamilyPolicyDefault keeps the priorities computed by the ICE
+	// agent.
+	ICEAddressFamilyPolicyDefault ICEAddressFamilyPolicy = iota
+
+	// ICEAddressFamilyPolicyPreferIPv6 ranks IPv6 candidates above the IPv4
+	// candidates of the same type.
+	ICEAddressFamilyPolicyPreferIPv6
+
+	// ICEAddressFamilyPolicyPreferIPv4 ranks IPv4 candidates above the IPv6
+	// candidates of the same type.
+	ICEAddressFamilyPolicyPreferIPv4
+
+	// ICEAddressFamilyPolicyInterleave alternates IPv6 and IPv4 among the
+	// local candidates of the same type, as recommended by RFC 8421, so that
+	// connectivity checks of a broken family don't delay the other one.
+	ICEAddressFamilyPolicyInterleave
+)
+
+// This is done this way because of a linter.
+const (
+	iceAddressFamilyPolicyDefaultStr    = "default"
+	iceAddressFamilyPolicyPreferIPv6Str = "prefer-ipv6"
+	iceAddressFamilyPolicyPreferIPv4Str = "prefer-ipv4"
+	iceAddressFamilyPolicyInterleaveStr = "interleave"
+)
+
+func (p ICEAddressFamilyPolicy) String() string {
+	switch p {
+	case ICEAddressFamilyPolicyDefault:
+		return iceAddressFamilyPolicyDefaultStr
+	case ICEAddressFamilyPolicyPreferIPv6:
+		return iceAddressFamilyPolicyPreferIPv6Str
+	case ICEAddressFamilyPolicyPreferIPv4:
+		return iceAddressFamilyPolicyPreferIPv4Str
+	case ICEAddressFamilyPolicyInterleave:
+		return iceAddressFamilyPolicyInterleaveStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// SetICEAddressFamilyPolicy sets how the priorities of the UDP candidates are
+// ordered across address families, for networks where one family is known to
+// be broken. The priorities of the local candidates are rewritten before they
+// are signaled, and with the prefer policies the priorities of the remote
+// candidates are rewritten before the connectivity checks. maxHostCandidates
+// caps the number of local host candidates signaled per address family, leave
+// it 0 for no limit.
+//
+// The ICE Agent keeps its own priorities for the local candidates, and still
+// sends connectivity checks from the host candidates over the cap. The
+// rewritten local priorities only steer the remote, which orders and
+// nominates the pairs when it is controlling. When Pion is controlling only
+// the prefer policies have an effect, through the remote priorities.
+func (e *SettingEngine) SetICEAddressFamilyPolicy(policy ICEAddressFamilyPolicy, maxHostCandidates int) {
+	e.iceAddressFamilyPolicy = policy
+	e.iceMaxHostCandidatesPerFamily = maxHostCandidates
+}
+
+// candidateIsIPv6 returns if c has an IPv6 address, and false for ok if its
+// address is a mDNS host name
+func candidateIsIPv6(c ICECandidate) (isIPv6 bool, ok bool) {
+	ip := net.ParseIP(c.Address)
+	if ip == nil {
+		return false, false
+	}
+
+	return ip.To4() == nil, true
+}
+
+// withLocalPreference returns priority with its local preference replaced
+func withLocalPreference(priority uint32, localPreference uint16) uint32 {
+	return priority&0xFF0000FF | uint32(localPreference)<<8
+}
+
+// preferAddressFamily returns the priority of c with the local preference of
+// the preferred family above the one of the other family, keeping the order
+// of the candidates of a family
+func preferAddressFamily(c ICECandidate, policy ICEAddressFamilyPolicy) uint32 {
+	isIPv6, ok := candidateIsIPv6(c)
+	if !ok || c.Protocol != ICEProtocolUDP {
+		return c.Priority
+	}
+
+	localPreference := uint16(c.Priority>>8) >> 1
+	if isIPv6 == (policy == ICEAddressFamilyPolicyPreferIPv6) {
+		localPreference |= 0x8000
+	}
+
+	return withLocalPreference(c.Priority, localPreference)
+}
+
+// remoteCandidatePriority returns the priority of the remote candidate c with
+// the address family policy of the SettingEngine applied
+func (e *SettingEngine) remoteCandidatePriority(c ICECandidate) uint32 {
+	switch e.iceAddressFamilyPolicy {
+	case ICEAddressFamilyPolicyPreferIPv6, ICEAddressFamilyPolicyPreferIPv4:
+		return preferAddressFamily(c, e.iceAddressFamilyPolicy)
+	default:
+		return c.Priority
+	}
+}
+
+// iceAddressFamilyKey groups the candidates ranked together by
+// ICEAddressFamilyPolicyInterleave
+type iceAddressFamilyKey struct {
+	typ    ICECandidateType
+	isIPv6 bool
+}
+
+// iceAddressFamilyRanker applies the address family policy to the local
+// candidates of an ICEGatherer. It remembers the decisions taken for every
+// candidate, so that a candidate has the same priority when it is trickled and
+// when it is listed by GetLocalCandidates.
+type iceAddressFamilyRanker struct {
+	policy            ICEAddressFamilyPolicy
+	maxHostCandidates int
+
+	mu         sync.Mutex
+	priorities map[string]uint32 // 0 for dropped candidates
+	counts     map[iceAddressFamilyKey]int
+	hostCounts map[bool]int
+}
+
+// newICEAddressFamilyRanker returns nil if there is nothing to apply
+func newICEAddressFamilyRanker(policy ICEAddressFamilyPolicy, maxHostCandidates int) *iceAddressFamilyRanker {
+	if policy == ICEAddressFamilyPolicyDefault && maxHostCandidates <= 0 {
+		return nil
+	}
+
+	r := &iceAddressFamilyRanker{
+		policy:            policy,
+		maxHostCandidates: maxHostCandidates,
+	}
+	r.reset()
+
+	return r
+}
+
+// reset forgets the candidates, to be called when candidates are gathered
+// again
+func (r *iceAddressFamilyRanker) reset() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.priorities = map[string]uint32{}
+	r.counts = map[iceAddressFamilyKey]int{}
+	r.hostCounts = map[bool]int{}
+}
+
+// rank rewrites the priority of c and returns false if c is dropped
+func (r *iceAddressFamilyRanker) rank(c *ICECandidate) bool {
+	if r == nil {
+		return true
+	}
+
+	isIPv6, ok := candidateIsIPv6(*c)
+	if !ok {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if priority, ok := r.priorities[c.statsID]; ok {
+		c.Priority = priority
+		return priority != 0
+	}
+
+	if c.Typ == ICECandidateTypeHost && r.maxHostCandidates > 0 {
+		if r.hostCounts[isIPv6] >= r.maxHostCandidates {
+			r.priorities[c.statsID] = 0
+			return false
+		}
+		r.hostCounts[isIPv6]++
+	}
+
+	switch {
+	case c.Protocol != ICEProtocolUDP:
+	case r.policy == ICEAddressFamilyPolicyPreferIPv6, r.policy == ICEAddressFamilyPolicyPreferIPv4:
+		c.Priority = preferAddressFamily(*c, r.policy)
+	case r.policy == ICEAddressFamilyPolicyInterleave:
+		// IPv6 first, then IPv4, then the second IPv6 and so on
+		key := iceAddressFamilyKey{typ: c.Typ, isIPv6: isIPv6}
+		rank := 2 * r.counts[key]
+		if !isIPv6 {
+			rank++
+		}
+		r.counts[key]++
+
+		localPreference := uint16(0)
+		if rank < 0xFFFF {
+			localPreference = uint16(0xFFFF - rank)
+		}
+		c.Priority = withLocalPreference(c.Priority, localPreference)
+	}
+	r.priorities[c.statsID] = c.Priority
+
+	return true
+}
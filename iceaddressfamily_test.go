@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hostPriority is the priority pion/ice gives to UDP host candidates
+const hostPriority = 126<<24 | 0xFFFF<<8 | 255
+
+func TestICEAddressFamilyPolicy_String(t *testing.T) {
+	testCases := []struct {
+		policy         ICEAddressFamilyPolicy
+		expectedString string
+	}{
+		{ICEAddressFamilyPolicyDefault, "default"},
+		{ICEAddressFamilyPolicyPreferIPv6, "prefer-ipv6"},
+		{ICEAddressFamilyPolicyPreferIPv4, "prefer-ipv4"},
+		{ICEAddressFamilyPolicyInterleave, "interleave"},
+		{ICEAddressFamilyPolicy(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.policy.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestSettingEngine_RemoteCandidatePriority(t *testing.T) {
+	ipv4 := ICECandidate{Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "192.168.1.2", Priority: hostPriority}
+	ipv6 := ICECandidate{Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "2001:db8::2", Priority: hostPriority}
+	tcp := ICECandidate{Typ: ICECandidateTypeHost, Protocol: ICEProtocolTCP, Address: "2001:db8::2", Priority: hostPriority}
+	mDNS := ICECandidate{Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "pion.local", Priority: hostPriority}
+
+	testCases := []struct {
+		policy           ICEAddressFamilyPolicy
+		candidate        ICECandidate
+		expectedPriority uint32
+	}{
+		{ICEAddressFamilyPolicyDefault, ipv4, hostPriority},
+		{ICEAddressFamilyPolicyInterleave, ipv4, hostPriority},
+		{ICEAddressFamilyPolicyPreferIPv6, ipv6, hostPriority},
+		{ICEAddressFamilyPolicyPreferIPv6, ipv4, 126<<24 | 0x7FFF<<8 | 255},
+		{ICEAddressFamilyPolicyPreferIPv4, ipv4, hostPriority},
+		{ICEAddressFamilyPolicyPreferIPv4, ipv6, 126<<24 | 0x7FFF<<8 | 255},
+		{ICEAddressFamilyPolicyPreferIPv4, tcp, hostPriority},
+		{ICEAddressFamilyPolicyPreferIPv4, mDNS, hostPriority},
+	}
+
+	for i, testCase := range testCases {
+		s := SettingEngine{}
+		s.SetICEAddressFamilyPolicy(testCase.policy, 0)
+		assert.Equal(t, testCase.expectedPriority, s.remoteCandidatePriority(testCase.candidate), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestICEAddressFamilyRanker(t *testing.T) {
+	assert.Nil(t, newICEAddressFamilyRanker(ICEAddressFamilyPolicyDefault, 0))
+
+	candidate := func(id, address string) ICECandidate {
+		return ICECandidate{statsID: id, Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: address, Priority: hostPriority}
+	}
+
+	r := newICEAddressFamilyRanker(ICEAddressFamilyPolicyInterleave, 2)
+
+	testCases := []struct {
+		candidate        ICECandidate
+		expectedAllowed  bool
+		expectedPriority uint32
+	}{
+		{candidate("a", "192.168.1.2"), true, 126<<24 | 0xFFFE<<8 | 255},
+		{candidate("b", "192.168.2.2"), true, 126<<24 | 0xFFFC<<8 | 255},
+		{candidate("c", "2001:db8::2"), true, hostPriority},
+		// Over the cap of the IPv4 host candidates
+		{candidate("d", "192.168.3.2"), false, 0},
+		{candidate("e", "2001:db8::3"), true, 126<<24 | 0xFFFD<<8 | 255},
+		// The decisions are remembered
+		{candidate("a", "192.168.1.2"), true, 126<<24 | 0xFFFE<<8 | 255},
+		{candidate("d", "192.168.3.2"), false, 0},
+		// mDNS candidates are neither ranked nor capped
+		{candidate("f", "pion.local"), true, hostPriority},
+	}
+
+	for i, testCase := range testCases {
+		c := testCase.candidate
+		assert.Equal(t, testCase.expectedAllowed, r.rank(&c), "testCase: %d %v", i, testCase)
+		if testCase.expectedAllowed {
+			assert.Equal(t, testCase.expectedPriority, c.Priority, "testCase: %d %v", i, testCase)
+		}
+	}
+
+	// Gathering again starts over
+	r.reset()
+	c := candidate("d", "192.168.3.2")
+	assert.True(t, r.rank(&c))
+	assert.Equal(t, uint32(126<<24|0xFFFE<<8|255), c.Priority)
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v3"
+)
+
+// iceAgentConn is the connection of an ICETransport to the ICE Agent. The
+// Agent is replaced on an ICE restart if the ICE servers changed, reads
+// failing on the connection of the closed Agent wait for the connection of
+// the new one.
+type iceAgentConn struct {
+	mu   sync.Mutex
+	conn *ice.Conn
+	// replacing is closed once the connection of the new Agent is set, it is
+	// nil if no Agent is being replaced
+	replacing chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newICEAgentConn(conn *ice.Conn) *iceAgentConn {
+	return &iceAgentConn{
+		conn:   conn,
+		closed: make(chan struct{}),
+	}
+}
+
+func (c *iceAgentConn) current() *ice.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.conn
+}
+
+// replace must be called before the Agent of the connection is closed
+func (c *iceAgentConn) replace() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.replacing == nil {
+		c.replacing = make(chan struct{})
+	}
+}
+
+// isReplacing returns true if the connection of the new Agent isn't set yet
+func (c *iceAgentConn) isReplacing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.replacing != nil
+}
+
+// set sets the connection of the new Agent
+func (c *iceAgentConn) set(conn *ice.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn = conn
+	if c.replacing != nil {
+		close(c.replacing)
+		c.replacing = nil
+	}
+}
+
+func (c *iceAgentConn) Read(b []byte) (int, error) {
+	for {
+		conn := c.current()
+		n, err := conn.Read(b)
+		if err == nil {
+			return n, nil
+		}
+
+		c.mu.Lock()
+		replaced, replacing := c.conn != conn, c.replacing
+		c.mu.Unlock()
+
+		switch {
+		case replaced:
+			continue
+		case replacing == nil:
+			return n, err
+		}
+
+		select {
+		case <-replacing:
+		case <-c.closed:
+			return 0, ice.ErrClosed
+		}
+	}
+}
+
+func (c *iceAgentConn) Write(b []byte) (int, error) {
+	return c.current().Write(b)
+}
+
+// Close closes the connection, the Agent isn't closed if it is being
+// replaced since the connection doesn't own the new Agent yet
+func (c *iceAgentConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	if c.isReplacing() {
+		return nil
+	}
+
+	return c.current().Close()
+}
+
+func (c *iceAgentConn) LocalAddr() net.Addr {
+	return c.current().LocalAddr()
+}
+
+func (c *iceAgentConn) RemoteAddr() net.Addr {
+	return c.current().RemoteAddr()
+}
+
+func (c *iceAgentConn) SetDeadline(t time.Time) error {
+	return c.current().SetDeadline(t)
+}
+
+func (c *iceAgentConn) SetReadDeadline(t time.Time) error {
+	return c.current().SetReadDeadline(t)
+}
+
+func (c *iceAgentConn) SetWriteDeadline(t time.Time) error {
+	return c.current().SetWriteDeadline(t)
+}
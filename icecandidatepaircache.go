@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pion/sdp/v3"
+)
+
+// ICECandidatePairCache stores the candidate pair a PeerConnection last
+// connected with, keyed by the DTLS fingerprint of the remote. It is used to
+// prefer the same pair on the next connection to that remote, so frequently
+// reconnecting peers keep using a pair known to work. Implementations can
+// persist the pairs across restarts, ICECandidatePair can be marshaled to JSON.
+// See SettingEngine.SetICECandidatePairCache.
+type ICECandidatePairCache interface {
+	// Get returns the pair stored for fingerprint, and false if there is none
+	Get(fingerprint string) (*ICECandidatePair, bool)
+
+	// Put stores the pair a PeerConnection connected with
+	Put(fingerprint string, pair *ICECandidatePair)
+}
+
+// SetICECandidatePairCache sets the cache of the candidate pairs connected
+// with. The remote candidate of the cached pair is added first among the
+// remote candidates of a SessionDescription, with the highest priority, so
+// its pairs outrank the pairs of the other remote candidates. The pair
+// nominated by a controlling remote isn't affected, and candidates trickled
+// with AddICECandidate are added unchanged.
+func (e *SettingEngine) SetICECandidatePairCache(cache ICECandidatePairCache) {
+	e.iceCandidatePairCache = cache
+}
+
+// memoryICECandidatePairCache is the ICECandidatePairCache returned by
+// NewICECandidatePairCache
+type memoryICECandidatePairCache struct {
+	mu    sync.Mutex
+	pairs map[string]*ICECandidatePair
+	order []string
+	size  int
+}
+
+// NewICECandidatePairCache returns an in-memory ICECandidatePairCache holding
+// the pairs of up to size remotes, evicting the remote stored first once it
+// is full
+func NewICECandidatePairCache(size int) ICECandidatePairCache {
+	return &memoryICECandidatePairCache{
+		pairs: map[string]*ICECandidatePair{},
+		size:  size,
+	}
+}
+
+func (c *memoryICECandidatePairCache) Get(fingerprint string) (*ICECandidatePair, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pair, ok := c.pairs[fingerprint]
+	return pair, ok
+}
+
+func (c *memoryICECandidatePairCache) Put(fingerprint string, pair *ICECandidatePair) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+
+	if _, ok := c.pairs[fingerprint]; !ok {
+		if len(c.order) >= c.size {
+			delete(c.pairs, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, fingerprint)
+	}
+	c.pairs[fingerprint] = pair
+}
+
+// iceCandidatePairCacheKey returns the key of the remote with a fingerprint
+// in the format of its SessionDescription
+func iceCandidatePairCacheKey(fingerprintHash, fingerprint string) string {
+	return strings.ToLower(fingerprintHash) + " " + strings.ToUpper(fingerprint)
+}
+
+// iceCachedCandidatePriority is the priority of the remote candidate of a
+// cached pair, the highest priority of RFC 8445 Section 5.1.2
+const iceCachedCandidatePriority = 1<<31 - 1
+
+// preferCachedICECandidate moves the remote candidate of the pair cached for
+// the remote of desc to the front of candidates, and raises its priority
+func (pc *PeerConnection) preferCachedICECandidate(desc *sdp.SessionDescription, candidates []ICECandidate) {
+	cache := pc.api.settingEngine.iceCandidatePairCache
+	if cache == nil || len(candidates) < 2 {
+		return
+	}
+
+	fingerprint, fingerprintHash, err := extractFingerprint(desc)
+	if err != nil {
+		return
+	}
+
+	pair, ok := cache.Get(iceCandidatePairCacheKey(fingerprintHash, fingerprint))
+	if !ok || pair == nil || pair.Remote == nil {
+		return
+	}
+
+	for i, c := range candidates {
+		if c.Protocol == pair.Remote.Protocol && c.Address == pair.Remote.Address && c.Port == pair.Remote.Port {
+			c.Priority = iceCachedCandidatePriority
+			copy(candidates[1:i+1], candidates[:i])
+			candidates[0] = c
+			pc.log.Debugf("Preferring cached remote candidate %s", c)
+			return
+		}
+	}
+}
+
+// cacheSelectedICECandidatePair stores the selected candidate pair for the
+// remote with the fingerprint, once DTLS is connected
+func (pc *PeerConnection) cacheSelectedICECandidatePair(fingerprintHash, fingerprint string) {
+	cache := pc.api.settingEngine.iceCandidatePairCache
+	if cache == nil {
+		return
+	}
+
+	pair, err := pc.iceTransport.GetSelectedCandidatePair()
+	if err != nil || pair == nil {
+		return
+	}
+
+	cache.Put(iceCandidatePairCacheKey(fingerprintHash, fingerprint), pair)
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICECandidatePairCache_Evict(t *testing.T) {
+	cache := NewICECandidatePairCache(2)
+
+	first := &ICECandidatePair{Remote: &ICECandidate{Address: "10.0.0.1"}}
+	cache.Put("a", first)
+	cache.Put("b", &ICECandidatePair{})
+	cache.Put("a", first)
+	cache.Put("c", &ICECandidatePair{})
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+
+	for _, fingerprint := range []string{"b", "c"} {
+		_, ok = cache.Get(fingerprint)
+		assert.True(t, ok, fingerprint)
+	}
+}
+
+func TestPeerConnection_PreferCachedICECandidate(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	parsed, err := offer.Unmarshal()
+	assert.NoError(t, err)
+
+	fingerprint, fingerprintHash, err := extractFingerprint(parsed)
+	assert.NoError(t, err)
+
+	cache := NewICECandidatePairCache(1)
+	cache.Put(iceCandidatePairCacheKey(fingerprintHash, fingerprint), &ICECandidatePair{
+		Remote: &ICECandidate{Protocol: ICEProtocolUDP, Address: "10.0.0.3", Port: 5000},
+	})
+	pc.api.settingEngine.SetICECandidatePairCache(cache)
+
+	candidates := []ICECandidate{
+		{Protocol: ICEProtocolUDP, Address: "10.0.0.1", Port: 5000},
+		{Protocol: ICEProtocolUDP, Address: "10.0.0.2", Port: 5000},
+		{Protocol: ICEProtocolUDP, Address: "10.0.0.3", Port: 5000},
+	}
+	pc.preferCachedICECandidate(parsed, candidates)
+
+	addresses := []string{}
+	for _, c := range candidates {
+		addresses = append(addresses, c.Address)
+	}
+	assert.Equal(t, []string{"10.0.0.3", "10.0.0.1", "10.0.0.2"}, addresses)
+	assert.Equal(t, uint32(iceCachedCandidatePriority), candidates[0].Priority)
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestPeerConnection_ICECandidatePairCache(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cache := NewICECandidatePairCache(1)
+	s := SettingEngine{}
+	s.SetICECandidatePairCache(cache)
+
+	pcOffer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	answer, err := pcAnswer.LocalDescription().Unmarshal()
+	assert.NoError(t, err)
+	fingerprint, fingerprintHash, err := extractFingerprint(answer)
+	assert.NoError(t, err)
+
+	key := iceCandidatePairCacheKey(fingerprintHash, fingerprint)
+	assert.Eventually(t, func() bool {
+		_, ok := cache.Get(key)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pair, ok := cache.Get(key)
+	if assert.True(t, ok) {
+		selected, err := pcOffer.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+		assert.NoError(t, err)
+		assert.Equal(t, selected.Remote.Address, pair.Remote.Address)
+		assert.Equal(t, selected.Remote.Port, pair.Remote.Port)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
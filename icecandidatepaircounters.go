@@ -0,0 +1,310 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/pion/transport/v3"
+)
+
+const (
+	stunHeaderSize        = 20
+	stunMagicCookie       = 0x2112A442
+	stunBindingRequest    = 0x0001
+	stunBindingSuccess    = 0x0101
+	stunBindingError      = 0x0111
+	stunTransactionIDSize = 12
+
+	// iceMaxPendingRequests bounds the binding requests of a pair waiting
+	// for a response, older ones never get one
+	iceMaxPendingRequests = 64
+)
+
+// iceCandidatePairCounters counts the packets, bytes and binding
+// transactions of the candidate pairs, which the ICE agent doesn't. They are
+// observed on the UDP sockets the agent opens with iceCountingNet, so pairs
+// of relay and TCP candidates, of sockets of an ICEUDPMux and of remote mDNS
+// candidates aren't counted.
+type iceCandidatePairCounters struct {
+	mu    sync.Mutex
+	pairs map[iceSocketPair]*iceSocketPairCounters
+}
+
+// iceSocketPair identifies a candidate pair by the port of the local socket
+// and the address of the remote candidate
+type iceSocketPair struct {
+	localPort int
+	remote    string
+}
+
+type iceSocketPairCounters struct {
+	packetsSent, packetsReceived                                     uint32
+	bytesSent, bytesReceived                                         uint64
+	lastPacketSent, lastPacketReceived                               time.Time
+	requestsSent, requestsReceived, responsesSent, responsesReceived uint64
+	retransmissionsSent                                              uint64
+	firstRequest, lastRequest, lastResponse                          time.Time
+
+	// Round trip times in seconds
+	totalRoundTripTime, currentRoundTripTime float64
+
+	pendingRequests map[[stunTransactionIDSize]byte]time.Time
+}
+
+func newICECandidatePairCounters() *iceCandidatePairCounters {
+	return &iceCandidatePairCounters{pairs: map[iceSocketPair]*iceSocketPairCounters{}}
+}
+
+// observe counts the packet b sent or received on the socket with the local
+// port localPort
+func (c *iceCandidatePairCounters) observe(sent bool, localPort int, remote net.Addr, b []byte, now time.Time) {
+	if c == nil || remote == nil {
+		return
+	}
+
+	key := iceSocketPair{localPort: localPort, remote: remote.String()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pair, ok := c.pairs[key]
+	if !ok {
+		pair = &iceSocketPairCounters{pendingRequests: map[[stunTransactionIDSize]byte]time.Time{}}
+		c.pairs[key] = pair
+	}
+
+	if sent {
+		pair.packetsSent++
+		pair.bytesSent += uint64(len(b))
+		pair.lastPacketSent = now
+	} else {
+		pair.packetsReceived++
+		pair.bytesReceived += uint64(len(b))
+		pair.lastPacketReceived = now
+	}
+
+	// STUN messages start with two zero bits and have a fixed magic cookie
+	if len(b) < stunHeaderSize || b[0] > 1 || binary.BigEndian.Uint32(b[4:8]) != stunMagicCookie {
+		return
+	}
+
+	var transactionID [stunTransactionIDSize]byte
+	copy(transactionID[:], b[8:stunHeaderSize])
+
+	switch messageType := binary.BigEndian.Uint16(b[0:2]); {
+	case messageType == stunBindingRequest && sent:
+		pair.requestsSent++
+		if pair.firstRequest.IsZero() {
+			pair.firstRequest = now
+		}
+		pair.lastRequest = now
+
+		if _, ok := pair.pendingRequests[transactionID]; ok {
+			pair.retransmissionsSent++
+			return
+		}
+		if len(pair.pendingRequests) >= iceMaxPendingRequests {
+			pair.dropOldestPendingRequest()
+		}
+		pair.pendingRequests[transactionID] = now
+	case messageType == stunBindingRequest:
+		pair.requestsReceived++
+	case (messageType == stunBindingSuccess || messageType == stunBindingError) && sent:
+		pair.responsesSent++
+	case messageType == stunBindingSuccess || messageType == stunBindingError:
+		pair.responsesReceived++
+		pair.lastResponse = now
+
+		if requestSent, ok := pair.pendingRequests[transactionID]; ok {
+			delete(pair.pendingRequests, transactionID)
+			pair.currentRoundTripTime = now.Sub(requestSent).Seconds()
+			pair.totalRoundTripTime += pair.currentRoundTripTime
+		}
+	}
+}
+
+func (p *iceSocketPairCounters) dropOldestPendingRequest() {
+	var (
+		oldestID   [stunTransactionIDSize]byte
+		oldestTime time.Time
+	)
+	for id, sentAt := range p.pendingRequests {
+		if oldestTime.IsZero() || sentAt.Before(oldestTime) {
+			oldestID, oldestTime = id, sentAt
+		}
+	}
+	delete(p.pendingRequests, oldestID)
+}
+
+// fill sets the counters of the pair of local and remote on stats
+func (c *iceCandidatePairCounters) fill(stats *ICECandidatePairStats, local, remote ice.Candidate) {
+	if c == nil {
+		return
+	}
+
+	key, ok := iceSocketPairOf(local, remote)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pair, ok := c.pairs[key]
+	if !ok {
+		return
+	}
+
+	stats.PacketsSent = pair.packetsSent
+	stats.PacketsReceived = pair.packetsReceived
+	stats.BytesSent = pair.bytesSent
+	stats.BytesReceived = pair.bytesReceived
+	stats.LastPacketSentTimestamp = iceCountersTimestamp(pair.lastPacketSent)
+	stats.LastPacketReceivedTimestamp = iceCountersTimestamp(pair.lastPacketReceived)
+	stats.FirstRequestTimestamp = iceCountersTimestamp(pair.firstRequest)
+	stats.LastRequestTimestamp = iceCountersTimestamp(pair.lastRequest)
+	stats.LastResponseTimestamp = iceCountersTimestamp(pair.lastResponse)
+	stats.TotalRoundTripTime = pair.totalRoundTripTime
+	stats.CurrentRoundTripTime = pair.currentRoundTripTime
+	stats.RequestsSent = pair.requestsSent
+	stats.RequestsReceived = pair.requestsReceived
+	stats.ResponsesSent = pair.responsesSent
+	stats.ResponsesReceived = pair.responsesReceived
+	stats.RetransmissionsSent = pair.retransmissionsSent
+}
+
+// firstUnansweredRequest returns when the first binding request sent on the
+// pair of local and remote after the last response received was sent, zero
+// if there is none, and false if the pair isn't counted
+func (c *iceCandidatePairCounters) firstUnansweredRequest(local, remote ice.Candidate) (time.Time, bool) {
+	if c == nil {
+		return time.Time{}, false
+	}
+
+	key, ok := iceSocketPairOf(local, remote)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pair, ok := c.pairs[key]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var first time.Time
+	for _, sentAt := range pair.pendingRequests {
+		if sentAt.After(pair.lastResponse) && (first.IsZero() || sentAt.Before(first)) {
+			first = sentAt
+		}
+	}
+
+	return first, true
+}
+
+// iceSocketPairOf returns the socket pair of the candidate pair of local and
+// remote. Server reflexive candidates share the socket of their base.
+func iceSocketPairOf(local, remote ice.Candidate) (iceSocketPair, bool) {
+	if local.NetworkType().IsTCP() || local.Type() == ice.CandidateTypeRelay {
+		return iceSocketPair{}, false
+	}
+
+	localPort := local.Port()
+	if related := local.RelatedAddress(); local.Type() == ice.CandidateTypeServerReflexive && related != nil {
+		localPort = related.Port
+	}
+
+	remoteIP := net.ParseIP(remote.Address())
+	if remoteIP == nil {
+		return iceSocketPair{}, false
+	}
+	if ip4 := remoteIP.To4(); ip4 != nil {
+		remoteIP = ip4
+	}
+
+	return iceSocketPair{
+		localPort: localPort,
+		remote:    net.JoinHostPort(remoteIP.String(), strconv.Itoa(remote.Port())),
+	}, true
+}
+
+// iceCountingNet is a transport.Net whose UDP sockets count the candidate
+// pairs with iceCandidatePairCounters
+type iceCountingNet struct {
+	transport.Net
+	counters *iceCandidatePairCounters
+}
+
+func newICECountingNet(base transport.Net, counters *iceCandidatePairCounters) transport.Net {
+	return &iceCountingNet{Net: base, counters: counters}
+}
+
+func (n *iceCountingNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.Net.ListenUDP(network, locAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The mDNS socket joins its multicast group through the socket options
+	// of the unwrapped connection, and carries no candidate pair
+	if locAddr != nil && locAddr.IP.IsMulticast() {
+		return conn, nil
+	}
+
+	return &iceCountingUDPConn{UDPConn: conn, counters: n.counters, localPort: localPortOf(conn.LocalAddr())}, nil
+}
+
+func localPortOf(addr net.Addr) int {
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		return udpAddr.Port
+	}
+
+	return 0
+}
+
+// iceCountingUDPConn counts the packets the ICE agent sends and receives
+type iceCountingUDPConn struct {
+	transport.UDPConn
+	counters  *iceCandidatePairCounters
+	localPort int
+}
+
+func (c *iceCountingUDPConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.UDPConn.ReadFrom(b)
+	if err == nil {
+		c.counters.observe(false, c.localPort, addr, b[:n], time.Now())
+	}
+
+	return n, addr, err
+}
+
+func (c *iceCountingUDPConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	n, err := c.UDPConn.WriteTo(b, addr)
+	if err == nil {
+		c.counters.observe(true, c.localPort, addr, b[:n], time.Now())
+	}
+
+	return n, err
+}
+
+// iceCountersTimestamp leaves the timestamps of events which did not happen
+// yet unset
+func iceCountersTimestamp(t time.Time) StatsTimestamp {
+	if t.IsZero() {
+		return 0
+	}
+
+	return statsTimestampFrom(t)
+}
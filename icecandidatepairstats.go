@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/pion/logging"
+)
+
+const defaultICECandidatePairStatsInterval = time.Second
+
+// newICECandidatePairStats converts the stats of a candidate pair of the ICE agent
+func newICECandidatePairStats(candidatePairStats ice.CandidatePairStats, log logging.LeveledLogger) ICECandidatePairStats {
+	state, err := toStatsICECandidatePairState(candidatePairStats.State)
+	if err != nil {
+		log.Error(err.Error())
+	}
+
+	pairID := newICECandidatePairStatsID(candidatePairStats.LocalCandidateID,
+		candidatePairStats.RemoteCandidateID)
+
+	return ICECandidatePairStats{
+		Timestamp: statsTimestampFrom(candidatePairStats.Timestamp),
+		Type:      StatsTypeCandidatePair,
+		ID:        pairID,
+		// TransportID:
+		LocalCandidateID:            candidatePairStats.LocalCandidateID,
+		RemoteCandidateID:           candidatePairStats.RemoteCandidateID,
+		State:                       state,
+		Nominated:                   candidatePairStats.Nominated,
+		PacketsSent:                 candidatePairStats.PacketsSent,
+		PacketsReceived:             candidatePairStats.PacketsReceived,
+		BytesSent:                   candidatePairStats.BytesSent,
+		BytesReceived:               candidatePairStats.BytesReceived,
+		LastPacketSentTimestamp:     statsTimestampFrom(candidatePairStats.LastPacketSentTimestamp),
+		LastPacketReceivedTimestamp: statsTimestampFrom(candidatePairStats.LastPacketReceivedTimestamp),
+		FirstRequestTimestamp:       statsTimestampFrom(candidatePairStats.FirstRequestTimestamp),
+		LastRequestTimestamp:        statsTimestampFrom(candidatePairStats.LastRequestTimestamp),
+		LastResponseTimestamp:       statsTimestampFrom(candidatePairStats.LastResponseTimestamp),
+		TotalRoundTripTime:          candidatePairStats.TotalRoundTripTime,
+		CurrentRoundTripTime:        candidatePairStats.CurrentRoundTripTime,
+		AvailableOutgoingBitrate:    candidatePairStats.AvailableOutgoingBitrate,
+		AvailableIncomingBitrate:    candidatePairStats.AvailableIncomingBitrate,
+		CircuitBreakerTriggerCount:  candidatePairStats.CircuitBreakerTriggerCount,
+		RequestsReceived:            candidatePairStats.RequestsReceived,
+		RequestsSent:                candidatePairStats.RequestsSent,
+		ResponsesReceived:           candidatePairStats.ResponsesReceived,
+		ResponsesSent:               candidatePairStats.ResponsesSent,
+		RetransmissionsReceived:     candidatePairStats.RetransmissionsReceived,
+		RetransmissionsSent:         candidatePairStats.RetransmissionsSent,
+		ConsentRequestsSent:         candidatePairStats.ConsentRequestsSent,
+		ConsentExpiredTimestamp:     statsTimestampFrom(candidatePairStats.ConsentExpiredTimestamp),
+	}
+}
+
+// GetCandidatePairsStats returns the current stats of every candidate pair of
+// the ICETransport. Unlike PeerConnection.GetStats it only queries the ICE
+// agent, so it is cheap enough to be polled.
+func (t *ICETransport) GetCandidatePairsStats() []ICECandidatePairStats {
+	t.lock.RLock()
+	gatherer := t.gatherer
+	t.lock.RUnlock()
+	if gatherer == nil {
+		return nil
+	}
+
+	agent := gatherer.getAgent()
+	if agent == nil {
+		return nil
+	}
+
+	return gatherer.candidatePairsStats(agent)
+}
+
+// candidatePairsStats returns the stats of the candidate pairs of agent, with
+// the counters the agent doesn't maintain filled from the pairCounters
+func (g *ICEGatherer) candidatePairsStats(agent *ice.Agent) []ICECandidatePairStats {
+	candidates := map[string]ice.Candidate{}
+	if local, err := agent.GetLocalCandidates(); err == nil {
+		for _, c := range local {
+			candidates[c.ID()] = c
+		}
+	}
+	if remote, err := agent.GetRemoteCandidates(); err == nil {
+		for _, c := range remote {
+			candidates[c.ID()] = c
+		}
+	}
+
+	candidatePairsStats := agent.GetCandidatePairsStats()
+	stats := make([]ICECandidatePairStats, 0, len(candidatePairsStats))
+	for _, candidatePairStats := range candidatePairsStats {
+		pairStats := newICECandidatePairStats(candidatePairStats, g.log)
+
+		local, localOK := candidates[candidatePairStats.LocalCandidateID]
+		remote, remoteOK := candidates[candidatePairStats.RemoteCandidateID]
+		if localOK && remoteOK {
+			g.pairCounters.fill(&pairStats, local, remote)
+		}
+
+		stats = append(stats, pairStats)
+	}
+
+	return stats
+}
+
+// SetICECandidatePairStatsInterval sets how often
+// PeerConnection.OnICECandidatePairStats is invoked, one second by default.
+func (e *SettingEngine) SetICECandidatePairStatsInterval(interval time.Duration) {
+	e.iceCandidatePairStatsInterval = interval
+}
+
+// OnICECandidatePairStats sets an event handler which is invoked with the
+// stats of every ICE candidate pair every
+// SettingEngine.SetICECandidatePairStatsInterval while the ICE connection is
+// alive. Besides the state and nominated flag, the packets, bytes, binding
+// requests and responses and round trip times are counted from the traffic
+// of the ICE agent sockets, which allows to follow them without calling
+// GetStats. Pairs using a relay or TCP candidate, the ICEUDPMux or a remote
+// mDNS candidate are not counted and only report their state.
+func (pc *PeerConnection) OnICECandidatePairStats(f func([]ICECandidatePairStats)) {
+	pc.onICECandidatePairStatsHandler.Store(f)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.iceCandidatePairStatsMonitor == nil && !pc.isClosed.get() {
+		pc.iceCandidatePairStatsMonitor = newICECandidatePairStatsMonitor(pc)
+	}
+}
+
+func (pc *PeerConnection) onICECandidatePairStats(stats []ICECandidatePairStats) {
+	if handler, ok := pc.onICECandidatePairStatsHandler.Load().(func([]ICECandidatePairStats)); ok && handler != nil {
+		handler(stats)
+	}
+}
+
+// iceCandidatePairStatsMonitor periodically emits the candidate pair stats of
+// a PeerConnection
+type iceCandidatePairStatsMonitor struct {
+	pc       *PeerConnection
+	interval time.Duration
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newICECandidatePairStatsMonitor(pc *PeerConnection) *iceCandidatePairStatsMonitor {
+	interval := pc.api.settingEngine.iceCandidatePairStatsInterval
+	if interval <= 0 {
+		interval = defaultICECandidatePairStatsInterval
+	}
+
+	m := &iceCandidatePairStatsMonitor{
+		pc:       pc,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	go m.run()
+
+	return m
+}
+
+func (m *iceCandidatePairStatsMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		switch m.pc.ICEConnectionState() {
+		case ICEConnectionStateChecking, ICEConnectionStateConnected, ICEConnectionStateCompleted, ICEConnectionStateDisconnected:
+		default:
+			continue
+		}
+
+		if stats := m.pc.iceTransport.GetCandidatePairsStats(); len(stats) != 0 {
+			m.pc.onICECandidatePairStats(stats)
+		}
+	}
+}
+
+func (m *iceCandidatePairStatsMonitor) close() {
+	if m == nil {
+		return
+	}
+
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+}
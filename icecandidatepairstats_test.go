@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerConnection_OnICECandidatePairStats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetICECandidatePairStatsInterval(50 * time.Millisecond)
+
+	pcOffer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// The ICETransport has no candidate pair before negotiating
+	assert.Empty(t, pcOffer.iceTransport.GetCandidatePairsStats())
+
+	var once sync.Once
+	nominated := make(chan ICECandidatePairStats)
+	pcOffer.OnICECandidatePairStats(func(stats []ICECandidatePairStats) {
+		for _, pairStats := range stats {
+			if pairStats.Nominated && pairStats.ResponsesReceived > 0 && pairStats.CurrentRoundTripTime > 0 {
+				once.Do(func() {
+					nominated <- pairStats
+				})
+			}
+		}
+	})
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	pairStats := <-nominated
+	assert.Equal(t, StatsTypeCandidatePair, pairStats.Type)
+	assert.Equal(t, StatsICECandidatePairStateSucceeded, pairStats.State)
+	assert.NotEmpty(t, pairStats.LocalCandidateID)
+	assert.NotEmpty(t, pairStats.RemoteCandidateID)
+	assert.NotZero(t, pairStats.RequestsSent)
+	assert.NotZero(t, pairStats.PacketsSent)
+	assert.NotZero(t, pairStats.BytesSent)
+	assert.NotZero(t, pairStats.BytesReceived)
+	assert.NotZero(t, pairStats.TotalRoundTripTime)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestICECandidatePairCounters(t *testing.T) {
+	stunMessage := func(messageType uint16, transactionID byte) []byte {
+		b := make([]byte, stunHeaderSize)
+		binary.BigEndian.PutUint16(b[0:2], messageType)
+		binary.BigEndian.PutUint32(b[4:8], stunMagicCookie)
+		b[8] = transactionID
+		return b
+	}
+
+	local, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network:   "udp",
+		Address:   "192.168.0.1",
+		Port:      5000,
+		Component: 1,
+	})
+	assert.NoError(t, err)
+	remote, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network:   "udp",
+		Address:   "192.168.0.2",
+		Port:      6000,
+		Component: 1,
+	})
+	assert.NoError(t, err)
+	remoteAddr := &net.UDPAddr{IP: net.ParseIP("192.168.0.2"), Port: 6000}
+
+	counters := newICECandidatePairCounters()
+	now := time.Now()
+
+	counters.observe(true, 5000, remoteAddr, stunMessage(stunBindingRequest, 1), now)
+	counters.observe(true, 5000, remoteAddr, stunMessage(stunBindingRequest, 1), now.Add(10*time.Millisecond))
+	counters.observe(false, 5000, remoteAddr, stunMessage(stunBindingRequest, 2), now.Add(20*time.Millisecond))
+	counters.observe(true, 5000, remoteAddr, stunMessage(stunBindingSuccess, 2), now.Add(20*time.Millisecond))
+	counters.observe(false, 5000, remoteAddr, stunMessage(stunBindingSuccess, 1), now.Add(50*time.Millisecond))
+	counters.observe(false, 5000, remoteAddr, []byte{0x80, 0x60, 0x00, 0x01}, now.Add(60*time.Millisecond))
+
+	// Traffic of another socket is not counted for the pair
+	counters.observe(true, 5001, remoteAddr, []byte{0x80}, now)
+
+	stats := ICECandidatePairStats{}
+	counters.fill(&stats, local, remote)
+
+	assert.Equal(t, uint32(3), stats.PacketsSent)
+	assert.Equal(t, uint32(3), stats.PacketsReceived)
+	assert.Equal(t, uint64(3*stunHeaderSize), stats.BytesSent)
+	assert.Equal(t, uint64(2*stunHeaderSize+4), stats.BytesReceived)
+	assert.Equal(t, uint64(2), stats.RequestsSent)
+	assert.Equal(t, uint64(1), stats.RetransmissionsSent)
+	assert.Equal(t, uint64(1), stats.RequestsReceived)
+	assert.Equal(t, uint64(1), stats.ResponsesSent)
+	assert.Equal(t, uint64(1), stats.ResponsesReceived)
+	assert.InDelta(t, 0.05, stats.CurrentRoundTripTime, 1e-9)
+	assert.InDelta(t, 0.05, stats.TotalRoundTripTime, 1e-9)
+	assert.Equal(t, statsTimestampFrom(now), stats.FirstRequestTimestamp)
+	assert.Equal(t, statsTimestampFrom(now.Add(50*time.Millisecond)), stats.LastResponseTimestamp)
+
+	// Every request got a response
+	unanswered, ok := counters.firstUnansweredRequest(local, remote)
+	assert.True(t, ok)
+	assert.True(t, unanswered.IsZero())
+
+	counters.observe(true, 5000, remoteAddr, stunMessage(stunBindingRequest, 3), now.Add(time.Second))
+	counters.observe(true, 5000, remoteAddr, stunMessage(stunBindingRequest, 4), now.Add(2*time.Second))
+	unanswered, ok = counters.firstUnansweredRequest(local, remote)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Second), unanswered)
+
+	// A response answers the requests sent before it
+	counters.observe(false, 5000, remoteAddr, stunMessage(stunBindingSuccess, 4), now.Add(3*time.Second))
+	unanswered, ok = counters.firstUnansweredRequest(local, remote)
+	assert.True(t, ok)
+	assert.True(t, unanswered.IsZero())
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pion/transport/v3"
+)
+
+// ICECandidatePriorityFunc returns the priority of a local candidate. It is
+// given the candidate, with the priority computed by the ICE agent, and the
+// name of the network interface the candidate was gathered from, empty if it
+// is unknown. Return candidate.Priority to keep it.
+type ICECandidatePriorityFunc func(candidate ICECandidate, networkInterface string) uint32
+
+// SetICECandidatePriorityFunc sets a function overriding the priorities of the
+// local candidates, so that path selection reflects what the application knows
+// about the cost of the links, like deprioritizing a metered cellular
+// interface. The priorities are overridden in the candidates signaled to the
+// remote, which orders its connectivity checks and, when it is controlling,
+// nominates the pair with them. The ICE Agent keeps its own priorities, so
+// the function has no effect on the pairs nominated when Pion is
+// controlling. The function is called once per candidate, after the policy
+// of SetICEAddressFamilyPolicy is applied.
+func (e *SettingEngine) SetICECandidatePriorityFunc(f ICECandidatePriorityFunc) {
+	e.iceCandidatePriorityFunc = f
+}
+
+// iceCandidatePriorityOverrider applies the ICECandidatePriorityFunc to the
+// local candidates of an ICEGatherer. It remembers the priority returned for
+// every candidate, so that the function is called once per candidate, and
+// the network interfaces, so that they are enumerated once per gathering.
+type iceCandidatePriorityOverrider struct {
+	priorityFunc ICECandidatePriorityFunc
+	net          transport.Net
+
+	mu         sync.Mutex
+	priorities map[string]uint32
+	interfaces map[string]string // nil until enumerated
+}
+
+// newICECandidatePriorityOverrider returns nil if there is no function
+func newICECandidatePriorityOverrider(priorityFunc ICECandidatePriorityFunc, n transport.Net) *iceCandidatePriorityOverrider {
+	if priorityFunc == nil {
+		return nil
+	}
+
+	o := &iceCandidatePriorityOverrider{
+		priorityFunc: priorityFunc,
+		net:          n,
+	}
+	o.reset()
+
+	return o
+}
+
+// reset forgets the candidates and the network interfaces, to be called when
+// candidates are gathered again
+func (o *iceCandidatePriorityOverrider) reset() {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.priorities = map[string]uint32{}
+	o.interfaces = nil
+}
+
+// override sets the priority of the local candidate c
+func (o *iceCandidatePriorityOverrider) override(c *ICECandidate) {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if priority, ok := o.priorities[c.statsID]; ok {
+		c.Priority = priority
+		return
+	}
+
+	if o.interfaces == nil {
+		o.interfaces = networkInterfaces(o.net)
+	}
+
+	// Other candidates are bound to the interface of their base
+	address := c.RelatedAddress
+	if c.Typ == ICECandidateTypeHost {
+		address = c.Address
+	}
+
+	networkInterface := ""
+	if ip := net.ParseIP(address); ip != nil {
+		networkInterface = o.interfaces[ip.String()]
+	}
+
+	c.Priority = o.priorityFunc(*c, networkInterface)
+	o.priorities[c.statsID] = c.Priority
+}
+
+// networkInterfaces returns the names of the interfaces of n by IP address
+func networkInterfaces(n transport.Net) map[string]string {
+	interfaces := map[string]string{}
+
+	ifaces, err := n.Interfaces()
+	if err != nil {
+		return interfaces
+	}
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				interfaces[ipNet.IP.String()] = iface.Name
+			}
+		}
+	}
+
+	return interfaces
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkInterfaces(t *testing.T) {
+	n := &interfacesNet{interfaces: []*transport.Interface{
+		newTestInterface("wlan0", net.FlagUp, "192.168.1.2"),
+		newTestInterface("tun0", net.FlagUp),
+		newTestInterface("wwan0", net.FlagUp, "100.64.0.2"),
+	}}
+
+	testCases := []struct {
+		address           string
+		expectedInterface string
+	}{
+		{"192.168.1.2", "wlan0"},
+		{"100.64.0.2", "wwan0"},
+		{"10.0.0.2", ""},
+		{"pion.local", ""},
+		{"", ""},
+	}
+
+	interfaces := networkInterfaces(n)
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expectedInterface, interfaces[testCase.address], "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestSettingEngine_SetICECandidatePriorityFunc(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetIncludeLoopbackCandidate(true)
+	s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	var calls sync.Map
+	s.SetICECandidatePriorityFunc(func(candidate ICECandidate, networkInterface string) uint32 {
+		assert.NotEmpty(t, networkInterface)
+		_, called := calls.LoadOrStore(candidate.statsID, struct{}{})
+		assert.False(t, called, "called twice for %s", candidate)
+		return 1234
+	})
+
+	pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pc.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			assert.Equal(t, uint32(1234), c.Priority)
+		}
+	})
+
+	_, err = pc.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	gatherComplete := GatheringCompletePromise(pc)
+	assert.NoError(t, pc.SetLocalDescription(offer))
+	<-gatherComplete
+
+	assert.Contains(t, pc.LocalDescription().SDP, " 1234 ")
+
+	// The priorities are remembered rather than computed again
+	candidates, err := pc.iceGatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+	for _, c := range candidates {
+		assert.Equal(t, uint32(1234), c.Priority)
+	}
+
+	assert.NoError(t, pc.Close())
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v3"
+)
+
+// defaultICEConsentExpiryTimeout is the consent timeout of RFC 7675
+const defaultICEConsentExpiryTimeout = 30 * time.Second
+
+// SetICEConsentFreshness configures the consent freshness checks of RFC 7675.
+// checkInterval is how often a consent check is sent on the selected candidate
+// pair when it carries no traffic, like the keepAliveInterval of
+// SetICETimeouts. expiryTimeout is how long a consent check sent on the
+// selected candidate pair may stay unanswered before its consent expires and
+// PeerConnection.OnICEConsentExpired is invoked, 30 seconds by default. Leave
+// a duration 0 to keep its default.
+func (e *SettingEngine) SetICEConsentFreshness(checkInterval, expiryTimeout time.Duration) {
+	if checkInterval != 0 {
+		e.timeout.ICEKeepaliveInterval = &checkInterval
+	}
+	e.iceConsentExpiryTimeout = expiryTimeout
+}
+
+// OnICEConsentExpired sets an event handler which is invoked with the selected
+// candidate pair when its consent expired, because a consent check sent on it
+// got no response for the expiry timeout of
+// SettingEngine.SetICEConsentFreshness. Unlike the ICE connection state, which
+// goes disconnected and failed after the timeouts of
+// SettingEngine.SetICETimeouts when nothing is received, this tells that the
+// remote stopped answering consent checks. The responses aren't observed on
+// relay and TCP candidate pairs, and on pairs of an ICEUDPMux, their consent
+// expires when nothing was received on them for the expiry timeout instead.
+// The handler is invoked again if the consent of the pair is refreshed and
+// expires again.
+func (pc *PeerConnection) OnICEConsentExpired(f func(*ICECandidatePair)) {
+	pc.onICEConsentExpiredHandler.Store(f)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.iceConsentMonitor == nil && !pc.isClosed.get() {
+		pc.iceConsentMonitor = newICEConsentMonitor(pc)
+	}
+}
+
+func (pc *PeerConnection) onICEConsentExpired(pair *ICECandidatePair) {
+	pc.log.Warnf("ICE consent expired on candidate pair %s", pair)
+	if handler, ok := pc.onICEConsentExpiredHandler.Load().(func(*ICECandidatePair)); ok && handler != nil {
+		handler(pair)
+	}
+}
+
+// selectedCandidatePairConsent returns the selected candidate pair of
+// pion/ice and since when its consent isn't known to be fresh at now: when
+// the first binding request sent on it which got no response was sent, or
+// when a packet was last received on it if responses aren't observed
+func (t *ICETransport) selectedCandidatePairConsent(now time.Time) (*ice.CandidatePair, time.Time) {
+	agent := t.gatherer.getAgent()
+	if agent == nil {
+		return nil, time.Time{}
+	}
+
+	icePair, err := agent.GetSelectedCandidatePair()
+	if icePair == nil || err != nil {
+		return nil, time.Time{}
+	}
+
+	unanswered, ok := t.gatherer.pairCounters.firstUnansweredRequest(icePair.Local, icePair.Remote)
+	switch {
+	case !ok:
+		return icePair, remoteCandidateLastReceived(agent, icePair.Remote)
+	case unanswered.IsZero():
+		return icePair, now
+	default:
+		return icePair, unanswered
+	}
+}
+
+// remoteCandidateLastReceived returns when a packet was last received from
+// the remote candidate of agent equal to remote. The candidates of the
+// selected pair returned by the agent are copies without it.
+func remoteCandidateLastReceived(agent *ice.Agent, remote ice.Candidate) time.Time {
+	candidates, err := agent.GetRemoteCandidates()
+	if err != nil {
+		return time.Time{}
+	}
+
+	for _, c := range candidates {
+		if c.Equal(remote) {
+			return c.LastReceived()
+		}
+	}
+
+	return time.Time{}
+}
+
+// iceConsentMonitor periodically checks the consent of the selected candidate
+// pair of a PeerConnection
+type iceConsentMonitor struct {
+	pc      *PeerConnection
+	timeout time.Duration
+
+	// expiredPair is the pair the consent expired on, to report it once
+	expiredPair *ice.CandidatePair
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newICEConsentMonitor(pc *PeerConnection) *iceConsentMonitor {
+	timeout := pc.api.settingEngine.iceConsentExpiryTimeout
+	if timeout <= 0 {
+		timeout = defaultICEConsentExpiryTimeout
+	}
+
+	m := &iceConsentMonitor{
+		pc:      pc,
+		timeout: timeout,
+		done:    make(chan struct{}),
+	}
+	go m.run()
+
+	return m
+}
+
+func (m *iceConsentMonitor) run() {
+	// Checked often enough to report the expiry at most 20% late
+	ticker := time.NewTicker(m.timeout / 5)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		switch m.pc.ICEConnectionState() {
+		case ICEConnectionStateConnected, ICEConnectionStateCompleted, ICEConnectionStateDisconnected:
+		default:
+			continue
+		}
+
+		now := time.Now()
+		icePair, freshUntil := m.pc.iceTransport.selectedCandidatePairConsent(now)
+		if m.check(icePair, freshUntil, now) {
+			if pair, err := m.pc.iceTransport.GetSelectedCandidatePair(); err == nil && pair != nil {
+				m.pc.onICEConsentExpired(pair)
+			}
+		}
+	}
+}
+
+// check returns true if the consent of icePair, last known to be fresh at
+// freshUntil, expired at now, and it wasn't reported yet
+func (m *iceConsentMonitor) check(icePair *ice.CandidatePair, freshUntil, now time.Time) bool {
+	if icePair == nil || now.Sub(freshUntil) <= m.timeout {
+		m.expiredPair = nil
+		return false
+	}
+	if m.expiredPair != nil && m.expiredPair.Local.Equal(icePair.Local) && m.expiredPair.Remote.Equal(icePair.Remote) {
+		return false
+	}
+
+	m.expiredPair = icePair
+	return true
+}
+
+func (m *iceConsentMonitor) close() {
+	if m == nil {
+		return
+	}
+
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+}
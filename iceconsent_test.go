@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICEConsentMonitor_Check(t *testing.T) {
+	m := &iceConsentMonitor{timeout: 30 * time.Second}
+	newCandidate := func(address string) ice.Candidate {
+		c, err := ice.NewCandidateHost(&ice.CandidateHostConfig{Network: "udp", Address: address, Port: 1000, Component: 1})
+		assert.NoError(t, err)
+		return c
+	}
+	local, remote, otherRemote := newCandidate("192.168.0.1"), newCandidate("192.168.0.2"), newCandidate("192.168.0.3")
+
+	// The ICE agent returns a copy of the selected pair every time
+	pair := func() *ice.CandidatePair { return &ice.CandidatePair{Local: local, Remote: remote} }
+	otherPair := func() *ice.CandidatePair { return &ice.CandidatePair{Local: local, Remote: otherRemote} }
+	now := time.Now()
+
+	testCases := []struct {
+		pair            func() *ice.CandidatePair
+		freshUntil      time.Time
+		expectedExpired bool
+	}{
+		{func() *ice.CandidatePair { return nil }, time.Time{}, false},
+		{pair, now.Add(-time.Second), false},
+		{pair, now.Add(-time.Minute), true},
+		// Reported once
+		{pair, now.Add(-time.Minute), false},
+		{otherPair, now.Add(-time.Minute), true},
+		// Reported again after being refreshed
+		{otherPair, now, false},
+		{otherPair, now.Add(-time.Minute), true},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expectedExpired, m.check(testCase.pair(), testCase.freshUntil, now), "testCase: %d %v", i, testCase)
+	}
+}
+
+// Assert that OnICEConsentExpired fires when the remote PeerConnection stops
+// answering consent checks, long before ICE is disconnected
+func TestPeerConnection_OnICEConsentExpired(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerSettings := SettingEngine{}
+	offerSettings.SetICETimeouts(time.Minute, time.Minute, time.Minute)
+	offerSettings.SetICEConsentFreshness(200*time.Millisecond, time.Second)
+
+	// Consent checks of the answerer keep the consent fresh while it is up
+	answerSettings := SettingEngine{}
+	answerSettings.SetICEConsentFreshness(100*time.Millisecond, 0)
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	var once sync.Once
+	expired := make(chan *ICECandidatePair)
+	pcOffer.OnICEConsentExpired(func(pair *ICECandidatePair) {
+		once.Do(func() {
+			expired <- pair
+		})
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	// Nothing expires while the answerer is up
+	select {
+	case <-expired:
+		t.Fatal("consent expired while the answerer is up")
+	case <-time.After(2 * time.Second):
+	}
+
+	// The answerer goes away without closing DTLS, which would close the
+	// offerer
+	assert.NoError(t, pcAnswer.iceTransport.Stop())
+
+	pair := <-expired
+	assert.NotNil(t, pair.Local)
+	assert.NotNil(t, pair.Remote)
+	assert.Equal(t, ICEConnectionStateConnected, pcOffer.ICEConnectionState())
+
+	assert.NoError(t, pcOffer.Close())
+	// The ICE Agent of the answerer is closed already
+	assert.ErrorIs(t, pcAnswer.Close(), ice.ErrClosed)
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/v3"
+)
+
+// SetICEContinualGathering makes PeerConnections watch the addresses of the
+// local network interfaces every interval, and gather candidates again when
+// the local address of the selected candidate pair disappears, like when
+// switching from Wi-Fi to cellular. The ICE agent can't add local candidates
+// to a running session, so this requests an ICE restart: OnNegotiationNeeded
+// fires, and once the new offer is set the new candidates are surfaced by
+// OnICECandidate. Leave interval 0, the default, to gather only once.
+//
+// Addresses appearing, like a VPN going up, don't restart ICE since the
+// selected pair still works, so a better path isn't used until the next
+// restart. Before a pair is selected nothing is restarted either, use
+// SetICERestartPolicy to restart failed connections. The local address of
+// server reflexive and relayed candidates isn't known, any change of the
+// addresses restarts ICE when one of them is selected.
+func (e *SettingEngine) SetICEContinualGathering(interval time.Duration) {
+	e.iceContinualGatheringInterval = interval
+}
+
+// localAddresses returns the sorted addresses of n candidates may be gathered
+// from, as interface/ip strings
+func localAddresses(n transport.Net, interfaceFilter func(string) bool, ipFilter func(net.IP) bool, includeLoopback bool) ([]string, error) {
+	ifaces, err := n.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []string{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if iface.Flags&net.FlagLoopback != 0 && !includeLoopback {
+			continue
+		}
+		if interfaceFilter != nil && !interfaceFilter(iface.Name) {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if errors.Is(err, transport.ErrNoAddressAssigned) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			var ip net.IP
+			switch addr := addr.(type) {
+			case *net.IPNet:
+				ip = addr.IP
+			case *net.IPAddr:
+				ip = addr.IP
+			default:
+				continue
+			}
+
+			if ip.IsLoopback() && !includeLoopback {
+				continue
+			}
+			if ipFilter != nil && !ipFilter(ip) {
+				continue
+			}
+			addresses = append(addresses, iface.Name+"/"+ip.String())
+		}
+	}
+	sort.Strings(addresses)
+
+	return addresses, nil
+}
+
+// networkChangeMonitor periodically lists the local addresses of a
+// PeerConnection and restarts ICE when they change
+type networkChangeMonitor struct {
+	pc        *PeerConnection
+	net       transport.Net
+	interval  time.Duration
+	addresses []string
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newNetworkChangeMonitor(pc *PeerConnection, n transport.Net) *networkChangeMonitor {
+	m := &networkChangeMonitor{
+		pc:       pc,
+		net:      n,
+		interval: pc.api.settingEngine.iceContinualGatheringInterval,
+		done:     make(chan struct{}),
+	}
+	m.addresses, _ = m.localAddresses()
+
+	return m
+}
+
+func (m *networkChangeMonitor) localAddresses() ([]string, error) {
+	settingEngine := m.pc.api.settingEngine
+
+	return localAddresses(
+		m.net,
+		settingEngine.candidates.InterfaceFilter,
+		settingEngine.getIPFilter(),
+		settingEngine.candidates.IncludeLoopbackCandidate,
+	)
+}
+
+func (m *networkChangeMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		m.check()
+	}
+}
+
+// check restarts ICE and returns true if the local addresses changed since
+// the previous check and the local address of the selected pair disappeared
+func (m *networkChangeMonitor) check() bool {
+	addresses, err := m.localAddresses()
+	if err != nil {
+		m.pc.log.Warnf("Failed to list local addresses: %v", err)
+		return false
+	}
+	if reflect.DeepEqual(addresses, m.addresses) {
+		return false
+	}
+
+	m.pc.log.Infof("Local addresses changed from %v to %v", m.addresses, addresses)
+	m.addresses = addresses
+
+	pair, err := m.pc.iceTransport.GetSelectedCandidatePair()
+	if err != nil || pair == nil {
+		return false
+	}
+	if ip := selectedLocalIP(pair.Local); ip != nil && hasLocalAddress(addresses, ip) {
+		return false
+	}
+
+	m.pc.log.Infof("Local address of the selected candidate pair %s disappeared, restarting ICE", pair.Local)
+	if err := m.pc.RestartICE(); err != nil {
+		m.pc.log.Warnf("Failed to restart ICE: %v", err)
+	}
+
+	return true
+}
+
+// selectedLocalIP returns the IP of the local network interface of a local
+// candidate, nil if it isn't known
+func selectedLocalIP(candidate *ICECandidate) net.IP {
+	if candidate.Typ != ICECandidateTypeHost && candidate.Typ != ICECandidateTypePrflx {
+		return nil
+	}
+
+	return net.ParseIP(candidate.Address)
+}
+
+// hasLocalAddress returns true if ip is one of the interface/ip addresses
+// returned by localAddresses
+func hasLocalAddress(addresses []string, ip net.IP) bool {
+	for _, address := range addresses {
+		if ip.Equal(net.ParseIP(address[strings.LastIndex(address, "/")+1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *networkChangeMonitor) close() {
+	if m == nil {
+		return
+	}
+
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// interfacesNet is a transport.Net which only lists interfaces
+type interfacesNet struct {
+	transport.Net
+	interfaces []*transport.Interface
+}
+
+func (n *interfacesNet) Interfaces() ([]*transport.Interface, error) {
+	return n.interfaces, nil
+}
+
+func newTestInterface(name string, flags net.Flags, ips ...string) *transport.Interface {
+	iface := transport.NewInterface(net.Interface{Name: name, Flags: flags})
+	for _, ip := range ips {
+		iface.AddAddress(&net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(24, 32)})
+	}
+
+	return iface
+}
+
+func TestLocalAddresses(t *testing.T) {
+	n := &interfacesNet{interfaces: []*transport.Interface{
+		newTestInterface("wlan0", net.FlagUp, "192.168.1.2", "10.0.0.2"),
+		newTestInterface("lo", net.FlagUp|net.FlagLoopback, "127.0.0.1"),
+		newTestInterface("eth0", 0, "192.168.2.2"),
+		newTestInterface("tun0", net.FlagUp),
+		newTestInterface("wwan0", net.FlagUp, "100.64.0.2"),
+	}}
+
+	testCases := []struct {
+		interfaceFilter   func(string) bool
+		ipFilter          func(net.IP) bool
+		includeLoopback   bool
+		expectedAddresses []string
+	}{
+		{nil, nil, false, []string{"wlan0/10.0.0.2", "wlan0/192.168.1.2", "wwan0/100.64.0.2"}},
+		{nil, nil, true, []string{"lo/127.0.0.1", "wlan0/10.0.0.2", "wlan0/192.168.1.2", "wwan0/100.64.0.2"}},
+		{func(name string) bool { return name != "wlan0" }, nil, false, []string{"wwan0/100.64.0.2"}},
+		{nil, func(ip net.IP) bool { return !ip.Equal(net.ParseIP("10.0.0.2")) }, false, []string{"wlan0/192.168.1.2", "wwan0/100.64.0.2"}},
+	}
+
+	for i, testCase := range testCases {
+		addresses, err := localAddresses(n, testCase.interfaceFilter, testCase.ipFilter, testCase.includeLoopback)
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedAddresses, addresses, "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestNetworkChangeMonitor(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetICEContinualGathering(time.Hour)
+
+	pcOffer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	pair, err := pcOffer.iceTransport.GetSelectedCandidatePair()
+	assert.NoError(t, err)
+	if !assert.NotNil(t, pair) {
+		return
+	}
+
+	wlan := newTestInterface("wlan0", net.FlagUp, pair.Local.Address)
+	n := &interfacesNet{interfaces: []*transport.Interface{wlan}}
+	m := newNetworkChangeMonitor(pcOffer, n)
+	assert.Equal(t, []string{"wlan0/" + pair.Local.Address}, m.addresses)
+
+	// Nothing changed
+	assert.False(t, m.check())
+	assert.False(t, pcOffer.isICERestartRequested.get())
+
+	// A VPN goes up, the selected pair still works
+	n.interfaces = append(n.interfaces, newTestInterface("tun0", net.FlagUp, "10.8.0.2"))
+	assert.False(t, m.check())
+	assert.False(t, pcOffer.isICERestartRequested.get())
+	assert.Equal(t, []string{"tun0/10.8.0.2", "wlan0/" + pair.Local.Address}, m.addresses)
+
+	// Wi-Fi goes down with the local address of the selected pair
+	n.interfaces = n.interfaces[1:]
+	assert.True(t, m.check())
+	assert.True(t, pcOffer.isICERestartRequested.get())
+	assert.Equal(t, []string{"tun0/10.8.0.2"}, m.addresses)
+
+	m.close()
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestHasLocalAddress(t *testing.T) {
+	addresses := []string{"tun0/10.8.0.2", "wlan0/2001:db8::1"}
+
+	testCases := []struct {
+		ip       string
+		expected bool
+	}{
+		{"10.8.0.2", true},
+		{"2001:db8:0::1", true},
+		{"10.8.0.20", false},
+		{"2001:db8::2", false},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expected, hasLocalAddress(addresses, net.ParseIP(testCase.ip)), "testCase: %d %v", i, testCase)
+	}
+}
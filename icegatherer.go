@@ -8,6 +8,7 @@ package webrtc
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 	"sync/atomic"
 
@@ -27,6 +28,9 @@ type ICEGatherer struct {
 
 	validatedServers []*stun.URI
 	gatherPolicy     ICETransportPolicy
+	// optionsChanged is set when the options changed after the ICE Agent
+	// was created
+	optionsChanged bool
 
 	agent *ice.Agent
 
@@ -36,6 +40,18 @@ type ICEGatherer struct {
 	// Used for GatheringCompletePromise
 	onGatheringCompleteHandler atomic.Value // func()
 
+	// Candidates gathered ahead of time for the ICE candidate pool are held
+	// back until the pool is released. A nil entry marks the end of gathering.
+	poolLock         sync.Mutex
+	pooling          bool
+	pooledCandidates []*ICECandidate
+
+	addressFamilyRanker *iceAddressFamilyRanker
+	priorityOverrider   *iceCandidatePriorityOverrider
+
+	// pairCounters counts the candidate pairs of the sockets of the agent
+	pairCounters *iceCandidatePairCounters
+
 	api *API
 }
 
@@ -43,26 +59,83 @@ type ICEGatherer struct {
 // This constructor is part of the ORTC API. It is not
 // meant to be used together with the basic WebRTC API.
 func (api *API) NewICEGatherer(opts ICEGatherOptions) (*ICEGatherer, error) {
-	var validatedServers []*stun.URI
-	if len(opts.ICEServers) > 0 {
-		for _, server := range opts.ICEServers {
-			url, err := server.urls()
-			if err != nil {
-				return nil, err
-			}
-			validatedServers = append(validatedServers, url...)
-		}
+	validatedServers, err := validateICEServers(opts.ICEServers)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ICEGatherer{
-		state:            ICEGathererStateNew,
-		gatherPolicy:     opts.ICEGatherPolicy,
-		validatedServers: validatedServers,
-		api:              api,
-		log:              api.settingEngine.LoggerFactory.NewLogger("ice"),
+		state:               ICEGathererStateNew,
+		gatherPolicy:        opts.ICEGatherPolicy,
+		validatedServers:    validatedServers,
+		pairCounters:        newICECandidatePairCounters(),
+		addressFamilyRanker: newICEAddressFamilyRanker(api.settingEngine.iceAddressFamilyPolicy, api.settingEngine.iceMaxHostCandidatesPerFamily),
+		priorityOverrider:   newICECandidatePriorityOverrider(api.settingEngine.iceCandidatePriorityFunc, api.settingEngine.iceNet()),
+		api:                 api,
+		log:                 api.settingEngine.LoggerFactory.NewLogger("ice"),
 	}, nil
 }
 
+func validateICEServers(iceServers []ICEServer) ([]*stun.URI, error) {
+	var validatedServers []*stun.URI
+	for _, server := range iceServers {
+		url, err := server.urls()
+		if err != nil {
+			return nil, err
+		}
+		validatedServers = append(validatedServers, url...)
+	}
+
+	return validatedServers, nil
+}
+
+// setOptions replaces the ICE servers and gather policy. They are used the
+// next time an ICE Agent is created by this ICEGatherer, an ICETransport
+// creates a new ICE Agent on the next ICE restart if they changed.
+func (g *ICEGatherer) setOptions(opts ICEGatherOptions) error {
+	validatedServers, err := validateICEServers(opts.ICEServers)
+	if err != nil {
+		return err
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.agent != nil && (g.gatherPolicy != opts.ICEGatherPolicy || !reflect.DeepEqual(g.validatedServers, validatedServers)) {
+		g.optionsChanged = true
+	}
+	g.validatedServers = validatedServers
+	g.gatherPolicy = opts.ICEGatherPolicy
+	return nil
+}
+
+// haveOptionsChanged returns true if the options changed since the ICE Agent
+// was created
+func (g *ICEGatherer) haveOptionsChanged() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.optionsChanged
+}
+
+// recreateAgent closes the ICE Agent and creates a new one with the current
+// options
+func (g *ICEGatherer) recreateAgent() error {
+	g.lock.Lock()
+	agent := g.agent
+	g.agent = nil
+	atomicStoreICEGathererState(&g.state, ICEGathererStateNew)
+	g.lock.Unlock()
+
+	if agent != nil {
+		if err := agent.Close(); err != nil {
+			return err
+		}
+	}
+
+	return g.createAgent()
+}
+
 func (g *ICEGatherer) createAgent() error {
 	g.lock.Lock()
 	defer g.lock.Unlock()
@@ -94,9 +167,47 @@ func (g *ICEGatherer) createAgent() error {
 		mDNSMode = ice.MulticastDNSModeQueryOnly
 	}
 
+	urls := g.validatedServers
+	if g.api.settingEngine.candidates.ICELite {
+		// A lite agent only gathers host candidates, pion/ice rejects URLs
+		// it wouldn't use
+		urls = nil
+	}
+	interfaceFilter := g.api.settingEngine.candidates.InterfaceFilter
+	nat1To1IPs := g.api.settingEngine.candidates.NAT1To1IPs
+	udpMux := g.api.settingEngine.iceUDPMux
+	requestedNetworkTypes := g.api.settingEngine.candidates.ICENetworkTypes
+
+	if staticCandidates := g.api.settingEngine.candidates.StaticHostCandidates; len(staticCandidates) != 0 {
+		if udpMux == nil {
+			return errICEStaticCandidatesNoUDPMux
+		}
+
+		// Only advertise the static candidates, everything that would
+		// require interface enumeration or network requests is disabled
+		udpMux = newICEStaticUDPMux(udpMux, staticCandidates)
+		candidateTypes = []ice.CandidateType{ice.CandidateTypeHost}
+		urls = nil
+		interfaceFilter = func(string) bool { return false }
+		nat1To1IPs = nil
+		nat1To1CandiTyp = ice.CandidateTypeUnspecified
+		mDNSMode = ice.MulticastDNSModeDisabled
+		requestedNetworkTypes = staticCandidateNetworkTypes(staticCandidates)
+	}
+
+	ufrag, err := g.api.settingEngine.getICEUsernameFragment()
+	if err != nil {
+		return err
+	}
+
+	pwd, err := g.api.settingEngine.getICEPassword()
+	if err != nil {
+		return err
+	}
+
 	config := &ice.AgentConfig{
 		Lite:                   g.api.settingEngine.candidates.ICELite,
-		Urls:                   g.validatedServers,
+		Urls:                   urls,
 		PortMin:                g.api.settingEngine.ephemeralUDP.PortMin,
 		PortMax:                g.api.settingEngine.ephemeralUDP.PortMax,
 		DisconnectedTimeout:    g.api.settingEngine.timeout.ICEDisconnectedTimeout,
@@ -108,24 +219,23 @@ func (g *ICEGatherer) createAgent() error {
 		SrflxAcceptanceMinWait: g.api.settingEngine.timeout.ICESrflxAcceptanceMinWait,
 		PrflxAcceptanceMinWait: g.api.settingEngine.timeout.ICEPrflxAcceptanceMinWait,
 		RelayAcceptanceMinWait: g.api.settingEngine.timeout.ICERelayAcceptanceMinWait,
-		InterfaceFilter:        g.api.settingEngine.candidates.InterfaceFilter,
-		IPFilter:               g.api.settingEngine.candidates.IPFilter,
-		NAT1To1IPs:             g.api.settingEngine.candidates.NAT1To1IPs,
+		InterfaceFilter:        interfaceFilter,
+		IPFilter:               g.api.settingEngine.getIPFilter(),
+		NAT1To1IPs:             nat1To1IPs,
 		NAT1To1IPCandidateType: nat1To1CandiTyp,
 		IncludeLoopback:        g.api.settingEngine.candidates.IncludeLoopbackCandidate,
-		Net:                    g.api.settingEngine.net,
+		Net:                    newICECountingNet(g.api.settingEngine.iceNet(), g.pairCounters),
 		MulticastDNSMode:       mDNSMode,
 		MulticastDNSHostName:   g.api.settingEngine.candidates.MulticastDNSHostName,
-		LocalUfrag:             g.api.settingEngine.candidates.UsernameFragment,
-		LocalPwd:               g.api.settingEngine.candidates.Password,
+		LocalUfrag:             ufrag,
+		LocalPwd:               pwd,
 		TCPMux:                 g.api.settingEngine.iceTCPMux,
-		UDPMux:                 g.api.settingEngine.iceUDPMux,
-		ProxyDialer:            g.api.settingEngine.iceProxyDialer,
+		UDPMux:                 udpMux,
+		ProxyDialer:            newTURNSProxyDialer(g.api.settingEngine.iceProxyDialer, urls, g.api.settingEngine.iceTURNTLSConfig, g.api.settingEngine.iceNet()),
 		DisableActiveTCP:       g.api.settingEngine.iceDisableActiveTCP,
 		MaxBindingRequests:     g.api.settingEngine.iceMaxBindingRequests,
 	}
 
-	requestedNetworkTypes := g.api.settingEngine.candidates.ICENetworkTypes
 	if len(requestedNetworkTypes) == 0 {
 		requestedNetworkTypes = supportedNetworkTypes()
 	}
@@ -140,6 +250,7 @@ func (g *ICEGatherer) createAgent() error {
 	}
 
 	g.agent = agent
+	g.optionsChanged = false
 	return nil
 }
 
@@ -156,8 +267,10 @@ func (g *ICEGatherer) Gather() error {
 	}
 
 	g.setState(ICEGathererStateGathering)
+	g.addressFamilyRanker.reset()
+	g.priorityOverrider.reset()
 	if err := agent.OnCandidate(func(candidate ice.Candidate) {
-		onLocalCandidateHandler := func(*ICECandidate) {}
+		var onLocalCandidateHandler func(*ICECandidate)
 		if handler, ok := g.onLocalCandidateHandler.Load().(func(candidate *ICECandidate)); ok && handler != nil {
 			onLocalCandidateHandler = handler
 		}
@@ -173,12 +286,21 @@ func (g *ICEGatherer) Gather() error {
 				g.log.Warnf("Failed to convert ice.Candidate: %s", err)
 				return
 			}
-			onLocalCandidateHandler(&c)
+			if !g.api.settingEngine.allowsCandidate(c) {
+				g.log.Debugf("Dropping local candidate %s, rejected by the candidate filter", c)
+				return
+			}
+			if !g.addressFamilyRanker.rank(&c) {
+				g.log.Debugf("Dropping local candidate %s, over the host candidates of its address family", c)
+				return
+			}
+			g.priorityOverrider.override(&c)
+			g.emitLocalCandidate(onLocalCandidateHandler, &c)
 		} else {
 			g.setState(ICEGathererStateComplete)
 
 			onGatheringCompleteHandler()
-			onLocalCandidateHandler(nil)
+			g.emitLocalCandidate(onLocalCandidateHandler, nil)
 		}
 	}); err != nil {
 		return err
@@ -186,6 +308,67 @@ func (g *ICEGatherer) Gather() error {
 	return agent.GatherCandidates()
 }
 
+func (g *ICEGatherer) emitLocalCandidate(handler func(*ICECandidate), candidate *ICECandidate) {
+	g.poolLock.Lock()
+	defer g.poolLock.Unlock()
+
+	if g.pooling {
+		g.pooledCandidates = append(g.pooledCandidates, candidate)
+	} else if handler != nil {
+		handler(candidate)
+	}
+}
+
+// gatherPool starts gathering the ICE candidate pool with the current options,
+// discarding a pool gathered previously. Local candidates are only emitted
+// once releasePool is called. Nothing is done if candidates are already
+// gathered outside of a pool, since they may be in use by a local description.
+func (g *ICEGatherer) gatherPool() error {
+	g.lock.Lock()
+	g.poolLock.Lock()
+	if g.agent != nil && !g.pooling {
+		g.poolLock.Unlock()
+		g.lock.Unlock()
+		return nil
+	}
+
+	var err error
+	if g.agent != nil {
+		err = g.agent.Close()
+		g.agent = nil
+		atomicStoreICEGathererState(&g.state, ICEGathererStateNew)
+	}
+	g.pooling = true
+	g.pooledCandidates = nil
+	g.poolLock.Unlock()
+	g.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return g.Gather()
+}
+
+// releasePool emits the candidates gathered by gatherPool, later candidates
+// are emitted as soon as they are gathered.
+func (g *ICEGatherer) releasePool() {
+	g.poolLock.Lock()
+	defer g.poolLock.Unlock()
+
+	if !g.pooling {
+		return
+	}
+	g.pooling = false
+
+	handler, ok := g.onLocalCandidateHandler.Load().(func(candidate *ICECandidate))
+	for _, candidate := range g.pooledCandidates {
+		if ok && handler != nil {
+			handler(candidate)
+		}
+	}
+	g.pooledCandidates = nil
+}
+
 // Close prunes all local candidates, and closes the ports.
 func (g *ICEGatherer) Close() error {
 	g.lock.Lock()
@@ -223,7 +406,7 @@ func (g *ICEGatherer) GetLocalParameters() (ICEParameters, error) {
 	return ICEParameters{
 		UsernameFragment: frag,
 		Password:         pwd,
-		ICELite:          false,
+		ICELite:          g.api.settingEngine.candidates.ICELite,
 	}, nil
 }
 
@@ -244,7 +427,20 @@ func (g *ICEGatherer) GetLocalCandidates() ([]ICECandidate, error) {
 		return nil, err
 	}
 
-	return newICECandidatesFromICE(iceCandidates)
+	candidates, err := newICECandidatesFromICE(iceCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := candidates[:0]
+	for _, c := range candidates {
+		if g.api.settingEngine.allowsCandidate(c) && g.addressFamilyRanker.rank(&c) {
+			g.priorityOverrider.override(&c)
+			allowed = append(allowed, c)
+		}
+	}
+
+	return allowed, nil
 }
 
 // OnLocalCandidate sets an event handler which fires when a new local ICE candidate is available
@@ -285,49 +481,8 @@ func (g *ICEGatherer) collectStats(collector *statsReportCollector) {
 
 	collector.Collecting()
 	go func(collector *statsReportCollector, agent *ice.Agent) {
-		for _, candidatePairStats := range agent.GetCandidatePairsStats() {
+		for _, stats := range g.candidatePairsStats(agent) {
 			collector.Collecting()
-
-			state, err := toStatsICECandidatePairState(candidatePairStats.State)
-			if err != nil {
-				g.log.Error(err.Error())
-			}
-
-			pairID := newICECandidatePairStatsID(candidatePairStats.LocalCandidateID,
-				candidatePairStats.RemoteCandidateID)
-
-			stats := ICECandidatePairStats{
-				Timestamp: statsTimestampFrom(candidatePairStats.Timestamp),
-				Type:      StatsTypeCandidatePair,
-				ID:        pairID,
-				// TransportID:
-				LocalCandidateID:            candidatePairStats.LocalCandidateID,
-				RemoteCandidateID:           candidatePairStats.RemoteCandidateID,
-				State:                       state,
-				Nominated:                   candidatePairStats.Nominated,
-				PacketsSent:                 candidatePairStats.PacketsSent,
-				PacketsReceived:             candidatePairStats.PacketsReceived,
-				BytesSent:                   candidatePairStats.BytesSent,
-				BytesReceived:               candidatePairStats.BytesReceived,
-				LastPacketSentTimestamp:     statsTimestampFrom(candidatePairStats.LastPacketSentTimestamp),
-				LastPacketReceivedTimestamp: statsTimestampFrom(candidatePairStats.LastPacketReceivedTimestamp),
-				FirstRequestTimestamp:       statsTimestampFrom(candidatePairStats.FirstRequestTimestamp),
-				LastRequestTimestamp:        statsTimestampFrom(candidatePairStats.LastRequestTimestamp),
-				LastResponseTimestamp:       statsTimestampFrom(candidatePairStats.LastResponseTimestamp),
-				TotalRoundTripTime:          candidatePairStats.TotalRoundTripTime,
-				CurrentRoundTripTime:        candidatePairStats.CurrentRoundTripTime,
-				AvailableOutgoingBitrate:    candidatePairStats.AvailableOutgoingBitrate,
-				AvailableIncomingBitrate:    candidatePairStats.AvailableIncomingBitrate,
-				CircuitBreakerTriggerCount:  candidatePairStats.CircuitBreakerTriggerCount,
-				RequestsReceived:            candidatePairStats.RequestsReceived,
-				RequestsSent:                candidatePairStats.RequestsSent,
-				ResponsesReceived:           candidatePairStats.ResponsesReceived,
-				ResponsesSent:               candidatePairStats.ResponsesSent,
-				RetransmissionsReceived:     candidatePairStats.RetransmissionsReceived,
-				RetransmissionsSent:         candidatePairStats.RetransmissionsSent,
-				ConsentRequestsSent:         candidatePairStats.ConsentRequestsSent,
-				ConsentExpiredTimestamp:     statsTimestampFrom(candidatePairStats.ConsentExpiredTimestamp),
-			}
 			collector.Collect(stats.ID, stats)
 		}
 
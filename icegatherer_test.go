@@ -8,6 +8,7 @@ package webrtc
 
 import (
 	"context"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -156,3 +157,103 @@ func TestICEGatherer_AlreadyClosed(t *testing.T) {
 		assert.ErrorIs(t, err, errICEAgentNotExist)
 	})
 }
+
+func TestICEGatherer_StaticHostCandidates(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	staticAddr := &net.UDPAddr{IP: net.IPv4(203, 0, 113, 1), Port: 3478}
+
+	t.Run("UDPMux Required", func(t *testing.T) {
+		s := SettingEngine{}
+		s.SetICEStaticHostCandidates([]*net.UDPAddr{staticAddr})
+
+		gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, gatherer.Gather(), errICEStaticCandidatesNoUDPMux)
+	})
+
+	t.Run("Gather", func(t *testing.T) {
+		udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		assert.NoError(t, err)
+
+		udpMux := NewICEUDPMux(nil, udpConn)
+
+		s := SettingEngine{}
+		s.SetICEUDPMux(udpMux)
+		s.SetICEStaticHostCandidates([]*net.UDPAddr{staticAddr})
+
+		gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{
+			ICEServers: []ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}},
+		})
+		assert.NoError(t, err)
+
+		gatherFinished := make(chan struct{})
+		gatherer.OnLocalCandidate(func(c *ICECandidate) {
+			if c == nil {
+				close(gatherFinished)
+			}
+		})
+		assert.NoError(t, gatherer.Gather())
+		<-gatherFinished
+
+		candidates, err := gatherer.GetLocalCandidates()
+		assert.NoError(t, err)
+		assert.Len(t, candidates, 1)
+		assert.Equal(t, ICECandidateTypeHost, candidates[0].Typ)
+		assert.Equal(t, staticAddr.IP.String(), candidates[0].Address)
+		assert.Equal(t, uint16(staticAddr.Port), candidates[0].Port)
+
+		assert.NoError(t, gatherer.Close())
+		assert.NoError(t, udpMux.Close())
+	})
+}
+
+func TestICEGatherer_Lite(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetLite(true)
+	s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	s.SetIncludeLoopbackCandidate(true)
+
+	// The ICE servers and the relay policy are ignored by a lite agent
+	for i, policy := range []ICETransportPolicy{ICETransportPolicyAll, ICETransportPolicyRelay} {
+		gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{
+			ICEServers:      []ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}},
+			ICEGatherPolicy: policy,
+		})
+		assert.NoError(t, err, "testCase: %d %v", i, policy)
+
+		gatherFinished := make(chan struct{})
+		gatherer.OnLocalCandidate(func(c *ICECandidate) {
+			if c == nil {
+				close(gatherFinished)
+			}
+		})
+		assert.NoError(t, gatherer.Gather(), "testCase: %d %v", i, policy)
+		<-gatherFinished
+
+		params, err := gatherer.GetLocalParameters()
+		assert.NoError(t, err, "testCase: %d %v", i, policy)
+		assert.True(t, params.ICELite, "testCase: %d %v", i, policy)
+
+		candidates, err := gatherer.GetLocalCandidates()
+		assert.NoError(t, err, "testCase: %d %v", i, policy)
+		assert.NotEmpty(t, candidates, "testCase: %d %v", i, policy)
+		for _, c := range candidates {
+			assert.Equal(t, ICECandidateTypeHost, c.Typ, "testCase: %d %v", i, policy)
+		}
+
+		assert.NoError(t, gatherer.Close(), "testCase: %d %v", i, policy)
+	}
+}
@@ -28,3 +28,65 @@ func NewICEUDPMux(logger logging.LeveledLogger, udpConn net.PacketConn) ice.UDPM
 		Logger:  logger,
 	})
 }
+
+// iceStaticUDPMux advertises a fixed set of addresses while routing all
+// traffic through the wrapped UDPMux. It is used by SetICEStaticHostCandidates
+type iceStaticUDPMux struct {
+	ice.UDPMux
+	addrs []net.Addr
+}
+
+func newICEStaticUDPMux(udpMux ice.UDPMux, staticAddrs []*net.UDPAddr) *iceStaticUDPMux {
+	addrs := make([]net.Addr, 0, len(staticAddrs))
+	for _, addr := range staticAddrs {
+		addrs = append(addrs, addr)
+	}
+
+	return &iceStaticUDPMux{UDPMux: udpMux, addrs: addrs}
+}
+
+// GetListenAddresses returns the static addresses instead of the ones the UDPMux is bound to
+func (m *iceStaticUDPMux) GetListenAddresses() []net.Addr {
+	return m.addrs
+}
+
+// GetConn translates the static address into an address the wrapped UDPMux is listening on
+func (m *iceStaticUDPMux) GetConn(ufrag string, addr net.Addr) (net.PacketConn, error) {
+	listenAddrs := m.UDPMux.GetListenAddresses()
+	if len(listenAddrs) == 0 {
+		return m.UDPMux.GetConn(ufrag, addr)
+	}
+
+	isIPv6 := false
+	if udpAddr, ok := addr.(*net.UDPAddr); ok && udpAddr.IP.To4() == nil {
+		isIPv6 = true
+	}
+
+	for _, listenAddr := range listenAddrs {
+		if udpAddr, ok := listenAddr.(*net.UDPAddr); ok && (udpAddr.IP.To4() == nil) == isIPv6 {
+			return m.UDPMux.GetConn(ufrag, listenAddr)
+		}
+	}
+
+	return m.UDPMux.GetConn(ufrag, listenAddrs[0])
+}
+
+// staticCandidateNetworkTypes returns the UDP NetworkTypes needed to serve addrs
+func staticCandidateNetworkTypes(addrs []*net.UDPAddr) (networkTypes []NetworkType) {
+	hasIPv4, hasIPv6 := false, false
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+
+	if hasIPv4 {
+		networkTypes = append(networkTypes, NetworkTypeUDP4)
+	}
+	if hasIPv6 {
+		networkTypes = append(networkTypes, NetworkTypeUDP6)
+	}
+	return networkTypes
+}
@@ -0,0 +1,412 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/stun/v2"
+)
+
+const (
+	// iceUDPMuxFrameHeaderSize is the size of the header of the frames
+	// exchanged between a ICEUDPMuxFrontend and its workers: the length of the
+	// IP, the IP padded to 16 bytes, the port and the length of the packet
+	iceUDPMuxFrameHeaderSize = 1 + net.IPv6len + 2 + 2
+
+	iceUDPMuxReceiveMTU = 8192
+
+	// iceUDPMuxFrontendStaleRouteTimeout is how long a remote address is
+	// routed without receiving a packet from it when no timeout is set in
+	// ICEUDPMuxFrontendOptions. ICE consent checks are sent every few
+	// seconds, so only the addresses of closed connections become stale.
+	iceUDPMuxFrontendStaleRouteTimeout = 30 * time.Second
+)
+
+var errICEUDPMuxFrameInvalid = errors.New("invalid ICE UDP mux frame")
+
+// ICEUDPMuxBackend receives the packets a ICEUDPMuxFrontend demultiplexed
+// for it, usually by forwarding them to another process
+type ICEUDPMuxBackend interface {
+	// WriteTo forwards a packet the frontend received from addr
+	WriteTo(p []byte, addr net.Addr) (int, error)
+}
+
+// ICEUDPMuxResolver returns the backend serving the local ICE username
+// fragment ufrag, and false if there is none. Resolvers usually map a prefix
+// set with SettingEngine.SetICEUsernameFragmentPrefix to a worker process.
+type ICEUDPMuxResolver func(ufrag string) (ICEUDPMuxBackend, bool)
+
+// ICEUDPMuxFrontend owns a public UDP socket shared by the ICE UDP muxes of
+// many processes. STUN Binding requests are routed by the local username
+// fragment in their USERNAME attribute, using a ICEUDPMuxResolver, and every
+// other packet is routed by the remote address a Binding request was
+// previously received from, until no packet was received from it for the
+// stale route timeout. Backends send their packets out of the public socket
+// with WriteTo.
+type ICEUDPMuxFrontend struct {
+	conn     net.PacketConn
+	resolver ICEUDPMuxResolver
+	log      logging.LeveledLogger
+
+	mu     sync.RWMutex
+	routes map[string]*iceUDPMuxFrontendRoute
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// iceUDPMuxFrontendRoute is the backend the packets of a remote address are
+// routed to
+type iceUDPMuxFrontendRoute struct {
+	backend ICEUDPMuxBackend
+	// lastActivity is when a packet was last received from the address, in
+	// Unix nanoseconds, updated atomically
+	lastActivity int64
+}
+
+// ICEUDPMuxFrontendOptions configures a ICEUDPMuxFrontend created with
+// NewICEUDPMuxFrontendWithOptions
+type ICEUDPMuxFrontendOptions struct {
+	// StaleRouteTimeout is how long the packets of a remote address are
+	// routed to its backend after the last packet received from it, so the
+	// addresses of closed connections are forgotten. The default is 30
+	// seconds, a negative timeout disables the eviction.
+	StaleRouteTimeout time.Duration
+}
+
+// NewICEUDPMuxFrontend creates a ICEUDPMuxFrontend serving conn. It reads
+// from conn until closed.
+func NewICEUDPMuxFrontend(logger logging.LeveledLogger, conn net.PacketConn, resolver ICEUDPMuxResolver) *ICEUDPMuxFrontend {
+	return NewICEUDPMuxFrontendWithOptions(logger, conn, resolver, ICEUDPMuxFrontendOptions{})
+}
+
+// NewICEUDPMuxFrontendWithOptions creates a ICEUDPMuxFrontend serving conn
+// configured with options. It reads from conn until closed.
+func NewICEUDPMuxFrontendWithOptions(logger logging.LeveledLogger, conn net.PacketConn, resolver ICEUDPMuxResolver, options ICEUDPMuxFrontendOptions) *ICEUDPMuxFrontend {
+	if logger == nil {
+		logger = logging.NewDefaultLoggerFactory().NewLogger("ice")
+	}
+	if options.StaleRouteTimeout == 0 {
+		options.StaleRouteTimeout = iceUDPMuxFrontendStaleRouteTimeout
+	}
+
+	f := &ICEUDPMuxFrontend{
+		conn:     conn,
+		resolver: resolver,
+		log:      logger,
+		routes:   map[string]*iceUDPMuxFrontendRoute{},
+		closed:   make(chan struct{}),
+	}
+	go f.readLoop()
+
+	if options.StaleRouteTimeout > 0 {
+		go f.evictLoop(options.StaleRouteTimeout)
+	}
+
+	return f
+}
+
+// LocalAddr returns the address of the public socket
+func (f *ICEUDPMuxFrontend) LocalAddr() net.Addr {
+	return f.conn.LocalAddr()
+}
+
+// WriteTo sends a packet of a backend out of the public socket
+func (f *ICEUDPMuxFrontend) WriteTo(p []byte, addr net.Addr) (int, error) {
+	return f.conn.WriteTo(p, addr)
+}
+
+// RemoveBackend stops routing packets to backend, like when its process
+// exited
+func (f *ICEUDPMuxFrontend) RemoveBackend(backend ICEUDPMuxBackend) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for addr, r := range f.routes {
+		if r.backend == backend {
+			delete(f.routes, addr)
+		}
+	}
+}
+
+// EvictStaleRoutes stops routing the remote addresses no packet was received
+// from for longer than timeout, and returns them
+func (f *ICEUDPMuxFrontend) EvictStaleRoutes(timeout time.Duration) []string {
+	deadline := time.Now().Add(-timeout).UnixNano()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stale := []string{}
+	for addr, r := range f.routes {
+		if atomic.LoadInt64(&r.lastActivity) < deadline {
+			stale = append(stale, addr)
+			delete(f.routes, addr)
+		}
+	}
+	sort.Strings(stale)
+
+	return stale
+}
+
+// Close closes the public socket
+func (f *ICEUDPMuxFrontend) Close() (err error) {
+	f.closeOnce.Do(func() {
+		close(f.closed)
+		err = f.conn.Close()
+	})
+
+	return err
+}
+
+func (f *ICEUDPMuxFrontend) evictLoop(timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.EvictStaleRoutes(timeout)
+		case <-f.closed:
+			return
+		}
+	}
+}
+
+func (f *ICEUDPMuxFrontend) readLoop() {
+	buf := make([]byte, iceUDPMuxReceiveMTU)
+	for {
+		n, addr, err := f.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-f.closed:
+			default:
+				f.log.Errorf("Failed to read from ICE UDP mux frontend: %v", err)
+			}
+			return
+		}
+
+		backend, ok := f.route(buf[:n], addr)
+		if !ok {
+			f.log.Tracef("Dropping packet from %s, no ICE UDP mux backend", addr)
+			continue
+		}
+
+		if _, err := backend.WriteTo(buf[:n], addr); err != nil {
+			f.log.Warnf("Failed to forward packet from %s to ICE UDP mux backend: %v", addr, err)
+		}
+	}
+}
+
+// route returns the backend of a packet received from addr
+func (f *ICEUDPMuxFrontend) route(p []byte, addr net.Addr) (ICEUDPMuxBackend, bool) {
+	now := time.Now().UnixNano()
+	if ufrag, ok := stunLocalUsernameFragment(p); ok {
+		if backend, ok := f.resolver(ufrag); ok {
+			f.mu.Lock()
+			f.routes[addr.String()] = &iceUDPMuxFrontendRoute{backend: backend, lastActivity: now}
+			f.mu.Unlock()
+
+			return backend, true
+		}
+	}
+
+	f.mu.RLock()
+	r, ok := f.routes[addr.String()]
+	f.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	atomic.StoreInt64(&r.lastActivity, now)
+	return r.backend, true
+}
+
+// stunLocalUsernameFragment returns the local username fragment of a STUN
+// Binding request, the part of its USERNAME before the colon
+func stunLocalUsernameFragment(p []byte) (string, bool) {
+	if !stun.IsMessage(p) {
+		return "", false
+	}
+
+	m := &stun.Message{Raw: append([]byte{}, p...)}
+	if err := m.Decode(); err != nil || m.Type != stun.BindingRequest {
+		return "", false
+	}
+
+	var username stun.Username
+	if err := username.GetFrom(m); err != nil {
+		return "", false
+	}
+
+	ufrag := strings.SplitN(username.String(), ":", 2)[0]
+	return ufrag, ufrag != ""
+}
+
+// writeICEUDPMuxFrame writes a packet and its remote address to w as a frame
+func writeICEUDPMuxFrame(w io.Writer, p []byte, addr net.Addr) (int, error) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, errICEUDPMuxFrameInvalid
+	}
+	if len(p) > 0xFFFF {
+		return 0, errICEUDPMuxFrameInvalid
+	}
+
+	frame := make([]byte, iceUDPMuxFrameHeaderSize+len(p))
+	ip := udpAddr.IP.To4()
+	if ip == nil {
+		ip = udpAddr.IP.To16()
+	}
+	frame[0] = byte(len(ip))
+	copy(frame[1:], ip)
+	binary.BigEndian.PutUint16(frame[1+net.IPv6len:], uint16(udpAddr.Port))
+	binary.BigEndian.PutUint16(frame[3+net.IPv6len:], uint16(len(p)))
+	copy(frame[iceUDPMuxFrameHeaderSize:], p)
+
+	if _, err := w.Write(frame); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// readICEUDPMuxFrame reads a frame from r into p, and returns the size of the
+// packet and its remote address
+func readICEUDPMuxFrame(r io.Reader, p []byte) (int, *net.UDPAddr, error) {
+	header := make([]byte, iceUDPMuxFrameHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	ipLen := int(header[0])
+	if ipLen != net.IPv4len && ipLen != net.IPv6len {
+		return 0, nil, errICEUDPMuxFrameInvalid
+	}
+
+	addr := &net.UDPAddr{
+		IP:   append(net.IP{}, header[1:1+ipLen]...),
+		Port: int(binary.BigEndian.Uint16(header[1+net.IPv6len:])),
+	}
+
+	size := int(binary.BigEndian.Uint16(header[3+net.IPv6len:]))
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+
+	return copy(p, packet), addr, nil
+}
+
+// iceUDPMuxStreamBackend forwards packets to a worker process over a stream
+type iceUDPMuxStreamBackend struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (b *iceUDPMuxStreamBackend) WriteTo(p []byte, addr net.Addr) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return writeICEUDPMuxFrame(b.conn, p, addr)
+}
+
+// AddStreamBackend returns a ICEUDPMuxBackend forwarding packets to a worker
+// process over conn, like a Unix socket, which the worker passes to
+// NewICEUDPMuxWorkerConn. The packets the worker sends over conn are written
+// to the public socket. The backend is removed once conn is closed.
+func (f *ICEUDPMuxFrontend) AddStreamBackend(conn net.Conn) ICEUDPMuxBackend {
+	backend := &iceUDPMuxStreamBackend{conn: conn}
+
+	go func() {
+		defer f.RemoveBackend(backend)
+
+		buf := make([]byte, iceUDPMuxReceiveMTU)
+		for {
+			n, addr, err := readICEUDPMuxFrame(conn, buf)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					f.log.Warnf("Failed to read from ICE UDP mux backend: %v", err)
+				}
+				return
+			}
+
+			if _, err := f.WriteTo(buf[:n], addr); err != nil {
+				f.log.Warnf("Failed to write packet of ICE UDP mux backend to %s: %v", addr, err)
+			}
+		}
+	}()
+
+	return backend
+}
+
+// iceUDPMuxWorkerConn is the net.PacketConn of a worker process, exchanging
+// packets with a ICEUDPMuxFrontend over a stream
+type iceUDPMuxWorkerConn struct {
+	conn       net.Conn
+	publicAddr *net.UDPAddr
+
+	readMu, writeMu sync.Mutex
+}
+
+// NewICEUDPMuxWorkerConn returns the net.PacketConn of a worker process
+// served by a ICEUDPMuxFrontend, exchanging packets with the frontend over
+// conn. Pass it to NewICEUDPMux to create the UDPMux of the worker.
+// publicAddr is the address of the public socket of the frontend, which is
+// advertised in the host candidates.
+func NewICEUDPMuxWorkerConn(conn net.Conn, publicAddr *net.UDPAddr) net.PacketConn {
+	return &iceUDPMuxWorkerConn{conn: conn, publicAddr: publicAddr}
+}
+
+func (c *iceUDPMuxWorkerConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	n, addr, err := readICEUDPMuxFrame(c.conn, p)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return n, addr, nil
+}
+
+func (c *iceUDPMuxWorkerConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return writeICEUDPMuxFrame(c.conn, p, addr)
+}
+
+func (c *iceUDPMuxWorkerConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *iceUDPMuxWorkerConn) LocalAddr() net.Addr {
+	return c.publicAddr
+}
+
+func (c *iceUDPMuxWorkerConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *iceUDPMuxWorkerConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *iceUDPMuxWorkerConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
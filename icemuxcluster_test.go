@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/stun/v2"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICEUDPMuxFrame(t *testing.T) {
+	for i, addr := range []*net.UDPAddr{
+		{IP: net.IPv4(192, 0, 2, 1).To4(), Port: 5000},
+		{IP: net.ParseIP("2001:db8::1"), Port: 443},
+	} {
+		var buf bytes.Buffer
+		n, err := writeICEUDPMuxFrame(&buf, []byte("packet"), addr)
+		assert.NoError(t, err, "testCase: %d %v", i, addr)
+		assert.Equal(t, 6, n, "testCase: %d %v", i, addr)
+
+		p := make([]byte, 64)
+		n, readAddr, err := readICEUDPMuxFrame(&buf, p)
+		assert.NoError(t, err, "testCase: %d %v", i, addr)
+		assert.Equal(t, "packet", string(p[:n]), "testCase: %d %v", i, addr)
+		assert.Equal(t, addr.String(), readAddr.String(), "testCase: %d %v", i, addr)
+	}
+
+	_, err := writeICEUDPMuxFrame(&bytes.Buffer{}, []byte("packet"), &net.TCPAddr{})
+	assert.ErrorIs(t, err, errICEUDPMuxFrameInvalid)
+}
+
+func TestSTUNLocalUsernameFragment(t *testing.T) {
+	request, err := stun.Build(stun.BindingRequest, stun.TransactionID, stun.NewUsername("workerAlocal:remote"))
+	assert.NoError(t, err)
+
+	ufrag, ok := stunLocalUsernameFragment(request.Raw)
+	assert.True(t, ok)
+	assert.Equal(t, "workerAlocal", ufrag)
+
+	response, err := stun.Build(stun.BindingSuccess, stun.TransactionID, stun.NewUsername("workerAlocal:remote"))
+	assert.NoError(t, err)
+
+	_, ok = stunLocalUsernameFragment(response.Raw)
+	assert.False(t, ok)
+
+	_, ok = stunLocalUsernameFragment([]byte("not stun"))
+	assert.False(t, ok)
+}
+
+func TestICEUDPMuxFrontend(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	publicConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	publicAddr, ok := publicConn.LocalAddr().(*net.UDPAddr)
+	assert.True(t, ok)
+
+	frontendSide, workerSide := net.Pipe()
+
+	var backend ICEUDPMuxBackend
+	frontend := NewICEUDPMuxFrontend(nil, publicConn, func(ufrag string) (ICEUDPMuxBackend, bool) {
+		return backend, strings.HasPrefix(ufrag, "workerA")
+	})
+	backend = frontend.AddStreamBackend(frontendSide)
+
+	workerConn := NewICEUDPMuxWorkerConn(workerSide, publicAddr)
+	assert.Equal(t, publicAddr, workerConn.LocalAddr())
+
+	client, err := net.DialUDP("udp4", nil, publicAddr)
+	assert.NoError(t, err)
+
+	buf := make([]byte, 1500)
+
+	// Packets from unknown addresses are dropped until a Binding request
+	// routes the address to the worker
+	_, err = client.Write([]byte("dropped"))
+	assert.NoError(t, err)
+
+	request, err := stun.Build(stun.BindingRequest, stun.TransactionID, stun.NewUsername("workerAlocal:remote"))
+	assert.NoError(t, err)
+	_, err = client.Write(request.Raw)
+	assert.NoError(t, err)
+
+	n, addr, err := workerConn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, request.Raw, buf[:n])
+	assert.Equal(t, client.LocalAddr().String(), addr.String())
+
+	_, err = client.Write([]byte("media"))
+	assert.NoError(t, err)
+
+	n, _, err = workerConn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "media", string(buf[:n]))
+
+	// The worker replies through the public socket
+	_, err = workerConn.WriteTo([]byte("reply"), addr)
+	assert.NoError(t, err)
+
+	n, err = client.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "reply", string(buf[:n]))
+
+	assert.NoError(t, client.Close())
+	assert.NoError(t, workerConn.Close())
+	assert.NoError(t, frontend.Close())
+}
+
+func TestICEUDPMuxFrontend_EvictStaleRoutes(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	publicConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+
+	backend := &iceUDPMuxStreamBackend{}
+	frontend := NewICEUDPMuxFrontendWithOptions(nil, publicConn, func(string) (ICEUDPMuxBackend, bool) {
+		return backend, true
+	}, ICEUDPMuxFrontendOptions{StaleRouteTimeout: 50 * time.Millisecond})
+
+	request, err := stun.Build(stun.BindingRequest, stun.TransactionID, stun.NewUsername("workerAlocal:remote"))
+	assert.NoError(t, err)
+
+	remote := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5000}
+	_, ok := frontend.route(request.Raw, remote)
+	assert.True(t, ok)
+	assert.Empty(t, frontend.EvictStaleRoutes(time.Hour))
+
+	routed, ok := frontend.route([]byte("media"), remote)
+	assert.True(t, ok)
+	assert.Equal(t, ICEUDPMuxBackend(backend), routed)
+
+	// The route is evicted once no packet was received for the timeout
+	assert.Eventually(t, func() bool {
+		frontend.mu.RLock()
+		defer frontend.mu.RUnlock()
+		return len(frontend.routes) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	_, ok = frontend.route([]byte("media"), remote)
+	assert.False(t, ok)
+
+	_, ok = frontend.route(request.Raw, remote)
+	assert.True(t, ok)
+	assert.Equal(t, []string{remote.String()}, frontend.EvictStaleRoutes(0))
+
+	assert.NoError(t, frontend.Close())
+}
@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/ice/v3"
+)
+
+// ICEUDPMuxRoute is a local ICE username fragment served by a
+// ICEUDPMuxMonitor, which is one PeerConnection
+type ICEUDPMuxRoute struct {
+	Ufrag string
+
+	PacketsReceived uint64
+	BytesReceived   uint64
+	PacketsSent     uint64
+	BytesSent       uint64
+
+	// CreatedAt is when the route was added, LastActivity when a packet was
+	// last received or sent on it
+	CreatedAt    time.Time
+	LastActivity time.Time
+
+	// LastRemoteAddr is the address a packet was last received from, nil if
+	// none was received
+	LastRemoteAddr net.Addr
+}
+
+// ICEUDPMuxMonitor is an ice.UDPMux which counts the packets of every route of
+// the wrapped UDPMux, so the routes of a UDPMux shared by many PeerConnections
+// with SettingEngine.SetICEUDPMux can be enumerated and monitored. Routes
+// without traffic can be evicted, closing their connections.
+type ICEUDPMuxMonitor struct {
+	ice.UDPMux
+
+	mu     sync.Mutex
+	routes map[string]*iceUDPMuxRoute
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewICEUDPMuxMonitor creates a ICEUDPMuxMonitor wrapping udpMux. If
+// staleRouteTimeout isn't 0, routes without traffic for longer are evicted
+// periodically.
+func NewICEUDPMuxMonitor(udpMux ice.UDPMux, staleRouteTimeout time.Duration) *ICEUDPMuxMonitor {
+	m := &ICEUDPMuxMonitor{
+		UDPMux: udpMux,
+		routes: map[string]*iceUDPMuxRoute{},
+		closed: make(chan struct{}),
+	}
+
+	if staleRouteTimeout > 0 {
+		go m.evictLoop(staleRouteTimeout)
+	}
+
+	return m
+}
+
+// GetConn returns the connection of the route of ufrag, creating the route if
+// needed
+func (m *ICEUDPMuxMonitor) GetConn(ufrag string, addr net.Addr) (net.PacketConn, error) {
+	conn, err := m.UDPMux.GetConn(ufrag, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	route, ok := m.routes[ufrag]
+	if !ok {
+		now := time.Now()
+		route = &iceUDPMuxRoute{ufrag: ufrag, createdAt: now, lastActivity: now.UnixNano()}
+		m.routes[ufrag] = route
+	}
+
+	return &iceUDPMuxRouteConn{PacketConn: conn, route: route}, nil
+}
+
+// RemoveConnByUfrag removes the route of ufrag and closes its connections
+func (m *ICEUDPMuxMonitor) RemoveConnByUfrag(ufrag string) {
+	m.mu.Lock()
+	delete(m.routes, ufrag)
+	m.mu.Unlock()
+
+	m.UDPMux.RemoveConnByUfrag(ufrag)
+}
+
+// Routes returns the current routes, sorted by ufrag
+func (m *ICEUDPMuxMonitor) Routes() []ICEUDPMuxRoute {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := make([]ICEUDPMuxRoute, 0, len(m.routes))
+	for _, route := range m.routes {
+		routes = append(routes, route.snapshot())
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Ufrag < routes[j].Ufrag
+	})
+
+	return routes
+}
+
+// Route returns the route of ufrag, and false if there is none
+func (m *ICEUDPMuxMonitor) Route(ufrag string) (ICEUDPMuxRoute, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	route, ok := m.routes[ufrag]
+	if !ok {
+		return ICEUDPMuxRoute{}, false
+	}
+
+	return route.snapshot(), true
+}
+
+// EvictStaleRoutes removes the routes without traffic for longer than
+// timeout, and returns their ufrags
+func (m *ICEUDPMuxMonitor) EvictStaleRoutes(timeout time.Duration) []string {
+	deadline := time.Now().Add(-timeout).UnixNano()
+
+	m.mu.Lock()
+	stale := []string{}
+	for ufrag, route := range m.routes {
+		if atomic.LoadInt64(&route.lastActivity) < deadline {
+			stale = append(stale, ufrag)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Strings(stale)
+	for _, ufrag := range stale {
+		m.RemoveConnByUfrag(ufrag)
+	}
+
+	return stale
+}
+
+// Close stops the eviction of stale routes and closes the wrapped UDPMux
+func (m *ICEUDPMuxMonitor) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+
+	return m.UDPMux.Close()
+}
+
+func (m *ICEUDPMuxMonitor) evictLoop(timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.EvictStaleRoutes(timeout)
+		case <-m.closed:
+			return
+		}
+	}
+}
+
+// iceUDPMuxRoute holds the counters of a route, updated atomically by its
+// connections
+type iceUDPMuxRoute struct {
+	packetsReceived, bytesReceived uint64
+	packetsSent, bytesSent         uint64
+	lastActivity                   int64
+
+	ufrag          string
+	createdAt      time.Time
+	lastRemoteAddr atomic.Value // net.Addr
+}
+
+func (r *iceUDPMuxRoute) snapshot() ICEUDPMuxRoute {
+	lastRemoteAddr, _ := r.lastRemoteAddr.Load().(net.Addr)
+
+	return ICEUDPMuxRoute{
+		Ufrag:           r.ufrag,
+		PacketsReceived: atomic.LoadUint64(&r.packetsReceived),
+		BytesReceived:   atomic.LoadUint64(&r.bytesReceived),
+		PacketsSent:     atomic.LoadUint64(&r.packetsSent),
+		BytesSent:       atomic.LoadUint64(&r.bytesSent),
+		CreatedAt:       r.createdAt,
+		LastActivity:    time.Unix(0, atomic.LoadInt64(&r.lastActivity)),
+		LastRemoteAddr:  lastRemoteAddr,
+	}
+}
+
+// iceUDPMuxRouteConn counts the packets of a connection of a route
+type iceUDPMuxRouteConn struct {
+	net.PacketConn
+	route *iceUDPMuxRoute
+}
+
+func (c *iceUDPMuxRouteConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(p)
+	if err == nil {
+		atomic.AddUint64(&c.route.packetsReceived, 1)
+		atomic.AddUint64(&c.route.bytesReceived, uint64(n))
+		atomic.StoreInt64(&c.route.lastActivity, time.Now().UnixNano())
+		if addr != nil {
+			c.route.lastRemoteAddr.Store(addr)
+		}
+	}
+
+	return n, addr, err
+}
+
+func (c *iceUDPMuxRouteConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	n, err := c.PacketConn.WriteTo(p, addr)
+	if err == nil {
+		atomic.AddUint64(&c.route.packetsSent, 1)
+		atomic.AddUint64(&c.route.bytesSent, uint64(n))
+		atomic.StoreInt64(&c.route.lastActivity, time.Now().UnixNano())
+	}
+
+	return n, err
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeICEUDPMux serves every ufrag with its own socket
+type fakeICEUDPMux struct {
+	conns map[string]net.PacketConn
+}
+
+func (m *fakeICEUDPMux) GetConn(ufrag string, _ net.Addr) (net.PacketConn, error) {
+	if conn, ok := m.conns[ufrag]; ok {
+		return conn, nil
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+	m.conns[ufrag] = conn
+
+	return conn, nil
+}
+
+func (m *fakeICEUDPMux) RemoveConnByUfrag(ufrag string) {
+	if conn, ok := m.conns[ufrag]; ok {
+		_ = conn.Close()
+		delete(m.conns, ufrag)
+	}
+}
+
+func (m *fakeICEUDPMux) GetListenAddresses() []net.Addr {
+	return nil
+}
+
+func (m *fakeICEUDPMux) Close() error {
+	for ufrag := range m.conns {
+		m.RemoveConnByUfrag(ufrag)
+	}
+
+	return nil
+}
+
+func TestICEUDPMuxMonitor(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	udpMux := &fakeICEUDPMux{conns: map[string]net.PacketConn{}}
+	monitor := NewICEUDPMuxMonitor(udpMux, 0)
+
+	connA, err := monitor.GetConn("ufragA", nil)
+	assert.NoError(t, err)
+	_, err = monitor.GetConn("ufragB", nil)
+	assert.NoError(t, err)
+
+	remote, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+
+	_, err = remote.WriteTo([]byte("ping"), udpMux.conns["ufragA"].LocalAddr())
+	assert.NoError(t, err)
+
+	buf := make([]byte, 64)
+	n, addr, err := connA.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf[:n]))
+
+	_, err = connA.WriteTo([]byte("pong!"), addr)
+	assert.NoError(t, err)
+
+	routes := monitor.Routes()
+	if assert.Len(t, routes, 2) {
+		assert.Equal(t, "ufragA", routes[0].Ufrag)
+		assert.Equal(t, uint64(1), routes[0].PacketsReceived)
+		assert.Equal(t, uint64(4), routes[0].BytesReceived)
+		assert.Equal(t, uint64(1), routes[0].PacketsSent)
+		assert.Equal(t, uint64(5), routes[0].BytesSent)
+		assert.Equal(t, remote.LocalAddr().String(), routes[0].LastRemoteAddr.String())
+
+		assert.Equal(t, "ufragB", routes[1].Ufrag)
+		assert.Zero(t, routes[1].PacketsReceived)
+		assert.Nil(t, routes[1].LastRemoteAddr)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	_, err = connA.WriteTo([]byte("pong!"), addr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"ufragB"}, monitor.EvictStaleRoutes(10*time.Millisecond))
+	_, ok := monitor.Route("ufragB")
+	assert.False(t, ok)
+	assert.NotContains(t, udpMux.conns, "ufragB")
+
+	route, ok := monitor.Route("ufragA")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), route.PacketsSent)
+
+	monitor.RemoveConnByUfrag("ufragA")
+	assert.Empty(t, monitor.Routes())
+
+	assert.NoError(t, remote.Close())
+	assert.NoError(t, monitor.Close())
+}
+
+func TestICEUDPMuxMonitor_StaleRouteTimeout(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	udpMux := &fakeICEUDPMux{conns: map[string]net.PacketConn{}}
+	monitor := NewICEUDPMuxMonitor(udpMux, 20*time.Millisecond)
+
+	_, err := monitor.GetConn("ufragA", nil)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(monitor.Routes()) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, monitor.Close())
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/pion/stun/v2"
+	"github.com/pion/transport/v3"
+	"golang.org/x/net/proxy"
+)
+
+// httpProxyDialer dials through a HTTP proxy with the CONNECT method
+type httpProxyDialer struct {
+	proxyURL *url.URL
+	forward  proxy.Dialer
+}
+
+// NewHTTPProxyDialer returns a proxy.Dialer which dials through the HTTP
+// proxy at proxyURL with the CONNECT method, using forward to reach the
+// proxy. The scheme of proxyURL is http or https, for a proxy which is
+// reached over TLS, and its user info is sent as Basic credentials. Pass it
+// to SettingEngine.SetICEProxyDialer to reach TURN servers over TCP and TLS
+// through the proxy.
+func NewHTTPProxyDialer(proxyURL *url.URL, forward proxy.Dialer) proxy.Dialer {
+	if forward == nil {
+		forward = proxy.Direct
+	}
+
+	return &httpProxyDialer{proxyURL: proxyURL, forward: forward}
+}
+
+func (d *httpProxyDialer) Dial(network, addr string) (net.Conn, error) {
+	proxyAddr := d.proxyURL.Host
+	if d.proxyURL.Port() == "" {
+		port := "80"
+		if d.proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(d.proxyURL.Hostname(), port)
+	}
+
+	conn, err := d.forward.Dial(network, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err = tlsConn.Handshake(); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if conn, err = d.connect(conn, addr); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// connect requests a tunnel to addr over conn, which is closed on failure
+func (d *httpProxyDialer) connect(conn net.Conn, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := d.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: %s", errICEProxyConnectFailed, res.Status)
+	}
+
+	if reader.Buffered() == 0 {
+		return conn, nil
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn is a net.Conn whose first bytes were already read into reader
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// SetICEProxyURL sets the proxy TURN servers are reached through over TCP
+// and TLS, like SetICEProxyDialer. The scheme of proxyURL is socks5 for a
+// SOCKS5 proxy, or http or https for a HTTP proxy supporting the CONNECT
+// method.
+func (e *SettingEngine) SetICEProxyURL(proxyURL *url.URL) error {
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
+		if err != nil {
+			return err
+		}
+		e.SetICEProxyDialer(dialer)
+	case "http", "https":
+		e.SetICEProxyDialer(NewHTTPProxyDialer(proxyURL, proxy.Direct))
+	default:
+		return fmt.Errorf("%w: %s", errICEProxySchemeUnsupported, proxyURL.Scheme)
+	}
+
+	return nil
+}
+
+// SetICETURNTLSConfig sets the TLS configuration of the connections to TURN
+// servers with the turns scheme over TCP, the default transport of turns
+// URLs. RootCAs allows relays with a private certificate authority,
+// VerifyPeerCertificate or VerifyConnection allows pinning their certificates.
+// ServerName defaults to the host of the URL. The configuration is not used
+// for turns over UDP, which is secured with DTLS.
+func (e *SettingEngine) SetICETURNTLSConfig(config *tls.Config) {
+	e.iceTURNTLSConfig = config
+}
+
+// turnsProxyDialer establishes TLS over the connections of a proxy dialer to
+// TURN servers with the turns scheme, which pion/ice expects from the dialer
+type turnsProxyDialer struct {
+	proxy.Dialer
+
+	// serverNames maps the addresses of the TURN servers to their names
+	serverNames map[string]string
+
+	// tlsConfig is the configuration set with SetICETURNTLSConfig, or nil
+	tlsConfig *tls.Config
+}
+
+// newTURNSProxyDialer returns dialer establishing TLS to the TURN servers of
+// urls with the turns scheme, or dialer if there is none. pion/ice only
+// establishes TLS itself, without tlsConfig, if there is no dialer, so n is
+// dialed directly when there is a tlsConfig but no dialer.
+func newTURNSProxyDialer(dialer proxy.Dialer, urls []*stun.URI, tlsConfig *tls.Config, n transport.Net) proxy.Dialer {
+	if dialer == nil && tlsConfig == nil {
+		return nil
+	}
+
+	serverNames := map[string]string{}
+	for _, u := range urls {
+		if u.Scheme == stun.SchemeTypeTURNS && u.Proto == stun.ProtoTypeTCP {
+			// Formatted like pion/ice does
+			serverNames[fmt.Sprintf("%s:%d", u.Host, u.Port)] = u.Host
+		}
+	}
+	if len(serverNames) == 0 {
+		return dialer
+	}
+	if dialer == nil {
+		dialer = n
+	}
+
+	return &turnsProxyDialer{Dialer: dialer, serverNames: serverNames, tlsConfig: tlsConfig}
+}
+
+func (d *turnsProxyDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.Dialer.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	serverName, ok := d.serverNames[addr]
+	if !ok {
+		return conn, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if d.tlsConfig != nil {
+		tlsConfig = d.tlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = serverName
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err = tlsConn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
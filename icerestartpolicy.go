@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultICERestartInitialBackoff = time.Second
+	defaultICERestartMaxBackoff     = 30 * time.Second
+)
+
+// ICERestartPolicy configures the automatic ICE restarts of a PeerConnection.
+type ICERestartPolicy struct {
+	// RestartOnDisconnected also restarts ICE when the ICE connection is
+	// disconnected, and not only when it failed. The restart is canceled if
+	// the connection recovers before the backoff elapsed.
+	RestartOnDisconnected bool
+
+	// InitialBackoff is the delay before the first restart, one second by
+	// default. The delay doubles after every restart which didn't connect.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the longest delay between two restarts, 30 seconds by
+	// default.
+	MaxBackoff time.Duration
+
+	// MaxAttempts is the number of restarts attempted before giving up until
+	// the ICE connection is connected again. Leave it 0 to never give up.
+	MaxAttempts int
+}
+
+// SetICERestartPolicy enables automatic ICE restarts: when the ICE connection
+// fails, or is disconnected if RestartOnDisconnected is set, the
+// PeerConnection calls RestartICE after a backoff. The ICE credentials are
+// regenerated and OnNegotiationNeeded fires, the application only has to
+// exchange the new offer and answer like for any other renegotiation.
+// Automatic restarts are disabled by default.
+func (e *SettingEngine) SetICERestartPolicy(policy ICERestartPolicy) {
+	e.iceRestartPolicy = &policy
+}
+
+// iceRestarter restarts the ICE of a PeerConnection according to an
+// ICERestartPolicy
+type iceRestarter struct {
+	pc     *PeerConnection
+	policy ICERestartPolicy
+
+	mu       sync.Mutex
+	attempts int
+	timer    *time.Timer
+	closed   bool
+}
+
+func newICERestarter(pc *PeerConnection, policy ICERestartPolicy) *iceRestarter {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = defaultICERestartInitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = defaultICERestartMaxBackoff
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+
+	return &iceRestarter{
+		pc:     pc,
+		policy: policy,
+	}
+}
+
+// backoff returns the delay before the restart following attempts restarts
+func (r *iceRestarter) backoff(attempts int) time.Duration {
+	backoff := r.policy.InitialBackoff
+	for i := 0; i < attempts && backoff < r.policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.policy.MaxBackoff {
+		backoff = r.policy.MaxBackoff
+	}
+
+	return backoff
+}
+
+func (r *iceRestarter) onICEConnectionStateChange(state ICEConnectionState) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch state {
+	case ICEConnectionStateConnected, ICEConnectionStateCompleted:
+		r.attempts = 0
+		r.stopTimer()
+	case ICEConnectionStateDisconnected:
+		if r.policy.RestartOnDisconnected {
+			r.schedule()
+		}
+	case ICEConnectionStateFailed:
+		r.schedule()
+	default:
+	}
+}
+
+// schedule starts the backoff before the next restart, unless one is already
+// pending. r.mu must be held.
+func (r *iceRestarter) schedule() {
+	if r.closed || r.timer != nil {
+		return
+	}
+	if r.policy.MaxAttempts > 0 && r.attempts >= r.policy.MaxAttempts {
+		r.pc.log.Warnf("Giving up restarting ICE after %d attempts", r.attempts)
+		return
+	}
+
+	backoff := r.backoff(r.attempts)
+	r.pc.log.Infof("Restarting ICE in %s", backoff)
+	r.timer = time.AfterFunc(backoff, r.restart)
+}
+
+func (r *iceRestarter) restart() {
+	r.mu.Lock()
+	if r.closed || r.timer == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.timer = nil
+	r.attempts++
+	r.mu.Unlock()
+
+	if err := r.pc.RestartICE(); err != nil {
+		r.pc.log.Warnf("Failed to restart ICE: %v", err)
+	}
+}
+
+// stopTimer cancels the pending restart. r.mu must be held.
+func (r *iceRestarter) stopTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+func (r *iceRestarter) close() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.stopTimer()
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICERestarter_Backoff(t *testing.T) {
+	r := newICERestarter(nil, ICERestartPolicy{MaxBackoff: 5 * time.Second})
+
+	testCases := []struct {
+		attempts        int
+		expectedBackoff time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expectedBackoff, r.backoff(testCase.attempts), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestICERestarter(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pc.SetLocalDescription(offer))
+
+	r := newICERestarter(pc, ICERestartPolicy{InitialBackoff: 10 * time.Millisecond, MaxAttempts: 1})
+
+	// Disconnected is ignored unless RestartOnDisconnected is set
+	r.onICEConnectionStateChange(ICEConnectionStateDisconnected)
+	r.mu.Lock()
+	assert.Nil(t, r.timer)
+	r.mu.Unlock()
+
+	r.onICEConnectionStateChange(ICEConnectionStateFailed)
+	for !pc.isICERestartRequested.get() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// MaxAttempts is reached until the connection recovers
+	r.onICEConnectionStateChange(ICEConnectionStateFailed)
+	r.mu.Lock()
+	assert.Equal(t, 1, r.attempts)
+	assert.Nil(t, r.timer)
+	r.mu.Unlock()
+
+	r.onICEConnectionStateChange(ICEConnectionStateConnected)
+	r.mu.Lock()
+	assert.Equal(t, 0, r.attempts)
+	r.mu.Unlock()
+
+	// A pending restart is canceled by close
+	r.onICEConnectionStateChange(ICEConnectionStateFailed)
+	r.close()
+	r.mu.Lock()
+	assert.Nil(t, r.timer)
+	r.mu.Unlock()
+
+	assert.NoError(t, pc.Close())
+}
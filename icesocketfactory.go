@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/pion/transport/v3"
+)
+
+// ICESocketFactory creates the sockets of ICE, like the sockets of a VPN
+// tunnel, of a userspace network stack or of a test transport. See
+// SettingEngine.SetICESocketFactory.
+type ICESocketFactory interface {
+	// ListenPacket creates the UDP sockets of host candidates, of STUN and
+	// TURN over UDP, and of mDNS. network is udp, udp4 or udp6, and address
+	// is a host:port which may have the port 0.
+	ListenPacket(network, address string) (net.PacketConn, error)
+
+	// Dial creates the connections to TURN servers over TCP, TLS and DTLS.
+	// network is tcp, tcp4 or udp.
+	Dial(network, address string) (net.Conn, error)
+}
+
+// SetICESocketFactory sets the factory the ICE sockets are created with,
+// instead of the network stack of the host or of the Net set with SetNet.
+// Interfaces are still listed by the Net and the InterfaceProvider, and names
+// are still resolved by them or by the DNSResolver. Sockets of a UDPMux or
+// TCPMux and of active ICE-TCP candidates aren't created by the factory.
+func (e *SettingEngine) SetICESocketFactory(factory ICESocketFactory) {
+	e.iceSocketFactory = factory
+}
+
+// iceNet returns the Net pion/ice gathers candidates and creates its sockets
+// with
+func (e *SettingEngine) iceNet() transport.Net {
+	return newDNSResolverNet(
+		newSocketFactoryNet(newInterfaceProviderNet(e.net, e.interfaceProvider), e.iceSocketFactory),
+		e.dnsResolver,
+	)
+}
+
+// socketFactoryNet is a transport.Net which creates its sockets with an
+// ICESocketFactory
+type socketFactoryNet struct {
+	transport.Net
+	factory ICESocketFactory
+}
+
+func newSocketFactoryNet(base transport.Net, factory ICESocketFactory) transport.Net {
+	if factory == nil {
+		return base
+	}
+
+	return &socketFactoryNet{Net: base, factory: factory}
+}
+
+func (n *socketFactoryNet) ListenPacket(network string, address string) (net.PacketConn, error) {
+	return n.factory.ListenPacket(network, address)
+}
+
+func (n *socketFactoryNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	address := ":0"
+	if locAddr != nil {
+		address = locAddr.String()
+	}
+
+	conn, err := n.factory.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if udpConn, ok := conn.(transport.UDPConn); ok {
+		return udpConn, nil
+	}
+
+	return &packetConnUDPConn{PacketConn: conn}, nil
+}
+
+func (n *socketFactoryNet) Dial(network, address string) (net.Conn, error) {
+	return n.factory.Dial(network, address)
+}
+
+func (n *socketFactoryNet) DialUDP(network string, _, raddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.factory.Dial(network, raddr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if udpConn, ok := conn.(transport.UDPConn); ok {
+		return udpConn, nil
+	}
+
+	return &connUDPConn{Conn: conn}, nil
+}
+
+func (n *socketFactoryNet) DialTCP(network string, _, raddr *net.TCPAddr) (transport.TCPConn, error) {
+	conn, err := n.factory.Dial(network, raddr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if tcpConn, ok := conn.(transport.TCPConn); ok {
+		return tcpConn, nil
+	}
+
+	return &connTCPConn{Conn: conn}, nil
+}
+
+// socketBufferSetter is implemented by sockets which have buffers, like
+// *net.UDPConn
+type socketBufferSetter interface {
+	SetReadBuffer(bytes int) error
+	SetWriteBuffer(bytes int) error
+}
+
+// packetConnUDPConn is a transport.UDPConn created from an unconnected
+// net.PacketConn. Out-of-band data isn't supported.
+type packetConnUDPConn struct {
+	net.PacketConn
+}
+
+func (c *packetConnUDPConn) RemoteAddr() net.Addr {
+	return nil
+}
+
+func (c *packetConnUDPConn) SetReadBuffer(bytes int) error {
+	if setter, ok := c.PacketConn.(socketBufferSetter); ok {
+		return setter.SetReadBuffer(bytes)
+	}
+
+	return nil
+}
+
+func (c *packetConnUDPConn) SetWriteBuffer(bytes int) error {
+	if setter, ok := c.PacketConn.(socketBufferSetter); ok {
+		return setter.SetWriteBuffer(bytes)
+	}
+
+	return nil
+}
+
+func (c *packetConnUDPConn) Read(b []byte) (int, error) {
+	n, _, err := c.PacketConn.ReadFrom(b)
+	return n, err
+}
+
+func (c *packetConnUDPConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	udpAddr, _ := addr.(*net.UDPAddr)
+
+	return n, udpAddr, err
+}
+
+func (c *packetConnUDPConn) ReadMsgUDP(b, _ []byte) (int, int, int, *net.UDPAddr, error) {
+	n, addr, err := c.ReadFromUDP(b)
+	return n, 0, 0, addr, err
+}
+
+func (c *packetConnUDPConn) Write([]byte) (int, error) {
+	return 0, errICESocketNotConnected
+}
+
+func (c *packetConnUDPConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func (c *packetConnUDPConn) WriteMsgUDP(b, _ []byte, addr *net.UDPAddr) (int, int, error) {
+	n, err := c.PacketConn.WriteTo(b, addr)
+	return n, 0, err
+}
+
+// connUDPConn is a transport.UDPConn created from a connected net.Conn.
+// Out-of-band data isn't supported.
+type connUDPConn struct {
+	net.Conn
+}
+
+func (c *connUDPConn) SetReadBuffer(bytes int) error {
+	if setter, ok := c.Conn.(socketBufferSetter); ok {
+		return setter.SetReadBuffer(bytes)
+	}
+
+	return nil
+}
+
+func (c *connUDPConn) SetWriteBuffer(bytes int) error {
+	if setter, ok := c.Conn.(socketBufferSetter); ok {
+		return setter.SetWriteBuffer(bytes)
+	}
+
+	return nil
+}
+
+func (c *connUDPConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, err := c.Conn.Read(b)
+	return n, c.Conn.RemoteAddr(), err
+}
+
+func (c *connUDPConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	n, err := c.Conn.Read(b)
+	udpAddr, _ := c.Conn.RemoteAddr().(*net.UDPAddr)
+
+	return n, udpAddr, err
+}
+
+func (c *connUDPConn) ReadMsgUDP(b, _ []byte) (int, int, int, *net.UDPAddr, error) {
+	n, addr, err := c.ReadFromUDP(b)
+	return n, 0, 0, addr, err
+}
+
+// WriteTo writes to the remote the connection is connected to, whatever addr
+func (c *connUDPConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	return c.Conn.Write(b)
+}
+
+func (c *connUDPConn) WriteToUDP(b []byte, _ *net.UDPAddr) (int, error) {
+	return c.Conn.Write(b)
+}
+
+func (c *connUDPConn) WriteMsgUDP(b, _ []byte, _ *net.UDPAddr) (int, int, error) {
+	n, err := c.Conn.Write(b)
+	return n, 0, err
+}
+
+// connTCPConn is a transport.TCPConn created from a net.Conn. The options of
+// TCP are ignored if the net.Conn doesn't support them.
+type connTCPConn struct {
+	net.Conn
+}
+
+func (c *connTCPConn) CloseRead() error {
+	if closer, ok := c.Conn.(interface{ CloseRead() error }); ok {
+		return closer.CloseRead()
+	}
+
+	return nil
+}
+
+func (c *connTCPConn) CloseWrite() error {
+	if closer, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return closer.CloseWrite()
+	}
+
+	return nil
+}
+
+func (c *connTCPConn) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(c.Conn, r)
+}
+
+func (c *connTCPConn) SetLinger(int) error {
+	return nil
+}
+
+func (c *connTCPConn) SetKeepAlive(bool) error {
+	return nil
+}
+
+func (c *connTCPConn) SetKeepAlivePeriod(time.Duration) error {
+	return nil
+}
+
+func (c *connTCPConn) SetNoDelay(bool) error {
+	return nil
+}
+
+func (c *connTCPConn) SetReadBuffer(bytes int) error {
+	if setter, ok := c.Conn.(socketBufferSetter); ok {
+		return setter.SetReadBuffer(bytes)
+	}
+
+	return nil
+}
+
+func (c *connTCPConn) SetWriteBuffer(bytes int) error {
+	if setter, ok := c.Conn.(socketBufferSetter); ok {
+		return setter.SetWriteBuffer(bytes)
+	}
+
+	return nil
+}
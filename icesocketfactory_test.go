@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// opaquePacketConn hides the type of the net.PacketConn it wraps
+type opaquePacketConn struct {
+	net.PacketConn
+}
+
+type countingICESocketFactory struct {
+	listened, dialed int32
+}
+
+func (f *countingICESocketFactory) ListenPacket(network, address string) (net.PacketConn, error) {
+	atomic.AddInt32(&f.listened, 1)
+
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &opaquePacketConn{conn}, nil
+}
+
+func (f *countingICESocketFactory) Dial(network, address string) (net.Conn, error) {
+	atomic.AddInt32(&f.dialed, 1)
+
+	return net.Dial(network, address)
+}
+
+func TestSettingEngine_SetICESocketFactory(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	factory := &countingICESocketFactory{}
+
+	s := SettingEngine{}
+	s.SetICESocketFactory(factory)
+	s.SetIncludeLoopbackCandidate(true)
+	s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+
+	offerPC, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	assert.NotZero(t, atomic.LoadInt32(&factory.listened))
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestPacketConnUDPConn(t *testing.T) {
+	n := newSocketFactoryNet(nil, &countingICESocketFactory{})
+
+	conn, err := n.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	assert.IsType(t, &packetConnUDPConn{}, conn)
+
+	peer, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+
+	_, err = conn.WriteToUDP([]byte("ping"), peer.LocalAddr().(*net.UDPAddr))
+	assert.NoError(t, err)
+
+	buf := make([]byte, 16)
+	n1, addr, err := peer.ReadFromUDP(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf[:n1]))
+
+	_, err = peer.WriteToUDP([]byte("pong"), addr)
+	assert.NoError(t, err)
+
+	n2, from, err := conn.ReadFromUDP(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(buf[:n2]))
+	assert.Equal(t, peer.LocalAddr().String(), from.String())
+
+	_, err = conn.Write([]byte("ping"))
+	assert.ErrorIs(t, err, errICESocketNotConnected)
+
+	assert.NoError(t, conn.Close())
+	assert.NoError(t, peer.Close())
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/pion/logging"
+)
+
+// iceTCPFirstPacketTimeout is how long a connection of the backlog waits for
+// its first packet when none is set in ICETCPMuxOptions
+const iceTCPFirstPacketTimeout = 5 * time.Second
+
+var errICETCPMuxListenerClosed = errors.New("ICE TCP mux listener closed")
+
+// ICETCPMuxOptions configures a TCPMux created with NewICETCPMuxWithOptions
+// or SettingEngine.EnableICETCP
+type ICETCPMuxOptions struct {
+	// ReadBufferSize is how many packets are buffered for each connection.
+	// The default is 8.
+	ReadBufferSize int
+
+	// WriteBufferSize is how many bytes are buffered for writes on each
+	// connection, packets are dropped while it is full. The default is 0,
+	// writes block until the packet is written.
+	WriteBufferSize int
+
+	// Backlog is how many accepted connections may wait for their first STUN
+	// packet at once, which identifies their PeerConnection. Further
+	// connections aren't accepted until one of them sent it or was closed,
+	// and wait in the listen queue of the OS. The default is 0, no limit.
+	Backlog int
+
+	// FirstPacketTimeout is how long a connection of the backlog waits for
+	// its first packet before it is closed and leaves the backlog. It is only
+	// used if Backlog is set. The default is 5 seconds.
+	FirstPacketTimeout time.Duration
+}
+
+// NewICETCPMuxWithOptions creates a new instance of ice.TCPMuxDefault
+// configured with options. It enables use of passive ICE TCP candidates.
+func NewICETCPMuxWithOptions(logger logging.LeveledLogger, listener net.Listener, options ICETCPMuxOptions) ice.TCPMux {
+	if options.ReadBufferSize == 0 {
+		options.ReadBufferSize = iceTCPReadBufferSize
+	}
+	if options.FirstPacketTimeout == 0 {
+		options.FirstPacketTimeout = iceTCPFirstPacketTimeout
+	}
+	if options.Backlog > 0 {
+		listener = newICETCPBacklogListener(listener, options.Backlog, options.FirstPacketTimeout)
+	}
+
+	return ice.NewTCPMuxDefault(ice.TCPMuxParams{
+		Listener:        listener,
+		Logger:          logger,
+		ReadBufferSize:  options.ReadBufferSize,
+		WriteBufferSize: options.WriteBufferSize,
+	})
+}
+
+// iceTCPBacklogListener stops accepting connections while backlog accepted
+// connections didn't receive their first packet. The reads of the accepted
+// connections time out after firstPacketTimeout until then.
+type iceTCPBacklogListener struct {
+	net.Listener
+	pending            chan struct{}
+	closed             chan struct{}
+	firstPacketTimeout time.Duration
+
+	closeOnce sync.Once
+}
+
+func newICETCPBacklogListener(listener net.Listener, backlog int, firstPacketTimeout time.Duration) *iceTCPBacklogListener {
+	return &iceTCPBacklogListener{
+		Listener:           listener,
+		pending:            make(chan struct{}, backlog),
+		closed:             make(chan struct{}),
+		firstPacketTimeout: firstPacketTimeout,
+	}
+}
+
+func (l *iceTCPBacklogListener) Accept() (net.Conn, error) {
+	for {
+		select {
+		case l.pending <- struct{}{}:
+		case <-l.closed:
+			return nil, errICETCPMuxListenerClosed
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			<-l.pending
+			return nil, err
+		}
+
+		// A connection without deadline could hold its slot forever, it is
+		// dropped since an error stops the TCPMux from accepting
+		backlogConn := &iceTCPBacklogConn{Conn: conn, listener: l}
+		if err = conn.SetReadDeadline(time.Now().Add(l.firstPacketTimeout)); err != nil {
+			_ = backlogConn.Close()
+			continue
+		}
+
+		return backlogConn, nil
+	}
+}
+
+func (l *iceTCPBacklogListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+
+	return l.Listener.Close()
+}
+
+// iceTCPBacklogConn leaves the backlog of its listener once it received data,
+// its first read timed out or it is closed
+type iceTCPBacklogConn struct {
+	net.Conn
+	listener *iceTCPBacklogListener
+
+	releaseOnce  sync.Once
+	deadlineOnce sync.Once
+}
+
+func (c *iceTCPBacklogConn) release() {
+	c.releaseOnce.Do(func() {
+		<-c.listener.pending
+	})
+}
+
+func (c *iceTCPBacklogConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 || err != nil {
+		c.release()
+	}
+	if n > 0 && err == nil {
+		err = c.clearFirstPacketDeadline()
+	}
+
+	return n, err
+}
+
+func (c *iceTCPBacklogConn) clearFirstPacketDeadline() (err error) {
+	c.deadlineOnce.Do(func() {
+		err = c.Conn.SetReadDeadline(time.Time{})
+	})
+
+	return
+}
+
+func (c *iceTCPBacklogConn) Close() error {
+	c.release()
+
+	return c.Conn.Close()
+}
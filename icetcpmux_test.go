@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICETCPBacklogListener(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	tcpListener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+
+	listener := newICETCPBacklogListener(tcpListener, 1, iceTCPFirstPacketTimeout)
+
+	dial := func() net.Conn {
+		conn, dialErr := net.Dial("tcp", tcpListener.Addr().String())
+		assert.NoError(t, dialErr)
+		return conn
+	}
+	clientA, clientB := dial(), dial()
+
+	serverA, err := listener.Accept()
+	assert.NoError(t, err)
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, acceptErr := listener.Accept()
+		assert.NoError(t, acceptErr)
+		accepted <- conn
+	}()
+
+	select {
+	case <-accepted:
+		assert.Fail(t, "accepted a connection while the backlog is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = clientA.Write([]byte("stun"))
+	assert.NoError(t, err)
+	_, err = serverA.Read(make([]byte, 4))
+	assert.NoError(t, err)
+
+	serverB := <-accepted
+
+	// Closing a pending connection leaves the backlog as well
+	assert.NoError(t, serverB.Close())
+	assert.Len(t, listener.pending, 0)
+
+	assert.NoError(t, listener.Close())
+	_, err = listener.Accept()
+	assert.Error(t, err)
+
+	for _, conn := range []net.Conn{clientA, clientB, serverA} {
+		assert.NoError(t, conn.Close())
+	}
+}
+
+func TestICETCPBacklogListener_FirstPacketTimeout(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	tcpListener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+
+	listener := newICETCPBacklogListener(tcpListener, 1, 50*time.Millisecond)
+
+	client, err := net.Dial("tcp", tcpListener.Addr().String())
+	assert.NoError(t, err)
+
+	server, err := listener.Accept()
+	assert.NoError(t, err)
+
+	// The silent connection leaves the backlog once its read timed out
+	_, err = server.Read(make([]byte, 4))
+	var netErr net.Error
+	if assert.True(t, errors.As(err, &netErr)) {
+		assert.True(t, netErr.Timeout())
+	}
+	assert.Len(t, listener.pending, 0)
+
+	// The deadline is cleared after the first packet
+	assert.NoError(t, server.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = client.Write([]byte("stun"))
+	assert.NoError(t, err)
+	_, err = server.Read(make([]byte, 4))
+	assert.NoError(t, err)
+
+	read := make(chan error)
+	go func() {
+		_, readErr := server.Read(make([]byte, 4))
+		read <- readErr
+	}()
+	select {
+	case readErr := <-read:
+		assert.Fail(t, "read returned before data was sent", readErr)
+	case <-time.After(100 * time.Millisecond):
+	}
+	_, err = client.Write([]byte("stun"))
+	assert.NoError(t, err)
+	assert.NoError(t, <-read)
+
+	assert.NoError(t, listener.Close())
+	assert.NoError(t, client.Close())
+	assert.NoError(t, server.Close())
+}
@@ -18,6 +18,9 @@ import (
 	"github.com/pion/webrtc/v4/internal/mux"
 )
 
+// iceTransportStatsID is the ID of the TransportStats in a StatsReport
+const iceTransportStatsID = "iceTransport"
+
 // ICETransport allows an application access to information about the ICE
 // transport over which packets are sent and received.
 type ICETransport struct {
@@ -28,12 +31,24 @@ type ICETransport struct {
 	onConnectionStateChangeHandler         atomic.Value // func(ICETransportState)
 	internalOnConnectionStateChangeHandler atomic.Value // func(ICETransportState)
 	onSelectedCandidatePairChangeHandler   atomic.Value // func(*ICECandidatePair)
+	// Invoked with the reason of the change, used by PeerConnection
+	internalOnSelectedCandidatePairChangeHandler atomic.Value // func(SelectedCandidatePairChange)
+
+	// The previously selected candidate pair, and if the ICETransport was
+	// restarted or got disconnected since it was selected
+	selectedCandidatePair atomic.Value // *ICECandidatePair
+	restarted             atomicBool
+	disconnected          atomicBool
 
 	state atomic.Value // ICETransportState
 
-	gatherer *ICEGatherer
-	conn     *ice.Conn
-	mux      *mux.Mux
+	gatherer  *ICEGatherer
+	conn      *ice.Conn
+	agentConn *iceAgentConn
+	mux       *mux.Mux
+	// agentPending is set when the ICE Agent was replaced on a restart and
+	// waits for the remote credentials to connect
+	agentPending bool
 
 	ctx       context.Context
 	ctxCancel func()
@@ -102,22 +117,7 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		return fmt.Errorf("%w: unable to start ICETransport", errICEAgentNotExist)
 	}
 
-	if err := agent.OnConnectionStateChange(func(iceState ice.ConnectionState) {
-		state := newICETransportStateFromICE(iceState)
-
-		t.setState(state)
-		t.onConnectionStateChange(state)
-	}); err != nil {
-		return err
-	}
-	if err := agent.OnSelectedCandidatePairChange(func(local, remote ice.Candidate) {
-		candidates, err := newICECandidatesFromICE([]ice.Candidate{local, remote})
-		if err != nil {
-			t.log.Warnf("%w: %s", errICECandiatesCoversionFailed, err)
-			return
-		}
-		t.onSelectedCandidatePairChange(NewICECandidatePair(&candidates[0], &candidates[1]))
-	}); err != nil {
+	if err := t.bindAgent(agent); err != nil {
 		return err
 	}
 
@@ -133,22 +133,7 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 	// added so that the agent can complete a connection
 	t.lock.Unlock()
 
-	var iceConn *ice.Conn
-	var err error
-	switch *role {
-	case ICERoleControlling:
-		iceConn, err = agent.Dial(t.ctx,
-			params.UsernameFragment,
-			params.Password)
-
-	case ICERoleControlled:
-		iceConn, err = agent.Accept(t.ctx,
-			params.UsernameFragment,
-			params.Password)
-
-	default:
-		err = errICERoleUnknown
-	}
+	iceConn, err := t.connect(agent, *role, params)
 
 	// Reacquire the lock to set the connection/mux
 	t.lock.Lock()
@@ -157,9 +142,10 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 	}
 
 	t.conn = iceConn
+	t.agentConn = newICEAgentConn(iceConn)
 
 	config := mux.Config{
-		Conn:          t.conn,
+		Conn:          t.agentConn,
 		BufferSize:    int(t.gatherer.api.settingEngine.getReceiveMTU()),
 		LoggerFactory: t.loggerFactory,
 	}
@@ -168,20 +154,106 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 	return nil
 }
 
+// bindAgent forwards the events of agent as long as it is the ICE Agent of
+// the ICEGatherer
+func (t *ICETransport) bindAgent(agent *ice.Agent) error {
+	if err := agent.OnConnectionStateChange(func(iceState ice.ConnectionState) {
+		if t.gatherer.getAgent() != agent {
+			return
+		}
+
+		state := newICETransportStateFromICE(iceState)
+
+		var selected *ice.CandidatePair
+		if state == ICETransportStateConnected {
+			selected, _ = agent.GetSelectedCandidatePair()
+		}
+		t.recordDisconnection(state, selected)
+
+		t.setState(state)
+		t.onConnectionStateChange(state)
+	}); err != nil {
+		return err
+	}
+
+	return agent.OnSelectedCandidatePairChange(func(local, remote ice.Candidate) {
+		if t.gatherer.getAgent() != agent {
+			return
+		}
+
+		candidates, err := newICECandidatesFromICE([]ice.Candidate{local, remote})
+		if err != nil {
+			t.log.Warnf("%w: %s", errICECandiatesCoversionFailed, err)
+			return
+		}
+		t.onSelectedCandidatePairChange(NewICECandidatePair(&candidates[0], &candidates[1]))
+	})
+}
+
+func (t *ICETransport) connect(agent *ice.Agent, role ICERole, params ICEParameters) (*ice.Conn, error) {
+	switch role {
+	case ICERoleControlling:
+		return agent.Dial(t.ctx, params.UsernameFragment, params.Password)
+	case ICERoleControlled:
+		return agent.Accept(t.ctx, params.UsernameFragment, params.Password)
+	default:
+		return nil, errICERoleUnknown
+	}
+}
+
 // restart is not exposed currently because ORTC has users create a whole new ICETransport
 // so for now lets keep it private so we don't cause ORTC users to depend on non-standard APIs
+//
+// The ICE Agent is replaced if the ICE servers or the gather policy changed,
+// since an ICE Agent can't change them on a restart. The new Agent connects
+// once the remote credentials are set.
 func (t *ICETransport) restart() error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	if t.agentConn != nil && t.gatherer.haveOptionsChanged() {
+		return t.replaceAgent()
+	}
+
 	agent := t.gatherer.getAgent()
 	if agent == nil {
 		return fmt.Errorf("%w: unable to restart ICETransport", errICEAgentNotExist)
 	}
 
-	if err := agent.Restart(t.gatherer.api.settingEngine.candidates.UsernameFragment, t.gatherer.api.settingEngine.candidates.Password); err != nil {
+	ufrag, err := t.gatherer.api.settingEngine.getICEUsernameFragment()
+	if err != nil {
 		return err
 	}
+
+	pwd, err := t.gatherer.api.settingEngine.getICEPassword()
+	if err != nil {
+		return err
+	}
+
+	if err := agent.Restart(ufrag, pwd); err != nil {
+		return err
+	}
+	t.restarted.set(true)
+
+	return t.gatherer.Gather()
+}
+
+func (t *ICETransport) replaceAgent() error {
+	t.agentConn.replace()
+	if err := t.gatherer.recreateAgent(); err != nil {
+		return err
+	}
+
+	agent := t.gatherer.getAgent()
+	if agent == nil {
+		return fmt.Errorf("%w: unable to restart ICETransport", errICEAgentNotExist)
+	}
+	if err := t.bindAgent(agent); err != nil {
+		return err
+	}
+	t.agentPending = true
+	t.restarted.set(true)
+
 	return t.gatherer.Gather()
 }
 
@@ -197,7 +269,15 @@ func (t *ICETransport) Stop() error {
 	}
 
 	if t.mux != nil {
-		return t.mux.Close()
+		if err := t.mux.Close(); err != nil {
+			return err
+		}
+
+		// The connection doesn't own an ICE Agent which didn't connect yet
+		if t.agentConn.isReplacing() {
+			return t.gatherer.Close()
+		}
+		return nil
 	} else if t.gatherer != nil {
 		return t.gatherer.Close()
 	}
@@ -211,9 +291,35 @@ func (t *ICETransport) OnSelectedCandidatePairChange(f func(*ICECandidatePair))
 }
 
 func (t *ICETransport) onSelectedCandidatePairChange(pair *ICECandidatePair) {
+	previous, _ := t.selectedCandidatePair.Load().(*ICECandidatePair)
+	t.selectedCandidatePair.Store(pair)
+	reason := selectedCandidatePairChangeReason(previous, t.restarted.swap(false), t.disconnected.swap(false))
+
 	if handler, ok := t.onSelectedCandidatePairChangeHandler.Load().(func(*ICECandidatePair)); ok {
 		handler(pair)
 	}
+
+	if handler, ok := t.internalOnSelectedCandidatePairChangeHandler.Load().(func(SelectedCandidatePairChange)); ok {
+		handler(SelectedCandidatePairChange{Pair: pair, Previous: previous, Reason: reason})
+	}
+}
+
+// recordDisconnection remembers that the ICE Agent got disconnected or failed
+// until the next candidate pair is selected, or until it connected again on
+// the selected candidate pair. The ICE Agent changes to connected before it
+// reports a newly selected pair, which is then selected.
+func (t *ICETransport) recordDisconnection(state ICETransportState, selected *ice.CandidatePair) {
+	switch state {
+	case ICETransportStateDisconnected, ICETransportStateFailed:
+		t.disconnected.set(true)
+	case ICETransportStateConnected:
+		previous, _ := t.selectedCandidatePair.Load().(*ICECandidatePair)
+		if previous != nil && selected != nil &&
+			previous.Local.statsID == selected.Local.ID() && previous.Remote.statsID == selected.Remote.ID() {
+			t.disconnected.set(false)
+		}
+	default:
+	}
 }
 
 // OnConnectionStateChange sets a handler that is fired when the ICE
@@ -254,6 +360,16 @@ func (t *ICETransport) SetRemoteCandidates(remoteCandidates []ICECandidate) erro
 	}
 
 	for _, c := range remoteCandidates {
+		if !t.gatherer.api.settingEngine.allowsRemoteCandidate(c) {
+			t.log.Debugf("Dropping remote candidate %s, rejected by the candidate filter", c)
+			continue
+		}
+
+		c.Priority = t.gatherer.api.settingEngine.remoteCandidatePriority(c)
+		if t.resolveMulticastDNSCandidate(c) {
+			continue
+		}
+
 		i, err := c.toICE()
 		if err != nil {
 			return err
@@ -282,7 +398,18 @@ func (t *ICETransport) AddRemoteCandidate(remoteCandidate *ICECandidate) error {
 	}
 
 	if remoteCandidate != nil {
-		if c, err = remoteCandidate.toICE(); err != nil {
+		if !t.gatherer.api.settingEngine.allowsRemoteCandidate(*remoteCandidate) {
+			t.log.Debugf("Dropping remote candidate %s, rejected by the candidate filter", remoteCandidate)
+			return nil
+		}
+
+		candidate := *remoteCandidate
+		candidate.Priority = t.gatherer.api.settingEngine.remoteCandidatePriority(candidate)
+		if t.resolveMulticastDNSCandidate(candidate) {
+			return nil
+		}
+
+		if c, err = candidate.toICE(); err != nil {
 			return err
 		}
 	}
@@ -335,6 +462,10 @@ func (t *ICETransport) ensureGatherer() error {
 	return nil
 }
 
+func (t *ICETransport) getStatsID() string {
+	return iceTransportStatsID
+}
+
 func (t *ICETransport) collectStats(collector *statsReportCollector) {
 	t.lock.Lock()
 	conn := t.conn
@@ -345,7 +476,7 @@ func (t *ICETransport) collectStats(collector *statsReportCollector) {
 	stats := TransportStats{
 		Timestamp: statsTimestampFrom(time.Now()),
 		Type:      StatsTypeTransport,
-		ID:        "iceTransport",
+		ID:        t.getStatsID(),
 	}
 
 	if conn != nil {
@@ -356,6 +487,17 @@ func (t *ICETransport) collectStats(collector *statsReportCollector) {
 	collector.Collect(stats.ID, stats)
 }
 
+func (t *ICETransport) bytesTransferred() (sent, received uint64) {
+	t.lock.Lock()
+	conn := t.conn
+	t.lock.Unlock()
+
+	if conn == nil {
+		return 0, 0
+	}
+	return conn.BytesSent(), conn.BytesReceived()
+}
+
 func (t *ICETransport) haveRemoteCredentialsChange(newUfrag, newPwd string) bool {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -382,5 +524,30 @@ func (t *ICETransport) setRemoteCredentials(newUfrag, newPwd string) error {
 		return fmt.Errorf("%w: unable to SetRemoteCredentials", errICEAgentNotExist)
 	}
 
+	if t.agentPending {
+		t.agentPending = false
+		go t.connectReplacedAgent(agent, ICEParameters{UsernameFragment: newUfrag, Password: newPwd})
+		return nil
+	}
+
 	return agent.SetRemoteCredentials(newUfrag, newPwd)
 }
+
+// connectReplacedAgent connects the ICE Agent created on a restart and hands
+// its connection to the mux
+func (t *ICETransport) connectReplacedAgent(agent *ice.Agent, params ICEParameters) {
+	t.lock.RLock()
+	role := t.role
+	t.lock.RUnlock()
+
+	iceConn, err := t.connect(agent, role, params)
+	if err != nil {
+		t.log.Warnf("Failed to connect the ICE Agent after a restart: %s", err)
+		return
+	}
+
+	t.lock.Lock()
+	t.conn = iceConn
+	t.lock.Unlock()
+	t.agentConn.set(iceConn)
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// ICETrickleCapability represents whether the remote endpoint accepts
+// trickled ICE candidates.
+type ICETrickleCapability int
+
+const (
+	// ICETrickleCapabilityUnknown indicates that no remote description
+	// has been applied yet.
+	ICETrickleCapabilityUnknown ICETrickleCapability = iota
+
+	// ICETrickleCapabilitySupported indicates that the remote endpoint
+	// listed the trickle ICE option and accepts trickled candidates.
+	ICETrickleCapabilitySupported
+
+	// ICETrickleCapabilityUnsupported indicates that the remote endpoint
+	// didn't list the trickle ICE option, so all candidates should be
+	// signaled in the session description.
+	ICETrickleCapabilityUnsupported
+)
+
+// This is done this way because of a linter.
+const (
+	iceTrickleCapabilitySupportedStr   = "supported"
+	iceTrickleCapabilityUnsupportedStr = "unsupported"
+)
+
+func (t ICETrickleCapability) String() string {
+	switch t {
+	case ICETrickleCapabilitySupported:
+		return iceTrickleCapabilitySupportedStr
+	case ICETrickleCapabilityUnsupported:
+		return iceTrickleCapabilityUnsupportedStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICETrickleCapability_String(t *testing.T) {
+	testCases := []struct {
+		capability     ICETrickleCapability
+		expectedString string
+	}{
+		{ICETrickleCapabilityUnknown, ErrUnknownType.Error()},
+		{ICETrickleCapabilitySupported, "supported"},
+		{ICETrickleCapabilityUnsupported, "unsupported"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.capability.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -7,13 +7,17 @@
 package webrtc
 
 import (
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
 	"github.com/pion/interceptor/pkg/nack"
-	"github.com/pion/interceptor/pkg/report"
 	"github.com/pion/interceptor/pkg/rfc8888"
+	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/logging"
 	"github.com/pion/rtp"
 	"github.com/pion/sdp/v3"
 )
@@ -34,24 +38,114 @@ func RegisterDefaultInterceptors(mediaEngine *MediaEngine, interceptorRegistry *
 		return err
 	}
 
+	if err := ConfigureStatsInterceptor(interceptorRegistry); err != nil {
+		return err
+	}
+
 	return ConfigureTWCCSender(mediaEngine, interceptorRegistry)
 }
 
-// ConfigureRTCPReports will setup everything necessary for generating Sender and Receiver Reports
+// ConfigureRTCPReports will setup everything necessary for generating Sender and Receiver Reports.
+// The reports are sent with the intervals set with SettingEngine.SetRTCPReportIntervals.
 func ConfigureRTCPReports(interceptorRegistry *interceptor.Registry) error {
-	reciver, err := report.NewReceiverInterceptor()
+	interceptorRegistry.Add(&rtcpReportsFactory{})
+	return nil
+}
+
+// interceptorSettingEngines maps a PeerConnection's stats ID, unique within
+// the process, to its SettingEngine while its interceptors are built, for the
+// interceptors which are configured with the SettingEngine
+var interceptorSettingEngines sync.Map // nolint:gochecknoglobals
+
+// lookupInterceptorSettingEngine returns the SettingEngine of the PeerConnection
+// with the given stats ID while its interceptors are built
+func lookupInterceptorSettingEngine(id string) (*SettingEngine, bool) {
+	if value, ok := interceptorSettingEngines.Load(id); ok {
+		if settingEngine, ok := value.(*SettingEngine); ok {
+			return settingEngine, true
+		}
+	}
+	return nil, false
+}
+
+// statsGetters maps a PeerConnection's stats ID to the stats.Getter of its stats interceptor
+var statsGetters sync.Map // nolint:gochecknoglobals
+
+// ConfigureStatsInterceptor will setup everything necessary for generating RTP stream statistics.
+// The statistics are reported by PeerConnection.GetStats as inbound-rtp, outbound-rtp,
+// remote-inbound-rtp and remote-outbound-rtp entries. The remote entries are built
+// from RTCP, which is only accounted for if the application reads it.
+func ConfigureStatsInterceptor(interceptorRegistry *interceptor.Registry) error {
+	statsInterceptor, err := stats.NewInterceptor()
 	if err != nil {
 		return err
 	}
 
-	sender, err := report.NewSenderInterceptor()
+	statsInterceptor.OnNewPeerConnection(func(id string, getter stats.Getter) {
+		statsGetters.Store(id, getter)
+	})
+
+	interceptorRegistry.Add(statsInterceptor)
+	return nil
+}
+
+// lookupStats returns the stats.Getter for the PeerConnection with the given stats ID
+func lookupStats(id string) (stats.Getter, bool) {
+	if value, ok := statsGetters.Load(id); ok {
+		if getter, ok := value.(stats.Getter); ok {
+			return getter, true
+		}
+	}
+	return nil, false
+}
+
+// cleanupStats removes the stats.Getter and BandwidthEstimator for the
+// PeerConnection with the given stats ID
+func cleanupStats(id string) {
+	statsGetters.Delete(id)
+	bandwidthEstimators.Delete(id)
+}
+
+// ConfigureCongestionController will setup a congestion controller which
+// estimates the available outgoing bitrate from TWCC feedback, with the
+// BandwidthEstimators of factory, GCC if nil. The estimate is reported by
+// PeerConnection.OnConnectionQuality. onNewPeerConnection, if not nil, is
+// called with the BandwidthEstimator of every PeerConnection.
+func ConfigureCongestionController(
+	mediaEngine *MediaEngine,
+	interceptorRegistry *interceptor.Registry,
+	factory cc.BandwidthEstimatorFactory,
+	onNewPeerConnection cc.NewPeerConnectionCallback,
+) error {
+	congestionController, err := cc.NewInterceptor(factory)
 	if err != nil {
 		return err
 	}
 
-	interceptorRegistry.Add(reciver)
-	interceptorRegistry.Add(sender)
-	return nil
+	congestionController.OnNewPeerConnection(func(id string, estimator cc.BandwidthEstimator) {
+		bandwidthEstimators.Store(id, estimator)
+		if onNewPeerConnection != nil {
+			onNewPeerConnection(id, estimator)
+		}
+	})
+
+	interceptorRegistry.Add(congestionController)
+	return ConfigureTWCCHeaderExtensionSender(mediaEngine, interceptorRegistry)
+}
+
+// bandwidthEstimators maps a PeerConnection's stats ID to the BandwidthEstimator
+// of its congestion controller
+var bandwidthEstimators sync.Map // nolint:gochecknoglobals
+
+// lookupBandwidthEstimator returns the BandwidthEstimator for the
+// PeerConnection with the given stats ID
+func lookupBandwidthEstimator(id string) (cc.BandwidthEstimator, bool) {
+	if value, ok := bandwidthEstimators.Load(id); ok {
+		if estimator, ok := value.(cc.BandwidthEstimator); ok {
+			return estimator, true
+		}
+	}
+	return nil, false
 }
 
 // ConfigureNack will setup everything necessary for handling generating/responding to nack messages.
@@ -94,6 +188,10 @@ func ConfigureTWCCHeaderExtensionSender(mediaEngine *MediaEngine, interceptorReg
 }
 
 // ConfigureTWCCSender will setup everything necessary for generating TWCC reports.
+// The reports cover the packets of every SSRC received over the transport, including
+// the ones of recvonly transceivers, and are sent with the interval set with
+// SettingEngine.SetTWCCFeedbackInterval. Packets are accounted for when they
+// arrive, whether or not the application reads them from their TrackRemote.
 func ConfigureTWCCSender(mediaEngine *MediaEngine, interceptorRegistry *interceptor.Registry) error {
 	mediaEngine.RegisterFeedback(RTCPFeedback{Type: TypeRTCPFBTransportCC}, RTPCodecTypeVideo)
 	if err := mediaEngine.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: sdp.TransportCCURI}, RTPCodecTypeVideo); err != nil {
@@ -105,13 +203,46 @@ func ConfigureTWCCSender(mediaEngine *MediaEngine, interceptorRegistry *intercep
 		return err
 	}
 
-	generator, err := twcc.NewSenderInterceptor()
-	if err != nil {
-		return err
+	interceptorRegistry.Add(&twccSenderFactory{})
+	return nil
+}
+
+// twccSenderFactory builds the TWCC sender interceptor with the feedback
+// interval set with SettingEngine.SetTWCCFeedbackInterval
+type twccSenderFactory struct{}
+
+func (f *twccSenderFactory) NewInterceptor(id string) (interceptor.Interceptor, error) {
+	var interval time.Duration
+	loggerFactory := logging.LoggerFactory(logging.NewDefaultLoggerFactory())
+	if settingEngine, ok := lookupInterceptorSettingEngine(id); ok {
+		interval = settingEngine.twccFeedbackInterval
+		if settingEngine.LoggerFactory != nil {
+			loggerFactory = settingEngine.LoggerFactory
+		}
 	}
 
-	interceptorRegistry.Add(generator)
-	return nil
+	i := newTWCCSenderInterceptor(interval, loggerFactory.NewLogger("twcc_sender_interceptor"))
+	twccSenders.Store(id, i)
+
+	return i, nil
+}
+
+// twccSenders maps a PeerConnection's stats ID to its TWCC sender interceptor
+// while its interceptors are built, so its DTLSTransport can account for the
+// packets arriving on the SRTP connection
+var twccSenders sync.Map // nolint:gochecknoglobals
+
+// takeTWCCSender returns the TWCC sender interceptor built for the
+// PeerConnection with the given stats ID and forgets it
+func takeTWCCSender(id string) *twccSenderInterceptor {
+	value, ok := twccSenders.Load(id)
+	if !ok {
+		return nil
+	}
+	twccSenders.Delete(id)
+
+	i, _ := value.(*twccSenderInterceptor)
+	return i
 }
 
 // ConfigureCongestionControlFeedback registers congestion control feedback as
@@ -140,11 +271,36 @@ func ConfigureSimulcastExtensionHeaders(mediaEngine *MediaEngine) error {
 	return mediaEngine.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: sdesRepairRTPStreamIDURI}, RTPCodecTypeVideo)
 }
 
-type interceptorToTrackLocalWriter struct{ interceptor atomic.Value } // interceptor.RTPWriter }
+// ConfigureVideoLayersAllocation registers the video layers allocation header
+// extension, which RTPSender.SetVideoLayersAllocation sends and
+// TrackRemote.VideoLayersAllocation parses
+func ConfigureVideoLayersAllocation(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: VideoLayersAllocationURI}, RTPCodecTypeVideo)
+}
+
+// ConfigureDependencyDescriptor registers the dependency descriptor header
+// extension, which TrackLocalStaticSample sends with AV1 and
+// TrackRemote.DependencyDescriptor parses, so SFUs can select the layers of
+// AV1 streams
+func ConfigureDependencyDescriptor(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: DependencyDescriptorURI}, RTPCodecTypeVideo)
+}
+
+type interceptorToTrackLocalWriter struct {
+	interceptor atomic.Value // interceptor.RTPWriter
+
+	// reportWrites returns true if the time of every write has to be recorded
+	reportWrites func() bool
+}
 
 func (i *interceptorToTrackLocalWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
 	if writer, ok := i.interceptor.Load().(interceptor.RTPWriter); ok && writer != nil {
-		return writer.Write(header, payload, interceptor.Attributes{})
+		attributes := interceptor.Attributes{}
+		if i.reportWrites != nil && i.reportWrites() {
+			attributes.Set(writeStartAttribute, time.Now())
+		}
+
+		return writer.Write(header, payload, attributes)
 	}
 
 	return 0, nil
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
 	mock_interceptor "github.com/pion/interceptor/pkg/mock"
 	"github.com/pion/rtp"
 	"github.com/pion/transport/v3/test"
@@ -240,3 +241,26 @@ func Test_InterceptorRegistry_Build(t *testing.T) {
 	assert.Equal(t, 2, registryBuildCount)
 	closePairNow(t, peerConnectionA, peerConnectionB)
 }
+
+func TestConfigureCongestionController(t *testing.T) {
+	m := &MediaEngine{}
+	assert.NoError(t, m.RegisterDefaultCodecs())
+
+	var newEstimator cc.BandwidthEstimator
+	ir := &interceptor.Registry{}
+	assert.NoError(t, ConfigureCongestionController(m, ir, nil, func(_ string, estimator cc.BandwidthEstimator) {
+		newEstimator = estimator
+	}))
+
+	pc, err := NewAPI(WithMediaEngine(m), WithInterceptorRegistry(ir)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	estimator, ok := lookupBandwidthEstimator(pc.statsID)
+	assert.True(t, ok)
+	assert.Equal(t, newEstimator, estimator)
+	assert.NotZero(t, estimator.GetTargetBitrate())
+
+	assert.NoError(t, pc.Close())
+	_, ok = lookupBandwidthEstimator(pc.statsID)
+	assert.False(t, ok)
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+)
+
+// defaultInterfaceCacheTTL is how long the interfaces of the host are cached
+// by the InterfaceProvider shared by PeerConnections without one configured
+const defaultInterfaceCacheTTL = 10 * time.Second
+
+// InterfaceProvider lists the network interfaces host candidates are gathered
+// from. See SettingEngine.SetInterfaceProvider.
+type InterfaceProvider interface {
+	Interfaces() ([]*transport.Interface, error)
+}
+
+var defaultInterfaceProvider = NewCachedInterfaceProvider(defaultInterfaceCacheTTL) //nolint:gochecknoglobals
+
+type cachedInterfaceProvider struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	interfaces []*transport.Interface
+	updated    time.Time
+}
+
+// NewCachedInterfaceProvider returns an InterfaceProvider which enumerates the
+// interfaces of the host at most once per ttl, however many PeerConnections
+// gather candidates in the meantime.
+func NewCachedInterfaceProvider(ttl time.Duration) InterfaceProvider {
+	return &cachedInterfaceProvider{ttl: ttl}
+}
+
+func (p *cachedInterfaceProvider) Interfaces() ([]*transport.Interface, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.interfaces != nil && time.Since(p.updated) < p.ttl {
+		return p.interfaces, nil
+	}
+
+	interfaces, err := enumerateInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	p.interfaces, p.updated = interfaces, time.Now()
+
+	return interfaces, nil
+}
+
+// enumerateInterfaces lists the interfaces of the host with their addresses
+func enumerateInterfaces() ([]*transport.Interface, error) {
+	netInterfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := make([]*transport.Interface, 0, len(netInterfaces))
+	for _, netInterface := range netInterfaces {
+		addrs, err := netInterface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+
+		iface := transport.NewInterface(netInterface)
+		for _, addr := range addrs {
+			iface.AddAddress(addr)
+		}
+		interfaces = append(interfaces, iface)
+	}
+
+	return interfaces, nil
+}
+
+type staticInterfaceProvider []*transport.Interface
+
+// NewStaticInterfaceProvider returns an InterfaceProvider which always returns
+// interfaces, so the host is never scanned.
+func NewStaticInterfaceProvider(interfaces []*transport.Interface) InterfaceProvider {
+	return staticInterfaceProvider(append([]*transport.Interface{}, interfaces...))
+}
+
+func (p staticInterfaceProvider) Interfaces() ([]*transport.Interface, error) {
+	return p, nil
+}
+
+// interfaceProviderNet is a transport.Net which takes its interfaces from an
+// InterfaceProvider
+type interfaceProviderNet struct {
+	transport.Net
+	provider InterfaceProvider
+}
+
+// newInterfaceProviderNet returns the Net pion/ice gathers candidates with.
+// Without a Net configured, the standard network stack is used and the host is
+// scanned through the shared cached provider instead of once per PeerConnection.
+func newInterfaceProviderNet(base transport.Net, provider InterfaceProvider) transport.Net {
+	switch {
+	case provider == nil && base != nil:
+		return base
+	case provider == nil:
+		provider = defaultInterfaceProvider
+	}
+
+	if base == nil {
+		// The zero value doesn't enumerate interfaces, unlike stdnet.NewNet
+		base = &stdnet.Net{}
+	}
+
+	return &interfaceProviderNet{Net: base, provider: provider}
+}
+
+func (n *interfaceProviderNet) Interfaces() ([]*transport.Interface, error) {
+	return n.provider.Interfaces()
+}
+
+func (n *interfaceProviderNet) InterfaceByIndex(index int) (*transport.Interface, error) {
+	interfaces, err := n.provider.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		if iface.Index == index {
+			return iface, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: index=%d", transport.ErrInterfaceNotFound, index)
+}
+
+func (n *interfaceProviderNet) InterfaceByName(name string) (*transport.Interface, error) {
+	interfaces, err := n.provider.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		if iface.Name == name {
+			return iface, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", transport.ErrInterfaceNotFound, name)
+}
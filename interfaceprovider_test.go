@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticInterfaceProvider(t *testing.T) {
+	iface := transport.NewInterface(net.Interface{Index: 7, Name: "static0", Flags: net.FlagUp})
+	iface.AddAddress(&net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(8, 32)})
+
+	n := newInterfaceProviderNet(nil, NewStaticInterfaceProvider([]*transport.Interface{iface}))
+
+	interfaces, err := n.Interfaces()
+	assert.NoError(t, err)
+	assert.Equal(t, []*transport.Interface{iface}, interfaces)
+
+	byIndex, err := n.InterfaceByIndex(7)
+	assert.NoError(t, err)
+	assert.Equal(t, iface, byIndex)
+
+	byName, err := n.InterfaceByName("static0")
+	assert.NoError(t, err)
+	assert.Equal(t, iface, byName)
+
+	_, err = n.InterfaceByName("eth0")
+	assert.ErrorIs(t, err, transport.ErrInterfaceNotFound)
+}
+
+func TestCachedInterfaceProvider(t *testing.T) {
+	provider := NewCachedInterfaceProvider(time.Hour)
+
+	first, err := provider.Interfaces()
+	assert.NoError(t, err)
+
+	second, err := provider.Interfaces()
+	assert.NoError(t, err)
+
+	// The second call is served from the cache
+	assert.Equal(t, len(first), len(second))
+	if len(first) > 0 {
+		assert.Same(t, first[0], second[0])
+	}
+}
+
+func TestNewInterfaceProviderNet(t *testing.T) {
+	vnetNet, err := vnet.NewNet(&vnet.NetConfig{})
+	assert.NoError(t, err)
+
+	// A configured Net is used as is unless a provider is set
+	assert.Equal(t, vnetNet, newInterfaceProviderNet(vnetNet, nil))
+
+	n, ok := newInterfaceProviderNet(nil, nil).(*interfaceProviderNet)
+	assert.True(t, ok)
+	assert.Equal(t, defaultInterfaceProvider, n.provider)
+
+	n, ok = newInterfaceProviderNet(vnetNet, NewStaticInterfaceProvider(nil)).(*interfaceProviderNet)
+	assert.True(t, ok)
+	assert.Equal(t, vnetNet, n.Net)
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+type av1FMTP struct {
+	parameters map[string]string
+}
+
+func (a *av1FMTP) MimeType() string {
+	return "video/av1"
+}
+
+// profile returns the AV1 profile, which is 0 when absent
+func (a *av1FMTP) profile() string {
+	if profile, ok := a.parameters["profile"]; ok && profile != "" {
+		return profile
+	}
+
+	return "0"
+}
+
+// Match returns true if a and b are compatible fmtp descriptions
+// Based on the RTP Payload Format For AV1 Section 7.2.2, the profile must be
+// used symmetrically and defaults to 0, while level-idx and tier describe the
+// capabilities of the receiver and may differ in each direction.
+func (a *av1FMTP) Match(b FMTP) bool {
+	c, ok := b.(*av1FMTP)
+	if !ok {
+		return false
+	}
+
+	return a.profile() == c.profile()
+}
+
+func (a *av1FMTP) Parameter(key string) (string, bool) {
+	v, ok := a.parameters[key]
+	return v, ok
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+import (
+	"testing"
+)
+
+func TestAV1FMTPCompare(t *testing.T) {
+	testCases := map[string]struct {
+		a, b    string
+		consist bool
+	}{
+		"Equal": {
+			a:       "profile=1;level-idx=5;tier=0",
+			b:       "profile=1;level-idx=5;tier=0",
+			consist: true,
+		},
+		"DifferentLevel": {
+			a:       "profile=0;level-idx=5",
+			b:       "profile=0;level-idx=8;tier=1",
+			consist: true,
+		},
+		"DefaultProfile": {
+			a:       "profile=0",
+			b:       "",
+			consist: true,
+		},
+		"DifferentProfile": {
+			a:       "profile=1",
+			b:       "level-idx=5",
+			consist: false,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			aa := Parse("video/av1", testCase.a)
+			bb := Parse("video/AV1", testCase.b)
+			if c := aa.Match(bb); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to match: %v, got: %v", testCase.a, testCase.b, testCase.consist, c)
+			}
+			if c := bb.Match(aa); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to match: %v, got: %v", testCase.b, testCase.a, testCase.consist, c)
+			}
+		})
+	}
+
+	if Parse("video/av1", "").Match(Parse("video/vp9", "")) {
+		t.Error("AV1 is expected to only match AV1")
+	}
+}
@@ -22,6 +22,25 @@ type FMTP interface {
 	Parameter(key string) (string, bool)
 }
 
+// Parser parses the fmtp line of a codec
+type Parser func(line string) FMTP
+
+// Parsers are the parsers of the fmtp lines of codecs by lowercase
+// mimetype, they take precedence over the builtin parsing
+type Parsers map[string]Parser
+
+// Parse parses an fmtp string with the parser of mimetype, or the builtin
+// parsing if there is none or it returns nil
+func (p Parsers) Parse(mimetype, line string) FMTP {
+	if parser, ok := p[strings.ToLower(mimetype)]; ok {
+		if f := parser(line); f != nil {
+			return f
+		}
+	}
+
+	return Parse(mimetype, line)
+}
+
 // Parse parses an fmtp string based on the MimeType
 func Parse(mimetype, line string) FMTP {
 	var f FMTP
@@ -43,6 +62,18 @@ func Parse(mimetype, line string) FMTP {
 		f = &h264FMTP{
 			parameters: parameters,
 		}
+	case strings.EqualFold(mimetype, "audio/opus"):
+		f = &opusFMTP{
+			parameters: parameters,
+		}
+	case strings.EqualFold(mimetype, "audio/multiopus"):
+		f = &multiopusFMTP{
+			parameters: parameters,
+		}
+	case strings.EqualFold(mimetype, "video/av1"):
+		f = &av1FMTP{
+			parameters: parameters,
+		}
 	default:
 		f = &genericFMTP{
 			mimeType:   mimetype,
@@ -132,3 +132,42 @@ func TestGenericFmtpCompare(t *testing.T) {
 		})
 	}
 }
+
+type customFMTP struct {
+	line string
+}
+
+func (c *customFMTP) MimeType() string {
+	return "video/custom"
+}
+
+func (c *customFMTP) Match(b FMTP) bool {
+	d, ok := b.(*customFMTP)
+	return ok && len(c.line) == len(d.line)
+}
+
+func (c *customFMTP) Parameter(string) (string, bool) {
+	return "", false
+}
+
+func TestParsers(t *testing.T) {
+	parsers := Parsers{"video/custom": func(line string) FMTP {
+		return &customFMTP{line: line}
+	}}
+
+	if !parsers.Parse("video/custom", "a=1").Match(parsers.Parse("video/Custom", "b=2")) {
+		t.Error("Expected the parser to be used")
+	}
+
+	if parsers.Parse("video/custom", "a=1").Match(parsers.Parse("video/custom", "a=10")) {
+		t.Error("Expected the parser to be used")
+	}
+
+	if _, ok := Parse("video/custom", "a=1").(*genericFMTP); !ok {
+		t.Error("Expected the builtin parsing without the parser")
+	}
+
+	if _, ok := Parsers(nil).Parse("video/custom", "a=1").(*genericFMTP); !ok {
+		t.Error("Expected the builtin parsing without parsers")
+	}
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+import (
+	"strings"
+)
+
+// multiopusFMTP is the fmtp of the non-standard multiopus codec of Chromium,
+// which carries surround audio as multiple Opus streams in each packet
+type multiopusFMTP struct {
+	parameters map[string]string
+}
+
+func (m *multiopusFMTP) MimeType() string {
+	return "audio/multiopus"
+}
+
+// Match returns true if m and b are compatible fmtp descriptions
+// num_streams, coupled_streams and channel_mapping describe how the channels
+// are packed in the streams of a packet, so both directions must use the same.
+// The other parameters are the ones of Opus and only describe the
+// preferences of the receiver.
+func (m *multiopusFMTP) Match(b FMTP) bool {
+	c, ok := b.(*multiopusFMTP)
+	if !ok {
+		return false
+	}
+
+	for _, key := range []string{"num_streams", "coupled_streams", "channel_mapping"} {
+		if strings.TrimSpace(m.parameters[key]) != strings.TrimSpace(c.parameters[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *multiopusFMTP) Parameter(key string) (string, bool) {
+	v, ok := m.parameters[key]
+	return v, ok
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+import (
+	"testing"
+)
+
+func TestMultiOpusFMTPCompare(t *testing.T) {
+	const surround51 = "channel_mapping=0,4,1,2,3,5;coupled_streams=2;minptime=10;num_streams=4;useinbandfec=1"
+	const surround71 = "channel_mapping=0,6,1,2,3,4,5,7;coupled_streams=3;minptime=10;num_streams=5;useinbandfec=1"
+
+	testCases := map[string]struct {
+		a, b    string
+		consist bool
+	}{
+		"Equal": {
+			a:       surround51,
+			b:       surround51,
+			consist: true,
+		},
+		"DifferentOpusParameters": {
+			a:       surround51,
+			b:       "num_streams=4;coupled_streams=2;channel_mapping=0,4,1,2,3,5;useinbandfec=0",
+			consist: true,
+		},
+		"DifferentLayout": {
+			a:       surround51,
+			b:       surround71,
+			consist: false,
+		},
+		"DifferentChannelMapping": {
+			a:       surround51,
+			b:       "channel_mapping=0,1,2,3,4,5;coupled_streams=2;num_streams=4",
+			consist: false,
+		},
+		"MissingLayout": {
+			a:       surround51,
+			b:       "minptime=10;useinbandfec=1",
+			consist: false,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			aa := Parse("audio/multiopus", testCase.a)
+			bb := Parse("audio/MULTIOPUS", testCase.b)
+			if c := aa.Match(bb); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to match: %v, got: %v", testCase.a, testCase.b, testCase.consist, c)
+			}
+			if c := bb.Match(aa); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to match: %v, got: %v", testCase.b, testCase.a, testCase.consist, c)
+			}
+		})
+	}
+
+	if Parse("audio/multiopus", surround51).Match(Parse("audio/opus", surround51)) {
+		t.Error("multiopus is expected to only match multiopus")
+	}
+
+	if v, ok := Parse("audio/multiopus", surround51).Parameter("num_streams"); !ok || v != "4" {
+		t.Errorf("Expected num_streams=4, got: %s %v", v, ok)
+	}
+}
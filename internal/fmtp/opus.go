@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+import (
+	"strings"
+)
+
+type opusFMTP struct {
+	parameters map[string]string
+}
+
+func (o *opusFMTP) MimeType() string {
+	return "audio/opus"
+}
+
+// opusReceiverParameters are the Opus parameters which RFC7587 Section 7
+// defines as the preferences of the receiver or properties of the sender,
+// they may differ in each direction.
+var opusReceiverParameters = map[string]bool{
+	"maxplaybackrate":      true,
+	"sprop-maxcapturerate": true,
+	"maxptime":             true,
+	"ptime":                true,
+	"minptime":             true,
+	"maxaveragebitrate":    true,
+	"stereo":               true,
+	"sprop-stereo":         true,
+	"cbr":                  true,
+	"useinbandfec":         true,
+	"usedtx":               true,
+}
+
+// Match returns true if o and b are compatible fmtp descriptions
+// Based on RFC7587 Section 7 the Opus parameters, such as useinbandfec,
+// stereo or maxplaybackrate, only describe the preferences of the receiver
+// and may differ in each direction, so they are ignored. Other parameters
+// present in both descriptions must be equal.
+func (o *opusFMTP) Match(b FMTP) bool {
+	c, ok := b.(*opusFMTP)
+	if !ok {
+		return false
+	}
+
+	for k, v := range o.parameters {
+		if opusReceiverParameters[k] {
+			continue
+		}
+		if vb, ok := c.parameters[k]; ok && !strings.EqualFold(vb, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o *opusFMTP) Parameter(key string) (string, bool) {
+	v, ok := o.parameters[key]
+	return v, ok
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+import (
+	"testing"
+)
+
+func TestOpusFMTPCompare(t *testing.T) {
+	testCases := map[string]struct {
+		a, b    string
+		consist bool
+	}{
+		"Equal": {
+			a:       "minptime=10;useinbandfec=1",
+			b:       "minptime=10;useinbandfec=1",
+			consist: true,
+		},
+		"DifferentInBandFEC": {
+			a:       "minptime=10;useinbandfec=1",
+			b:       "useinbandfec=0;stereo=1",
+			consist: true,
+		},
+		"DifferentReceiverParameters": {
+			a:       "maxplaybackrate=48000;stereo=1;usedtx=1",
+			b:       "maxplaybackrate=16000;stereo=0;cbr=1",
+			consist: true,
+		},
+		"DifferentOtherParameter": {
+			a:       "useinbandfec=1;x-google-param=1",
+			b:       "useinbandfec=1;x-google-param=2",
+			consist: false,
+		},
+		"Empty": {
+			a:       "minptime=10;useinbandfec=1",
+			b:       "",
+			consist: true,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			aa := Parse("audio/opus", testCase.a)
+			bb := Parse("audio/OPUS", testCase.b)
+			if c := aa.Match(bb); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to match: %v, got: %v", testCase.a, testCase.b, testCase.consist, c)
+			}
+			if c := bb.Match(aa); c != testCase.consist {
+				t.Errorf("'%s' and '%s' are expected to match: %v, got: %v", testCase.b, testCase.a, testCase.consist, c)
+			}
+		})
+	}
+
+	if Parse("audio/opus", "").Match(Parse("audio/pcmu", "")) {
+		t.Error("Opus is expected to only match Opus")
+	}
+
+	if v, ok := Parse("audio/opus", "useinbandfec=1").Parameter("useinbandfec"); !ok || v != "1" {
+		t.Errorf("Expected useinbandfec=1, got: %s %v", v, ok)
+	}
+}
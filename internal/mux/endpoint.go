@@ -71,9 +71,10 @@ func (e *Endpoint) SetDeadline(time.Time) error {
 	return nil
 }
 
-// SetReadDeadline is a stub
-func (e *Endpoint) SetReadDeadline(time.Time) error {
-	return nil
+// SetReadDeadline sets the deadline of pending and future reads, it allows
+// pion/dtls to stop reading once a handshake failed
+func (e *Endpoint) SetReadDeadline(t time.Time) error {
+	return e.buffer.SetReadDeadline(t)
 }
 
 // SetWriteDeadline is a stub
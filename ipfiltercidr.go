@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ipFilterCIDRs are the networks set with SettingEngine.SetIPFilterCIDRs
+type ipFilterCIDRs struct {
+	allow, deny []*net.IPNet
+}
+
+// SetIPFilterCIDRs sets the networks local IPs are gathered as host candidates
+// from. Entries are CIDRs like 10.0.0.0/8 or single IPs. An IP in a network of
+// deny is never gathered, like 172.17.0.0/16 for the Docker bridge or
+// fe80::/10 for IPv6 link-local addresses. If allow isn't empty, only the IPs
+// in one of its networks are gathered. The lists are applied in addition to
+// the filter set with SetIPFilter. Pass two empty lists to remove them.
+func (e *SettingEngine) SetIPFilterCIDRs(allow, deny []string) error {
+	allowNets, err := parseIPFilterCIDRs(allow)
+	if err != nil {
+		return err
+	}
+
+	denyNets, err := parseIPFilterCIDRs(deny)
+	if err != nil {
+		return err
+	}
+
+	e.ipFilterCIDRs = ipFilterCIDRs{allow: allowNets, deny: denyNets}
+
+	return nil
+}
+
+func parseIPFilterCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: %q", errIPFilterCIDRInvalid, cidr)
+			}
+
+			bits := net.IPv6len * 8
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, net.IPv4len*8
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errIPFilterCIDRInvalid, cidr)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// allowed returns true if ip isn't denied, and is allowed if there is an
+// allow list
+func (f ipFilterCIDRs) allowed(ip net.IP) bool {
+	for _, ipNet := range f.deny {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	if len(f.allow) == 0 {
+		return true
+	}
+
+	for _, ipNet := range f.allow {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getIPFilter returns the filter of the local IPs gathered as host
+// candidates, combining the filter set with SetIPFilter and the networks set
+// with SetIPFilterCIDRs
+func (e *SettingEngine) getIPFilter() func(net.IP) bool {
+	ipFilter := e.candidates.IPFilter
+	cidrs := e.ipFilterCIDRs
+	if len(cidrs.allow) == 0 && len(cidrs.deny) == 0 {
+		return ipFilter
+	}
+
+	return func(ip net.IP) bool {
+		if ipFilter != nil && !ipFilter(ip) {
+			return false
+		}
+
+		return cidrs.allowed(ip)
+	}
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingEngine_SetIPFilterCIDRs(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.getIPFilter())
+
+	assert.ErrorIs(t, s.SetIPFilterCIDRs([]string{"10.0.0.0/33"}, nil), errIPFilterCIDRInvalid)
+	assert.ErrorIs(t, s.SetIPFilterCIDRs(nil, []string{"docker0"}), errIPFilterCIDRInvalid)
+
+	assert.NoError(t, s.SetIPFilterCIDRs([]string{"10.0.0.0/8", "fe80::/10"}, []string{"10.1.0.0/16", "fe80::1"}))
+	s.SetIPFilter(func(ip net.IP) bool {
+		return !ip.Equal(net.ParseIP("10.0.0.2"))
+	})
+
+	filter := s.getIPFilter()
+	for i, testCase := range []struct {
+		ip      string
+		allowed bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.2", false},
+		{"10.1.0.1", false},
+		{"192.168.1.1", false},
+		{"fe80::2", true},
+		{"fe80::1", false},
+		{"2001:db8::1", false},
+	} {
+		assert.Equal(t, testCase.allowed, filter(net.ParseIP(testCase.ip)), "testCase: %d %v", i, testCase)
+	}
+
+	assert.NoError(t, s.SetIPFilterCIDRs(nil, nil))
+	assert.True(t, s.getIPFilter()(net.ParseIP("192.168.1.1")))
+}
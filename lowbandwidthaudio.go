@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+const (
+	sdpAttributePacketTime    = "ptime"
+	sdpAttributeMaxPacketTime = "maxptime"
+
+	defaultLowBandwidthAudioPacketTime        = 60 * time.Millisecond
+	defaultLowBandwidthAudioMaxPacketTime     = 120 * time.Millisecond
+	defaultLowBandwidthAudioMaxAverageBitrate = 16000
+
+	// opusMaxPacketDuration is the largest duration of audio in an Opus
+	// packet, RFC 6716 Section 3.2.5
+	opusMaxPacketDuration = 120 * time.Millisecond
+	opusMaxFrameSize      = 1275
+)
+
+// LowBandwidthAudioOptions configures MediaEngine.RegisterLowBandwidthAudio
+type LowBandwidthAudioOptions struct {
+	// PacketTime is the duration of audio in a packet, advertised with
+	// a=ptime. The default is 60ms.
+	PacketTime time.Duration
+
+	// MaxPacketTime is the largest duration of audio in a packet, advertised
+	// with a=maxptime. The default is 120ms.
+	MaxPacketTime time.Duration
+
+	// MaxAverageBitrate is the maxaveragebitrate of Opus in bits per second.
+	// The default is 16000.
+	MaxAverageBitrate uint32
+}
+
+// RegisterLowBandwidthAudio configures the MediaEngine for audio-only devices
+// on constrained links, like IoT and voice devices, in one switch:
+//   - Opus is registered with CBR and DTX hints and a max average bitrate,
+//     replacing the fmtp line of Opus if it was registered already
+//   - the audio media sections negotiate a=ptime and a=maxptime, so fewer
+//     and larger packets are sent
+//   - the RTCP feedback of video codecs is removed, including feedback
+//     registered afterwards by interceptors
+//
+// TrackLocalStaticSample honors the a=ptime of the remote in any mode, by
+// merging the samples of Opus, PCMU, PCMA and G722 into packets of that
+// duration.
+func (m *MediaEngine) RegisterLowBandwidthAudio(options *LowBandwidthAudioOptions) error {
+	resolved := LowBandwidthAudioOptions{}
+	if options != nil {
+		resolved = *options
+	}
+	if resolved.PacketTime <= 0 {
+		resolved.PacketTime = defaultLowBandwidthAudioPacketTime
+	}
+	if resolved.MaxPacketTime <= 0 {
+		resolved.MaxPacketTime = defaultLowBandwidthAudioMaxPacketTime
+	}
+	if resolved.MaxPacketTime < resolved.PacketTime {
+		resolved.MaxPacketTime = resolved.PacketTime
+	}
+	if resolved.MaxAverageBitrate == 0 {
+		resolved.MaxAverageBitrate = defaultLowBandwidthAudioMaxAverageBitrate
+	}
+
+	fmtpLine := fmt.Sprintf("minptime=10;useinbandfec=0;usedtx=1;cbr=1;maxaveragebitrate=%d", resolved.MaxAverageBitrate)
+
+	m.mu.Lock()
+	hasOpus := false
+	for i := range m.audioCodecs {
+		if strings.EqualFold(m.audioCodecs[i].MimeType, MimeTypeOpus) {
+			m.audioCodecs[i].SDPFmtpLine = fmtpLine
+			hasOpus = true
+		}
+	}
+	m.lowBandwidthAudio = &resolved
+	for i := range m.videoCodecs {
+		m.videoCodecs[i].RTCPFeedback = nil
+	}
+	m.mu.Unlock()
+
+	if hasOpus {
+		return nil
+	}
+
+	return m.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeOpus, 48000, 2, fmtpLine, nil},
+		PayloadType:        111,
+	}, RTPCodecTypeAudio)
+}
+
+// getLowBandwidthAudio returns the options set with RegisterLowBandwidthAudio,
+// or nil if it isn't in use
+func (m *MediaEngine) getLowBandwidthAudio() *LowBandwidthAudioOptions {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.lowBandwidthAudio
+}
+
+// addPacketTimeAttributes adds a=ptime and a=maxptime to an audio media
+// section if RegisterLowBandwidthAudio is in use
+func addPacketTimeAttributes(section *sdp.MediaDescription, mediaEngine *MediaEngine) {
+	options := mediaEngine.getLowBandwidthAudio()
+	if options == nil {
+		return
+	}
+
+	section.WithValueAttribute(sdpAttributePacketTime, strconv.FormatInt(options.PacketTime.Milliseconds(), 10))
+	section.WithValueAttribute(sdpAttributeMaxPacketTime, strconv.FormatInt(options.MaxPacketTime.Milliseconds(), 10))
+}
+
+// remotePacketTime returns the a=ptime of a remote media section, or 0 if it
+// has none. It is bounded by a=maxptime.
+func remotePacketTime(section *sdp.MediaDescription) time.Duration {
+	if section == nil {
+		return 0
+	}
+
+	parse := func(key string) time.Duration {
+		value, ok := section.Attribute(key)
+		if !ok {
+			return 0
+		}
+
+		milliseconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || milliseconds <= 0 {
+			return 0
+		}
+
+		return time.Duration(milliseconds * float64(time.Millisecond))
+	}
+
+	packetTime := parse(sdpAttributePacketTime)
+	if maxPacketTime := parse(sdpAttributeMaxPacketTime); maxPacketTime > 0 && packetTime > maxPacketTime {
+		packetTime = maxPacketTime
+	}
+
+	return packetTime
+}
+
+// sampleRepacketizer merges the audio samples written to a
+// TrackLocalStaticSample into samples of the packet time of the remote
+type sampleRepacketizer struct {
+	mu       sync.Mutex
+	mimeType string
+	pending  []media.Sample
+	duration time.Duration
+}
+
+// push adds sample, and returns the samples to packetize now. Samples which
+// can't be merged flush the pending samples and are returned as is.
+func (r *sampleRepacketizer) push(sample media.Sample, packetTime time.Duration) []media.Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if strings.EqualFold(r.mimeType, MimeTypeOpus) && packetTime > opusMaxPacketDuration {
+		packetTime = opusMaxPacketDuration
+	}
+
+	mergeable := sample.PrevDroppedPackets == 0 && sample.Duration > 0 && sample.Duration < packetTime && r.canMerge(sample)
+	if !mergeable {
+		return append(r.flush(), sample)
+	}
+
+	ready := []media.Sample{}
+	if len(r.pending) != 0 && (!r.compatible(r.pending[0], sample) || r.duration+sample.Duration > packetTime) {
+		ready = r.flush()
+	}
+
+	r.pending = append(r.pending, sample)
+	r.duration += sample.Duration
+	if r.duration >= packetTime {
+		ready = append(ready, r.flush()...)
+	}
+
+	return ready
+}
+
+// flush returns the pending samples merged into one. r.mu must be held.
+func (r *sampleRepacketizer) flush() []media.Sample {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	merged := media.Sample{
+		Timestamp: r.pending[0].Timestamp,
+		Duration:  r.duration,
+	}
+	if len(r.pending) == 1 {
+		merged.Data = r.pending[0].Data
+	} else if strings.EqualFold(r.mimeType, MimeTypeOpus) {
+		merged.Data = mergeOpusFrames(r.pending)
+	} else {
+		for _, sample := range r.pending {
+			merged.Data = append(merged.Data, sample.Data...)
+		}
+	}
+
+	r.pending, r.duration = nil, 0
+
+	return []media.Sample{merged}
+}
+
+// canMerge returns true if sample can be merged with others
+func (r *sampleRepacketizer) canMerge(sample media.Sample) bool {
+	switch strings.ToLower(r.mimeType) {
+	case strings.ToLower(MimeTypePCMU), strings.ToLower(MimeTypePCMA), strings.ToLower(MimeTypeG722):
+		return true
+	case strings.ToLower(MimeTypeOpus):
+		// Only packets with a single frame, code 0 of RFC 6716 Section 3.2.2
+		return len(sample.Data) >= 1 && len(sample.Data)-1 <= opusMaxFrameSize && sample.Data[0]&0x03 == 0
+	default:
+		return false
+	}
+}
+
+// compatible returns true if two mergeable samples can be in the same packet
+func (r *sampleRepacketizer) compatible(a, b media.Sample) bool {
+	if !strings.EqualFold(r.mimeType, MimeTypeOpus) {
+		return true
+	}
+
+	// Same configuration and stereo flag
+	return a.Data[0]&^0x03 == b.Data[0]&^0x03
+}
+
+// mergeOpusFrames merges Opus packets of one frame sharing their TOC byte
+// into a code 3 packet, RFC 6716 Section 3.2.5
+func mergeOpusFrames(samples []media.Sample) []byte {
+	cbr := true
+	for _, sample := range samples[1:] {
+		cbr = cbr && len(sample.Data) == len(samples[0].Data)
+	}
+
+	frameCount := byte(len(samples))
+	if !cbr {
+		frameCount |= 0x80
+	}
+	packet := []byte{samples[0].Data[0] | 0x03, frameCount}
+
+	if !cbr {
+		for _, sample := range samples[:len(samples)-1] {
+			size := len(sample.Data) - 1
+			if size < 252 {
+				packet = append(packet, byte(size))
+				continue
+			}
+
+			first := 252 + size&0x03
+			packet = append(packet, byte(first), byte((size-first)>>2))
+		}
+	}
+
+	for _, sample := range samples {
+		packet = append(packet, sample.Data[1:]...)
+	}
+
+	return packet
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaEngine_RegisterLowBandwidthAudio(t *testing.T) {
+	m := &MediaEngine{}
+	assert.NoError(t, m.RegisterDefaultCodecs())
+	assert.NoError(t, m.RegisterLowBandwidthAudio(nil))
+	m.RegisterFeedback(RTCPFeedback{Type: TypeRTCPFBNACK}, RTPCodecTypeVideo)
+
+	for _, codec := range m.videoCodecs {
+		assert.Empty(t, codec.RTCPFeedback, codec.MimeType)
+	}
+
+	pc, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=fmtp:111 minptime=10;useinbandfec=0;usedtx=1;cbr=1;maxaveragebitrate=16000")
+	assert.Equal(t, 1, strings.Count(offer.SDP, "a=ptime:60"))
+	assert.Equal(t, 1, strings.Count(offer.SDP, "a=maxptime:120"))
+
+	// The default interceptors only keep their feedback on audio
+	parsed, err := offer.Unmarshal()
+	assert.NoError(t, err)
+	for _, media := range parsed.MediaDescriptions {
+		if media.MediaName.Media != RTPCodecTypeVideo.String() {
+			continue
+		}
+		_, hasFeedback := media.Attribute("rtcp-fb")
+		assert.False(t, hasFeedback)
+	}
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestRemotePacketTime(t *testing.T) {
+	for i, testCase := range []struct {
+		attributes []sdp.Attribute
+		packetTime time.Duration
+	}{
+		{nil, 0},
+		{[]sdp.Attribute{{Key: "ptime", Value: "40"}}, 40 * time.Millisecond},
+		{[]sdp.Attribute{{Key: "ptime", Value: "2.5"}}, 2500 * time.Microsecond},
+		{[]sdp.Attribute{{Key: "ptime", Value: "60"}, {Key: "maxptime", Value: "40"}}, 40 * time.Millisecond},
+		{[]sdp.Attribute{{Key: "maxptime", Value: "40"}}, 0},
+		{[]sdp.Attribute{{Key: "ptime", Value: "-20"}}, 0},
+	} {
+		assert.Equal(t, testCase.packetTime, remotePacketTime(&sdp.MediaDescription{Attributes: testCase.attributes}), "testCase: %d %v", i, testCase)
+	}
+
+	assert.Equal(t, time.Duration(0), remotePacketTime(nil))
+}
+
+func TestSampleRepacketizer(t *testing.T) {
+	const packetTime = 60 * time.Millisecond
+	sample := func(data ...byte) media.Sample {
+		return media.Sample{Data: data, Duration: 20 * time.Millisecond}
+	}
+
+	t.Run("PCMU", func(t *testing.T) {
+		r := &sampleRepacketizer{mimeType: MimeTypePCMU}
+		assert.Empty(t, r.push(sample(1), packetTime))
+		assert.Empty(t, r.push(sample(2), packetTime))
+		assert.Equal(t, []media.Sample{{Data: []byte{1, 2, 3}, Duration: packetTime}}, r.push(sample(3), packetTime))
+
+		// A sample following dropped packets isn't merged
+		assert.Empty(t, r.push(sample(4), packetTime))
+		dropped := sample(5)
+		dropped.PrevDroppedPackets = 1
+		assert.Equal(t, []media.Sample{sample(4), dropped}, r.push(dropped, packetTime))
+	})
+
+	t.Run("Opus", func(t *testing.T) {
+		r := &sampleRepacketizer{mimeType: MimeTypeOpus}
+		assert.Empty(t, r.push(sample(0x78, 1, 2), packetTime))
+		assert.Empty(t, r.push(sample(0x78, 3, 4), packetTime))
+		assert.Equal(t, []media.Sample{{Data: []byte{0x7b, 0x03, 1, 2, 3, 4, 5, 6}, Duration: packetTime}}, r.push(sample(0x78, 5, 6), packetTime))
+
+		// Frames of different sizes
+		assert.Empty(t, r.push(sample(0x78, 1), packetTime))
+		assert.Empty(t, r.push(sample(0x78, 2, 3), packetTime))
+		assert.Equal(t, []media.Sample{{Data: []byte{0x7b, 0x83, 1, 2, 1, 2, 3, 4}, Duration: packetTime}}, r.push(sample(0x78, 4), packetTime))
+
+		// A different configuration flushes the pending frames
+		assert.Empty(t, r.push(sample(0x78, 1), packetTime))
+		assert.Equal(t, []media.Sample{sample(0x78, 1)}, r.push(sample(0x80, 2), packetTime))
+
+		// Packets of more than one frame aren't merged
+		assert.Equal(t, []media.Sample{sample(0x80, 2), sample(0x79, 1, 2)}, r.push(sample(0x79, 1, 2), packetTime))
+	})
+
+	t.Run("VP8", func(t *testing.T) {
+		r := &sampleRepacketizer{mimeType: MimeTypeVP8}
+		assert.Equal(t, []media.Sample{sample(1)}, r.push(sample(1), packetTime))
+	})
+}
+
+func TestMergeOpusFrames_LongFrames(t *testing.T) {
+	long := media.Sample{Data: append([]byte{0x78}, make([]byte, 300)...)}
+	short := media.Sample{Data: []byte{0x78, 1}}
+
+	packet := mergeOpusFrames([]media.Sample{long, short})
+	// 300 = 252 + 0 + 4*12
+	assert.Equal(t, []byte{0x7b, 0x82, 252, 12}, packet[:4])
+	assert.Equal(t, 4+300+1, len(packet))
+}
@@ -8,6 +8,7 @@ package webrtc
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +30,12 @@ const (
 	// MimeTypeOpus Opus MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypeOpus = "audio/opus"
+	// MimeTypeMultiOpus is the non-standard multiopus MIME type used by
+	// Chromium for surround audio, like 6 channels for 5.1 and 8 for 7.1. The
+	// SDPFmtpLine must describe the streams with num_streams,
+	// coupled_streams and channel_mapping.
+	// Note: Matching should be case insensitive.
+	MimeTypeMultiOpus = "audio/multiopus"
 	// MimeTypeVP8 VP8 MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypeVP8 = "video/VP8"
@@ -47,6 +54,15 @@ const (
 	// MimeTypePCMA PCMA MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypePCMA = "audio/PCMA"
+	// MimeTypeRTX RTX MIME type
+	// Note: Matching should be case insensitive.
+	MimeTypeRTX = "video/rtx"
+	// MimeTypeRED RED MIME type of video
+	// Note: Matching should be case insensitive.
+	MimeTypeRED = "video/red"
+	// MimeTypeULPFEC ULPFEC MIME type
+	// Note: Matching should be case insensitive.
+	MimeTypeULPFEC = "video/ulpfec"
 )
 
 type mediaEngineHeaderExtension struct {
@@ -66,9 +82,26 @@ type MediaEngine struct {
 	videoCodecs, audioCodecs                     []RTPCodecParameters
 	negotiatedVideoCodecs, negotiatedAudioCodecs []RTPCodecParameters
 
+	// pendingVideoCodecs and pendingAudioCodecs are registered after their
+	// kind was negotiated, they are offered until the next negotiation
+	pendingVideoCodecs, pendingAudioCodecs []RTPCodecParameters
+
+	codecMatchModes map[string]CodecMatchMode
+	rejectedCodecs  []RejectedCodec
+
+	// fmtpParsers are set by RegisterFMTPParser
+	fmtpParsers fmtp.Parsers
+
 	headerExtensions           []mediaEngineHeaderExtension
 	negotiatedHeaderExtensions map[int]mediaEngineHeaderExtension
 
+	// negotiatedExtMapAllowMixed is set if the remote allows two-byte header
+	// extensions with a=extmap-allow-mixed
+	negotiatedExtMapAllowMixed bool
+
+	// lowBandwidthAudio is set by RegisterLowBandwidthAudio
+	lowBandwidthAudio *LowBandwidthAudioOptions
+
 	mu sync.RWMutex
 }
 
@@ -220,23 +253,158 @@ func (m *MediaEngine) addCodec(codecs []RTPCodecParameters, codec RTPCodecParame
 
 // RegisterCodec adds codec to the MediaEngine
 // These are the list of codecs supported by this PeerConnection.
+// Codecs registered once the MediaEngine of a PeerConnection negotiated are
+// offered in the next negotiation, see PeerConnection.RegisterCodec.
 // RegisterCodec is not safe for concurrent use.
 func (m *MediaEngine) RegisterCodec(codec RTPCodecParameters, typ RTPCodecType) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	codec.statsID = fmt.Sprintf("RTPCodec-%d", time.Now().UnixNano())
+	if typ == RTPCodecTypeVideo && m.lowBandwidthAudio != nil {
+		// Video feedback is disabled by RegisterLowBandwidthAudio
+		codec.RTCPFeedback = nil
+	}
+
 	switch typ {
 	case RTPCodecTypeAudio:
 		m.audioCodecs = m.addCodec(m.audioCodecs, codec)
+		if m.negotiatedAudio {
+			m.pendingAudioCodecs = m.addCodec(m.pendingAudioCodecs, codec)
+		}
 	case RTPCodecTypeVideo:
 		m.videoCodecs = m.addCodec(m.videoCodecs, codec)
+		if m.negotiatedVideo {
+			m.pendingVideoCodecs = m.addCodec(m.pendingVideoCodecs, codec)
+		}
 	default:
 		return ErrUnknownType
 	}
 	return nil
 }
 
+// getCapabilities returns the registered codecs of kind typ, and the header
+// extensions allowed in direction, or nil for an unknown kind
+func (m *MediaEngine) getCapabilities(typ RTPCodecType, direction RTPTransceiverDirection) *RTPCapabilities {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var codecs []RTPCodecParameters
+	switch typ {
+	case RTPCodecTypeAudio:
+		codecs = m.audioCodecs
+	case RTPCodecTypeVideo:
+		codecs = m.videoCodecs
+	default:
+		return nil
+	}
+
+	capabilities := &RTPCapabilities{
+		Codecs:           []RTPCodecCapability{},
+		HeaderExtensions: []RTPHeaderExtensionCapability{},
+	}
+
+	// Codecs registered with several payload types are only listed once
+	for _, codec := range codecs {
+		duplicate := false
+		for _, c := range capabilities.Codecs {
+			duplicate = duplicate || (strings.EqualFold(c.MimeType, codec.MimeType) && c.ClockRate == codec.ClockRate &&
+				c.Channels == codec.Channels && c.SDPFmtpLine == codec.SDPFmtpLine)
+		}
+		if !duplicate {
+			capabilities.Codecs = append(capabilities.Codecs, codec.RTPCodecCapability)
+		}
+	}
+
+	for _, e := range m.headerExtensions {
+		if (e.isAudio && typ == RTPCodecTypeAudio || e.isVideo && typ == RTPCodecTypeVideo) &&
+			haveRTPTransceiverDirectionIntersection(e.allowedDirections, []RTPTransceiverDirection{direction}) {
+			capabilities.HeaderExtensions = append(capabilities.HeaderExtensions, RTPHeaderExtensionCapability{URI: e.uri})
+		}
+	}
+
+	return capabilities
+}
+
+// hasPendingCodecs returns true if codecs were registered after the
+// MediaEngine negotiated, so they must be negotiated again
+func (m *MediaEngine) hasPendingCodecs() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.pendingVideoCodecs) != 0 || len(m.pendingAudioCodecs) != 0
+}
+
+// withPendingCodecs returns the negotiated codecs followed by the pending
+// codecs which aren't negotiated yet. Pending codecs whose payload type is
+// used by a negotiated codec get a free one, and the apt of their RTX codecs
+// is updated before they are compared with the negotiated codecs.
+func withPendingCodecs(parsers fmtp.Parsers, negotiated, pending []RTPCodecParameters) []RTPCodecParameters {
+	if len(pending) == 0 {
+		return negotiated
+	}
+
+	used := map[PayloadType]bool{}
+	for _, codec := range negotiated {
+		used[codec.PayloadType] = true
+	}
+
+	codecs := append([]RTPCodecParameters{}, negotiated...)
+	remapped := map[PayloadType]PayloadType{}
+	for _, codec := range pending {
+		codec.SDPFmtpLine = remapApt(parsers, codec, remapped)
+
+		if match, matchType := codecParametersFuzzySearch(parsers, codec, negotiated); matchType == codecMatchExact {
+			remapped[codec.PayloadType] = match.PayloadType
+			continue
+		}
+
+		if used[codec.PayloadType] {
+			payloadType, ok := freePayloadType(used)
+			if !ok {
+				continue
+			}
+			remapped[codec.PayloadType] = payloadType
+			codec.PayloadType = payloadType
+		}
+		used[codec.PayloadType] = true
+		codecs = append(codecs, codec)
+	}
+
+	return codecs
+}
+
+// remapApt returns the fmtp line of codec with its apt replaced by the
+// payload type it was remapped to
+func remapApt(parsers fmtp.Parsers, codec RTPCodecParameters, remapped map[PayloadType]PayloadType) string {
+	apt, hasApt := parsers.Parse(codec.MimeType, codec.SDPFmtpLine).Parameter("apt")
+	if !hasApt {
+		return codec.SDPFmtpLine
+	}
+	aptPayloadType, err := strconv.ParseUint(apt, 10, 8)
+	if err != nil {
+		return codec.SDPFmtpLine
+	}
+	payloadType, ok := remapped[PayloadType(aptPayloadType)]
+	if !ok {
+		return codec.SDPFmtpLine
+	}
+
+	return strings.Replace(codec.SDPFmtpLine, "apt="+apt, fmt.Sprintf("apt=%d", payloadType), 1)
+}
+
+// freePayloadType returns a dynamic payload type which isn't used
+func freePayloadType(used map[PayloadType]bool) (PayloadType, bool) {
+	for _, payloadTypes := range [][2]PayloadType{{96, 127}, {35, 63}} {
+		for payloadType := payloadTypes[0]; payloadType <= payloadTypes[1]; payloadType++ {
+			if !used[payloadType] {
+				return payloadType, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
 // RegisterHeaderExtension adds a header extension to the MediaEngine
 // To determine the negotiated value use `GetHeaderExtensionID` after signaling is complete
 func (m *MediaEngine) RegisterHeaderExtension(extension RTPHeaderExtensionCapability, typ RTPCodecType, allowedDirections ...RTPTransceiverDirection) error {
@@ -286,6 +454,11 @@ func (m *MediaEngine) RegisterFeedback(feedback RTCPFeedback, typ RTPCodecType)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if typ == RTPCodecTypeVideo && m.lowBandwidthAudio != nil {
+		// Video feedback is disabled by RegisterLowBandwidthAudio
+		return
+	}
+
 	if typ == RTPCodecTypeVideo {
 		for i, v := range m.videoCodecs {
 			v.RTCPFeedback = append(v.RTCPFeedback, feedback)
@@ -324,9 +497,17 @@ func (m *MediaEngine) copy() *MediaEngine {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	cloned := &MediaEngine{
-		videoCodecs:      append([]RTPCodecParameters{}, m.videoCodecs...),
-		audioCodecs:      append([]RTPCodecParameters{}, m.audioCodecs...),
-		headerExtensions: append([]mediaEngineHeaderExtension{}, m.headerExtensions...),
+		videoCodecs:       append([]RTPCodecParameters{}, m.videoCodecs...),
+		audioCodecs:       append([]RTPCodecParameters{}, m.audioCodecs...),
+		headerExtensions:  append([]mediaEngineHeaderExtension{}, m.headerExtensions...),
+		lowBandwidthAudio: m.lowBandwidthAudio,
+		fmtpParsers:       m.fmtpParsers,
+	}
+	if len(m.codecMatchModes) > 0 {
+		cloned.codecMatchModes = map[string]CodecMatchMode{}
+		for mimeType, mode := range m.codecMatchModes {
+			cloned.codecMatchModes[mimeType] = mode
+		}
 	}
 	if len(m.headerExtensions) > 0 {
 		cloned.negotiatedHeaderExtensions = map[int]mediaEngineHeaderExtension{}
@@ -373,7 +554,25 @@ func (m *MediaEngine) getCodecByPayload(payloadType PayloadType) (RTPCodecParame
 	return RTPCodecParameters{}, 0, ErrCodecNotFound
 }
 
-func (m *MediaEngine) collectStats(collector *statsReportCollector) {
+// localSDPFmtpLine returns the fmtp line to advertise for a codec. The Opus
+// parameters, like useinbandfec, describe what the receiver prefers, so we
+// advertise the locally registered ones instead of echoing the remote's.
+// https://datatracker.ietf.org/doc/html/rfc7587#section-7
+func (m *MediaEngine) localSDPFmtpLine(codec RTPCodecParameters) string {
+	if !strings.EqualFold(codec.MimeType, MimeTypeOpus) {
+		return codec.SDPFmtpLine
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if local, matchType := codecParametersFuzzySearch(m.fmtpParsers, codec, m.audioCodecs); matchType != codecMatchNone {
+		return local.SDPFmtpLine
+	}
+	return codec.SDPFmtpLine
+}
+
+func (m *MediaEngine) collectStats(collector *statsReportCollector, transportID string) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -384,6 +583,7 @@ func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 				Timestamp:   statsTimestampFrom(time.Now()),
 				Type:        StatsTypeCodec,
 				ID:          codec.statsID,
+				TransportID: transportID,
 				PayloadType: codec.PayloadType,
 				MimeType:    codec.MimeType,
 				ClockRate:   codec.ClockRate,
@@ -395,8 +595,19 @@ func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 		}
 	}
 
-	statsLoop(m.videoCodecs)
-	statsLoop(m.audioCodecs)
+	// Only the negotiated codecs are used by the RTP streams
+	statsLoop(m.negotiatedVideoCodecs)
+	statsLoop(m.negotiatedAudioCodecs)
+}
+
+// codecStatsID returns the ID of the CodecStats of a codec negotiated on the
+// transport, a codec is identified by its payload type and fmtp line
+func codecStatsID(transportID string, codec RTPCodecParameters) string {
+	if codec.SDPFmtpLine == "" {
+		return fmt.Sprintf("RTPCodec-%s-%d", transportID, codec.PayloadType)
+	}
+
+	return fmt.Sprintf("RTPCodec-%s-%d-%s", transportID, codec.PayloadType, codec.SDPFmtpLine)
 }
 
 // Look up a codec and enable if it exists
@@ -406,7 +617,7 @@ func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCo
 		codecs = m.audioCodecs
 	}
 
-	remoteFmtp := fmtp.Parse(remoteCodec.RTPCodecCapability.MimeType, remoteCodec.RTPCodecCapability.SDPFmtpLine)
+	remoteFmtp := m.fmtpParsers.Parse(remoteCodec.RTPCodecCapability.MimeType, remoteCodec.RTPCodecCapability.SDPFmtpLine)
 	if apt, hasApt := remoteFmtp.Parameter("apt"); hasApt {
 		payloadType, err := strconv.ParseUint(apt, 10, 8)
 		if err != nil {
@@ -439,31 +650,38 @@ func (m *MediaEngine) matchRemoteCodec(remoteCodec RTPCodecParameters, typ RTPCo
 
 		// replace the apt value with the original codec's payload type
 		toMatchCodec := remoteCodec
-		if aptMatched, mt := codecParametersFuzzySearch(aptCodec, codecs); mt == aptMatch {
+		if aptMatched, mt := codecParametersFuzzySearch(m.fmtpParsers, aptCodec, codecs); mt == aptMatch {
 			toMatchCodec.SDPFmtpLine = strings.Replace(toMatchCodec.SDPFmtpLine, fmt.Sprintf("apt=%d", payloadType), fmt.Sprintf("apt=%d", aptMatched.PayloadType), 1)
 		}
 
 		// if apt's media codec is partial match, then apt codec must be partial match too
-		_, matchType := codecParametersFuzzySearch(toMatchCodec, codecs)
+		_, matchType := codecParametersFuzzySearch(m.fmtpParsers, toMatchCodec, codecs)
 		if matchType == codecMatchExact && aptMatch == codecMatchPartial {
 			matchType = codecMatchPartial
 		}
 		return matchType, nil
 	}
 
-	_, matchType := codecParametersFuzzySearch(remoteCodec, codecs)
+	_, matchType := codecParametersFuzzySearch(m.fmtpParsers, remoteCodec, codecs)
 	return matchType, nil
 }
 
 // Look up a header extension and enable if it exists
-func (m *MediaEngine) updateHeaderExtension(id int, extension string, typ RTPCodecType) error {
+// The remote only sending or receiving the extension restricts its
+// directions, so we only receive or send it, RFC 8285 Section 7.
+func (m *MediaEngine) updateHeaderExtension(id int, extension string, typ RTPCodecType, remoteDirection sdp.Direction) error {
 	if m.negotiatedHeaderExtensions == nil {
 		return nil
 	}
 
 	for _, localExtension := range m.headerExtensions {
 		if localExtension.uri == extension {
-			h := mediaEngineHeaderExtension{uri: extension, allowedDirections: localExtension.allowedDirections}
+			allowedDirections := headerExtensionAllowedDirections(localExtension.allowedDirections, remoteDirection)
+			if len(allowedDirections) == 0 {
+				continue
+			}
+
+			h := mediaEngineHeaderExtension{uri: extension, allowedDirections: allowedDirections}
 			if existingValue, ok := m.negotiatedHeaderExtensions[id]; ok {
 				h = existingValue
 			}
@@ -481,8 +699,65 @@ func (m *MediaEngine) updateHeaderExtension(id int, extension string, typ RTPCod
 	return nil
 }
 
+// headerExtensionAllowedDirections returns the directions of local allowed
+// by the direction of the remote a=extmap
+func headerExtensionAllowedDirections(local []RTPTransceiverDirection, remoteDirection sdp.Direction) []RTPTransceiverDirection {
+	var allowed RTPTransceiverDirection
+	switch remoteDirection {
+	case sdp.DirectionSendOnly:
+		allowed = RTPTransceiverDirectionRecvonly
+	case sdp.DirectionRecvOnly:
+		allowed = RTPTransceiverDirectionSendonly
+	case sdp.DirectionInactive:
+		return nil
+	default:
+		return local
+	}
+
+	if !haveRTPTransceiverDirectionIntersection(local, []RTPTransceiverDirection{allowed}) {
+		return nil
+	}
+
+	return []RTPTransceiverDirection{allowed}
+}
+
+// headerExtensionDirection returns the direction of the a=extmap of the
+// header extension uri, which is omitted when it is sent and received
+func (m *MediaEngine) headerExtensionDirection(uri string, typ RTPCodecType) sdp.Direction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var allowedDirections []RTPTransceiverDirection
+	if m.negotiatedVideo && typ == RTPCodecTypeVideo || m.negotiatedAudio && typ == RTPCodecTypeAudio {
+		for _, e := range m.negotiatedHeaderExtensions {
+			if e.uri == uri && (e.isAudio && typ == RTPCodecTypeAudio || e.isVideo && typ == RTPCodecTypeVideo) {
+				allowedDirections = e.allowedDirections
+			}
+		}
+	} else {
+		for _, e := range m.headerExtensions {
+			if e.uri == uri {
+				allowedDirections = e.allowedDirections
+			}
+		}
+	}
+
+	send := haveRTPTransceiverDirectionIntersection(allowedDirections, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly})
+	recv := haveRTPTransceiverDirectionIntersection(allowedDirections, []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly})
+	switch {
+	case send && !recv:
+		return sdp.DirectionSendOnly
+	case recv && !send:
+		return sdp.DirectionRecvOnly
+	default:
+		// Omitted for sendrecv
+		return 0
+	}
+}
+
 func (m *MediaEngine) pushCodecs(codecs []RTPCodecParameters, typ RTPCodecType) {
 	for _, codec := range codecs {
+		codec.statsID = codecStatsID(iceTransportStatsID, codec)
 		if typ == RTPCodecTypeAudio {
 			m.negotiatedAudioCodecs = m.addCodec(m.negotiatedAudioCodecs, codec)
 		} else if typ == RTPCodecTypeVideo {
@@ -496,14 +771,25 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for _, media := range desc.MediaDescriptions {
+	m.negotiatedExtMapAllowMixed = isExtMapAllowMixedSet(&desc)
+
+	// Kinds with pending codecs are negotiated again with the first media
+	// section of the kind
+	renegotiateAudio, renegotiateVideo := len(m.pendingAudioCodecs) != 0, len(m.pendingVideoCodecs) != 0
+
+	for i, media := range desc.MediaDescriptions {
 		var typ RTPCodecType
+		renegotiate := false
 		switch {
-		case !m.negotiatedAudio && strings.EqualFold(media.MediaName.Media, "audio"):
+		case (!m.negotiatedAudio || renegotiateAudio) && strings.EqualFold(media.MediaName.Media, "audio"):
+			renegotiate, renegotiateAudio = renegotiateAudio, false
 			m.negotiatedAudio = true
+			m.pendingAudioCodecs = nil
 			typ = RTPCodecTypeAudio
-		case !m.negotiatedVideo && strings.EqualFold(media.MediaName.Media, "video"):
+		case (!m.negotiatedVideo || renegotiateVideo) && strings.EqualFold(media.MediaName.Media, "video"):
+			renegotiate, renegotiateVideo = renegotiateVideo, false
 			m.negotiatedVideo = true
+			m.pendingVideoCodecs = nil
 			typ = RTPCodecTypeVideo
 		default:
 			continue
@@ -511,22 +797,55 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 
 		codecs, err := codecsFromMediaDescription(media)
 		if err != nil {
-			return err
+			return &SDPError{MediaIndex: i, Attribute: "rtpmap", Err: fmt.Errorf("%w: %v", ErrSessionDescriptionInvalidCodec, err)}
 		}
 
 		exactMatches := make([]RTPCodecParameters, 0, len(codecs))
 		partialMatches := make([]RTPCodecParameters, 0, len(codecs))
 
+		registeredCodecs := m.videoCodecs
+		if typ == RTPCodecTypeAudio {
+			registeredCodecs = m.audioCodecs
+		}
+
+		rejectedCodecs := m.rejectedCodecs[:0]
+		for _, rejected := range m.rejectedCodecs {
+			if rejected.Kind != typ {
+				rejectedCodecs = append(rejectedCodecs, rejected)
+			}
+		}
+
 		for _, codec := range codecs {
 			matchType, mErr := m.matchRemoteCodec(codec, typ, exactMatches, partialMatches)
 			if mErr != nil {
-				return mErr
+				return &SDPError{MediaIndex: i, Attribute: "fmtp", Err: fmt.Errorf("%w: %v", ErrSessionDescriptionInvalidCodec, mErr)}
 			}
 
-			if matchType == codecMatchExact {
+			switch {
+			case matchType == codecMatchExact:
 				exactMatches = append(exactMatches, codec)
-			} else if matchType == codecMatchPartial {
+			case matchType == codecMatchPartial && m.codecMatchMode(codec.MimeType) == CodecMatchModeExact:
+				rejectedCodecs = append(rejectedCodecs, RejectedCodec{Kind: typ, Codec: codec, Reason: CodecRejectionReasonFmtpMismatch})
+			case matchType == codecMatchPartial:
 				partialMatches = append(partialMatches, codec)
+			default:
+				rejectedCodecs = append(rejectedCodecs, RejectedCodec{Kind: typ, Codec: codec, Reason: codecRejectionReason(m.fmtpParsers, codec, registeredCodecs)})
+			}
+		}
+
+		if len(exactMatches) > 0 {
+			for _, codec := range partialMatches {
+				rejectedCodecs = append(rejectedCodecs, RejectedCodec{Kind: typ, Codec: codec, Reason: CodecRejectionReasonSuperseded})
+			}
+		}
+		m.rejectedCodecs = rejectedCodecs
+
+		// The codecs negotiated before are kept if none matches
+		if renegotiate && (len(exactMatches) > 0 || len(partialMatches) > 0) {
+			if typ == RTPCodecTypeAudio {
+				m.negotiatedAudioCodecs = nil
+			} else {
+				m.negotiatedVideoCodecs = nil
 			}
 		}
 
@@ -543,31 +862,55 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 
 		extensions, err := rtpExtensionsFromMediaDescription(media)
 		if err != nil {
-			return err
+			return &SDPError{MediaIndex: i, Attribute: sdp.AttrKeyExtMap, Err: err}
+		}
+
+		directions, err := rtpExtensionDirectionsFromMediaDescription(media)
+		if err != nil {
+			return &SDPError{MediaIndex: i, Attribute: sdp.AttrKeyExtMap, Err: err}
 		}
 
 		for extension, id := range extensions {
-			if err = m.updateHeaderExtension(id, extension, typ); err != nil {
-				return err
+			if err = m.updateHeaderExtension(id, extension, typ, directions[extension]); err != nil {
+				return &SDPError{MediaIndex: i, Attribute: sdp.AttrKeyExtMap, Err: err}
 			}
 		}
 	}
 	return nil
 }
 
+// supportsAnyCodec reports whether at least one of the remote codecs matches a
+// registered codec of the given kind
+func (m *MediaEngine) supportsAnyCodec(remoteCodecs []RTPCodecParameters, typ RTPCodecType) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	codecs := m.videoCodecs
+	if typ == RTPCodecTypeAudio {
+		codecs = m.audioCodecs
+	}
+
+	for _, remoteCodec := range remoteCodecs {
+		if _, matchType := codecParametersFuzzySearch(m.fmtpParsers, remoteCodec, codecs); matchType != codecMatchNone {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *MediaEngine) getCodecsByKind(typ RTPCodecType) []RTPCodecParameters {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	if typ == RTPCodecTypeVideo {
 		if m.negotiatedVideo {
-			return m.negotiatedVideoCodecs
+			return withPendingCodecs(m.fmtpParsers, m.negotiatedVideoCodecs, m.pendingVideoCodecs)
 		}
 
 		return m.videoCodecs
 	} else if typ == RTPCodecTypeAudio {
 		if m.negotiatedAudio {
-			return m.negotiatedAudioCodecs
+			return withPendingCodecs(m.fmtpParsers, m.negotiatedAudioCodecs, m.pendingAudioCodecs)
 		}
 
 		return m.audioCodecs
@@ -603,7 +946,7 @@ func (m *MediaEngine) getRTPParametersByKind(typ RTPCodecType, directions []RTPT
 				}
 			}
 			if !usingNegotiatedID {
-				for id := 1; id < 15; id++ {
+				for id := 1; id <= m.maxHeaderExtensionID(e); id++ {
 					idAvailable := true
 					if _, ok := mediaHeaderExtensions[id]; ok {
 						idAvailable = false
@@ -623,12 +966,38 @@ func (m *MediaEngine) getRTPParametersByKind(typ RTPCodecType, directions []RTPT
 		}
 	}
 
+	// The header extensions are sorted by ID so descriptions are stable
+	sort.Slice(headerExtensions, func(i, j int) bool {
+		return headerExtensions[i].ID < headerExtensions[j].ID
+	})
+
 	return RTPParameters{
 		HeaderExtensions: headerExtensions,
 		Codecs:           foundCodecs,
 	}
 }
 
+// maxHeaderExtensionID returns the largest ID that can be allocated to the
+// header extension e. IDs above 14 need two-byte header extensions, so they
+// are only used once the remote allowed them with a=extmap-allow-mixed, and
+// only for extensions which are received or written by Pion itself.
+func (m *MediaEngine) maxHeaderExtensionID(e mediaEngineHeaderExtension) int {
+	sent := haveRTPTransceiverDirectionIntersection(e.allowedDirections, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly})
+	if m.negotiatedExtMapAllowMixed && (!sent || twoByteHeaderExtensionWriters[e.uri]) {
+		return maxTwoByteHeaderExtensionID
+	}
+
+	return maxOneByteHeaderExtensionID
+}
+
+// extMapAllowMixed returns true if the remote allows two-byte header extensions
+func (m *MediaEngine) extMapAllowMixed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.negotiatedExtMapAllowMixed
+}
+
 func (m *MediaEngine) getRTPParametersByPayloadType(payloadType PayloadType) (RTPParameters, error) {
 	codec, typ, err := m.getCodecByPayload(payloadType)
 	if err != nil {
@@ -654,7 +1023,9 @@ func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 	switch strings.ToLower(codec.MimeType) {
 	case strings.ToLower(MimeTypeH264):
 		return &codecs.H264Payloader{}, nil
-	case strings.ToLower(MimeTypeOpus):
+	case strings.ToLower(MimeTypeOpus), strings.ToLower(MimeTypeMultiOpus):
+		// Packets of multiopus hold the frames of all its streams, they are
+		// payloaded like Opus
 		return &codecs.OpusPayloader{}, nil
 	case strings.ToLower(MimeTypeVP8):
 		return &codecs.VP8Payloader{
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v3/test"
@@ -32,6 +33,72 @@ func TestOpusCase(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+func TestOpusInBandFEC(t *testing.T) {
+	offerer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	offer, err := offerer.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	// The offerer doesn't want FEC, but the answerer still does
+	offer.SDP = strings.Replace(offer.SDP, "useinbandfec=1", "useinbandfec=0", 1)
+	assert.NoError(t, answerer.SetRemoteDescription(offer))
+
+	opusCodec, _, err := answerer.api.mediaEngine.getCodecByPayload(111)
+	assert.NoError(t, err)
+	assert.Equal(t, "minptime=10;useinbandfec=0", opusCodec.SDPFmtpLine)
+
+	answer, err := answerer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, answer.SDP, "a=fmtp:111 minptime=10;useinbandfec=1")
+
+	closePairNow(t, offerer, answerer)
+}
+
+func TestMultiOpus(t *testing.T) {
+	const chromiumOffer = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=audio 9 UDP/TLS/RTP/SAVPF 111 114 115
+a=rtpmap:111 opus/48000/2
+a=fmtp:111 minptime=10;useinbandfec=1
+a=rtpmap:114 multiopus/48000/6
+a=fmtp:114 channel_mapping=0,4,1,2,3,5;coupled_streams=2;minptime=10;num_streams=4;useinbandfec=1
+a=rtpmap:115 multiopus/48000/8
+a=fmtp:115 channel_mapping=0,6,1,2,3,4,5,7;coupled_streams=3;minptime=10;num_streams=5;useinbandfec=1
+`
+
+	m := MediaEngine{}
+	assert.NoError(t, m.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeMultiOpus, 48000, 6, "channel_mapping=0,4,1,2,3,5;coupled_streams=2;num_streams=4", nil},
+		PayloadType:        116,
+	}, RTPCodecTypeAudio))
+
+	s := sdp.SessionDescription{}
+	assert.NoError(t, s.Unmarshal([]byte(chromiumOffer)))
+	assert.NoError(t, m.updateFromRemoteDescription(s))
+	assert.True(t, m.negotiatedAudio)
+
+	// Only the 5.1 layout is negotiated, with the payload type of the remote
+	codec, _, err := m.getCodecByPayload(114)
+	assert.NoError(t, err)
+	assert.Equal(t, MimeTypeMultiOpus, codec.MimeType)
+	assert.Equal(t, uint16(6), codec.Channels)
+
+	_, _, err = m.getCodecByPayload(115)
+	assert.ErrorIs(t, err, ErrCodecNotFound)
+
+	_, err = payloaderForCodec(codec.RTPCodecCapability)
+	assert.NoError(t, err)
+}
+
 // pion/example-webrtc-applications#89
 func TestVideoCase(t *testing.T) {
 	pc, err := NewPeerConnection(Configuration{})
@@ -520,7 +587,7 @@ func TestUpdateHeaderExtenstionToClonedMediaEngine(t *testing.T) {
 	assert.NoError(t, src.RegisterHeaderExtension(RTPHeaderExtensionCapability{"test-extension"}, RTPCodecTypeAudio))
 
 	validate := func(m *MediaEngine) {
-		assert.NoError(t, m.updateHeaderExtension(2, "test-extension", RTPCodecTypeAudio))
+		assert.NoError(t, m.updateHeaderExtension(2, "test-extension", RTPCodecTypeAudio, sdp.DirectionSendRecv))
 
 		id, audioNegotiated, videoNegotiated := m.getHeaderExtensionID(RTPHeaderExtensionCapability{URI: "test-extension"})
 		assert.Equal(t, 2, id)
@@ -689,3 +756,151 @@ a=fmtp:127 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001
 		})
 	}
 }
+
+func TestWithPendingCodecs(t *testing.T) {
+	negotiated := []RTPCodecParameters{
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 96},
+		{RTPCodecCapability: RTPCodecCapability{"video/rtx", 90000, 0, "apt=96", nil}, PayloadType: 97},
+	}
+	assert.Equal(t, negotiated, withPendingCodecs(nil, negotiated, nil))
+
+	pending := []RTPCodecParameters{
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 100},
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeVP9, 90000, 0, "profile-id=0", nil}, PayloadType: 96},
+		{RTPCodecCapability: RTPCodecCapability{"video/rtx", 90000, 0, "apt=96", nil}, PayloadType: 99},
+	}
+
+	// VP8 is already negotiated, and VP9 gets a free payload type
+	codecs := withPendingCodecs(nil, negotiated, pending)
+	assert.Equal(t, 4, len(codecs))
+	assert.Equal(t, MimeTypeVP9, codecs[2].MimeType)
+	assert.Equal(t, PayloadType(98), codecs[2].PayloadType)
+	assert.Equal(t, PayloadType(99), codecs[3].PayloadType)
+	assert.Equal(t, "apt=98", codecs[3].SDPFmtpLine)
+}
+
+func TestPeerConnection_RegisterCodec(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	vp8 := RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 96}
+	vp9 := RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeTypeVP9, 90000, 0, "profile-id=0", nil}, PayloadType: 98}
+
+	newPeerConnection := func() *PeerConnection {
+		m := &MediaEngine{}
+		assert.NoError(t, m.RegisterCodec(vp8, RTPCodecTypeVideo))
+		pc, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		return pc
+	}
+
+	pcOffer, pcAnswer := newPeerConnection(), newPeerConnection()
+
+	_, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	negotiationNeeded := make(chan struct{}, 1)
+	pcOffer.OnNegotiationNeeded(func() {
+		select {
+		case negotiationNeeded <- struct{}{}:
+		default:
+		}
+	})
+
+	assert.NoError(t, pcOffer.RegisterCodec(vp9, RTPCodecTypeVideo))
+	assert.NoError(t, pcAnswer.RegisterCodec(vp9, RTPCodecTypeVideo))
+	<-negotiationNeeded
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=rtpmap:98 VP9/90000")
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		assert.False(t, pc.api.mediaEngine.hasPendingCodecs())
+		codec, _, err := pc.api.mediaEngine.getCodecByPayload(98)
+		assert.NoError(t, err)
+		assert.Equal(t, MimeTypeVP9, codec.MimeType)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestHeaderExtensionAllowedDirections(t *testing.T) {
+	both := []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionSendonly}
+	sendonly := []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}
+	recvonly := []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly}
+
+	testCases := []struct {
+		local           []RTPTransceiverDirection
+		remoteDirection sdp.Direction
+		expected        []RTPTransceiverDirection
+	}{
+		{both, 0, both},
+		{both, sdp.DirectionSendRecv, both},
+		{both, sdp.DirectionSendOnly, recvonly},
+		{both, sdp.DirectionRecvOnly, sendonly},
+		{both, sdp.DirectionInactive, nil},
+		{sendonly, sdp.DirectionRecvOnly, sendonly},
+		{sendonly, sdp.DirectionSendOnly, nil},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expected,
+			headerExtensionAllowedDirections(testCase.local, testCase.remoteDirection),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestHeaderExtensionDirectionNegotiation(t *testing.T) {
+	const videoOrientationURI = "urn:3gpp:video-orientation"
+
+	newPeerConnection := func(directions ...RTPTransceiverDirection) *PeerConnection {
+		m := &MediaEngine{}
+		assert.NoError(t, m.RegisterDefaultCodecs())
+		assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{videoOrientationURI}, RTPCodecTypeVideo, directions...))
+
+		pc, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		return pc
+	}
+
+	// The offerer only sends the extension, so the answerer only receives it
+	offerer := newPeerConnection(RTPTransceiverDirectionSendonly)
+	answerer := newPeerConnection()
+
+	_, err := offerer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := offerer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "/sendonly "+videoOrientationURI)
+
+	assert.NoError(t, offerer.SetLocalDescription(offer))
+	assert.NoError(t, answerer.SetRemoteDescription(offer))
+
+	answer, err := answerer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, answer.SDP, "/recvonly "+videoOrientationURI)
+
+	sendParameters := answerer.api.mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly})
+	for _, extension := range sendParameters.HeaderExtensions {
+		assert.NotEqual(t, videoOrientationURI, extension.URI)
+	}
+
+	recvParameters := answerer.api.mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly})
+	found := false
+	for _, extension := range recvParameters.HeaderExtensions {
+		found = found || extension.URI == videoOrientationURI
+	}
+	assert.True(t, found)
+
+	closePairNow(t, offerer, answerer)
+}
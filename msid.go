@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pion/sdp/v3"
+)
+
+// msidNoStream is the stream ID of a=msid for a track that doesn't belong to
+// any stream
+// https://datatracker.ietf.org/doc/html/rfc8830#section-2
+const msidNoStream = "-"
+
+// msidValues returns the values of the a=msid attributes of a track
+// belonging to the streams streamIDs
+func msidValues(streamIDs []string, trackID string) []string {
+	if len(streamIDs) == 0 {
+		return []string{msidNoStream + " " + trackID}
+	}
+
+	values := make([]string, 0, len(streamIDs))
+	for _, streamID := range streamIDs {
+		values = append(values, streamID+" "+trackID)
+	}
+
+	return values
+}
+
+// msidValuesFromMediaDescription returns the values of the a=msid attributes
+// of media
+func msidValuesFromMediaDescription(media *sdp.MediaDescription) []string {
+	var values []string
+	for _, a := range media.Attributes {
+		if a.Key == sdp.AttrKeyMsid {
+			values = append(values, a.Value)
+		}
+	}
+
+	return values
+}
+
+// streamIDsFromMsidValues returns the stream IDs of the a=msid values, without
+// the one of tracks that don't belong to any stream
+func streamIDsFromMsidValues(values []string) []string {
+	streamIDs := []string{}
+	for _, value := range values {
+		if split := strings.Split(value, " "); len(split) == 2 && split[0] != msidNoStream {
+			streamIDs = append(streamIDs, split[0])
+		}
+	}
+
+	return streamIDs
+}
+
+// trackStreamIDs returns the stream IDs of a track from the a=msid values of its
+// media section, or from streamID of an a=ssrc msid when there are none
+func trackStreamIDs(mediaMsids []string, streamID string) []string {
+	if len(mediaMsids) != 0 {
+		return streamIDsFromMsidValues(mediaMsids)
+	}
+
+	if streamID == "" || streamID == msidNoStream {
+		return []string{}
+	}
+
+	return []string{streamID}
+}
+
+// SetStreams sets the IDs of the streams the track of the sender belongs to,
+// which are advertised with one a=msid attribute each in the next offer or
+// answer. Without streamIDs the track doesn't belong to any stream. Unless
+// SetStreams is called the StreamID of the track is used. The PeerConnection
+// of the sender fires OnNegotiationNeeded when the streams changed.
+func (r *RTPSender) SetStreams(streamIDs ...string) {
+	r.mu.Lock()
+	r.streamIDs = append([]string{}, streamIDs...)
+	rtpTransceiver := r.rtpTransceiver
+	r.mu.Unlock()
+
+	if rtpTransceiver != nil {
+		rtpTransceiver.negotiationNeeded()
+	}
+}
+
+// StreamIDs returns the IDs of the streams the track of the sender belongs to.
+// See SetStreams
+func (r *RTPSender) StreamIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.streamIDs != nil {
+		return append([]string{}, r.streamIDs...)
+	}
+
+	if len(r.trackEncodings) == 0 || r.trackEncodings[0].track == nil {
+		return []string{}
+	}
+
+	return []string{r.trackEncodings[0].track.StreamID()}
+}
+
+// StreamIDs returns the IDs of all the streams the track belongs to, from the
+// a=msid attributes of the remote. It is empty when the track doesn't belong
+// to any stream.
+func (t *TrackRemote) StreamIDs() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return append([]string{}, t.streamIDs...)
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsidValues(t *testing.T) {
+	testCases := []struct {
+		streamIDs         []string
+		expectedValues    []string
+		expectedStreamIDs []string
+	}{
+		{[]string{}, []string{"- track"}, []string{}},
+		{[]string{"stream"}, []string{"stream track"}, []string{"stream"}},
+		{[]string{"first", "second"}, []string{"first track", "second track"}, []string{"first", "second"}},
+	}
+
+	for i, testCase := range testCases {
+		values := msidValues(testCase.streamIDs, "track")
+		assert.Equal(t, testCase.expectedValues, values, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedStreamIDs, streamIDsFromMsidValues(values), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestTrackStreamIDs(t *testing.T) {
+	testCases := []struct {
+		mediaMsids []string
+		streamID   string
+		expected   []string
+	}{
+		{nil, "", []string{}},
+		{nil, "-", []string{}},
+		{nil, "stream", []string{"stream"}},
+		{[]string{"first track", "second track"}, "first", []string{"first", "second"}},
+		{[]string{"- track"}, "-", []string{}},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expected, trackStreamIDs(testCase.mediaMsids, testCase.streamID), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestRTPSender_SetStreams(t *testing.T) {
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	negotiationNeeded := make(chan struct{}, 1)
+	pcOffer.OnNegotiationNeeded(func() {
+		select {
+		case negotiationNeeded <- struct{}{}:
+		default:
+		}
+	})
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pion"}, sender.StreamIDs())
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	pcOffer.WaitForOperations()
+	assert.False(t, pcOffer.checkNegotiationNeeded())
+
+	// Drop the event of AddTrack
+	select {
+	case <-negotiationNeeded:
+	default:
+	}
+
+	sender.SetStreams("first", "second")
+	assert.Equal(t, []string{"first", "second"}, sender.StreamIDs())
+	assert.True(t, pcOffer.checkNegotiationNeeded())
+	<-negotiationNeeded
+
+	sender.SetStreams()
+	assert.Equal(t, []string{}, sender.StreamIDs())
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=msid:- video\r\n")
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestRTPSender_StreamIDsWithoutTrack(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pc.AddTrack(track)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sender.ReplaceTrack(nil))
+	assert.Equal(t, []string{}, sender.StreamIDs())
+
+	assert.NoError(t, pc.Close())
+}
+
+// Assert that remote tracks have the stream IDs of RTPTransceiverInit
+func TestPeerConnection_RemoteStreamIDs(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		trackID           string
+		streamIDs         []string
+		expectedStreamID  string
+		expectedStreamIDs []string
+	}{
+		{"default", nil, "pion", []string{"pion"}},
+		{"several", []string{"first", "second"}, "first", []string{"first", "second"}},
+		{"none", []string{}, "-", []string{}},
+	}
+
+	tracks := []*TrackLocalStaticSample{}
+	for _, testCase := range testCases {
+		track, trackErr := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, testCase.trackID, "pion")
+		assert.NoError(t, trackErr)
+		tracks = append(tracks, track)
+
+		_, err = pcOffer.AddTransceiverFromTrack(track, RTPTransceiverInit{
+			Direction: RTPTransceiverDirectionSendonly,
+			StreamIDs: testCase.streamIDs,
+		})
+		assert.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	remoteTracks := map[string]*TrackRemote{}
+	allTracks := make(chan struct{})
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		remoteTracks[track.ID()] = track
+		if len(remoteTracks) == len(testCases) {
+			close(allTracks)
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(allTracks, t, tracks)
+
+	mu.Lock()
+	for i, testCase := range testCases {
+		track := remoteTracks[testCase.trackID]
+		assert.Equal(t, testCase.expectedStreamID, track.StreamID(), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedStreamIDs, track.StreamIDs(), "testCase: %d %v", i, testCase)
+	}
+	mu.Unlock()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
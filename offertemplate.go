@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
+// offerTemplatePerConnectionAttributes are the attributes of an offer which
+// differ between PeerConnections, they are generated for every offer
+var offerTemplatePerConnectionAttributes = map[string]bool{ //nolint:gochecknoglobals
+	sdp.AttrKeyCandidate:       true,
+	sdp.AttrKeyEndOfCandidates: true,
+	sdp.AttrKeySSRC:            true,
+	sdp.AttrKeySSRCGroup:       true,
+	sdp.AttrKeyMsid:            true,
+	sdpAttributeRid:            true,
+	sdpAttributeSimulcast:      true,
+	"ice-ufrag":                true,
+	"ice-pwd":                  true,
+	"fingerprint":              true,
+}
+
+// offerTemplateSection is the signature of a media section of an
+// OfferTemplate, PeerConnections must have a transceiver matching it
+type offerTemplateSection struct {
+	mid       string
+	kind      RTPCodecType
+	direction RTPTransceiverDirection
+	data      bool
+}
+
+// OfferTemplate generates the initial offers of PeerConnections with identical
+// transceivers and configuration, like the viewers of a broadcast. The codecs,
+// header extensions and media sections are generated once from the offer of a
+// first PeerConnection, and only the ICE credentials and candidates, the DTLS
+// fingerprints and the sources of the RTPSenders are generated for every
+// offer. This saves CPU when thousands of viewers join at once.
+type OfferTemplate struct {
+	session  sdp.SessionDescription
+	sections []offerTemplateSection
+
+	bundlePolicy           BundlePolicy
+	iceLite                bool
+	mediaLevelFingerprints bool
+	offeringConnectionRole sdp.ConnectionRole
+}
+
+// NewOfferTemplate creates an OfferTemplate from the initial offer of pc. It
+// calls CreateOffer on pc, which can be used as any other PeerConnection
+// afterwards.
+func NewOfferTemplate(pc *PeerConnection) (*OfferTemplate, error) {
+	if pc.CurrentRemoteDescription() != nil || pc.configuration.SDPSemantics == SDPSemanticsPlanB {
+		return nil, ErrOfferTemplateMismatch
+	}
+
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &OfferTemplate{
+		session:                *offer.parsed,
+		bundlePolicy:           pc.configuration.BundlePolicy,
+		iceLite:                pc.api.settingEngine.candidates.ICELite,
+		mediaLevelFingerprints: pc.api.settingEngine.sdpMediaLevelFingerprints,
+		offeringConnectionRole: pc.api.settingEngine.offeringConnectionRole(),
+	}
+	t.session.Attributes = stripOfferTemplateAttributes(offer.parsed.Attributes)
+	t.session.MediaDescriptions = make([]*sdp.MediaDescription, 0, len(offer.parsed.MediaDescriptions))
+
+	transceivers := pc.GetTransceivers()
+	for i, media := range offer.parsed.MediaDescriptions {
+		section := offerTemplateSection{mid: getMidValue(media)}
+		if i < len(transceivers) {
+			section.kind = transceivers[i].Kind()
+			section.direction = transceivers[i].Direction()
+		} else {
+			section.data = true
+		}
+		t.sections = append(t.sections, section)
+
+		stripped := *media
+		stripped.Attributes = stripOfferTemplateAttributes(media.Attributes)
+		t.session.MediaDescriptions = append(t.session.MediaDescriptions, &stripped)
+	}
+
+	return t, nil
+}
+
+func stripOfferTemplateAttributes(attributes []sdp.Attribute) []sdp.Attribute {
+	stripped := make([]sdp.Attribute, 0, len(attributes))
+	for _, a := range attributes {
+		// msid is a property attribute holding the stream and track IDs
+		if !offerTemplatePerConnectionAttributes[a.Key] && !strings.HasPrefix(a.Key, sdp.AttrKeyMsid+":") {
+			stripped = append(stripped, a)
+		}
+	}
+
+	return stripped
+}
+
+// CreateOffer creates the initial offer of pc from the template, it is used
+// like PeerConnection.CreateOffer. ErrOfferTemplateMismatch is returned if the
+// transceivers, DataChannels or configuration of pc don't match the
+// PeerConnection the template was created from, or if pc already negotiated.
+func (t *OfferTemplate) CreateOffer(pc *PeerConnection) (SessionDescription, error) {
+	if pc.isClosed.get() {
+		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	if pc.configuration.SDPSemantics == SDPSemanticsPlanB ||
+		pc.configuration.BundlePolicy != t.bundlePolicy ||
+		pc.api.settingEngine.candidates.ICELite != t.iceLite ||
+		pc.api.settingEngine.sdpMediaLevelFingerprints != t.mediaLevelFingerprints ||
+		pc.api.settingEngine.offeringConnectionRole() != t.offeringConnectionRole {
+		return SessionDescription{}, ErrOfferTemplateMismatch
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.currentRemoteDescription != nil {
+		return SessionDescription{}, ErrOfferTemplateMismatch
+	}
+
+	transceivers := pc.rtpTransceivers
+	if err := t.assignMids(pc, transceivers); err != nil {
+		return SessionDescription{}, err
+	}
+
+	iceParams, err := pc.iceGatherer.GetLocalParameters()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	candidates, err := pc.iceGatherer.GetLocalCandidates()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	dtlsFingerprints, err := pc.configuration.Certificates[0].GetFingerprints()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	base, err := pc.newJSEPSessionDescription(false)
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	d := t.session
+	d.Origin = base.Origin
+	d.Attributes = append([]sdp.Attribute{}, t.session.Attributes...)
+	d.MediaDescriptions = make([]*sdp.MediaDescription, 0, len(t.session.MediaDescriptions))
+
+	candidatesAdded := false
+	for i, templateMedia := range t.session.MediaDescriptions {
+		media := *templateMedia
+		media.Attributes = append([]sdp.Attribute{}, templateMedia.Attributes...)
+		media.WithICECredentials(iceParams.UsernameFragment, iceParams.Password)
+
+		if t.mediaLevelFingerprints {
+			for _, fingerprint := range dtlsFingerprints {
+				media.WithFingerprint(fingerprint.Algorithm, strings.ToUpper(fingerprint.Value))
+			}
+		}
+
+		if !t.sections[i].data {
+			transceiver := transceivers[i]
+			if sender := transceiver.Sender(); sender != nil {
+				sender.setNegotiated()
+			}
+			addSenderSDP(mediaSection{id: t.sections[i].mid, transceivers: []*RTPTransceiver{transceiver}}, false, &media)
+		}
+
+		if !candidatesAdded {
+			if err = addCandidatesToMediaDescriptions(candidates, &media, pc.ICEGatheringState()); err != nil {
+				return SessionDescription{}, err
+			}
+			candidatesAdded = true
+		}
+
+		d.MediaDescriptions = append(d.MediaDescriptions, &media)
+	}
+
+	if !t.mediaLevelFingerprints {
+		for _, fingerprint := range dtlsFingerprints {
+			d.WithFingerprint(fingerprint.Algorithm, strings.ToUpper(fingerprint.Value))
+		}
+	}
+
+	updateSDPOrigin(&pc.sdpOrigin, &d)
+	sdpBytes, err := d.Marshal()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	offer := SessionDescription{
+		Type:   SDPTypeOffer,
+		SDP:    string(sdpBytes),
+		parsed: &d,
+	}
+	pc.lastOffer = offer.SDP
+
+	return offer, nil
+}
+
+// assignMids checks that the transceivers and DataChannels of pc match the
+// template, and assigns the mids of the template to the transceivers.
+// pc.mu must be held.
+func (t *OfferTemplate) assignMids(pc *PeerConnection, transceivers []*RTPTransceiver) error {
+	pc.sctpTransport.lock.Lock()
+	dataChannelsRequested := pc.sctpTransport.dataChannelsRequested != 0
+	pc.sctpTransport.lock.Unlock()
+
+	sections := len(transceivers)
+	if dataChannelsRequested {
+		sections++
+	}
+	if sections != len(t.sections) {
+		return ErrOfferTemplateMismatch
+	}
+
+	for i, transceiver := range transceivers {
+		section := t.sections[i]
+		if section.data || transceiver.Kind() != section.kind || transceiver.Direction() != section.direction {
+			return ErrOfferTemplateMismatch
+		}
+		if mid := transceiver.Mid(); mid != "" && mid != section.mid {
+			return ErrOfferTemplateMismatch
+		}
+	}
+	if dataChannelsRequested && !t.sections[len(t.sections)-1].data {
+		return ErrOfferTemplateMismatch
+	}
+
+	for i, transceiver := range transceivers {
+		if transceiver.Mid() == "" {
+			if err := transceiver.SetMid(t.sections[i].mid); err != nil {
+				return err
+			}
+		}
+
+		if numericMid, err := strconv.Atoi(t.sections[i].mid); err == nil && numericMid > pc.greaterMid {
+			pc.greaterMid = numericMid
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// newOfferTemplateViewer returns a PeerConnection sending a video track and
+// with a DataChannel, like a viewer of a broadcast
+func newOfferTemplateViewer(t *testing.T) *PeerConnection {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "broadcast")
+	assert.NoError(t, err)
+	_, err = pc.AddTrack(track)
+	assert.NoError(t, err)
+
+	_, err = pc.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	return pc
+}
+
+func TestOfferTemplate(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	first := newOfferTemplateViewer(t)
+	template, err := NewOfferTemplate(first)
+	assert.NoError(t, err)
+
+	viewer := newOfferTemplateViewer(t)
+	offer, err := template.CreateOffer(viewer)
+	assert.NoError(t, err)
+
+	firstParams, err := first.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	viewerParams, err := viewer.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(offer.SDP, firstParams.UsernameFragment))
+	assert.True(t, strings.Contains(offer.SDP, viewerParams.UsernameFragment))
+
+	ssrc := viewer.GetSenders()[0].GetParameters().Encodings[0].SSRC
+	assert.True(t, strings.Contains(offer.SDP, fmt.Sprintf("a=ssrc:%d ", ssrc)))
+	assert.Equal(t, "0", viewer.GetTransceivers()[0].Mid())
+
+	answerer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, viewer, answerer)
+
+	gatherComplete := GatheringCompletePromise(viewer)
+	assert.NoError(t, viewer.SetLocalDescription(offer))
+	<-gatherComplete
+	assert.NoError(t, answerer.SetRemoteDescription(*viewer.LocalDescription()))
+
+	answer, err := answerer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	gatherComplete = GatheringCompletePromise(answerer)
+	assert.NoError(t, answerer.SetLocalDescription(answer))
+	<-gatherComplete
+	assert.NoError(t, viewer.SetRemoteDescription(*answerer.LocalDescription()))
+
+	connected.Wait()
+
+	_, err = template.CreateOffer(viewer)
+	assert.ErrorIs(t, err, ErrOfferTemplateMismatch)
+
+	assert.NoError(t, first.Close())
+	closePairNow(t, viewer, answerer)
+}
+
+func TestOfferTemplate_Mismatch(t *testing.T) {
+	first := newOfferTemplateViewer(t)
+	template, err := NewOfferTemplate(first)
+	assert.NoError(t, err)
+
+	viewer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	_, err = viewer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	_, err = template.CreateOffer(viewer)
+	assert.ErrorIs(t, err, ErrOfferTemplateMismatch)
+
+	assert.NoError(t, first.Close())
+	assert.NoError(t, viewer.Close())
+}
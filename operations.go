@@ -49,6 +49,13 @@ func (o *operations) IsEmpty() bool {
 	return o.ops.Len() == 0
 }
 
+// Len returns the number of operations waiting to be executed
+func (o *operations) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.ops.Len()
+}
+
 // Done blocks until all currently enqueued operations are finished executing.
 // For more complex synchronization, use Enqueue directly.
 func (o *operations) Done() {
@@ -5,6 +5,7 @@ package webrtc
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -32,3 +33,22 @@ func TestOperations_Done(*testing.T) {
 	ops := newOperations()
 	ops.Done()
 }
+
+func TestOperations_Len(t *testing.T) {
+	ops := newOperations()
+	assert.Equal(t, 0, ops.Len())
+
+	block := make(chan struct{})
+	ops.Enqueue(func() {
+		<-block
+	})
+	ops.Enqueue(func() {})
+	ops.Enqueue(func() {})
+
+	// The first operation was popped and is blocked
+	assert.Eventually(t, func() bool { return ops.Len() == 2 }, time.Second, time.Millisecond)
+
+	close(block)
+	ops.Done()
+	assert.Equal(t, 0, ops.Len())
+}
@@ -0,0 +1,354 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/packetio"
+)
+
+// PacketMirrorMode decides how much of the mirrored RTP packets is copied
+type PacketMirrorMode int
+
+const (
+	// PacketMirrorModeHeaders copies only the RTP headers, including their
+	// extensions, so no media leaves the PeerConnection. Packets whose header
+	// can't be parsed aren't mirrored. This is the default.
+	PacketMirrorModeHeaders PacketMirrorMode = iota
+
+	// PacketMirrorModeFull copies the whole packets
+	PacketMirrorModeFull
+)
+
+// This is done this way because of a linter.
+const (
+	packetMirrorModeHeadersStr = "headers"
+	packetMirrorModeFullStr    = "full"
+)
+
+func (m PacketMirrorMode) String() string {
+	switch m {
+	case PacketMirrorModeHeaders:
+		return packetMirrorModeHeadersStr
+	case PacketMirrorModeFull:
+		return packetMirrorModeFullStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// PacketMirrorPoint decides where RTP packets are mirrored
+type PacketMirrorPoint int
+
+const (
+	// PacketMirrorPointRTP mirrors the packets before they are encrypted and
+	// after they are decrypted. This is the default.
+	PacketMirrorPointRTP PacketMirrorPoint = iota
+
+	// PacketMirrorPointSRTP mirrors the packets as they are sent and received
+	// on the network, the payloads are encrypted
+	PacketMirrorPointSRTP
+)
+
+// This is done this way because of a linter.
+const (
+	packetMirrorPointRTPStr  = "rtp"
+	packetMirrorPointSRTPStr = "srtp"
+)
+
+func (p PacketMirrorPoint) String() string {
+	switch p {
+	case PacketMirrorPointRTP:
+		return packetMirrorPointRTPStr
+	case PacketMirrorPointSRTP:
+		return packetMirrorPointSRTPStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// MirroredPacket is a RTP packet passed to PacketMirror.Handler
+type MirroredPacket struct {
+	// Outgoing is true for the packets sent by the PeerConnection
+	Outgoing bool
+	Point    PacketMirrorPoint
+
+	// Data is the packet, or only its header with PacketMirrorModeHeaders. It
+	// is a copy owned by the handler.
+	Data []byte
+}
+
+// PacketMirror configures the mirroring of a sample of the RTP packets of the
+// PeerConnections to a troubleshooting tool, see SettingEngine.SetPacketMirror
+type PacketMirror struct {
+	Mode  PacketMirrorMode
+	Point PacketMirrorPoint
+
+	// Incoming and Outgoing select the packets that are mirrored, at least one
+	// of them must be set
+	Incoming bool
+	Outgoing bool
+
+	// Destination receives each mirrored packet as a UDP datagram, and Handler
+	// is invoked with each of them. At least one of them must be set. Handler
+	// is invoked on the goroutine sending or receiving the packet, so it must
+	// return quickly.
+	Destination *net.UDPAddr
+	Handler     func(MirroredPacket)
+
+	// SampleInterval mirrors one out of SampleInterval packets. Leave it 0 to
+	// mirror every packet.
+	SampleInterval uint32
+
+	// MaxPacketsPerSecond limits the packets mirrored by each PeerConnection.
+	// Leave it 0 for no limit.
+	MaxPacketsPerSecond int
+}
+
+// SetPacketMirror mirrors a sample of the RTP packets sent and received by
+// the PeerConnections for live troubleshooting. Mirroring is best effort,
+// packets which can't be mirrored are skipped without affecting the media.
+func (e *SettingEngine) SetPacketMirror(mirror PacketMirror) error {
+	if mirror.Destination == nil && mirror.Handler == nil {
+		return errPacketMirrorNoDestination
+	}
+
+	if !mirror.Incoming && !mirror.Outgoing {
+		return errPacketMirrorNoDirection
+	}
+
+	e.packetMirror = &mirror
+
+	return nil
+}
+
+// packetMirrorConn is the socket sending the mirrored packets to
+// PacketMirror.Destination
+type packetMirrorConn interface {
+	WriteTo(b []byte, addr net.Addr) (int, error)
+	Close() error
+}
+
+// packetMirror mirrors the packets of a DTLSTransport. All its methods can be
+// called on a nil *packetMirror, which mirrors nothing.
+type packetMirror struct {
+	config PacketMirror
+	conn   packetMirrorConn
+
+	sampled uint32 // atomic
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+
+	closeOnce sync.Once
+}
+
+func newPacketMirror(config PacketMirror, n transport.Net) (*packetMirror, error) {
+	m := &packetMirror{config: config}
+	if config.Destination == nil {
+		return m, nil
+	}
+
+	var err error
+	if n != nil {
+		m.conn, err = n.ListenUDP("udp", nil)
+	} else {
+		m.conn, err = net.ListenUDP("udp", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// sample returns if a packet sent or received at point is mirrored
+func (m *packetMirror) sample(outgoing bool, point PacketMirrorPoint) bool {
+	if m == nil || m.config.Point != point || (outgoing && !m.config.Outgoing) || (!outgoing && !m.config.Incoming) {
+		return false
+	}
+
+	if interval := m.config.SampleInterval; interval > 1 && (atomic.AddUint32(&m.sampled, 1)-1)%interval != 0 {
+		return false
+	}
+
+	if m.config.MaxPacketsPerSecond <= 0 {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now := time.Now(); now.Sub(m.windowStart) >= time.Second {
+		m.windowStart = now
+		m.windowCount = 0
+	}
+	if m.windowCount >= m.config.MaxPacketsPerSecond {
+		return false
+	}
+	m.windowCount++
+
+	return true
+}
+
+// mirror mirrors a sample of the marshaled RTP or SRTP packets
+func (m *packetMirror) mirror(outgoing bool, point PacketMirrorPoint, packet []byte) {
+	if !m.sample(outgoing, point) {
+		return
+	}
+
+	if m.config.Mode == PacketMirrorModeHeaders {
+		header := &rtp.Header{}
+		n, err := header.Unmarshal(packet)
+		if err != nil {
+			return
+		}
+		packet = packet[:n]
+	}
+
+	m.send(MirroredPacket{Outgoing: outgoing, Point: point, Data: append([]byte{}, packet...)})
+}
+
+// mirrorRTP mirrors a sample of the RTP packets sent with WriteRTP, it only
+// marshals the sampled packets
+func (m *packetMirror) mirrorRTP(header *rtp.Header, payload []byte) {
+	if !m.sample(true, PacketMirrorPointRTP) {
+		return
+	}
+
+	var data []byte
+	var err error
+	if m.config.Mode == PacketMirrorModeHeaders {
+		data, err = header.Marshal()
+	} else {
+		data, err = (&rtp.Packet{Header: *header, Payload: payload}).Marshal()
+	}
+	if err != nil {
+		return
+	}
+
+	m.send(MirroredPacket{Outgoing: true, Point: PacketMirrorPointRTP, Data: data})
+}
+
+func (m *packetMirror) send(packet MirroredPacket) {
+	if m.conn != nil {
+		// Mirroring is best effort, errors are ignored
+		_, _ = m.conn.WriteTo(packet.Data, m.config.Destination)
+	}
+
+	if m.config.Handler != nil {
+		m.config.Handler(packet)
+	}
+}
+
+func (m *packetMirror) close() error {
+	if m == nil || m.conn == nil {
+		return nil
+	}
+
+	var err error
+	m.closeOnce.Do(func() {
+		err = m.conn.Close()
+	})
+
+	return err
+}
+
+// bufferFactory wraps the read buffers of RTP streams created by factory, so
+// the decrypted packets written to them are mirrored
+func (m *packetMirror) bufferFactory(factory func(packetio.BufferPacketType, uint32) io.ReadWriteCloser) func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+	if m == nil || m.config.Point != PacketMirrorPointRTP || !m.config.Incoming {
+		return factory
+	}
+
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		var buffer io.ReadWriteCloser
+		if factory != nil {
+			buffer = factory(packetType, ssrc)
+		} else {
+			defaultBuffer := packetio.NewBuffer()
+			defaultBuffer.SetLimitSize(srtpBufferSize)
+			buffer = defaultBuffer
+		}
+
+		if packetType != packetio.RTPBufferPacket {
+			return buffer
+		}
+
+		return &mirroredSRTPBuffer{ReadWriteCloser: buffer, mirror: m}
+	}
+}
+
+// mirroredSRTPBuffer is the read buffer of a RTP stream mirroring the packets
+// written to it
+type mirroredSRTPBuffer struct {
+	io.ReadWriteCloser
+
+	mirror *packetMirror
+}
+
+func (b *mirroredSRTPBuffer) Write(packet []byte) (int, error) {
+	b.mirror.mirror(false, PacketMirrorPointRTP, packet)
+
+	return b.ReadWriteCloser.Write(packet)
+}
+
+func (b *mirroredSRTPBuffer) Size() int {
+	if sized, ok := b.ReadWriteCloser.(interface{ Size() int }); ok {
+		return sized.Size()
+	}
+
+	return 0
+}
+
+func (b *mirroredSRTPBuffer) SetReadDeadline(t time.Time) error {
+	if deadliner, ok := b.ReadWriteCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return deadliner.SetReadDeadline(t)
+	}
+
+	return nil
+}
+
+// mirroredSRTPConn is the connection of a SRTP session mirroring the packets
+// sent and received on it
+type mirroredSRTPConn struct {
+	net.Conn
+
+	mirror *packetMirror
+}
+
+func (c *mirroredSRTPConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err == nil {
+		c.mirror.mirror(false, PacketMirrorPointSRTP, b[:n])
+	}
+
+	return n, err
+}
+
+func (c *mirroredSRTPConn) Write(b []byte) (int, error) {
+	c.mirror.mirror(true, PacketMirrorPointSRTP, b)
+
+	return c.Conn.Write(b)
+}
+
+// srtpConn returns the connection of the SRTP session over conn
+func (m *packetMirror) srtpConn(conn net.Conn) net.Conn {
+	if m == nil || m.config.Point != PacketMirrorPointSRTP {
+		return conn
+	}
+
+	return &mirroredSRTPConn{Conn: conn, mirror: m}
+}
@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPacketMirrorMode_String(t *testing.T) {
+	testCases := []struct {
+		mode           PacketMirrorMode
+		expectedString string
+	}{
+		{PacketMirrorModeHeaders, "headers"},
+		{PacketMirrorModeFull, "full"},
+		{PacketMirrorMode(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.mode.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestPacketMirrorPoint_String(t *testing.T) {
+	testCases := []struct {
+		point          PacketMirrorPoint
+		expectedString string
+	}{
+		{PacketMirrorPointRTP, "rtp"},
+		{PacketMirrorPointSRTP, "srtp"},
+		{PacketMirrorPoint(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.point.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestSettingEngine_SetPacketMirror(t *testing.T) {
+	handler := func(MirroredPacket) {}
+
+	testCases := []struct {
+		mirror      PacketMirror
+		expectedErr error
+	}{
+		{PacketMirror{Outgoing: true}, errPacketMirrorNoDestination},
+		{PacketMirror{Handler: handler}, errPacketMirrorNoDirection},
+		{PacketMirror{Handler: handler, Incoming: true}, nil},
+		{PacketMirror{Destination: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}, Outgoing: true}, nil},
+	}
+
+	for i, testCase := range testCases {
+		s := SettingEngine{}
+		assert.Equal(t, testCase.expectedErr, s.SetPacketMirror(testCase.mirror), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedErr == nil, s.packetMirror != nil, "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestPacketMirror_Sample(t *testing.T) {
+	packet, err := (&rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: 5000, SequenceNumber: 1},
+		Payload: []byte{0xAA, 0xBB, 0xCC},
+	}).Marshal()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		config           PacketMirror
+		outgoing         bool
+		expectedMirrored int
+		expectedSize     int
+	}{
+		// Only the 12 bytes header is mirrored by default
+		{PacketMirror{Incoming: true}, false, 10, 12},
+		{PacketMirror{Incoming: true, Mode: PacketMirrorModeFull}, false, 10, 15},
+		{PacketMirror{Incoming: true}, true, 0, 0},
+		{PacketMirror{Outgoing: true, Point: PacketMirrorPointSRTP}, true, 0, 0},
+		{PacketMirror{Outgoing: true, SampleInterval: 3}, true, 4, 12},
+		{PacketMirror{Outgoing: true, MaxPacketsPerSecond: 2}, true, 2, 12},
+	}
+
+	for i, testCase := range testCases {
+		var mirrored []MirroredPacket
+		testCase.config.Handler = func(p MirroredPacket) {
+			mirrored = append(mirrored, p)
+		}
+
+		m, err := newPacketMirror(testCase.config, nil)
+		assert.NoError(t, err)
+		for j := 0; j < 10; j++ {
+			m.mirror(testCase.outgoing, PacketMirrorPointRTP, packet)
+		}
+
+		assert.Equal(t, testCase.expectedMirrored, len(mirrored), "testCase: %d %v", i, testCase)
+		for _, p := range mirrored {
+			assert.Equal(t, testCase.outgoing, p.Outgoing, "testCase: %d %v", i, testCase)
+			assert.Equal(t, testCase.expectedSize, len(p.Data), "testCase: %d %v", i, testCase)
+		}
+		assert.NoError(t, m.close())
+	}
+
+	// A nil packetMirror mirrors nothing
+	var m *packetMirror
+	m.mirror(true, PacketMirrorPointRTP, packet)
+	assert.NoError(t, m.close())
+}
+
+// Assert that the packets sent and received by PeerConnections are mirrored
+// to the Handler and the Destination
+func TestPeerConnection_PacketMirror(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	destination, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, destination.Close())
+	}()
+
+	var outgoingOnce, incomingOnce sync.Once
+	outgoingMirrored, incomingMirrored := make(chan struct{}), make(chan struct{})
+
+	offerSettings := SettingEngine{}
+	assert.NoError(t, offerSettings.SetPacketMirror(PacketMirror{
+		Point:    PacketMirrorPointSRTP,
+		Outgoing: true,
+		Handler: func(p MirroredPacket) {
+			if p.Outgoing && p.Point == PacketMirrorPointSRTP {
+				outgoingOnce.Do(func() { close(outgoingMirrored) })
+			}
+		},
+	}))
+
+	answerSettings := SettingEngine{}
+	assert.NoError(t, answerSettings.SetPacketMirror(PacketMirror{
+		Incoming:    true,
+		Destination: destination.LocalAddr().(*net.UDPAddr),
+		Handler: func(p MirroredPacket) {
+			if !p.Outgoing && p.Point == PacketMirrorPointRTP {
+				incomingOnce.Do(func() { close(incomingMirrored) })
+			}
+		},
+	}))
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, readErr := remote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	mirrored := make(chan struct{})
+	go func() {
+		defer close(mirrored)
+		<-outgoingMirrored
+		<-incomingMirrored
+	}()
+	sendVideoUntilDone(mirrored, t, []*TrackLocalStaticSample{track})
+
+	// Mirrored packets only hold the RTP header by default
+	buffer := make([]byte, 1500)
+	assert.NoError(t, destination.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := destination.Read(buffer)
+	assert.NoError(t, err)
+	header := &rtp.Header{}
+	headerSize, err := header.Unmarshal(buffer[:n])
+	assert.NoError(t, err)
+	assert.Equal(t, n, headerSize)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
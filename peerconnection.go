@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,6 +34,10 @@ import (
 // peer-to-peer communications with another PeerConnection instance in a
 // browser, or to another endpoint implementing the required protocols.
 type PeerConnection struct {
+	// goroutines is accessed atomically and first for 64-bit alignment on
+	// 32-bit platforms, see goTracked
+	goroutines int64
+
 	statsID string
 	mu      sync.RWMutex
 
@@ -57,7 +62,15 @@ type PeerConnection struct {
 
 	isClosed               *atomicBool
 	isNegotiationNeeded    *atomicBool
+	isICERestartRequested  *atomicBool
 	negotiationNeededState negotiationNeededState
+	negotiationNeededTimer *time.Timer
+
+	connectionQualityMonitor     *connectionQualityMonitor
+	iceCandidatePairStatsMonitor *iceCandidatePairStatsMonitor
+	networkChangeMonitor         *networkChangeMonitor
+	iceRestarter                 *iceRestarter
+	iceConsentMonitor            *iceConsentMonitor
 
 	lastOffer  string
 	lastAnswer string
@@ -70,12 +83,22 @@ type PeerConnection struct {
 
 	rtpTransceivers []*RTPTransceiver
 
-	onSignalingStateChangeHandler     func(SignalingState)
-	onICEConnectionStateChangeHandler atomic.Value // func(ICEConnectionState)
-	onConnectionStateChangeHandler    atomic.Value // func(PeerConnectionState)
-	onTrackHandler                    func(*TrackRemote, *RTPReceiver)
-	onDataChannelHandler              func(*DataChannel)
-	onNegotiationNeededHandler        atomic.Value // func()
+	onSignalingStateChangeHandler        func(SignalingState)
+	onICEConnectionStateChangeHandler    atomic.Value // func(ICEConnectionState)
+	onConnectionStateChangeHandler       atomic.Value // func(PeerConnectionState)
+	onTrackHandler                       func(*TrackRemote, *RTPReceiver)
+	onDataChannelHandler                 func(*DataChannel)
+	onNegotiationNeededHandler           atomic.Value // func()
+	onCodecNegotiationWarningHandler     atomic.Value // func(CodecNegotiationWarning)
+	onSelectedCandidatePairChangeHandler atomic.Value // func(SelectedCandidatePairChange)
+	onConnectionQualityHandler           atomic.Value // func(ConnectionQualityReport)
+	onICECandidatePairStatsHandler       atomic.Value // func([]ICECandidatePairStats)
+	onICEConsentExpiredHandler           atomic.Value // func(*ICECandidatePair)
+
+	onTrackRemoteBufferWatermarkHandler atomic.Value // func(TrackRemoteBufferWatermarkEvent)
+	onSRTPBufferDropHandler             atomic.Value // func(SRTPBufferDropEvent)
+
+	descriptionHistory []PeerConnectionSnapshotDescription
 
 	iceGatherer   *ICEGatherer
 	iceTransport  *ICETransport
@@ -110,13 +133,34 @@ func NewPeerConnection(configuration Configuration) (*PeerConnection, error) {
 	return api.NewPeerConnection(configuration)
 }
 
+// lastPeerConnectionStatsID is the number of the last stats ID of a
+// PeerConnection, see newPeerConnectionStatsID
+var lastPeerConnectionStatsID int64 // nolint:gochecknoglobals
+
+// newPeerConnectionStatsID returns a stats ID which is unique within the
+// process even for PeerConnections created within the same nanosecond. The
+// interceptors look up the SettingEngine and stats of their PeerConnection by
+// this ID.
+func newPeerConnectionStatsID() string {
+	for {
+		last := atomic.LoadInt64(&lastPeerConnectionStatsID)
+		next := time.Now().UnixNano()
+		if next <= last {
+			next = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastPeerConnectionStatsID, last, next) {
+			return fmt.Sprintf("PeerConnection-%d", next)
+		}
+	}
+}
+
 // NewPeerConnection creates a new PeerConnection with the provided configuration against the received API object
 func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection, error) {
 	// https://w3c.github.io/webrtc-pc/#constructor (Step #2)
 	// Some variables defined explicitly despite their implicit zero values to
 	// allow better readability to understand what is happening.
 	pc := &PeerConnection{
-		statsID: fmt.Sprintf("PeerConnection-%d", time.Now().UnixNano()),
+		statsID: newPeerConnectionStatsID(),
 		configuration: Configuration{
 			ICEServers:           []ICEServer{},
 			ICETransportPolicy:   ICETransportPolicyAll,
@@ -128,6 +172,7 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		ops:                    newOperations(),
 		isClosed:               &atomicBool{},
 		isNegotiationNeeded:    &atomicBool{},
+		isICERestartRequested:  &atomicBool{},
 		negotiationNeededState: negotiationNeededStateEmpty,
 		lastOffer:              "",
 		lastAnswer:             "",
@@ -140,7 +185,10 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	pc.iceConnectionState.Store(ICEConnectionStateNew)
 	pc.connectionState.Store(PeerConnectionStateNew)
 
-	i, err := api.interceptorRegistry.Build("")
+	interceptorSettingEngines.Store(pc.statsID, api.settingEngine)
+	i, err := api.interceptorRegistry.Build(pc.statsID)
+	interceptorSettingEngines.Delete(pc.statsID)
+	twccSender := takeTWCCSender(pc.statsID)
 	if err != nil {
 		return nil, err
 	}
@@ -148,6 +196,8 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	pc.api = &API{
 		settingEngine: api.settingEngine,
 		interceptor:   i,
+		twccSender:    twccSender,
+		registry:      api.registry,
 	}
 
 	if api.settingEngine.disableMediaEngineCopy {
@@ -165,6 +215,10 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		return nil, err
 	}
 
+	if policy := pc.api.settingEngine.iceRestartPolicy; policy != nil {
+		pc.iceRestarter = newICERestarter(pc, *policy)
+	}
+
 	// Create the ice transport
 	iceTransport := pc.createICETransport()
 	pc.iceTransport = iceTransport
@@ -190,6 +244,18 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	})
 
 	pc.interceptorRTCPWriter = pc.api.interceptor.BindRTCPWriter(interceptor.RTCPWriterFunc(pc.writeRTCP))
+	pc.api.registry.add(pc)
+
+	if pc.configuration.ICECandidatePoolSize != 0 {
+		if err = pc.iceGatherer.gatherPool(); err != nil {
+			pc.log.Warnf("Failed to gather ICE candidate pool: %s", err)
+		}
+	}
+
+	if pc.api.settingEngine.iceContinualGatheringInterval > 0 {
+		pc.networkChangeMonitor = newNetworkChangeMonitor(pc, pc.api.settingEngine.iceNet())
+		go pc.networkChangeMonitor.run()
+	}
 
 	return pc, nil
 }
@@ -268,7 +334,7 @@ func (pc *PeerConnection) onSignalingStateChange(newState SignalingState) {
 
 	pc.log.Infof("signaling state changed to %s", newState)
 	if handler != nil {
-		go handler(newState)
+		pc.goTracked(func() { handler(newState) })
 	}
 }
 
@@ -289,6 +355,13 @@ func (pc *PeerConnection) OnNegotiationNeeded(f func()) {
 // onNegotiationNeeded enqueues negotiationNeededOp if necessary
 // caller of this method should hold `pc.mu` lock
 func (pc *PeerConnection) onNegotiationNeeded() {
+	debounce := pc.api.settingEngine.negotiationNeededDebounce
+	// A change while the debounced check is pending restarts the timer
+	if debounce > 0 && pc.negotiationNeededTimer != nil {
+		pc.debounceNegotiationNeeded(debounce)
+		return
+	}
+
 	// https://w3c.github.io/webrtc-pc/#updating-the-negotiation-needed-flag
 	// non-canon step 1
 	if pc.negotiationNeededState == negotiationNeededStateRun {
@@ -298,12 +371,103 @@ func (pc *PeerConnection) onNegotiationNeeded() {
 		return
 	}
 	pc.negotiationNeededState = negotiationNeededStateRun
+
+	if debounce > 0 {
+		pc.debounceNegotiationNeeded(debounce)
+		return
+	}
 	pc.ops.Enqueue(pc.negotiationNeededOp)
 }
 
+// debounceNegotiationNeeded enqueues negotiationNeededOp once no change
+// happened for the debounce duration. Changes made while the timer is
+// pending restart it.
+// caller of this method should hold `pc.mu` lock
+func (pc *PeerConnection) debounceNegotiationNeeded(debounce time.Duration) {
+	if pc.negotiationNeededTimer != nil {
+		pc.negotiationNeededTimer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(debounce, func() {
+		pc.mu.Lock()
+		isPending := pc.negotiationNeededTimer == timer
+		if isPending {
+			pc.negotiationNeededTimer = nil
+		}
+		pc.mu.Unlock()
+
+		if isPending {
+			pc.ops.Enqueue(pc.negotiationNeededOp)
+		}
+	})
+	pc.negotiationNeededTimer = timer
+}
+
+// FlushNegotiationNeeded skips the remaining debounce delay configured with
+// SettingEngine.SetNegotiationNeededDebounce and blocks until the operations
+// queue drained, which includes the pending OnNegotiationNeeded check.
+// It must not be called from an OnNegotiationNeeded handler.
+func (pc *PeerConnection) FlushNegotiationNeeded() {
+	pc.mu.Lock()
+	if pc.negotiationNeededTimer != nil {
+		pc.negotiationNeededTimer.Stop()
+		pc.negotiationNeededTimer = nil
+		pc.ops.Enqueue(pc.negotiationNeededOp)
+	}
+	pc.mu.Unlock()
+
+	// negotiationNeededOp enqueues itself again while other operations are pending
+	for {
+		pc.ops.Done()
+		if pc.ops.IsEmpty() {
+			return
+		}
+	}
+}
+
+// PendingOperations returns the number of operations, like remote description
+// updates or negotiation needed checks, waiting in the queue of the
+// PeerConnection.
+func (pc *PeerConnection) PendingOperations() int {
+	return pc.ops.Len()
+}
+
+// WaitForOperations blocks until all the operations enqueued before the call
+// finished executing. It must not be called from a callback that runs as an
+// operation, like OnNegotiationNeeded.
+func (pc *PeerConnection) WaitForOperations() {
+	pc.ops.Done()
+}
+
+// setRemoteCodecPreferences sets the codec preferences of the transceiver
+// t created by the remote description to the codecs of media the
+// MediaEngine matches exactly
+func (pc *PeerConnection) setRemoteCodecPreferences(t *RTPTransceiver, media *sdp.MediaDescription, kind RTPCodecType) {
+	codecs, err := codecsFromMediaDescription(media)
+	if err != nil {
+		return
+	}
+
+	parsers := pc.api.mediaEngine.getFMTPParsers()
+	filteredCodecs := []RTPCodecParameters{}
+	for _, codec := range codecs {
+		if c, matchType := codecParametersFuzzySearch(parsers, codec, pc.api.mediaEngine.getCodecsByKind(kind)); matchType == codecMatchExact {
+			// if codec match exact, use payloadtype register to mediaengine
+			codec.PayloadType = c.PayloadType
+			filteredCodecs = append(filteredCodecs, codec)
+		}
+	}
+	t.setRemoteCodecPreferences(filteredCodecs)
+}
+
 func (pc *PeerConnection) negotiationNeededOp() {
 	// Don't run NegotiatedNeeded checks if OnNegotiationNeeded is not set
 	if handler, ok := pc.onNegotiationNeededHandler.Load().(func()); !ok || handler == nil {
+		// Let changes made once the handler is set run the checks
+		pc.mu.Lock()
+		pc.negotiationNeededState = negotiationNeededStateEmpty
+		pc.mu.Unlock()
 		return
 	}
 
@@ -367,6 +531,16 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 		return true
 	}
 
+	// https://www.w3.org/TR/webrtc/#dfn-check-if-negotiation-is-needed (step #3)
+	if pc.isICERestartRequested.get() {
+		return true
+	}
+
+	// non-canon, codecs were registered with RegisterCodec
+	if pc.api.mediaEngine.hasPendingCodecs() {
+		return true
+	}
+
 	pc.sctpTransport.lock.Lock()
 	lenDataChannel := len(pc.sctpTransport.dataChannels)
 	pc.sctpTransport.lock.Unlock()
@@ -389,7 +563,6 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 		if !t.stopped && m != nil {
 			// Step 5.3.1
 			if t.Direction() == RTPTransceiverDirectionSendrecv || t.Direction() == RTPTransceiverDirectionSendonly {
-				descMsid, okMsid := m.Attribute(sdp.AttrKeyMsid)
 				sender := t.Sender()
 				if sender == nil {
 					return true
@@ -403,7 +576,7 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 					// As calling replaceTrack does not require renegotiation, we skip check for this transceiver
 					continue
 				}
-				if !okMsid || descMsid != track.StreamID()+" "+track.ID() {
+				if !reflect.DeepEqual(msidValuesFromMediaDescription(m), msidValues(sender.StreamIDs(), track.ID())) {
 					return true
 				}
 			}
@@ -437,6 +610,40 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit
 	return false
 }
 
+// OnCodecNegotiationWarning sets an event handler which is invoked when a
+// remote media section is rejected because it shares no codec with the
+// MediaEngine. See SettingEngine.SetCodecNegotiationFailurePolicy
+func (pc *PeerConnection) OnCodecNegotiationWarning(f func(CodecNegotiationWarning)) {
+	pc.onCodecNegotiationWarningHandler.Store(f)
+}
+
+func (pc *PeerConnection) onCodecNegotiationWarning(warning CodecNegotiationWarning) {
+	pc.log.Warnf("Rejecting media section %q: %s", warning.Mid, warning.Err)
+	if handler, ok := pc.onCodecNegotiationWarningHandler.Load().(func(CodecNegotiationWarning)); ok && handler != nil {
+		pc.goTracked(func() { handler(warning) })
+	}
+}
+
+// codecNegotiationFailure returns a warning if the remote media section has to
+// be rejected because none of its codecs are supported, nil otherwise. It
+// always returns nil unless CodecNegotiationFailurePolicyReject is in use.
+func (pc *PeerConnection) codecNegotiationFailure(media *sdp.MediaDescription, kind RTPCodecType) *CodecNegotiationWarning {
+	if pc.api.settingEngine.codecNegotiationFailurePolicy != CodecNegotiationFailurePolicyReject ||
+		kind == 0 || (media.MediaName.Port.Value == 0 && !isBundleOnly(media)) {
+		return nil
+	}
+
+	codecs, err := codecsFromMediaDescription(media)
+	if err == nil {
+		if pc.api.mediaEngine.supportsAnyCodec(codecs, kind) {
+			return nil
+		}
+		err = ErrNoCommonCodec
+	}
+
+	return &CodecNegotiationWarning{Mid: getMidValue(media), Kind: kind, RemoteCodecs: codecs, Err: err}
+}
+
 // OnICECandidate sets an event handler which is invoked when a new ICE
 // candidate is found.
 // ICE candidate gathering only begins when SetLocalDescription or
@@ -479,7 +686,7 @@ func (pc *PeerConnection) onTrack(t *TrackRemote, r *RTPReceiver) {
 	pc.log.Debugf("got new track: %+v", t)
 	if t != nil {
 		if handler != nil {
-			go handler(t, r)
+			pc.goTracked(func() { handler(t, r) })
 		} else {
 			pc.log.Warnf("OnTrack unset, unable to handle incoming media streams")
 		}
@@ -510,17 +717,24 @@ func (pc *PeerConnection) onConnectionStateChange(cs PeerConnectionState) {
 	pc.connectionState.Store(cs)
 	pc.log.Infof("peer connection state changed: %s", cs)
 	if handler, ok := pc.onConnectionStateChangeHandler.Load().(func(PeerConnectionState)); ok && handler != nil {
-		go handler(cs)
+		pc.goTracked(func() { handler(cs) })
 	}
 }
 
 // SetConfiguration updates the configuration of this PeerConnection object.
+//
+// Updated ICEServers and ICETransportPolicy are handed to the ICEGatherer and
+// are used when it creates its ICE Agent, which happens on the first call to
+// CreateOffer, CreateAnswer or SetLocalDescription. An ICE Agent that has
+// already been created is replaced by one using them on the next ICE restart.
 func (pc *PeerConnection) SetConfiguration(configuration Configuration) error { //nolint:gocognit
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-setconfiguration (step #2)
 	if pc.isClosed.get() {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	poolSize, iceTransportPolicy, iceServers := pc.configuration.ICECandidatePoolSize, pc.configuration.ICETransportPolicy, pc.configuration.ICEServers
+
 	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #3)
 	if configuration.PeerIdentity != "" {
 		if configuration.PeerIdentity != pc.configuration.PeerIdentity {
@@ -572,15 +786,53 @@ func (pc *PeerConnection) SetConfiguration(configuration Configuration) error {
 	pc.configuration.ICETransportPolicy = configuration.ICETransportPolicy
 
 	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11)
-	if len(configuration.ICEServers) > 0 {
+	if sanitizedICEServers := configuration.getICEServers(); len(sanitizedICEServers) > 0 {
 		// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3)
-		for _, server := range configuration.ICEServers {
+		for _, server := range sanitizedICEServers {
 			if err := server.validate(); err != nil {
 				return err
 			}
 		}
-		pc.configuration.ICEServers = configuration.ICEServers
+		pc.configuration.ICEServers = sanitizedICEServers
+	}
+
+	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #12)
+	if err := pc.iceGatherer.setOptions(ICEGatherOptions{
+		ICEServers:      pc.configuration.ICEServers,
+		ICEGatherPolicy: pc.configuration.ICETransportPolicy,
+	}); err != nil {
+		return err
+	}
+
+	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #7.2)
+	// The ICE candidate pool is gathered again with the new configuration
+	if pc.configuration.ICECandidatePoolSize != 0 && pc.LocalDescription() == nil &&
+		(poolSize != pc.configuration.ICECandidatePoolSize || iceTransportPolicy != pc.configuration.ICETransportPolicy ||
+			!reflect.DeepEqual(iceServers, pc.configuration.ICEServers)) {
+		return pc.iceGatherer.gatherPool()
+	}
+	return nil
+}
+
+// RestartICE tells the PeerConnection that ICE should be restarted. The next
+// call to CreateOffer will generate an offer with new ICE credentials, as if
+// OfferOptions.ICERestart was set, and OnNegotiationNeeded is fired so the
+// application knows to start a new offer/answer exchange.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-restartice
+func (pc *PeerConnection) RestartICE() error {
+	if pc.isClosed.get() {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	// There are no credentials to replace until a local description has been set
+	if pc.LocalDescription() == nil {
+		return nil
 	}
+	pc.isICERestartRequested.set(true)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.onNegotiationNeeded()
 	return nil
 }
 
@@ -626,10 +878,11 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
-	if options != nil && options.ICERestart {
+	if (options != nil && options.ICERestart) || pc.isICERestartRequested.get() {
 		if err := pc.iceTransport.restart(); err != nil {
 			return SessionDescription{}, err
 		}
+		pc.isICERestartRequested.set(false)
 	}
 
 	var (
@@ -698,14 +951,22 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		if pc.currentRemoteDescription == nil {
 			d, err = pc.generateUnmatchedSDP(currentTransceivers, useIdentity)
 		} else {
-			d, err = pc.generateMatchedSDP(currentTransceivers, useIdentity, true /*includeUnmatched */, connectionRoleFromDtlsRole(defaultDtlsRoleOffer))
+			d, err = pc.generateMatchedSDP(currentTransceivers, useIdentity, true /*includeUnmatched */, pc.api.settingEngine.offeringConnectionRole())
 		}
 
 		if err != nil {
 			return SessionDescription{}, err
 		}
 
+		if pc.api.settingEngine.rtcpMuxOnly {
+			addRTCPMuxOnly(d)
+		}
+
 		updateSDPOrigin(&pc.sdpOrigin, d)
+		if err = pc.api.settingEngine.transformLocalDescription(SDPTypeOffer, d); err != nil {
+			return SessionDescription{}, err
+		}
+
 		sdpBytes, err := d.Marshal()
 		if err != nil {
 			return SessionDescription{}, err
@@ -813,7 +1074,9 @@ func (pc *PeerConnection) createICETransport() *ICETransport {
 		}
 		pc.onICEConnectionStateChange(cs)
 		pc.updateConnectionState(cs, pc.dtlsTransport.State())
+		pc.iceRestarter.onICEConnectionStateChange(cs)
 	})
+	t.internalOnSelectedCandidatePairChangeHandler.Store(pc.onSelectedCandidatePairChange)
 
 	return t
 }
@@ -834,7 +1097,9 @@ func (pc *PeerConnection) CreateAnswer(*AnswerOptions) (SessionDescription, erro
 	}
 
 	connectionRole := connectionRoleFromDtlsRole(pc.api.settingEngine.answeringDTLSRole)
-	if connectionRole == sdp.ConnectionRole(0) {
+	if forcedRole := pc.api.settingEngine.forcedDTLSRole; forcedRole == DTLSRoleClient || forcedRole == DTLSRoleServer {
+		connectionRole = connectionRoleFromDtlsRole(forcedRole)
+	} else if connectionRole == sdp.ConnectionRole(0) {
 		connectionRole = connectionRoleFromDtlsRole(defaultDtlsRoleAnswer)
 
 		// If one of the agents is lite and the other one is not, the lite agent must be the controlled agent.
@@ -853,6 +1118,10 @@ func (pc *PeerConnection) CreateAnswer(*AnswerOptions) (SessionDescription, erro
 	}
 
 	updateSDPOrigin(&pc.sdpOrigin, d)
+	if err = pc.api.settingEngine.transformLocalDescription(SDPTypeAnswer, d); err != nil {
+		return SessionDescription{}, err
+	}
+
 	sdpBytes, err := d.Marshal()
 	if err != nil {
 		return SessionDescription{}, err
@@ -970,6 +1239,7 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 	}()
 
 	if err == nil {
+		pc.recordDescription(sd, op == stateChangeOpSetLocal)
 		pc.signalingState.Set(nextState)
 		if pc.signalingState.Get() == SignalingStateStable {
 			pc.isNegotiationNeeded.set(false)
@@ -1030,6 +1300,7 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 	if pc.iceGatherer.State() == ICEGathererStateNew {
 		return pc.iceGatherer.Gather()
 	}
+	pc.iceGatherer.releasePool()
 	return nil
 }
 
@@ -1052,15 +1323,45 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 
 	isRenegotiation := pc.currentRemoteDescription != nil
 
+	if err := pc.api.settingEngine.sdpLimits.check(desc.SDP); err != nil {
+		return &rtcerr.InvalidAccessError{Err: err}
+	}
+
 	if _, err := desc.Unmarshal(); err != nil {
 		return err
 	}
+	if err := pc.api.settingEngine.transformRemoteDescription(&desc); err != nil {
+		return err
+	}
+	if pc.api.settingEngine.rtcpMuxOnly {
+		if err := checkRTCPMux(desc.parsed); err != nil {
+			return err
+		}
+	}
 	if err := pc.setDescription(&desc, stateChangeOpSetRemote); err != nil {
 		return err
 	}
 
-	if err := pc.api.mediaEngine.updateFromRemoteDescription(*desc.parsed); err != nil {
-		return err
+	weOffer := desc.Type == SDPTypeAnswer
+
+	// Media sections rejected for lack of a common codec must not take part
+	// in the codec negotiation of the MediaEngine
+	negotiable := *desc.parsed
+	rejectedMids := map[string]bool{}
+	if !weOffer {
+		negotiable.MediaDescriptions = nil
+		for _, media := range desc.parsed.MediaDescriptions {
+			if warning := pc.codecNegotiationFailure(media, NewRTPCodecType(media.MediaName.Media)); warning != nil {
+				rejectedMids[warning.Mid] = true
+				pc.onCodecNegotiationWarning(*warning)
+				continue
+			}
+			negotiable.MediaDescriptions = append(negotiable.MediaDescriptions, media)
+		}
+	}
+
+	if err := pc.api.mediaEngine.updateFromRemoteDescription(negotiable); err != nil {
+		return remapSDPError(err, negotiable.MediaDescriptions, desc.parsed.MediaDescriptions)
 	}
 
 	var t *RTPTransceiver
@@ -1070,13 +1371,11 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		detectedPlanB = descriptionPossiblyPlanB(pc.RemoteDescription())
 	}
 
-	weOffer := desc.Type == SDPTypeAnswer
-
 	if !weOffer && !detectedPlanB {
-		for _, media := range pc.RemoteDescription().parsed.MediaDescriptions {
+		for i, media := range pc.RemoteDescription().parsed.MediaDescriptions {
 			midValue := getMidValue(media)
 			if midValue == "" {
-				return errPeerConnRemoteDescriptionWithoutMidValue
+				return &SDPError{MediaIndex: i, Attribute: sdp.AttrKeyMID, Err: errPeerConnRemoteDescriptionWithoutMidValue}
 			}
 
 			if media.MediaName.Media == mediaSectionApplication {
@@ -1089,15 +1388,23 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				continue
 			}
 
+			// A rejected media section must not claim a transceiver the user added
+			isRejected := rejectedMids[midValue]
 			t, localTransceivers = findByMid(midValue, localTransceivers)
-			if t == nil {
+			if t == nil && !isRejected {
 				t, localTransceivers = satisfyTypeAndDirection(kind, direction, localTransceivers)
-			} else if direction == RTPTransceiverDirectionInactive {
+			} else if t != nil && direction == RTPTransceiverDirectionInactive {
 				if err := t.Stop(); err != nil {
 					return err
 				}
 			}
 
+			if t != nil && t.hasRemoteCodecPreferences() {
+				// Codecs registered with RegisterCodec since the remote
+				// created the transceiver are negotiated again
+				pc.setRemoteCodecPreferences(t, media, kind)
+			}
+
 			switch {
 			case t == nil:
 				receiver, err := pc.api.NewRTPReceiver(kind, pc.dtlsTransport)
@@ -1108,7 +1415,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				localDirection := RTPTransceiverDirectionRecvonly
 				if direction == RTPTransceiverDirectionRecvonly {
 					localDirection = RTPTransceiverDirectionSendonly
-				} else if direction == RTPTransceiverDirectionInactive {
+				} else if direction == RTPTransceiverDirectionInactive || isRejected {
 					localDirection = RTPTransceiverDirectionInactive
 				}
 
@@ -1118,17 +1425,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				pc.mu.Unlock()
 
 				// if transceiver is create by remote sdp, set prefer codec same as remote peer
-				if codecs, err := codecsFromMediaDescription(media); err == nil {
-					filteredCodecs := []RTPCodecParameters{}
-					for _, codec := range codecs {
-						if c, matchType := codecParametersFuzzySearch(codec, pc.api.mediaEngine.getCodecsByKind(kind)); matchType == codecMatchExact {
-							// if codec match exact, use payloadtype register to mediaengine
-							codec.PayloadType = c.PayloadType
-							filteredCodecs = append(filteredCodecs, codec)
-						}
-					}
-					_ = t.SetCodecPreferences(filteredCodecs)
-				}
+				pc.setRemoteCodecPreferences(t, media, kind)
 
 			case direction == RTPTransceiverDirectionRecvonly:
 				if t.Direction() == RTPTransceiverDirectionSendrecv {
@@ -1148,6 +1445,8 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		}
 	}
 
+	updateRemoteRIDRestrictions(desc.parsed, pc.GetTransceivers())
+
 	remoteUfrag, remotePwd, candidates, err := extractICEDetails(desc.parsed, pc.log)
 	if err != nil {
 		return err
@@ -1166,6 +1465,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		}
 	}
 
+	pc.preferCachedICECandidate(desc.parsed, candidates)
 	for i := range candidates {
 		if err = pc.iceTransport.AddRemoteCandidate(&candidates[i]); err != nil {
 			return err
@@ -1215,7 +1515,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 	}
 
 	pc.ops.Enqueue(func() {
-		pc.startTransports(iceRole, dtlsRoleFromRemoteSDP(desc.parsed), remoteUfrag, remotePwd, fingerprint, fingerprintHash)
+		pc.startTransports(iceRole, dtlsRoleFromRemoteSDP(desc.parsed), remoteUfrag, remotePwd, remoteIsLite, fingerprint, fingerprintHash)
 		if weOffer {
 			pc.startRTP(false, &desc, currentTransceivers)
 		}
@@ -1232,6 +1532,7 @@ func (pc *PeerConnection) configureReceiver(incoming trackDetails, receiver *RTP
 		receiver.tracks[i].track.mu.Lock()
 		receiver.tracks[i].track.id = incoming.id
 		receiver.tracks[i].track.streamID = incoming.streamID
+		receiver.tracks[i].track.streamIDs = incoming.streamIDs
 		receiver.tracks[i].track.mu.Unlock()
 	}
 }
@@ -1247,7 +1548,8 @@ func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPRece
 			return
 		}
 
-		go func(track *TrackRemote) {
+		track := t
+		pc.goTracked(func() {
 			b := make([]byte, pc.api.settingEngine.getReceiveMTU())
 			n, _, err := track.peek(b)
 			if err != nil {
@@ -1261,7 +1563,7 @@ func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPRece
 			}
 
 			pc.onTrack(track, receiver)
-		}(t)
+		})
 	}
 }
 
@@ -1364,12 +1666,14 @@ func (pc *PeerConnection) configureRTPReceivers(isRenegotiation bool, remoteDesc
 						if details := trackDetailsForRID(incomingTracks, mid, t.rid); details != nil {
 							t.id = details.id
 							t.streamID = details.streamID
+							t.streamIDs = details.streamIDs
 							continue
 						}
 					} else if t.ssrc != 0 {
 						if details := trackDetailsForSSRC(incomingTracks, t.ssrc); details != nil {
 							t.id = details.id
 							t.streamID = details.streamID
+							t.streamIDs = details.streamIDs
 							continue
 						}
 					}
@@ -1464,8 +1768,18 @@ func (pc *PeerConnection) startRTPReceivers(remoteDesc *SessionDescription, curr
 
 // startRTPSenders starts all outbound RTP streams
 func (pc *PeerConnection) startRTPSenders(currentTransceivers []*RTPTransceiver) error {
+	remoteDescription := pc.RemoteDescription()
 	for _, transceiver := range currentTransceivers {
 		if sender := transceiver.Sender(); sender != nil && sender.isNegotiated() && !sender.hasSent() {
+			if remoteDescription != nil && remoteDescription.parsed != nil {
+				media := getByMid(transceiver.Mid(), remoteDescription)
+				// The remote rejected the media section, no codec was negotiated
+				if media != nil && media.MediaName.Port.Value == 0 && !isBundleOnly(media) {
+					continue
+				}
+				sender.setRemotePacketTime(remotePacketTime(media))
+			}
+
 			err := sender.Send(sender.GetParameters())
 			if err != nil {
 				return err
@@ -1477,10 +1791,10 @@ func (pc *PeerConnection) startRTPSenders(currentTransceivers []*RTPTransceiver)
 }
 
 // Start SCTP subsystem
-func (pc *PeerConnection) startSCTP() {
+func (pc *PeerConnection) startSCTP(maxMessageSize uint32) {
 	// Start sctp
 	if err := pc.sctpTransport.Start(SCTPCapabilities{
-		MaxMessageSize: 0,
+		MaxMessageSize: maxMessageSize,
 	}); err != nil {
 		pc.log.Warnf("Failed to start SCTP: %s", err)
 		if err = pc.sctpTransport.Stop(); err != nil {
@@ -1501,6 +1815,7 @@ func (pc *PeerConnection) handleUndeclaredSSRC(ssrc SSRC, remoteDescription *Ses
 	id := ""
 	hasRidAttribute := false
 	hasSSRCAttribute := false
+	streamIDs := streamIDsFromMsidValues(msidValuesFromMediaDescription(onlyMediaSection))
 
 	for _, a := range onlyMediaSection.Attributes {
 		switch a.Key {
@@ -1523,10 +1838,11 @@ func (pc *PeerConnection) handleUndeclaredSSRC(ssrc SSRC, remoteDescription *Ses
 	}
 
 	incoming := trackDetails{
-		ssrcs:    []SSRC{ssrc},
-		kind:     RTPCodecTypeVideo,
-		streamID: streamID,
-		id:       id,
+		ssrcs:     []SSRC{ssrc},
+		kind:      RTPCodecTypeVideo,
+		streamID:  streamID,
+		streamIDs: streamIDs,
+		id:        id,
 	}
 	if onlyMediaSection.MediaName.Media == RTPCodecTypeAudio.String() {
 		incoming.kind = RTPCodecTypeAudio
@@ -1651,8 +1967,8 @@ func (pc *PeerConnection) handleIncomingSSRC(rtpStream io.Reader, ssrc SSRC) err
 
 // undeclaredMediaProcessor handles RTP/RTCP packets that don't match any a:ssrc lines
 func (pc *PeerConnection) undeclaredMediaProcessor() {
-	go pc.undeclaredRTPMediaProcessor()
-	go pc.undeclaredRTCPMediaProcessor()
+	pc.goTracked(pc.undeclaredRTPMediaProcessor)
+	pc.goTracked(pc.undeclaredRTCPMediaProcessor)
 }
 
 func (pc *PeerConnection) undeclaredRTPMediaProcessor() {
@@ -1685,12 +2001,13 @@ func (pc *PeerConnection) undeclaredRTPMediaProcessor() {
 			continue
 		}
 
-		go func(rtpStream io.Reader, ssrc SSRC) {
-			if err := pc.handleIncomingSSRC(rtpStream, ssrc); err != nil {
-				pc.log.Errorf(incomingUnhandledRTPSsrc, ssrc, err)
+		rtpStream, incomingSSRC := stream, SSRC(ssrc)
+		pc.goTracked(func() {
+			if err := pc.handleIncomingSSRC(rtpStream, incomingSSRC); err != nil {
+				pc.log.Errorf(incomingUnhandledRTPSsrc, incomingSSRC, err)
 			}
 			atomic.AddUint64(&simulcastRoutineCount, ^uint64(0))
-		}(stream, SSRC(ssrc))
+		})
 	}
 }
 
@@ -1732,6 +2049,24 @@ func (pc *PeerConnection) RemoteDescription() *SessionDescription {
 	return pc.currentRemoteDescription
 }
 
+// CanTrickleICECandidates reports whether the remote endpoint indicated
+// support for receiving trickled ICE candidates. Signaling layers can use this
+// to decide whether to wait for gathering to complete before sending a
+// description. ICETrickleCapabilityUnknown is returned until a remote
+// description has been set.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-cantrickleicecandidates
+func (pc *PeerConnection) CanTrickleICECandidates() ICETrickleCapability {
+	desc := pc.RemoteDescription()
+	if desc == nil || desc.parsed == nil {
+		return ICETrickleCapabilityUnknown
+	}
+
+	if isTrickleSet(desc.parsed) {
+		return ICETrickleCapabilitySupported
+	}
+	return ICETrickleCapabilityUnsupported
+}
+
 // AddICECandidate accepts an ICE candidate string and adds it
 // to the existing set of candidates.
 func (pc *PeerConnection) AddICECandidate(candidate ICECandidateInit) error {
@@ -1898,9 +2233,45 @@ func (pc *PeerConnection) newTransceiverFromTrack(direction RTPTransceiverDirect
 		s.trackEncodings[0].ssrc = init[0].SendEncodings[0].SSRC
 	}
 
+	if s != nil && len(init) == 1 && init[0].StreamIDs != nil {
+		s.SetStreams(init[0].StreamIDs...)
+	}
+
+	if s != nil && len(s.trackEncodings) == 1 &&
+		len(init) == 1 && len(init[0].SendEncodings) == 1 && init[0].SendEncodings[0].ScalabilityMode != "" {
+		if err = s.SetScalabilityMode("", init[0].SendEncodings[0].ScalabilityMode); err != nil {
+			return
+		}
+	}
+
 	return newRTPTransceiver(r, s, direction, track.Kind(), pc.api), nil
 }
 
+// RegisterCodec adds codec to the MediaEngine of the PeerConnection, so long
+// lived PeerConnections can use codecs deployed after they were created.
+// Codecs registered on the MediaEngine of the API aren't used by existing
+// PeerConnections, unless SettingEngine.DisableMediaEngineCopy is set. Once
+// negotiated, the codec is offered in the next negotiation, which
+// OnNegotiationNeeded fires for, and is used when the remote accepts it.
+func (pc *PeerConnection) RegisterCodec(codec RTPCodecParameters, typ RTPCodecType) error {
+	if pc.isClosed.get() {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	if err := pc.api.mediaEngine.RegisterCodec(codec, typ); err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.api.mediaEngine.hasPendingCodecs() {
+		pc.onNegotiationNeeded()
+	}
+
+	return nil
+}
+
 // AddTransceiverFromKind Create a new RtpTransceiver and adds it to the set of transceivers.
 func (pc *PeerConnection) AddTransceiverFromKind(kind RTPCodecType, init ...RTPTransceiverInit) (t *RTPTransceiver, err error) {
 	if pc.isClosed.get() {
@@ -1919,7 +2290,15 @@ func (pc *PeerConnection) AddTransceiverFromKind(kind RTPCodecType, init ...RTPT
 		if len(codecs) == 0 {
 			return nil, ErrNoCodecsAvailable
 		}
-		track, err := NewTrackLocalStaticSample(codecs[0].RTPCodecCapability, util.MathRandAlpha(16), util.MathRandAlpha(16))
+		trackID, err := pc.api.settingEngine.randomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		if err != nil {
+			return nil, err
+		}
+		streamID, err := pc.api.settingEngine.randomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		if err != nil {
+			return nil, err
+		}
+		track, err := NewTrackLocalStaticSample(codecs[0].RTPCodecCapability, trackID, streamID)
 		if err != nil {
 			return nil, err
 		}
@@ -2015,6 +2394,11 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		if options.Negotiated != nil {
 			params.Negotiated = *options.Negotiated
 		}
+
+		// https://w3c.github.io/webrtc-pc/#peer-to-peer-data-api (Step #13)
+		if options.Priority != nil {
+			params.Priority = *options.Priority
+		}
 	}
 
 	d, err := pc.api.newDataChannel(params, nil, pc.log)
@@ -2082,9 +2466,20 @@ func (pc *PeerConnection) Close() error {
 	closeErrs := make([]error, 4)
 
 	closeErrs = append(closeErrs, pc.api.interceptor.Close())
+	cleanupStats(pc.statsID)
+	pc.api.registry.remove(pc)
 
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #4)
 	pc.mu.Lock()
+	if pc.negotiationNeededTimer != nil {
+		pc.negotiationNeededTimer.Stop()
+		pc.negotiationNeededTimer = nil
+	}
+	pc.connectionQualityMonitor.close()
+	pc.iceCandidatePairStatsMonitor.close()
+	pc.networkChangeMonitor.close()
+	pc.iceRestarter.close()
+	pc.iceConsentMonitor.close()
 	for _, t := range pc.rtpTransceivers {
 		if !t.stopped {
 			closeErrs = append(closeErrs, t.Stop())
@@ -2122,6 +2517,11 @@ func (pc *PeerConnection) Close() error {
 // and fires onNegotiationNeeded;
 // caller of this method should hold `pc.mu` lock
 func (pc *PeerConnection) addRTPTransceiver(t *RTPTransceiver) {
+	t.setOnNegotiationNeeded(func() {
+		pc.mu.Lock()
+		defer pc.mu.Unlock()
+		pc.onNegotiationNeeded()
+	})
 	pc.rtpTransceivers = append(pc.rtpTransceivers, t)
 	pc.onNegotiationNeeded()
 }
@@ -2243,6 +2643,19 @@ func (pc *PeerConnection) GetStats() StatsReport {
 	}
 	pc.sctpTransport.collectStats(statsCollector)
 
+	var clockDriftSum float64
+	var clockDriftTracks int
+	for _, transceiver := range pc.rtpTransceivers {
+		if receiver := transceiver.Receiver(); receiver != nil {
+			for _, track := range receiver.Tracks() {
+				if ppm, ok := track.ClockDrift(); ok {
+					clockDriftSum += ppm
+					clockDriftTracks++
+				}
+			}
+		}
+	}
+
 	stats := PeerConnectionStats{
 		Timestamp:             statsTimestampNow(),
 		Type:                  StatsTypePeerConnection,
@@ -2252,6 +2665,9 @@ func (pc *PeerConnection) GetStats() StatsReport {
 		DataChannelsOpened:    dataChannelsOpened,
 		DataChannelsRequested: dataChannelsRequested,
 	}
+	if clockDriftTracks > 0 {
+		stats.ClockDriftPPM = clockDriftSum / float64(clockDriftTracks)
+	}
 
 	statsCollector.Collect(stats.ID, stats)
 
@@ -2261,22 +2677,32 @@ func (pc *PeerConnection) GetStats() StatsReport {
 			continue
 		}
 	}
+
+	statsGetter, _ := lookupStats(pc.statsID)
+	for _, transceiver := range pc.rtpTransceivers {
+		if sender := transceiver.Sender(); sender != nil {
+			sender.collectStats(statsCollector, statsGetter)
+		}
+		if receiver := transceiver.Receiver(); receiver != nil {
+			receiver.collectStats(statsCollector, statsGetter)
+		}
+	}
 	pc.mu.Unlock()
 
-	pc.api.mediaEngine.collectStats(statsCollector)
+	pc.api.mediaEngine.collectStats(statsCollector, pc.iceTransport.getStatsID())
 
 	return statsCollector.Ready()
 }
 
 // Start all transports. PeerConnection now has enough state
-func (pc *PeerConnection) startTransports(iceRole ICERole, dtlsRole DTLSRole, remoteUfrag, remotePwd, fingerprint, fingerprintHash string) {
+func (pc *PeerConnection) startTransports(iceRole ICERole, dtlsRole DTLSRole, remoteUfrag, remotePwd string, remoteIsLite bool, fingerprint, fingerprintHash string) {
 	// Start the ice transport
 	err := pc.iceTransport.Start(
 		pc.iceGatherer,
 		ICEParameters{
 			UsernameFragment: remoteUfrag,
 			Password:         remotePwd,
-			ICELite:          false,
+			ICELite:          remoteIsLite,
 		},
 		&iceRole,
 	)
@@ -2295,6 +2721,9 @@ func (pc *PeerConnection) startTransports(iceRole ICERole, dtlsRole DTLSRole, re
 		}()
 	}
 
+	pc.dtlsTransport.internalOnBufferWatermarkHandler = pc.onTrackRemoteBufferWatermark
+	pc.dtlsTransport.internalOnBufferDropHandler = pc.onSRTPBufferDrop
+
 	// Start the dtls transport
 	err = pc.dtlsTransport.Start(DTLSParameters{
 		Role:         dtlsRole,
@@ -2305,6 +2734,8 @@ func (pc *PeerConnection) startTransports(iceRole ICERole, dtlsRole DTLSRole, re
 		pc.log.Warnf("Failed to start manager: %s", err)
 		return
 	}
+
+	pc.cacheSelectedICECandidatePair(fingerprintHash, fingerprint)
 }
 
 // nolint: gocognit
@@ -2315,14 +2746,14 @@ func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDesc
 
 	pc.startRTPReceivers(remoteDesc, currentTransceivers)
 	if haveApplicationMediaSection(remoteDesc.parsed) {
-		pc.startSCTP()
+		pc.startSCTP(getMaxMessageSize(remoteDesc.parsed))
 	}
 }
 
 // generateUnmatchedSDP generates an SDP that doesn't take remote state into account
 // This is used for the initial call for CreateOffer
 func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, useIdentity bool) (*sdp.SessionDescription, error) {
-	d, err := sdp.NewJSEPSessionDescription(useIdentity)
+	d, err := pc.newJSEPSessionDescription(useIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -2380,6 +2811,8 @@ func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, u
 		if pc.sctpTransport.dataChannelsRequested != 0 {
 			mediaSections = append(mediaSections, mediaSection{id: strconv.Itoa(len(mediaSections)), data: true})
 		}
+
+		markBundleOnlyMediaSections(pc.configuration.BundlePolicy, mediaSections)
 	}
 
 	dtlsFingerprints, err := pc.configuration.Certificates[0].GetFingerprints()
@@ -2387,14 +2820,14 @@ func (pc *PeerConnection) generateUnmatchedSDP(transceivers []*RTPTransceiver, u
 		return nil, err
 	}
 
-	return populateSDP(d, isPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, true, pc.api.mediaEngine, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), candidates, iceParams, mediaSections, pc.ICEGatheringState(), nil)
+	return populateSDP(d, isPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, true, pc.api.mediaEngine, pc.api.settingEngine.offeringConnectionRole(), candidates, iceParams, mediaSections, pc.ICEGatheringState(), nil, pc.api.settingEngine.getSCTPMaxMessageSize())
 }
 
 // generateMatchedSDP generates a SDP and takes the remote state into account
 // this is used everytime we have a RemoteDescription
 // nolint: gocyclo
 func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, useIdentity bool, includeUnmatched bool, connectionRole sdp.ConnectionRole) (*sdp.SessionDescription, error) { //nolint:gocognit
-	d, err := sdp.NewJSEPSessionDescription(useIdentity)
+	d, err := pc.newJSEPSessionDescription(useIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -2477,11 +2910,15 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 			if t == nil {
 				return nil, fmt.Errorf("%w: %q", errPeerConnTranscieverMidNil, midValue)
 			}
+			if t.isRejected() || pc.codecNegotiationFailure(media, kind) != nil {
+				mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: []*RTPTransceiver{t}, rejected: true})
+				continue
+			}
 			if sender := t.Sender(); sender != nil {
 				sender.setNegotiated()
 			}
 			mediaTransceivers := []*RTPTransceiver{t}
-			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers, ridMap: getRids(media)})
+			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers, rids: getRids(media)})
 		}
 	}
 
@@ -2505,9 +2942,12 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 			}
 		}
 	} else if remoteDescription != nil {
-		groupValue, _ := remoteDescription.parsed.Attribute(sdp.AttrKeyGroup)
+		groupValue, haveGroup := remoteDescription.parsed.Attribute(sdp.AttrKeyGroup)
 		groupValue = strings.TrimLeft(groupValue, "BUNDLE")
 		bundleGroup = &groupValue
+		if !haveGroup {
+			acceptUnbundledMediaSections(mediaSections, remoteDescription.parsed)
+		}
 	}
 
 	if pc.configuration.SDPSemantics == SDPSemanticsUnifiedPlanWithFallback && detectedPlanB {
@@ -2519,7 +2959,7 @@ func (pc *PeerConnection) generateMatchedSDP(transceivers []*RTPTransceiver, use
 		return nil, err
 	}
 
-	return populateSDP(d, detectedPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, isExtmapAllowMixed, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState(), bundleGroup)
+	return populateSDP(d, detectedPlanB, dtlsFingerprints, pc.api.settingEngine.sdpMediaLevelFingerprints, pc.api.settingEngine.candidates.ICELite, isExtmapAllowMixed, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState(), bundleGroup, pc.api.settingEngine.getSCTPMaxMessageSize())
 }
 
 func (pc *PeerConnection) setGatherCompleteHandler(handler func()) {
@@ -284,6 +284,35 @@ func TestPeerConnection_SetConfiguration_Go(t *testing.T) {
 	}
 }
 
+// Assert that ICEServers and ICETransportPolicy set after construction
+// are used by the ICEGatherer
+func TestPeerConnection_SetConfiguration_ICEServers(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	assert.Empty(t, pc.iceGatherer.validatedServers)
+
+	assert.NoError(t, pc.SetConfiguration(Configuration{
+		ICEServers:         []ICEServer{{URLs: []string{"stun:stun.l.google.com:19302?transport=udp"}}},
+		ICETransportPolicy: ICETransportPolicyRelay,
+	}))
+
+	assert.Equal(t, "stun:stun.l.google.com:19302", pc.GetConfiguration().ICEServers[0].URLs[0])
+	assert.Len(t, pc.iceGatherer.validatedServers, 1)
+	assert.Equal(t, "stun.l.google.com", pc.iceGatherer.validatedServers[0].Host)
+	assert.Equal(t, ICETransportPolicyRelay, pc.iceGatherer.gatherPolicy)
+
+	// Invalid servers leave the gatherer untouched
+	assert.Error(t, pc.SetConfiguration(Configuration{
+		ICEServers: []ICEServer{{URLs: []string{"turn:pion.ly"}}},
+	}))
+	assert.Len(t, pc.iceGatherer.validatedServers, 1)
+
+	assert.NoError(t, pc.Close())
+}
+
 func TestPeerConnection_EventHandlers_Go(t *testing.T) {
 	lim := test.TimeOut(time.Second * 5)
 	defer lim.Stop()
@@ -657,6 +686,48 @@ func TestOnICEGatheringStateChange(t *testing.T) {
 	assert.NoError(t, peerConn.Close())
 }
 
+// Assert that candidates are gathered ahead of time with an ICE candidate
+// pool, and only emitted after SetLocalDescription
+func TestPeerConnection_ICECandidatePool(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	s.SetIncludeLoopbackCandidate(true)
+
+	pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{ICECandidatePoolSize: 1})
+	assert.NoError(t, err)
+
+	candidatesEmitted := make(chan *ICECandidate, 64)
+	pc.OnICECandidate(func(c *ICECandidate) {
+		candidatesEmitted <- c
+	})
+
+	<-GatheringCompletePromise(pc)
+	assert.Len(t, candidatesEmitted, 0)
+
+	// The offer needs a media section to carry the candidates
+	_, err = pc.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=candidate:")
+
+	assert.NoError(t, pc.SetLocalDescription(offer))
+	for c := range candidatesEmitted {
+		if c == nil {
+			break
+		}
+	}
+
+	assert.NoError(t, pc.Close())
+}
+
 // Assert Trickle ICE behaviors
 func TestPeerConnectionTrickle(t *testing.T) {
 	offerPC, answerPC, err := newPair()
@@ -751,6 +822,38 @@ func TestPeerConnectionTrickle(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+func TestPeerConnection_CanTrickleICECandidates(t *testing.T) {
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = offerPC.CreateDataChannel("test-channel", nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ICETrickleCapabilityUnknown, answerPC.CanTrickleICECandidates())
+
+	offer, err := offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	// The trickle option may be listed at the session or the media level
+	for _, testCase := range []struct {
+		sdp        string
+		capability ICETrickleCapability
+	}{
+		{offer.SDP, ICETrickleCapabilityUnsupported},
+		{strings.Replace(offer.SDP, "t=0 0\r\n", "t=0 0\r\na=ice-options:trickle\r\n", 1), ICETrickleCapabilitySupported},
+		{strings.Replace(offer.SDP, "a=mid:0\r\n", "a=mid:0\r\na=ice-options:ice2 trickle\r\n", 1), ICETrickleCapabilitySupported},
+	} {
+		pc, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		assert.NoError(t, pc.SetRemoteDescription(SessionDescription{Type: SDPTypeOffer, SDP: testCase.sdp}))
+		assert.Equal(t, testCase.capability, pc.CanTrickleICECandidates())
+		assert.NoError(t, pc.Close())
+	}
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 // Issue #1121, assert populateLocalCandidates doesn't mutate
 func TestPopulateLocalCandidates(t *testing.T) {
 	t.Run("PendingLocalDescription shouldn't add extra mutations", func(t *testing.T) {
@@ -895,6 +998,137 @@ func TestICERestart(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+// Assert that ICE servers set with SetConfiguration are used after an ICE
+// restart, and that the connection keeps working over the new ICE Agent
+func TestICERestart_SetConfiguration(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	var connectedWaitGroup sync.WaitGroup
+	connectedWaitGroup.Add(2)
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		pc.OnICEConnectionStateChange(func(state ICEConnectionState) {
+			if state == ICEConnectionStateConnected {
+				connectedWaitGroup.Done()
+			}
+		})
+	}
+
+	messages := make(chan string, 1)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			messages <- string(msg.Data)
+		})
+	})
+
+	dataChannelOpened := make(chan struct{})
+	dataChannel, err := offerPC.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	dataChannel.OnOpen(func() {
+		close(dataChannelOpened)
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connectedWaitGroup.Wait()
+	<-dataChannelOpened
+
+	firstAgent := offerPC.iceGatherer.getAgent()
+	assert.False(t, offerPC.iceGatherer.haveOptionsChanged())
+
+	configuration := offerPC.GetConfiguration()
+	configuration.ICEServers = []ICEServer{{URLs: []string{"stun:127.0.0.1:3478"}}}
+	assert.NoError(t, offerPC.SetConfiguration(configuration))
+	assert.True(t, offerPC.iceGatherer.haveOptionsChanged())
+
+	offerPC.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			assert.NoError(t, answerPC.AddICECandidate(c.ToJSON()))
+		}
+	})
+	answerPC.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			assert.NoError(t, offerPC.AddICECandidate(c.ToJSON()))
+		}
+	})
+
+	connectedWaitGroup.Add(2)
+	offer, err := offerPC.CreateOffer(&OfferOptions{ICERestart: true})
+	assert.NoError(t, err)
+
+	// The ICE Agent was replaced to use the new ICE servers
+	assert.NotEqual(t, firstAgent, offerPC.iceGatherer.getAgent())
+	assert.False(t, offerPC.iceGatherer.haveOptionsChanged())
+
+	assert.NoError(t, offerPC.SetLocalDescription(offer))
+	assert.NoError(t, answerPC.SetRemoteDescription(offer))
+
+	answer, err := answerPC.CreateAnswer(nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, answerPC.SetLocalDescription(answer))
+	assert.NoError(t, offerPC.SetRemoteDescription(answer))
+
+	connectedWaitGroup.Wait()
+
+	assert.NoError(t, dataChannel.SendText("after restart"))
+	assert.Equal(t, "after restart", <-messages)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+// Assert that RestartICE fires OnNegotiationNeeded and that the
+// next offer carries new ICE credentials
+func TestPeerConnection_RestartICE(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	// RestartICE before any local description is a no-op
+	assert.NoError(t, offerPC.RestartICE())
+	assert.False(t, offerPC.isICERestartRequested.get())
+
+	restartCalled := &atomicBool{}
+	negotiationNeeded := make(chan struct{}, 1)
+	offerPC.OnNegotiationNeeded(func() {
+		if restartCalled.get() {
+			negotiationNeeded <- struct{}{}
+		}
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	firstParams, err := offerPC.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+
+	restartCalled.set(true)
+	assert.NoError(t, offerPC.RestartICE())
+	<-negotiationNeeded
+
+	_, err = offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	secondParams, err := offerPC.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstParams.UsernameFragment, secondParams.UsernameFragment)
+	assert.NotEqual(t, firstParams.Password, secondParams.Password)
+
+	// The restart request is consumed by CreateOffer
+	assert.False(t, offerPC.isICERestartRequested.get())
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 // Assert error handling when an Agent is restart
 func TestICERestart_Error_Handling(t *testing.T) {
 	iceStates := make(chan ICEConnectionState, 100)
@@ -1445,10 +1679,10 @@ a=fmtp:125 level-asymmetry-allowed=1;packetization-mode=0;profile-level-id=42e01
 		assert.NoError(t, pc.SetLocalDescription(ans))
 		codecOfTr1 := pc.GetTransceivers()[0].getCodecs()[0]
 		codecs := pc.api.mediaEngine.getCodecsByKind(RTPCodecTypeVideo)
-		_, matchType := codecParametersFuzzySearch(codecOfTr1, codecs)
+		_, matchType := codecParametersFuzzySearch(nil, codecOfTr1, codecs)
 		assert.Equal(t, codecMatchExact, matchType)
 		codecOfTr2 := pc.GetTransceivers()[1].getCodecs()[0]
-		_, matchType = codecParametersFuzzySearch(codecOfTr2, codecs)
+		_, matchType = codecParametersFuzzySearch(nil, codecOfTr2, codecs)
 		assert.Equal(t, codecMatchExact, matchType)
 		assert.EqualValues(t, 94, codecOfTr2.PayloadType)
 		assert.NoError(t, pc.Close())
@@ -1503,10 +1737,10 @@ a=sendonly
 		assert.NoError(t, pc.SetLocalDescription(ans))
 		codecOfTr1 := pc.GetTransceivers()[0].getCodecs()[0]
 		codecs := pc.api.mediaEngine.getCodecsByKind(RTPCodecTypeVideo)
-		_, matchType := codecParametersFuzzySearch(codecOfTr1, codecs)
+		_, matchType := codecParametersFuzzySearch(nil, codecOfTr1, codecs)
 		assert.Equal(t, codecMatchExact, matchType)
 		codecOfTr2 := pc.GetTransceivers()[1].getCodecs()[0]
-		_, matchType = codecParametersFuzzySearch(codecOfTr2, codecs)
+		_, matchType = codecParametersFuzzySearch(nil, codecOfTr2, codecs)
 		assert.Equal(t, codecMatchExact, matchType)
 		// h.264/profile-id=640032 should be remap to 106 as same as transceiver 1
 		assert.EqualValues(t, 106, codecOfTr2.PayloadType)
@@ -1571,6 +1805,56 @@ a=ssrc:1455629982 cname:{61fd3093-0326-4b12-8258-86bdc1fe677a}
 	assert.NoError(t, peerConnection.Close())
 }
 
+func TestOfferWithSimulcast(t *testing.T) {
+	const remoteSDP = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+a=fingerprint:sha-256 F7:BF:B4:42:5B:44:C0:B9:49:70:6D:26:D7:3E:E6:08:B1:5B:25:2E:32:88:50:B6:3C:BE:4E:18:A7:2C:85:7C
+a=group:BUNDLE 0
+m=video 9 UDP/TLS/RTP/SAVPF 96 97
+c=IN IP4 0.0.0.0
+a=sendonly
+a=ice-pwd:05d682b2902af03db90d9a9a5f2f8d7f
+a=ice-ufrag:93cc7e4d
+a=mid:0
+a=rtpmap:96 VP8/90000
+a=rtpmap:97 unknown/90000
+a=setup:actpass
+a=extmap:1 urn:ietf:params:rtp-hdrext:sdes:mid
+a=extmap:2 urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id
+a=rid:q send pt=96;max-width=320
+a=rid:h send
+a=rid:f send
+a=rid:unknown send pt=97
+a=simulcast:send f;h;~q;unknown
+`
+
+	peerConnection, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, peerConnection.SetRemoteDescription(SessionDescription{Type: SDPTypeOffer, SDP: remoteSDP}))
+
+	answer, err := peerConnection.CreateAnswer(nil)
+	assert.NoError(t, err)
+
+	// rids are answered in order with their restrictions, the one without a supported codec is removed
+	assert.Contains(t, answer.SDP, "a=rid:f recv\r\na=rid:h recv\r\na=rid:q recv pt=96;max-width=320\r\na=simulcast:recv f;h;~q\r\n")
+	assert.NotContains(t, answer.SDP, "unknown")
+
+	// The offer has no msid, the receive encodings are still set up per rid
+	incomingTracks := trackDetailsFromSDP(peerConnection.log, peerConnection.RemoteDescription().parsed)
+	assert.Len(t, incomingTracks, 1)
+	assert.Equal(t, RTPReceiveParameters{Encodings: []RTPDecodingParameters{
+		{RTPCodingParameters{RID: "f"}},
+		{RTPCodingParameters{RID: "h"}},
+		{RTPCodingParameters{RID: "q"}},
+		{RTPCodingParameters{RID: "unknown"}},
+	}}, trackDetailsToRTPReceiveParameters(&incomingTracks[0]))
+
+	assert.NoError(t, peerConnection.Close())
+}
+
 func TestPeerConnectionState(t *testing.T) {
 	pc, err := NewPeerConnection(Configuration{})
 	assert.NoError(t, err)
@@ -1606,3 +1890,12 @@ func TestPeerConnectionState(t *testing.T) {
 	assert.NoError(t, pc.Close())
 	assert.Equal(t, PeerConnectionStateClosed, pc.ConnectionState())
 }
+
+func TestNewPeerConnectionStatsID(t *testing.T) {
+	ids := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := newPeerConnectionStatsID()
+		assert.False(t, ids[id], id)
+		ids[id] = true
+	}
+}
@@ -230,6 +230,19 @@ func (pc *PeerConnection) SetConfiguration(configuration Configuration) (err err
 	return nil
 }
 
+// RestartICE tells the PeerConnection that ICE should be restarted. The next
+// call to CreateOffer will generate an offer with new ICE credentials.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-restartice
+func (pc *PeerConnection) RestartICE() (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = recoveryToError(e)
+		}
+	}()
+	pc.underlying.Call("restartIce")
+	return nil
+}
+
 // GetConfiguration returns a Configuration object representing the current
 // configuration of this PeerConnection object. The returned object is a
 // copy and direct mutation on it will not take affect until SetConfiguration
@@ -483,6 +496,20 @@ func (pc *PeerConnection) ConnectionState() PeerConnectionState {
 	return newPeerConnectionState(rawState)
 }
 
+// CanTrickleICECandidates reports whether the remote endpoint indicated
+// support for receiving trickled ICE candidates.
+func (pc *PeerConnection) CanTrickleICECandidates() ICETrickleCapability {
+	canTrickle := pc.underlying.Get("canTrickleIceCandidates")
+	switch {
+	case canTrickle.IsNull() || canTrickle.IsUndefined():
+		return ICETrickleCapabilityUnknown
+	case canTrickle.Bool():
+		return ICETrickleCapabilitySupported
+	default:
+		return ICETrickleCapabilityUnsupported
+	}
+}
+
 func (pc *PeerConnection) setGatherCompleteHandler(handler func()) {
 	pc.onGatherCompleteHandler = handler
 
@@ -753,6 +780,11 @@ func dataChannelInitToValue(options *DataChannelInit) js.Value {
 		return js.Undefined()
 	}
 
+	priority := js.Undefined()
+	if options.Priority != nil {
+		priority = js.ValueOf(options.Priority.String())
+	}
+
 	maxPacketLifeTime := uint16PointerToValue(options.MaxPacketLifeTime)
 	return js.ValueOf(map[string]interface{}{
 		"ordered":           boolPointerToValue(options.Ordered),
@@ -764,6 +796,7 @@ func dataChannelInitToValue(options *DataChannelInit) js.Value {
 		"protocol":          stringPointerToValue(options.Protocol),
 		"negotiated":        boolPointerToValue(options.Negotiated),
 		"id":                uint16PointerToValue(options.ID),
+		"priority":          priority,
 	})
 }
 
@@ -1157,6 +1157,86 @@ func TestPeerConnection_CreateOffer_NoCodecs(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+// Assert that a media section without a common codec is rejected on its own
+// when CodecNegotiationFailurePolicyReject is in use
+func TestPeerConnection_CodecNegotiationFailurePolicyReject(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerMediaEngine := &MediaEngine{}
+	assert.NoError(t, offerMediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}, RTPCodecTypeVideo))
+	assert.NoError(t, offerMediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP9, ClockRate: 90000},
+		PayloadType:        98,
+	}, RTPCodecTypeVideo))
+
+	answerMediaEngine := &MediaEngine{}
+	assert.NoError(t, answerMediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}, RTPCodecTypeVideo))
+
+	s := SettingEngine{}
+	s.SetCodecNegotiationFailurePolicy(CodecNegotiationFailurePolicyReject)
+
+	pcOffer, err := NewAPI(WithMediaEngine(offerMediaEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithMediaEngine(answerMediaEngine), WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	vp9Transceiver, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	assert.NoError(t, vp9Transceiver.SetCodecPreferences([]RTPCodecParameters{{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP9, ClockRate: 90000},
+		PayloadType:        98,
+	}}))
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcAnswer.AddTrack(track)
+	assert.NoError(t, err)
+
+	warnings := make(chan CodecNegotiationWarning, 1)
+	pcAnswer.OnCodecNegotiationWarning(func(w CodecNegotiationWarning) {
+		warnings <- w
+	})
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	warning := <-warnings
+	assert.Equal(t, "1", warning.Mid)
+	assert.Equal(t, RTPCodecTypeVideo, warning.Kind)
+	assert.ErrorIs(t, warning.Err, ErrNoCommonCodec)
+	assert.Equal(t, MimeTypeVP9, warning.RemoteCodecs[0].MimeType)
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+
+	parsed, err := answer.Unmarshal()
+	assert.NoError(t, err)
+	assert.Len(t, parsed.MediaDescriptions, 2)
+	assert.NotEqual(t, 0, parsed.MediaDescriptions[0].MediaName.Port.Value)
+	assert.Equal(t, 0, parsed.MediaDescriptions[1].MediaName.Port.Value)
+	assert.Equal(t, "1", getMidValue(parsed.MediaDescriptions[1]))
+	assert.Equal(t, RTPTransceiverDirectionSendrecv, getPeerDirection(parsed.MediaDescriptions[0]))
+
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 // Assert that AddTrack is thread-safe
 func TestPeerConnection_RaceReplaceTrack(t *testing.T) {
 	pc, err := NewPeerConnection(Configuration{})
@@ -878,6 +878,80 @@ func TestNegotiationCreateDataChannel(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+// Assert that debounced OnNegotiationNeeded coalesces changes
+func TestNegotiationNeededDebounce(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newDebouncedPeerConnection := func(debounce time.Duration) (*PeerConnection, chan struct{}) {
+		s := SettingEngine{}
+		s.SetNegotiationNeededDebounce(debounce)
+
+		pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		negotiationNeeded := make(chan struct{}, 10)
+		pc.OnNegotiationNeeded(func() {
+			negotiationNeeded <- struct{}{}
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+			assert.NoError(t, err)
+		}
+		_, err = pc.CreateDataChannel("testChannel", nil)
+		assert.NoError(t, err)
+
+		return pc, negotiationNeeded
+	}
+
+	t.Run("Flush", func(t *testing.T) {
+		pc, negotiationNeeded := newDebouncedPeerConnection(time.Hour)
+
+		pc.WaitForOperations()
+		assert.Equal(t, 0, pc.PendingOperations())
+		assert.Len(t, negotiationNeeded, 0)
+
+		pc.FlushNegotiationNeeded()
+		assert.Len(t, negotiationNeeded, 1)
+
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Timer", func(t *testing.T) {
+		pc, negotiationNeeded := newDebouncedPeerConnection(time.Millisecond * 50)
+
+		<-negotiationNeeded
+		time.Sleep(time.Millisecond * 100)
+		pc.WaitForOperations()
+		assert.Len(t, negotiationNeeded, 0)
+
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		pc, negotiationNeeded := newDebouncedPeerConnection(time.Millisecond * 200)
+
+		// Every change restarts the timer, so the check runs 200ms after the
+		// last one
+		for i := 0; i < 5; i++ {
+			time.Sleep(time.Millisecond * 100)
+			_, err := pc.AddTransceiverFromKind(RTPCodecTypeAudio)
+			assert.NoError(t, err)
+		}
+		assert.Len(t, negotiationNeeded, 0)
+
+		<-negotiationNeeded
+		pc.WaitForOperations()
+		assert.Len(t, negotiationNeeded, 0)
+
+		assert.NoError(t, pc.Close())
+	})
+}
+
 func TestNegotiationNeededRemoveTrack(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
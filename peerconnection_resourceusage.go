@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/transport/v3/packetio"
+)
+
+// PeerConnectionResourceUsage is the resources held by a PeerConnection, as
+// returned by PeerConnection.ResourceUsage. It helps finding the session
+// responsible for a leak without a heap dump.
+type PeerConnectionResourceUsage struct {
+	// Goroutines is the number of goroutines started by the PeerConnection
+	// which are still running: the processors of undeclared media, the event
+	// handlers, including the OnTrack handlers which usually read the track
+	// until it ends, and the connection quality monitor. The goroutines of
+	// the ICE, DTLS and SCTP transports and of DataChannels aren't counted.
+	Goroutines int
+
+	// ReceiveBufferedBytes is the size of the packets in the read buffers of
+	// the SRTP and SRTCP streams which weren't read yet. Buffers created by
+	// SettingEngine.BufferFactory are only measured if they have a Size method.
+	ReceiveBufferedBytes int
+
+	// SendBufferedBytes is the sum of the BufferedAmount of the DataChannels
+	SendBufferedBytes uint64
+
+	// RTPReadStreams and RTCPReadStreams are the number of open SRTP and
+	// SRTCP read streams, one per remote SSRC
+	RTPReadStreams  int
+	RTCPReadStreams int
+
+	// RTPSendStreams is the number of encodings of the RTPSenders which are
+	// sending
+	RTPSendStreams int
+
+	// DataChannels is the number of DataChannels which aren't closed
+	DataChannels int
+
+	// PendingTimers is the number of timers and tickers of the PeerConnection
+	// which are pending, like the debounce of OnNegotiationNeeded
+	PendingTimers int
+}
+
+// ResourceUsage returns the resources held by the PeerConnection
+func (pc *PeerConnection) ResourceUsage() PeerConnectionResourceUsage {
+	usage := PeerConnectionResourceUsage{
+		Goroutines: int(atomic.LoadInt64(&pc.goroutines)),
+	}
+
+	usage.RTPReadStreams, usage.RTCPReadStreams, usage.ReceiveBufferedBytes = pc.dtlsTransport.srtpBuffers.usage()
+
+	for _, transceiver := range pc.GetTransceivers() {
+		sender := transceiver.Sender()
+		if sender == nil || !sender.hasSent() || sender.hasStopped() {
+			continue
+		}
+
+		sender.mu.RLock()
+		usage.RTPSendStreams += len(sender.trackEncodings)
+		sender.mu.RUnlock()
+	}
+
+	pc.sctpTransport.lock.RLock()
+	dataChannels := append([]*DataChannel{}, pc.sctpTransport.dataChannels...)
+	pc.sctpTransport.lock.RUnlock()
+	for _, d := range dataChannels {
+		if d.ReadyState() == DataChannelStateClosed {
+			continue
+		}
+
+		usage.DataChannels++
+		usage.SendBufferedBytes += d.BufferedAmount()
+	}
+
+	pc.mu.RLock()
+	if pc.negotiationNeededTimer != nil {
+		usage.PendingTimers++
+	}
+	if m := pc.connectionQualityMonitor; m != nil {
+		select {
+		case <-m.done:
+		default:
+			// The ticker of the monitor
+			usage.PendingTimers++
+		}
+	}
+	pc.mu.RUnlock()
+
+	return usage
+}
+
+// goTracked runs f in a goroutine which is counted by ResourceUsage
+func (pc *PeerConnection) goTracked(f func()) {
+	atomic.AddInt64(&pc.goroutines, 1)
+	go func() {
+		defer atomic.AddInt64(&pc.goroutines, -1)
+		f()
+	}()
+}
+
+// srtpBufferTracker keeps the read buffers of the SRTP and SRTCP streams of
+// a DTLSTransport, so their usage can be reported
+type srtpBufferTracker struct {
+	mu      sync.Mutex
+	buffers map[*trackedSRTPBuffer]struct{}
+}
+
+// factory returns a BufferFactory tracking the buffers created by factory,
+// or the default buffers of SRTP if factory is nil
+func (t *srtpBufferTracker) factory(factory func(packetio.BufferPacketType, uint32) io.ReadWriteCloser) func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		var buffer io.ReadWriteCloser
+		if factory != nil {
+			buffer = factory(packetType, ssrc)
+		} else {
+			buffer = newDefaultSRTPBuffer(packetType)
+		}
+
+		tracked := &trackedSRTPBuffer{ReadWriteCloser: buffer, packetType: packetType, tracker: t}
+
+		t.mu.Lock()
+		if t.buffers == nil {
+			t.buffers = map[*trackedSRTPBuffer]struct{}{}
+		}
+		t.buffers[tracked] = struct{}{}
+		t.mu.Unlock()
+
+		return tracked
+	}
+}
+
+func (t *srtpBufferTracker) remove(buffer *trackedSRTPBuffer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.buffers, buffer)
+}
+
+// usage returns the number of open RTP and RTCP buffers and the bytes they
+// hold
+func (t *srtpBufferTracker) usage() (rtpBuffers, rtcpBuffers, bufferedBytes int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for buffer := range t.buffers {
+		if buffer.packetType == packetio.RTCPBufferPacket {
+			rtcpBuffers++
+		} else {
+			rtpBuffers++
+		}
+
+		if sized, ok := buffer.ReadWriteCloser.(interface{ Size() int }); ok {
+			bufferedBytes += sized.Size()
+		}
+	}
+
+	return rtpBuffers, rtcpBuffers, bufferedBytes
+}
+
+// trackedSRTPBuffer is a read buffer of a SRTP or SRTCP stream, which is
+// forgotten by its srtpBufferTracker once closed
+type trackedSRTPBuffer struct {
+	io.ReadWriteCloser
+
+	packetType packetio.BufferPacketType
+	tracker    *srtpBufferTracker
+	closeOnce  sync.Once
+}
+
+func (b *trackedSRTPBuffer) SetReadDeadline(t time.Time) error {
+	if deadliner, ok := b.ReadWriteCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return deadliner.SetReadDeadline(t)
+	}
+
+	return nil
+}
+
+func (b *trackedSRTPBuffer) Close() error {
+	b.closeOnce.Do(func() {
+		b.tracker.remove(b)
+	})
+
+	return b.ReadWriteCloser.Close()
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/packetio"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerConnection_ResourceUsage(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	assert.Equal(t, PeerConnectionResourceUsage{}, pcOffer.ResourceUsage())
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	_, err = pcOffer.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	onTrackFired, onTrackFiredFunc := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		onTrackFiredFunc()
+		for {
+			if _, _, readErr := remote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(onTrackFired.Done(), t, []*TrackLocalStaticSample{track})
+
+	offerUsage := pcOffer.ResourceUsage()
+	assert.Equal(t, 1, offerUsage.RTPSendStreams)
+	assert.Equal(t, 2, offerUsage.DataChannels, "signalPair creates a DataChannel too")
+
+	answerUsage := pcAnswer.ResourceUsage()
+	assert.Equal(t, 0, answerUsage.RTPSendStreams)
+	assert.GreaterOrEqual(t, answerUsage.RTPReadStreams, 1)
+	assert.GreaterOrEqual(t, answerUsage.Goroutines, 1, "the OnTrack handler is running")
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	assert.Eventually(t, func() bool {
+		usage := pcAnswer.ResourceUsage()
+		return usage.Goroutines == 0 && usage.RTPReadStreams == 0 && usage.RTCPReadStreams == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSRTPBufferTracker(t *testing.T) {
+	tracker := &srtpBufferTracker{}
+	factory := tracker.factory(nil)
+
+	rtpBuffer := factory(packetio.RTPBufferPacket, 1)
+	rtcpBuffer := factory(packetio.RTCPBufferPacket, 1)
+
+	_, err := rtpBuffer.Write(make([]byte, 98))
+	assert.NoError(t, err)
+
+	rtpBuffers, rtcpBuffers, bufferedBytes := tracker.usage()
+	assert.Equal(t, 1, rtpBuffers)
+	assert.Equal(t, 1, rtcpBuffers)
+	assert.Equal(t, 100, bufferedBytes)
+
+	// RTCP buffers keep the smaller limit of pion/srtp
+	packet := make([]byte, 60000)
+	_, err = rtcpBuffer.Write(packet)
+	assert.NoError(t, err)
+	_, err = rtcpBuffer.Write(packet)
+	assert.ErrorIs(t, err, packetio.ErrFull)
+	_, err = rtpBuffer.Write(packet)
+	assert.NoError(t, err)
+	_, err = rtpBuffer.Write(packet)
+	assert.NoError(t, err)
+
+	assert.NoError(t, rtpBuffer.Close())
+	assert.NoError(t, rtcpBuffer.Close())
+
+	rtpBuffers, rtcpBuffers, bufferedBytes = tracker.usage()
+	assert.Equal(t, 0, rtpBuffers)
+	assert.Equal(t, 0, rtcpBuffers)
+	assert.Equal(t, 0, bufferedBytes)
+}
@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pion/sdp/v3"
+)
+
+// snapshotDescriptionHistorySize is how many descriptions a PeerConnection
+// keeps for its snapshots
+const snapshotDescriptionHistorySize = 32
+
+// PeerConnectionSnapshotDescription is a description set on a PeerConnection
+type PeerConnectionSnapshotDescription struct {
+	Local       bool               `json:"local"`
+	SetAt       time.Time          `json:"setAt"`
+	Description SessionDescription `json:"description"`
+}
+
+// RTPReceiveTrackSnapshot is a track received by a RTPReceiver
+type RTPReceiveTrackSnapshot struct {
+	ID       string `json:"id"`
+	StreamID string `json:"streamId"`
+	RID      string `json:"rid,omitempty"`
+	SSRC     SSRC   `json:"ssrc"`
+	RtxSSRC  SSRC   `json:"rtxSsrc,omitempty"`
+}
+
+// RTPTransceiverSnapshot is the negotiated state of a RTPTransceiver
+type RTPTransceiverSnapshot struct {
+	Mid              string `json:"mid"`
+	Kind             string `json:"kind"`
+	Direction        string `json:"direction"`
+	CurrentDirection string `json:"currentDirection"`
+
+	Codecs           []RTPCodecParameters          `json:"codecs"`
+	HeaderExtensions []RTPHeaderExtensionParameter `json:"headerExtensions"`
+
+	SendTrackID   string                  `json:"sendTrackId,omitempty"`
+	SendEncodings []RTPEncodingParameters `json:"sendEncodings,omitempty"`
+
+	ReceiveTracks []RTPReceiveTrackSnapshot `json:"receiveTracks,omitempty"`
+}
+
+// PeerConnectionSnapshot is the negotiated state of a PeerConnection, see
+// PeerConnection.Snapshot. It is meant to be attached to bug reports, and to
+// be loaded with API.NewPeerConnectionFromSnapshot to reproduce issues.
+type PeerConnectionSnapshot struct {
+	TakenAt            time.Time `json:"takenAt"`
+	SignalingState     string    `json:"signalingState"`
+	ICEConnectionState string    `json:"iceConnectionState"`
+	ConnectionState    string    `json:"connectionState"`
+
+	// Descriptions are the last descriptions set, oldest first
+	Descriptions []PeerConnectionSnapshotDescription `json:"descriptions"`
+
+	CurrentLocalDescription  *SessionDescription `json:"currentLocalDescription,omitempty"`
+	PendingLocalDescription  *SessionDescription `json:"pendingLocalDescription,omitempty"`
+	CurrentRemoteDescription *SessionDescription `json:"currentRemoteDescription,omitempty"`
+	PendingRemoteDescription *SessionDescription `json:"pendingRemoteDescription,omitempty"`
+
+	Transceivers []RTPTransceiverSnapshot `json:"transceivers"`
+}
+
+// Snapshot returns the negotiated state of the PeerConnection: the last
+// descriptions set on it, and the codecs, header extensions and SSRCs of its
+// transceivers.
+func (pc *PeerConnection) Snapshot() PeerConnectionSnapshot {
+	pc.mu.RLock()
+	descriptions := append([]PeerConnectionSnapshotDescription{}, pc.descriptionHistory...)
+	pc.mu.RUnlock()
+
+	snapshot := PeerConnectionSnapshot{
+		TakenAt:                  time.Now(),
+		SignalingState:           pc.SignalingState().String(),
+		ICEConnectionState:       pc.ICEConnectionState().String(),
+		ConnectionState:          pc.ConnectionState().String(),
+		Descriptions:             descriptions,
+		CurrentLocalDescription:  pc.CurrentLocalDescription(),
+		PendingLocalDescription:  pc.PendingLocalDescription(),
+		CurrentRemoteDescription: pc.CurrentRemoteDescription(),
+		PendingRemoteDescription: pc.PendingRemoteDescription(),
+	}
+
+	for _, t := range pc.GetTransceivers() {
+		transceiver := RTPTransceiverSnapshot{
+			Mid:              t.Mid(),
+			Kind:             t.Kind().String(),
+			Direction:        t.Direction().String(),
+			CurrentDirection: t.getCurrentDirection().String(),
+			Codecs:           t.getCodecs(),
+		}
+
+		if sender := t.Sender(); sender != nil {
+			parameters := sender.GetParameters()
+			transceiver.HeaderExtensions = parameters.HeaderExtensions
+			transceiver.SendEncodings = parameters.Encodings
+			if track := sender.Track(); track != nil {
+				transceiver.SendTrackID = track.ID()
+			}
+		}
+
+		if receiver := t.Receiver(); receiver != nil {
+			if transceiver.HeaderExtensions == nil {
+				transceiver.HeaderExtensions = receiver.GetParameters().HeaderExtensions
+			}
+			for _, track := range receiver.Tracks() {
+				transceiver.ReceiveTracks = append(transceiver.ReceiveTracks, RTPReceiveTrackSnapshot{
+					ID:       track.ID(),
+					StreamID: track.StreamID(),
+					RID:      track.RID(),
+					SSRC:     track.SSRC(),
+					RtxSSRC:  track.RtxSSRC(),
+				})
+			}
+		}
+
+		snapshot.Transceivers = append(snapshot.Transceivers, transceiver)
+	}
+
+	return snapshot
+}
+
+// recordDescription adds a description which was set to the history of the
+// snapshots
+func (pc *PeerConnection) recordDescription(desc *SessionDescription, local bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.descriptionHistory = append(pc.descriptionHistory, PeerConnectionSnapshotDescription{
+		Local:       local,
+		SetAt:       time.Now(),
+		Description: SessionDescription{Type: desc.Type, SDP: desc.SDP},
+	})
+	if len(pc.descriptionHistory) > snapshotDescriptionHistorySize {
+		pc.descriptionHistory = pc.descriptionHistory[len(pc.descriptionHistory)-snapshotDescriptionHistorySize:]
+	}
+}
+
+// WriteFile writes the snapshot to the file path as JSON
+func (s PeerConnectionSnapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0o600)
+}
+
+// ReadPeerConnectionSnapshot reads a snapshot written by
+// PeerConnectionSnapshot.WriteFile
+func ReadPeerConnectionSnapshot(path string) (*PeerConnectionSnapshot, error) {
+	data, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &PeerConnectionSnapshot{}
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// NewPeerConnectionFromSnapshot creates a PeerConnection with the negotiated
+// state of snapshot, by setting its descriptions in order, for offline
+// debugging. The candidates are removed from the descriptions, so the
+// PeerConnection never connects. The MediaEngine of the API has to support
+// the codecs of the snapshot, like the one of the PeerConnection the
+// snapshot was taken of.
+func (api *API) NewPeerConnectionFromSnapshot(snapshot *PeerConnectionSnapshot) (*PeerConnection, error) {
+	pc, err := api.NewPeerConnection(Configuration{})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, description := range snapshot.Descriptions {
+		if err = pc.replaySnapshotDescription(description); err != nil {
+			_ = pc.Close()
+			return nil, fmt.Errorf("description %d: %w", i, err)
+		}
+	}
+
+	return pc, nil
+}
+
+// replaySnapshotDescription sets a description of a snapshot. The
+// PeerConnection didn't create the local descriptions, so the transceivers of
+// local offers are created first, and local descriptions are restored as if
+// they were the last ones created, which SetLocalDescription requires.
+func (pc *PeerConnection) replaySnapshotDescription(description PeerConnectionSnapshotDescription) error {
+	desc := SessionDescription{Type: description.Description.Type, SDP: removeSDPCandidates(description.Description.SDP)}
+
+	if !description.Local {
+		return pc.SetRemoteDescription(desc)
+	}
+
+	if desc.Type == SDPTypeOffer {
+		parsed, err := desc.Unmarshal()
+		if err != nil {
+			return err
+		}
+		if err = pc.addSnapshotTransceivers(parsed); err != nil {
+			return err
+		}
+	}
+
+	pc.mu.Lock()
+	if desc.Type == SDPTypeOffer {
+		pc.lastOffer = desc.SDP
+	} else {
+		pc.lastAnswer = desc.SDP
+	}
+	pc.mu.Unlock()
+
+	return pc.SetLocalDescription(desc)
+}
+
+// addSnapshotTransceivers adds a transceiver for every media section of a
+// local offer without one
+func (pc *PeerConnection) addSnapshotTransceivers(parsed *sdp.SessionDescription) error {
+	for _, media := range parsed.MediaDescriptions {
+		kind := NewRTPCodecType(media.MediaName.Media)
+		mid := getMidValue(media)
+		if kind == RTPCodecType(0) || mid == "" {
+			continue
+		}
+		if t, _ := findByMid(mid, pc.GetTransceivers()); t != nil {
+			continue
+		}
+
+		direction := RTPTransceiverDirectionRecvonly
+		if d := getPeerDirection(media); d == RTPTransceiverDirectionSendrecv || d == RTPTransceiverDirectionSendonly {
+			direction = d
+		}
+
+		t, err := pc.AddTransceiverFromKind(kind, RTPTransceiverInit{Direction: direction})
+		if err != nil {
+			return err
+		}
+		if err = t.SetMid(mid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeSDPCandidates removes the candidate lines of a description
+func removeSDPCandidates(raw string) string {
+	lines := strings.SplitAfter(raw, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "a=candidate:") || strings.HasPrefix(line, "a=end-of-candidates") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "")
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerConnection_Snapshot(t *testing.T) {
+	offerer, answerer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = offerer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	_, err = offerer.AddTransceiverFromKind(RTPCodecTypeAudio, RTPTransceiverInit{Direction: RTPTransceiverDirectionRecvonly})
+	assert.NoError(t, err)
+
+	offer, err := offerer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, offerer.SetLocalDescription(offer))
+	assert.NoError(t, answerer.SetRemoteDescription(*offerer.LocalDescription()))
+
+	answer, err := answerer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, answerer.SetLocalDescription(answer))
+	assert.NoError(t, offerer.SetRemoteDescription(*answerer.LocalDescription()))
+
+	snapshot := offerer.Snapshot()
+	assert.Equal(t, SignalingStateStable.String(), snapshot.SignalingState)
+	if assert.Len(t, snapshot.Descriptions, 2) {
+		assert.True(t, snapshot.Descriptions[0].Local)
+		assert.Equal(t, SDPTypeOffer, snapshot.Descriptions[0].Description.Type)
+		assert.False(t, snapshot.Descriptions[1].Local)
+		assert.Equal(t, SDPTypeAnswer, snapshot.Descriptions[1].Description.Type)
+	}
+	if assert.Len(t, snapshot.Transceivers, 2) {
+		assert.Equal(t, "0", snapshot.Transceivers[0].Mid)
+		assert.Equal(t, RTPCodecTypeVideo.String(), snapshot.Transceivers[0].Kind)
+		assert.NotEmpty(t, snapshot.Transceivers[0].Codecs)
+		assert.Len(t, snapshot.Transceivers[0].SendEncodings, 1)
+		assert.Equal(t, RTPTransceiverDirectionRecvonly.String(), snapshot.Transceivers[1].Direction)
+	}
+
+	closePairNow(t, offerer, answerer)
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+
+	path := filepath.Join(dir, "snapshot.json")
+	assert.NoError(t, snapshot.WriteFile(path))
+
+	loaded, err := ReadPeerConnectionSnapshot(path)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Descriptions[0].Description, loaded.Descriptions[0].Description)
+
+	mock, err := NewAPI().NewPeerConnectionFromSnapshot(loaded)
+	require.NoError(t, err)
+	assert.Equal(t, SignalingStateStable, mock.SignalingState())
+
+	transceivers := mock.GetTransceivers()
+	if assert.Len(t, transceivers, 2) {
+		assert.Equal(t, "0", transceivers[0].Mid())
+		assert.Equal(t, RTPCodecTypeVideo, transceivers[0].Kind())
+		assert.Equal(t, "1", transceivers[1].Mid())
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, transceivers[1].Direction())
+	}
+
+	assert.NoError(t, mock.Close())
+}
+
+func TestRemoveSDPCandidates(t *testing.T) {
+	assert.Equal(t,
+		"v=0\r\na=mid:0\r\n",
+		removeSDPCandidates("v=0\r\na=candidate:1 1 udp 2130706431 192.0.2.1 5000 typ host\r\na=mid:0\r\na=end-of-candidates\r\n"),
+	)
+}
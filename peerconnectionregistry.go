@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4/internal/util"
+)
+
+// PeerConnectionInfo is a snapshot of a PeerConnection created by an API.
+// It allows long-running services to audit their sessions.
+type PeerConnectionInfo struct {
+	// PeerConnection is the PeerConnection described by this snapshot
+	PeerConnection *PeerConnection
+
+	// ID is the identifier the PeerConnection uses in its stats
+	ID string
+
+	// CreatedAt is the time the PeerConnection was created
+	CreatedAt time.Time
+
+	// Age is the time elapsed since the PeerConnection was created
+	Age time.Duration
+
+	// LastActivity is the last time the PeerConnection was observed sending
+	// or receiving data, or changing its connection state
+	LastActivity time.Time
+
+	// Idle is the time elapsed since LastActivity
+	Idle time.Duration
+
+	ConnectionState    PeerConnectionState
+	ICEConnectionState ICEConnectionState
+	SignalingState     SignalingState
+
+	// BytesSent is the total number of bytes sent on the ICE transport
+	BytesSent uint64
+
+	// BytesReceived is the total number of bytes received on the ICE transport
+	BytesReceived uint64
+}
+
+type peerConnectionRegistryEntry struct {
+	createdAt       time.Time
+	lastActivity    time.Time
+	bytesSent       uint64
+	bytesReceived   uint64
+	connectionState PeerConnectionState
+}
+
+// peerConnectionRegistry keeps track of the PeerConnections which are
+// created by an API and not closed yet
+type peerConnectionRegistry struct {
+	mu              sync.Mutex
+	peerConnections map[*PeerConnection]*peerConnectionRegistryEntry
+}
+
+func newPeerConnectionRegistry() *peerConnectionRegistry {
+	return &peerConnectionRegistry{
+		peerConnections: map[*PeerConnection]*peerConnectionRegistryEntry{},
+	}
+}
+
+func (r *peerConnectionRegistry) add(pc *PeerConnection) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	r.peerConnections[pc] = &peerConnectionRegistryEntry{
+		createdAt:       now,
+		lastActivity:    now,
+		connectionState: PeerConnectionStateNew,
+	}
+	r.mu.Unlock()
+}
+
+func (r *peerConnectionRegistry) remove(pc *PeerConnection) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	delete(r.peerConnections, pc)
+	r.mu.Unlock()
+}
+
+// snapshot samples the traffic counters and states of every PeerConnection.
+// A PeerConnection is considered active if any of them changed since the
+// previous sample.
+func (r *peerConnectionRegistry) snapshot() []PeerConnectionInfo {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	peerConnections := make(map[*PeerConnection]*peerConnectionRegistryEntry, len(r.peerConnections))
+	for pc, entry := range r.peerConnections {
+		peerConnections[pc] = entry
+	}
+	r.mu.Unlock()
+
+	now := time.Now()
+	infos := make([]PeerConnectionInfo, 0, len(peerConnections))
+	for pc, entry := range peerConnections {
+		bytesSent, bytesReceived := pc.iceTransport.bytesTransferred()
+		connectionState := pc.ConnectionState()
+
+		r.mu.Lock()
+		if bytesSent != entry.bytesSent || bytesReceived != entry.bytesReceived || connectionState != entry.connectionState {
+			entry.lastActivity = now
+		}
+		entry.bytesSent, entry.bytesReceived, entry.connectionState = bytesSent, bytesReceived, connectionState
+		lastActivity, createdAt := entry.lastActivity, entry.createdAt
+		r.mu.Unlock()
+
+		infos = append(infos, PeerConnectionInfo{
+			PeerConnection:     pc,
+			ID:                 pc.statsID,
+			CreatedAt:          createdAt,
+			Age:                now.Sub(createdAt),
+			LastActivity:       lastActivity,
+			Idle:               now.Sub(lastActivity),
+			ConnectionState:    connectionState,
+			ICEConnectionState: pc.ICEConnectionState(),
+			SignalingState:     pc.SignalingState(),
+			BytesSent:          bytesSent,
+			BytesReceived:      bytesReceived,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+
+	return infos
+}
+
+// PeerConnections returns a snapshot of every PeerConnection created by this
+// API which has not been closed yet, oldest first.
+//
+// Activity is sampled: a PeerConnection counts as active when its traffic
+// counters or connection state changed since the previous call to
+// PeerConnections or CloseIdlePeerConnections.
+func (api *API) PeerConnections() []PeerConnectionInfo {
+	return api.registry.snapshot()
+}
+
+// CloseIdlePeerConnections closes every PeerConnection created by this API
+// which has been idle for at least maxIdle, and returns the ones it closed.
+// See PeerConnections for how activity is measured.
+func (api *API) CloseIdlePeerConnections(maxIdle time.Duration) ([]*PeerConnection, error) {
+	closed := []*PeerConnection{}
+	closeErrs := []error{}
+	for _, info := range api.registry.snapshot() {
+		if info.Idle < maxIdle {
+			continue
+		}
+
+		closeErrs = append(closeErrs, info.PeerConnection.Close())
+		closed = append(closed, info.PeerConnection)
+	}
+
+	return closed, util.FlattenErrs(closeErrs)
+}
@@ -18,6 +18,11 @@ type Sample struct {
 	PacketTimestamp    uint32
 	PrevDroppedPackets uint16
 	Metadata           interface{}
+
+	// FEC is set on samples that stand in for a lost sample. Data is the
+	// sample following the loss, which carries in-band forward error
+	// correction data for it, and should be decoded in FEC mode.
+	FEC bool
 }
 
 // Writer defines an interface to handle
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package rtmpbridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// AMF0 type markers
+// https://rtmp.veriskope.com/pdf/amf0-file-format-specification.pdf
+const (
+	amf0Number      = 0x00
+	amf0Boolean     = 0x01
+	amf0String      = 0x02
+	amf0Object      = 0x03
+	amf0Null        = 0x05
+	amf0Undefined   = 0x06
+	amf0ECMAArray   = 0x08
+	amf0ObjectEnd   = 0x09
+	amf0StrictArray = 0x0A
+	amf0LongString  = 0x0C
+)
+
+var (
+	errShortAMF0       = errors.New("AMF0 value is truncated")
+	errUnsupportedAMF0 = errors.New("AMF0 type is not supported")
+)
+
+// amf0Properties is an AMF0 object or ECMA array
+type amf0Properties map[string]interface{}
+
+// decodeAMF0 returns all values encoded in b. Numbers are returned as float64,
+// booleans as bool, strings as string, objects as amf0Properties and null
+// or undefined as nil.
+func decodeAMF0(b []byte) ([]interface{}, error) {
+	values := []interface{}{}
+	for len(b) > 0 {
+		value, rest, err := decodeAMF0Value(b)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		b = rest
+	}
+	return values, nil
+}
+
+func decodeAMF0String(b []byte, lengthSize int) (string, []byte, error) {
+	if len(b) < lengthSize {
+		return "", nil, errShortAMF0
+	}
+
+	var length int
+	if lengthSize == 2 {
+		length = int(binary.BigEndian.Uint16(b))
+	} else {
+		length = int(binary.BigEndian.Uint32(b))
+	}
+	b = b[lengthSize:]
+
+	if length > len(b) {
+		return "", nil, errShortAMF0
+	}
+	return string(b[:length]), b[length:], nil
+}
+
+func decodeAMF0Properties(b []byte) (amf0Properties, []byte, error) {
+	properties := amf0Properties{}
+	for {
+		key, rest, err := decodeAMF0String(b, 2)
+		if err != nil {
+			return nil, nil, err
+		}
+		if key == "" && len(rest) > 0 && rest[0] == amf0ObjectEnd {
+			return properties, rest[1:], nil
+		}
+
+		value, rest, err := decodeAMF0Value(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		properties[key] = value
+		b = rest
+	}
+}
+
+func decodeAMF0Value(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errShortAMF0
+	}
+
+	marker, b := b[0], b[1:]
+	switch marker {
+	case amf0Number:
+		if len(b) < 8 {
+			return nil, nil, errShortAMF0
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), b[8:], nil
+	case amf0Boolean:
+		if len(b) < 1 {
+			return nil, nil, errShortAMF0
+		}
+		return b[0] != 0, b[1:], nil
+	case amf0String:
+		return decodeAMF0String(b, 2)
+	case amf0LongString:
+		return decodeAMF0String(b, 4)
+	case amf0Object:
+		return decodeAMF0Properties(b)
+	case amf0ECMAArray:
+		if len(b) < 4 {
+			return nil, nil, errShortAMF0
+		}
+		return decodeAMF0Properties(b[4:])
+	case amf0StrictArray:
+		if len(b) < 4 {
+			return nil, nil, errShortAMF0
+		}
+		count := binary.BigEndian.Uint32(b)
+		b = b[4:]
+
+		values := []interface{}{}
+		for i := uint32(0); i < count; i++ {
+			value, rest, err := decodeAMF0Value(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			values = append(values, value)
+			b = rest
+		}
+		return values, b, nil
+	case amf0Null, amf0Undefined:
+		return nil, b, nil
+	default:
+		return nil, nil, errUnsupportedAMF0
+	}
+}
+
+// encodeAMF0 encodes values of the types returned by decodeAMF0. Object keys are sorted.
+func encodeAMF0(values ...interface{}) []byte {
+	b := []byte{}
+	for _, value := range values {
+		b = appendAMF0Value(b, value)
+	}
+	return b
+}
+
+func appendAMF0String(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+func appendAMF0Value(b []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case float64:
+		bits := math.Float64bits(v)
+		b = append(b, amf0Number)
+		for i := 7; i >= 0; i-- {
+			b = append(b, byte(bits>>(8*uint(i))))
+		}
+		return b
+	case int:
+		return appendAMF0Value(b, float64(v))
+	case bool:
+		if v {
+			return append(b, amf0Boolean, 1)
+		}
+		return append(b, amf0Boolean, 0)
+	case string:
+		return appendAMF0String(append(b, amf0String), v)
+	case amf0Properties:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b = append(b, amf0Object)
+		for _, key := range keys {
+			b = appendAMF0Value(appendAMF0String(b, key), v[key])
+		}
+		return append(b, 0x00, 0x00, amf0ObjectEnd)
+	default:
+		return append(b, amf0Null)
+	}
+}
@@ -0,0 +1,293 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package rtmpbridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	defaultChunkSize  = 128
+	maxChunkSize      = 0xFFFFFF
+	maxMessageLength  = 8 << 20
+	extendedTimestamp = 0xFFFFFF
+)
+
+// Message type IDs
+// https://rtmp.veriskope.com/docs/spec/#54-protocol-control-messages
+const (
+	typeSetChunkSize     = 1
+	typeAbort            = 2
+	typeAcknowledgement  = 3
+	typeUserControl      = 4
+	typeWindowAckSize    = 5
+	typeSetPeerBandwidth = 6
+	typeAudio            = 8
+	typeVideo            = 9
+	typeDataAMF0         = 18
+	typeCommandAMF0      = 20
+)
+
+var (
+	errBadChunkSize      = errors.New("chunk size is out of range")
+	errMessageTooLarge   = errors.New("message is larger than supported")
+	errNoPreviousMessage = errors.New("chunk header references a chunk stream without a previous message")
+)
+
+type message struct {
+	typeID    uint8
+	streamID  uint32
+	timestamp uint32
+	payload   []byte
+}
+
+type chunkStream struct {
+	timestamp, delta uint32
+	length           uint32
+	typeID           uint8
+	streamID         uint32
+	extended         bool
+	hasHeader        bool
+
+	buffer []byte
+}
+
+type countingReader struct {
+	reader    io.Reader
+	bytesRead uint64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.reader.Read(b)
+	c.bytesRead += uint64(n)
+	return n, err
+}
+
+// chunkReader reassembles messages from a RTMP chunk stream
+// https://rtmp.veriskope.com/docs/spec/#53-chunking
+type chunkReader struct {
+	reader    *countingReader
+	chunkSize uint32
+	streams   map[uint32]*chunkStream
+	header    [11]byte
+}
+
+func newChunkReader(r io.Reader) *chunkReader {
+	return &chunkReader{
+		reader:    &countingReader{reader: r},
+		chunkSize: defaultChunkSize,
+		streams:   map[uint32]*chunkStream{},
+	}
+}
+
+func (c *chunkReader) bytesRead() uint64 {
+	return c.reader.bytesRead
+}
+
+func (c *chunkReader) setChunkSize(size uint32) error {
+	if size == 0 || size > maxChunkSize {
+		return errBadChunkSize
+	}
+	c.chunkSize = size
+	return nil
+}
+
+func (c *chunkReader) readUint(n int) (uint32, error) {
+	if _, err := io.ReadFull(c.reader, c.header[:n]); err != nil {
+		return 0, err
+	}
+
+	var v uint32
+	for _, b := range c.header[:n] {
+		v = v<<8 | uint32(b)
+	}
+	return v, nil
+}
+
+// readMessage returns the next complete message
+func (c *chunkReader) readMessage() (*message, error) {
+	for {
+		msg, err := c.readChunk()
+		if err != nil || msg != nil {
+			return msg, err
+		}
+	}
+}
+
+// readChunk reads one chunk, and returns the message it completes if any
+func (c *chunkReader) readChunk() (*message, error) { // nolint:gocognit
+	basicHeader, err := c.readUint(1)
+	if err != nil {
+		return nil, err
+	}
+
+	format := basicHeader >> 6
+	csid := basicHeader & 0x3F
+	switch csid {
+	case 0:
+		b, err := c.readUint(1)
+		if err != nil {
+			return nil, err
+		}
+		csid = 64 + b
+	case 1:
+		b, err := c.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		csid = 64 + b>>8 + (b&0xFF)*256
+	}
+
+	stream, ok := c.streams[csid]
+	if !ok {
+		stream = &chunkStream{}
+		c.streams[csid] = stream
+	}
+	if format != 0 && !stream.hasHeader {
+		return nil, errNoPreviousMessage
+	}
+
+	var timestamp uint32
+	if format <= 2 {
+		if timestamp, err = c.readUint(3); err != nil {
+			return nil, err
+		}
+	}
+	if format <= 1 {
+		if stream.length, err = c.readUint(3); err != nil {
+			return nil, err
+		}
+		typeID, err := c.readUint(1)
+		if err != nil {
+			return nil, err
+		}
+		stream.typeID = uint8(typeID)
+	}
+	if format == 0 {
+		if _, err = io.ReadFull(c.reader, c.header[:4]); err != nil {
+			return nil, err
+		}
+		stream.streamID = binary.LittleEndian.Uint32(c.header[:4])
+	}
+
+	if format <= 2 {
+		stream.extended = timestamp == extendedTimestamp
+	}
+	if stream.extended {
+		// Type 3 chunks repeat the extended timestamp of the chunk stream
+		extended, err := c.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		if format <= 2 {
+			timestamp = extended
+		}
+	}
+
+	if stream.length > maxMessageLength {
+		return nil, errMessageTooLarge
+	}
+
+	// A chunk starting a new message updates the timestamp of the chunk stream
+	if len(stream.buffer) == 0 {
+		switch format {
+		case 0:
+			stream.timestamp, stream.delta = timestamp, 0
+		case 1, 2:
+			stream.timestamp, stream.delta = stream.timestamp+timestamp, timestamp
+		case 3:
+			if stream.hasHeader {
+				stream.timestamp += stream.delta
+			}
+		}
+	}
+	stream.hasHeader = true
+
+	toRead := stream.length - uint32(len(stream.buffer))
+	if toRead > c.chunkSize {
+		toRead = c.chunkSize
+	}
+
+	start := len(stream.buffer)
+	stream.buffer = append(stream.buffer, make([]byte, toRead)...)
+	if _, err = io.ReadFull(c.reader, stream.buffer[start:]); err != nil {
+		return nil, err
+	}
+
+	if uint32(len(stream.buffer)) < stream.length {
+		return nil, nil
+	}
+
+	msg := &message{
+		typeID:    stream.typeID,
+		streamID:  stream.streamID,
+		timestamp: stream.timestamp,
+		payload:   stream.buffer,
+	}
+	stream.buffer = nil
+	return msg, nil
+}
+
+// abort discards the partially received message of a chunk stream
+func (c *chunkReader) abort(csid uint32) {
+	if stream, ok := c.streams[csid]; ok {
+		stream.buffer = nil
+	}
+}
+
+// chunkWriter splits messages into chunks, only chunk stream IDs below 64 are supported
+type chunkWriter struct {
+	writer    io.Writer
+	chunkSize uint32
+}
+
+func (c *chunkWriter) writeMessage(csid uint8, msg *message) error {
+	header := make([]byte, 0, 16)
+	header = append(header, csid&0x3F)
+
+	timestamp := msg.timestamp
+	if timestamp >= extendedTimestamp {
+		timestamp = extendedTimestamp
+	}
+	length := uint32(len(msg.payload))
+	header = append(header,
+		byte(timestamp>>16), byte(timestamp>>8), byte(timestamp),
+		byte(length>>16), byte(length>>8), byte(length),
+		msg.typeID,
+	)
+	header = append(header, byte(msg.streamID), byte(msg.streamID>>8), byte(msg.streamID>>16), byte(msg.streamID>>24))
+	if timestamp == extendedTimestamp {
+		header = appendUint32(header, msg.timestamp)
+	}
+
+	out := append([]byte{}, header...)
+	for payload := msg.payload; ; {
+		n := uint32(len(payload))
+		if n > c.chunkSize {
+			n = c.chunkSize
+		}
+		out = append(out, payload[:n]...)
+		payload = payload[n:]
+		if len(payload) == 0 {
+			break
+		}
+
+		out = append(out, 0xC0|csid&0x3F)
+		if timestamp == extendedTimestamp {
+			out = appendUint32(out, msg.timestamp)
+		}
+	}
+
+	_, err := c.writer.Write(out)
+	return err
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
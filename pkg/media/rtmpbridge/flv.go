@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package rtmpbridge
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	flvVideoFrameTypeKey = 1
+	flvVideoCodecAVC     = 7
+
+	flvAVCSequenceHeader = 0
+	flvAVCNALU           = 1
+
+	flvAudioFormatAAC = 10
+
+	flvAACSequenceHeader = 0
+	flvAACRaw            = 1
+
+	aacFrameSamples  = 1024
+	adtsHeaderLength = 7
+)
+
+var (
+	errShortAVCConfiguration = errors.New("AVC decoder configuration record is truncated")
+	errShortAVCNALU          = errors.New("AVC NALU is truncated")
+	errNoAVCConfiguration    = errors.New("AVC NALU received before the decoder configuration record")
+	errShortAudioConfig      = errors.New("AAC audio specific config is truncated")
+	errUnsupportedAudioConf  = errors.New("AAC audio specific config uses an unsupported object type or sample rate")
+	errNoAudioConfig         = errors.New("AAC frame received before the audio specific config")
+)
+
+// https://wiki.multimedia.cx/index.php/MPEG-4_Audio#Sampling_Frequencies
+var aacSampleRates = []int{ // nolint:gochecknoglobals
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+var annexBStartCode = []byte{0x00, 0x00, 0x00, 0x01} // nolint:gochecknoglobals
+
+// avcConverter converts FLV AVC packets, which are length prefixed, to Annex-B
+type avcConverter struct {
+	nalLengthSize int
+	parameterSets []byte
+}
+
+// setDecoderConfiguration parses an AVCDecoderConfigurationRecord, ISO/IEC 14496-15 5.2.4.1
+func (a *avcConverter) setDecoderConfiguration(b []byte) error {
+	if len(b) < 6 {
+		return errShortAVCConfiguration
+	}
+	nalLengthSize := int(b[4]&0x03) + 1
+
+	parameterSets := []byte{}
+	readParameterSets := func(count int) error {
+		for i := 0; i < count; i++ {
+			if len(b) < 2 {
+				return errShortAVCConfiguration
+			}
+			length := int(b[0])<<8 | int(b[1])
+			if 2+length > len(b) {
+				return errShortAVCConfiguration
+			}
+			parameterSets = append(parameterSets, annexBStartCode...)
+			parameterSets = append(parameterSets, b[2:2+length]...)
+			b = b[2+length:]
+		}
+		return nil
+	}
+
+	numSPS := int(b[5] & 0x1F)
+	b = b[6:]
+	if err := readParameterSets(numSPS); err != nil {
+		return err
+	}
+
+	if len(b) < 1 {
+		return errShortAVCConfiguration
+	}
+	numPPS := int(b[0])
+	b = b[1:]
+	if err := readParameterSets(numPPS); err != nil {
+		return err
+	}
+
+	a.nalLengthSize = nalLengthSize
+	a.parameterSets = parameterSets
+	return nil
+}
+
+// toAnnexB converts the NALUs of a FLV AVC packet, keyframes are prefixed with the parameter sets
+func (a *avcConverter) toAnnexB(b []byte, keyframe bool) ([]byte, error) {
+	if a.nalLengthSize == 0 {
+		return nil, errNoAVCConfiguration
+	}
+
+	out := []byte{}
+	if keyframe {
+		out = append(out, a.parameterSets...)
+	}
+
+	for len(b) > 0 {
+		if len(b) < a.nalLengthSize {
+			return nil, errShortAVCNALU
+		}
+
+		length := 0
+		for _, v := range b[:a.nalLengthSize] {
+			length = length<<8 | int(v)
+		}
+		b = b[a.nalLengthSize:]
+		if length > len(b) {
+			return nil, errShortAVCNALU
+		}
+
+		out = append(out, annexBStartCode...)
+		out = append(out, b[:length]...)
+		b = b[length:]
+	}
+	return out, nil
+}
+
+// aacConverter converts raw FLV AAC frames to ADTS frames
+type aacConverter struct {
+	objectType, sampleRateIndex, channelConfig uint8
+	hasConfig                                  bool
+}
+
+// setAudioSpecificConfig parses an AudioSpecificConfig, ISO/IEC 14496-3 1.6.2.1
+func (a *aacConverter) setAudioSpecificConfig(b []byte) error {
+	if len(b) < 2 {
+		return errShortAudioConfig
+	}
+
+	objectType := b[0] >> 3
+	sampleRateIndex := (b[0]&0x07)<<1 | b[1]>>7
+	if objectType == 0 || objectType > 4 || int(sampleRateIndex) >= len(aacSampleRates) {
+		// ADTS can only describe the first four object types
+		return errUnsupportedAudioConf
+	}
+
+	a.objectType = objectType
+	a.sampleRateIndex = sampleRateIndex
+	a.channelConfig = (b[1] >> 3) & 0x0F
+	a.hasConfig = true
+	return nil
+}
+
+// toADTS prepends an ADTS header to a raw AAC frame, and returns the frame with its duration
+// https://wiki.multimedia.cx/index.php/ADTS
+func (a *aacConverter) toADTS(raw []byte) ([]byte, time.Duration, error) {
+	if !a.hasConfig {
+		return nil, 0, errNoAudioConfig
+	}
+
+	frameLength := adtsHeaderLength + len(raw)
+	out := []byte{
+		0xFF,
+		0xF1,
+		(a.objectType-1)<<6 | a.sampleRateIndex<<2 | a.channelConfig>>2&0x01,
+		a.channelConfig&0x03<<6 | byte(frameLength>>11)&0x03,
+		byte(frameLength >> 3),
+		byte(frameLength&0x07)<<5 | 0x1F,
+		0xFC,
+	}
+	out = append(out, raw...)
+
+	return out, time.Duration(aacFrameSamples) * time.Second / time.Duration(aacSampleRates[a.sampleRateIndex]), nil
+}
@@ -0,0 +1,453 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+// Package rtmpbridge terminates RTMP publish sessions and writes their media
+// to TrackLocals that can be added to a PeerConnection, so a single binary can
+// take RTMP in and send WebRTC out.
+//
+// H264 video is forwarded as is. AAC audio can't be sent over WebRTC, it is
+// handed to an AudioTranscoder if one is configured and dropped otherwise.
+// Sample durations are derived from the RTMP timestamps, which are decode
+// timestamps, so streams with B-frames are not supported.
+package rtmpbridge
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+const (
+	rtmpVersion   = 3
+	handshakeSize = 1536
+
+	serverChunkSize     = 4096
+	serverWindowAckSize = 2500000
+	peerBandwidthLimit  = 2 // dynamic
+
+	publishStreamID = 1
+
+	// Chunk stream IDs used for the messages we send
+	csidProtocolControl = 2
+	csidCommand         = 3
+	csidStatus          = 5
+)
+
+var (
+	errNilConn              = errors.New("conn is nil")
+	errBadHandshakeVersion  = errors.New("client requested an unsupported RTMP version")
+	errBadCommand           = errors.New("command message is malformed")
+	errAlreadyPublishing    = errors.New("session is already publishing")
+	errPublishBeforeConnect = errors.New("publish requested before connect")
+)
+
+// AudioTranscoder converts audio that can't be sent over WebRTC to Opus
+type AudioTranscoder interface {
+	// Transcode is called with every AAC frame, with an ADTS header prepended,
+	// and returns the Opus samples to write to the audio track, if any.
+	Transcode(frame []byte, duration time.Duration) ([]media.Sample, error)
+}
+
+// Config configures how RTMP publish sessions are bridged
+type Config struct {
+	// OnPublish is called when a client starts publishing, with the tracks its media
+	// will be written to. The stream ID of the tracks is the stream key. Returning
+	// an error rejects the publish request.
+	OnPublish func(app, streamKey string, videoTrack, audioTrack *webrtc.TrackLocalStaticSample) error
+
+	// OnUnpublish is called when a publishing session ends
+	OnUnpublish func(app, streamKey string)
+
+	// AudioTranscoder converts AAC audio to Opus. Without one AAC audio is dropped.
+	AudioTranscoder AudioTranscoder
+
+	LoggerFactory logging.LoggerFactory
+}
+
+// Serve accepts RTMP connections on listener and handles each of them in its
+// own goroutine, until the listener fails or is closed
+func Serve(listener net.Listener, config Config) error {
+	loggerFactory := config.LoggerFactory
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+	log := loggerFactory.NewLogger("rtmpbridge")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := Handle(conn, config); err != nil {
+				log.Warnf("RTMP session from %s failed: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// Handle runs the RTMP session of a single connection until the client stops
+// publishing or disconnects, and closes conn. It returns nil if the session ended cleanly.
+func Handle(conn net.Conn, config Config) error {
+	if conn == nil {
+		return errNilConn
+	}
+
+	s := &session{
+		conn:   conn,
+		config: config,
+		reader: newChunkReader(conn),
+		writer: &chunkWriter{writer: conn, chunkSize: defaultChunkSize},
+	}
+
+	err := s.run()
+	if flushErr := s.flushVideo(); err == nil {
+		err = flushErr
+	}
+	s.unpublish()
+
+	if closeErr := conn.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+type session struct {
+	conn   io.ReadWriter
+	config Config
+
+	reader *chunkReader
+	writer *chunkWriter
+
+	windowAckSize     uint32
+	lastAcknowledged  uint64
+	connected, closed bool
+
+	app, streamKey         string
+	publishing             bool
+	videoTrack, audioTrack *webrtc.TrackLocalStaticSample
+
+	avc avcConverter
+	aac aacConverter
+
+	// Video durations are only known once the next frame arrives
+	pendingVideo          *media.Sample
+	pendingVideoTimestamp uint32
+	lastVideoDelta        uint32
+}
+
+// handshake performs the simple RTMP handshake
+// https://rtmp.veriskope.com/docs/spec/#52-handshake
+func (s *session) handshake() error {
+	c0c1 := make([]byte, 1+handshakeSize)
+	if _, err := io.ReadFull(s.conn, c0c1); err != nil {
+		return err
+	}
+	if c0c1[0] != rtmpVersion {
+		return errBadHandshakeVersion
+	}
+
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	s0s1s2[0] = rtmpVersion
+	if _, err := rand.Read(s0s1s2[1+8 : 1+handshakeSize]); err != nil {
+		return err
+	}
+	copy(s0s1s2[1+handshakeSize:], c0c1[1:])
+	if _, err := s.conn.Write(s0s1s2); err != nil {
+		return err
+	}
+
+	c2 := make([]byte, handshakeSize)
+	_, err := io.ReadFull(s.conn, c2)
+	return err
+}
+
+func (s *session) run() error {
+	if err := s.handshake(); err != nil {
+		return err
+	}
+
+	for !s.closed {
+		msg, err := s.reader.readMessage()
+		if err != nil {
+			return err
+		}
+
+		if err = s.handleMessage(msg); err != nil {
+			return err
+		}
+
+		if err = s.acknowledge(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *session) acknowledge() error {
+	bytesRead := s.reader.bytesRead()
+	if s.windowAckSize == 0 || bytesRead-s.lastAcknowledged < uint64(s.windowAckSize) {
+		return nil
+	}
+
+	s.lastAcknowledged = bytesRead
+	return s.writer.writeMessage(csidProtocolControl, &message{
+		typeID:  typeAcknowledgement,
+		payload: appendUint32(nil, uint32(bytesRead)),
+	})
+}
+
+func (s *session) handleMessage(msg *message) error {
+	switch msg.typeID {
+	case typeSetChunkSize:
+		if len(msg.payload) < 4 {
+			return errBadChunkSize
+		}
+		return s.reader.setChunkSize(binary.BigEndian.Uint32(msg.payload) & 0x7FFFFFFF)
+	case typeAbort:
+		if len(msg.payload) >= 4 {
+			s.reader.abort(binary.BigEndian.Uint32(msg.payload))
+		}
+	case typeWindowAckSize:
+		if len(msg.payload) >= 4 {
+			s.windowAckSize = binary.BigEndian.Uint32(msg.payload)
+		}
+	case typeCommandAMF0:
+		return s.handleCommand(msg)
+	case typeVideo:
+		if s.publishing {
+			return s.handleVideo(msg)
+		}
+	case typeAudio:
+		if s.publishing && s.config.AudioTranscoder != nil {
+			return s.handleAudio(msg)
+		}
+	}
+
+	// Acknowledgements, user control and metadata messages are not needed to bridge media
+	return nil
+}
+
+func (s *session) writeCommand(csid uint8, streamID uint32, values ...interface{}) error {
+	return s.writer.writeMessage(csid, &message{
+		typeID:   typeCommandAMF0,
+		streamID: streamID,
+		payload:  encodeAMF0(values...),
+	})
+}
+
+func (s *session) handleCommand(msg *message) error {
+	values, err := decodeAMF0(msg.payload)
+	if err != nil {
+		return err
+	}
+	if len(values) < 2 {
+		return errBadCommand
+	}
+	name, ok := values[0].(string)
+	if !ok {
+		return errBadCommand
+	}
+	transactionID, ok := values[1].(float64)
+	if !ok {
+		return errBadCommand
+	}
+
+	switch name {
+	case "connect":
+		if len(values) >= 3 {
+			if properties, ok := values[2].(amf0Properties); ok {
+				s.app, _ = properties["app"].(string)
+			}
+		}
+		return s.connect(transactionID)
+	case "createStream":
+		return s.writeCommand(csidCommand, 0, "_result", transactionID, nil, publishStreamID)
+	case "publish":
+		if len(values) < 4 {
+			return errBadCommand
+		}
+		streamKey, ok := values[3].(string)
+		if !ok {
+			return errBadCommand
+		}
+		return s.publish(msg.streamID, streamKey)
+	case "deleteStream", "closeStream", "FCUnpublish":
+		s.closed = s.publishing
+	}
+
+	// releaseStream, FCPublish and the like need no answer
+	return nil
+}
+
+func (s *session) connect(transactionID float64) error {
+	if err := s.writer.writeMessage(csidProtocolControl, &message{
+		typeID:  typeWindowAckSize,
+		payload: appendUint32(nil, serverWindowAckSize),
+	}); err != nil {
+		return err
+	}
+
+	if err := s.writer.writeMessage(csidProtocolControl, &message{
+		typeID:  typeSetPeerBandwidth,
+		payload: append(appendUint32(nil, serverWindowAckSize), peerBandwidthLimit),
+	}); err != nil {
+		return err
+	}
+
+	if err := s.writer.writeMessage(csidProtocolControl, &message{
+		typeID:  typeSetChunkSize,
+		payload: appendUint32(nil, serverChunkSize),
+	}); err != nil {
+		return err
+	}
+	s.writer.chunkSize = serverChunkSize
+
+	s.connected = true
+	return s.writeCommand(csidCommand, 0, "_result", transactionID,
+		amf0Properties{"fmsVer": "FMS/3,0,1,123", "capabilities": 31},
+		amf0Properties{
+			"level":          "status",
+			"code":           "NetConnection.Connect.Success",
+			"description":    "Connection succeeded.",
+			"objectEncoding": 0,
+		},
+	)
+}
+
+func (s *session) publish(streamID uint32, streamKey string) error {
+	switch {
+	case !s.connected:
+		return errPublishBeforeConnect
+	case s.publishing:
+		return errAlreadyPublishing
+	}
+
+	videoTrack, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264}, "video", streamKey)
+	if err != nil {
+		return err
+	}
+	audioTrack, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", streamKey)
+	if err != nil {
+		return err
+	}
+
+	if s.config.OnPublish != nil {
+		if err = s.config.OnPublish(s.app, streamKey, videoTrack, audioTrack); err != nil {
+			if writeErr := s.writeCommand(csidStatus, streamID, "onStatus", 0, nil, amf0Properties{
+				"level":       "error",
+				"code":        "NetStream.Publish.BadName",
+				"description": err.Error(),
+			}); writeErr != nil {
+				return writeErr
+			}
+			return err
+		}
+	}
+
+	s.streamKey = streamKey
+	s.videoTrack, s.audioTrack = videoTrack, audioTrack
+	s.publishing = true
+
+	return s.writeCommand(csidStatus, streamID, "onStatus", 0, nil, amf0Properties{
+		"level":       "status",
+		"code":        "NetStream.Publish.Start",
+		"description": "Start publishing",
+	})
+}
+
+func (s *session) unpublish() {
+	if !s.publishing {
+		return
+	}
+	s.publishing = false
+
+	if s.config.OnUnpublish != nil {
+		s.config.OnUnpublish(s.app, s.streamKey)
+	}
+}
+
+func (s *session) handleVideo(msg *message) error {
+	if len(msg.payload) < 5 || msg.payload[0]&0x0F != flvVideoCodecAVC {
+		return nil
+	}
+
+	switch msg.payload[1] {
+	case flvAVCSequenceHeader:
+		return s.avc.setDecoderConfiguration(msg.payload[5:])
+	case flvAVCNALU:
+		data, err := s.avc.toAnnexB(msg.payload[5:], msg.payload[0]>>4 == flvVideoFrameTypeKey)
+		if err != nil {
+			return err
+		}
+		return s.writeVideo(data, msg.timestamp)
+	}
+	return nil
+}
+
+func (s *session) writeVideo(data []byte, timestamp uint32) error {
+	if s.pendingVideo != nil {
+		s.lastVideoDelta = timestamp - s.pendingVideoTimestamp
+		s.pendingVideo.Duration = time.Duration(s.lastVideoDelta) * time.Millisecond
+		if err := s.videoTrack.WriteSample(*s.pendingVideo); err != nil {
+			return err
+		}
+	}
+
+	s.pendingVideo = &media.Sample{Data: data}
+	s.pendingVideoTimestamp = timestamp
+	return nil
+}
+
+func (s *session) flushVideo() error {
+	if s.pendingVideo == nil {
+		return nil
+	}
+
+	s.pendingVideo.Duration = time.Duration(s.lastVideoDelta) * time.Millisecond
+	err := s.videoTrack.WriteSample(*s.pendingVideo)
+	s.pendingVideo = nil
+	return err
+}
+
+func (s *session) handleAudio(msg *message) error {
+	if len(msg.payload) < 2 || msg.payload[0]>>4 != flvAudioFormatAAC {
+		return nil
+	}
+
+	switch msg.payload[1] {
+	case flvAACSequenceHeader:
+		return s.aac.setAudioSpecificConfig(msg.payload[2:])
+	case flvAACRaw:
+		frame, duration, err := s.aac.toADTS(msg.payload[2:])
+		if err != nil {
+			return err
+		}
+
+		samples, err := s.config.AudioTranscoder.Transcode(frame, duration)
+		if err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			if err := s.audioTrack.WriteSample(sample); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
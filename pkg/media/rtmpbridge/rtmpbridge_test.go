@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package rtmpbridge
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+var errRejected = errors.New("rejected")
+
+func TestAMF0(t *testing.T) {
+	values := []interface{}{
+		"connect",
+		float64(1),
+		amf0Properties{"app": "live", "fpad": false, "nested": amf0Properties{"a": float64(2)}},
+		nil,
+	}
+
+	decoded, err := decodeAMF0(encodeAMF0(values...))
+	assert.NoError(t, err)
+	assert.Equal(t, values, decoded)
+
+	// ECMA arrays and strict arrays, as sent in onMetaData
+	decoded, err = decodeAMF0([]byte{
+		amf0ECMAArray, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 'w', amf0Number, 0x40, 0x94, 0, 0, 0, 0, 0, 0, 0x00, 0x00, amf0ObjectEnd,
+		amf0StrictArray, 0x00, 0x00, 0x00, 0x01, amf0Boolean, 0x01,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{amf0Properties{"w": float64(1280)}, []interface{}{true}}, decoded)
+
+	_, err = decodeAMF0([]byte{amf0Number, 0x00})
+	assert.Equal(t, errShortAMF0, err)
+
+	_, err = decodeAMF0([]byte{0x0B})
+	assert.Equal(t, errUnsupportedAMF0, err)
+}
+
+func TestChunks(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := &chunkWriter{writer: buffer, chunkSize: defaultChunkSize}
+
+	large := &message{typeID: typeVideo, streamID: 1, timestamp: 0x01000000, payload: bytes.Repeat([]byte{0xAA}, 300)}
+	small := &message{typeID: typeAudio, streamID: 1, timestamp: 40, payload: []byte{0x01}}
+	assert.NoError(t, writer.writeMessage(6, large))
+	assert.NoError(t, writer.writeMessage(7, small))
+
+	// A type 2 chunk adds a delta to the previous timestamp, a type 3 chunk repeats it
+	buffer.Write([]byte{0x87, 0x00, 0x00, 0x14, 0x02})
+	buffer.Write([]byte{0xC7, 0x03})
+
+	reader := newChunkReader(buffer)
+	for _, expected := range []*message{
+		large,
+		small,
+		{typeID: typeAudio, streamID: 1, timestamp: 60, payload: []byte{0x02}},
+		{typeID: typeAudio, streamID: 1, timestamp: 80, payload: []byte{0x03}},
+	} {
+		msg, err := reader.readMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, msg)
+	}
+
+	_, err := reader.readMessage()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, errBadChunkSize, reader.setChunkSize(0))
+
+	_, err = newChunkReader(bytes.NewReader([]byte{0x48})).readMessage()
+	assert.Equal(t, errNoPreviousMessage, err)
+}
+
+func TestAVCConverter(t *testing.T) {
+	converter := avcConverter{}
+
+	_, err := converter.toAnnexB([]byte{0x00, 0x00, 0x00, 0x01, 0x65}, true)
+	assert.Equal(t, errNoAVCConfiguration, err)
+
+	assert.NoError(t, converter.setDecoderConfiguration([]byte{
+		0x01, 0x42, 0x00, 0x1F, 0xFF, 0xE1, 0x00, 0x02, 0x67, 0x42, 0x01, 0x00, 0x01, 0x68,
+	}))
+
+	annexB, err := converter.toAnnexB([]byte{0x00, 0x00, 0x00, 0x02, 0x65, 0x88, 0x00, 0x00, 0x00, 0x01, 0x06}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 1, 0x67, 0x42, 0, 0, 0, 1, 0x68, 0, 0, 0, 1, 0x65, 0x88, 0, 0, 0, 1, 0x06}, annexB)
+
+	annexB, err = converter.toAnnexB([]byte{0x00, 0x00, 0x00, 0x01, 0x41}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 1, 0x41}, annexB)
+
+	_, err = converter.toAnnexB([]byte{0x00, 0x00, 0x00, 0x05, 0x41}, false)
+	assert.Equal(t, errShortAVCNALU, err)
+
+	assert.Equal(t, errShortAVCConfiguration, converter.setDecoderConfiguration([]byte{0x01, 0x42, 0x00, 0x1F, 0xFF, 0xE1, 0x00, 0x05}))
+}
+
+func TestAACConverter(t *testing.T) {
+	converter := aacConverter{}
+
+	_, _, err := converter.toADTS([]byte{0x21})
+	assert.Equal(t, errNoAudioConfig, err)
+
+	// AAC-LC, 44.1kHz, stereo
+	assert.NoError(t, converter.setAudioSpecificConfig([]byte{0x12, 0x10}))
+
+	frame, duration, err := converter.toADTS([]byte{0x21, 0x00})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xFF, 0xF1, 0x50, 0x80, 0x01, 0x3F, 0xFC, 0x21, 0x00}, frame)
+	assert.Equal(t, time.Second*aacFrameSamples/44100, duration)
+
+	assert.Equal(t, errUnsupportedAudioConf, converter.setAudioSpecificConfig([]byte{0xF8, 0x00}))
+}
+
+type testTranscoder struct {
+	frames chan []byte
+}
+
+func (t *testTranscoder) Transcode(frame []byte, duration time.Duration) ([]media.Sample, error) {
+	t.frames <- frame
+	return []media.Sample{{Data: []byte{0xF8}, Duration: duration}}, nil
+}
+
+type testClient struct {
+	t        *testing.T
+	conn     net.Conn
+	writer   *chunkWriter
+	commands chan []interface{}
+}
+
+func newTestClient(t *testing.T, conn net.Conn) *testClient {
+	c := &testClient{
+		t:        t,
+		conn:     conn,
+		writer:   &chunkWriter{writer: conn, chunkSize: defaultChunkSize},
+		commands: make(chan []interface{}, 16),
+	}
+
+	c0c1 := make([]byte, 1+handshakeSize)
+	c0c1[0] = rtmpVersion
+	_, err := conn.Write(c0c1)
+	assert.NoError(t, err)
+
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	_, err = io.ReadFull(conn, s0s1s2)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(rtmpVersion), s0s1s2[0])
+	assert.Equal(t, c0c1[1:], s0s1s2[1+handshakeSize:])
+
+	_, err = conn.Write(s0s1s2[1 : 1+handshakeSize])
+	assert.NoError(t, err)
+
+	go func() {
+		reader := newChunkReader(conn)
+		defer close(c.commands)
+		for {
+			msg, err := reader.readMessage()
+			if err != nil {
+				return
+			}
+
+			switch msg.typeID {
+			case typeSetChunkSize:
+				assert.NoError(t, reader.setChunkSize(uint32(msg.payload[3])|uint32(msg.payload[2])<<8))
+			case typeCommandAMF0:
+				values, err := decodeAMF0(msg.payload)
+				assert.NoError(t, err)
+				c.commands <- values
+			}
+		}
+	}()
+
+	return c
+}
+
+func (c *testClient) send(csid uint8, msg *message) {
+	assert.NoError(c.t, c.writer.writeMessage(csid, msg))
+}
+
+func (c *testClient) command(streamID uint32, values ...interface{}) []interface{} {
+	c.send(3, &message{typeID: typeCommandAMF0, streamID: streamID, payload: encodeAMF0(values...)})
+	return <-c.commands
+}
+
+func TestHandle(t *testing.T) {
+	assert.Equal(t, errNilConn, Handle(nil, Config{}))
+
+	serverConn, clientConn := net.Pipe()
+
+	transcoder := &testTranscoder{frames: make(chan []byte, 1)}
+	published := make(chan *webrtc.TrackLocalStaticSample, 1)
+	unpublished := make(chan string, 1)
+	handleErr := make(chan error)
+	go func() {
+		handleErr <- Handle(serverConn, Config{
+			AudioTranscoder: transcoder,
+			OnPublish: func(app, streamKey string, videoTrack, _ *webrtc.TrackLocalStaticSample) error {
+				assert.Equal(t, "live", app)
+				if streamKey != "key" {
+					return errRejected
+				}
+				published <- videoTrack
+				return nil
+			},
+			OnUnpublish: func(app, streamKey string) {
+				unpublished <- streamKey
+			},
+		})
+	}()
+
+	client := newTestClient(t, clientConn)
+
+	result := client.command(0, "connect", 1, amf0Properties{"app": "live"})
+	assert.Equal(t, "_result", result[0])
+	assert.Equal(t, "NetConnection.Connect.Success", result[3].(amf0Properties)["code"])
+
+	result = client.command(0, "createStream", 2, nil)
+	assert.Equal(t, []interface{}{"_result", float64(2), nil, float64(publishStreamID)}, result)
+
+	result = client.command(publishStreamID, "publish", 3, nil, "key", "live")
+	assert.Equal(t, "NetStream.Publish.Start", result[3].(amf0Properties)["code"])
+
+	videoTrack := <-published
+	assert.Equal(t, "key", videoTrack.StreamID())
+	assert.Equal(t, webrtc.MimeTypeH264, videoTrack.Codec().MimeType)
+
+	client.send(4, &message{typeID: typeAudio, streamID: publishStreamID, payload: []byte{0xAF, flvAACSequenceHeader, 0x12, 0x10}})
+	client.send(4, &message{typeID: typeAudio, streamID: publishStreamID, timestamp: 23, payload: []byte{0xAF, flvAACRaw, 0x21, 0x00}})
+	assert.Equal(t, []byte{0xFF, 0xF1, 0x50, 0x80, 0x01, 0x3F, 0xFC, 0x21, 0x00}, <-transcoder.frames)
+
+	client.send(6, &message{typeID: typeVideo, streamID: publishStreamID, payload: []byte{
+		0x17, flvAVCSequenceHeader, 0, 0, 0, 0x01, 0x42, 0x00, 0x1F, 0xFF, 0xE1, 0x00, 0x02, 0x67, 0x42, 0x01, 0x00, 0x01, 0x68,
+	}})
+	client.send(6, &message{typeID: typeVideo, streamID: publishStreamID, payload: []byte{0x17, flvAVCNALU, 0, 0, 0, 0, 0, 0, 1, 0x65}})
+	client.send(6, &message{typeID: typeVideo, streamID: publishStreamID, timestamp: 33, payload: []byte{0x27, flvAVCNALU, 0, 0, 0, 0, 0, 0, 1, 0x41}})
+
+	client.send(3, &message{typeID: typeCommandAMF0, streamID: publishStreamID, payload: encodeAMF0("deleteStream", 4, nil, publishStreamID)})
+	assert.Equal(t, "key", <-unpublished)
+	assert.NoError(t, <-handleErr)
+	assert.NoError(t, clientConn.Close())
+}
+
+func TestHandle_Rejected(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+
+	handleErr := make(chan error)
+	go func() {
+		handleErr <- Handle(serverConn, Config{
+			OnPublish: func(string, string, *webrtc.TrackLocalStaticSample, *webrtc.TrackLocalStaticSample) error {
+				return errRejected
+			},
+		})
+	}()
+
+	client := newTestClient(t, clientConn)
+	client.command(0, "connect", 1, amf0Properties{"app": "live"})
+	client.command(0, "createStream", 2, nil)
+
+	result := client.command(publishStreamID, "publish", 3, nil, "key", "live")
+	assert.Equal(t, "NetStream.Publish.BadName", result[3].(amf0Properties)["code"])
+	assert.ErrorIs(t, <-handleErr, errRejected)
+	assert.NoError(t, clientConn.Close())
+}
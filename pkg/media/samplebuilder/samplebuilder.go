@@ -10,6 +10,7 @@ import (
 
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/rtpmath"
 )
 
 // SampleBuilder buffers packets until media frames are complete.
@@ -39,6 +40,7 @@ type SampleBuilder struct {
 	prepared sampleSequenceLocation
 
 	lastSampleTimestamp *uint32
+	lastSampleSequence  uint16
 
 	// number of packets forced to be dropped
 	droppedPackets uint16
@@ -48,6 +50,9 @@ type SampleBuilder struct {
 
 	// allows inspecting head packets of each sample and then returns a custom metadata
 	packetHeadHandler func(headPacket interface{}) interface{}
+
+	// emit the in-band FEC data of Opus packets following a loss
+	opusInBandFEC bool
 }
 
 // New constructs a new SampleBuilder.
@@ -287,16 +292,23 @@ func (s *SampleBuilder) buildSample(purgingBuffers bool) *media.Sample {
 
 	sample := &media.Sample{
 		Data:               data,
-		Duration:           time.Duration((float64(samples)/float64(s.sampleRate))*secondToNanoseconds) * time.Nanosecond,
+		Duration:           s.toDuration(samples),
 		PacketTimestamp:    sampleTimestamp,
 		PrevDroppedPackets: s.droppedPackets,
 		Metadata:           metadata,
 	}
 
+	if fecSample := s.buildFECSample(consume, sample); fecSample != nil {
+		s.preparedSamples[s.prepared.tail] = fecSample
+		s.prepared.tail++
+		sample.PrevDroppedPackets = 0
+	}
+
 	s.droppedPackets = 0
 	s.paddingPackets = 0
 	s.lastSampleTimestamp = new(uint32)
 	*s.lastSampleTimestamp = sampleTimestamp
+	s.lastSampleSequence = consume.tail - 1
 
 	s.preparedSamples[s.prepared.tail] = sample
 	s.prepared.tail++
@@ -307,6 +319,45 @@ func (s *SampleBuilder) buildSample(purgingBuffers bool) *media.Sample {
 	return sample
 }
 
+func (s *SampleBuilder) toDuration(samples uint32) time.Duration {
+	return time.Duration((float64(samples)/float64(s.sampleRate))*secondToNanoseconds) * time.Nanosecond
+}
+
+// buildFECSample returns a sample standing in for the packet lost right
+// before sample, if sample carries Opus in-band FEC data for it.
+// https://datatracker.ietf.org/doc/html/rfc6716#section-2.1.7
+func (s *SampleBuilder) buildFECSample(consume sampleSequenceLocation, sample *media.Sample) *media.Sample {
+	if !s.opusInBandFEC || s.lastSampleTimestamp == nil {
+		return nil
+	}
+
+	// Padding packets are dropped without being lost
+	lost := consume.head - s.lastSampleSequence - 1 - s.paddingPackets
+	if lost == 0 || lost >= consume.head-s.lastSampleSequence || int32(sample.PacketTimestamp-*s.lastSampleTimestamp) <= 0 {
+		return nil
+	}
+
+	// Only SILK and Hybrid frames, TOC configurations 0 to 15, carry LBRR frames
+	if len(sample.Data) == 0 || sample.Data[0]>>3 > 15 {
+		return nil
+	}
+
+	// The lost packets evenly split the time between the surrounding samples
+	samples := (sample.PacketTimestamp - *s.lastSampleTimestamp) / uint32(lost+1)
+	prevDroppedPackets := sample.PrevDroppedPackets
+	if prevDroppedPackets > 0 {
+		prevDroppedPackets--
+	}
+
+	return &media.Sample{
+		Data:               sample.Data,
+		Duration:           s.toDuration(samples),
+		PacketTimestamp:    sample.PacketTimestamp - samples,
+		PrevDroppedPackets: prevDroppedPackets,
+		FEC:                true,
+	}
+}
+
 // Pop compiles pushed RTP packets into media samples and then
 // returns the next valid sample (or nil if no sample is compiled).
 func (s *SampleBuilder) Pop() *media.Sample {
@@ -333,22 +384,12 @@ func (s *SampleBuilder) PopWithTimestamp() (*media.Sample, uint32) {
 
 // seqnumDistance computes the distance between two sequence numbers
 func seqnumDistance(x, y uint16) uint16 {
-	diff := int16(x - y)
-	if diff < 0 {
-		return uint16(-diff)
-	}
-
-	return uint16(diff)
+	return rtpmath.SequenceNumberDistance(x, y)
 }
 
 // timestampDistance computes the distance between two timestamps
 func timestampDistance(x, y uint32) uint32 {
-	diff := int32(x - y)
-	if diff < 0 {
-		return uint32(-diff)
-	}
-
-	return uint32(diff)
+	return rtpmath.TimestampDistance(x, y)
 }
 
 // An Option configures a SampleBuilder.
@@ -384,3 +425,14 @@ func WithMaxTimeDelay(maxLateDuration time.Duration) Option {
 		o.maxLateTimestamp = uint32(int64(o.sampleRate) * totalMillis / 1000)
 	}
 }
+
+// WithOpusInBandFEC makes the builder recover lost Opus packets from the
+// in-band FEC (LBRR) data of the packet that follows them. The recovered sample
+// is returned before that packet's sample, flagged with media.Sample.FEC.
+// Only the last packet of a loss can be recovered, and the remote only
+// sends FEC data if useinbandfec=1 was negotiated.
+func WithOpusInBandFEC() Option {
+	return func(o *SampleBuilder) {
+		o.opusInBandFEC = true
+	}
+}
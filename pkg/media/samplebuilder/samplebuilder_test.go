@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
 	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
 )
@@ -474,6 +475,37 @@ func TestPopWithTimestamp(t *testing.T) {
 	})
 }
 
+func TestSampleBuilderWithOpusInBandFEC(t *testing.T) {
+	s := New(2, &codecs.OpusPacket{}, 48000, WithOpusInBandFEC())
+
+	silk, celt := []byte{0x08, 0x01}, []byte{0xF8, 0x01}
+	for _, pkt := range []*rtp.Packet{
+		{Header: rtp.Header{SequenceNumber: 65535, Timestamp: 0}, Payload: silk},
+		{Header: rtp.Header{SequenceNumber: 1, Timestamp: 1920}, Payload: silk},
+		{Header: rtp.Header{SequenceNumber: 2, Timestamp: 2880}, Payload: celt},
+		{Header: rtp.Header{SequenceNumber: 4, Timestamp: 4800}, Payload: celt},
+		{Header: rtp.Header{SequenceNumber: 5, Timestamp: 5760}, Payload: celt},
+		{Header: rtp.Header{SequenceNumber: 6, Timestamp: 6720}, Payload: celt},
+	} {
+		s.Push(pkt)
+	}
+
+	samples := []*media.Sample{}
+	for sample := s.Pop(); sample != nil; sample = s.Pop() {
+		samples = append(samples, sample)
+	}
+
+	// Packet 0 is recovered from the SILK packet 1, packet 3 can't be recovered from the CELT packet 4
+	assert.Equal(t, []*media.Sample{
+		{Data: silk, Duration: 40 * time.Millisecond, PacketTimestamp: 0},
+		{Data: silk, Duration: 20 * time.Millisecond, PacketTimestamp: 960, FEC: true},
+		{Data: silk, Duration: 20 * time.Millisecond, PacketTimestamp: 1920},
+		{Data: celt, Duration: 40 * time.Millisecond, PacketTimestamp: 2880},
+		{Data: celt, Duration: 20 * time.Millisecond, PacketTimestamp: 4800, PrevDroppedPackets: 1},
+		{Data: celt, Duration: 20 * time.Millisecond, PacketTimestamp: 5760},
+	}, samples)
+}
+
 type truePartitionHeadChecker struct{}
 
 func (f *truePartitionHeadChecker) IsPartitionHead([]byte) bool {
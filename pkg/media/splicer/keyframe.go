@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package splicer
+
+const (
+	h264NALUTypeIDR  = 5
+	h264NALUTypeSPS  = 7
+	h264NALUTypeSTAP = 24
+	h264NALUTypeFUA  = 28
+
+	h264NALUTypeMask    = 0x1F
+	h264FUAStartBitmask = 0x80
+	h264STAPAHeaderSize = 1
+	h264NALULengthSize  = 2
+)
+
+// H264Keyframe reports whether a H264 RTP payload starts an IDR frame, either
+// with its parameter sets or with the IDR slice itself
+func H264Keyframe(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	switch naluType := payload[0] & h264NALUTypeMask; naluType {
+	case h264NALUTypeIDR, h264NALUTypeSPS:
+		return true
+	case h264NALUTypeFUA:
+		return len(payload) > 1 && payload[1]&h264FUAStartBitmask != 0 && payload[1]&h264NALUTypeMask == h264NALUTypeIDR
+	case h264NALUTypeSTAP:
+		for offset := h264STAPAHeaderSize; offset+h264NALULengthSize < len(payload); {
+			naluSize := int(payload[offset])<<8 | int(payload[offset+1])
+			offset += h264NALULengthSize
+			if t := payload[offset] & h264NALUTypeMask; t == h264NALUTypeIDR || t == h264NALUTypeSPS {
+				return true
+			}
+			offset += naluSize
+		}
+	}
+
+	return false
+}
+
+// VP8Keyframe reports whether a VP8 RTP payload starts a key frame
+func VP8Keyframe(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	// The first packet of a frame has the S bit set and partition index 0
+	if payload[0]&0x10 == 0 || payload[0]&0x07 != 0 {
+		return false
+	}
+
+	offset := 1
+	if payload[0]&0x80 != 0 {
+		if len(payload) < 2 {
+			return false
+		}
+		extensions := payload[1]
+		offset++
+
+		if extensions&0x80 != 0 { // PictureID
+			if len(payload) <= offset {
+				return false
+			}
+			if payload[offset]&0x80 != 0 {
+				offset++
+			}
+			offset++
+		}
+		if extensions&0x40 != 0 { // TL0PICIDX
+			offset++
+		}
+		if extensions&0x30 != 0 { // TID/KEYIDX
+			offset++
+		}
+	}
+
+	// The P bit of the VP8 payload header is 0 for key frames
+	return len(payload) > offset && payload[offset]&0x01 == 0
+}
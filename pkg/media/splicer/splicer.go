@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package splicer switches the source of an outgoing RTP stream, for example
+// to insert ads or to cut between cameras on a server. The switch happens on a
+// keyframe of the new source and the sequence numbers and timestamps of the
+// outgoing stream stay continuous, so the receiver sees a single stream and
+// doesn't have to request a keyframe itself.
+package splicer
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+)
+
+var (
+	errNilWriter      = errors.New("splicer: writer is nil")
+	errNoClockRate    = errors.New("splicer: clock rate is unknown")
+	errEmptySource    = errors.New("splicer: source is empty")
+	errSameSource     = errors.New("splicer: source is already active")
+	errNoActiveSource = errors.New("splicer: a timestamp can only be given when a source is active")
+)
+
+// RTPWriter is where the spliced stream is written, usually a
+// *webrtc.TrackLocalStaticRTP
+type RTPWriter interface {
+	WriteRTP(*rtp.Packet) error
+}
+
+// codecWriter is a RTPWriter that knows its codec
+type codecWriter interface {
+	Codec() webrtc.RTPCodecCapability
+}
+
+// KeyframeDetector reports whether an RTP payload starts a keyframe
+type KeyframeDetector func(payload []byte) bool
+
+// Config configures a Splicer
+type Config struct {
+	// Writer receives the spliced stream
+	Writer RTPWriter
+
+	// ClockRate of the stream. It can be left 0 if Writer has a codec, like
+	// *webrtc.TrackLocalStaticRTP, the clock rate of its codec is used then.
+	ClockRate uint32
+
+	// IsKeyframe detects the packets a switch can happen on. Leave it nil to
+	// switch on any packet, which is fine for audio.
+	IsKeyframe KeyframeDetector
+
+	// RequestKeyframe is called with the source the Splicer switches to once it
+	// starts waiting for a keyframe of it. It is meant to send a PLI upstream
+	// so the switch doesn't wait for the next periodic keyframe. It is called
+	// in its own goroutine.
+	RequestKeyframe func(source string)
+}
+
+// Splicer forwards the packets of one source at a time to a RTPWriter
+type Splicer struct {
+	mu sync.Mutex
+
+	writer          RTPWriter
+	clockRate       uint32
+	isKeyframe      KeyframeDetector
+	requestKeyframe func(source string)
+
+	active  string
+	pending *pendingSplice
+
+	// Rewriting of the active source into the outgoing stream
+	sequenceNumberOffset uint16
+	timestampOffset      uint32
+
+	// Last packet written, used to keep the outgoing stream continuous
+	started            bool
+	lastSequenceNumber uint16
+	lastTimestamp      uint32
+	lastWrite          time.Time
+
+	now func() time.Time
+}
+
+type pendingSplice struct {
+	source string
+
+	// cut is true if the active source is cut at timestamp, otherwise it
+	// plays until the keyframe of source arrives
+	cut       bool
+	timestamp uint32
+	reached   bool
+
+	keyframeRequested bool
+}
+
+// New creates a Splicer. No source is active until Splice is called.
+func New(config Config) (*Splicer, error) {
+	if config.Writer == nil {
+		return nil, errNilWriter
+	}
+
+	clockRate := config.ClockRate
+	if track, ok := config.Writer.(codecWriter); ok && clockRate == 0 {
+		clockRate = track.Codec().ClockRate
+	}
+	if clockRate == 0 {
+		return nil, errNoClockRate
+	}
+
+	return &Splicer{
+		writer:          config.Writer,
+		clockRate:       clockRate,
+		isKeyframe:      config.IsKeyframe,
+		requestKeyframe: config.RequestKeyframe,
+		now:             time.Now,
+	}, nil
+}
+
+// Active returns the source being forwarded, empty if there is none
+func (s *Splicer) Active() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active
+}
+
+// Splice switches to source on its next keyframe. The active source is
+// forwarded until then. It replaces any switch which is still pending.
+func (s *Splicer) Splice(source string) error {
+	return s.schedule(&pendingSplice{source: source, reached: true})
+}
+
+// SpliceAt switches to source once the active source reaches timestamp,
+// which is in the RTP timestamp space of the active source. Packets of the
+// active source from timestamp on are dropped, and the outgoing stream
+// resumes with the first keyframe of source. It replaces any switch which is
+// still pending.
+func (s *Splicer) SpliceAt(source string, timestamp uint32) error {
+	return s.schedule(&pendingSplice{source: source, cut: true, timestamp: timestamp})
+}
+
+func (s *Splicer) schedule(pending *pendingSplice) error {
+	if pending.source == "" {
+		return errEmptySource
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case pending.source == s.active:
+		return errSameSource
+	case pending.cut && s.active == "":
+		return errNoActiveSource
+	}
+
+	s.pending = pending
+	if pending.reached {
+		s.requestKeyframeLocked()
+	}
+
+	return nil
+}
+
+// WriteRTP hands a packet of source to the Splicer. It is written to the
+// outgoing stream if source is active, and dropped otherwise.
+func (s *Splicer) WriteRTP(source string, p *rtp.Packet) error {
+	s.mu.Lock()
+
+	if pending := s.pending; pending != nil {
+		switch {
+		case source == s.active && pending.cut && !pending.reached:
+			if int32(p.Timestamp-pending.timestamp) >= 0 {
+				pending.reached = true
+				s.requestKeyframeLocked()
+			}
+		case source == pending.source && pending.reached && (s.isKeyframe == nil || s.isKeyframe(p.Payload)):
+			s.switchTo(source, p)
+		}
+
+		if source == s.active && s.pending != nil && s.pending.cut && s.pending.reached {
+			s.mu.Unlock()
+			return nil
+		}
+	}
+
+	if source != s.active {
+		s.mu.Unlock()
+		return nil
+	}
+
+	out := *p
+	out.SequenceNumber += s.sequenceNumberOffset
+	out.Timestamp += s.timestampOffset
+
+	s.started = true
+	s.lastSequenceNumber = out.SequenceNumber
+	s.lastTimestamp = out.Timestamp
+	s.lastWrite = s.now()
+	s.mu.Unlock()
+
+	return s.writer.WriteRTP(&out)
+}
+
+// switchTo makes source active, first is the packet it starts with
+func (s *Splicer) switchTo(source string, first *rtp.Packet) {
+	s.active = source
+	s.pending = nil
+
+	if !s.started {
+		return
+	}
+
+	// Continue right after the last packet written, and advance the
+	// timestamp by the time elapsed since then
+	elapsed := uint32(s.now().Sub(s.lastWrite).Seconds() * float64(s.clockRate))
+	if elapsed == 0 {
+		elapsed = 1
+	}
+
+	s.sequenceNumberOffset = s.lastSequenceNumber + 1 - first.SequenceNumber
+	s.timestampOffset = s.lastTimestamp + elapsed - first.Timestamp
+}
+
+func (s *Splicer) requestKeyframeLocked() {
+	if s.pending.keyframeRequested || s.requestKeyframe == nil || s.isKeyframe == nil {
+		return
+	}
+
+	s.pending.keyframeRequested = true
+	go s.requestKeyframe(s.pending.source)
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package splicer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+type testWriter struct {
+	packets []rtp.Packet
+}
+
+func (w *testWriter) WriteRTP(p *rtp.Packet) error {
+	w.packets = append(w.packets, *p)
+	return nil
+}
+
+func packet(sequenceNumber uint16, timestamp uint32, keyframe bool) *rtp.Packet {
+	payload := []byte{0x01}
+	if keyframe {
+		payload = []byte{h264NALUTypeIDR}
+	}
+
+	return &rtp.Packet{
+		Header:  rtp.Header{SequenceNumber: sequenceNumber, Timestamp: timestamp},
+		Payload: payload,
+	}
+}
+
+func newTestSplicer(t *testing.T, requested chan string) (*Splicer, *testWriter, *time.Time) {
+	writer := &testWriter{}
+	s, err := New(Config{
+		Writer:     writer,
+		ClockRate:  90000,
+		IsKeyframe: H264Keyframe,
+		RequestKeyframe: func(source string) {
+			requested <- source
+		},
+	})
+	assert.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+
+	return s, writer, &now
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	assert.ErrorIs(t, err, errNilWriter)
+
+	_, err = New(Config{Writer: &testWriter{}})
+	assert.ErrorIs(t, err, errNoClockRate)
+}
+
+func TestSplicer_Splice(t *testing.T) {
+	requested := make(chan string, 2)
+	s, writer, now := newTestSplicer(t, requested)
+
+	assert.ErrorIs(t, s.SpliceAt("main", 0), errNoActiveSource)
+	assert.NoError(t, s.Splice("main"))
+	assert.Equal(t, "main", <-requested)
+
+	// Nothing is forwarded before the first keyframe
+	assert.NoError(t, s.WriteRTP("main", packet(10, 1000, false)))
+	assert.NoError(t, s.WriteRTP("main", packet(11, 1000, true)))
+	assert.NoError(t, s.WriteRTP("main", packet(12, 4000, false)))
+	assert.Equal(t, "main", s.Active())
+	assert.ErrorIs(t, s.Splice("main"), errSameSource)
+
+	// The active source plays until the new one sends a keyframe
+	assert.NoError(t, s.Splice("ad"))
+	assert.Equal(t, "ad", <-requested)
+	assert.NoError(t, s.WriteRTP("ad", packet(500, 70000, false)))
+	assert.NoError(t, s.WriteRTP("main", packet(13, 7000, false)))
+
+	*now = now.Add(100 * time.Millisecond)
+	assert.NoError(t, s.WriteRTP("ad", packet(501, 73000, true)))
+	assert.NoError(t, s.WriteRTP("main", packet(14, 10000, false)))
+	assert.NoError(t, s.WriteRTP("ad", packet(502, 76000, false)))
+	assert.Equal(t, "ad", s.Active())
+
+	sequenceNumbers := []uint16{}
+	timestamps := []uint32{}
+	for _, p := range writer.packets {
+		sequenceNumbers = append(sequenceNumbers, p.SequenceNumber)
+		timestamps = append(timestamps, p.Timestamp)
+	}
+	assert.Equal(t, []uint16{11, 12, 13, 14, 15}, sequenceNumbers)
+	assert.Equal(t, []uint32{1000, 4000, 7000, 16000, 19000}, timestamps)
+}
+
+func TestSplicer_SpliceAt(t *testing.T) {
+	requested := make(chan string, 2)
+	s, writer, _ := newTestSplicer(t, requested)
+
+	assert.NoError(t, s.Splice("main"))
+	<-requested
+	assert.NoError(t, s.WriteRTP("main", packet(1, 0, true)))
+
+	assert.NoError(t, s.SpliceAt("ad", 6000))
+
+	// The new source isn't used before the cut point, even on a keyframe
+	assert.NoError(t, s.WriteRTP("ad", packet(100, 50000, true)))
+	assert.NoError(t, s.WriteRTP("main", packet(2, 3000, false)))
+	select {
+	case <-requested:
+		assert.Fail(t, "keyframe requested before the cut point")
+	default:
+	}
+
+	// From the cut point on the active source is dropped
+	assert.NoError(t, s.WriteRTP("main", packet(3, 6000, false)))
+	assert.Equal(t, "ad", <-requested)
+	assert.NoError(t, s.WriteRTP("ad", packet(101, 53000, false)))
+	assert.NoError(t, s.WriteRTP("main", packet(4, 9000, false)))
+	assert.NoError(t, s.WriteRTP("ad", packet(102, 56000, true)))
+	assert.Equal(t, "ad", s.Active())
+
+	assert.Len(t, writer.packets, 3)
+	assert.Equal(t, uint16(3), writer.packets[2].SequenceNumber)
+	assert.Equal(t, uint32(3001), writer.packets[2].Timestamp)
+}
+
+func TestH264Keyframe(t *testing.T) {
+	assert.False(t, H264Keyframe(nil))
+	assert.True(t, H264Keyframe([]byte{0x65}))
+	assert.True(t, H264Keyframe([]byte{0x67, 0x42}))
+	assert.False(t, H264Keyframe([]byte{0x41}))
+	assert.True(t, H264Keyframe([]byte{0x7C, 0x85}))
+	assert.False(t, H264Keyframe([]byte{0x7C, 0x05}))
+	assert.True(t, H264Keyframe([]byte{0x78, 0x00, 0x02, 0x09, 0xF0, 0x00, 0x01, 0x67}))
+	assert.False(t, H264Keyframe([]byte{0x78, 0x00, 0x02, 0x09, 0xF0}))
+}
+
+func TestVP8Keyframe(t *testing.T) {
+	assert.False(t, VP8Keyframe(nil))
+	assert.True(t, VP8Keyframe([]byte{0x10, 0x00}))
+	assert.False(t, VP8Keyframe([]byte{0x10, 0x01}))
+	assert.False(t, VP8Keyframe([]byte{0x00, 0x00}))
+	assert.True(t, VP8Keyframe([]byte{0x90, 0x80, 0x81, 0x23, 0x00}))
+}
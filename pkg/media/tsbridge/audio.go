@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package tsbridge
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	opusControlHeaderPrefix = 0x7FE0
+	opusControlHeaderMask   = 0xFFE0
+
+	opusStartTrimFlag        = 0x10
+	opusEndTrimFlag          = 0x08
+	opusControlExtensionFlag = 0x04
+
+	adtsHeaderLength = 7
+	aacFrameSamples  = 1024
+)
+
+var (
+	errBadOpusControlHeader = errors.New("opus access unit does not start with a control header")
+	errShortOpusAccessUnit  = errors.New("opus access unit is truncated")
+	errBadADTSSyncWord      = errors.New("AAC frame does not start with an ADTS sync word")
+	errShortADTSFrame       = errors.New("ADTS frame is truncated")
+	errBadADTSSampleRate    = errors.New("ADTS frame has an invalid sampling frequency index")
+)
+
+// https://wiki.multimedia.cx/index.php/MPEG-4_Audio#Sampling_Frequencies
+var adtsSampleRates = []int{ // nolint:gochecknoglobals
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+// splitOpusAccessUnits returns the Opus packets of a PES, stripping their control headers
+// https://opus-codec.org/docs/ETSI_TS_opus-v0.1.3-draft.pdf
+func splitOpusAccessUnits(data []byte) ([][]byte, error) {
+	packets := [][]byte{}
+	for len(data) > 0 {
+		if len(data) < 2 || (uint16(data[0])<<8|uint16(data[1]))&opusControlHeaderMask != opusControlHeaderPrefix {
+			return nil, errBadOpusControlHeader
+		}
+		flags := data[1]
+		data = data[2:]
+
+		size := 0
+		for {
+			if len(data) == 0 {
+				return nil, errShortOpusAccessUnit
+			}
+			b := data[0]
+			data = data[1:]
+			size += int(b)
+			if b != 0xFF {
+				break
+			}
+		}
+
+		skip := 0
+		if flags&opusStartTrimFlag != 0 {
+			skip += 2
+		}
+		if flags&opusEndTrimFlag != 0 {
+			skip += 2
+		}
+		if skip > len(data) {
+			return nil, errShortOpusAccessUnit
+		}
+		data = data[skip:]
+
+		if flags&opusControlExtensionFlag != 0 {
+			if len(data) == 0 || 1+int(data[0]) > len(data) {
+				return nil, errShortOpusAccessUnit
+			}
+			data = data[1+int(data[0]):]
+		}
+
+		if size > len(data) {
+			return nil, errShortOpusAccessUnit
+		}
+		packets = append(packets, data[:size])
+		data = data[size:]
+	}
+	return packets, nil
+}
+
+// opusPacketDuration returns the duration of an Opus packet from its TOC byte
+// https://datatracker.ietf.org/doc/html/rfc6716#section-3.1
+func opusPacketDuration(packet []byte) time.Duration {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	var frameDuration time.Duration
+	switch config := packet[0] >> 3; {
+	case config < 12: // SILK
+		frameDuration = []time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // Hybrid
+		frameDuration = []time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT
+		frameDuration = []time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	frames := 1
+	switch packet[0] & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3F)
+	}
+
+	return frameDuration * time.Duration(frames)
+}
+
+type adtsFrame struct {
+	data     []byte
+	duration time.Duration
+}
+
+// splitADTSFrames returns the ADTS frames of a PES
+// https://wiki.multimedia.cx/index.php/ADTS
+func splitADTSFrames(data []byte) ([]adtsFrame, error) {
+	frames := []adtsFrame{}
+	for len(data) > 0 {
+		if len(data) < adtsHeaderLength {
+			return nil, errShortADTSFrame
+		}
+		if data[0] != 0xFF || data[1]&0xF0 != 0xF0 {
+			return nil, errBadADTSSyncWord
+		}
+
+		sampleRateIndex := int(data[2]>>2) & 0x0F
+		if sampleRateIndex >= len(adtsSampleRates) {
+			return nil, errBadADTSSampleRate
+		}
+
+		frameLength := int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5]>>5)
+		if frameLength < adtsHeaderLength || frameLength > len(data) {
+			return nil, errShortADTSFrame
+		}
+
+		rawDataBlocks := int(data[6]&0x03) + 1
+		frames = append(frames, adtsFrame{
+			data:     data[:frameLength],
+			duration: time.Duration(rawDataBlocks*aacFrameSamples) * time.Second / time.Duration(adtsSampleRates[sampleRateIndex]),
+		})
+		data = data[frameLength:]
+	}
+	return frames, nil
+}
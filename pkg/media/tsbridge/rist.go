@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package tsbridge
+
+import (
+	"io"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	ristMaxDatagramSize = 1500
+
+	// RTCP packet types from SR (200) to APP (204). Their second byte looks
+	// like the marker bit and payload type of an RTP packet, so RTCP on a
+	// muxed port unmarshals as RTP.
+	rtcpPacketTypeFirst = 200
+	rtcpPacketTypeLast  = 204
+)
+
+type ristReader struct {
+	stream    io.Reader
+	datagram  []byte
+	remainder []byte
+}
+
+// NewRISTReader returns the MPEG-TS carried by a RIST Simple Profile (VSF TR-06-1)
+// stream, where every Read of in returns one RTP datagram, e.g. a *net.UDPConn.
+// Retransmission requests and packet reordering are not performed.
+func NewRISTReader(in io.Reader) io.Reader {
+	return &ristReader{
+		stream:   in,
+		datagram: make([]byte, ristMaxDatagramSize),
+	}
+}
+
+func (r *ristReader) Read(b []byte) (int, error) {
+	for len(r.remainder) == 0 {
+		n, err := r.stream.Read(r.datagram)
+		if err != nil {
+			return 0, err
+		}
+		if isRTCP(r.datagram[:n]) {
+			continue
+		}
+
+		packet := &rtp.Packet{}
+		if err = packet.Unmarshal(r.datagram[:n]); err != nil {
+			continue // not RTP
+		}
+		r.remainder = packet.Payload
+	}
+
+	n := copy(b, r.remainder)
+	r.remainder = r.remainder[n:]
+	return n, nil
+}
+
+func isRTCP(datagram []byte) bool {
+	return len(datagram) >= 2 && datagram[1] >= rtcpPacketTypeFirst && datagram[1] <= rtcpPacketTypeLast
+}
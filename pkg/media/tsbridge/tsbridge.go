@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+// Package tsbridge bridges MPEG-TS contribution feeds, as carried by SRT and
+// RIST, into TrackLocals that can be added to a PeerConnection.
+//
+// H264 video and Opus audio are forwarded as is. AAC audio can't be sent over
+// WebRTC, it is handed to an AudioTranscoder if one is configured and dropped otherwise.
+//
+// SRT connections deliver the transport stream as messages, so an SRT library's
+// connection can be passed to New directly. RIST Simple Profile streams are RTP,
+// use NewRISTReader to unwrap them first.
+package tsbridge
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/tsreader"
+)
+
+const (
+	defaultStreamID = "tsbridge"
+
+	opusRegistrationID = "Opus"
+
+	ptsClockRate = 90000
+	ptsMask      = 1<<33 - 1
+)
+
+var errNilStream = errors.New("stream is nil")
+
+// AudioTranscoder converts audio that can't be sent over WebRTC to Opus
+type AudioTranscoder interface {
+	// Transcode is called with every AAC frame, including its ADTS header,
+	// and returns the Opus samples to write to the audio track, if any.
+	Transcode(frame []byte, duration time.Duration) ([]media.Sample, error)
+}
+
+// Config configures a Bridge
+type Config struct {
+	// StreamID is the stream ID of the created tracks. Defaults to "tsbridge".
+	StreamID string
+
+	// AudioTranscoder converts AAC audio to Opus. Without one AAC audio is dropped.
+	AudioTranscoder AudioTranscoder
+}
+
+// Bridge demuxes a MPEG-TS stream and writes its first video and audio
+// elementary streams to a pair of TrackLocals
+type Bridge struct {
+	reader          *tsreader.TSReader
+	audioTranscoder AudioTranscoder
+
+	videoTrack, audioTrack *webrtc.TrackLocalStaticSample
+
+	videoPID, audioPID       uint16
+	hasVideoPID, hasAudioPID bool
+
+	// Video durations are only known once the next access unit arrives
+	pendingVideo     *media.Sample
+	pendingVideoPTS  uint64
+	lastVideoPTSDiff uint64
+}
+
+// New creates a Bridge reading the MPEG-TS stream from in. Run must be called to start bridging.
+func New(in io.Reader, config Config) (*Bridge, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+
+	reader, err := tsreader.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+
+	streamID := config.StreamID
+	if streamID == "" {
+		streamID = defaultStreamID
+	}
+
+	videoTrack, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264}, "video", streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	audioTrack, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bridge{
+		reader:          reader,
+		audioTranscoder: config.AudioTranscoder,
+		videoTrack:      videoTrack,
+		audioTrack:      audioTrack,
+	}, nil
+}
+
+// VideoTrack returns the H264 track the video of the stream is written to
+func (b *Bridge) VideoTrack() *webrtc.TrackLocalStaticSample {
+	return b.videoTrack
+}
+
+// AudioTrack returns the Opus track the audio of the stream is written to
+func (b *Bridge) AudioTrack() *webrtc.TrackLocalStaticSample {
+	return b.audioTrack
+}
+
+// Run bridges the stream until it ends or fails. It returns nil once the stream ends.
+func (b *Bridge) Run() error {
+	for {
+		pes, err := b.reader.ReadPES()
+		if errors.Is(err, io.EOF) {
+			return b.flushVideo()
+		} else if err != nil {
+			return err
+		}
+
+		switch {
+		case pes.StreamType == tsreader.StreamTypeH264 && b.selectVideo(pes.PID):
+			err = b.writeVideo(pes)
+		case pes.StreamType == tsreader.StreamTypePrivateData && pes.RegistrationID == opusRegistrationID && b.selectAudio(pes.PID):
+			err = b.writeOpus(pes)
+		case pes.StreamType == tsreader.StreamTypeAAC && b.audioTranscoder != nil && b.selectAudio(pes.PID):
+			err = b.writeAAC(pes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Bridge) selectVideo(pid uint16) bool {
+	if !b.hasVideoPID {
+		b.videoPID, b.hasVideoPID = pid, true
+	}
+	return b.videoPID == pid
+}
+
+func (b *Bridge) selectAudio(pid uint16) bool {
+	if !b.hasAudioPID {
+		b.audioPID, b.hasAudioPID = pid, true
+	}
+	return b.audioPID == pid
+}
+
+func (b *Bridge) writeVideo(pes *tsreader.PES) error {
+	if !pes.HasPTS {
+		return nil
+	}
+
+	if b.pendingVideo != nil {
+		b.lastVideoPTSDiff = (pes.PTS - b.pendingVideoPTS) & ptsMask
+		b.pendingVideo.Duration = ptsToDuration(b.lastVideoPTSDiff)
+		if err := b.videoTrack.WriteSample(*b.pendingVideo); err != nil {
+			return err
+		}
+	}
+
+	b.pendingVideo = &media.Sample{Data: pes.Data}
+	b.pendingVideoPTS = pes.PTS
+	return nil
+}
+
+func (b *Bridge) flushVideo() error {
+	if b.pendingVideo == nil {
+		return nil
+	}
+
+	b.pendingVideo.Duration = ptsToDuration(b.lastVideoPTSDiff)
+	err := b.videoTrack.WriteSample(*b.pendingVideo)
+	b.pendingVideo = nil
+	return err
+}
+
+func (b *Bridge) writeOpus(pes *tsreader.PES) error {
+	packets, err := splitOpusAccessUnits(pes.Data)
+	if err != nil {
+		return err
+	}
+
+	for _, packet := range packets {
+		if err := b.audioTrack.WriteSample(media.Sample{Data: packet, Duration: opusPacketDuration(packet)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bridge) writeAAC(pes *tsreader.PES) error {
+	frames, err := splitADTSFrames(pes.Data)
+	if err != nil {
+		return err
+	}
+
+	for _, frame := range frames {
+		samples, err := b.audioTranscoder.Transcode(frame.data, frame.duration)
+		if err != nil {
+			return err
+		}
+
+		for _, sample := range samples {
+			if err := b.audioTrack.WriteSample(sample); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ptsToDuration(pts uint64) time.Duration {
+	return time.Duration(pts) * time.Second / ptsClockRate
+}
@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package tsbridge
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+type testTranscoder struct {
+	frames    [][]byte
+	durations []time.Duration
+}
+
+func (t *testTranscoder) Transcode(frame []byte, duration time.Duration) ([]media.Sample, error) {
+	t.frames = append(t.frames, frame)
+	t.durations = append(t.durations, duration)
+	return []media.Sample{{Data: []byte{0xF8}, Duration: duration}}, nil
+}
+
+// buildADTSFrame returns a 48kHz ADTS frame around payload
+func buildADTSFrame(payload []byte) []byte {
+	frameLength := adtsHeaderLength + len(payload)
+	header := []byte{0xFF, 0xF1, 0x4C, 0x80 | byte(frameLength>>11), byte(frameLength >> 3), byte(frameLength<<5) | 0x1F, 0xFC}
+	return append(header, payload...)
+}
+
+// buildTS returns a single program transport stream with one elementary stream
+// on PID 0x100, with one PES per frame
+func buildTS(streamType byte, frames ...[]byte) []byte {
+	packet := func(pid uint16, counter uint8, payload []byte) []byte {
+		out := make([]byte, 188)
+		out[0], out[1], out[2], out[3] = 0x47, 0x40|byte(pid>>8), byte(pid), 0x30|counter&0x0F
+		stuffing := 188 - 4 - len(payload)
+		out[4] = byte(stuffing - 1)
+		for i := 5; i < 4+stuffing; i++ {
+			out[i] = 0xFF
+		}
+		if stuffing > 1 {
+			out[5] = 0x00
+		}
+		copy(out[4+stuffing:], payload)
+		return out
+	}
+
+	pat := []byte{0x00, 0x00, 0xB0, 0x0D, 0x00, 0x01, 0xC1, 0x00, 0x00, 0x00, 0x01, 0xF0, 0x00, 0, 0, 0, 0}
+	pmt := []byte{0x00, 0x02, 0xB0, 0x12, 0x00, 0x01, 0xC1, 0x00, 0x00, 0xE1, 0x00, 0xF0, 0x00, streamType, 0xE1, 0x00, 0xF0, 0x00, 0, 0, 0, 0}
+
+	stream := append(packet(0x0000, 0, pat), packet(0x1000, 0, pmt)...)
+	for i, frame := range frames {
+		pts := uint64(i) * 1920
+		pes := []byte{0x00, 0x00, 0x01, 0xC0, 0x00, 0x00, 0x80, 0x80, 0x05,
+			byte(0x21 | pts>>29&0x0E), byte(pts >> 22), byte(pts>>14 | 0x01), byte(pts >> 7), byte(pts<<1 | 0x01)}
+		stream = append(stream, packet(0x0100, uint8(i), append(pes, frame...))...)
+	}
+	return stream
+}
+
+func TestSplitOpusAccessUnits(t *testing.T) {
+	first := bytes.Repeat([]byte{0xFC}, 300)
+	second := []byte{0xF8, 0x01}
+
+	// The first access unit has a start trim and a size spanning two bytes
+	data := []byte{0x7F, 0xF0, 0xFF, 0x2D, 0x00, 0x10}
+	data = append(data, first...)
+	data = append(data, 0x7F, 0xE0, 0x02)
+	data = append(data, second...)
+
+	packets, err := splitOpusAccessUnits(data)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{first, second}, packets)
+
+	_, err = splitOpusAccessUnits([]byte{0x00, 0x00})
+	assert.Equal(t, errBadOpusControlHeader, err)
+
+	_, err = splitOpusAccessUnits([]byte{0x7F, 0xE0, 0x05, 0x01})
+	assert.Equal(t, errShortOpusAccessUnit, err)
+}
+
+func TestOpusPacketDuration(t *testing.T) {
+	for _, test := range []struct {
+		packet   []byte
+		duration time.Duration
+	}{
+		{[]byte{0xF8}, 20 * time.Millisecond},                 // CELT 20ms, one frame
+		{[]byte{0xF9}, 40 * time.Millisecond},                 // CELT 20ms, two frames
+		{[]byte{0xF3, 0x03}, 3 * 10 * time.Millisecond},       // CELT 10ms, three frames
+		{[]byte{0x08}, 20 * time.Millisecond},                 // SILK 20ms
+		{[]byte{0x68}, 20 * time.Millisecond},                 // Hybrid 20ms
+		{[]byte{0x80}, 2500 * time.Microsecond},               // CELT 2.5ms
+		{[]byte{}, 0},                                         // empty
+		{[]byte{0xFB}, 0},                                     // code 3 without frame count
+		{[]byte{0x18}, 60 * time.Millisecond},                 // SILK 60ms
+		{[]byte{0x19, 0x00}, 2 * 60 * time.Millisecond},       // SILK 60ms, two frames
+		{[]byte{0xFA, 0x00, 0x00}, 2 * 20 * time.Millisecond}, // CELT 20ms, two VBR frames
+	} {
+		assert.Equal(t, test.duration, opusPacketDuration(test.packet))
+	}
+}
+
+func TestSplitADTSFrames(t *testing.T) {
+	first, second := buildADTSFrame([]byte{0x01, 0x02}), buildADTSFrame([]byte{0x03})
+
+	frames, err := splitADTSFrames(append(append([]byte{}, first...), second...))
+	assert.NoError(t, err)
+	assert.Len(t, frames, 2)
+	assert.Equal(t, first, frames[0].data)
+	assert.Equal(t, second, frames[1].data)
+	assert.Equal(t, time.Second*aacFrameSamples/48000, frames[0].duration)
+
+	_, err = splitADTSFrames([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	assert.Equal(t, errBadADTSSyncWord, err)
+
+	_, err = splitADTSFrames(first[:len(first)-1])
+	assert.Equal(t, errShortADTSFrame, err)
+}
+
+func TestRISTReader(t *testing.T) {
+	datagrams := [][]byte{}
+	for i, payload := range [][]byte{{0x47, 0x01}, {0x47, 0x02, 0x03}} {
+		raw, err := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 33, SequenceNumber: uint16(i)},
+			Payload: payload,
+		}).Marshal()
+		assert.NoError(t, err)
+		datagrams = append(datagrams, raw)
+	}
+	// RTCP would unmarshal as RTP
+	rtcpDatagram, err := (&rtcp.SenderReport{SSRC: 1}).Marshal()
+	assert.NoError(t, err)
+	datagrams = append(datagrams[:1], append([][]byte{{0x00}, rtcpDatagram}, datagrams[1:]...)...)
+
+	reader := NewRISTReader(&datagramReader{datagrams: datagrams})
+
+	out, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x47, 0x01, 0x47, 0x02, 0x03}, out)
+}
+
+type datagramReader struct {
+	datagrams [][]byte
+}
+
+func (d *datagramReader) Read(b []byte) (int, error) {
+	if len(d.datagrams) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, d.datagrams[0])
+	d.datagrams = d.datagrams[1:]
+	return n, nil
+}
+
+func TestBridge_AudioTranscoder(t *testing.T) {
+	_, err := New(nil, Config{})
+	assert.Equal(t, errNilStream, err)
+
+	frames := [][]byte{buildADTSFrame([]byte{0x01}), buildADTSFrame([]byte{0x02})}
+
+	transcoder := &testTranscoder{}
+	bridge, err := New(bytes.NewReader(buildTS(0x0F, frames...)), Config{AudioTranscoder: transcoder})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultStreamID, bridge.AudioTrack().StreamID())
+	assert.Equal(t, "video", bridge.VideoTrack().ID())
+
+	assert.NoError(t, bridge.Run())
+	assert.Equal(t, frames, transcoder.frames)
+	assert.Equal(t, []time.Duration{time.Second * aacFrameSamples / 48000, time.Second * aacFrameSamples / 48000}, transcoder.durations)
+}
+
+func TestBridge_Video(t *testing.T) {
+	bridge, err := New(bytes.NewReader(buildTS(0x1B, []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xF0}, []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xF0})), Config{StreamID: "feed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "feed", bridge.VideoTrack().StreamID())
+
+	assert.NoError(t, bridge.Run())
+	assert.Equal(t, uint16(0x0100), bridge.videoPID)
+	assert.Equal(t, ptsToDuration(1920), 1920*time.Second/ptsClockRate)
+	assert.Equal(t, uint64(1920), bridge.lastVideoPTSDiff)
+}
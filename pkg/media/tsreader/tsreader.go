@@ -0,0 +1,373 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package tsreader implements a MPEG-TS demuxer that returns the PES packets
+// of every elementary stream announced in the Program Map Table
+package tsreader
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+const (
+	packetSize = 188
+	syncByte   = 0x47
+
+	patPID = 0x0000
+
+	pmtTableID = 0x02
+
+	registrationDescriptorTag = 0x05
+
+	readBufferSize = 1 << 16
+)
+
+// StreamType is the stream_type of an elementary stream as signaled in the PMT
+type StreamType uint8
+
+// StreamType values that are commonly carried in contribution feeds
+const (
+	StreamTypePrivateData StreamType = 0x06
+	StreamTypeAAC         StreamType = 0x0F
+	StreamTypeH264        StreamType = 0x1B
+	StreamTypeH265        StreamType = 0x24
+)
+
+var (
+	errNilStream     = errors.New("stream is nil")
+	errBadSyncByte   = errors.New("packet does not start with the MPEG-TS sync byte")
+	errShortPSI      = errors.New("PSI section is truncated")
+	errShortPES      = errors.New("PES header is truncated")
+	errBadPESPrefix  = errors.New("PES packet does not start with a start code prefix")
+	errShortTSPacket = errors.New("stream ended in the middle of a packet")
+)
+
+// PES is a complete Packetized Elementary Stream packet
+type PES struct {
+	PID        uint16
+	StreamType StreamType
+
+	// RegistrationID is the format_identifier of the registration descriptor
+	// of the elementary stream, e.g. "Opus". It is empty if there is none.
+	RegistrationID string
+
+	// PTS and DTS are in 90kHz units, and are only valid if the matching Has flag is set
+	PTS, DTS       uint64
+	HasPTS, HasDTS bool
+
+	Data []byte
+}
+
+type elementaryStream struct {
+	streamType     StreamType
+	registrationID string
+
+	continuityCounter uint8
+	hasCounter        bool
+
+	buffer    []byte
+	collected bool
+}
+
+// TSReader reads a MPEG-TS stream and returns the PES packets it carries
+type TSReader struct {
+	stream     io.Reader
+	readBuffer []byte
+	tmpReadBuf []byte
+
+	pmtPIDs map[uint16]struct{}
+	streams map[uint16]*elementaryStream
+
+	pending []*PES
+	eof     bool
+}
+
+// NewReader creates a new TSReader. The stream may deliver any number of
+// 188 byte packets per Read, as SRT and RIST do with their datagrams.
+func NewReader(in io.Reader) (*TSReader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+
+	return &TSReader{
+		stream:     in,
+		tmpReadBuf: make([]byte, readBufferSize),
+		pmtPIDs:    map[uint16]struct{}{},
+		streams:    map[uint16]*elementaryStream{},
+	}, nil
+}
+
+// ReadPES returns the next complete PES packet. Once the stream ends the PES
+// packets still being collected are returned, followed by io.EOF.
+func (r *TSReader) ReadPES() (*PES, error) {
+	for len(r.pending) == 0 {
+		if r.eof {
+			return nil, io.EOF
+		}
+
+		packet, err := r.readPacket()
+		switch {
+		case errors.Is(err, io.EOF):
+			r.eof = true
+			r.flush()
+		case err != nil:
+			return nil, err
+		default:
+			if err = r.handlePacket(packet); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pes := r.pending[0]
+	r.pending = r.pending[1:]
+	return pes, nil
+}
+
+func (r *TSReader) readPacket() ([]byte, error) {
+	for len(r.readBuffer) < packetSize {
+		n, err := r.stream.Read(r.tmpReadBuf)
+		if n > 0 {
+			r.readBuffer = append(r.readBuffer, r.tmpReadBuf[:n]...)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(r.readBuffer) != 0 {
+				return nil, errShortTSPacket
+			}
+			return nil, err
+		}
+	}
+
+	if r.readBuffer[0] != syncByte {
+		return nil, errBadSyncByte
+	}
+
+	packet := r.readBuffer[:packetSize]
+	r.readBuffer = r.readBuffer[packetSize:]
+	return packet, nil
+}
+
+func (r *TSReader) handlePacket(packet []byte) error {
+	payloadUnitStart := packet[1]&0x40 != 0
+	pid := uint16(packet[1]&0x1F)<<8 | uint16(packet[2])
+	adaptationFieldControl := (packet[3] >> 4) & 0x03
+	continuityCounter := packet[3] & 0x0F
+
+	payload := packet[4:]
+	if adaptationFieldControl&0x02 != 0 {
+		adaptationFieldLength := int(payload[0])
+		if adaptationFieldLength+1 > len(payload) {
+			return nil
+		}
+		payload = payload[adaptationFieldLength+1:]
+	}
+	if adaptationFieldControl&0x01 == 0 {
+		return nil
+	}
+
+	if pid == patPID {
+		return r.handlePAT(payload, payloadUnitStart)
+	}
+	if _, ok := r.pmtPIDs[pid]; ok {
+		return r.handlePMT(payload, payloadUnitStart)
+	}
+
+	stream, ok := r.streams[pid]
+	if !ok {
+		return nil
+	}
+
+	// Drop duplicate packets, and the PES being collected if packets were lost
+	if stream.hasCounter {
+		if continuityCounter == stream.continuityCounter {
+			return nil
+		}
+		if continuityCounter != (stream.continuityCounter+1)&0x0F {
+			stream.buffer, stream.collected = nil, false
+		}
+	}
+	stream.continuityCounter, stream.hasCounter = continuityCounter, true
+
+	if payloadUnitStart {
+		if err := r.emit(pid, stream); err != nil {
+			return err
+		}
+		stream.collected = true
+	}
+	if stream.collected {
+		stream.buffer = append(stream.buffer, payload...)
+	}
+
+	return nil
+}
+
+func psiSection(payload []byte, payloadUnitStart bool) ([]byte, error) {
+	// Sections spanning multiple packets are not supported, all common PATs and PMTs fit in one
+	if !payloadUnitStart {
+		return nil, nil
+	}
+
+	// The adaptation field may fill the whole packet and leave no payload
+	if len(payload) == 0 {
+		return nil, errShortPSI
+	}
+
+	pointerField := int(payload[0])
+	if pointerField+1+3 > len(payload) {
+		return nil, errShortPSI
+	}
+	section := payload[pointerField+1:]
+
+	sectionLength := int(section[1]&0x0F)<<8 | int(section[2])
+	if 3+sectionLength > len(section) || sectionLength < 9 {
+		return nil, errShortPSI
+	}
+
+	// Strip the table header and the trailing CRC32
+	return section[:3+sectionLength-4], nil
+}
+
+func (r *TSReader) handlePAT(payload []byte, payloadUnitStart bool) error {
+	section, err := psiSection(payload, payloadUnitStart)
+	if err != nil || section == nil {
+		return err
+	}
+
+	for entries := section[8:]; len(entries) >= 4; entries = entries[4:] {
+		programNumber := uint16(entries[0])<<8 | uint16(entries[1])
+		if programNumber == 0 {
+			continue // network PID
+		}
+		r.pmtPIDs[uint16(entries[2]&0x1F)<<8|uint16(entries[3])] = struct{}{}
+	}
+
+	return nil
+}
+
+func (r *TSReader) handlePMT(payload []byte, payloadUnitStart bool) error {
+	section, err := psiSection(payload, payloadUnitStart)
+	if err != nil || section == nil {
+		return err
+	}
+	if section[0] != pmtTableID || len(section) < 12 {
+		return nil
+	}
+
+	programInfoLength := int(section[10]&0x0F)<<8 | int(section[11])
+	if 12+programInfoLength > len(section) {
+		return errShortPSI
+	}
+
+	for entries := section[12+programInfoLength:]; len(entries) >= 5; {
+		streamType := StreamType(entries[0])
+		pid := uint16(entries[1]&0x1F)<<8 | uint16(entries[2])
+		esInfoLength := int(entries[3]&0x0F)<<8 | int(entries[4])
+		if 5+esInfoLength > len(entries) {
+			return errShortPSI
+		}
+
+		registrationID := registrationIDFromDescriptors(entries[5 : 5+esInfoLength])
+		if stream, ok := r.streams[pid]; !ok || stream.streamType != streamType || stream.registrationID != registrationID {
+			r.streams[pid] = &elementaryStream{streamType: streamType, registrationID: registrationID}
+		}
+
+		entries = entries[5+esInfoLength:]
+	}
+
+	return nil
+}
+
+func registrationIDFromDescriptors(descriptors []byte) string {
+	for len(descriptors) >= 2 {
+		tag, length := descriptors[0], int(descriptors[1])
+		if 2+length > len(descriptors) {
+			return ""
+		}
+		if tag == registrationDescriptorTag && length >= 4 {
+			return string(descriptors[2:6])
+		}
+		descriptors = descriptors[2+length:]
+	}
+	return ""
+}
+
+// emit queues the PES collected so far for the given stream
+func (r *TSReader) emit(pid uint16, stream *elementaryStream) error {
+	buffer := stream.buffer
+	stream.buffer, stream.collected = nil, false
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	pes, err := parsePES(buffer)
+	if err != nil {
+		return err
+	}
+	pes.PID = pid
+	pes.StreamType = stream.streamType
+	pes.RegistrationID = stream.registrationID
+
+	r.pending = append(r.pending, pes)
+	return nil
+}
+
+func (r *TSReader) flush() {
+	pids := make([]int, 0, len(r.streams))
+	for pid, stream := range r.streams {
+		if stream.collected {
+			pids = append(pids, int(pid))
+		}
+	}
+	sort.Ints(pids)
+
+	for _, pid := range pids {
+		if err := r.emit(uint16(pid), r.streams[uint16(pid)]); err != nil {
+			continue // a truncated trailing PES is not worth failing the stream over
+		}
+	}
+}
+
+func parsePES(buffer []byte) (*PES, error) {
+	if len(buffer) < 9 {
+		return nil, errShortPES
+	}
+	if buffer[0] != 0x00 || buffer[1] != 0x00 || buffer[2] != 0x01 {
+		return nil, errBadPESPrefix
+	}
+
+	headerDataLength := int(buffer[8])
+	if 9+headerDataLength > len(buffer) {
+		return nil, errShortPES
+	}
+
+	pes := &PES{}
+	ptsDTSFlags := buffer[7] >> 6
+	if ptsDTSFlags&0x02 != 0 && headerDataLength >= 5 {
+		pes.PTS, pes.HasPTS = parseTimestamp(buffer[9:14]), true
+	}
+	if ptsDTSFlags == 0x03 && headerDataLength >= 10 {
+		pes.DTS, pes.HasDTS = parseTimestamp(buffer[14:19]), true
+	}
+
+	data := buffer[9+headerDataLength:]
+
+	// A non zero PES_packet_length bounds the payload, anything after it is stuffing
+	if pesPacketLength := int(buffer[4])<<8 | int(buffer[5]); pesPacketLength != 0 {
+		if end := 6 + pesPacketLength - 9 - headerDataLength; end >= 0 && end < len(data) {
+			data = data[:end]
+		}
+	}
+
+	pes.Data = append([]byte{}, data...)
+	return pes, nil
+}
+
+func parseTimestamp(b []byte) uint64 {
+	return uint64(b[0]>>1&0x07)<<30 |
+		uint64(b[1])<<22 |
+		uint64(b[2]>>1)<<15 |
+		uint64(b[3])<<7 |
+		uint64(b[4]>>1)
+}
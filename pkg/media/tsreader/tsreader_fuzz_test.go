@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build go1.18
+// +build go1.18
+
+package tsreader
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzReader(f *testing.F) {
+	var patCounter, pmtCounter, videoCounter uint8
+
+	stream := buildTSPackets(patPID, buildPAT(), &patCounter)
+	stream = append(stream, buildTSPackets(testPMTPID, buildPMT(), &pmtCounter)...)
+	stream = append(stream, buildTSPackets(testVideoPID, buildPES(0xE0, 3000, []byte{0x01, 0x02}), &videoCounter)...)
+	f.Add(stream)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reader, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Malformed streams may fail, but must never panic
+		for i := 0; i <= len(data)/packetSize+1; i++ {
+			if _, err = reader.ReadPES(); err != nil {
+				return
+			}
+		}
+	})
+}
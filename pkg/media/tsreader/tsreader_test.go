@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package tsreader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testPMTPID   = 0x1000
+	testVideoPID = 0x0100
+	testAudioPID = 0x0101
+)
+
+// buildTSPackets splits payload over as many 188 byte packets as needed,
+// padding the last one with an adaptation field
+func buildTSPackets(pid uint16, payload []byte, counter *uint8) []byte {
+	out := []byte{}
+	for first := true; first || len(payload) > 0; first = false {
+		header := []byte{syncByte, byte(pid >> 8 & 0x1F), byte(pid), 0x10 | *counter&0x0F}
+		if first {
+			header[1] |= 0x40
+		}
+		*counter++
+
+		chunk := payload
+		if len(chunk) > packetSize-4 {
+			chunk = chunk[:packetSize-4]
+		}
+		payload = payload[len(chunk):]
+
+		if stuffing := packetSize - 4 - len(chunk); stuffing > 0 {
+			header[3] |= 0x20
+			adaptationField := make([]byte, stuffing)
+			adaptationField[0] = byte(stuffing - 1)
+			if stuffing > 1 {
+				adaptationField[1] = 0x00
+				for i := 2; i < stuffing; i++ {
+					adaptationField[i] = 0xFF
+				}
+			}
+			header = append(header, adaptationField...)
+		}
+
+		out = append(out, header...)
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+func buildPSI(tableID byte, body []byte) []byte {
+	sectionLength := len(body) + 5 + 4
+	section := []byte{0x00, tableID, 0xB0 | byte(sectionLength>>8), byte(sectionLength), 0x00, 0x01, 0xC1, 0x00, 0x00}
+	section = append(section, body...)
+	return append(section, 0x00, 0x00, 0x00, 0x00) // CRC32 is not verified
+}
+
+func buildPAT() []byte {
+	return buildPSI(0x00, []byte{0x00, 0x01, 0xE0 | testPMTPID>>8, testPMTPID & 0xFF})
+}
+
+func buildPMT() []byte {
+	body := []byte{0xE0 | testVideoPID>>8, testVideoPID & 0xFF, 0xF0, 0x00}
+	body = append(body, byte(StreamTypeH264), 0xE0|testVideoPID>>8, testVideoPID&0xFF, 0xF0, 0x00)
+	body = append(body, byte(StreamTypePrivateData), 0xE0|testAudioPID>>8, testAudioPID&0xFF, 0xF0, 0x06,
+		registrationDescriptorTag, 0x04, 'O', 'p', 'u', 's')
+	return buildPSI(pmtTableID, body)
+}
+
+func buildPES(streamID byte, pts uint64, data []byte) []byte {
+	pes := []byte{0x00, 0x00, 0x01, streamID, 0x00, 0x00, 0x80, 0x80, 0x05,
+		byte(0x21 | pts>>29&0x0E), byte(pts >> 22), byte(pts>>14 | 0x01), byte(pts >> 7), byte(pts<<1 | 0x01)}
+	return append(pes, data...)
+}
+
+func TestTSReader_ReadPES(t *testing.T) {
+	var patCounter, pmtCounter, videoCounter, audioCounter uint8
+
+	videoFrame := bytes.Repeat([]byte{0xAB}, 500)
+	opusPacket := []byte{0x7F, 0xE0, 0x03, 0xF8, 0xFF, 0xFE}
+
+	stream := buildTSPackets(patPID, buildPAT(), &patCounter)
+	stream = append(stream, buildTSPackets(testPMTPID, buildPMT(), &pmtCounter)...)
+	stream = append(stream, buildTSPackets(testVideoPID, buildPES(0xE0, 3000, videoFrame), &videoCounter)...)
+	stream = append(stream, buildTSPackets(testAudioPID, buildPES(0xBD, 3600, opusPacket), &audioCounter)...)
+	stream = append(stream, buildTSPackets(testVideoPID, buildPES(0xE0, 6000, videoFrame), &videoCounter)...)
+
+	reader, err := NewReader(bytes.NewReader(stream))
+	assert.NoError(t, err)
+
+	pes, err := reader.ReadPES()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(testVideoPID), pes.PID)
+	assert.Equal(t, StreamTypeH264, pes.StreamType)
+	assert.True(t, pes.HasPTS)
+	assert.Equal(t, uint64(3000), pes.PTS)
+	assert.Equal(t, videoFrame, pes.Data)
+
+	// Remaining PES packets are flushed once the stream ends, ordered by PID
+	pes, err = reader.ReadPES()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(testVideoPID), pes.PID)
+	assert.Equal(t, uint64(6000), pes.PTS)
+
+	pes, err = reader.ReadPES()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(testAudioPID), pes.PID)
+	assert.Equal(t, StreamTypePrivateData, pes.StreamType)
+	assert.Equal(t, "Opus", pes.RegistrationID)
+	assert.Equal(t, uint64(3600), pes.PTS)
+	assert.Equal(t, opusPacket, pes.Data)
+
+	_, err = reader.ReadPES()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTSReader_Errors(t *testing.T) {
+	_, err := NewReader(nil)
+	assert.Equal(t, errNilStream, err)
+
+	reader, err := NewReader(bytes.NewReader(make([]byte, packetSize)))
+	assert.NoError(t, err)
+	_, err = reader.ReadPES()
+	assert.Equal(t, errBadSyncByte, err)
+
+	reader, err = NewReader(bytes.NewReader([]byte{syncByte, 0x00}))
+	assert.NoError(t, err)
+	_, err = reader.ReadPES()
+	assert.Equal(t, errShortTSPacket, err)
+}
+
+func TestTSReader_ContinuityError(t *testing.T) {
+	var patCounter, pmtCounter, videoCounter uint8
+
+	stream := buildTSPackets(patPID, buildPAT(), &patCounter)
+	stream = append(stream, buildTSPackets(testPMTPID, buildPMT(), &pmtCounter)...)
+
+	// Lose the second packet of the first PES
+	firstPES := buildTSPackets(testVideoPID, buildPES(0xE0, 3000, bytes.Repeat([]byte{0x01}, 400)), &videoCounter)
+	stream = append(stream, firstPES[:packetSize]...)
+	stream = append(stream, firstPES[2*packetSize:]...)
+	stream = append(stream, buildTSPackets(testVideoPID, buildPES(0xE0, 6000, []byte{0x02}), &videoCounter)...)
+
+	reader, err := NewReader(bytes.NewReader(stream))
+	assert.NoError(t, err)
+
+	pes, err := reader.ReadPES()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6000), pes.PTS)
+	assert.Equal(t, []byte{0x02}, pes.Data)
+
+	_, err = reader.ReadPES()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTSReader_EmptyPSIPayload(t *testing.T) {
+	// adaptation_field_control is 3, but the adaptation field fills the packet
+	packet := make([]byte, packetSize)
+	packet[0], packet[1], packet[2], packet[3] = syncByte, 0x40|patPID>>8, patPID&0xFF, 0x30
+	packet[4] = packetSize - 5
+	for i := 5; i < packetSize; i++ {
+		packet[i] = 0xFF
+	}
+
+	reader, err := NewReader(bytes.NewReader(packet))
+	assert.NoError(t, err)
+	_, err = reader.ReadPES()
+	assert.Equal(t, errShortPSI, err)
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package rtpmath provides wrap-around aware arithmetic for RTP sequence
+// numbers and timestamps, and conversions between timestamps and durations.
+package rtpmath
+
+import "time"
+
+// SequenceNumberNewer returns true if the sequence number a comes after b,
+// accounting for wrap-around. Of two sequence numbers exactly half the range
+// apart, the larger one is considered newer.
+func SequenceNumberNewer(a, b uint16) bool {
+	diff := a - b
+	if diff == 1<<15 {
+		return a > b
+	}
+
+	return diff != 0 && diff < 1<<15
+}
+
+// SequenceNumberDiff returns the signed number of sequence numbers from b to
+// a, accounting for wrap-around. It is positive if a is newer than b.
+func SequenceNumberDiff(a, b uint16) int {
+	return int(int16(a - b))
+}
+
+// SequenceNumberDistance returns the number of sequence numbers between a
+// and b, regardless of their order, accounting for wrap-around
+func SequenceNumberDistance(a, b uint16) uint16 {
+	diff := int16(a - b)
+	if diff < 0 {
+		return uint16(-diff)
+	}
+
+	return uint16(diff)
+}
+
+// TimestampNewer returns true if the timestamp a comes after b, accounting
+// for wrap-around. Of two timestamps exactly half the range apart, the larger
+// one is considered newer.
+func TimestampNewer(a, b uint32) bool {
+	diff := a - b
+	if diff == 1<<31 {
+		return a > b
+	}
+
+	return diff != 0 && diff < 1<<31
+}
+
+// TimestampDiff returns the signed number of ticks from b to a, accounting
+// for wrap-around. It is positive if a is newer than b.
+func TimestampDiff(a, b uint32) int64 {
+	return int64(int32(a - b))
+}
+
+// TimestampDistance returns the number of ticks between a and b, regardless
+// of their order, accounting for wrap-around
+func TimestampDistance(a, b uint32) uint32 {
+	diff := int32(a - b)
+	if diff < 0 {
+		return uint32(-diff)
+	}
+
+	return uint32(diff)
+}
+
+// TimestampToDuration converts a number of ticks of a clock running at
+// clockRate Hz to a time.Duration. It returns 0 if clockRate is 0.
+func TimestampToDuration(ticks int64, clockRate uint32) time.Duration {
+	if clockRate == 0 {
+		return 0
+	}
+
+	rate := int64(clockRate)
+	// Split into seconds and remainder so large tick counts don't overflow
+	return time.Duration(ticks/rate)*time.Second + time.Duration(ticks%rate*int64(time.Second)/rate)
+}
+
+// DurationToTimestamp converts a time.Duration to a number of ticks of a
+// clock running at clockRate Hz, truncating partial ticks
+func DurationToTimestamp(d time.Duration, clockRate uint32) int64 {
+	rate := int64(clockRate)
+	seconds, remainder := int64(d/time.Second), int64(d%time.Second)
+
+	return seconds*rate + remainder*rate/int64(time.Second)
+}
+
+// SequenceNumberUnwrapper extends 16-bit RTP sequence numbers to 64 bits by
+// counting how often they rolled over. Packets may arrive out of order,
+// a sequence number is placed relative to the newest one seen so far.
+// The zero value is ready to use.
+type SequenceNumberUnwrapper struct {
+	started bool
+	newest  uint64
+}
+
+// Unwrap returns the extended sequence number of seq. Packets older than the
+// first one unwrapped, which would be before the first roll over, keep their
+// 16-bit value.
+func (u *SequenceNumberUnwrapper) Unwrap(seq uint16) uint64 {
+	if !u.started {
+		u.started = true
+		u.newest = uint64(seq)
+
+		return u.newest
+	}
+
+	extended := int64(u.newest) + int64(SequenceNumberDiff(seq, uint16(u.newest)))
+	if extended < 0 {
+		return uint64(seq)
+	}
+	if uint64(extended) > u.newest {
+		u.newest = uint64(extended)
+	}
+
+	return uint64(extended)
+}
+
+// RolloverCount returns how often the sequence numbers rolled over, the ROC
+// of the newest sequence number
+func (u *SequenceNumberUnwrapper) RolloverCount() uint32 {
+	return uint32(u.newest >> 16)
+}
+
+// TimestampUnwrapper extends 32-bit RTP timestamps to 64 bits by counting how
+// often they rolled over. Timestamps may arrive out of order, a timestamp is
+// placed relative to the newest one seen so far. The zero value is ready to
+// use.
+type TimestampUnwrapper struct {
+	started bool
+	newest  uint64
+}
+
+// Unwrap returns the extended timestamp of ts. Timestamps older than the
+// first one unwrapped, which would be before the first roll over, keep their
+// 32-bit value.
+func (u *TimestampUnwrapper) Unwrap(ts uint32) uint64 {
+	if !u.started {
+		u.started = true
+		u.newest = uint64(ts)
+
+		return u.newest
+	}
+
+	extended := int64(u.newest) + TimestampDiff(ts, uint32(u.newest))
+	if extended < 0 {
+		return uint64(ts)
+	}
+	if uint64(extended) > u.newest {
+		u.newest = uint64(extended)
+	}
+
+	return uint64(extended)
+}
+
+// RolloverCount returns how often the timestamps rolled over
+func (u *TimestampUnwrapper) RolloverCount() uint32 {
+	return uint32(u.newest >> 32)
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpmath
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceNumber(t *testing.T) {
+	for i, testCase := range []struct {
+		a, b     uint16
+		newer    bool
+		diff     int
+		distance uint16
+	}{
+		{0, 0, false, 0, 0},
+		{1, 0, true, 1, 1},
+		{0, 1, false, -1, 1},
+		{0, 65535, true, 1, 1},
+		{65535, 0, false, -1, 1},
+		{100, 65500, true, 136, 136},
+		{32768, 0, true, -32768, 32768},
+		{0, 32768, false, -32768, 32768},
+	} {
+		assert.Equal(t, testCase.newer, SequenceNumberNewer(testCase.a, testCase.b), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.diff, SequenceNumberDiff(testCase.a, testCase.b), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.distance, SequenceNumberDistance(testCase.a, testCase.b), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	for i, testCase := range []struct {
+		a, b     uint32
+		newer    bool
+		diff     int64
+		distance uint32
+	}{
+		{0, 0, false, 0, 0},
+		{3000, 0, true, 3000, 3000},
+		{0, 3000, false, -3000, 3000},
+		{1000, 4294966296, true, 2000, 2000},
+		{4294966296, 1000, false, -2000, 2000},
+		{1 << 31, 0, true, -1 << 31, 1 << 31},
+	} {
+		assert.Equal(t, testCase.newer, TimestampNewer(testCase.a, testCase.b), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.diff, TimestampDiff(testCase.a, testCase.b), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.distance, TimestampDistance(testCase.a, testCase.b), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestTimestampDuration(t *testing.T) {
+	for i, testCase := range []struct {
+		ticks     int64
+		clockRate uint32
+		duration  time.Duration
+	}{
+		{90000, 90000, time.Second},
+		{4500, 90000, 50 * time.Millisecond},
+		{960, 48000, 20 * time.Millisecond},
+		{-960, 48000, -20 * time.Millisecond},
+		{90000 * 3600 * 24 * 365, 90000, time.Hour * 24 * 365},
+	} {
+		assert.Equal(t, testCase.duration, TimestampToDuration(testCase.ticks, testCase.clockRate), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.ticks, DurationToTimestamp(testCase.duration, testCase.clockRate), "testCase: %d %v", i, testCase)
+	}
+
+	assert.Equal(t, time.Duration(0), TimestampToDuration(90000, 0))
+}
+
+func TestSequenceNumberUnwrapper(t *testing.T) {
+	u := &SequenceNumberUnwrapper{}
+
+	for i, testCase := range []struct {
+		seq      uint16
+		extended uint64
+		roc      uint32
+	}{
+		{65530, 65530, 0},
+		{65535, 65535, 0},
+		{2, 65538, 1},
+		{65534, 65534, 1}, // Reordered from before the roll over
+		{3, 65539, 1},
+		{32770, 98306, 1},
+		{1, 131073, 2},
+	} {
+		assert.Equal(t, testCase.extended, u.Unwrap(testCase.seq), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.roc, u.RolloverCount(), "testCase: %d %v", i, testCase)
+	}
+
+	// Older than the first sequence number, before any roll over
+	u = &SequenceNumberUnwrapper{}
+	assert.Equal(t, uint64(1), u.Unwrap(1))
+	assert.Equal(t, uint64(65535), u.Unwrap(65535))
+	assert.Equal(t, uint32(0), u.RolloverCount())
+}
+
+func TestTimestampUnwrapper(t *testing.T) {
+	u := &TimestampUnwrapper{}
+
+	assert.Equal(t, uint64(4294964296), u.Unwrap(4294964296))
+	assert.Equal(t, uint64(4294967296+2000), u.Unwrap(2000))
+	assert.Equal(t, uint32(1), u.RolloverCount())
+	assert.Equal(t, uint64(4294966296), u.Unwrap(4294966296))
+	assert.Equal(t, uint32(1), u.RolloverCount())
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// PriorityType describes the relative priority of a DataChannel. When
+// DataChannels share an SCTP association, messages of a higher priority
+// DataChannel are sent before the ones of lower priority DataChannels.
+type PriorityType int
+
+const (
+	// PriorityTypeUnknown is the enum's zero-value
+	PriorityTypeUnknown PriorityType = iota
+
+	// PriorityTypeVeryLow is the lowest priority
+	PriorityTypeVeryLow
+
+	// PriorityTypeLow is the default priority of DataChannels
+	PriorityTypeLow
+
+	// PriorityTypeMedium is a priority above the default
+	PriorityTypeMedium
+
+	// PriorityTypeHigh is the highest priority
+	PriorityTypeHigh
+)
+
+// This is done this way because of a linter.
+const (
+	priorityTypeVeryLowStr = "very-low"
+	priorityTypeLowStr     = "low"
+	priorityTypeMediumStr  = "medium"
+	priorityTypeHighStr    = "high"
+)
+
+// NewPriorityType takes a string and converts it to PriorityType
+func NewPriorityType(raw string) PriorityType {
+	switch raw {
+	case priorityTypeVeryLowStr:
+		return PriorityTypeVeryLow
+	case priorityTypeLowStr:
+		return PriorityTypeLow
+	case priorityTypeMediumStr:
+		return PriorityTypeMedium
+	case priorityTypeHighStr:
+		return PriorityTypeHigh
+	default:
+		return PriorityTypeUnknown
+	}
+}
+
+func (p PriorityType) String() string {
+	switch p {
+	case PriorityTypeVeryLow:
+		return priorityTypeVeryLowStr
+	case PriorityTypeLow:
+		return priorityTypeLowStr
+	case PriorityTypeMedium:
+		return priorityTypeMediumStr
+	case PriorityTypeHigh:
+		return priorityTypeHighStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
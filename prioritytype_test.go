@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPriorityType(t *testing.T) {
+	testCases := []struct {
+		priorityString   string
+		expectedPriority PriorityType
+	}{
+		{ErrUnknownType.Error(), PriorityTypeUnknown},
+		{"very-low", PriorityTypeVeryLow},
+		{"low", PriorityTypeLow},
+		{"medium", PriorityTypeMedium},
+		{"high", PriorityTypeHigh},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedPriority,
+			NewPriorityType(testCase.priorityString),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestPriorityType_String(t *testing.T) {
+	testCases := []struct {
+		priority       PriorityType
+		expectedString string
+	}{
+		{PriorityTypeUnknown, ErrUnknownType.Error()},
+		{PriorityTypeVeryLow, "very-low"},
+		{PriorityTypeLow, "low"},
+		{PriorityTypeMedium, "medium"},
+		{PriorityTypeHigh, "high"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.priority.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
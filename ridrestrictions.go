@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+)
+
+// The restrictions of a=rid, https://datatracker.ietf.org/doc/html/rfc8851#section-4
+const (
+	ridRestrictionMaxWidth  = "max-width"
+	ridRestrictionMaxHeight = "max-height"
+	ridRestrictionMaxFPS    = "max-fps"
+	ridRestrictionMaxFS     = "max-fs"
+	ridRestrictionMaxBR     = "max-br"
+	ridRestrictionMaxPPS    = "max-pps"
+	ridRestrictionMaxBPP    = "max-bpp"
+)
+
+// RIDRestrictions are the restrictions of a=rid on the RTP stream of a RID,
+// as defined in RFC 8851 Section 4. Zero means unrestricted.
+type RIDRestrictions struct {
+	MaxWidth  uint32
+	MaxHeight uint32
+	MaxFPS    float64
+	// MaxFS is the maximum frame size in pixels
+	MaxFS uint32
+	// MaxBR is the maximum bitrate in bits per second
+	MaxBR uint32
+	// MaxPPS is the maximum pixel rate in pixels per second
+	MaxPPS uint32
+	// MaxBPP is the maximum number of bits per pixel
+	MaxBPP float64
+}
+
+// IsZero returns true if the RTP stream isn't restricted
+func (r RIDRestrictions) IsZero() bool {
+	return r == RIDRestrictions{}
+}
+
+// String returns the restrictions in the format of a=rid
+func (r RIDRestrictions) String() string {
+	restrictions := []string{}
+	addUint := func(key string, value uint32) {
+		if value != 0 {
+			restrictions = append(restrictions, key+"="+strconv.FormatUint(uint64(value), 10))
+		}
+	}
+	addFloat := func(key string, value float64) {
+		if value != 0 {
+			restrictions = append(restrictions, key+"="+strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+
+	addUint(ridRestrictionMaxWidth, r.MaxWidth)
+	addUint(ridRestrictionMaxHeight, r.MaxHeight)
+	addFloat(ridRestrictionMaxFPS, r.MaxFPS)
+	addUint(ridRestrictionMaxFS, r.MaxFS)
+	addUint(ridRestrictionMaxBR, r.MaxBR)
+	addUint(ridRestrictionMaxPPS, r.MaxPPS)
+	addFloat(ridRestrictionMaxBPP, r.MaxBPP)
+
+	return strings.Join(restrictions, ";")
+}
+
+// restrict returns the stricter of both restrictions for every field
+func (r RIDRestrictions) restrict(other RIDRestrictions) RIDRestrictions {
+	minUint := func(a, b uint32) uint32 {
+		if a == 0 || (b != 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	minFloat := func(a, b float64) float64 {
+		if a == 0 || (b != 0 && b < a) {
+			return b
+		}
+		return a
+	}
+
+	return RIDRestrictions{
+		MaxWidth:  minUint(r.MaxWidth, other.MaxWidth),
+		MaxHeight: minUint(r.MaxHeight, other.MaxHeight),
+		MaxFPS:    minFloat(r.MaxFPS, other.MaxFPS),
+		MaxFS:     minUint(r.MaxFS, other.MaxFS),
+		MaxBR:     minUint(r.MaxBR, other.MaxBR),
+		MaxPPS:    minUint(r.MaxPPS, other.MaxPPS),
+		MaxBPP:    minFloat(r.MaxBPP, other.MaxBPP),
+	}
+}
+
+// parseRIDRestrictions parses the restrictions of a=rid. The restrictions
+// which aren't RIDRestrictions, like pt, are returned as is in others.
+func parseRIDRestrictions(raw string) (restrictions RIDRestrictions, others []string) {
+	for _, restriction := range strings.Split(raw, ";") {
+		if restriction == "" {
+			continue
+		}
+
+		split := strings.SplitN(restriction, "=", 2)
+		if len(split) != 2 {
+			others = append(others, restriction)
+			continue
+		}
+
+		// Malformed values are ignored, like unknown restrictions
+		switch split[0] {
+		case ridRestrictionMaxWidth:
+			restrictions.MaxWidth = parseRIDRestrictionUint(split[1])
+		case ridRestrictionMaxHeight:
+			restrictions.MaxHeight = parseRIDRestrictionUint(split[1])
+		case ridRestrictionMaxFPS:
+			restrictions.MaxFPS = parseRIDRestrictionFloat(split[1])
+		case ridRestrictionMaxFS:
+			restrictions.MaxFS = parseRIDRestrictionUint(split[1])
+		case ridRestrictionMaxBR:
+			restrictions.MaxBR = parseRIDRestrictionUint(split[1])
+		case ridRestrictionMaxPPS:
+			restrictions.MaxPPS = parseRIDRestrictionUint(split[1])
+		case ridRestrictionMaxBPP:
+			restrictions.MaxBPP = parseRIDRestrictionFloat(split[1])
+		default:
+			others = append(others, restriction)
+		}
+	}
+
+	return restrictions, others
+}
+
+func parseRIDRestrictionUint(raw string) uint32 {
+	value, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0
+	}
+
+	return uint32(value)
+}
+
+func parseRIDRestrictionFloat(raw string) float64 {
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+
+	return value
+}
+
+// restrictRIDRestrictions adds the local restrictions to the restrictions of
+// a=rid, keeping the stricter value of the restrictions present in both
+func restrictRIDRestrictions(raw string, local RIDRestrictions) string {
+	restrictions, others := parseRIDRestrictions(raw)
+	if merged := restrictions.restrict(local).String(); merged != "" {
+		others = append(others, merged)
+	}
+
+	return strings.Join(others, ";")
+}
+
+// getRIDRestrictions returns the restrictions of the rids of a media section
+// with the given direction
+func getRIDRestrictions(media *sdp.MediaDescription, direction string) map[string]RIDRestrictions {
+	ridRestrictions := map[string]RIDRestrictions{}
+	for _, attr := range media.Attributes {
+		if attr.Key != sdpAttributeRid {
+			continue
+		}
+
+		fields := strings.Fields(attr.Value)
+		if len(fields) < 2 || fields[1] != direction {
+			continue
+		}
+
+		restrictions := RIDRestrictions{}
+		if len(fields) > 2 {
+			restrictions, _ = parseRIDRestrictions(strings.Join(fields[2:], " "))
+		}
+		ridRestrictions[fields[0]] = restrictions
+	}
+
+	return ridRestrictions
+}
+
+// SetReceiveRIDRestrictions sets restrictions for the RTP stream of rid, which
+// the remote sends as part of a simulcast. They are added to the restrictions
+// the remote offered with the next offer or answer, so renegotiate to update
+// them at runtime. Restrictions can only be tightened, the stricter value of
+// the local and remote restrictions is used.
+func (t *RTPTransceiver) SetReceiveRIDRestrictions(rid string, restrictions RIDRestrictions) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.receiveRIDRestrictions == nil {
+		t.receiveRIDRestrictions = map[string]RIDRestrictions{}
+	}
+	t.receiveRIDRestrictions[rid] = restrictions
+}
+
+func (t *RTPTransceiver) getReceiveRIDRestrictions(rid string) RIDRestrictions {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.receiveRIDRestrictions[rid]
+}
+
+// RemoteRIDRestrictions returns the restrictions the remote imposed on the
+// RTP streams of the sender by RID, from the a=rid recv attributes of the
+// remote description. Encoders should comply with them.
+func (r *RTPSender) RemoteRIDRestrictions() map[string]RIDRestrictions {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ridRestrictions := make(map[string]RIDRestrictions, len(r.remoteRIDRestrictions))
+	for rid, restrictions := range r.remoteRIDRestrictions {
+		ridRestrictions[rid] = restrictions
+	}
+
+	return ridRestrictions
+}
+
+// OnRemoteRIDRestrictionsChange sets an event handler which is invoked when a
+// remote description changes the restrictions returned by
+// RemoteRIDRestrictions
+func (r *RTPSender) OnRemoteRIDRestrictionsChange(f func(map[string]RIDRestrictions)) {
+	r.onRemoteRIDRestrictionsChangeHandler.Store(f)
+}
+
+func (r *RTPSender) setRemoteRIDRestrictions(ridRestrictions map[string]RIDRestrictions) {
+	r.mu.Lock()
+	changed := len(ridRestrictions) != len(r.remoteRIDRestrictions)
+	for rid, restrictions := range ridRestrictions {
+		if current, ok := r.remoteRIDRestrictions[rid]; !ok || current != restrictions {
+			changed = true
+		}
+	}
+	r.remoteRIDRestrictions = ridRestrictions
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if handler, ok := r.onRemoteRIDRestrictionsChangeHandler.Load().(func(map[string]RIDRestrictions)); ok && handler != nil {
+		go handler(r.RemoteRIDRestrictions())
+	}
+}
+
+// updateRemoteRIDRestrictions passes the restrictions of the remote
+// description on the RTP streams the transceivers send to their senders
+func updateRemoteRIDRestrictions(desc *sdp.SessionDescription, transceivers []*RTPTransceiver) {
+	for _, media := range desc.MediaDescriptions {
+		midValue := getMidValue(media)
+		if midValue == "" {
+			continue
+		}
+
+		for _, t := range transceivers {
+			if t.Mid() != midValue {
+				continue
+			}
+			if sender := t.Sender(); sender != nil {
+				sender.setRemoteRIDRestrictions(getRIDRestrictions(media, "recv"))
+			}
+		}
+	}
+}
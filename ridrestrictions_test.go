@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRIDRestrictions(t *testing.T) {
+	for i, testCase := range []struct {
+		raw          string
+		restrictions RIDRestrictions
+		others       []string
+		marshaled    string
+	}{
+		{"", RIDRestrictions{}, nil, ""},
+		{"pt=96,97", RIDRestrictions{}, []string{"pt=96,97"}, ""},
+		{
+			"pt=96;max-width=1280;max-height=720;max-fps=29.97;max-br=2500000;depend=a",
+			RIDRestrictions{MaxWidth: 1280, MaxHeight: 720, MaxFPS: 29.97, MaxBR: 2500000},
+			[]string{"pt=96", "depend=a"},
+			"max-width=1280;max-height=720;max-fps=29.97;max-br=2500000",
+		},
+		{
+			"max-fs=3600;max-pps=1000000;max-bpp=0.5",
+			RIDRestrictions{MaxFS: 3600, MaxPPS: 1000000, MaxBPP: 0.5},
+			nil,
+			"max-fs=3600;max-pps=1000000;max-bpp=0.5",
+		},
+		{"max-width=wide;max-fps=-1", RIDRestrictions{}, nil, ""},
+	} {
+		restrictions, others := parseRIDRestrictions(testCase.raw)
+		assert.Equal(t, testCase.restrictions, restrictions, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.others, others, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.marshaled, restrictions.String(), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestRestrictRIDRestrictions(t *testing.T) {
+	for i, testCase := range []struct {
+		raw    string
+		local  RIDRestrictions
+		result string
+	}{
+		{"", RIDRestrictions{}, ""},
+		{"pt=96", RIDRestrictions{}, "pt=96"},
+		{"", RIDRestrictions{MaxWidth: 640}, "max-width=640"},
+		{"pt=96;max-width=1280;max-br=500000", RIDRestrictions{MaxWidth: 640, MaxBR: 1000000}, "pt=96;max-width=640;max-br=500000"},
+	} {
+		assert.Equal(t, testCase.result, restrictRIDRestrictions(testCase.raw, testCase.local), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestRIDRestrictions_Negotiation(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	trackA, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("a"))
+	assert.NoError(t, err)
+	trackB, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("b"))
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(trackA)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.AddEncoding(trackB))
+
+	restrictionsChanged := make(chan map[string]RIDRestrictions, 1)
+	sender.OnRemoteRIDRestrictionsChange(func(ridRestrictions map[string]RIDRestrictions) {
+		restrictionsChanged <- ridRestrictions
+	})
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	transceivers := pcAnswer.GetTransceivers()
+	assert.Len(t, transceivers, 1)
+	transceivers[0].SetReceiveRIDRestrictions("a", RIDRestrictions{MaxWidth: 640, MaxBR: 500000})
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(answer.SDP, "a=rid:a recv max-width=640;max-br=500000\r\n"))
+	assert.True(t, strings.Contains(answer.SDP, "a=rid:b recv\r\n"))
+
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	expected := map[string]RIDRestrictions{
+		"a": {MaxWidth: 640, MaxBR: 500000},
+		"b": {},
+	}
+	assert.Equal(t, expected, sender.RemoteRIDRestrictions())
+	assert.Equal(t, expected, <-restrictionsChanged)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
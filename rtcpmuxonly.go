@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"github.com/pion/sdp/v3"
+)
+
+// SetRTCPMuxOnly configures whether RTP and RTCP must be multiplexed. When
+// enabled the RTP media sections of offers have a=rtcp-mux-only, and remote
+// descriptions with an accepted RTP media section lacking a=rtcp-mux are
+// refused with an SDPError wrapping ErrSessionDescriptionMissingRTCPMux.
+// Pion always multiplexes RTP and RTCP, this only makes the remote commit to it.
+// https://datatracker.ietf.org/doc/html/rfc8858
+func (e *SettingEngine) SetRTCPMuxOnly(rtcpMuxOnly bool) {
+	e.rtcpMuxOnly = rtcpMuxOnly
+}
+
+// isRTPMediaSection returns true if media is an accepted media section of RTP
+func isRTPMediaSection(media *sdp.MediaDescription) bool {
+	return NewRTPCodecType(media.MediaName.Media) != 0 &&
+		(media.MediaName.Port.Value != 0 || isBundleOnly(media))
+}
+
+// addRTCPMuxOnly adds a=rtcp-mux-only to the RTP media sections of the offer d
+func addRTCPMuxOnly(d *sdp.SessionDescription) {
+	for _, media := range d.MediaDescriptions {
+		if isRTPMediaSection(media) {
+			media.WithPropertyAttribute(sdpAttributeRTCPMuxOnly)
+		}
+	}
+}
+
+// checkRTCPMux returns an SDPError for the first RTP media section of d which
+// doesn't multiplex RTP and RTCP
+func checkRTCPMux(d *sdp.SessionDescription) error {
+	for i, media := range d.MediaDescriptions {
+		if !isRTPMediaSection(media) {
+			continue
+		}
+
+		if _, ok := media.Attribute(sdp.AttrKeyRTCPMux); !ok {
+			return &SDPError{MediaIndex: i, Attribute: sdp.AttrKeyRTCPMux, Err: ErrSessionDescriptionMissingRTCPMux}
+		}
+	}
+
+	return nil
+}
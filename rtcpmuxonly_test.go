@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRTCPMux(t *testing.T) {
+	media := func(kind string, port int, attributes ...sdp.Attribute) *sdp.MediaDescription {
+		return &sdp.MediaDescription{
+			MediaName:  sdp.MediaName{Media: kind, Port: sdp.RangedPort{Value: port}},
+			Attributes: attributes,
+		}
+	}
+	rtcpMux := sdp.Attribute{Key: sdp.AttrKeyRTCPMux}
+	bundleOnly := sdp.Attribute{Key: sdpAttributeBundleOnly}
+
+	testCases := []struct {
+		medias      []*sdp.MediaDescription
+		expectedErr error
+	}{
+		{[]*sdp.MediaDescription{media("audio", 9, rtcpMux), media("video", 9, rtcpMux)}, nil},
+		{[]*sdp.MediaDescription{media("application", 9), media("video", 0)}, nil},
+		{[]*sdp.MediaDescription{media("audio", 9, rtcpMux), media("video", 9)}, &SDPError{MediaIndex: 1, Attribute: sdp.AttrKeyRTCPMux, Err: ErrSessionDescriptionMissingRTCPMux}},
+		{[]*sdp.MediaDescription{media("video", 0, bundleOnly)}, &SDPError{MediaIndex: 0, Attribute: sdp.AttrKeyRTCPMux, Err: ErrSessionDescriptionMissingRTCPMux}},
+	}
+
+	for i, testCase := range testCases {
+		d := &sdp.SessionDescription{MediaDescriptions: testCase.medias}
+		assert.Equal(t, testCase.expectedErr, checkRTCPMux(d), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestSettingEngine_SetRTCPMuxOnly(t *testing.T) {
+	s := SettingEngine{}
+	s.SetRTCPMuxOnly(true)
+
+	pcOffer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(offer.SDP, "a=rtcp-mux-only\r\n"))
+
+	// A remote that doesn't multiplex RTCP is refused
+	withoutRTCPMux := SessionDescription{Type: SDPTypeOffer, SDP: strings.Replace(offer.SDP, "a=rtcp-mux\r\n", "", 1)}
+	assert.ErrorIs(t, pcAnswer.SetRemoteDescription(withoutRTCPMux), ErrSessionDescriptionMissingRTCPMux)
+	assert.Nil(t, pcAnswer.RemoteDescription())
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	answer := pcAnswer.LocalDescription()
+	assert.NotNil(t, answer)
+	assert.NotContains(t, answer.SDP, "a=rtcp-mux-only")
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,310 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/report"
+	"github.com/pion/webrtc/v4/internal/util"
+)
+
+const (
+	// rtcpReportDefaultInterval is the interval of the report interceptors
+	// when none is configured
+	rtcpReportDefaultInterval = time.Second
+
+	// rtcpReportAverageSize is the size of a compound RTCP report including
+	// the UDP and IP headers, used to compute intervals from the bandwidth
+	rtcpReportAverageSize = 128
+
+	// rtcpReportDefaultBandwidthFraction is the fraction of the session
+	// bandwidth used for RTCP, see RFC 3550 Section 6.2
+	rtcpReportDefaultBandwidthFraction = 0.05
+
+	// rtcpReportDefaultMembers is the number of participants of a session
+	// between two PeerConnections
+	rtcpReportDefaultMembers = 2
+
+	// rtcpReportSenderBandwidthShare is the share of the RTCP bandwidth of
+	// the senders when they are at most a quarter of the participants, see
+	// RFC 3550 Section 6.2
+	rtcpReportSenderBandwidthShare = 0.25
+
+	// rtcpReportCompensation compensates the randomization of the interval
+	// for the timer reconsideration, see RFC 3550 Section 6.3.1
+	rtcpReportCompensation = math.E - 1.5
+)
+
+// RTCPReportIntervals configures how often the Sender and Receiver Reports of
+// the interceptors registered with ConfigureRTCPReports are sent. The interval
+// of a kind of media is AudioInterval or VideoInterval if set, else it is
+// computed from the bandwidth of that kind like RFC 3550 Section 6.3.1, else
+// it is one second. See SettingEngine.SetRTCPReportIntervals.
+//
+// The computed intervals give the single sender of a stream a quarter of the
+// RTCP bandwidth when the senders are at most a quarter of the Members, and
+// are randomized between 0.5 and 1.5 times their value. Sender Reports draw a
+// new interval for every report, Receiver Reports draw one for the lifetime
+// of the PeerConnection.
+type RTCPReportIntervals struct {
+	AudioInterval time.Duration
+	VideoInterval time.Duration
+
+	// AudioBandwidth and VideoBandwidth are the bandwidth of the media in bits
+	// per second, BandwidthFraction of it is used for RTCP. BandwidthFraction
+	// defaults to 5%.
+	AudioBandwidth    uint64
+	VideoBandwidth    uint64
+	BandwidthFraction float64
+
+	// Members is the number of participants of the session, like the
+	// subscribers of an SFU. It defaults to 2.
+	Members int
+
+	// MinInterval bounds the interval of both kinds of media, like the minimum
+	// interval of RFC 3550
+	MinInterval time.Duration
+}
+
+// SetRTCPReportIntervals sets the intervals of Sender and Receiver Reports
+func (e *SettingEngine) SetRTCPReportIntervals(intervals RTCPReportIntervals) error {
+	if intervals.AudioInterval < 0 || intervals.VideoInterval < 0 || intervals.MinInterval < 0 ||
+		intervals.BandwidthFraction < 0 || intervals.BandwidthFraction > 1 || intervals.Members < 0 {
+		return errSettingEngineRTCPReportIntervals
+	}
+
+	e.rtcpReportIntervals = &intervals
+
+	return nil
+}
+
+// rtcpReportsConfig is the configuration of the report interceptors of a
+// kind of media. The intervals are the deterministic ones.
+type rtcpReportsConfig struct {
+	senderInterval, receiverInterval time.Duration
+	randomized                       bool
+}
+
+// config returns the configuration of the report interceptors of a kind of
+// media
+func (r RTCPReportIntervals) config(kind RTPCodecType) rtcpReportsConfig {
+	config := rtcpReportsConfig{}
+	interval, bandwidth := r.VideoInterval, r.VideoBandwidth
+	if kind == RTPCodecTypeAudio {
+		interval, bandwidth = r.AudioInterval, r.AudioBandwidth
+	}
+
+	switch {
+	case interval != 0:
+		config.senderInterval, config.receiverInterval = interval, interval
+	case bandwidth != 0:
+		fraction := r.BandwidthFraction
+		if fraction == 0 {
+			fraction = rtcpReportDefaultBandwidthFraction
+		}
+		members := r.Members
+		if members == 0 {
+			members = rtcpReportDefaultMembers
+		}
+
+		rtcpBandwidth := float64(bandwidth) * fraction
+		config.senderInterval = rtcpReportInterval(rtcpBandwidth, members, true)
+		config.receiverInterval = rtcpReportInterval(rtcpBandwidth, members, false)
+		config.randomized = true
+	default:
+		config.senderInterval, config.receiverInterval = rtcpReportDefaultInterval, rtcpReportDefaultInterval
+	}
+
+	if config.senderInterval < r.MinInterval {
+		config.senderInterval = r.MinInterval
+	}
+	if config.receiverInterval < r.MinInterval {
+		config.receiverInterval = r.MinInterval
+	}
+
+	return config
+}
+
+// rtcpReportInterval returns the deterministic interval of RFC 3550 Section
+// 6.3.1 of a participant of a session of members with a single sender
+func rtcpReportInterval(rtcpBandwidth float64, members int, weSent bool) time.Duration {
+	const senders = 1
+
+	n, share := members, 1.0
+	if senders <= float64(members)*rtcpReportSenderBandwidthShare {
+		if weSent {
+			n, share = senders, rtcpReportSenderBandwidthShare
+		} else {
+			n, share = members-senders, 1-rtcpReportSenderBandwidthShare
+		}
+	}
+
+	seconds := float64(n) * rtcpReportAverageSize * 8 / (rtcpBandwidth * share)
+
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
+
+// randomizeRTCPReportInterval returns interval multiplied by a random factor
+// between 0.5 and 1.5, divided by the compensation of RFC 3550 Section 6.3.1
+func randomizeRTCPReportInterval(interval time.Duration) time.Duration {
+	factor := 0.5 + float64(util.RandUint32())/math.MaxUint32
+
+	return time.Duration(float64(interval) * factor / rtcpReportCompensation)
+}
+
+// rtcpReportTicker ticks at randomized intervals, drawing a new one for every
+// tick
+type rtcpReportTicker struct {
+	interval time.Duration
+	ch       chan time.Time
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+func newRTCPReportTicker(interval time.Duration) report.Ticker {
+	t := &rtcpReportTicker{interval: interval, ch: make(chan time.Time, 1)}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer = time.AfterFunc(randomizeRTCPReportInterval(interval), t.tick)
+
+	return t
+}
+
+func (t *rtcpReportTicker) tick() {
+	select {
+	case t.ch <- time.Now():
+	default:
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.stopped {
+		t.timer.Reset(randomizeRTCPReportInterval(t.interval))
+	}
+}
+
+func (t *rtcpReportTicker) Ch() <-chan time.Time {
+	return t.ch
+}
+
+func (t *rtcpReportTicker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.timer.Stop()
+}
+
+// rtcpReportsFactory builds the report interceptors with the intervals of the
+// SettingEngine of the PeerConnection they are built for
+type rtcpReportsFactory struct{}
+
+func (f *rtcpReportsFactory) NewInterceptor(id string) (interceptor.Interceptor, error) {
+	intervals := RTCPReportIntervals{}
+	if settingEngine, ok := lookupInterceptorSettingEngine(id); ok && settingEngine.rtcpReportIntervals != nil {
+		intervals = *settingEngine.rtcpReportIntervals
+	}
+
+	audioConfig, videoConfig := intervals.config(RTPCodecTypeAudio), intervals.config(RTPCodecTypeVideo)
+	if audioConfig == videoConfig {
+		return newRTCPReportsInterceptor(id, audioConfig)
+	}
+
+	audio, err := newRTCPReportsInterceptor(id, audioConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := newRTCPReportsInterceptor(id, videoConfig)
+	if err != nil {
+		return nil, util.FlattenErrs([]error{err, audio.Close()})
+	}
+
+	return &rtcpReportsByKindInterceptor{audio: audio, video: video}, nil
+}
+
+// newRTCPReportsInterceptor returns the Receiver and Sender Report
+// interceptors with config
+func newRTCPReportsInterceptor(id string, config rtcpReportsConfig) (interceptor.Interceptor, error) {
+	receiverInterval := config.receiverInterval
+	senderOptions := []report.SenderOption{report.SenderInterval(config.senderInterval)}
+	if config.randomized {
+		receiverInterval = randomizeRTCPReportInterval(receiverInterval)
+		senderOptions = append(senderOptions, report.SenderTicker(newRTCPReportTicker))
+	}
+
+	receiverFactory, err := report.NewReceiverInterceptor(report.ReceiverInterval(receiverInterval))
+	if err != nil {
+		return nil, err
+	}
+
+	senderFactory, err := report.NewSenderInterceptor(senderOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := receiverFactory.NewInterceptor(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := senderFactory.NewInterceptor(id)
+	if err != nil {
+		return nil, util.FlattenErrs([]error{err, receiver.Close()})
+	}
+
+	return interceptor.NewChain([]interceptor.Interceptor{receiver, sender}), nil
+}
+
+// rtcpReportsByKindInterceptor routes the streams of each kind of media to
+// the report interceptors with the interval of that kind
+type rtcpReportsByKindInterceptor struct {
+	audio, video interceptor.Interceptor
+}
+
+func (i *rtcpReportsByKindInterceptor) byKind(info *interceptor.StreamInfo) interceptor.Interceptor {
+	if strings.HasPrefix(strings.ToLower(info.MimeType), "audio/") {
+		return i.audio
+	}
+
+	return i.video
+}
+
+func (i *rtcpReportsByKindInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return i.audio.BindRTCPReader(i.video.BindRTCPReader(reader))
+}
+
+func (i *rtcpReportsByKindInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return i.audio.BindRTCPWriter(i.video.BindRTCPWriter(writer))
+}
+
+func (i *rtcpReportsByKindInterceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	return i.byKind(info).BindLocalStream(info, writer)
+}
+
+func (i *rtcpReportsByKindInterceptor) UnbindLocalStream(info *interceptor.StreamInfo) {
+	i.byKind(info).UnbindLocalStream(info)
+}
+
+func (i *rtcpReportsByKindInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	return i.byKind(info).BindRemoteStream(info, reader)
+}
+
+func (i *rtcpReportsByKindInterceptor) UnbindRemoteStream(info *interceptor.StreamInfo) {
+	i.byKind(info).UnbindRemoteStream(info)
+}
+
+func (i *rtcpReportsByKindInterceptor) Close() error {
+	return util.FlattenErrs([]error{i.audio.Close(), i.video.Close()})
+}
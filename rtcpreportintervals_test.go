@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTCPReportIntervals_Config(t *testing.T) {
+	second := rtcpReportsConfig{senderInterval: time.Second, receiverInterval: time.Second}
+
+	for i, testCase := range []struct {
+		intervals    RTCPReportIntervals
+		audio, video rtcpReportsConfig
+	}{
+		{RTCPReportIntervals{}, second, second},
+		{
+			RTCPReportIntervals{AudioInterval: 5 * time.Second},
+			rtcpReportsConfig{senderInterval: 5 * time.Second, receiverInterval: 5 * time.Second},
+			second,
+		},
+		{RTCPReportIntervals{VideoInterval: 500 * time.Millisecond, MinInterval: time.Second}, second, second},
+		{
+			RTCPReportIntervals{AudioBandwidth: 20480, VideoBandwidth: 1024000},
+			rtcpReportsConfig{senderInterval: 2 * time.Second, receiverInterval: 2 * time.Second, randomized: true},
+			rtcpReportsConfig{senderInterval: 40 * time.Millisecond, receiverInterval: 40 * time.Millisecond, randomized: true},
+		},
+		{
+			RTCPReportIntervals{AudioBandwidth: 20480, BandwidthFraction: 0.1},
+			rtcpReportsConfig{senderInterval: time.Second, receiverInterval: time.Second, randomized: true},
+			second,
+		},
+		{
+			RTCPReportIntervals{VideoBandwidth: 1024000, MinInterval: 100 * time.Millisecond},
+			second,
+			rtcpReportsConfig{senderInterval: 100 * time.Millisecond, receiverInterval: 100 * time.Millisecond, randomized: true},
+		},
+		// The single sender gets a quarter of the RTCP bandwidth
+		{
+			RTCPReportIntervals{AudioBandwidth: 20480, Members: 8},
+			rtcpReportsConfig{senderInterval: 4 * time.Second, receiverInterval: 9333333333, randomized: true},
+			second,
+		},
+	} {
+		assert.Equal(t, testCase.audio, testCase.intervals.config(RTPCodecTypeAudio), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.video, testCase.intervals.config(RTPCodecTypeVideo), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestRandomizeRTCPReportInterval(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		interval := randomizeRTCPReportInterval(time.Second)
+		assert.GreaterOrEqual(t, float64(interval), 0.5*float64(time.Second)/rtcpReportCompensation)
+		assert.LessOrEqual(t, float64(interval), 1.5*float64(time.Second)/rtcpReportCompensation)
+	}
+}
+
+func TestRTCPReportTicker(t *testing.T) {
+	ticker := newRTCPReportTicker(10 * time.Millisecond)
+	<-ticker.Ch()
+	<-ticker.Ch()
+	ticker.Stop()
+}
+
+func TestSetRTCPReportIntervals(t *testing.T) {
+	s := SettingEngine{}
+	assert.ErrorIs(t, s.SetRTCPReportIntervals(RTCPReportIntervals{AudioInterval: -time.Second}), errSettingEngineRTCPReportIntervals)
+	assert.ErrorIs(t, s.SetRTCPReportIntervals(RTCPReportIntervals{BandwidthFraction: 2}), errSettingEngineRTCPReportIntervals)
+	assert.ErrorIs(t, s.SetRTCPReportIntervals(RTCPReportIntervals{Members: -1}), errSettingEngineRTCPReportIntervals)
+	assert.Nil(t, s.rtcpReportIntervals)
+
+	assert.NoError(t, s.SetRTCPReportIntervals(RTCPReportIntervals{AudioInterval: 5 * time.Second}))
+	pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, ok := lookupInterceptorSettingEngine(pc.statsID)
+	assert.False(t, ok)
+	assert.NoError(t, pc.Close())
+}
+
+func TestRTCPReportsFactory(t *testing.T) {
+	f := &rtcpReportsFactory{}
+
+	i, err := f.NewInterceptor("same")
+	assert.NoError(t, err)
+	_, byKind := i.(*rtcpReportsByKindInterceptor)
+	assert.False(t, byKind)
+	assert.NoError(t, i.Close())
+
+	interceptorSettingEngines.Store("by-kind", &SettingEngine{rtcpReportIntervals: &RTCPReportIntervals{AudioInterval: 5 * time.Second}})
+	defer interceptorSettingEngines.Delete("by-kind")
+
+	i, err = f.NewInterceptor("by-kind")
+	assert.NoError(t, err)
+	_, byKind = i.(*rtcpReportsByKindInterceptor)
+	assert.True(t, byKind)
+	assert.NoError(t, i.Close())
+}
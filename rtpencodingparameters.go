@@ -8,4 +8,10 @@ package webrtc
 // http://draft.ortc.org/#dom-rtcrtpencodingparameters
 type RTPEncodingParameters struct {
 	RTPCodingParameters
+
+	// ScalabilityMode is the SVC configuration of the encoding, like L1T3 or
+	// L3T3_KEY. It isn't negotiated in SDP, the remote learns the layers from
+	// the packets. Leave it empty for the default of the codec.
+	// https://www.w3.org/TR/webrtc-svc/#scalabilitymodes*
+	ScalabilityMode string `json:"scalabilityMode,omitempty"`
 }
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/rtcp"
 	"github.com/pion/srtp/v3"
 	"github.com/pion/webrtc/v4/internal/util"
@@ -123,6 +124,12 @@ func (r *RTPReceiver) GetParameters() RTPParameters {
 	return r.getParameters()
 }
 
+// GetCapabilities returns the codecs and header extensions of kind the
+// RTPReceiver's PeerConnection can receive, see API.GetReceiverCapabilities
+func (r *RTPReceiver) GetCapabilities(kind RTPCodecType) *RTPCapabilities {
+	return r.api.GetReceiverCapabilities(kind)
+}
+
 // Track returns the RtpTransceiver TrackRemote
 func (r *RTPReceiver) Track() *TrackRemote {
 	r.mu.RLock()
@@ -244,7 +251,11 @@ func (r *RTPReceiver) Receive(parameters RTPReceiveParameters) error {
 func (r *RTPReceiver) Read(b []byte) (n int, a interceptor.Attributes, err error) {
 	select {
 	case <-r.received:
-		return r.tracks[0].rtcpInterceptor.Read(b, a)
+		n, a, err = r.tracks[0].rtcpInterceptor.Read(b, a)
+		if err == nil {
+			r.handleSenderReports(b[:n], time.Now())
+		}
+		return n, a, err
 	case <-r.closed:
 		return 0, nil, io.ErrClosedPipe
 	}
@@ -267,7 +278,11 @@ func (r *RTPReceiver) ReadSimulcast(b []byte, rid string) (n int, a interceptor.
 		if rtcpInterceptor == nil {
 			return 0, nil, fmt.Errorf("%w: %s", errRTPReceiverForRIDTrackStreamNotFound, rid)
 		}
-		return rtcpInterceptor.Read(b, a)
+		n, a, err = rtcpInterceptor.Read(b, a)
+		if err == nil {
+			r.handleSenderReports(b[:n], time.Now())
+		}
+		return n, a, err
 
 	case <-r.closed:
 		return 0, nil, io.ErrClosedPipe
@@ -303,6 +318,34 @@ func (r *RTPReceiver) ReadSimulcastRTCP(rid string) ([]rtcp.Packet, interceptor.
 	return pkts, attributes, err
 }
 
+// handleSenderReports feeds the timestamps of the Sender Reports in a
+// compound RTCP packet to the clock drift estimation and the clock
+// synchronization of their track
+func (r *RTPReceiver) handleSenderReports(b []byte, arrival time.Time) {
+	for len(b) >= 4 {
+		length := 4 * (int(binary.BigEndian.Uint16(b[2:4])) + 1)
+		if length > len(b) {
+			return
+		}
+
+		// SSRC of sender at 4, NTP timestamp at 8 and RTP timestamp at 16
+		// https://datatracker.ietf.org/doc/html/rfc3550#section-6.4.1
+		if b[1] == uint8(rtcp.TypeSenderReport) && length >= 20 {
+			ssrc := SSRC(binary.BigEndian.Uint32(b[4:8]))
+
+			r.mu.RLock()
+			for i := range r.tracks {
+				if track := r.tracks[i].track; track.SSRC() == ssrc {
+					track.clockDrift.addSample(binary.BigEndian.Uint32(b[16:20]), track.Codec().ClockRate, arrival)
+					track.clockSync.addSenderReport(binary.BigEndian.Uint64(b[8:16]), binary.BigEndian.Uint32(b[16:20]), track.Codec().ClockRate, arrival)
+				}
+			}
+			r.mu.RUnlock()
+		}
+		b = b[length:]
+	}
+}
+
 func (r *RTPReceiver) haveReceived() bool {
 	select {
 	case <-r.received:
@@ -375,7 +418,11 @@ func (r *RTPReceiver) streamsForTrack(t *TrackRemote) *trackStreams {
 func (r *RTPReceiver) readRTP(b []byte, reader *TrackRemote) (n int, a interceptor.Attributes, err error) {
 	<-r.received
 	if t := r.streamsForTrack(reader); t != nil {
-		return t.rtpInterceptor.Read(b, a)
+		n, a, err = t.rtpInterceptor.Read(b, a)
+		if err == nil && n >= 8 {
+			reader.clockDrift.addSample(binary.BigEndian.Uint32(b[4:8]), reader.Codec().ClockRate, time.Now())
+		}
+		return n, a, err
 	}
 
 	return 0, nil, fmt.Errorf("%w: %d", errRTPReceiverWithSSRCTrackStreamNotFound, reader.SSRC())
@@ -541,3 +588,91 @@ func (r *RTPReceiver) readRTX(reader *TrackRemote) *rtxPacketWithAttributes {
 	}
 	return nil
 }
+
+func (r *RTPReceiver) collectStats(collector *statsReportCollector, statsGetter stats.Getter) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.haveReceived() {
+		return
+	}
+
+	transportID := r.transport.ICETransport().getStatsID()
+	now := statsTimestampFrom(time.Now())
+
+	for i := range r.tracks {
+		track := r.tracks[i].track
+		ssrc := track.SSRC()
+		if ssrc == 0 {
+			continue
+		}
+
+		codecID := ""
+		if codec, _, err := r.api.mediaEngine.getCodecByPayload(track.PayloadType()); err == nil {
+			codecID = codec.statsID
+		}
+
+		inboundID := fmt.Sprintf("RTPInboundStream-%d", ssrc)
+		remoteOutboundID := fmt.Sprintf("RTPRemoteOutboundStream-%d", ssrc)
+
+		inbound := InboundRTPStreamStats{
+			Timestamp:   now,
+			Type:        StatsTypeInboundRTP,
+			ID:          inboundID,
+			SSRC:        ssrc,
+			Kind:        r.kind.String(),
+			TransportID: transportID,
+			CodecID:     codecID,
+			TrackID:     track.ID(),
+		}
+		if ppm, ok := track.ClockDrift(); ok {
+			inbound.ClockDriftPPM = ppm
+		}
+
+		var streamStats *stats.Stats
+		if statsGetter != nil {
+			streamStats = statsGetter.Get(uint32(ssrc))
+		}
+
+		if streamStats != nil {
+			inbound.PacketsReceived = uint32(streamStats.InboundRTPStreamStats.PacketsReceived)
+			inbound.PacketsLost = int32(streamStats.InboundRTPStreamStats.PacketsLost)
+			inbound.Jitter = streamStats.InboundRTPStreamStats.Jitter
+			inbound.BytesReceived = streamStats.InboundRTPStreamStats.BytesReceived
+			inbound.FIRCount = streamStats.InboundRTPStreamStats.FIRCount
+			inbound.PLICount = streamStats.InboundRTPStreamStats.PLICount
+			inbound.NACKCount = streamStats.InboundRTPStreamStats.NACKCount
+			if !streamStats.LastPacketReceivedTimestamp.IsZero() {
+				inbound.LastPacketReceivedTimestamp = statsTimestampFrom(streamStats.LastPacketReceivedTimestamp)
+			}
+		}
+
+		// A remote-outbound-rtp entry only exists once the remote has sent a Sender Report
+		remoteOutbound := streamStats != nil && streamStats.RemoteOutboundRTPStreamStats.ReportsSent != 0
+		if remoteOutbound {
+			inbound.RemoteID = remoteOutboundID
+		}
+
+		collector.Collecting()
+		collector.Collect(inboundID, inbound)
+
+		if !remoteOutbound {
+			continue
+		}
+
+		collector.Collecting()
+		collector.Collect(remoteOutboundID, RemoteOutboundRTPStreamStats{
+			Timestamp:       now,
+			Type:            StatsTypeRemoteOutboundRTP,
+			ID:              remoteOutboundID,
+			SSRC:            ssrc,
+			Kind:            r.kind.String(),
+			TransportID:     transportID,
+			CodecID:         codecID,
+			PacketsSent:     uint32(streamStats.RemoteOutboundRTPStreamStats.PacketsSent),
+			BytesSent:       streamStats.RemoteOutboundRTPStreamStats.BytesSent,
+			LocalID:         inboundID,
+			RemoteTimestamp: statsTimestampFrom(streamStats.RemoteTimeStamp),
+		})
+	}
+}
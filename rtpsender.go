@@ -10,12 +10,14 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/interceptor"
-	"github.com/pion/randutil"
+	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/internal/mux"
 	"github.com/pion/webrtc/v4/internal/util"
 )
 
@@ -30,6 +32,8 @@ type trackEncoding struct {
 	context *baseTrackLocalContext
 
 	ssrc SSRC
+
+	scalabilityMode string
 }
 
 // RTPSender allows an application to control how a given Track is encoded and transmitted to a remote peer
@@ -55,6 +59,21 @@ type RTPSender struct {
 
 	mu                     sync.RWMutex
 	sendCalled, stopCalled chan struct{}
+
+	onWriteResultHandler atomic.Value // func(TrackLocalWriteResult)
+
+	onKeyframeRequiredHandler atomic.Value // func(TrackLocal)
+
+	remoteRIDRestrictions                map[string]RIDRestrictions
+	onRemoteRIDRestrictionsChangeHandler atomic.Value // func(map[string]RIDRestrictions)
+
+	videoLayersAllocation videoLayersAllocationSender
+
+	// remotePacketTime is the a=ptime of the remote media section
+	remotePacketTime time.Duration
+
+	// streamIDs set by SetStreams, nil to use the StreamID of the track
+	streamIDs []string
 }
 
 // NewRTPSender constructs a new RTPSender
@@ -65,7 +84,7 @@ func (api *API) NewRTPSender(track TrackLocal, transport *DTLSTransport) (*RTPSe
 		return nil, errRTPSenderDTLSTransportNil
 	}
 
-	id, err := randutil.GenerateCryptoRandomString(32, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	id, err := api.settingEngine.randomString(32, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +98,9 @@ func (api *API) NewRTPSender(track TrackLocal, transport *DTLSTransport) (*RTPSe
 		kind:       track.Kind(),
 	}
 
-	r.addEncoding(track)
+	if err = r.addEncoding(track); err != nil {
+		return nil, err
+	}
 
 	return r, nil
 }
@@ -102,6 +123,13 @@ func (r *RTPSender) setRTPTransceiver(rtpTransceiver *RTPTransceiver) {
 	r.rtpTransceiver = rtpTransceiver
 }
 
+// Ready returns a channel which is closed once the SRTP session of the
+// RTPSender is available. RTP packets written before are dropped, unless
+// SettingEngine.SetSRTPWriteQueueSize is used.
+func (r *RTPSender) Ready() <-chan struct{} {
+	return r.Transport().srtpReady
+}
+
 // Transport returns the currently-configured *DTLSTransport or nil
 // if one has not yet been configured
 func (r *RTPSender) Transport() *DTLSTransport {
@@ -126,6 +154,7 @@ func (r *RTPSender) getParameters() RTPSendParameters {
 				SSRC:        trackEncoding.ssrc,
 				PayloadType: r.payloadType,
 			},
+			ScalabilityMode: trackEncoding.scalabilityMode,
 		})
 	}
 	sendParameters := RTPSendParameters{
@@ -194,17 +223,72 @@ func (r *RTPSender) AddEncoding(track TrackLocal) error {
 		}
 	}
 
-	r.addEncoding(track)
-	return nil
+	return r.addEncoding(track)
 }
 
-func (r *RTPSender) addEncoding(track TrackLocal) {
+func (r *RTPSender) addEncoding(track TrackLocal) error {
+	ssrc, err := r.api.settingEngine.generateSSRC()
+	if err != nil {
+		return err
+	}
+
 	trackEncoding := &trackEncoding{
 		track: track,
-		ssrc:  SSRC(randutil.NewMathRandomGenerator().Uint32()),
+		ssrc:  ssrc,
 	}
 
 	r.trackEncodings = append(r.trackEncodings, trackEncoding)
+
+	return nil
+}
+
+// SetScalabilityMode sets the scalability mode of the encoding rid, or of
+// the only encoding if rid is empty, see RTPEncodingParameters. It is
+// reflected in GetParameters for the application to configure its encoder.
+// Before Send the mode must be supported by one of the codecs of the
+// sender, once sending by the negotiated codec.
+func (r *RTPSender) SetScalabilityMode(rid, mode string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hasStopped() {
+		return errRTPSenderStopped
+	}
+
+	var encoding *trackEncoding
+	for _, e := range r.trackEncodings {
+		if (rid == "" && len(r.trackEncodings) == 1) || (e.track != nil && e.track.RID() == rid) {
+			encoding = e
+		}
+	}
+	if encoding == nil {
+		return errRTPSenderNoTrackForRID
+	}
+
+	var codecs []RTPCodecParameters
+	switch {
+	case r.hasSent():
+		codecs = encoding.context.params.Codecs
+	case r.rtpTransceiver != nil:
+		codecs = r.rtpTransceiver.getCodecs()
+	default:
+		codecs = r.api.mediaEngine.getCodecsByKind(r.kind)
+	}
+
+	for _, codec := range codecs {
+		if scalabilityModeSupported(codec.MimeType, mode) {
+			encoding.scalabilityMode = mode
+			return nil
+		}
+	}
+
+	return ErrUnsupportedScalabilityMode
+}
+
+// GetCapabilities returns the codecs and header extensions of kind the
+// RTPSender's PeerConnection can send, see API.GetSenderCapabilities
+func (r *RTPSender) GetCapabilities(kind RTPCodecType) *RTPCapabilities {
+	return r.api.GetSenderCapabilities(kind)
 }
 
 // Track returns the RTCRtpTransceiver track, or nil
@@ -226,13 +310,20 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	_, err := r.replaceTrack(track)
+	return err
+}
+
+// replaceTrack is ReplaceTrack, which also returns the codec track was bound
+// with if the sender is sending. r.mu must be held.
+func (r *RTPSender) replaceTrack(track TrackLocal) (RTPCodecParameters, error) { //nolint:cyclop
 	if track != nil && r.kind != track.Kind() {
-		return ErrRTPSenderNewTrackHasIncorrectKind
+		return RTPCodecParameters{}, ErrRTPSenderNewTrackHasIncorrectKind
 	}
 
 	// cannot replace simulcast envelope
 	if track != nil && len(r.trackEncodings) > 1 {
-		return ErrRTPSenderNewTrackHasIncorrectEnvelope
+		return RTPCodecParameters{}, ErrRTPSenderNewTrackHasIncorrectEnvelope
 	}
 
 	var replacedTrack TrackLocal
@@ -243,7 +334,7 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error {
 
 		if r.hasSent() && replacedTrack != nil {
 			if err := replacedTrack.Unbind(context); err != nil {
-				return err
+				return RTPCodecParameters{}, err
 			}
 		}
 
@@ -253,7 +344,7 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error {
 	}
 
 	if !r.hasSent() || track == nil {
-		return nil
+		return RTPCodecParameters{}, nil
 	}
 
 	// If we reach this point in the routine, there is only 1 track encoding
@@ -263,14 +354,18 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error {
 		ssrc:            context.SSRC(),
 		writeStream:     context.WriteStream(),
 		rtcpInterceptor: context.RTCPReader(),
+		packetTime:      r.remotePacketTime,
+
+		extMapAllowMixed: r.api.mediaEngine.extMapAllowMixed(),
+		fmtpParsers:      r.api.mediaEngine.getFMTPParsers(),
 	})
 	if err != nil {
 		// Re-bind the original track
 		if _, reBindErr := replacedTrack.Bind(context); reBindErr != nil {
-			return reBindErr
+			return RTPCodecParameters{}, reBindErr
 		}
 
-		return err
+		return RTPCodecParameters{}, err
 	}
 
 	// Codec has changed
@@ -278,9 +373,52 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error {
 		context.params.Codecs = []RTPCodecParameters{codec}
 	}
 
+	if !scalabilityModeSupported(codec.MimeType, r.trackEncodings[0].scalabilityMode) {
+		r.trackEncodings[0].scalabilityMode = ""
+	}
+
 	r.trackEncodings[0].track = track
 
-	return nil
+	return codec, nil
+}
+
+// SwitchCodec replaces the track of a sending RTPSender with track, whose
+// codec differs, without renegotiation. It is meant to fall back to another
+// codec when the encoder of the current one fails mid-call, like a hardware
+// encoder. The codec of track must have been negotiated with the remote, so
+// SwitchCodec returns ErrUnsupportedCodec otherwise. The packets of track are
+// sent with the payload type of the returned codec. The remote can only decode
+// the new codec from a keyframe, so for video the handler set with
+// OnKeyframeRequired is called with track before SwitchCodec returns.
+func (r *RTPSender) SwitchCodec(track TrackLocal) (RTPCodecParameters, error) {
+	if track == nil {
+		return RTPCodecParameters{}, errRTPSenderTrackNil
+	}
+
+	r.mu.Lock()
+	if !r.hasSent() {
+		r.mu.Unlock()
+		return RTPCodecParameters{}, errRTPSenderSendNotCalled
+	}
+
+	codec, err := r.replaceTrack(track)
+	r.mu.Unlock()
+	if err != nil {
+		return RTPCodecParameters{}, err
+	}
+
+	if handler, ok := r.onKeyframeRequiredHandler.Load().(func(TrackLocal)); ok && handler != nil && track.Kind() == RTPCodecTypeVideo {
+		handler(track)
+	}
+
+	return codec, nil
+}
+
+// OnKeyframeRequired sets an event handler which is called when the next
+// packets written to a track of the RTPSender have to start a keyframe, which
+// happens when SwitchCodec switched the codec of a video track.
+func (r *RTPSender) OnKeyframeRequired(f func(track TrackLocal)) {
+	r.onKeyframeRequiredHandler.Store(f)
 }
 
 // Send Attempts to set the parameters controlling the sending of media.
@@ -297,8 +435,12 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 
 	for idx := range r.trackEncodings {
 		trackEncoding := r.trackEncodings[idx]
-		srtpStream := &srtpWriterFuture{ssrc: parameters.Encodings[idx].SSRC, rtpSender: r}
-		writeStream := &interceptorToTrackLocalWriter{}
+		srtpStream := &srtpWriterFuture{
+			ssrc:      parameters.Encodings[idx].SSRC,
+			rtpSender: r,
+			queueSize: r.api.settingEngine.srtpWriteQueueSize,
+		}
+		writeStream := &interceptorToTrackLocalWriter{reportWrites: r.reportWrites}
 
 		trackEncoding.srtpStream = srtpStream
 		trackEncoding.ssrc = parameters.Encodings[idx].SSRC
@@ -308,6 +450,10 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 			ssrc:            parameters.Encodings[idx].SSRC,
 			writeStream:     writeStream,
 			rtcpInterceptor: trackEncoding.rtcpInterceptor,
+			packetTime:      r.remotePacketTime,
+
+			extMapAllowMixed: r.api.mediaEngine.extMapAllowMixed(),
+			fmtpParsers:      r.api.mediaEngine.getFMTPParsers(),
 		}
 
 		codec, err := trackEncoding.track.Bind(trackEncoding.context)
@@ -316,6 +462,14 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 		}
 		trackEncoding.context.params.Codecs = []RTPCodecParameters{codec}
 
+		// Fallback to the default of the codec, as WebRTC-SVC does for modes
+		// the negotiated codec doesn't support
+		trackEncoding.scalabilityMode = parameters.Encodings[idx].ScalabilityMode
+		if !scalabilityModeSupported(codec.MimeType, trackEncoding.scalabilityMode) {
+			r.transport.log.Warnf("%s doesn't support scalability mode %s", codec.MimeType, trackEncoding.scalabilityMode)
+			trackEncoding.scalabilityMode = ""
+		}
+
 		trackEncoding.streamInfo = *createStreamInfo(
 			r.id,
 			parameters.Encodings[idx].SSRC,
@@ -331,10 +485,14 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 			}),
 		)
 
+		streamIndex := idx
+		videoLayersAllocationID := headerExtensionID(parameters.HeaderExtensions, VideoLayersAllocationURI)
+		extMapAllowMixed := trackEncoding.context.extMapAllowMixed
 		rtpInterceptor := r.api.interceptor.BindLocalStream(
 			&trackEncoding.streamInfo,
 			interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
-				return srtpStream.WriteRTP(header, payload)
+				header = r.videoLayersAllocation.withVideoLayersAllocation(header, streamIndex, videoLayersAllocationID, extMapAllowMixed)
+				return r.writeRTP(srtpStream, header, payload, attributes)
 			}),
 		)
 
@@ -386,6 +544,33 @@ func (r *RTPSender) Read(b []byte) (n int, a interceptor.Attributes, err error)
 	}
 }
 
+// WriteSRTP sends a RTP packet which is already SRTP protected, like one
+// forwarded from another hop of a cascade with end-to-end protected payloads.
+// The packet is written to the transport as is, without the SRTP protection
+// of this PeerConnection, and bypasses the Interceptors and the TrackLocal of
+// the sender. The remote has to know the SSRC of the packet and get the keys
+// it is protected with out of band. It returns ErrTrackLocalWriteNotConnected
+// until DTLS is connected.
+func (r *RTPSender) WriteSRTP(packet []byte) (int, error) {
+	if r.hasStopped() {
+		return 0, errRTPSenderStopped
+	}
+
+	header := &rtp.Header{}
+	if _, err := header.Unmarshal(packet); err != nil || !mux.MatchSRTP(packet) {
+		return 0, errRTPSenderSRTPInvalid
+	}
+
+	transport := r.Transport()
+	select {
+	case <-transport.srtpReady:
+	default:
+		return 0, ErrTrackLocalWriteNotConnected
+	}
+
+	return transport.writeSRTP(packet)
+}
+
 // ReadRTCP is a convenience method that wraps Read and unmarshals for you.
 func (r *RTPSender) ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error) {
 	b := make([]byte, r.api.settingEngine.getReceiveMTU())
@@ -448,6 +633,15 @@ func (r *RTPSender) SetReadDeadlineSimulcast(deadline time.Time, rid string) err
 	return fmt.Errorf("%w: %s", errRTPSenderNoTrackForRID, rid)
 }
 
+// setRemotePacketTime sets the a=ptime of the remote media section, which is
+// passed to the tracks bound by Send
+func (r *RTPSender) setRemotePacketTime(packetTime time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remotePacketTime = packetTime
+}
+
 // hasSent tells if data has been ever sent for this instance
 func (r *RTPSender) hasSent() bool {
 	select {
@@ -467,3 +661,107 @@ func (r *RTPSender) hasStopped() bool {
 		return false
 	}
 }
+
+func (r *RTPSender) collectStats(collector *statsReportCollector, statsGetter stats.Getter) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.hasSent() {
+		return
+	}
+
+	transportID := r.transport.ICETransport().getStatsID()
+	now := statsTimestampFrom(time.Now())
+
+	for _, trackEncoding := range r.trackEncodings {
+		if trackEncoding.track == nil {
+			continue
+		}
+
+		codecID := ""
+		if codec, _, err := r.api.mediaEngine.getCodecByPayload(PayloadType(trackEncoding.streamInfo.PayloadType)); err == nil {
+			codecID = codec.statsID
+		}
+
+		mediaSourceID := fmt.Sprintf("RTPMediaSource-%s-%s", r.kind, trackEncoding.track.ID())
+		outboundID := fmt.Sprintf("RTPOutboundStream-%d", trackEncoding.ssrc)
+		remoteInboundID := fmt.Sprintf("RTPRemoteInboundStream-%d", trackEncoding.ssrc)
+
+		collector.Collecting()
+		if r.kind == RTPCodecTypeAudio {
+			collector.Collect(mediaSourceID, AudioSourceStats{
+				Timestamp:       now,
+				Type:            StatsTypeMediaSource,
+				ID:              mediaSourceID,
+				TrackIdentifier: trackEncoding.track.ID(),
+				Kind:            r.kind.String(),
+			})
+		} else {
+			collector.Collect(mediaSourceID, VideoSourceStats{
+				Timestamp:       now,
+				Type:            StatsTypeMediaSource,
+				ID:              mediaSourceID,
+				TrackIdentifier: trackEncoding.track.ID(),
+				Kind:            r.kind.String(),
+			})
+		}
+
+		outbound := OutboundRTPStreamStats{
+			Timestamp:     now,
+			Type:          StatsTypeOutboundRTP,
+			ID:            outboundID,
+			SSRC:          trackEncoding.ssrc,
+			Kind:          r.kind.String(),
+			TransportID:   transportID,
+			CodecID:       codecID,
+			TrackID:       trackEncoding.track.ID(),
+			SenderID:      r.id,
+			MediaSourceID: mediaSourceID,
+		}
+
+		var streamStats *stats.Stats
+		if statsGetter != nil {
+			streamStats = statsGetter.Get(uint32(trackEncoding.ssrc))
+		}
+
+		if streamStats != nil {
+			outbound.PacketsSent = uint32(streamStats.OutboundRTPStreamStats.PacketsSent)
+			outbound.BytesSent = streamStats.OutboundRTPStreamStats.BytesSent
+			outbound.NACKCount = streamStats.OutboundRTPStreamStats.NACKCount
+			outbound.FIRCount = streamStats.OutboundRTPStreamStats.FIRCount
+			outbound.PLICount = streamStats.OutboundRTPStreamStats.PLICount
+		}
+
+		// A remote-inbound-rtp entry only exists once the remote has reported on the stream
+		remoteInbound := streamStats != nil && (streamStats.RemoteInboundRTPStreamStats.PacketsReceived != 0 ||
+			streamStats.RemoteInboundRTPStreamStats.PacketsLost != 0 ||
+			streamStats.RemoteInboundRTPStreamStats.RoundTripTimeMeasurements != 0)
+		if remoteInbound {
+			outbound.RemoteID = remoteInboundID
+		}
+
+		collector.Collecting()
+		collector.Collect(outboundID, outbound)
+
+		if !remoteInbound {
+			continue
+		}
+
+		collector.Collecting()
+		collector.Collect(remoteInboundID, RemoteInboundRTPStreamStats{
+			Timestamp:       now,
+			Type:            StatsTypeRemoteInboundRTP,
+			ID:              remoteInboundID,
+			SSRC:            trackEncoding.ssrc,
+			Kind:            r.kind.String(),
+			TransportID:     transportID,
+			CodecID:         codecID,
+			PacketsReceived: uint32(streamStats.RemoteInboundRTPStreamStats.PacketsReceived),
+			PacketsLost:     int32(streamStats.RemoteInboundRTPStreamStats.PacketsLost),
+			Jitter:          streamStats.RemoteInboundRTPStreamStats.Jitter,
+			LocalID:         outboundID,
+			RoundTripTime:   streamStats.RemoteInboundRTPStreamStats.RoundTripTime.Seconds(),
+			FractionLost:    streamStats.RemoteInboundRTPStreamStats.FractionLost,
+		})
+	}
+}
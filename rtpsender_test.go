@@ -14,6 +14,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
 	"github.com/pion/transport/v3/test"
 	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
@@ -404,3 +407,337 @@ func Test_RTPSender_Add_Encoding(t *testing.T) {
 
 	assert.NoError(t, peerConnection.Close())
 }
+
+func Test_RTPSender_OnWriteResult(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	t.Run("NotConnected", func(t *testing.T) {
+		rtpSender := &RTPSender{
+			transport:  &DTLSTransport{srtpReady: make(chan struct{})},
+			stopCalled: make(chan struct{}),
+		}
+		srtpStream := &srtpWriterFuture{ssrc: 1234, rtpSender: rtpSender}
+
+		var results []TrackLocalWriteResult
+		rtpSender.OnWriteResult(func(result TrackLocalWriteResult) {
+			results = append(results, result)
+		})
+
+		writeStream := &interceptorToTrackLocalWriter{reportWrites: rtpSender.reportWrites}
+		writeStream.interceptor.Store(interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+			return rtpSender.writeRTP(srtpStream, header, payload, attributes)
+		}))
+
+		n, err := writeStream.WriteRTP(&rtp.Header{SSRC: 1234, SequenceNumber: 5}, []byte{0x00})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		assert.Len(t, results, 1)
+		assert.ErrorIs(t, results[0].Err, ErrTrackLocalWriteNotConnected)
+		assert.Equal(t, SSRC(1234), results[0].SSRC)
+		assert.Equal(t, uint16(5), results[0].SequenceNumber)
+		assert.Equal(t, 0, results[0].BytesSent)
+	})
+
+	t.Run("Connected", func(t *testing.T) {
+		sender, receiver, err := newPair()
+		assert.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+
+		rtpSender, err := sender.AddTrack(track)
+		assert.NoError(t, err)
+
+		sent, sentCancel := context.WithCancel(context.Background())
+		rtpSender.OnWriteResult(func(result TrackLocalWriteResult) {
+			if result.Err == nil {
+				assert.Greater(t, result.BytesSent, 0)
+				assert.GreaterOrEqual(t, result.Latency, time.Duration(0))
+				sentCancel()
+			}
+		})
+
+		assert.NoError(t, signalPair(sender, receiver))
+
+		func() {
+			for range time.Tick(time.Millisecond * 20) {
+				select {
+				case <-sent.Done():
+					return
+				default:
+					assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xAA}, Duration: time.Second}))
+				}
+			}
+		}()
+
+		closePairNow(t, sender, receiver)
+	})
+}
+
+func Test_RTPSender_WriteQueue(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	t.Run("Bounded", func(t *testing.T) {
+		rtpSender := &RTPSender{
+			transport:  &DTLSTransport{srtpReady: make(chan struct{})},
+			stopCalled: make(chan struct{}),
+		}
+		srtpStream := &srtpWriterFuture{ssrc: 1234, rtpSender: rtpSender, queueSize: 2}
+
+		for i, expectQueued := range []bool{true, true, false} {
+			n, queued, err := srtpStream.writeRTP(&rtp.Header{SSRC: 1234, SequenceNumber: uint16(i)}, []byte{0x00})
+			assert.NoError(t, err)
+			assert.Equal(t, 0, n)
+			assert.Equal(t, expectQueued, queued)
+		}
+
+		select {
+		case <-rtpSender.Ready():
+			assert.Fail(t, "RTPSender ready before DTLS is connected")
+		default:
+		}
+
+		// Stopping the RTPSender drops the queue
+		close(rtpSender.stopCalled)
+		assert.Eventually(t, func() bool {
+			srtpStream.queueMu.Lock()
+			defer srtpStream.queueMu.Unlock()
+
+			return atomic.LoadInt32(&srtpStream.queuePending) == 0 && len(srtpStream.queue) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		s := SettingEngine{}
+		s.SetSRTPWriteQueueSize(16)
+
+		sender, receiver, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+		assert.NoError(t, err)
+
+		track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+
+		rtpSender, err := sender.AddTrack(track)
+		assert.NoError(t, err)
+
+		received := make(chan bool)
+		receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+			for i := 0; i < 8; i++ {
+				pkt, _, err := track.ReadRTP()
+				assert.NoError(t, err)
+				assert.Equal(t, uint16(1000+i), pkt.SequenceNumber)
+			}
+			received <- true
+		})
+
+		assert.NoError(t, signalPair(sender, receiver))
+
+		// Written once, as soon as the track is bound. The packets written
+		// while the queue is flushed stay behind the queued ones.
+		<-rtpSender.sendCalled
+		for i := 0; i < 8; i++ {
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{Version: 2, SequenceNumber: uint16(1000 + i)}, Payload: []byte{0xAA}}))
+		}
+
+		<-rtpSender.Ready()
+		closePair(t, sender, receiver, received)
+	})
+}
+
+func Test_RTPSender_WriteSRTP(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	sender, receiver, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	rtpSender, err := sender.AddTrack(track)
+	assert.NoError(t, err)
+
+	_, err = rtpSender.WriteSRTP([]byte{0x80, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01})
+	assert.ErrorIs(t, err, ErrTrackLocalWriteNotConnected)
+
+	_, err = rtpSender.WriteSRTP([]byte{0x80, 0xC8})
+	assert.ErrorIs(t, err, errRTPSenderSRTPInvalid)
+
+	received := make(chan bool)
+	receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		pkt, _, err := track.ReadRTP()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xAA, 0xBB}, pkt.Payload)
+		received <- true
+	})
+
+	assert.NoError(t, signalPair(sender, receiver))
+	<-rtpSender.Ready()
+
+	// Protect the packet with keys shared out of band, which are the keys of
+	// this hop here
+	transport := rtpSender.Transport()
+	config := &srtp.Config{Profile: transport.srtpProtectionProfile}
+	connState := transport.conn.ConnectionState()
+	assert.NoError(t, config.ExtractSessionKeysFromDTLS(&connState, transport.role() == DTLSRoleClient))
+
+	srtpContext, err := srtp.CreateContext(config.Keys.LocalMasterKey, config.Keys.LocalMasterSalt, config.Profile)
+	assert.NoError(t, err)
+
+	packet, err := (&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 1,
+			SSRC:           uint32(rtpSender.GetParameters().Encodings[0].SSRC),
+		},
+		Payload: []byte{0xAA, 0xBB},
+	}).Marshal()
+	assert.NoError(t, err)
+
+	protected, err := srtpContext.EncryptRTP(nil, packet, nil)
+	assert.NoError(t, err)
+
+	n, err := rtpSender.WriteSRTP(protected)
+	assert.NoError(t, err)
+	assert.Equal(t, len(protected), n)
+
+	closePair(t, sender, receiver, received)
+}
+
+func Test_RTPSender_SwitchCodec(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	sender, receiver, err := newPair()
+	assert.NoError(t, err)
+
+	trackA, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	trackB, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeH264}, "video", "pion")
+	assert.NoError(t, err)
+
+	rtpSender, err := sender.AddTrack(trackA)
+	assert.NoError(t, err)
+
+	// The codec can't be switched before Send
+	_, err = rtpSender.SwitchCodec(trackB)
+	assert.ErrorIs(t, err, errRTPSenderSendNotCalled)
+
+	_, err = rtpSender.SwitchCodec(nil)
+	assert.ErrorIs(t, err, errRTPSenderTrackNil)
+
+	seenPacketA, seenPacketACancel := context.WithCancel(context.Background())
+	seenPacketB, seenPacketBCancel := context.WithCancel(context.Background())
+
+	receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			pkt, _, err := track.ReadRTP()
+			if err != nil {
+				return
+			}
+
+			switch pkt.Payload[len(pkt.Payload)-1] {
+			case 0xAA:
+				seenPacketACancel()
+			case 0xBB:
+				assert.Equal(t, MimeTypeH264, track.Codec().MimeType)
+				seenPacketBCancel()
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(sender, receiver))
+
+	func() {
+		for range time.Tick(time.Millisecond * 20) {
+			select {
+			case <-seenPacketA.Done():
+				return
+			default:
+				assert.NoError(t, trackA.WriteSample(media.Sample{Data: []byte{0xAA}, Duration: time.Second}))
+			}
+		}
+	}()
+
+	// The application is asked for a keyframe of the new codec
+	var keyframeRequired TrackLocal
+	rtpSender.OnKeyframeRequired(func(track TrackLocal) {
+		keyframeRequired = track
+	})
+
+	codec, err := rtpSender.SwitchCodec(trackB)
+	assert.NoError(t, err)
+	assert.Equal(t, MimeTypeH264, codec.MimeType)
+	assert.Equal(t, trackB, keyframeRequired)
+
+	func() {
+		for range time.Tick(time.Millisecond * 20) {
+			select {
+			case <-seenPacketB.Done():
+				return
+			default:
+				assert.NoError(t, trackB.WriteSample(media.Sample{Data: []byte{0xBB}, Duration: time.Second}))
+			}
+		}
+	}()
+
+	closePairNow(t, sender, receiver)
+}
+
+func Test_RTPSender_ScalabilityMode(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	sender, receiver, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP9}, "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = sender.AddTransceiverFromTrack(track, RTPTransceiverInit{
+		Direction:     RTPTransceiverDirectionSendonly,
+		SendEncodings: []RTPEncodingParameters{{ScalabilityMode: "L4T4"}},
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedScalabilityMode)
+
+	transceiver, err := sender.AddTransceiverFromTrack(track, RTPTransceiverInit{
+		Direction:     RTPTransceiverDirectionSendonly,
+		SendEncodings: []RTPEncodingParameters{{ScalabilityMode: "L3T3_KEY"}},
+	})
+	assert.NoError(t, err)
+
+	rtpSender := transceiver.Sender()
+	assert.Equal(t, "L3T3_KEY", rtpSender.GetParameters().Encodings[0].ScalabilityMode)
+	assert.ErrorIs(t, rtpSender.SetScalabilityMode("f", "L1T3"), errRTPSenderNoTrackForRID)
+
+	assert.NoError(t, signalPair(sender, receiver))
+
+	// The mode is kept once sending, as VP9 supports it
+	<-rtpSender.sendCalled
+	assert.Equal(t, "L3T3_KEY", rtpSender.GetParameters().Encodings[0].ScalabilityMode)
+
+	assert.NoError(t, rtpSender.SetScalabilityMode("", "L1T3"))
+	assert.Equal(t, "L1T3", rtpSender.GetParameters().Encodings[0].ScalabilityMode)
+
+	closePairNow(t, sender, receiver)
+}
@@ -22,10 +22,16 @@ type RTPTransceiver struct {
 	direction        atomic.Value // RTPTransceiverDirection
 	currentDirection atomic.Value // RTPTransceiverDirection
 
-	codecs []RTPCodecParameters // User provided codecs via SetCodecPreferences
+	codecs       []RTPCodecParameters // User provided codecs via SetCodecPreferences
+	remoteCodecs bool                 // codecs were set from the remote description which created the transceiver
 
-	stopped bool
-	kind    RTPCodecType
+	receiveRIDRestrictions map[string]RIDRestrictions // Set via SetReceiveRIDRestrictions
+
+	stopped  bool
+	rejected bool
+	kind     RTPCodecType
+
+	onNegotiationNeeded func() // Set by the PeerConnection the transceiver belongs to
 
 	api *API
 	mu  sync.RWMutex
@@ -52,16 +58,36 @@ func (t *RTPTransceiver) SetCodecPreferences(codecs []RTPCodecParameters) error
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	parsers := t.api.mediaEngine.getFMTPParsers()
 	for _, codec := range codecs {
-		if _, matchType := codecParametersFuzzySearch(codec, t.api.mediaEngine.getCodecsByKind(t.kind)); matchType == codecMatchNone {
+		if _, matchType := codecParametersFuzzySearch(parsers, codec, t.api.mediaEngine.getCodecsByKind(t.kind)); matchType == codecMatchNone {
 			return fmt.Errorf("%w %s", errRTPTransceiverCodecUnsupported, codec.MimeType)
 		}
 	}
 
 	t.codecs = codecs
+	t.remoteCodecs = false
 	return nil
 }
 
+// setRemoteCodecPreferences sets the codecs of the remote description which
+// created the transceiver, they are updated by later remote descriptions
+// until SetCodecPreferences is called
+func (t *RTPTransceiver) setRemoteCodecPreferences(codecs []RTPCodecParameters) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.codecs = codecs
+	t.remoteCodecs = true
+}
+
+func (t *RTPTransceiver) hasRemoteCodecPreferences() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.remoteCodecs
+}
+
 // Codecs returns list of supported codecs
 func (t *RTPTransceiver) getCodecs() []RTPCodecParameters {
 	t.mu.RLock()
@@ -72,9 +98,10 @@ func (t *RTPTransceiver) getCodecs() []RTPCodecParameters {
 		return mediaEngineCodecs
 	}
 
+	parsers := t.api.mediaEngine.getFMTPParsers()
 	filteredCodecs := []RTPCodecParameters{}
 	for _, codec := range t.codecs {
-		if c, matchType := codecParametersFuzzySearch(codec, mediaEngineCodecs); matchType != codecMatchNone {
+		if c, matchType := codecParametersFuzzySearch(parsers, codec, mediaEngineCodecs); matchType != codecMatchNone {
 			if codec.PayloadType == 0 {
 				codec.PayloadType = c.PayloadType
 			}
@@ -112,6 +139,24 @@ func (t *RTPTransceiver) setSender(s *RTPSender) {
 	t.sender.Store(s)
 }
 
+func (t *RTPTransceiver) setOnNegotiationNeeded(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onNegotiationNeeded = f
+}
+
+// negotiationNeeded updates the negotiation-needed flag of the PeerConnection
+// the transceiver belongs to
+func (t *RTPTransceiver) negotiationNeeded() {
+	t.mu.RLock()
+	f := t.onNegotiationNeeded
+	t.mu.RUnlock()
+
+	if f != nil {
+		f()
+	}
+}
+
 // Receiver returns the RTPTransceiver's RTPReceiver if it has one
 func (t *RTPTransceiver) Receiver() *RTPReceiver {
 	if v, ok := t.receiver.Load().(*RTPReceiver); ok {
@@ -169,6 +214,26 @@ func (t *RTPTransceiver) Stop() error {
 	return nil
 }
 
+// Reject marks the RTPTransceiver as rejected, its media section is answered
+// with port 0 while the other media sections are accepted. This allows to
+// decline the media of a remote offer after SetRemoteDescription without
+// failing the whole negotiation. A rejected RTPTransceiver is inactive and
+// stays rejected in later answers.
+func (t *RTPTransceiver) Reject() {
+	t.mu.Lock()
+	t.rejected = true
+	t.mu.Unlock()
+
+	t.setDirection(RTPTransceiverDirectionInactive)
+}
+
+func (t *RTPTransceiver) isRejected() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.rejected
+}
+
 func (t *RTPTransceiver) setReceiver(r *RTPReceiver) {
 	if r != nil {
 		r.setRTPTransceiver(t)
@@ -135,3 +135,47 @@ func Test_RTPTransceiver_SetCodecPreferences_PayloadType(t *testing.T) {
 
 	closePairNow(t, offerPC, answerPC)
 }
+
+func Test_RTPTransceiver_Reject(t *testing.T) {
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	for _, transceiver := range pcAnswer.GetTransceivers() {
+		if transceiver.Kind() == RTPCodecTypeVideo {
+			transceiver.Reject()
+			assert.Equal(t, RTPTransceiverDirectionInactive, transceiver.Direction())
+		}
+	}
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	parsed, err := answer.Unmarshal()
+	assert.NoError(t, err)
+	assert.Len(t, parsed.MediaDescriptions, 2)
+	for _, media := range parsed.MediaDescriptions {
+		if media.MediaName.Media == RTPCodecTypeVideo.String() {
+			assert.Equal(t, 0, media.MediaName.Port.Value)
+		} else {
+			assert.NotEqual(t, 0, media.MediaName.Port.Value)
+		}
+	}
+
+	group, ok := parsed.Attribute("group")
+	assert.True(t, ok)
+	assert.Equal(t, "BUNDLE 0", group)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
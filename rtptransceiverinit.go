@@ -7,5 +7,8 @@ package webrtc
 type RTPTransceiverInit struct {
 	Direction     RTPTransceiverDirection
 	SendEncodings []RTPEncodingParameters
-	// Streams       []*Track
+	// StreamIDs are the IDs of the streams the track belongs to, see
+	// RTPSender.SetStreams. When nil the StreamID of the track is used, while
+	// an empty slice sends the track without any stream.
+	StreamIDs []string
 }
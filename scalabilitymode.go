@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+)
+
+const (
+	scalabilityModeKeySuffix      = "_KEY"
+	scalabilityModeKeyShiftSuffix = "_KEY_SHIFT"
+)
+
+// scalabilityMode is a parsed scalability mode identifier of WebRTC-SVC, like
+// L3T3_KEY. L modes have SpatialLayers spatial layers and S modes have
+// SpatialLayers simulcast streams.
+// https://www.w3.org/TR/webrtc-svc/#scalabilitymodes*
+type scalabilityMode struct {
+	simulcast      bool
+	spatialLayers  int
+	temporalLayers int
+}
+
+// parseScalabilityMode parses mode and returns false if it isn't one of the
+// scalability modes of WebRTC-SVC
+func parseScalabilityMode(mode string) (scalabilityMode, bool) {
+	var parsed scalabilityMode
+
+	if len(mode) < 4 || mode[2] != 'T' {
+		return parsed, false
+	}
+
+	switch mode[0] {
+	case 'L':
+	case 'S':
+		parsed.simulcast = true
+	default:
+		return parsed, false
+	}
+
+	if mode[1] < '1' || mode[1] > '3' || mode[3] < '1' || mode[3] > '3' {
+		return parsed, false
+	}
+	parsed.spatialLayers = int(mode[1] - '0')
+	parsed.temporalLayers = int(mode[3] - '0')
+
+	if parsed.simulcast && parsed.spatialLayers == 1 {
+		return parsed, false
+	}
+
+	suffix := mode[4:]
+	switch {
+	case suffix == "":
+		return parsed, true
+	case suffix == "h":
+		// Spatial layers with a 1.5:1 resolution ratio
+		return parsed, parsed.spatialLayers > 1
+	case parsed.simulcast || parsed.spatialLayers == 1:
+		return parsed, false
+	case suffix == scalabilityModeKeySuffix:
+		return parsed, true
+	case suffix == scalabilityModeKeyShiftSuffix:
+		return parsed, parsed.temporalLayers > 1
+	default:
+		return parsed, false
+	}
+}
+
+// scalabilityModeSupported returns if the codec mimeType can encode with the
+// scalability mode mode. An empty mode is the default of every codec.
+func scalabilityModeSupported(mimeType, mode string) bool {
+	if mode == "" {
+		return true
+	}
+
+	parsed, ok := parseScalabilityMode(mode)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case strings.EqualFold(mimeType, MimeTypeVP9), strings.EqualFold(mimeType, MimeTypeAV1):
+		return true
+	case strings.EqualFold(mimeType, MimeTypeVP8), strings.EqualFold(mimeType, MimeTypeH264):
+		// Only temporal scalability
+		return !parsed.simulcast && parsed.spatialLayers == 1
+	default:
+		return false
+	}
+}
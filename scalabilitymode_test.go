@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScalabilityMode(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected scalabilityMode
+		ok       bool
+	}{
+		{"L1T1", scalabilityMode{spatialLayers: 1, temporalLayers: 1}, true},
+		{"L1T3", scalabilityMode{spatialLayers: 1, temporalLayers: 3}, true},
+		{"L3T3_KEY", scalabilityMode{spatialLayers: 3, temporalLayers: 3}, true},
+		{"L2T2_KEY_SHIFT", scalabilityMode{spatialLayers: 2, temporalLayers: 2}, true},
+		{"L2T1h", scalabilityMode{spatialLayers: 2, temporalLayers: 1}, true},
+		{"S3T3", scalabilityMode{simulcast: true, spatialLayers: 3, temporalLayers: 3}, true},
+		{"S2T1h", scalabilityMode{simulcast: true, spatialLayers: 2, temporalLayers: 1}, true},
+		{"", scalabilityMode{}, false},
+		{"L4T1", scalabilityMode{}, false},
+		{"L1T0", scalabilityMode{}, false},
+		{"S1T1", scalabilityMode{}, false},
+		{"L1T1h", scalabilityMode{}, false},
+		{"L1T2_KEY", scalabilityMode{}, false},
+		{"L2T1_KEY_SHIFT", scalabilityMode{}, false},
+		{"S2T2_KEY", scalabilityMode{}, false},
+		{"L2T2_KEYS", scalabilityMode{}, false},
+		{"l1t1", scalabilityMode{}, false},
+	}
+
+	for i, testCase := range testCases {
+		parsed, ok := parseScalabilityMode(testCase.mode)
+		assert.Equal(t, testCase.ok, ok, "testCase: %d %v", i, testCase)
+		if testCase.ok {
+			assert.Equal(t, testCase.expected, parsed, "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestScalabilityModeSupported(t *testing.T) {
+	testCases := []struct {
+		mimeType string
+		mode     string
+		expected bool
+	}{
+		{MimeTypeOpus, "", true},
+		{MimeTypeOpus, "L1T1", false},
+		{MimeTypeVP8, "L1T3", true},
+		{MimeTypeVP8, "L2T2", false},
+		{MimeTypeH264, "L1T2", true},
+		{MimeTypeH264, "S2T1", false},
+		{MimeTypeVP9, "L3T3_KEY", true},
+		{"video/av1", "S3T3", true},
+		{MimeTypeVP9, "L4T4", false},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expected,
+			scalabilityModeSupported(testCase.mimeType, testCase.mode),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
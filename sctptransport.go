@@ -8,6 +8,7 @@ package webrtc
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"sync"
@@ -21,6 +22,9 @@ import (
 
 const sctpMaxChannels = uint16(65535)
 
+// sctpTransportStatsID is the ID of the SCTPTransportStats in a StatsReport
+const sctpTransportStatsID = "sctpTransport"
+
 // SCTPTransport provides details about the SCTP transport.
 type SCTPTransport struct {
 	lock sync.RWMutex
@@ -56,6 +60,13 @@ type SCTPTransport struct {
 	dataChannelsRequested uint32
 	dataChannelsAccepted  uint32
 
+	// scheduler orders the messages of DataChannels by priority
+	scheduler *dataChannelScheduler
+
+	// readBufferPool holds the buffers the DataChannels read messages into,
+	// they fit the largest message the remote may send
+	readBufferPool *sync.Pool
+
 	api *API
 	log logging.LeveledLogger
 }
@@ -71,7 +82,9 @@ func (api *API) NewSCTPTransport(dtls *DTLSTransport) *SCTPTransport {
 		log:           api.settingEngine.LoggerFactory.NewLogger("ortc"),
 	}
 
-	res.updateMessageSize()
+	res.scheduler = newDataChannelScheduler(res)
+	res.readBufferPool = newDataChannelReadBufferPool(api.settingEngine.getSCTPMaxMessageSize())
+	res.updateMessageSize(sctpMaxMessageSizeUnsetValue)
 	res.updateMaxChannels()
 
 	return res
@@ -88,28 +101,35 @@ func (r *SCTPTransport) Transport() *DTLSTransport {
 // GetCapabilities returns the SCTPCapabilities of the SCTPTransport.
 func (r *SCTPTransport) GetCapabilities() SCTPCapabilities {
 	return SCTPCapabilities{
-		MaxMessageSize: 0,
+		MaxMessageSize: r.api.settingEngine.getSCTPMaxMessageSize(),
 	}
 }
 
 // Start the SCTPTransport. Since both local and remote parties must mutually
 // create an SCTPTransport, SCTP SO (Simultaneous Open) is used to establish
 // a connection over SCTP.
-func (r *SCTPTransport) Start(SCTPCapabilities) error {
+func (r *SCTPTransport) Start(capabilities SCTPCapabilities) error {
 	if r.isStarted {
 		return nil
 	}
 	r.isStarted = true
 
+	r.updateMessageSize(float64(capabilities.MaxMessageSize))
+
 	dtlsTransport := r.Transport()
-	if dtlsTransport == nil || dtlsTransport.conn == nil {
+	if dtlsTransport == nil {
+		return errSCTPTransportDTLS
+	}
+	conn := dtlsTransport.sctpConn()
+	if conn == nil {
 		return errSCTPTransportDTLS
 	}
 
 	sctpAssociation, err := sctp.Client(sctp.Config{
-		NetConn:              dtlsTransport.conn,
+		NetConn:              conn,
 		MaxReceiveBufferSize: r.api.settingEngine.sctp.maxReceiveBufferSize,
-		EnableZeroChecksum:   true,
+		MaxMessageSize:       r.sctpMaxMessageSize(),
+		EnableZeroChecksum:   !r.api.settingEngine.sctp.disableZeroChecksum,
 		LoggerFactory:        r.api.settingEngine.LoggerFactory,
 	})
 	if err != nil {
@@ -228,6 +248,7 @@ ACCEPT:
 			Ordered:           ordered,
 			MaxPacketLifeTime: maxPacketLifeTime,
 			MaxRetransmits:    maxRetransmits,
+			Priority:          priorityTypeFromChannelPriority(dc.Config.Priority),
 		}, r, r.api.settingEngine.LoggerFactory.NewLogger("ortc"))
 		if err != nil {
 			r.log.Errorf("Failed to accept data channel: %v", err)
@@ -306,16 +327,37 @@ func (r *SCTPTransport) onDataChannel(dc *DataChannel) (done chan struct{}) {
 	return
 }
 
-func (r *SCTPTransport) updateMessageSize() {
+func (r *SCTPTransport) updateMessageSize(remoteMaxMessageSize float64) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	var remoteMaxMessageSize float64 = 65536 // pion/webrtc#758
-	var canSendSize float64 = 65536          // pion/webrtc#758
+	// SCTP fragments messages, any size can be sent as long as the remote
+	// accepts it
+	var canSendSize float64
 
 	r.maxMessageSize = r.calcMessageSize(remoteMaxMessageSize, canSendSize)
 }
 
+// MaxMessageSize returns the maximum size of data that can be passed to a
+// DataChannel's Send method, as negotiated with the remote. It is +Inf if the
+// remote accepts messages of any size.
+func (r *SCTPTransport) MaxMessageSize() float64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.maxMessageSize
+}
+
+// sctpMaxMessageSize returns MaxMessageSize as the limit of the association
+func (r *SCTPTransport) sctpMaxMessageSize() uint32 {
+	maxMessageSize := r.MaxMessageSize()
+	if maxMessageSize > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return uint32(maxMessageSize)
+}
+
 func (r *SCTPTransport) calcMessageSize(remoteMaxMessageSize, canSendSize float64) float64 {
 	switch {
 	case remoteMaxMessageSize == 0 &&
@@ -363,10 +405,18 @@ func (r *SCTPTransport) State() SCTPTransportState {
 func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 	collector.Collecting()
 
+	stats := r.GetStats()
+	collector.Collect(stats.ID, stats)
+}
+
+// GetStats returns the statistics of the SCTP association, the same as the
+// SCTPTransportStats in the StatsReport of the PeerConnection. The counters
+// are zero until the association is established.
+func (r *SCTPTransport) GetStats() SCTPTransportStats {
 	stats := SCTPTransportStats{
 		Timestamp: statsTimestampFrom(time.Now()),
 		Type:      StatsTypeSCTPTransport,
-		ID:        "sctpTransport",
+		ID:        sctpTransportStatsID,
 	}
 
 	association := r.association()
@@ -379,7 +429,7 @@ func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 		stats.MTU = association.MTU()
 	}
 
-	collector.Collect(stats.ID, stats)
+	return stats
 }
 
 func (r *SCTPTransport) generateAndSetDataChannelID(dtlsRole DTLSRole, idOut **uint16) error {
@@ -414,6 +464,46 @@ func (r *SCTPTransport) generateAndSetDataChannelID(dtlsRole DTLSRole, idOut **u
 	return &rtcerr.OperationError{Err: ErrMaxDataChannelID}
 }
 
+// ValidateNegotiatedDataChannelID checks that id can be used for a DataChannel
+// negotiated out-of-band, with DataChannelInit.Negotiated set. It must be below
+// MaxChannels, not be used by another DataChannel of the SCTPTransport, and
+// have the parity of the local DTLS role, even for DTLSRoleClient and odd for
+// DTLSRoleServer, so the DataChannels the remote opens in-band can't collide
+// with it. The DTLS role is only final once the remote description is set.
+func (r *SCTPTransport) ValidateNegotiatedDataChannelID(id uint16) error {
+	if id >= r.MaxChannels() {
+		return &rtcerr.OperationError{Err: fmt.Errorf("%w: %d", ErrMaxDataChannelID, id)}
+	}
+
+	isServer := r.Transport().role() == DTLSRoleServer
+	if (id%2 == 1) != isServer {
+		return &rtcerr.OperationError{Err: fmt.Errorf("%w: %d", ErrDataChannelIDParity, id)}
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, dc := range r.dataChannels {
+		if dcID := dc.ID(); dcID != nil && *dcID == id {
+			return &rtcerr.OperationError{Err: fmt.Errorf("%w: %d", ErrDataChannelIDInUse, id)}
+		}
+	}
+
+	return nil
+}
+
+// NextNegotiatedDataChannelID allocates the lowest ID which passes
+// ValidateNegotiatedDataChannelID. The ID is only reserved once the
+// DataChannel is created with it, and has to be signaled to the remote.
+func (r *SCTPTransport) NextNegotiatedDataChannelID() (uint16, error) {
+	var id *uint16
+	if err := r.generateAndSetDataChannelID(r.Transport().role(), &id); err != nil {
+		return 0, err
+	}
+
+	return *id, nil
+}
+
 func (r *SCTPTransport) association() *sctp.Association {
 	if r == nil {
 		return nil
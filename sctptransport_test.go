@@ -6,7 +6,13 @@
 
 package webrtc
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
 
 func TestGenerateDataChannelID(t *testing.T) {
 	sctpTransportWithChannels := func(ids []uint16) *SCTPTransport {
@@ -48,3 +54,76 @@ func TestGenerateDataChannelID(t *testing.T) {
 		}
 	}
 }
+
+func TestSCTPTransport_GetStats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	stats := pcOffer.SCTP().GetStats()
+	assert.Equal(t, sctpTransportStatsID, stats.ID)
+	assert.Zero(t, stats.BytesSent)
+
+	d, err := pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	opened := make(chan struct{})
+	d.OnOpen(func() {
+		close(opened)
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-opened
+
+	stats = pcOffer.SCTP().GetStats()
+	assert.Equal(t, StatsTypeSCTPTransport, stats.Type)
+	assert.NotZero(t, stats.BytesSent)
+	assert.NotZero(t, stats.CongestionWindow)
+	assert.NotZero(t, stats.MTU)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestSCTPTransport_NegotiatedDataChannelID(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// The DTLS role of an offerer without remote description is the client
+	sctpTransport := pc.SCTP()
+	id, err := sctpTransport.NextNegotiatedDataChannelID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), id)
+	assert.NoError(t, sctpTransport.ValidateNegotiatedDataChannelID(id))
+
+	negotiated := true
+	_, err = pc.CreateDataChannel("negotiated", &DataChannelInit{Negotiated: &negotiated, ID: &id})
+	assert.NoError(t, err)
+
+	for i, testCase := range []struct {
+		id  uint16
+		err error
+	}{
+		{0, ErrDataChannelIDInUse},
+		{1, ErrDataChannelIDParity},
+		{2, nil},
+		{sctpMaxChannels, ErrMaxDataChannelID},
+	} {
+		err = sctpTransport.ValidateNegotiatedDataChannelID(testCase.id)
+		if testCase.err == nil {
+			assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		} else {
+			assert.ErrorIs(t, err, testCase.err, "testCase: %d %v", i, testCase)
+		}
+	}
+
+	id, err = sctpTransport.NextNegotiatedDataChannelID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(2), id)
+
+	assert.NoError(t, pc.Close())
+}
@@ -26,6 +26,7 @@ type trackDetails struct {
 	mid        string
 	kind       RTPCodecType
 	streamID   string
+	streamIDs  []string
 	id         string
 	ssrcs      []SSRC
 	repairSsrc *SSRC
@@ -88,6 +89,7 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) (
 		// Plan B can have multiple tracks in a signle media section
 		streamID := ""
 		trackID := ""
+		mediaMsids := msidValuesFromMediaDescription(media)
 
 		// If media section is recvonly or inactive skip
 		if _, ok := media.Attribute(sdp.AttrKeyRecvOnly); ok {
@@ -140,9 +142,10 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) (
 			// Handle `a=msid:<stream_id> <track_label>` for Unified plan. The first value is the same as MediaStream.id
 			// in the browser and can be used to figure out which tracks belong to the same stream. The browser should
 			// figure this out automatically when an ontrack event is emitted on RTCPeerConnection.
+			// A track belonging to several streams has an a=msid for each, the first is its StreamID.
 			case sdp.AttrKeyMsid:
 				split := strings.Split(attr.Value, " ")
-				if len(split) == 2 {
+				if len(split) == 2 && attr.Value == mediaMsids[0] {
 					streamID = split[0]
 					trackID = split[1]
 				}
@@ -178,6 +181,7 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) (
 				trackDetails.mid = midValue
 				trackDetails.kind = codecType
 				trackDetails.streamID = streamID
+				trackDetails.streamIDs = trackStreamIDs(mediaMsids, streamID)
 				trackDetails.id = trackID
 				trackDetails.ssrcs = []SSRC{SSRC(ssrc)}
 
@@ -194,16 +198,17 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) (
 			}
 		}
 
-		if rids := getRids(media); len(rids) != 0 && trackID != "" && streamID != "" {
+		if rids := getRids(media); len(rids) != 0 {
 			simulcastTrack := trackDetails{
-				mid:      midValue,
-				kind:     codecType,
-				streamID: streamID,
-				id:       trackID,
-				rids:     []string{},
+				mid:       midValue,
+				kind:      codecType,
+				streamID:  streamID,
+				streamIDs: trackStreamIDs(mediaMsids, streamID),
+				id:        trackID,
+				rids:      []string{},
 			}
-			for rid := range rids {
-				simulcastTrack.rids = append(simulcastTrack.rids, rid)
+			for _, rid := range rids {
+				simulcastTrack.rids = append(simulcastTrack.rids, rid.id)
 			}
 
 			tracksInMediaSection = []trackDetails{simulcastTrack}
@@ -238,33 +243,84 @@ func trackDetailsToRTPReceiveParameters(t *trackDetails) RTPReceiveParameters {
 	return RTPReceiveParameters{Encodings: encodings}
 }
 
-func getRids(media *sdp.MediaDescription) map[string]*simulcastRid {
-	rids := map[string]*simulcastRid{}
+// getRids returns the rids of a media section. If the section has a
+// simulcast attribute, the rids are ordered as listed in it and rids it
+// doesn't list are left out. Of alternatives, like "a=simulcast:send 1,2",
+// only the first one is kept.
+// https://datatracker.ietf.org/doc/html/rfc8853#section-5.1
+func getRids(media *sdp.MediaDescription) []*simulcastRid {
+	rids := []*simulcastRid{}
+	ridsByID := map[string]*simulcastRid{}
 	var simulcastAttr string
 	for _, attr := range media.Attributes {
 		if attr.Key == sdpAttributeRid {
 			split := strings.Split(attr.Value, " ")
-			rids[split[0]] = &simulcastRid{attrValue: attr.Value}
+			if _, ok := ridsByID[split[0]]; ok {
+				continue
+			}
+			rid := &simulcastRid{id: split[0], attrValue: attr.Value}
+			ridsByID[rid.id] = rid
+			rids = append(rids, rid)
 		} else if attr.Key == sdpAttributeSimulcast {
 			simulcastAttr = attr.Value
 		}
 	}
+	if simulcastAttr == "" {
+		return rids
+	}
+
 	// process paused stream like "a=simulcast:send 1;~2;~3"
-	if simulcastAttr != "" {
-		if space := strings.Index(simulcastAttr, " "); space > 0 {
-			simulcastAttr = simulcastAttr[space+1:]
-		}
-		ridStates := strings.Split(simulcastAttr, ";")
-		for _, ridState := range ridStates {
-			if ridState[:1] == "~" {
-				rid := ridState[1:]
-				if r, ok := rids[rid]; ok {
-					r.paused = true
+	orderedRids := []*simulcastRid{}
+	for _, field := range strings.Fields(simulcastAttr) {
+		if field == "send" || field == "recv" {
+			continue
+		}
+		for _, ridState := range strings.Split(field, ";") {
+			ridState = strings.Split(ridState, ",")[0]
+			paused := strings.HasPrefix(ridState, "~")
+			if r, ok := ridsByID[strings.TrimPrefix(ridState, "~")]; ok {
+				r.paused = paused
+				orderedRids = append(orderedRids, r)
+				delete(ridsByID, r.id)
+			}
+		}
+	}
+	return orderedRids
+}
+
+// ridRestrictionsForAnswer returns the restrictions of a rid to answer with.
+// Payload types the answer doesn't contain are removed, and ok is false if
+// none of the payload types the rid is restricted to remain.
+// https://datatracker.ietf.org/doc/html/rfc8851#section-5
+func ridRestrictionsForAnswer(rid *simulcastRid, codecs []RTPCodecParameters) (restrictions string, ok bool) {
+	fields := strings.Fields(rid.attrValue)
+	if len(fields) < 3 {
+		return "", true
+	}
+
+	answerRestrictions := []string{}
+	for _, restriction := range strings.Split(strings.Join(fields[2:], " "), ";") {
+		if !strings.HasPrefix(restriction, "pt=") {
+			answerRestrictions = append(answerRestrictions, restriction)
+			continue
+		}
+
+		payloadTypes := []string{}
+		for _, payloadType := range strings.Split(strings.TrimPrefix(restriction, "pt="), ",") {
+			for _, codec := range codecs {
+				if strconv.Itoa(int(codec.PayloadType)) == payloadType {
+					payloadTypes = append(payloadTypes, payloadType)
+					break
 				}
 			}
 		}
+		if len(payloadTypes) == 0 {
+			return "", false
+		}
+		answerRestrictions = append(answerRestrictions, "pt="+strings.Join(payloadTypes, ","))
 	}
-	return rids
+
+	return strings.Join(answerRestrictions, ";"), true
 }
 
 func addCandidatesToMediaDescriptions(candidates []ICECandidate, m *sdp.MediaDescription, iceGatheringState ICEGatheringState) error {
@@ -305,7 +361,7 @@ func addCandidatesToMediaDescriptions(candidates []ICECandidate, m *sdp.MediaDes
 	return nil
 }
 
-func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dtlsFingerprints []DTLSFingerprint, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState) error {
+func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dtlsFingerprints []DTLSFingerprint, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState, sctpMaxMessageSize uint32) error {
 	media := (&sdp.MediaDescription{
 		MediaName: sdp.MediaName{
 			Media:   mediaSectionApplication,
@@ -325,6 +381,7 @@ func addDataMediaSection(d *sdp.SessionDescription, shouldAddCandidates bool, dt
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
 		WithPropertyAttribute(RTPTransceiverDirectionSendrecv.String()).
 		WithPropertyAttribute("sctp-port:5000").
+		WithValueAttribute(sdpAttributeMaxMessageSize, strconv.FormatUint(uint64(sctpMaxMessageSize), 10)).
 		WithICECredentials(iceParams.UsernameFragment, iceParams.Password)
 
 	for _, f := range dtlsFingerprints {
@@ -387,11 +444,19 @@ func addSenderSDP(
 			continue
 		}
 
+		streamIDs := sender.StreamIDs()
+		streamLabel := msidNoStream
+		if len(streamIDs) != 0 {
+			streamLabel = streamIDs[0]
+		}
+
 		sendParameters := sender.GetParameters()
 		for _, encoding := range sendParameters.Encodings {
-			media = media.WithMediaSource(uint32(encoding.SSRC), track.StreamID() /* cname */, track.StreamID() /* streamLabel */, track.ID())
-			if !isPlanB {
-				media = media.WithPropertyAttribute("msid:" + track.StreamID() + " " + track.ID())
+			media = media.WithMediaSource(uint32(encoding.SSRC), track.StreamID() /* cname */, streamLabel, track.ID())
+		}
+		if !isPlanB {
+			for _, msid := range msidValues(streamIDs, track.ID()) {
+				media = media.WithPropertyAttribute(sdp.AttrKeyMsid + ":" + msid)
 			}
 		}
 
@@ -412,6 +477,26 @@ func addSenderSDP(
 	}
 }
 
+// rejectedMediaDescription returns a media section with port 0 which rejects
+// the corresponding offered media section
+func rejectedMediaDescription(kind RTPCodecType) *sdp.MediaDescription {
+	return &sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   kind.String(),
+			Port:    sdp.RangedPort{Value: 0},
+			Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
+			Formats: []string{"0"},
+		},
+		ConnectionInformation: &sdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address: &sdp.Address{
+				Address: "0.0.0.0",
+			},
+		},
+	}
+}
+
 func addTransceiverSDP(
 	d *sdp.SessionDescription,
 	isPlanB bool,
@@ -442,7 +527,7 @@ func addTransceiverSDP(
 	for _, codec := range codecs {
 		name := strings.TrimPrefix(codec.MimeType, "audio/")
 		name = strings.TrimPrefix(name, "video/")
-		media.WithCodec(uint8(codec.PayloadType), name, codec.ClockRate, codec.Channels, codec.SDPFmtpLine)
+		media.WithCodec(uint8(codec.PayloadType), name, codec.ClockRate, codec.Channels, mediaEngine.localSDPFmtpLine(codec))
 
 		for _, feedback := range codec.RTPCodecCapability.RTCPFeedback {
 			media.WithValueAttribute("rtcp-fb", fmt.Sprintf("%d %s %s", codec.PayloadType, feedback.Type, feedback.Parameter))
@@ -459,21 +544,7 @@ func addTransceiverSDP(
 		// parse the SDP with an error like:
 		// SIPCC Failed to parse SDP: SDP Parse Error on line 50:  c= connection line not specified for every media level, validation failed.
 		// In addition this makes our SDP compliant with RFC 4566 Section 5.7: https://datatracker.ietf.org/doc/html/rfc4566#section-5.7
-		d.WithMedia(&sdp.MediaDescription{
-			MediaName: sdp.MediaName{
-				Media:   t.kind.String(),
-				Port:    sdp.RangedPort{Value: 0},
-				Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
-				Formats: []string{"0"},
-			},
-			ConnectionInformation: &sdp.ConnectionInformation{
-				NetworkType: "IN",
-				AddressType: "IP4",
-				Address: &sdp.Address{
-					Address: "0.0.0.0",
-				},
-			},
-		})
+		d.WithMedia(rejectedMediaDescription(t.kind))
 		return false, nil
 	}
 
@@ -491,23 +562,38 @@ func addTransceiverSDP(
 		if err != nil {
 			return false, err
 		}
-		media.WithExtMap(sdp.ExtMap{Value: rtpExtension.ID, URI: extURL})
+		media.WithExtMap(sdp.ExtMap{Value: rtpExtension.ID, URI: extURL, Direction: mediaEngine.headerExtensionDirection(rtpExtension.URI, t.kind)})
 	}
 
-	if len(mediaSection.ridMap) > 0 {
-		recvRids := make([]string, 0, len(mediaSection.ridMap))
+	recvRids := make([]string, 0, len(mediaSection.rids))
+	for _, rid := range mediaSection.rids {
+		restrictions, ok := ridRestrictionsForAnswer(rid, codecs)
+		if !ok {
+			continue
+		}
+		restrictions = restrictRIDRestrictions(restrictions, t.getReceiveRIDRestrictions(rid.id))
+
+		attrValue := rid.id + " recv"
+		if restrictions != "" {
+			attrValue += " " + restrictions
+		}
+		media.WithValueAttribute(sdpAttributeRid, attrValue)
 
-		for rid := range mediaSection.ridMap {
-			media.WithValueAttribute(sdpAttributeRid, rid+" recv")
-			if mediaSection.ridMap[rid].paused {
-				rid = "~" + rid
-			}
-			recvRids = append(recvRids, rid)
+		if rid.paused {
+			recvRids = append(recvRids, "~"+rid.id)
+		} else {
+			recvRids = append(recvRids, rid.id)
 		}
+	}
+	if len(recvRids) > 0 {
 		// Simulcast
 		media.WithValueAttribute(sdpAttributeSimulcast, "recv "+strings.Join(recvRids, ";"))
 	}
 
+	if t.kind == RTPCodecTypeAudio {
+		addPacketTimeAttributes(media, mediaEngine)
+	}
+
 	addSenderSDP(mediaSection, isPlanB, media)
 
 	media = media.WithPropertyAttribute(t.Direction().String())
@@ -528,6 +614,7 @@ func addTransceiverSDP(
 }
 
 type simulcastRid struct {
+	id        string
 	attrValue string
 	paused    bool
 }
@@ -536,7 +623,16 @@ type mediaSection struct {
 	id           string
 	transceivers []*RTPTransceiver
 	data         bool
-	ridMap       map[string]*simulcastRid
+	rids         []*simulcastRid
+	rejected     bool
+
+	// bundleOnly is set for offered media sections which can only be used
+	// if the remote accepts to BUNDLE them
+	bundleOnly bool
+
+	// unbundled is set for answered media sections which are accepted even
+	// though the remote isn't BUNDLE-aware
+	unbundled bool
 }
 
 func bundleMatchFromRemote(matchBundleGroup *string) func(mid string) bool {
@@ -571,6 +667,7 @@ func populateSDP(
 	mediaSections []mediaSection,
 	iceGatheringState ICEGatheringState,
 	matchBundleGroup *string,
+	sctpMaxMessageSize uint32,
 ) (*sdp.SessionDescription, error) {
 	var err error
 	mediaDtlsFingerprints := []DTLSFingerprint{}
@@ -588,7 +685,8 @@ func populateSDP(
 		bundleCount++
 	}
 
-	for i, m := range mediaSections {
+	candidatesAdded := false
+	for _, m := range mediaSections {
 		if m.data && len(m.transceivers) != 0 {
 			return nil, errSDPMediaSectionMediaDataChanInvalid
 		} else if !isPlanB && len(m.transceivers) > 1 {
@@ -596,12 +694,19 @@ func populateSDP(
 		}
 
 		shouldAddID := true
-		shouldAddCandidates := i == 0
-		if m.data {
-			if err = addDataMediaSection(d, shouldAddCandidates, mediaDtlsFingerprints, m.id, iceParams, candidates, connectionRole, iceGatheringState); err != nil {
+		shouldAddCandidates := !candidatesAdded
+		switch {
+		case m.rejected:
+			if len(m.transceivers) < 1 {
+				return nil, errSDPZeroTransceivers
+			}
+			d.WithMedia(rejectedMediaDescription(m.transceivers[0].kind).WithValueAttribute(sdp.AttrKeyMID, m.id))
+			continue
+		case m.data:
+			if err = addDataMediaSection(d, shouldAddCandidates, mediaDtlsFingerprints, m.id, iceParams, candidates, connectionRole, iceGatheringState, sctpMaxMessageSize); err != nil {
 				return nil, err
 			}
-		} else {
+		default:
 			shouldAddID, err = addTransceiverSDP(d, isPlanB, shouldAddCandidates, mediaDtlsFingerprints, mediaEngine, m.id, iceParams, candidates, connectionRole, iceGatheringState, m)
 			if err != nil {
 				return nil, err
@@ -609,9 +714,18 @@ func populateSDP(
 		}
 
 		if shouldAddID {
-			if bundleMatch(m.id) {
+			candidatesAdded = true
+			switch {
+			case m.unbundled:
+				// The remote isn't BUNDLE-aware, there is no group to join
+			case bundleMatch(m.id):
 				appendBundle(m.id)
-			} else {
+				if m.bundleOnly {
+					// JSEP 5.2.1, bundle-only media sections are offered with port 0
+					d.MediaDescriptions[len(d.MediaDescriptions)-1].MediaName.Port = sdp.RangedPort{Value: 0}
+					d.MediaDescriptions[len(d.MediaDescriptions)-1].WithPropertyAttribute(sdpAttributeBundleOnly)
+				}
+			default:
 				d.MediaDescriptions[len(d.MediaDescriptions)-1].MediaName.Port = sdp.RangedPort{Value: 0}
 			}
 		}
@@ -638,6 +752,51 @@ func populateSDP(
 	return d, nil
 }
 
+// markBundleOnlyMediaSections marks the media sections of an initial offer which
+// are offered as bundle-only for the given BundlePolicy. Only max-bundle uses
+// bundle-only media sections, balanced and max-compat offer every media section
+// with the shared transport so a remote which isn't BUNDLE-aware can accept them.
+func markBundleOnlyMediaSections(bundlePolicy BundlePolicy, mediaSections []mediaSection) {
+	if bundlePolicy != BundlePolicyMaxBundle {
+		return
+	}
+
+	for i := range mediaSections {
+		mediaSections[i].bundleOnly = i != 0
+	}
+}
+
+// acceptUnbundledMediaSections decides which media sections of an offer from
+// a remote that isn't BUNDLE-aware are accepted. Each of them has its own
+// transport, but the PeerConnection has a single ICE and DTLS transport, so
+// only the first media section the remote didn't disable with port 0 is
+// accepted, whatever the BundlePolicy. The others are answered with port 0.
+func acceptUnbundledMediaSections(mediaSections []mediaSection, remote *sdp.SessionDescription) {
+	disabled := map[string]bool{}
+	for _, media := range remote.MediaDescriptions {
+		if media.MediaName.Port.Value == 0 {
+			disabled[getMidValue(media)] = true
+		}
+	}
+
+	for i := range mediaSections {
+		m := &mediaSections[i]
+		if m.rejected || disabled[m.id] {
+			continue
+		}
+
+		m.unbundled = true
+		return
+	}
+}
+
+// isBundleOnly returns true if a media section with port 0 has the bundle-only
+// attribute, which means it is offered and not rejected.
+func isBundleOnly(media *sdp.MediaDescription) bool {
+	_, bundleOnly := media.Attribute(sdpAttributeBundleOnly)
+	return bundleOnly
+}
+
 func getMidValue(media *sdp.MediaDescription) string {
 	for _, attr := range media.Attributes {
 		if attr.Key == "mid" {
@@ -694,30 +853,34 @@ func getPeerDirection(media *sdp.MediaDescription) RTPTransceiverDirection {
 
 func extractFingerprint(desc *sdp.SessionDescription) (string, string, error) {
 	fingerprints := []string{}
+	// The m-line index of each fingerprint, -1 at the session level
+	mediaIndexes := []int{}
 
 	if fingerprint, haveFingerprint := desc.Attribute("fingerprint"); haveFingerprint {
 		fingerprints = append(fingerprints, fingerprint)
+		mediaIndexes = append(mediaIndexes, -1)
 	}
 
-	for _, m := range desc.MediaDescriptions {
+	for i, m := range desc.MediaDescriptions {
 		if fingerprint, haveFingerprint := m.Attribute("fingerprint"); haveFingerprint {
 			fingerprints = append(fingerprints, fingerprint)
+			mediaIndexes = append(mediaIndexes, i)
 		}
 	}
 
 	if len(fingerprints) < 1 {
-		return "", "", ErrSessionDescriptionNoFingerprint
+		return "", "", &SDPError{MediaIndex: -1, Attribute: "fingerprint", Err: ErrSessionDescriptionNoFingerprint}
 	}
 
-	for _, m := range fingerprints {
+	for i, m := range fingerprints {
 		if m != fingerprints[0] {
-			return "", "", ErrSessionDescriptionConflictingFingerprints
+			return "", "", &SDPError{MediaIndex: mediaIndexes[i], Attribute: "fingerprint", Err: ErrSessionDescriptionConflictingFingerprints}
 		}
 	}
 
 	parts := strings.Split(fingerprints[0], " ")
 	if len(parts) != 2 {
-		return "", "", ErrSessionDescriptionInvalidFingerprint
+		return "", "", &SDPError{MediaIndex: mediaIndexes[0], Attribute: "fingerprint", Err: ErrSessionDescriptionInvalidFingerprint}
 	}
 	return parts[1], parts[0], nil
 }
@@ -726,20 +889,27 @@ func extractICEDetails(desc *sdp.SessionDescription, log logging.LeveledLogger)
 	candidates := []ICECandidate{}
 	remotePwds := []string{}
 	remoteUfrags := []string{}
+	// The m-line index of each ufrag and pwd, -1 at the session level
+	pwdMediaIndexes := []int{}
+	ufragMediaIndexes := []int{}
 
 	if ufrag, haveUfrag := desc.Attribute("ice-ufrag"); haveUfrag {
 		remoteUfrags = append(remoteUfrags, ufrag)
+		ufragMediaIndexes = append(ufragMediaIndexes, -1)
 	}
 	if pwd, havePwd := desc.Attribute("ice-pwd"); havePwd {
 		remotePwds = append(remotePwds, pwd)
+		pwdMediaIndexes = append(pwdMediaIndexes, -1)
 	}
 
-	for _, m := range desc.MediaDescriptions {
+	for i, m := range desc.MediaDescriptions {
 		if ufrag, haveUfrag := m.Attribute("ice-ufrag"); haveUfrag {
 			remoteUfrags = append(remoteUfrags, ufrag)
+			ufragMediaIndexes = append(ufragMediaIndexes, i)
 		}
 		if pwd, havePwd := m.Attribute("ice-pwd"); havePwd {
 			remotePwds = append(remotePwds, pwd)
+			pwdMediaIndexes = append(pwdMediaIndexes, i)
 		}
 
 		for _, a := range m.Attributes {
@@ -750,12 +920,12 @@ func extractICEDetails(desc *sdp.SessionDescription, log logging.LeveledLogger)
 						log.Warnf("Discarding remote candidate: %s", err)
 						continue
 					}
-					return "", "", nil, err
+					return "", "", nil, &SDPError{MediaIndex: i, Attribute: a.Key, Err: err}
 				}
 
 				candidate, err := newICECandidateFromICE(c)
 				if err != nil {
-					return "", "", nil, err
+					return "", "", nil, &SDPError{MediaIndex: i, Attribute: a.Key, Err: err}
 				}
 
 				candidates = append(candidates, candidate)
@@ -764,20 +934,20 @@ func extractICEDetails(desc *sdp.SessionDescription, log logging.LeveledLogger)
 	}
 
 	if len(remoteUfrags) == 0 {
-		return "", "", nil, ErrSessionDescriptionMissingIceUfrag
+		return "", "", nil, &SDPError{MediaIndex: -1, Attribute: "ice-ufrag", Err: ErrSessionDescriptionMissingIceUfrag}
 	} else if len(remotePwds) == 0 {
-		return "", "", nil, ErrSessionDescriptionMissingIcePwd
+		return "", "", nil, &SDPError{MediaIndex: -1, Attribute: "ice-pwd", Err: ErrSessionDescriptionMissingIcePwd}
 	}
 
-	for _, m := range remoteUfrags {
+	for i, m := range remoteUfrags {
 		if m != remoteUfrags[0] {
-			return "", "", nil, ErrSessionDescriptionConflictingIceUfrag
+			return "", "", nil, &SDPError{MediaIndex: ufragMediaIndexes[i], Attribute: "ice-ufrag", Err: ErrSessionDescriptionConflictingIceUfrag}
 		}
 	}
 
-	for _, m := range remotePwds {
+	for i, m := range remotePwds {
 		if m != remotePwds[0] {
-			return "", "", nil, ErrSessionDescriptionConflictingIcePwd
+			return "", "", nil, &SDPError{MediaIndex: pwdMediaIndexes[i], Attribute: "ice-pwd", Err: ErrSessionDescriptionConflictingIcePwd}
 		}
 	}
 
@@ -803,6 +973,28 @@ func getByMid(searchMid string, desc *SessionDescription) *sdp.MediaDescription
 	return nil
 }
 
+// getMaxMessageSize returns the max-message-size of the application media
+// section of desc, 0 meaning that any size is accepted
+func getMaxMessageSize(desc *sdp.SessionDescription) uint32 {
+	for _, m := range desc.MediaDescriptions {
+		if m.MediaName.Media != mediaSectionApplication {
+			continue
+		}
+
+		for _, a := range m.Attributes {
+			if a.Key != sdpAttributeMaxMessageSize {
+				continue
+			}
+
+			if size, err := strconv.ParseUint(a.Value, 10, 32); err == nil {
+				return uint32(size)
+			}
+		}
+	}
+
+	return sctpMaxMessageSizeUnsetValue
+}
+
 // haveDataChannel return MediaDescription with MediaName equal application
 func haveDataChannel(desc *SessionDescription) *sdp.MediaDescription {
 	for _, d := range desc.parsed.MediaDescriptions {
@@ -875,6 +1067,27 @@ func rtpExtensionsFromMediaDescription(m *sdp.MediaDescription) (map[string]int,
 	return out, nil
 }
 
+// rtpExtensionDirectionsFromMediaDescription returns the directions of the
+// a=extmap of m which have one, like 2/sendonly, RFC 8285 Section 7
+func rtpExtensionDirectionsFromMediaDescription(m *sdp.MediaDescription) (map[string]sdp.Direction, error) {
+	out := map[string]sdp.Direction{}
+
+	for _, a := range m.Attributes {
+		if a.Key == sdp.AttrKeyExtMap {
+			e := sdp.ExtMap{}
+			if err := e.Unmarshal(a.String()); err != nil {
+				return nil, err
+			}
+
+			if e.Direction != 0 {
+				out[e.URI.String()] = e.Direction
+			}
+		}
+	}
+
+	return out, nil
+}
+
 // updateSDPOrigin saves sdp.Origin in PeerConnection when creating 1st local SDP;
 // for subsequent calling, it updates Origin for SessionDescription from saved one
 // and increments session version by one.
@@ -903,6 +1116,9 @@ func isIceLiteSet(desc *sdp.SessionDescription) bool {
 	return false
 }
 
+// isExtMapAllowMixedSet returns true if the description allows mixing one-byte
+// and two-byte header extensions, at the session level or in any media section
+// https://datatracker.ietf.org/doc/html/rfc8285#section-6
 func isExtMapAllowMixedSet(desc *sdp.SessionDescription) bool {
 	for _, a := range desc.Attributes {
 		if strings.TrimSpace(a.Key) == sdp.AttrKeyExtMapAllowMixed {
@@ -910,5 +1126,43 @@ func isExtMapAllowMixedSet(desc *sdp.SessionDescription) bool {
 		}
 	}
 
+	for _, m := range desc.MediaDescriptions {
+		for _, a := range m.Attributes {
+			if strings.TrimSpace(a.Key) == sdp.AttrKeyExtMapAllowMixed {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isTrickleSet returns true if the description lists the trickle ICE option,
+// at the session level or in any media section
+// https://datatracker.ietf.org/doc/html/rfc8840#section-4.1.3
+func isTrickleSet(desc *sdp.SessionDescription) bool {
+	hasTrickle := func(attributes []sdp.Attribute) bool {
+		for _, a := range attributes {
+			if strings.TrimSpace(a.Key) != "ice-options" {
+				continue
+			}
+			for _, option := range strings.Fields(a.Value) {
+				if option == "trickle" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if hasTrickle(desc.Attributes) {
+		return true
+	}
+	for _, m := range desc.MediaDescriptions {
+		if hasTrickle(m.Attributes) {
+			return true
+		}
+	}
+
 	return false
 }
@@ -10,10 +10,13 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,7 +49,7 @@ func TestExtractFingerprint(t *testing.T) {
 		s := &sdp.SessionDescription{}
 
 		_, _, err := extractFingerprint(s)
-		assert.Equal(t, ErrSessionDescriptionNoFingerprint, err)
+		assert.Equal(t, &SDPError{MediaIndex: -1, Attribute: "fingerprint", Err: ErrSessionDescriptionNoFingerprint}, err)
 	})
 
 	t.Run("Invalid Fingerprint", func(t *testing.T) {
@@ -55,7 +58,7 @@ func TestExtractFingerprint(t *testing.T) {
 		}
 
 		_, _, err := extractFingerprint(s)
-		assert.Equal(t, ErrSessionDescriptionInvalidFingerprint, err)
+		assert.Equal(t, &SDPError{MediaIndex: -1, Attribute: "fingerprint", Err: ErrSessionDescriptionInvalidFingerprint}, err)
 	})
 
 	t.Run("Conflicting Fingerprint", func(t *testing.T) {
@@ -67,7 +70,7 @@ func TestExtractFingerprint(t *testing.T) {
 		}
 
 		_, _, err := extractFingerprint(s)
-		assert.Equal(t, ErrSessionDescriptionConflictingFingerprints, err)
+		assert.Equal(t, &SDPError{MediaIndex: 0, Attribute: "fingerprint", Err: ErrSessionDescriptionConflictingFingerprints}, err)
 	})
 }
 
@@ -83,7 +86,7 @@ func TestExtractICEDetails(t *testing.T) {
 		}
 
 		_, _, _, err := extractICEDetails(s, nil)
-		assert.Equal(t, err, ErrSessionDescriptionMissingIcePwd)
+		assert.Equal(t, &SDPError{MediaIndex: -1, Attribute: "ice-pwd", Err: ErrSessionDescriptionMissingIcePwd}, err)
 	})
 
 	t.Run("Missing ice-ufrag", func(t *testing.T) {
@@ -94,7 +97,7 @@ func TestExtractICEDetails(t *testing.T) {
 		}
 
 		_, _, _, err := extractICEDetails(s, nil)
-		assert.Equal(t, err, ErrSessionDescriptionMissingIceUfrag)
+		assert.Equal(t, &SDPError{MediaIndex: -1, Attribute: "ice-ufrag", Err: ErrSessionDescriptionMissingIceUfrag}, err)
 	})
 
 	t.Run("ice details at session level", func(t *testing.T) {
@@ -139,7 +142,7 @@ func TestExtractICEDetails(t *testing.T) {
 		}
 
 		_, _, _, err := extractICEDetails(s, nil)
-		assert.Equal(t, err, ErrSessionDescriptionConflictingIceUfrag)
+		assert.Equal(t, &SDPError{MediaIndex: 0, Attribute: "ice-ufrag", Err: ErrSessionDescriptionConflictingIceUfrag}, err)
 	})
 
 	t.Run("Conflict pwd", func(t *testing.T) {
@@ -151,7 +154,7 @@ func TestExtractICEDetails(t *testing.T) {
 		}
 
 		_, _, _, err := extractICEDetails(s, nil)
-		assert.Equal(t, err, ErrSessionDescriptionConflictingIcePwd)
+		assert.Equal(t, &SDPError{MediaIndex: 0, Attribute: "ice-pwd", Err: ErrSessionDescriptionConflictingIcePwd}, err)
 	})
 }
 
@@ -307,6 +310,31 @@ func TestHaveApplicationMediaSection(t *testing.T) {
 	})
 }
 
+func TestGetMaxMessageSize(t *testing.T) {
+	application := func(attributes ...sdp.Attribute) *sdp.SessionDescription {
+		return &sdp.SessionDescription{
+			MediaDescriptions: []*sdp.MediaDescription{
+				{
+					MediaName:  sdp.MediaName{Media: mediaSectionApplication},
+					Attributes: attributes,
+				},
+			},
+		}
+	}
+
+	for i, testCase := range []struct {
+		desc     *sdp.SessionDescription
+		expected uint32
+	}{
+		{application(), sctpMaxMessageSizeUnsetValue},
+		{application(sdp.Attribute{Key: sdpAttributeMaxMessageSize, Value: "262144"}), 262144},
+		{application(sdp.Attribute{Key: sdpAttributeMaxMessageSize, Value: "0"}), 0},
+		{application(sdp.Attribute{Key: sdpAttributeMaxMessageSize, Value: "invalid"}), sctpMaxMessageSizeUnsetValue},
+	} {
+		assert.Equal(t, testCase.expected, getMaxMessageSize(testCase.desc), "testCase: %d %v", i, testCase)
+	}
+}
+
 func TestMediaDescriptionFingerprints(t *testing.T) {
 	engine := &MediaEngine{}
 	assert.NoError(t, engine.RegisterDefaultCodecs())
@@ -357,7 +385,7 @@ func TestMediaDescriptionFingerprints(t *testing.T) {
 			s, err = populateSDP(s, false,
 				dtlsFingerprints,
 				SDPMediaDescriptionFingerprints,
-				false, true, engine, sdp.ConnectionRoleActive, []ICECandidate{}, ICEParameters{}, media, ICEGatheringStateNew, nil)
+				false, true, engine, sdp.ConnectionRoleActive, []ICECandidate{}, ICEParameters{}, media, ICEGatheringStateNew, nil, 0)
 			assert.NoError(t, err)
 
 			sdparray, err := s.Marshal()
@@ -381,20 +409,22 @@ func TestPopulateSDP(t *testing.T) {
 
 		tr := &RTPTransceiver{kind: RTPCodecTypeVideo, api: api, codecs: me.videoCodecs}
 		tr.setDirection(RTPTransceiverDirectionRecvonly)
-		ridMap := map[string]*simulcastRid{
-			"ridkey": {
-				attrValue: "some",
+		rids := []*simulcastRid{
+			{
+				id:        "ridkey",
+				attrValue: "ridkey send",
 			},
-			"ridPaused": {
-				attrValue: "some2",
+			{
+				id:        "ridPaused",
+				attrValue: "ridPaused send",
 				paused:    true,
 			},
 		}
-		mediaSections := []mediaSection{{id: "video", transceivers: []*RTPTransceiver{tr}, ridMap: ridMap}}
+		mediaSections := []mediaSection{{id: "video", transceivers: []*RTPTransceiver{tr}, rids: rids}}
 
 		d := &sdp.SessionDescription{}
 
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil, 0)
 		assert.Nil(t, err)
 
 		// Test contains rid map keys
@@ -403,12 +433,13 @@ func TestPopulateSDP(t *testing.T) {
 			if desc.MediaName.Media != "video" {
 				continue
 			}
-			ridInSDP := getRids(desc)
-			if ridKey, ok := ridInSDP["ridkey"]; ok && !ridKey.paused {
-				ridFound++
-			}
-			if ridPaused, ok := ridInSDP["ridPaused"]; ok && ridPaused.paused {
-				ridFound++
+			for _, rid := range getRids(desc) {
+				if rid.id == "ridkey" && !rid.paused {
+					ridFound++
+				}
+				if rid.id == "ridPaused" && rid.paused {
+					ridFound++
+				}
 			}
 		}
 		assert.Equal(t, 2, ridFound, "All rid keys should be present")
@@ -436,7 +467,7 @@ func TestPopulateSDP(t *testing.T) {
 
 		d := &sdp.SessionDescription{}
 
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil, 0)
 		assert.Nil(t, err)
 
 		// Test codecs
@@ -461,7 +492,7 @@ func TestPopulateSDP(t *testing.T) {
 		se := SettingEngine{}
 		se.SetLite(true)
 
-		offerSdp, err := populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, &MediaEngine{}, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, []mediaSection{}, ICEGatheringStateComplete, nil)
+		offerSdp, err := populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, &MediaEngine{}, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, []mediaSection{}, ICEGatheringStateComplete, nil, 0)
 		assert.Nil(t, err)
 
 		var found bool
@@ -494,7 +525,7 @@ func TestPopulateSDP(t *testing.T) {
 
 		d := &sdp.SessionDescription{}
 
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil, 0)
 		assert.NoError(t, err)
 
 		// Test codecs
@@ -512,7 +543,7 @@ func TestPopulateSDP(t *testing.T) {
 	})
 	t.Run("allow mixed extmap", func(t *testing.T) {
 		se := SettingEngine{}
-		offerSdp, err := populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, &MediaEngine{}, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, []mediaSection{}, ICEGatheringStateComplete, nil)
+		offerSdp, err := populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, &MediaEngine{}, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, []mediaSection{}, ICEGatheringStateComplete, nil, 0)
 		assert.Nil(t, err)
 
 		var found bool
@@ -527,7 +558,7 @@ func TestPopulateSDP(t *testing.T) {
 		}
 		assert.Equal(t, true, found, "AllowMixedExtMap key should be present")
 
-		offerSdp, err = populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, false, &MediaEngine{}, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, []mediaSection{}, ICEGatheringStateComplete, nil)
+		offerSdp, err = populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, false, &MediaEngine{}, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, []mediaSection{}, ICEGatheringStateComplete, nil, 0)
 		assert.Nil(t, err)
 
 		found = false
@@ -555,7 +586,7 @@ func TestPopulateSDP(t *testing.T) {
 
 		d := &sdp.SessionDescription{}
 
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil, 0)
 		assert.Nil(t, err)
 
 		bundle, ok := offerSdp.Attribute(sdp.AttrKeyGroup)
@@ -580,7 +611,7 @@ func TestPopulateSDP(t *testing.T) {
 		d := &sdp.SessionDescription{}
 
 		matchedBundle := "audio"
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, &matchedBundle)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, &matchedBundle, 0)
 		assert.Nil(t, err)
 
 		bundle, ok := offerSdp.Attribute(sdp.AttrKeyGroup)
@@ -607,12 +638,90 @@ func TestPopulateSDP(t *testing.T) {
 		d := &sdp.SessionDescription{}
 
 		matchedBundle := ""
-		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, &matchedBundle)
+		offerSdp, err := populateSDP(d, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, &matchedBundle, 0)
 		assert.Nil(t, err)
 
 		_, ok := offerSdp.Attribute(sdp.AttrKeyGroup)
 		assert.False(t, ok)
 	})
+	t.Run("bundle-only", func(t *testing.T) {
+		se := SettingEngine{}
+
+		me := &MediaEngine{}
+		assert.NoError(t, me.RegisterDefaultCodecs())
+		api := NewAPI(WithMediaEngine(me))
+
+		tra := &RTPTransceiver{kind: RTPCodecTypeVideo, api: api, codecs: me.videoCodecs}
+		tra.setDirection(RTPTransceiverDirectionRecvonly)
+		trv := &RTPTransceiver{kind: RTPCodecTypeAudio, api: api, codecs: me.audioCodecs}
+		trv.setDirection(RTPTransceiverDirectionRecvonly)
+		mediaSections := []mediaSection{
+			{id: "video", transceivers: []*RTPTransceiver{tra}},
+			{id: "audio", transceivers: []*RTPTransceiver{trv}},
+		}
+		markBundleOnlyMediaSections(BundlePolicyMaxBundle, mediaSections)
+
+		offerSdp, err := populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, mediaSections, ICEGatheringStateComplete, nil, 0)
+		assert.NoError(t, err)
+
+		bundle, ok := offerSdp.Attribute(sdp.AttrKeyGroup)
+		assert.True(t, ok)
+		assert.Equal(t, "BUNDLE video audio", bundle)
+
+		assert.NotEqual(t, 0, offerSdp.MediaDescriptions[0].MediaName.Port.Value)
+		assert.False(t, isBundleOnly(offerSdp.MediaDescriptions[0]))
+		assert.Equal(t, 0, offerSdp.MediaDescriptions[1].MediaName.Port.Value)
+		assert.True(t, isBundleOnly(offerSdp.MediaDescriptions[1]))
+	})
+	t.Run("remote not bundle-aware", func(t *testing.T) {
+		se := SettingEngine{}
+
+		me := &MediaEngine{}
+		assert.NoError(t, me.RegisterDefaultCodecs())
+		api := NewAPI(WithMediaEngine(me))
+
+		mediaSections := []mediaSection{}
+		for _, kind := range []RTPCodecType{RTPCodecTypeAudio, RTPCodecTypeVideo, RTPCodecTypeVideo} {
+			tr := &RTPTransceiver{kind: kind, api: api, codecs: me.getCodecsByKind(kind)}
+			tr.setDirection(RTPTransceiverDirectionRecvonly)
+			mediaSections = append(mediaSections, mediaSection{id: strconv.Itoa(len(mediaSections)), transceivers: []*RTPTransceiver{tr}})
+		}
+
+		remoteMedia := func(mid string, port int) *sdp.MediaDescription {
+			return &sdp.MediaDescription{
+				MediaName:  sdp.MediaName{Port: sdp.RangedPort{Value: port}},
+				Attributes: []sdp.Attribute{{Key: sdp.AttrKeyMID, Value: mid}},
+			}
+		}
+
+		for i, test := range []struct {
+			remotePorts   []int
+			expectedPorts []int
+		}{
+			{[]int{9, 9, 9}, []int{9, 0, 0}},
+			// A media section disabled by the remote doesn't get the transport
+			{[]int{0, 9, 9}, []int{0, 9, 0}},
+		} {
+			remote := &sdp.SessionDescription{}
+			for j, port := range test.remotePorts {
+				remote.MediaDescriptions = append(remote.MediaDescriptions, remoteMedia(strconv.Itoa(j), port))
+			}
+
+			sections := append([]mediaSection{}, mediaSections...)
+			acceptUnbundledMediaSections(sections, remote)
+
+			matchedBundle := ""
+			answerSdp, err := populateSDP(&sdp.SessionDescription{}, false, []DTLSFingerprint{}, se.sdpMediaLevelFingerprints, se.candidates.ICELite, true, me, connectionRoleFromDtlsRole(defaultDtlsRoleOffer), []ICECandidate{}, ICEParameters{}, sections, ICEGatheringStateComplete, &matchedBundle, 0)
+			assert.NoError(t, err)
+
+			_, ok := answerSdp.Attribute(sdp.AttrKeyGroup)
+			assert.False(t, ok)
+
+			for j, port := range test.expectedPorts {
+				assert.Equal(t, port, answerSdp.MediaDescriptions[j].MediaName.Port.Value, "testCase: %d media section %d", i, j)
+			}
+		}
+	})
 }
 
 func TestGetRIDs(t *testing.T) {
@@ -631,8 +740,40 @@ func TestGetRIDs(t *testing.T) {
 	rids := getRids(m[0])
 
 	assert.NotEmpty(t, rids, "Rid mapping should be present")
-	if _, ok := rids["f"]; !ok {
-		assert.Fail(t, "rid values should contain 'f'")
+	assert.Equal(t, "f", rids[0].id, "rid values should contain 'f'")
+
+	// The simulcast attribute orders the rids, and leaves out the ones it doesn't list
+	m[0].Attributes = append(m[0].Attributes,
+		sdp.Attribute{Key: sdpAttributeRid, Value: "h send"},
+		sdp.Attribute{Key: sdpAttributeRid, Value: "q send"},
+		sdp.Attribute{Key: sdpAttributeRid, Value: "unused send"},
+		sdp.Attribute{Key: sdpAttributeSimulcast, Value: "send q;~h,f;f"},
+	)
+
+	rids = getRids(m[0])
+	assert.Equal(t, []*simulcastRid{
+		{id: "q", attrValue: "q send"},
+		{id: "h", attrValue: "h send", paused: true},
+		{id: "f", attrValue: "f send pt=97;max-width=1280;max-height=720"},
+	}, rids)
+}
+
+func TestRidRestrictionsForAnswer(t *testing.T) {
+	codecs := []RTPCodecParameters{{PayloadType: 96}, {PayloadType: 98}}
+
+	for _, testCase := range []struct {
+		attrValue    string
+		restrictions string
+		ok           bool
+	}{
+		{"f send", "", true},
+		{"f send max-width=1280;max-height=720", "max-width=1280;max-height=720", true},
+		{"f send pt=96,97,98;max-fps=30", "pt=96,98;max-fps=30", true},
+		{"f send pt=97", "", false},
+	} {
+		restrictions, ok := ridRestrictionsForAnswer(&simulcastRid{attrValue: testCase.attrValue}, codecs)
+		assert.Equal(t, testCase.restrictions, restrictions, testCase.attrValue)
+		assert.Equal(t, testCase.ok, ok, testCase.attrValue)
 	}
 }
 
@@ -697,3 +838,49 @@ func TestRtpExtensionsFromMediaDescription(t *testing.T) {
 	assert.Equal(t, extensions[sdp.ABSSendTimeURI], 1)
 	assert.Equal(t, extensions[sdp.SDESMidURI], 3)
 }
+
+// Assert that the bundle-only media sections of a max-bundle offer, which have
+// port 0, are accepted and connected over the shared transport
+func TestPeerConnection_MaxBundleBundleOnlyOffer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, err := NewPeerConnection(Configuration{BundlePolicy: BundlePolicyMaxBundle})
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	for _, kind := range []RTPCodecType{RTPCodecTypeAudio, RTPCodecTypeVideo} {
+		_, err = pcOffer.AddTransceiverFromKind(kind)
+		assert.NoError(t, err)
+	}
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	parsedOffer, err := offer.Unmarshal()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, parsedOffer.MediaDescriptions[1].MediaName.Port.Value)
+	assert.True(t, isBundleOnly(parsedOffer.MediaDescriptions[1]))
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	parsedAnswer, err := pcAnswer.LocalDescription().Unmarshal()
+	assert.NoError(t, err)
+	bundle, ok := parsedAnswer.Attribute(sdp.AttrKeyGroup)
+	assert.True(t, ok)
+	for i, media := range parsedAnswer.MediaDescriptions {
+		assert.NotEqual(t, 0, media.MediaName.Port.Value, "media section %d", i)
+		assert.Contains(t, strings.Fields(bundle), getMidValue(media), "media section %d", i)
+	}
+
+	for _, transceiver := range pcAnswer.GetTransceivers() {
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, transceiver.getCurrentDirection())
+	}
+
+	connected.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/pion/randutil"
+	"github.com/pion/sdp/v3"
+)
+
+// deterministicRandom is the source of randomness set with
+// SettingEngine.SetDeterministicSDP. It is shared by the PeerConnections of
+// an API, so values are generated in the order they are requested.
+type deterministicRandom struct {
+	mu     sync.Mutex
+	reader io.Reader
+}
+
+func (r *deterministicRandom) read(n int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.reader, buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+func (r *deterministicRandom) uint64() (uint64, error) {
+	buf, err := r.read(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+func (r *deterministicRandom) string(n int, runes string) (string, error) {
+	buf, err := r.read(n)
+	if err != nil {
+		return "", err
+	}
+
+	s := make([]byte, n)
+	for i, b := range buf {
+		s[i] = runes[int(b)%len(runes)]
+	}
+
+	return string(s), nil
+}
+
+// SetDeterministicSDP makes the generated descriptions depend only on
+// randomness and on the calls made to the PeerConnections, so they can be
+// compared to golden files. randomness replaces the random generation of the
+// session ID of the o= line, of the ICE credentials which aren't set with
+// SetICECredentials, and of the SSRCs, use a fixed seeded reader for
+// reproducible values. The session version of the o= line starts at 1
+// instead of the current time. Transceivers and attributes are always generated in a
+// stable order.
+//
+// The DTLS fingerprints depend on the certificates, which have to be set
+// with Configuration.Certificates, and the candidates on the network, so
+// golden descriptions are best created before gathering or with
+// SetICEStaticHostCandidates. Pass nil to restore random generation.
+func (e *SettingEngine) SetDeterministicSDP(randomness io.Reader) {
+	if randomness == nil {
+		e.deterministicRandom = nil
+		return
+	}
+
+	e.deterministicRandom = &deterministicRandom{reader: randomness}
+}
+
+// randomString returns a random string of n runes, generated by the source
+// set with SetDeterministicSDP if any
+func (e *SettingEngine) randomString(n int, runes string) (string, error) {
+	if e.deterministicRandom != nil {
+		return e.deterministicRandom.string(n, runes)
+	}
+
+	return randutil.GenerateCryptoRandomString(n, runes)
+}
+
+// generateSSRC returns a random SSRC, generated by the source set with
+// SetDeterministicSDP if any
+func (e *SettingEngine) generateSSRC() (SSRC, error) {
+	if e.deterministicRandom != nil {
+		ssrc, err := e.deterministicRandom.uint64()
+		return SSRC(uint32(ssrc)), err
+	}
+
+	return SSRC(randutil.NewMathRandomGenerator().Uint32()), nil
+}
+
+// newJSEPSessionDescription creates the base of a generated description, with
+// a session ID generated by the source set with
+// SettingEngine.SetDeterministicSDP if any
+func (pc *PeerConnection) newJSEPSessionDescription(useIdentity bool) (*sdp.SessionDescription, error) {
+	d, err := sdp.NewJSEPSessionDescription(useIdentity)
+	if err != nil {
+		return nil, err
+	}
+
+	if random := pc.api.settingEngine.deterministicRandom; random != nil {
+		sessionID, err := random.uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		// RFC 3264 requires the session ID to fit in 63 bits, and
+		// updateSDPOrigin uses 0 for unset
+		d.Origin.SessionID = sessionID & math.MaxInt64
+		if d.Origin.SessionID == 0 {
+			d.Origin.SessionID = 1
+		}
+
+		// The session version defaults to the current time
+		d.Origin.SessionVersion = 1
+	}
+
+	return d, nil
+}
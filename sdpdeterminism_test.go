@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	mathRand "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingEngine_SetDeterministicSDP(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	certificate, err := GenerateCertificate(sk)
+	assert.NoError(t, err)
+
+	createOffer := func() string {
+		s := SettingEngine{}
+		s.SetDeterministicSDP(mathRand.New(mathRand.NewSource(1))) //nolint:gosec
+
+		pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{
+			Certificates: []Certificate{*certificate},
+		})
+		assert.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+
+		_, err = pc.AddTrack(track)
+		assert.NoError(t, err)
+
+		_, err = pc.AddTransceiverFromKind(RTPCodecTypeAudio, RTPTransceiverInit{Direction: RTPTransceiverDirectionRecvonly})
+		assert.NoError(t, err)
+
+		// The msid of the generated track is generated too
+		_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+		assert.NoError(t, err)
+
+		_, err = pc.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+
+		offer, err := pc.CreateOffer(nil)
+		assert.NoError(t, err)
+
+		// And the ICE credentials of a restart
+		restartOffer, err := pc.CreateOffer(&OfferOptions{ICERestart: true})
+		assert.NoError(t, err)
+		assert.NoError(t, pc.Close())
+
+		return offer.SDP + restartOffer.SDP
+	}
+
+	assert.Equal(t, createOffer(), createOffer())
+}
+
+func TestSettingEngine_SetDeterministicSDP_Disabled(t *testing.T) {
+	s := SettingEngine{}
+	s.SetDeterministicSDP(mathRand.New(mathRand.NewSource(1))) //nolint:gosec
+	s.SetDeterministicSDP(nil)
+	assert.Nil(t, s.deterministicRandom)
+
+	ufrag, err := s.getICEUsernameFragment()
+	assert.NoError(t, err)
+	assert.Empty(t, ufrag)
+
+	pwd, err := s.getICEPassword()
+	assert.NoError(t, err)
+	assert.Empty(t, pwd)
+}
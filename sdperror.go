@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/sdp/v3"
+)
+
+// SDPError is returned by SetRemoteDescription when a SessionDescription
+// can't be applied. It locates the fault so signaling servers can react to it.
+// The reason, like ErrSessionDescriptionNoFingerprint, can be checked with
+// errors.Is.
+type SDPError struct {
+	// MediaIndex is the index of the faulty m-line, or -1 if the fault is at
+	// the session level
+	MediaIndex int
+
+	// Attribute is the key of the faulty attribute, like fingerprint
+	Attribute string
+
+	// Err is the reason of the fault
+	Err error
+}
+
+func (e *SDPError) Error() string {
+	if e.MediaIndex < 0 {
+		return fmt.Sprintf("session a=%s: %v", e.Attribute, e.Err)
+	}
+
+	return fmt.Sprintf("m-line %d a=%s: %v", e.MediaIndex, e.Attribute, e.Err)
+}
+
+// Unwrap returns the reason of the fault
+func (e *SDPError) Unwrap() error {
+	return e.Err
+}
+
+// remapSDPError translates the MediaIndex of an SDPError from subset, a subset
+// of the media sections of all, to all
+func remapSDPError(err error, subset, all []*sdp.MediaDescription) error {
+	var sdpErr *SDPError
+	if !errors.As(err, &sdpErr) || sdpErr.MediaIndex < 0 || sdpErr.MediaIndex >= len(subset) {
+		return err
+	}
+
+	for i, media := range all {
+		if media == subset[sdpErr.MediaIndex] {
+			return &SDPError{MediaIndex: i, Attribute: sdpErr.Attribute, Err: sdpErr.Err}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSDPError(t *testing.T) {
+	testCases := []struct {
+		err            *SDPError
+		expectedString string
+	}{
+		{&SDPError{MediaIndex: -1, Attribute: "fingerprint", Err: ErrSessionDescriptionNoFingerprint}, "session a=fingerprint: SetRemoteDescription called with no fingerprint"},
+		{&SDPError{MediaIndex: 1, Attribute: "ice-pwd", Err: ErrSessionDescriptionConflictingIcePwd}, "m-line 1 a=ice-pwd: SetRemoteDescription called with multiple conflicting ice-pwd values"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expectedString, testCase.err.Error(), "testCase: %d %v", i, testCase)
+		assert.ErrorIs(t, testCase.err, testCase.err.Err, "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestRemapSDPError(t *testing.T) {
+	all := []*sdp.MediaDescription{{}, {}, {}}
+	subset := []*sdp.MediaDescription{all[0], all[2]}
+
+	testCases := []struct {
+		err      error
+		expected error
+	}{
+		{errSDPZeroTransceivers, errSDPZeroTransceivers},
+		{&SDPError{MediaIndex: -1, Attribute: "rtpmap"}, &SDPError{MediaIndex: -1, Attribute: "rtpmap"}},
+		{&SDPError{MediaIndex: 0, Attribute: "rtpmap"}, &SDPError{MediaIndex: 0, Attribute: "rtpmap"}},
+		{&SDPError{MediaIndex: 1, Attribute: "rtpmap"}, &SDPError{MediaIndex: 2, Attribute: "rtpmap"}},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expected, remapSDPError(testCase.err, subset, all), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestPeerConnection_SetRemoteDescription_SDPError(t *testing.T) {
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	offer.SDP = regexp.MustCompile(`a=ice-ufrag:.*\r\n`).ReplaceAllString(offer.SDP, "")
+
+	err = pcAnswer.SetRemoteDescription(offer)
+	var sdpErr *SDPError
+	assert.True(t, errors.As(err, &sdpErr))
+	assert.Equal(t, &SDPError{MediaIndex: -1, Attribute: "ice-ufrag", Err: ErrSessionDescriptionMissingIceUfrag}, sdpErr)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
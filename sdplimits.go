@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SDPLimits are hard limits on the remote descriptions a PeerConnection
+// accepts. They are checked on the raw SDP before it is parsed, so the memory
+// spent on an oversized description is bounded. A limit left 0 is not
+// enforced. See SettingEngine.SetSDPLimits.
+type SDPLimits struct {
+	// MaxSize is the maximum size of the SDP in bytes
+	MaxSize int
+
+	// MaxMediaSections is the maximum number of m-lines
+	MaxMediaSections int
+
+	// MaxAttributesPerSection is the maximum number of attributes in the
+	// session section and in each media section
+	MaxAttributesPerSection int
+
+	// MaxCandidates is the maximum number of candidates in the description
+	MaxCandidates int
+}
+
+// check returns an error wrapping ErrSDPTooLarge, ErrSDPTooManyMediaSections,
+// ErrSDPTooManyAttributes or ErrSDPTooManyCandidates if sdp exceeds a limit
+func (l SDPLimits) check(sdp string) error {
+	if l.MaxSize > 0 && len(sdp) > l.MaxSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrSDPTooLarge, len(sdp), l.MaxSize)
+	}
+
+	if l.MaxMediaSections <= 0 && l.MaxAttributesPerSection <= 0 && l.MaxCandidates <= 0 {
+		return nil
+	}
+
+	mediaSections, attributes, candidates := 0, 0, 0
+	for len(sdp) > 0 {
+		var line string
+		if i := strings.IndexByte(sdp, '\n'); i >= 0 {
+			line, sdp = sdp[:i], sdp[i+1:]
+		} else {
+			line, sdp = sdp, ""
+		}
+
+		switch {
+		case strings.HasPrefix(line, "m="):
+			mediaSections++
+			attributes = 0
+			if l.MaxMediaSections > 0 && mediaSections > l.MaxMediaSections {
+				return fmt.Errorf("%w: limit is %d", ErrSDPTooManyMediaSections, l.MaxMediaSections)
+			}
+		case strings.HasPrefix(line, "a="):
+			attributes++
+			if l.MaxAttributesPerSection > 0 && attributes > l.MaxAttributesPerSection {
+				return fmt.Errorf("%w: in section %d, limit is %d", ErrSDPTooManyAttributes, mediaSections, l.MaxAttributesPerSection)
+			}
+
+			if strings.HasPrefix(line, "a=candidate:") {
+				candidates++
+				if l.MaxCandidates > 0 && candidates > l.MaxCandidates {
+					return fmt.Errorf("%w: limit is %d", ErrSDPTooManyCandidates, l.MaxCandidates)
+				}
+			}
+		}
+	}
+
+	return nil
+}
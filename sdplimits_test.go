@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSDPLimits(t *testing.T) {
+	sdp := "v=0\r\n" +
+		"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"a=group:BUNDLE 0 1\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"a=mid:0\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"a=candidate:1 1 udp 2130706431 10.0.0.1 5000 typ host\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"a=mid:1\r\n" +
+		"a=rtpmap:96 VP8/90000\r\n" +
+		"a=candidate:1 1 udp 2130706431 10.0.0.1 5000 typ host\r\n"
+
+	for i, testCase := range []struct {
+		limits SDPLimits
+		err    error
+	}{
+		{SDPLimits{}, nil},
+		{SDPLimits{MaxSize: len(sdp), MaxMediaSections: 2, MaxAttributesPerSection: 3, MaxCandidates: 2}, nil},
+		{SDPLimits{MaxSize: len(sdp) - 1}, ErrSDPTooLarge},
+		{SDPLimits{MaxMediaSections: 1}, ErrSDPTooManyMediaSections},
+		{SDPLimits{MaxAttributesPerSection: 2}, ErrSDPTooManyAttributes},
+		{SDPLimits{MaxCandidates: 1}, ErrSDPTooManyCandidates},
+	} {
+		err := testCase.limits.check(sdp)
+		if testCase.err == nil {
+			assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		} else {
+			assert.ErrorIs(t, err, testCase.err, "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestPeerConnection_SDPLimits(t *testing.T) {
+	offerer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	offer, err := offerer.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetSDPLimits(SDPLimits{MaxSize: len(offer.SDP) - 1})
+
+	answerer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	err = answerer.SetRemoteDescription(offer)
+	assert.ErrorIs(t, err, ErrSDPTooLarge)
+
+	var invalidAccessErr *rtcerr.InvalidAccessError
+	assert.ErrorAs(t, err, &invalidAccessErr)
+	assert.Nil(t, answerer.RemoteDescription())
+
+	closePairNow(t, offerer, answerer)
+}
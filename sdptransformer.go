@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"github.com/pion/sdp/v3"
+)
+
+// SDPTransformer modifies description, a parsed session description of type
+// sdpType, in place. An error fails the call the description was given to.
+// See SettingEngine.SetSDPTransformers.
+type SDPTransformer func(sdpType SDPType, description *sdp.SessionDescription) error
+
+// SetSDPTransformers sets hooks to munge session descriptions without
+// editing SessionDescription.SDP. local is invoked on the offers and answers
+// generated by CreateOffer and CreateAnswer before they are returned, and
+// remote on the descriptions given to SetRemoteDescription before they are
+// applied, after SetSDPLimits is enforced. Either can be nil. Transformers
+// must keep the descriptions valid, Pion doesn't check what they changed.
+func (e *SettingEngine) SetSDPTransformers(local, remote SDPTransformer) {
+	e.sdpTransformers.local = local
+	e.sdpTransformers.remote = remote
+}
+
+// transformLocalDescription invokes the local SDPTransformer on d
+func (e *SettingEngine) transformLocalDescription(sdpType SDPType, d *sdp.SessionDescription) error {
+	if e.sdpTransformers.local == nil {
+		return nil
+	}
+
+	return e.sdpTransformers.local(sdpType, d)
+}
+
+// transformRemoteDescription invokes the remote SDPTransformer on the parsed
+// description of desc, and updates its SDP
+func (e *SettingEngine) transformRemoteDescription(desc *SessionDescription) error {
+	if e.sdpTransformers.remote == nil {
+		return nil
+	}
+
+	if err := e.sdpTransformers.remote(desc.Type, desc.parsed); err != nil {
+		return err
+	}
+
+	sdpBytes, err := desc.parsed.Marshal()
+	if err != nil {
+		return err
+	}
+	desc.SDP = string(sdpBytes)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingEngine_SetSDPTransformers(t *testing.T) {
+	var localTypes, remoteTypes []SDPType
+
+	offerSettings := SettingEngine{}
+	offerSettings.SetSDPTransformers(func(sdpType SDPType, d *sdp.SessionDescription) error {
+		localTypes = append(localTypes, sdpType)
+		d.WithPropertyAttribute("x-local-munged")
+		return nil
+	}, nil)
+
+	answerSettings := SettingEngine{}
+	answerSettings.SetSDPTransformers(func(sdpType SDPType, d *sdp.SessionDescription) error {
+		localTypes = append(localTypes, sdpType)
+		return nil
+	}, func(sdpType SDPType, d *sdp.SessionDescription) error {
+		remoteTypes = append(remoteTypes, sdpType)
+		d.WithPropertyAttribute("x-remote-munged")
+		return nil
+	})
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(offer.SDP, "a=x-local-munged"))
+
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	assert.True(t, strings.Contains(pcAnswer.RemoteDescription().SDP, "a=x-remote-munged"))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	assert.Equal(t, []SDPType{SDPTypeOffer, SDPTypeAnswer}, localTypes)
+	assert.Equal(t, []SDPType{SDPTypeOffer}, remoteTypes)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestSettingEngine_SetSDPTransformers_Error(t *testing.T) {
+	errTransform := errors.New("transform failed")
+	failing := func(SDPType, *sdp.SessionDescription) error {
+		return errTransform
+	}
+
+	localSettings := SettingEngine{}
+	localSettings.SetSDPTransformers(failing, nil)
+	remoteSettings := SettingEngine{}
+	remoteSettings.SetSDPTransformers(nil, failing)
+
+	pcLocal, err := NewAPI(WithSettingEngine(localSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcRemote, err := NewAPI(WithSettingEngine(remoteSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcOffer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcLocal.CreateOffer(nil)
+	assert.ErrorIs(t, err, errTransform)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, pcRemote.SetRemoteDescription(offer), errTransform)
+	assert.Nil(t, pcRemote.RemoteDescription())
+
+	assert.NoError(t, pcLocal.Close())
+	closePairNow(t, pcOffer, pcRemote)
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+// SelectedCandidatePairChangeReason is the reason the ICE agent selected
+// another candidate pair
+type SelectedCandidatePairChangeReason int
+
+const (
+	// SelectedCandidatePairChangeReasonInitial is the first candidate pair
+	// selected by the ICETransport
+	SelectedCandidatePairChangeReasonInitial SelectedCandidatePairChangeReason = iota + 1
+
+	// SelectedCandidatePairChangeReasonFailover is a candidate pair selected
+	// after the previous one got disconnected or failed, like a fallback to
+	// a relay
+	SelectedCandidatePairChangeReasonFailover
+
+	// SelectedCandidatePairChangeReasonICERestart is the first candidate pair
+	// selected after an ICE restart
+	SelectedCandidatePairChangeReasonICERestart
+
+	// SelectedCandidatePairChangeReasonRenomination is a candidate pair that
+	// replaced the previous one while it was still connected
+	SelectedCandidatePairChangeReasonRenomination
+)
+
+// This is done this way because of a linter.
+const (
+	selectedCandidatePairChangeReasonInitialStr      = "initial"
+	selectedCandidatePairChangeReasonFailoverStr     = "failover"
+	selectedCandidatePairChangeReasonICERestartStr   = "ice-restart"
+	selectedCandidatePairChangeReasonRenominationStr = "renomination"
+)
+
+func (r SelectedCandidatePairChangeReason) String() string {
+	switch r {
+	case SelectedCandidatePairChangeReasonInitial:
+		return selectedCandidatePairChangeReasonInitialStr
+	case SelectedCandidatePairChangeReasonFailover:
+		return selectedCandidatePairChangeReasonFailoverStr
+	case SelectedCandidatePairChangeReasonICERestart:
+		return selectedCandidatePairChangeReasonICERestartStr
+	case SelectedCandidatePairChangeReasonRenomination:
+		return selectedCandidatePairChangeReasonRenominationStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// SelectedCandidatePairChange describes a change of the candidate pair the
+// ICETransport sends and receives packets on
+type SelectedCandidatePairChange struct {
+	// Pair is the newly selected candidate pair
+	Pair *ICECandidatePair
+
+	// Previous is the candidate pair selected before, nil for
+	// SelectedCandidatePairChangeReasonInitial
+	Previous *ICECandidatePair
+
+	Reason SelectedCandidatePairChangeReason
+}
+
+// selectedCandidatePairChangeReason returns the reason a candidate pair is
+// selected, after previous and if the ICETransport was restarted or got
+// disconnected or failed since previous was selected
+func selectedCandidatePairChangeReason(previous *ICECandidatePair, restarted, disconnected bool) SelectedCandidatePairChangeReason {
+	switch {
+	case previous == nil:
+		return SelectedCandidatePairChangeReasonInitial
+	case restarted:
+		return SelectedCandidatePairChangeReasonICERestart
+	case disconnected:
+		return SelectedCandidatePairChangeReasonFailover
+	default:
+		return SelectedCandidatePairChangeReasonRenomination
+	}
+}
+
+// OnSelectedCandidatePairChange sets an event handler which is invoked when
+// the ICETransport of the PeerConnection selects a candidate pair, with the
+// reason of the change. It allows to log path changes, like a fallback to a
+// relay.
+func (pc *PeerConnection) OnSelectedCandidatePairChange(f func(SelectedCandidatePairChange)) {
+	pc.onSelectedCandidatePairChangeHandler.Store(f)
+}
+
+func (pc *PeerConnection) onSelectedCandidatePairChange(change SelectedCandidatePairChange) {
+	if handler, ok := pc.onSelectedCandidatePairChangeHandler.Load().(func(SelectedCandidatePairChange)); ok && handler != nil {
+		handler(change)
+	}
+}
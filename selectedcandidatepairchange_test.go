@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectedCandidatePairChangeReason_String(t *testing.T) {
+	testCases := []struct {
+		reason         SelectedCandidatePairChangeReason
+		expectedString string
+	}{
+		{SelectedCandidatePairChangeReasonInitial, "initial"},
+		{SelectedCandidatePairChangeReasonFailover, "failover"},
+		{SelectedCandidatePairChangeReasonICERestart, "ice-restart"},
+		{SelectedCandidatePairChangeReasonRenomination, "renomination"},
+		{SelectedCandidatePairChangeReason(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.reason.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestSelectedCandidatePairChangeReasonFromHistory(t *testing.T) {
+	previous := &ICECandidatePair{}
+
+	testCases := []struct {
+		previous       *ICECandidatePair
+		restarted      bool
+		disconnected   bool
+		expectedReason SelectedCandidatePairChangeReason
+	}{
+		{nil, false, false, SelectedCandidatePairChangeReasonInitial},
+		{nil, true, true, SelectedCandidatePairChangeReasonInitial},
+		{previous, true, false, SelectedCandidatePairChangeReasonICERestart},
+		{previous, true, true, SelectedCandidatePairChangeReasonICERestart},
+		{previous, false, true, SelectedCandidatePairChangeReasonFailover},
+		{previous, false, false, SelectedCandidatePairChangeReasonRenomination},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedReason,
+			selectedCandidatePairChangeReason(testCase.previous, testCase.restarted, testCase.disconnected),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestICETransport_RecordDisconnection(t *testing.T) {
+	iceTransport := NewICETransport(nil, logging.NewDefaultLoggerFactory())
+
+	local, err := ice.NewCandidateHost(&ice.CandidateHostConfig{Network: "udp", Address: "192.168.0.1", Port: 1000, Component: 1})
+	assert.NoError(t, err)
+	remote, err := ice.NewCandidateHost(&ice.CandidateHostConfig{Network: "udp", Address: "192.168.0.2", Port: 1000, Component: 1})
+	assert.NoError(t, err)
+	other, err := ice.NewCandidateHost(&ice.CandidateHostConfig{Network: "udp", Address: "192.168.0.3", Port: 1000, Component: 1})
+	assert.NoError(t, err)
+
+	candidates, err := newICECandidatesFromICE([]ice.Candidate{local, remote})
+	assert.NoError(t, err)
+	iceTransport.selectedCandidatePair.Store(NewICECandidatePair(&candidates[0], &candidates[1]))
+
+	// Failing over to another pair, the ICE Agent is connected before the
+	// pair is reported
+	iceTransport.recordDisconnection(ICETransportStateDisconnected, nil)
+	iceTransport.recordDisconnection(ICETransportStateConnected, &ice.CandidatePair{Local: local, Remote: other})
+	assert.True(t, iceTransport.disconnected.get())
+
+	// Connected again on the selected pair
+	iceTransport.recordDisconnection(ICETransportStateFailed, nil)
+	iceTransport.recordDisconnection(ICETransportStateConnected, &ice.CandidatePair{Local: local, Remote: remote})
+	assert.False(t, iceTransport.disconnected.get())
+}
+
+func TestPeerConnection_OnSelectedCandidatePairChange(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	changes := make(chan SelectedCandidatePairChange, 1)
+	pcOffer.OnSelectedCandidatePairChange(func(change SelectedCandidatePairChange) {
+		select {
+		case changes <- change:
+		default:
+		}
+	})
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	change := <-changes
+	assert.Equal(t, SelectedCandidatePairChangeReasonInitial, change.Reason)
+	assert.Nil(t, change.Previous)
+	assert.NotNil(t, change.Pair)
+	assert.NotNil(t, change.Pair.Local)
+	assert.NotNil(t, change.Pair.Remote)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,417 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/internal/util"
+)
+
+const (
+	selfTestLabel         = "selftest"
+	selfTestPacketization = 20 * time.Millisecond
+)
+
+var (
+	errSelfTestConnectionFailed = errors.New("PeerConnection failed")
+	errSelfTestEchoMismatch     = errors.New("echoed message doesn't match the sent message")
+	errSelfTestStatsMissing     = errors.New("stats are missing")
+)
+
+// SelfTestCheck is the outcome of one check of API.SelfTest
+type SelfTestCheck struct {
+	Name string
+
+	// Skipped is true if the check didn't run, because an earlier check
+	// failed or because the configuration has nothing to check, like a
+	// MediaEngine without codecs for the media check
+	Skipped bool
+
+	Duration time.Duration
+	Err      error
+}
+
+// SelfTestReport is the outcome of API.SelfTest
+type SelfTestReport struct {
+	Checks []SelfTestCheck
+}
+
+// Passed returns true if no check failed
+func (r SelfTestReport) Passed() bool {
+	return r.Err() == nil
+}
+
+// Err returns the errors of the failed checks, or nil if none failed
+func (r SelfTestReport) Err() error {
+	errs := []error{}
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.Name, check.Err))
+		}
+	}
+
+	return util.FlattenErrs(errs)
+}
+
+// SelfTest connects two PeerConnections created by the API to each other
+// in-process, and checks that the configuration of its MediaEngine,
+// SettingEngine and Interceptors works end-to-end: negotiation, connection,
+// a DataChannel echo, media flow for the first audio and video codec, and
+// the presence of stats. Checks after a failed check are skipped. SelfTest
+// returns once all checks ran or ctx is done, and is meant for health checks
+// of deployments.
+func (api *API) SelfTest(ctx context.Context) SelfTestReport {
+	s := &selfTest{
+		api:       api,
+		ctx:       ctx,
+		connected: make(chan struct{}),
+		failed:    make(chan struct{}),
+		opened:    make(chan struct{}),
+		echoed:    make(chan []byte, 1),
+		received:  make(chan struct{}, 2),
+	}
+	defer s.close()
+
+	report := SelfTestReport{}
+	failed := false
+	for _, check := range []struct {
+		name string
+		run  func() (skipped bool, err error)
+	}{
+		{"negotiation", s.negotiate},
+		{"connection", s.connect},
+		{"datachannel", s.echo},
+		{"media", s.media},
+		{"stats", s.stats},
+	} {
+		result := SelfTestCheck{Name: check.name}
+		if failed {
+			result.Skipped = true
+		} else {
+			start := time.Now()
+			result.Skipped, result.Err = check.run()
+			result.Duration = time.Since(start)
+			failed = result.Err != nil
+		}
+
+		report.Checks = append(report.Checks, result)
+	}
+
+	return report
+}
+
+type selfTest struct {
+	api *API
+	ctx context.Context
+
+	offerer, answerer *PeerConnection
+	dataChannel       *DataChannel
+	tracks            []*TrackLocalStaticRTP
+
+	connectedOnce, failedOnce, openedOnce sync.Once
+	connected, failed, opened             chan struct{}
+	echoed                                chan []byte
+	received                              chan struct{}
+}
+
+// wait blocks until ch is closed, the connection failed or ctx is done
+func (s *selfTest) wait(ch <-chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-s.failed:
+		return errSelfTestConnectionFailed
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func (s *selfTest) negotiate() (bool, error) {
+	var err error
+	if s.offerer, err = s.api.NewPeerConnection(Configuration{}); err != nil {
+		return false, err
+	}
+	if s.answerer, err = s.api.NewPeerConnection(Configuration{}); err != nil {
+		return false, err
+	}
+
+	for _, pc := range []*PeerConnection{s.offerer, s.answerer} {
+		pc.OnConnectionStateChange(s.handleConnectionStateChange)
+	}
+
+	if err = s.addDataChannel(); err != nil {
+		return false, err
+	}
+	if err = s.addTracks(); err != nil {
+		return false, err
+	}
+
+	offer, err := s.offerer.CreateOffer(nil)
+	if err != nil {
+		return false, err
+	}
+	if err = s.setLocalDescription(s.offerer, offer); err != nil {
+		return false, err
+	}
+	if err = s.answerer.SetRemoteDescription(*s.offerer.LocalDescription()); err != nil {
+		return false, err
+	}
+
+	answer, err := s.answerer.CreateAnswer(nil)
+	if err != nil {
+		return false, err
+	}
+	if err = s.setLocalDescription(s.answerer, answer); err != nil {
+		return false, err
+	}
+
+	return false, s.offerer.SetRemoteDescription(*s.answerer.LocalDescription())
+}
+
+// setLocalDescription sets the local description of pc and waits for the
+// gathering of its candidates
+func (s *selfTest) setLocalDescription(pc *PeerConnection, desc SessionDescription) error {
+	gatherComplete := GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(desc); err != nil {
+		return err
+	}
+
+	return s.wait(gatherComplete)
+}
+
+func (s *selfTest) handleConnectionStateChange(state PeerConnectionState) {
+	switch state { //nolint:exhaustive
+	case PeerConnectionStateConnected:
+		if s.offerer.ConnectionState() == PeerConnectionStateConnected &&
+			s.answerer.ConnectionState() == PeerConnectionStateConnected {
+			s.connectedOnce.Do(func() { close(s.connected) })
+		}
+	case PeerConnectionStateFailed:
+		s.failedOnce.Do(func() { close(s.failed) })
+	}
+}
+
+func (s *selfTest) connect() (bool, error) {
+	return false, s.wait(s.connected)
+}
+
+// addDataChannel creates the DataChannel of the offerer, the answerer echoes
+// its messages
+func (s *selfTest) addDataChannel() (err error) {
+	detach := s.api.settingEngine.detach.DataChannels
+
+	s.answerer.OnDataChannel(func(d *DataChannel) {
+		if !detach {
+			d.OnMessage(func(msg DataChannelMessage) {
+				if err := d.Send(msg.Data); err != nil {
+					d.log.Warnf("Failed to echo self-test message: %v", err)
+				}
+			})
+			return
+		}
+
+		d.OnOpen(func() {
+			raw, err := d.Detach()
+			if err != nil {
+				d.log.Warnf("Failed to detach self-test DataChannel: %v", err)
+				return
+			}
+
+			buf := make([]byte, dataChannelBufferSize)
+			n, err := raw.Read(buf)
+			if err != nil {
+				return
+			}
+			if _, err = raw.Write(buf[:n]); err != nil {
+				d.log.Warnf("Failed to echo self-test message: %v", err)
+			}
+		})
+	})
+
+	if s.dataChannel, err = s.offerer.CreateDataChannel(selfTestLabel, nil); err != nil {
+		return err
+	}
+
+	s.dataChannel.OnOpen(func() {
+		s.openedOnce.Do(func() { close(s.opened) })
+	})
+	if !detach {
+		s.dataChannel.OnMessage(func(msg DataChannelMessage) {
+			select {
+			case s.echoed <- msg.Data:
+			default:
+			}
+		})
+	}
+
+	return nil
+}
+
+func (s *selfTest) echo() (bool, error) {
+	if err := s.wait(s.opened); err != nil {
+		return false, err
+	}
+
+	message := []byte(selfTestLabel)
+	if !s.api.settingEngine.detach.DataChannels {
+		if err := s.dataChannel.Send(message); err != nil {
+			return false, err
+		}
+	} else {
+		raw, err := s.dataChannel.Detach()
+		if err != nil {
+			return false, err
+		}
+		if _, err = raw.Write(message); err != nil {
+			return false, err
+		}
+
+		go func() {
+			buf := make([]byte, dataChannelBufferSize)
+			if n, err := raw.Read(buf); err == nil {
+				s.echoed <- buf[:n]
+			}
+		}()
+	}
+
+	select {
+	case echoed := <-s.echoed:
+		if !bytes.Equal(message, echoed) {
+			return false, errSelfTestEchoMismatch
+		}
+		return false, nil
+	case <-s.ctx.Done():
+		return false, s.ctx.Err()
+	}
+}
+
+// addTracks adds a track for the first audio and video codec of the
+// MediaEngine to the offerer
+func (s *selfTest) addTracks() error {
+	s.answerer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		if _, _, err := track.ReadRTP(); err == nil {
+			s.received <- struct{}{}
+		}
+	})
+
+	for _, kind := range []RTPCodecType{RTPCodecTypeAudio, RTPCodecTypeVideo} {
+		for _, codec := range s.api.mediaEngine.getCodecsByKind(kind) {
+			if strings.HasSuffix(strings.ToLower(codec.MimeType), "/rtx") {
+				continue
+			}
+
+			track, err := NewTrackLocalStaticRTP(codec.RTPCodecCapability, selfTestLabel+"-"+kind.String(), selfTestLabel)
+			if err != nil {
+				return err
+			}
+			if _, err = s.offerer.AddTrack(track); err != nil {
+				return err
+			}
+
+			s.tracks = append(s.tracks, track)
+			break
+		}
+	}
+
+	return nil
+}
+
+// media writes packets on the tracks of the offerer until the answerer
+// received one on each of them
+func (s *selfTest) media() (bool, error) {
+	if len(s.tracks) == 0 {
+		return true, nil
+	}
+
+	ticker := time.NewTicker(selfTestPacketization)
+	defer ticker.Stop()
+
+	received := 0
+	for sequenceNumber := uint16(0); received < len(s.tracks); sequenceNumber++ {
+		for _, track := range s.tracks {
+			packet := &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					SequenceNumber: sequenceNumber,
+					Timestamp:      uint32(sequenceNumber) * uint32(selfTestPacketization.Milliseconds()) * track.Codec().ClockRate / 1000,
+				},
+				Payload: []byte{0x00},
+			}
+			if err := track.WriteRTP(packet); err != nil {
+				return false, err
+			}
+		}
+
+		for waiting := true; waiting && received < len(s.tracks); {
+			select {
+			case <-s.received:
+				received++
+			case <-ticker.C:
+				waiting = false
+			case <-s.failed:
+				return false, errSelfTestConnectionFailed
+			case <-s.ctx.Done():
+				return false, s.ctx.Err()
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (s *selfTest) stats() (bool, error) {
+	report := s.offerer.GetStats()
+
+	missing := []string{}
+	if _, ok := report.GetConnectionStats(s.offerer); !ok {
+		missing = append(missing, string(StatsTypePeerConnection))
+	}
+	if _, ok := report.GetDataChannelStats(s.dataChannel); !ok {
+		missing = append(missing, string(StatsTypeDataChannel))
+	}
+
+	var hasCandidatePair, hasSCTPTransport bool
+	for _, stats := range report {
+		switch stats.(type) {
+		case ICECandidatePairStats:
+			hasCandidatePair = true
+		case SCTPTransportStats:
+			hasSCTPTransport = true
+		}
+	}
+	if !hasCandidatePair {
+		missing = append(missing, string(StatsTypeCandidatePair))
+	}
+	if !hasSCTPTransport {
+		missing = append(missing, string(StatsTypeSCTPTransport))
+	}
+
+	if len(missing) != 0 {
+		return false, fmt.Errorf("%w: %s", errSelfTestStatsMissing, strings.Join(missing, ", "))
+	}
+
+	return false, nil
+}
+
+func (s *selfTest) close() {
+	for _, pc := range []*PeerConnection{s.offerer, s.answerer} {
+		if pc == nil {
+			continue
+		}
+		if err := pc.Close(); err != nil {
+			pc.log.Warnf("Failed to close self-test PeerConnection: %v", err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPI_SelfTest(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	t.Run("Default", func(t *testing.T) {
+		selfTest := NewAPI().SelfTest(ctx)
+		assert.NoError(t, selfTest.Err())
+		assert.True(t, selfTest.Passed())
+
+		names := []string{}
+		for _, check := range selfTest.Checks {
+			names = append(names, check.Name)
+			assert.False(t, check.Skipped, check.Name)
+		}
+		assert.Equal(t, []string{"negotiation", "connection", "datachannel", "media", "stats"}, names)
+	})
+
+	t.Run("Detached DataChannels without codecs", func(t *testing.T) {
+		s := SettingEngine{}
+		s.DetachDataChannels()
+
+		selfTest := NewAPI(WithSettingEngine(s), WithMediaEngine(&MediaEngine{})).SelfTest(ctx)
+		assert.True(t, selfTest.Passed(), selfTest.Err())
+		assert.True(t, selfTest.Checks[3].Skipped)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		canceledCtx, cancelNow := context.WithCancel(ctx)
+		cancelNow()
+
+		selfTest := NewAPI().SelfTest(canceledCtx)
+		assert.False(t, selfTest.Passed())
+		assert.ErrorIs(t, selfTest.Err(), context.Canceled)
+		assert.True(t, selfTest.Checks[len(selfTest.Checks)-1].Skipped)
+	})
+}
@@ -8,15 +8,18 @@ package webrtc
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"io"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/pion/dtls/v2"
 	dtlsElliptic "github.com/pion/dtls/v2/pkg/crypto/elliptic"
 	"github.com/pion/ice/v3"
 	"github.com/pion/logging"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v3"
 	"github.com/pion/transport/v3/packetio"
 	"golang.org/x/net/proxy"
@@ -52,8 +55,11 @@ type SettingEngine struct {
 		MulticastDNSMode         ice.MulticastDNSMode
 		MulticastDNSHostName     string
 		UsernameFragment         string
+		UsernameFragmentPrefix   string
 		Password                 string
 		IncludeLoopbackCandidate bool
+		StaticHostCandidates     []*net.UDPAddr
+		CandidateFilter          func(ICECandidate) bool
 	}
 	replayProtection struct {
 		DTLS  *uint
@@ -72,9 +78,14 @@ type SettingEngine struct {
 		rootCAs                   *x509.CertPool
 		keyLogWriter              io.Writer
 		customCipherSuites        func() []dtls.CipherSuite
+		customDataMatcher         func([]byte) bool
+		remoteCertificateVerifier DTLSRemoteCertificateVerifier
+		mtu                       int
 	}
 	sctp struct {
 		maxReceiveBufferSize uint32
+		maxMessageSize       uint32
+		disableZeroChecksum  bool
 	}
 	sdpMediaLevelFingerprints                 bool
 	answeringDTLSRole                         DTLSRole
@@ -82,6 +93,7 @@ type SettingEngine struct {
 	disableSRTPReplayProtection               bool
 	disableSRTCPReplayProtection              bool
 	net                                       transport.Net
+	interfaceProvider                         InterfaceProvider
 	BufferFactory                             func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser
 	LoggerFactory                             logging.LoggerFactory
 	iceTCPMux                                 ice.TCPMux
@@ -92,6 +104,48 @@ type SettingEngine struct {
 	srtpProtectionProfiles                    []dtls.SRTPProtectionProfile
 	receiveMTU                                uint
 	iceMaxBindingRequests                     *uint16
+	negotiationNeededDebounce                 time.Duration
+	codecNegotiationFailurePolicy             CodecNegotiationFailurePolicy
+	dataChannelAuthToken                      []byte
+	connectionQualityInterval                 time.Duration
+	connectionQualityThresholds               *ConnectionQualityThresholds
+	sdpLimits                                 SDPLimits
+	srtpWriteQueueSize                        int
+	dataChannelBackpressure                   struct {
+		highWaterMark uint64
+		block         bool
+	}
+	trackRemoteBufferWatermarks struct {
+		high, low int
+	}
+	deterministicRandom   *deterministicRandom
+	iceTCPMuxOptions      ICETCPMuxOptions
+	dnsResolver           DNSResolver
+	ipFilterCIDRs         ipFilterCIDRs
+	iceSocketFactory      ICESocketFactory
+	forcedDTLSRole        DTLSRole
+	iceCandidatePairCache ICECandidatePairCache
+	rtcpReportIntervals   *RTCPReportIntervals
+	twccFeedbackInterval  time.Duration
+	srtpReadBuffer        struct {
+		enabled bool
+		size    int
+		policy  SRTPBufferPolicy
+	}
+	packetMirror    *PacketMirror
+	sdpTransformers struct {
+		local, remote SDPTransformer
+	}
+	rtcpMuxOnly                   bool
+	iceCandidatePairStatsInterval time.Duration
+	iceContinualGatheringInterval time.Duration
+	iceRestartPolicy              *ICERestartPolicy
+	iceTURNTLSConfig              *tls.Config
+	iceConsentExpiryTimeout       time.Duration
+	iceMulticastDNSResolveOff     bool
+	iceAddressFamilyPolicy        ICEAddressFamilyPolicy
+	iceMaxHostCandidatesPerFamily int
+	iceCandidatePriorityFunc      ICECandidatePriorityFunc
 }
 
 // getReceiveMTU returns the configured MTU. If SettingEngine's MTU is configured to 0 it returns the default
@@ -103,6 +157,16 @@ func (e *SettingEngine) getReceiveMTU() uint {
 	return receiveMTU
 }
 
+// getSCTPMaxMessageSize returns the configured max-message-size. If it is
+// configured to 0 it returns the default
+func (e *SettingEngine) getSCTPMaxMessageSize() uint32 {
+	if e.sctp.maxMessageSize != 0 {
+		return e.sctp.maxMessageSize
+	}
+
+	return sctpMaxMessageSizeUnsetValue
+}
+
 // DetachDataChannels enables detaching data channels. When enabled
 // data channels have to be detached in the OnOpen callback using the
 // DataChannel.Detach method.
@@ -112,6 +176,13 @@ func (e *SettingEngine) DetachDataChannels() {
 
 // SetSRTPProtectionProfiles allows the user to override the default SRTP Protection Profiles
 // The default srtp protection profiles are provided by the function `defaultSrtpProtectionProfiles`
+// The profiles are offered in order of preference. Supported are
+// dtls.SRTP_AEAD_AES_256_GCM, dtls.SRTP_AEAD_AES_128_GCM and
+// dtls.SRTP_AES128_CM_HMAC_SHA1_80, so a deployment can require AES-GCM by
+// leaving out dtls.SRTP_AES128_CM_HMAC_SHA1_80. The DTLS handshake fails if
+// the remote doesn't support any of the profiles, or if an unsupported one is
+// selected. The selected profile is returned by
+// DTLSTransport.SelectedSRTPProtectionProfile.
 func (e *SettingEngine) SetSRTPProtectionProfiles(profiles ...dtls.SRTPProtectionProfile) {
 	e.srtpProtectionProfiles = profiles
 }
@@ -171,7 +242,11 @@ func (e *SettingEngine) SetEphemeralUDPPortRange(portMin, portMax uint16) error
 	return nil
 }
 
-// SetLite configures whether or not the ice agent should be a lite agent
+// SetLite configures whether or not the ice agent should be a lite agent.
+// A lite agent advertises a=ice-lite, only gathers host candidates, ignoring
+// ICEServers and ICETransportPolicyRelay, and never initiates connectivity
+// checks. It is meant for servers with a public address, like SFUs. When only
+// one side of a session is lite it takes the ICE controlled role.
 func (e *SettingEngine) SetLite(lite bool) {
 	e.candidates.ICELite = lite
 }
@@ -198,6 +273,39 @@ func (e *SettingEngine) SetIPFilter(filter func(net.IP) bool) {
 	e.candidates.IPFilter = filter
 }
 
+// SetCandidateFilter sets a function which drops ICE candidates it returns
+// false for, like candidates of a type or in an address range. Local
+// candidates it drops aren't emitted by OnICECandidate nor included in the
+// SessionDescription, and remote candidates it drops aren't used for
+// connectivity checks. Unlike SetIPFilter it is applied to every candidate,
+// after gathering.
+//
+// The filter only affects signaling for local candidates: the ICE Agent
+// still gathers them and sends connectivity checks from them, so the remote
+// may learn them as peer reflexive candidates and select them. Use
+// SetNetworkTypes, SetInterfaceFilter, SetIPFilter or the ICETransportPolicy
+// to keep the ICE Agent from gathering candidates.
+func (e *SettingEngine) SetCandidateFilter(filter func(ICECandidate) bool) {
+	e.candidates.CandidateFilter = filter
+}
+
+// allowsCandidate returns false if c is dropped by the filter set with
+// SetCandidateFilter
+func (e *SettingEngine) allowsCandidate(c ICECandidate) bool {
+	return e.candidates.CandidateFilter == nil || e.candidates.CandidateFilter(c)
+}
+
+// allowsRemoteCandidate returns false if the remote candidate c is dropped by
+// the filter set with SetCandidateFilter, or is a mDNS candidate and resolving
+// them is disabled with SetICEMulticastDNSPolicy
+func (e *SettingEngine) allowsRemoteCandidate(c ICECandidate) bool {
+	if e.iceMulticastDNSResolveOff && strings.HasSuffix(c.Address, ".local") {
+		return false
+	}
+
+	return e.allowsCandidate(c)
+}
+
 // SetNAT1To1IPs sets a list of external IP addresses of 1:1 (D)NAT
 // and a candidate type for which the external IP address is used.
 // This is useful when you host a server using Pion on an AWS EC2 instance
@@ -254,6 +362,37 @@ func (e *SettingEngine) SetAnsweringDTLSRole(role DTLSRole) error {
 	return nil
 }
 
+// SetForcedDTLSRole forces the local DTLS role, whatever the setup attribute
+// of the remote. It is advertised in offers and answers instead of actpass,
+// which is needed to interoperate with devices mishandling actpass, or for
+// servers which must always be passive. The handshake fails if the remote
+// forces the same role. Pass DTLSRoleAuto to negotiate the role again.
+//
+// DTLSRoleClient:
+//
+//	Always act as DTLS Client, send the ClientHello and start the handshake
+//
+// DTLSRoleServer:
+//
+//	Always act as DTLS Server, wait for ClientHello
+func (e *SettingEngine) SetForcedDTLSRole(role DTLSRole) error {
+	if role != DTLSRoleClient && role != DTLSRoleServer && role != DTLSRoleAuto {
+		return errSettingEngineSetForcedDTLSRole
+	}
+
+	e.forcedDTLSRole = role
+	return nil
+}
+
+// offeringConnectionRole returns the setup attribute of offers
+func (e *SettingEngine) offeringConnectionRole() sdp.ConnectionRole {
+	if e.forcedDTLSRole == DTLSRoleClient || e.forcedDTLSRole == DTLSRoleServer {
+		return connectionRoleFromDtlsRole(e.forcedDTLSRole)
+	}
+
+	return connectionRoleFromDtlsRole(defaultDtlsRoleOffer)
+}
+
 // SetNet sets the Net instance that is passed to pion/ice
 //
 // Net is an network interface layer for Pion, allowing users to replace
@@ -262,11 +401,40 @@ func (e *SettingEngine) SetNet(net transport.Net) {
 	e.net = net
 }
 
+// SetInterfaceProvider sets where the network interfaces host candidates are
+// gathered from. It applies on top of the Net given to SetNet.
+//
+// By default the interfaces of the host are cached for a few seconds and
+// shared by every PeerConnection, instead of being enumerated by each of them.
+// On hosts with many virtual interfaces a NewStaticInterfaceProvider avoids
+// scanning them at all.
+func (e *SettingEngine) SetInterfaceProvider(provider InterfaceProvider) {
+	e.interfaceProvider = provider
+}
+
 // SetICEMulticastDNSMode controls if pion/ice queries and generates mDNS ICE Candidates
 func (e *SettingEngine) SetICEMulticastDNSMode(multicastDNSMode ice.MulticastDNSMode) {
 	e.candidates.MulticastDNSMode = multicastDNSMode
 }
 
+// SetICEMulticastDNSPolicy controls mDNS for the PeerConnections of the API
+// the SettingEngine is given to, so that different APIs can use different
+// policies in a process. obfuscateHostCandidates replaces the IP addresses of
+// host candidates with mDNS host names. resolveRemoteCandidates resolves the
+// mDNS host names of remote candidates, which are dropped otherwise. This
+// overrides SetICEMulticastDNSMode, which can't obfuscate without resolving.
+func (e *SettingEngine) SetICEMulticastDNSPolicy(obfuscateHostCandidates, resolveRemoteCandidates bool) {
+	switch {
+	case obfuscateHostCandidates:
+		e.candidates.MulticastDNSMode = ice.MulticastDNSModeQueryAndGather
+	case resolveRemoteCandidates:
+		e.candidates.MulticastDNSMode = ice.MulticastDNSModeQueryOnly
+	default:
+		e.candidates.MulticastDNSMode = ice.MulticastDNSModeDisabled
+	}
+	e.iceMulticastDNSResolveOff = !resolveRemoteCandidates
+}
+
 // SetMulticastDNSHostName sets a static HostName to be used by pion/ice instead of generating one on startup
 //
 // This should only be used for a single PeerConnection. Having multiple PeerConnections with the same HostName will cause
@@ -283,6 +451,43 @@ func (e *SettingEngine) SetICECredentials(usernameFragment, password string) {
 	e.candidates.Password = password
 }
 
+// SetICEUsernameFragmentPrefix sets a prefix prepended to the randomly
+// generated ICE username fragments, including the ones generated on ICE
+// restarts. It is ignored if SetICECredentials is used.
+//
+// This allows namespacing the username fragments of the PeerConnections of a
+// process, so a ICEUDPMuxFrontend shared by many processes can resolve the
+// process serving a username fragment from its prefix.
+func (e *SettingEngine) SetICEUsernameFragmentPrefix(prefix string) {
+	e.candidates.UsernameFragmentPrefix = prefix
+}
+
+// getICEUsernameFragment returns the ICE username fragment to use, empty to
+// let pion/ice generate one
+func (e *SettingEngine) getICEUsernameFragment() (string, error) {
+	if e.candidates.UsernameFragment != "" ||
+		(e.candidates.UsernameFragmentPrefix == "" && e.deterministicRandom == nil) {
+		return e.candidates.UsernameFragment, nil
+	}
+
+	ufrag, err := e.randomString(iceUsernameFragmentRandomLength, iceUsernameFragmentRunes)
+	if err != nil {
+		return "", err
+	}
+
+	return e.candidates.UsernameFragmentPrefix + ufrag, nil
+}
+
+// getICEPassword returns the ICE password to use, empty to let pion/ice
+// generate one
+func (e *SettingEngine) getICEPassword() (string, error) {
+	if e.candidates.Password != "" || e.deterministicRandom == nil {
+		return e.candidates.Password, nil
+	}
+
+	return e.deterministicRandom.string(icePasswordRandomLength, iceUsernameFragmentRunes)
+}
+
 // DisableCertificateFingerprintVerification disables fingerprint verification after DTLS Handshake has finished
 func (e *SettingEngine) DisableCertificateFingerprintVerification(isDisabled bool) {
 	e.disableCertificateFingerprintVerification = isDisabled
@@ -323,12 +528,77 @@ func (e *SettingEngine) SetSDPMediaLevelFingerprints(sdpMediaLevelFingerprints b
 	e.sdpMediaLevelFingerprints = sdpMediaLevelFingerprints
 }
 
+// SetTrackRemoteBufferWatermarks enables PeerConnection.OnTrackRemoteBufferWatermark.
+// It is invoked when the packets buffered for a TrackRemote reach high bytes,
+// and again once they went back to low bytes. The buffers are limited to 1 MB
+// unless BufferFactory is set. Buffers of a BufferFactory which aren't a
+// *packetio.Buffer aren't measured.
+func (e *SettingEngine) SetTrackRemoteBufferWatermarks(high, low int) {
+	e.trackRemoteBufferWatermarks.high = high
+	e.trackRemoteBufferWatermarks.low = low
+}
+
 // SetICETCPMux enables ICE-TCP when set to a non-nil value. Make sure that
 // NetworkTypeTCP4 or NetworkTypeTCP6 is enabled as well.
 func (e *SettingEngine) SetICETCPMux(tcpMux ice.TCPMux) {
 	e.iceTCPMux = tcpMux
 }
 
+// SetICETCPMuxOptions configures the TCPMux created by EnableICETCP, like
+// its backlog and the buffer sizes of its connections.
+func (e *SettingEngine) SetICETCPMuxOptions(options ICETCPMuxOptions) {
+	e.iceTCPMuxOptions = options
+}
+
+// EnableICETCP enables ICE-TCP. It listens for passive TCP candidates on port
+// of every interface, 0 picks a free port, and adds NetworkTypeTCP4 and
+// NetworkTypeTCP6 to the network types, which also enables dialing the
+// active TCP candidates of the remote unless DisableActiveTCP is set. The
+// returned TCPMux is shared by every PeerConnection created with this
+// SettingEngine, close it once they are closed.
+func (e *SettingEngine) EnableICETCP(port int) (ice.TCPMux, error) {
+	var (
+		listener net.Listener
+		err      error
+	)
+	if e.net != nil {
+		listener, err = e.net.ListenTCP("tcp", &net.TCPAddr{Port: port})
+	} else {
+		listener, err = net.ListenTCP("tcp", &net.TCPAddr{Port: port})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loggerFactory := e.LoggerFactory
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+
+	tcpMux := NewICETCPMuxWithOptions(loggerFactory.NewLogger("ice"), listener, e.iceTCPMuxOptions)
+	e.SetICETCPMux(tcpMux)
+
+	networkTypes := append([]NetworkType{}, e.candidates.ICENetworkTypes...)
+	if len(networkTypes) == 0 {
+		networkTypes = supportedNetworkTypes()
+	}
+	for _, tcpType := range []NetworkType{NetworkTypeTCP4, NetworkTypeTCP6} {
+		found := false
+		for _, typ := range networkTypes {
+			if typ == tcpType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			networkTypes = append(networkTypes, tcpType)
+		}
+	}
+	e.SetNetworkTypes(networkTypes)
+
+	return tcpMux, nil
+}
+
 // SetICEUDPMux allows ICE traffic to come through a single UDP port, drastically
 // simplifying deployments where ports will need to be opened/forwarded.
 // UDPMux should be started prior to creating PeerConnections.
@@ -336,6 +606,18 @@ func (e *SettingEngine) SetICEUDPMux(udpMux ice.UDPMux) {
 	e.iceUDPMux = udpMux
 }
 
+// SetICEStaticHostCandidates configures a fixed set of host candidates that are
+// advertised instead of gathering. This is useful for servers that sit behind a
+// load balancer or 1:1 NAT and always receive traffic on a known IP:port. No
+// interfaces are enumerated and no STUN/TURN servers are contacted, which makes
+// starting a PeerConnection cheaper.
+//
+// Traffic for every static candidate is handled by the UDPMux set with SetICEUDPMux,
+// which is required when this option is used. NAT1To1IPs and mDNS are ignored.
+func (e *SettingEngine) SetICEStaticHostCandidates(addrs []*net.UDPAddr) {
+	e.candidates.StaticHostCandidates = addrs
+}
+
 // SetICEProxyDialer sets the proxy dialer interface based on golang.org/x/net/proxy.
 func (e *SettingEngine) SetICEProxyDialer(d proxy.Dialer) {
 	e.iceProxyDialer = d
@@ -432,8 +714,143 @@ func (e *SettingEngine) SetSCTPMaxReceiveBufferSize(maxReceiveBufferSize uint32)
 	e.sctp.maxReceiveBufferSize = maxReceiveBufferSize
 }
 
+// SetSCTPMaxMessageSize sets the largest message the remote may send on a
+// DataChannel, which is advertised with the a=max-message-size attribute.
+// Leave this 0 for the default of 65536 bytes.
+func (e *SettingEngine) SetSCTPMaxMessageSize(maxMessageSize uint32) {
+	e.sctp.maxMessageSize = maxMessageSize
+}
+
+// SetSRTPWriteQueueSize sets how many RTP packets a RTPSender queues when they
+// are written before DTLS is connected. The queued packets are sent once the
+// SRTP session is available, so media written right after negotiation, like
+// the first keyframe, isn't lost. When the queue is full further packets are
+// dropped. By default no packets are queued, and packets written before DTLS
+// is connected are dropped. See also RTPSender.Ready.
+func (e *SettingEngine) SetSRTPWriteQueueSize(packets int) {
+	e.srtpWriteQueueSize = packets
+}
+
+// EnableSCTPZeroChecksum controls the SCTP zero checksum extension
+// (draft-ietf-tsvwg-sctp-zero-checksum). SCTP always runs over DTLS in WebRTC,
+// which already protects the packets, so computing the CRC32c of every packet
+// only costs CPU. The extension is offered in the SCTP INIT and only used when
+// the remote accepts it too, there is no SDP attribute for it. It is enabled
+// by default.
+func (e *SettingEngine) EnableSCTPZeroChecksum(isEnabled bool) {
+	e.sctp.disableZeroChecksum = !isEnabled
+}
+
 // SetDTLSCustomerCipherSuites allows the user to specify a list of DTLS CipherSuites.
 // This allow usage of Ciphers that are reserved for private usage.
 func (e *SettingEngine) SetDTLSCustomerCipherSuites(customCipherSuites func() []dtls.CipherSuite) {
 	e.dtls.customCipherSuites = customCipherSuites
 }
+
+// SetDTLSCustomDataMatcher lets a custom protocol share the DTLS connection
+// with SCTP. DTLS application data for which match returns true is routed to
+// DTLSTransport.CustomDataConn instead of SCTP. SCTP packets start with the
+// SCTP port of the sender, 5000 unless negotiated otherwise, so the messages
+// of the custom protocol should start with a prefix which can't be confused
+// with it. The remote has to route the messages the same way.
+func (e *SettingEngine) SetDTLSCustomDataMatcher(match func(b []byte) bool) {
+	e.dtls.customDataMatcher = match
+}
+
+// SetNegotiationNeededDebounce delays OnNegotiationNeeded until no change
+// requiring negotiation happened for the given duration, so that many
+// AddTrack/RemoveTrack calls are coalesced into one negotiation. Use
+// PeerConnection.FlushNegotiationNeeded to fire a pending event right away.
+// Leave this 0 to fire the event as soon as possible, which is the default.
+func (e *SettingEngine) SetNegotiationNeededDebounce(debounce time.Duration) {
+	e.negotiationNeededDebounce = debounce
+}
+
+// SetCodecNegotiationFailurePolicy controls what happens when a remote offer
+// contains a media section without any codec supported by the MediaEngine.
+// By default the negotiation fails, CodecNegotiationFailurePolicyReject only
+// rejects that media section, like browsers do for multi-section offers.
+func (e *SettingEngine) SetCodecNegotiationFailurePolicy(policy CodecNegotiationFailurePolicy) {
+	e.codecNegotiationFailurePolicy = policy
+}
+
+// SetDataChannelAuthenticationToken enables an authentication handshake on
+// every DataChannel. Right after a DataChannel opens each side sends the
+// HMAC-SHA256 of the channel label keyed with token as its first message, and
+// OnOpen only fires once the first message received from the remote matches.
+// Otherwise OnError fires with ErrDataChannelAuthenticationFailed and the
+// DataChannel is closed. Both peers must use the same token, usually a
+// per-session secret exchanged over signaling.
+//
+// Messages sent before OnOpen fires are treated as the authenticator by the
+// remote. Unordered and partially reliable DataChannels don't guarantee the
+// authenticator is received first. Detached DataChannels are not authenticated.
+func (e *SettingEngine) SetDataChannelAuthenticationToken(token []byte) {
+	e.dataChannelAuthToken = append([]byte{}, token...)
+}
+
+// SetDataChannelHighWaterMark bounds the data buffered by each DataChannel.
+// When its BufferedAmount is above highWaterMark, DataChannel.Send waits for
+// it to go down if block is true, and returns ErrDataChannelBufferFull
+// otherwise. Use DataChannel.SendContext to bound the wait. Leave
+// highWaterMark 0 to buffer without limit, which is the default.
+func (e *SettingEngine) SetDataChannelHighWaterMark(highWaterMark uint64, block bool) {
+	e.dataChannelBackpressure.highWaterMark = highWaterMark
+	e.dataChannelBackpressure.block = block
+}
+
+// SetConnectionQualityInterval sets how often PeerConnection.OnConnectionQuality
+// is invoked. The default is 2 seconds.
+func (e *SettingEngine) SetConnectionQualityInterval(interval time.Duration) {
+	e.connectionQualityInterval = interval
+}
+
+// SetConnectionQualityThresholds sets the scores used to rate a
+// ConnectionQualityReport. DefaultConnectionQualityThresholds are used otherwise.
+func (e *SettingEngine) SetConnectionQualityThresholds(thresholds ConnectionQualityThresholds) {
+	e.connectionQualityThresholds = &thresholds
+}
+
+// SetSDPLimits sets hard limits on the size and complexity of the remote
+// descriptions given to PeerConnection.SetRemoteDescription. Descriptions over
+// a limit are rejected before being parsed, as a guard against denial of
+// service on publicly reachable signaling endpoints. No limit is enforced by
+// default.
+func (e *SettingEngine) SetSDPLimits(limits SDPLimits) {
+	e.sdpLimits = limits
+}
+
+// SetTWCCFeedbackInterval sets how often the interceptor registered with
+// ConfigureTWCCSender sends Transport Wide Congestion Control feedback. A
+// shorter interval gives the bandwidth estimation of the remote sender more
+// frequent input. Leave this 0 for the default of 100 milliseconds.
+func (e *SettingEngine) SetTWCCFeedbackInterval(interval time.Duration) {
+	e.twccFeedbackInterval = interval
+}
+
+// SetSRTPReadBuffer sets the size of the read buffer of every incoming RTP
+// stream in bytes, and what happens to the packets received while it is full.
+// Drops are reported by PeerConnection.OnSRTPBufferDrop. Leave size 0 for the
+// default of 1 MB. The setting is ignored if BufferFactory is set.
+func (e *SettingEngine) SetSRTPReadBuffer(size int, policy SRTPBufferPolicy) {
+	e.srtpReadBuffer.enabled = true
+	e.srtpReadBuffer.size = size
+	e.srtpReadBuffer.policy = policy
+}
+
+// SetDTLSHandshakeTimeout sets how long the DTLS handshake may take before it
+// fails, 30 seconds by default. Along with SetDTLSRetransmissionInterval it
+// allows handshakes over high latency links. It replaces the context maker set
+// with SetDTLSConnectContextMaker.
+func (e *SettingEngine) SetDTLSHandshakeTimeout(timeout time.Duration) {
+	e.dtls.connectContextMaker = func() (context.Context, func()) {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+}
+
+// SetDTLSMTU sets the size in bytes at which DTLS handshake messages are
+// fragmented, for paths which drop or fragment large datagrams badly. Leave
+// this 0 for the default of 1200 bytes.
+func (e *SettingEngine) SetDTLSMTU(mtu int) {
+	e.dtls.mtu = mtu
+}
@@ -9,10 +9,12 @@ package webrtc
 import (
 	"context"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pion/dtls/v2/pkg/crypto/elliptic"
+	"github.com/pion/ice/v3"
 	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -146,6 +148,25 @@ func TestSettingEngine_SetICETCP(t *testing.T) {
 	assert.Equal(t, tcpMux, settingEngine.iceTCPMux)
 }
 
+func TestSettingEngine_EnableICETCP(t *testing.T) {
+	settingEngine := SettingEngine{}
+	settingEngine.SetNetworkTypes([]NetworkType{NetworkTypeUDP4, NetworkTypeTCP4})
+
+	tcpMux, err := settingEngine.EnableICETCP(0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, tcpMux, settingEngine.iceTCPMux)
+	assert.Equal(t, []NetworkType{NetworkTypeUDP4, NetworkTypeTCP4, NetworkTypeTCP6}, settingEngine.candidates.ICENetworkTypes)
+	assert.NoError(t, tcpMux.Close())
+
+	settingEngine = SettingEngine{}
+	tcpMux, err = settingEngine.EnableICETCP(0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []NetworkType{NetworkTypeUDP4, NetworkTypeUDP6, NetworkTypeTCP4, NetworkTypeTCP6}, settingEngine.candidates.ICENetworkTypes)
+	assert.NoError(t, tcpMux.Close())
+}
+
 func TestSettingEngine_SetDisableMediaEngineCopy(t *testing.T) {
 	t.Run("Copy", func(t *testing.T) {
 		m := &MediaEngine{}
@@ -261,6 +282,27 @@ func TestSetDTLSHandShakeTimeout(*testing.T) {
 	})
 }
 
+func TestSetDTLSHandshakeTimeout(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.dtls.connectContextMaker)
+
+	s.SetDTLSHandshakeTimeout(time.Minute)
+	ctx, cancel := s.dtls.connectContextMaker()
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
+
+func TestSetDTLSMTU(t *testing.T) {
+	s := SettingEngine{}
+	assert.Equal(t, 0, s.dtls.mtu)
+
+	s.SetDTLSMTU(576)
+	assert.Equal(t, 576, s.dtls.mtu)
+}
+
 func TestSetSCTPMaxReceiverBufferSize(t *testing.T) {
 	s := SettingEngine{}
 	assert.Equal(t, uint32(0), s.sctp.maxReceiveBufferSize)
@@ -269,3 +311,96 @@ func TestSetSCTPMaxReceiverBufferSize(t *testing.T) {
 	s.SetSCTPMaxReceiveBufferSize(expSize)
 	assert.Equal(t, expSize, s.sctp.maxReceiveBufferSize)
 }
+
+func TestEnableSCTPZeroChecksum(t *testing.T) {
+	s := SettingEngine{}
+	assert.False(t, s.sctp.disableZeroChecksum)
+
+	s.EnableSCTPZeroChecksum(false)
+	assert.True(t, s.sctp.disableZeroChecksum)
+
+	s.EnableSCTPZeroChecksum(true)
+	assert.False(t, s.sctp.disableZeroChecksum)
+}
+
+func TestSetICEUsernameFragmentPrefix(t *testing.T) {
+	s := SettingEngine{}
+
+	ufrag, err := s.getICEUsernameFragment()
+	assert.NoError(t, err)
+	assert.Empty(t, ufrag)
+
+	s.SetICEUsernameFragmentPrefix("workerA")
+	ufrag, err = s.getICEUsernameFragment()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ufrag, "workerA"))
+	assert.Len(t, ufrag, len("workerA")+iceUsernameFragmentRandomLength)
+
+	s.SetICECredentials("static", "password")
+	ufrag, err = s.getICEUsernameFragment()
+	assert.NoError(t, err)
+	assert.Equal(t, "static", ufrag)
+}
+
+func TestSetCandidateFilter(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	assert.True(t, s.allowsCandidate(ICECandidate{Typ: ICECandidateTypeHost}))
+
+	s.SetCandidateFilter(func(c ICECandidate) bool {
+		return c.Typ != ICECandidateTypeHost
+	})
+	assert.False(t, s.allowsCandidate(ICECandidate{Typ: ICECandidateTypeHost}))
+	assert.True(t, s.allowsCandidate(ICECandidate{Typ: ICECandidateTypeRelay}))
+
+	pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pc.OnICECandidate(func(c *ICECandidate) {
+		assert.Nil(t, c)
+	})
+
+	_, err = pc.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	gatherComplete := GatheringCompletePromise(pc)
+	assert.NoError(t, pc.SetLocalDescription(offer))
+	<-gatherComplete
+
+	assert.NotContains(t, pc.LocalDescription().SDP, "a=candidate:")
+	assert.NoError(t, pc.Close())
+}
+
+func TestSetICEMulticastDNSPolicy(t *testing.T) {
+	testCases := []struct {
+		obfuscate, resolve bool
+		expectedMode       ice.MulticastDNSMode
+	}{
+		{false, false, ice.MulticastDNSModeDisabled},
+		{false, true, ice.MulticastDNSModeQueryOnly},
+		{true, false, ice.MulticastDNSModeQueryAndGather},
+		{true, true, ice.MulticastDNSModeQueryAndGather},
+	}
+
+	mDNSCandidate := ICECandidate{Typ: ICECandidateTypeHost, Address: "6e3ea8c9-ab43-4c36-a5a3-9af4b4d0a3a4.local"}
+	for i, testCase := range testCases {
+		s := SettingEngine{}
+		s.SetICEMulticastDNSPolicy(testCase.obfuscate, testCase.resolve)
+		assert.Equal(t, testCase.expectedMode, s.candidates.MulticastDNSMode, "testCase: %d %v", i, testCase)
+
+		// Remote mDNS candidates are dropped unless they are resolved
+		assert.Equal(t, testCase.resolve, s.allowsRemoteCandidate(mDNSCandidate), "testCase: %d %v", i, testCase)
+		assert.True(t, s.allowsRemoteCandidate(ICECandidate{Typ: ICECandidateTypeHost, Address: "192.168.1.2"}), "testCase: %d %v", i, testCase)
+
+		// Local mDNS candidates are always signaled
+		assert.True(t, s.allowsCandidate(mDNSCandidate), "testCase: %d %v", i, testCase)
+	}
+}
@@ -25,6 +25,13 @@ type srtpWriterFuture struct {
 	rtpWriteStream atomic.Value // *srtp.WriteStreamSRTP
 	mu             sync.Mutex
 	closed         bool
+
+	// Packets written before the SRTP Session is available are queued up to
+	// queueSize, and sent once it is. Without a queue they are dropped.
+	queueSize    int
+	queueMu      sync.Mutex
+	queue        [][]byte
+	queuePending int32 // atomic, 1 while queued packets wait to be flushed
 }
 
 func (s *srtpWriterFuture) init(returnWhenNoSRTP bool) error {
@@ -76,6 +83,12 @@ func (s *srtpWriterFuture) init(returnWhenNoSRTP bool) error {
 	return nil
 }
 
+// ready returns true once the SRTP Session is available, packets written
+// before are dropped
+func (s *srtpWriterFuture) ready() bool {
+	return s.rtpWriteStream.Load() != nil
+}
+
 func (s *srtpWriterFuture) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -117,25 +130,128 @@ func (s *srtpWriterFuture) SetReadDeadline(t time.Time) error {
 }
 
 func (s *srtpWriterFuture) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
-	if value, ok := s.rtpWriteStream.Load().(*srtp.WriteStreamSRTP); ok {
-		return value.WriteRTP(header, payload)
+	s.rtpSender.transport.packetMirror.mirrorRTP(header, payload)
+
+	n, _, err := s.writeRTP(header, payload)
+	return n, err
+}
+
+// writeRTP is WriteRTP, which also returns if the packet was queued until the
+// SRTP Session is available
+func (s *srtpWriterFuture) writeRTP(header *rtp.Header, payload []byte) (int, bool, error) {
+	if atomic.LoadInt32(&s.queuePending) == 0 {
+		if value, ok := s.rtpWriteStream.Load().(*srtp.WriteStreamSRTP); ok {
+			n, err := value.WriteRTP(header, payload)
+			return n, false, err
+		}
+	}
+
+	if s.queueSize > 0 {
+		return s.writeOrQueue(func() ([]byte, error) {
+			return (&rtp.Packet{Header: *header, Payload: payload}).Marshal()
+		})
 	}
 
 	if err := s.init(true); err != nil || s.rtpWriteStream.Load() == nil {
-		return 0, err
+		return 0, false, err
 	}
 
-	return s.WriteRTP(header, payload)
+	return s.writeRTP(header, payload)
 }
 
 func (s *srtpWriterFuture) Write(b []byte) (int, error) {
-	if value, ok := s.rtpWriteStream.Load().(*srtp.WriteStreamSRTP); ok {
-		return value.Write(b)
+	s.rtpSender.transport.packetMirror.mirror(true, PacketMirrorPointRTP, b)
+
+	return s.write(b)
+}
+
+func (s *srtpWriterFuture) write(b []byte) (int, error) {
+	if atomic.LoadInt32(&s.queuePending) == 0 {
+		if value, ok := s.rtpWriteStream.Load().(*srtp.WriteStreamSRTP); ok {
+			return value.Write(b)
+		}
+	}
+
+	if s.queueSize > 0 {
+		n, _, err := s.writeOrQueue(func() ([]byte, error) {
+			return append([]byte{}, b...), nil
+		})
+		return n, err
 	}
 
 	if err := s.init(true); err != nil || s.rtpWriteStream.Load() == nil {
 		return 0, err
 	}
 
-	return s.Write(b)
+	return s.write(b)
+}
+
+// writeOrQueue writes the packet returned by marshal if the SRTP Session is
+// available and no packets are queued, and queues it otherwise. A full queue
+// drops the packet, so the oldest packets, like the first keyframe, are kept.
+func (s *srtpWriterFuture) writeOrQueue(marshal func() ([]byte, error)) (int, bool, error) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	if atomic.LoadInt32(&s.queuePending) == 0 {
+		if s.rtpWriteStream.Load() == nil {
+			if err := s.init(true); err != nil {
+				return 0, false, err
+			}
+		}
+
+		if value, ok := s.rtpWriteStream.Load().(*srtp.WriteStreamSRTP); ok {
+			b, err := marshal()
+			if err != nil {
+				return 0, false, err
+			}
+
+			n, err := value.Write(b)
+			return n, false, err
+		}
+	}
+
+	if len(s.queue) >= s.queueSize {
+		return 0, false, nil
+	}
+
+	b, err := marshal()
+	if err != nil {
+		return 0, false, err
+	}
+	s.queue = append(s.queue, b)
+
+	if atomic.CompareAndSwapInt32(&s.queuePending, 0, 1) {
+		go s.flushQueue()
+	}
+
+	return 0, true, nil
+}
+
+// flushQueue waits for the SRTP Session and sends the queued packets. Writes
+// keep being queued until the queue drained, so the packets stay in order.
+func (s *srtpWriterFuture) flushQueue() {
+	var err error
+	if s.rtpWriteStream.Load() == nil {
+		err = s.init(false)
+	}
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	defer func() {
+		s.queue = nil
+		atomic.StoreInt32(&s.queuePending, 0)
+	}()
+
+	value, ok := s.rtpWriteStream.Load().(*srtp.WriteStreamSRTP)
+	if err != nil || !ok {
+		return
+	}
+
+	for _, b := range s.queue {
+		if _, err := value.Write(b); err != nil {
+			s.rtpSender.transport.log.Warnf("Failed to send queued RTP packet: %v", err)
+			return
+		}
+	}
 }
@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/v3/deadline"
+	"github.com/pion/transport/v3/packetio"
+)
+
+// SRTPBufferPolicy decides what the read buffer of a SRTP stream does with a
+// packet received while it is full, see SettingEngine.SetSRTPReadBuffer
+type SRTPBufferPolicy int
+
+const (
+	// SRTPBufferPolicyDropNewest drops the received packet. This is the
+	// default.
+	SRTPBufferPolicyDropNewest SRTPBufferPolicy = iota
+
+	// SRTPBufferPolicyDropOldest drops the oldest buffered packets until the
+	// received packet fits.
+	SRTPBufferPolicyDropOldest
+
+	// SRTPBufferPolicyBlock waits until the application read enough packets
+	// for the received packet to fit. This blocks the SRTP session, and so the
+	// reception of every track, until then.
+	SRTPBufferPolicyBlock
+)
+
+// This is done this way because of a linter.
+const (
+	srtpBufferPolicyDropNewestStr = "drop-newest"
+	srtpBufferPolicyDropOldestStr = "drop-oldest"
+	srtpBufferPolicyBlockStr      = "block"
+)
+
+func (p SRTPBufferPolicy) String() string {
+	switch p {
+	case SRTPBufferPolicyDropNewest:
+		return srtpBufferPolicyDropNewestStr
+	case SRTPBufferPolicyDropOldest:
+		return srtpBufferPolicyDropOldestStr
+	case SRTPBufferPolicyBlock:
+		return srtpBufferPolicyBlockStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// SRTPBufferDropEvent reports RTP packets dropped by the read buffer of a
+// SRTP stream because it was full
+type SRTPBufferDropEvent struct {
+	// Track is nil if the SSRC doesn't belong to a TrackRemote yet
+	Track *TrackRemote
+	SSRC  SSRC
+
+	// Dropped is the number of packets dropped at once, and TotalDropped the
+	// number of packets dropped by the buffer since it was created
+	Dropped      uint64
+	TotalDropped uint64
+}
+
+// OnSRTPBufferDrop sets an event handler which is invoked when the read buffer
+// of an incoming RTP stream dropped packets, once SettingEngine.SetSRTPReadBuffer
+// is set. The handler is invoked on the goroutine receiving the packets, so it
+// must return quickly.
+func (pc *PeerConnection) OnSRTPBufferDrop(f func(SRTPBufferDropEvent)) {
+	pc.onSRTPBufferDropHandler.Store(f)
+}
+
+func (pc *PeerConnection) onSRTPBufferDrop(ssrc SSRC, dropped, totalDropped uint64) {
+	handler, ok := pc.onSRTPBufferDropHandler.Load().(func(SRTPBufferDropEvent))
+	if !ok || handler == nil {
+		return
+	}
+
+	event := SRTPBufferDropEvent{SSRC: ssrc, Dropped: dropped, TotalDropped: totalDropped}
+	for _, receiver := range pc.GetReceivers() {
+		for _, track := range receiver.Tracks() {
+			if track.SSRC() == ssrc {
+				event.Track = track
+			}
+		}
+	}
+
+	handler(event)
+}
+
+// srtpBufferTimeoutError is returned by reads of a srtpReadBuffer once their
+// deadline passed
+type srtpBufferTimeoutError struct{}
+
+func (srtpBufferTimeoutError) Error() string   { return packetio.ErrTimeout.Error() }
+func (srtpBufferTimeoutError) Unwrap() error   { return packetio.ErrTimeout }
+func (srtpBufferTimeoutError) Timeout() bool   { return true }
+func (srtpBufferTimeoutError) Temporary() bool { return true }
+
+// srtpReadBuffer is the read buffer of an incoming RTP stream with a
+// SRTPBufferPolicy. Like packetio.Buffer, every packet counts 2 more bytes
+// towards the limit.
+type srtpReadBuffer struct {
+	policy SRTPBufferPolicy
+	limit  int
+	onDrop func(dropped, totalDropped uint64)
+
+	mu      sync.Mutex
+	packets [][]byte
+	size    int
+	dropped uint64
+	closed  bool
+
+	// readable and writable are signaled when a packet was queued or read
+	readable, writable chan struct{}
+	done               chan struct{}
+
+	readDeadline *deadline.Deadline
+}
+
+func newSRTPReadBuffer(limit int, policy SRTPBufferPolicy, onDrop func(dropped, totalDropped uint64)) *srtpReadBuffer {
+	return &srtpReadBuffer{
+		policy:       policy,
+		limit:        limit,
+		onDrop:       onDrop,
+		readable:     make(chan struct{}, 1),
+		writable:     make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		readDeadline: deadline.New(),
+	}
+}
+
+func signalSRTPReadBuffer(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Write queues a copy of packet, or applies the policy if the buffer is full
+func (b *srtpReadBuffer) Write(packet []byte) (int, error) {
+	packetSize := len(packet) + 2
+
+	b.mu.Lock()
+	for {
+		if b.closed {
+			b.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+
+		if b.size+packetSize <= b.limit {
+			break
+		}
+
+		switch {
+		// A packet larger than the buffer is dropped whatever the policy
+		case packetSize > b.limit || b.policy == SRTPBufferPolicyDropNewest:
+			b.dropLocked(1)
+			return 0, packetio.ErrFull
+		case b.policy == SRTPBufferPolicyDropOldest:
+			dropped := 0
+			for b.size+packetSize > b.limit {
+				b.size -= len(b.packets[dropped]) + 2
+				b.packets[dropped] = nil
+				dropped++
+			}
+			b.packets = b.packets[dropped:]
+			b.dropLocked(dropped)
+			b.mu.Lock()
+		default:
+			b.mu.Unlock()
+			select {
+			case <-b.writable:
+			case <-b.done:
+			}
+			b.mu.Lock()
+		}
+	}
+
+	b.packets = append(b.packets, append([]byte{}, packet...))
+	b.size += packetSize
+	b.mu.Unlock()
+
+	signalSRTPReadBuffer(b.readable)
+
+	return len(packet), nil
+}
+
+// dropLocked counts dropped packets and reports them. b.mu must be held, it
+// is released before onDrop is invoked.
+func (b *srtpReadBuffer) dropLocked(dropped int) {
+	b.dropped += uint64(dropped)
+	total := b.dropped
+	b.mu.Unlock()
+
+	if b.onDrop != nil {
+		b.onDrop(uint64(dropped), total)
+	}
+}
+
+// Read reads the oldest packet into packet. It blocks until a packet is
+// queued, the buffer is closed or the read deadline passed.
+func (b *srtpReadBuffer) Read(packet []byte) (int, error) {
+	select {
+	case <-b.readDeadline.Done():
+		return 0, srtpBufferTimeoutError{}
+	default:
+	}
+
+	for {
+		b.mu.Lock()
+		if len(b.packets) != 0 {
+			queued := b.packets[0]
+			b.packets[0] = nil
+			b.packets = b.packets[1:]
+			b.size -= len(queued) + 2
+			if len(b.packets) != 0 {
+				signalSRTPReadBuffer(b.readable)
+			}
+			b.mu.Unlock()
+
+			signalSRTPReadBuffer(b.writable)
+
+			n := copy(packet, queued)
+			if n < len(queued) {
+				return n, io.ErrShortBuffer
+			}
+			return n, nil
+		}
+
+		if b.closed {
+			b.mu.Unlock()
+			return 0, io.EOF
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.readable:
+		case <-b.done:
+		case <-b.readDeadline.Done():
+			return 0, srtpBufferTimeoutError{}
+		}
+	}
+}
+
+// Close unblocks pending reads and writes. Queued packets can still be read,
+// Read returns io.EOF once the buffer is empty.
+func (b *srtpReadBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+
+	return nil
+}
+
+// Size returns the size of the queued packets
+func (b *srtpReadBuffer) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.size
+}
+
+// SetReadDeadline sets the deadline of pending and future reads
+func (b *srtpReadBuffer) SetReadDeadline(t time.Time) error {
+	b.readDeadline.Set(t)
+
+	return nil
+}
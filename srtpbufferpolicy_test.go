@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/packetio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSRTPBufferPolicy_String(t *testing.T) {
+	testCases := []struct {
+		policy         SRTPBufferPolicy
+		expectedString string
+	}{
+		{SRTPBufferPolicyDropNewest, "drop-newest"},
+		{SRTPBufferPolicyDropOldest, "drop-oldest"},
+		{SRTPBufferPolicyBlock, "block"},
+		{SRTPBufferPolicy(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.policy.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestSRTPReadBuffer_Policies(t *testing.T) {
+	// Every packet is buffered with a 2 bytes header, so 3 packets fit
+	const limit = 300
+
+	testCases := []struct {
+		policy          SRTPBufferPolicy
+		expectedErr     error
+		expectedFirst   byte
+		expectedDropped uint64
+	}{
+		{SRTPBufferPolicyDropNewest, packetio.ErrFull, 0, 1},
+		{SRTPBufferPolicyDropOldest, nil, 1, 1},
+	}
+
+	for i, testCase := range testCases {
+		var dropped, totalDropped uint64
+		buffer := newSRTPReadBuffer(limit, testCase.policy, func(d, total uint64) {
+			dropped, totalDropped = d, total
+		})
+
+		for j := byte(0); j < 3; j++ {
+			packet := make([]byte, 98)
+			packet[0] = j
+			_, err := buffer.Write(packet)
+			assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		}
+		assert.Equal(t, limit, buffer.Size(), "testCase: %d %v", i, testCase)
+
+		packet := make([]byte, 98)
+		packet[0] = 3
+		_, err := buffer.Write(packet)
+		assert.Equal(t, testCase.expectedErr, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedDropped, dropped, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedDropped, totalDropped, "testCase: %d %v", i, testCase)
+
+		read := make([]byte, 100)
+		n, err := buffer.Read(read)
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, 98, n, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedFirst, read[0], "testCase: %d %v", i, testCase)
+
+		assert.NoError(t, buffer.Close())
+	}
+}
+
+func TestSRTPReadBuffer_Block(t *testing.T) {
+	buffer := newSRTPReadBuffer(100, SRTPBufferPolicyBlock, func(uint64, uint64) {
+		assert.Fail(t, "blocking buffer dropped a packet")
+	})
+
+	_, err := buffer.Write(make([]byte, 98))
+	assert.NoError(t, err)
+
+	written := make(chan error)
+	go func() {
+		_, writeErr := buffer.Write(make([]byte, 98))
+		written <- writeErr
+	}()
+
+	select {
+	case <-written:
+		assert.Fail(t, "Write didn't block on a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = buffer.Read(make([]byte, 100))
+	assert.NoError(t, err)
+	assert.NoError(t, <-written)
+
+	// Pending writes fail once closed, buffered packets are still read
+	go func() {
+		_, writeErr := buffer.Write(make([]byte, 98))
+		written <- writeErr
+	}()
+	assert.NoError(t, buffer.Close())
+	assert.ErrorIs(t, <-written, io.ErrClosedPipe)
+
+	_, err = buffer.Read(make([]byte, 100))
+	assert.NoError(t, err)
+	_, err = buffer.Read(make([]byte, 100))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestSRTPReadBuffer_Read(t *testing.T) {
+	buffer := newSRTPReadBuffer(srtpBufferSize, SRTPBufferPolicyDropNewest, nil)
+
+	// Packets larger than the buffer are always dropped
+	_, err := buffer.Write(make([]byte, srtpBufferSize))
+	assert.ErrorIs(t, err, packetio.ErrFull)
+
+	_, err = buffer.Write([]byte{1, 2, 3})
+	assert.NoError(t, err)
+
+	n, err := buffer.Read(make([]byte, 2))
+	assert.ErrorIs(t, err, io.ErrShortBuffer)
+	assert.Equal(t, 2, n)
+
+	assert.NoError(t, buffer.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, err = buffer.Read(make([]byte, 3))
+	assert.ErrorIs(t, err, packetio.ErrTimeout)
+	netErr, ok := err.(interface{ Timeout() bool })
+	assert.True(t, ok)
+	assert.True(t, netErr.Timeout())
+
+	assert.NoError(t, buffer.Close())
+}
+
+func TestSettingEngine_SetSRTPReadBuffer(t *testing.T) {
+	s := SettingEngine{}
+	s.SetSRTPReadBuffer(500, SRTPBufferPolicyDropOldest)
+
+	drops := make(chan SSRC, 1)
+	factory := s.srtpBufferFactory(nil, func(ssrc SSRC, dropped, totalDropped uint64) {
+		drops <- ssrc
+	})
+
+	_, ok := factory(packetio.RTCPBufferPacket, 5000).(*packetio.Buffer)
+	assert.True(t, ok)
+
+	buffer, ok := factory(packetio.RTPBufferPacket, 5000).(*srtpReadBuffer)
+	assert.True(t, ok)
+	assert.Equal(t, 500, buffer.limit)
+
+	for i := 0; i < 6; i++ {
+		_, err := buffer.Write(make([]byte, 98))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, SSRC(5000), <-drops)
+
+	// Watermarks are reported for the buffers of the policy as well
+	s.SetTrackRemoteBufferWatermarks(300, 100)
+	_, ok = s.srtpBufferFactory(nil, nil)(packetio.RTPBufferPacket, 5000).(*watermarkBuffer)
+	assert.True(t, ok)
+
+	// A BufferFactory takes precedence
+	s.BufferFactory = func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+		return packetio.NewBuffer()
+	}
+	_, ok = s.srtpBufferFactory(nil, nil)(packetio.RTPBufferPacket, 5000).(*srtpReadBuffer)
+	assert.False(t, ok)
+}
@@ -419,6 +419,11 @@ type InboundRTPStreamStats struct {
 	// these numbers are not expected to match the numbers seen on sending. Not all
 	// OSes make this information available.
 	PerDSCPPacketsReceived map[string]uint32 `json:"perDscpPacketsReceived"`
+
+	// ClockDriftPPM is a non-standard stat of how fast the RTP clock of the sender
+	// runs compared to the local clock, in parts per million. It is positive if the
+	// sender's clock runs fast, and zero until enough packets were read to estimate it.
+	ClockDriftPPM float64 `json:"clockDriftPpm,omitempty"`
 }
 
 func (s InboundRTPStreamStats) statsMarker() {}
@@ -531,6 +536,10 @@ type OutboundRTPStreamStats struct {
 	// object sending this stream.
 	SenderID string `json:"senderId"`
 
+	// MediaSourceID is the stats ID used to look up the AudioSourceStats or VideoSourceStats
+	// object of the track currently attached to the sender of this stream.
+	MediaSourceID string `json:"mediaSourceId"`
+
 	// RemoteID is used for looking up the remote RemoteInboundRTPStreamStats object
 	// for the same SSRC.
 	RemoteID string `json:"remoteId"`
@@ -1077,6 +1086,10 @@ type PeerConnectionStats struct {
 	// DataChannelsAccepted represents the number of unique DataChannels signaled
 	// in a "datachannel" event on the PeerConnection.
 	DataChannelsAccepted uint32 `json:"dataChannelsAccepted"`
+
+	// ClockDriftPPM is a non-standard stat with the average ClockDriftPPM of the
+	// inbound RTP streams that have an estimate, zero if none has.
+	ClockDriftPPM float64 `json:"clockDriftPpm,omitempty"`
 }
 
 func (s PeerConnectionStats) statsMarker() {}
@@ -1131,6 +1144,11 @@ type DataChannelStats struct {
 	// BytesReceived represents the total number of bytes received on this
 	// datachannel not including headers or padding.
 	BytesReceived uint64 `json:"bytesReceived"`
+
+	// BufferedAmount is the "bufferedAmount" value of the DataChannel object,
+	// the number of bytes of application data queued to be sent. This is not
+	// part of the standard stats.
+	BufferedAmount uint64 `json:"bufferedAmount"`
 }
 
 func (s DataChannelStats) statsMarker() {}
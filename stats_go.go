@@ -6,6 +6,10 @@
 
 package webrtc
 
+import (
+	"sort"
+)
+
 // GetConnectionStats is a helper method to return the associated stats for a given PeerConnection
 func (r StatsReport) GetConnectionStats(conn *PeerConnection) (PeerConnectionStats, bool) {
 	statsID := conn.getStatsID()
@@ -95,3 +99,125 @@ func (r StatsReport) GetCodecStats(c *RTPCodecParameters) (CodecStats, bool) {
 	}
 	return codecStats, true
 }
+
+// RTPSenderStats holds the stats of the RTP streams sent by an RTPSender,
+// ordered by SSRC. There is one OutboundRTPStreamStats per encoding, and a
+// RemoteInboundRTPStreamStats once the remote reported on the encoding.
+type RTPSenderStats struct {
+	OutboundRTPStreams      []OutboundRTPStreamStats
+	RemoteInboundRTPStreams []RemoteInboundRTPStreamStats
+}
+
+// RTPReceiverStats holds the stats of the RTP streams received by an
+// RTPReceiver, ordered by SSRC. There is one InboundRTPStreamStats per track,
+// and a RemoteOutboundRTPStreamStats once the remote sent a Sender Report.
+type RTPReceiverStats struct {
+	InboundRTPStreams        []InboundRTPStreamStats
+	RemoteOutboundRTPStreams []RemoteOutboundRTPStreamStats
+}
+
+// GetSenderStats is a helper method to return the stats of the RTP streams
+// sent by sender, without collecting the whole StatsReport. It returns false
+// if sender doesn't belong to the PeerConnection.
+func (pc *PeerConnection) GetSenderStats(sender *RTPSender) (RTPSenderStats, bool) {
+	collector := newStatsReportCollector()
+
+	pc.mu.Lock()
+	found := false
+	for _, transceiver := range pc.rtpTransceivers {
+		if sender != nil && transceiver.Sender() == sender {
+			found = true
+			break
+		}
+	}
+	if found {
+		statsGetter, _ := lookupStats(pc.statsID)
+		sender.collectStats(collector, statsGetter)
+	}
+	pc.mu.Unlock()
+
+	if !found {
+		return RTPSenderStats{}, false
+	}
+
+	var senderStats RTPSenderStats
+	for _, s := range collector.Ready() {
+		switch stats := s.(type) {
+		case OutboundRTPStreamStats:
+			senderStats.OutboundRTPStreams = append(senderStats.OutboundRTPStreams, stats)
+		case RemoteInboundRTPStreamStats:
+			senderStats.RemoteInboundRTPStreams = append(senderStats.RemoteInboundRTPStreams, stats)
+		}
+	}
+
+	sort.Slice(senderStats.OutboundRTPStreams, func(i, j int) bool {
+		return senderStats.OutboundRTPStreams[i].SSRC < senderStats.OutboundRTPStreams[j].SSRC
+	})
+	sort.Slice(senderStats.RemoteInboundRTPStreams, func(i, j int) bool {
+		return senderStats.RemoteInboundRTPStreams[i].SSRC < senderStats.RemoteInboundRTPStreams[j].SSRC
+	})
+	return senderStats, true
+}
+
+// GetReceiverStats is a helper method to return the stats of the RTP streams
+// received by receiver, without collecting the whole StatsReport. It returns
+// false if receiver doesn't belong to the PeerConnection.
+func (pc *PeerConnection) GetReceiverStats(receiver *RTPReceiver) (RTPReceiverStats, bool) {
+	collector := newStatsReportCollector()
+
+	pc.mu.Lock()
+	found := false
+	for _, transceiver := range pc.rtpTransceivers {
+		if receiver != nil && transceiver.Receiver() == receiver {
+			found = true
+			break
+		}
+	}
+	if found {
+		statsGetter, _ := lookupStats(pc.statsID)
+		receiver.collectStats(collector, statsGetter)
+	}
+	pc.mu.Unlock()
+
+	if !found {
+		return RTPReceiverStats{}, false
+	}
+
+	var receiverStats RTPReceiverStats
+	for _, s := range collector.Ready() {
+		switch stats := s.(type) {
+		case InboundRTPStreamStats:
+			receiverStats.InboundRTPStreams = append(receiverStats.InboundRTPStreams, stats)
+		case RemoteOutboundRTPStreamStats:
+			receiverStats.RemoteOutboundRTPStreams = append(receiverStats.RemoteOutboundRTPStreams, stats)
+		}
+	}
+
+	sort.Slice(receiverStats.InboundRTPStreams, func(i, j int) bool {
+		return receiverStats.InboundRTPStreams[i].SSRC < receiverStats.InboundRTPStreams[j].SSRC
+	})
+	sort.Slice(receiverStats.RemoteOutboundRTPStreams, func(i, j int) bool {
+		return receiverStats.RemoteOutboundRTPStreams[i].SSRC < receiverStats.RemoteOutboundRTPStreams[j].SSRC
+	})
+	return receiverStats, true
+}
+
+// GetTransportStats is a helper method to return the stats of the transport
+// of the PeerConnection, without collecting the whole StatsReport
+func (pc *PeerConnection) GetTransportStats() TransportStats {
+	collector := newStatsReportCollector()
+
+	pc.mu.Lock()
+	iceTransport := pc.iceTransport
+	if iceTransport != nil {
+		iceTransport.collectStats(collector)
+	}
+	pc.mu.Unlock()
+
+	if iceTransport == nil {
+		return TransportStats{}
+	}
+
+	transportStats, _ := collector.Ready()[iceTransport.getStatsID()].(TransportStats)
+	return transportStats
+}
@@ -181,6 +181,7 @@ func getStatsSamples() []statSample {
 		BytesDiscardedOnSend:    10,
 		TrackID:                 "d57dbc4b-484b-4b40-9088-d3150e3a2010",
 		SenderID:                "S01",
+		MediaSourceID:           "SO1",
 		RemoteID:                "ROA2184088143",
 		LastPacketSentTimestamp: 11,
 		TargetBitrate:           12,
@@ -219,6 +220,7 @@ func getStatsSamples() []statSample {
   "bytesDiscardedOnSend": 10,
   "trackId": "d57dbc4b-484b-4b40-9088-d3150e3a2010",
   "senderId": "S01",
+  "mediaSourceId": "SO1",
   "remoteId": "ROA2184088143",
   "lastPacketSentTimestamp": 11,
   "targetBitrate": 12,
@@ -478,6 +480,7 @@ func getStatsSamples() []statSample {
 		BytesSent:             16,
 		MessagesReceived:      2,
 		BytesReceived:         20,
+		BufferedAmount:        32,
 	}
 	dataChannelStatsJSON := `
 {
@@ -492,7 +495,8 @@ func getStatsSamples() []statSample {
   "messagesSent": 1,
   "bytesSent": 16,
   "messagesReceived": 2,
-  "bytesReceived": 20
+  "bytesReceived": 20,
+  "bufferedAmount": 32
 }
 `
 	streamStats := MediaStreamStats{
@@ -1263,6 +1267,12 @@ func TestPeerConnection_GetStats(t *testing.T) {
 	assert.Equal(t, DataChannelStateOpen, dcStatsOffer.State)
 	assert.Equal(t, uint32(1), dcStatsOffer.MessagesSent)
 	assert.Equal(t, uint64(len(msg)), dcStatsOffer.BytesSent)
+	assert.Equal(t, sctpTransportStatsID, dcStatsOffer.TransportID)
+	directStatsOffer := offerDC.GetStats()
+	assert.Equal(t, dcStatsOffer.ID, directStatsOffer.ID)
+	assert.Equal(t, dcStatsOffer.MessagesSent, directStatsOffer.MessagesSent)
+	assert.Equal(t, dcStatsOffer.BytesSent, directStatsOffer.BytesSent)
+	assert.Equal(t, offerDC.BufferedAmount(), directStatsOffer.BufferedAmount)
 	assert.NotEmpty(t, findLocalCandidateStats(reportPCOffer))
 	assert.NotEmpty(t, findRemoteCandidateStats(reportPCOffer))
 	assert.NotEmpty(t, findCandidatePairStats(t, reportPCOffer))
@@ -1280,14 +1290,21 @@ func TestPeerConnection_GetStats(t *testing.T) {
 	assert.NotEmpty(t, findRemoteCandidateStats(reportPCAnswer))
 	assert.NotEmpty(t, findCandidatePairStats(t, reportPCAnswer))
 	assert.NoError(t, err)
-	for i := range offerPC.api.mediaEngine.videoCodecs {
-		codecStat := getCodecStats(t, reportPCOffer, &(offerPC.api.mediaEngine.videoCodecs[i]))
-		assert.NotEmpty(t, codecStat)
+	negotiatedCodecs := append(append([]RTPCodecParameters{}, offerPC.api.mediaEngine.negotiatedVideoCodecs...), offerPC.api.mediaEngine.negotiatedAudioCodecs...)
+	assert.NotEmpty(t, negotiatedCodecs)
+	for i := range negotiatedCodecs {
+		codecStat := getCodecStats(t, reportPCOffer, &negotiatedCodecs[i])
+		assert.Equal(t, codecStatsID(iceTransportStatsID, negotiatedCodecs[i]), codecStat.ID)
+		assert.Equal(t, negotiatedCodecs[i].PayloadType, codecStat.PayloadType)
 	}
-	for i := range offerPC.api.mediaEngine.audioCodecs {
-		codecStat := getCodecStats(t, reportPCOffer, &(offerPC.api.mediaEngine.audioCodecs[i]))
-		assert.NotEmpty(t, codecStat)
+	// Only the negotiated codecs are reported
+	codecStatsCount := 0
+	for _, s := range reportPCOffer {
+		if _, ok := s.(CodecStats); ok {
+			codecStatsCount++
+		}
 	}
+	assert.Equal(t, len(negotiatedCodecs), codecStatsCount)
 
 	// Close answer DC now
 	dcWait = sync.WaitGroup{}
@@ -1337,6 +1354,108 @@ func TestPeerConnection_GetStats(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+func TestPeerConnection_GetStats_RTPStreams(t *testing.T) {
+	offerPC, answerPC, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	sender, err := offerPC.AddTrack(track)
+	require.NoError(t, err)
+
+	// RTCP is only accounted for when it is read
+	go func() {
+		for {
+			if _, _, readErr := sender.ReadRTCP(); readErr != nil {
+				return
+			}
+		}
+	}()
+
+	answerPC.OnTrack(func(trackRemote *TrackRemote, receiver *RTPReceiver) {
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			if _, _, readErr := trackRemote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	done := make(chan struct{})
+	go sendVideoUntilDone(done, t, []*TrackLocalStaticSample{track})
+
+	require.NoError(t, signalPair(offerPC, answerPC))
+
+	var (
+		outbound       OutboundRTPStreamStats
+		remoteInbound  RemoteInboundRTPStreamStats
+		inbound        InboundRTPStreamStats
+		remoteOutbound RemoteOutboundRTPStreamStats
+	)
+
+	// RTCP reports are sent once a second, so wait for both sides to have them
+	timeout := time.After(10 * time.Second)
+	for outbound.RemoteID == "" || inbound.RemoteID == "" {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for RTP stream stats")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		for _, s := range offerPC.GetStats() {
+			switch stat := s.(type) {
+			case OutboundRTPStreamStats:
+				outbound = stat
+			case RemoteInboundRTPStreamStats:
+				remoteInbound = stat
+			}
+		}
+		for _, s := range answerPC.GetStats() {
+			switch stat := s.(type) {
+			case InboundRTPStreamStats:
+				inbound = stat
+			case RemoteOutboundRTPStreamStats:
+				remoteOutbound = stat
+			}
+		}
+	}
+	close(done)
+
+	offerReport := offerPC.GetStats()
+	answerReport := answerPC.GetStats()
+
+	assert.NotZero(t, outbound.PacketsSent)
+	assert.Equal(t, "iceTransport", outbound.TransportID)
+	assert.IsType(t, CodecStats{}, offerReport[outbound.CodecID])
+	assert.IsType(t, VideoSourceStats{}, offerReport[outbound.MediaSourceID])
+	assert.Equal(t, outbound.RemoteID, remoteInbound.ID)
+	assert.Equal(t, outbound.ID, remoteInbound.LocalID)
+	assert.Equal(t, outbound.CodecID, remoteInbound.CodecID)
+
+	assert.NotZero(t, inbound.PacketsReceived)
+	assert.Equal(t, outbound.SSRC, inbound.SSRC)
+	assert.Equal(t, "iceTransport", inbound.TransportID)
+	assert.IsType(t, CodecStats{}, answerReport[inbound.CodecID])
+	assert.Equal(t, inbound.RemoteID, remoteOutbound.ID)
+	assert.Equal(t, inbound.ID, remoteOutbound.LocalID)
+	assert.NotZero(t, remoteOutbound.PacketsSent)
+
+	codecStats, ok := answerReport[inbound.CodecID].(CodecStats)
+	assert.True(t, ok)
+	assert.Equal(t, "iceTransport", codecStats.TransportID)
+	assert.Equal(t, MimeTypeVP8, codecStats.MimeType)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 func TestPeerConnection_GetStats_Closed(t *testing.T) {
 	pc, err := NewPeerConnection(Configuration{})
 	assert.NoError(t, err)
@@ -1345,3 +1464,73 @@ func TestPeerConnection_GetStats_Closed(t *testing.T) {
 
 	pc.GetStats()
 }
+
+func TestPeerConnection_GetTypedStats(t *testing.T) {
+	offerPC, answerPC, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	sender, err := offerPC.AddTrack(track)
+	require.NoError(t, err)
+
+	receiverChan := make(chan *RTPReceiver, 1)
+	answerPC.OnTrack(func(trackRemote *TrackRemote, receiver *RTPReceiver) {
+		receiverChan <- receiver
+		for {
+			if _, _, readErr := trackRemote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	// Senders and receivers of another PeerConnection have no stats
+	_, ok := answerPC.GetSenderStats(sender)
+	assert.False(t, ok)
+	_, ok = answerPC.GetSenderStats(nil)
+	assert.False(t, ok)
+
+	done := make(chan struct{})
+	go sendVideoUntilDone(done, t, []*TrackLocalStaticSample{track})
+
+	require.NoError(t, signalPair(offerPC, answerPC))
+	receiver := <-receiverChan
+
+	_, ok = offerPC.GetReceiverStats(receiver)
+	assert.False(t, ok)
+
+	var (
+		senderStats   RTPSenderStats
+		receiverStats RTPReceiverStats
+	)
+
+	timeout := time.After(10 * time.Second)
+	for len(senderStats.OutboundRTPStreams) == 0 || senderStats.OutboundRTPStreams[0].PacketsSent == 0 ||
+		len(receiverStats.InboundRTPStreams) == 0 || receiverStats.InboundRTPStreams[0].PacketsReceived == 0 {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for RTP stream stats")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		senderStats, ok = offerPC.GetSenderStats(sender)
+		assert.True(t, ok)
+		receiverStats, ok = answerPC.GetReceiverStats(receiver)
+		assert.True(t, ok)
+	}
+	close(done)
+
+	assert.Len(t, senderStats.OutboundRTPStreams, 1)
+	assert.Equal(t, sender.id, senderStats.OutboundRTPStreams[0].SenderID)
+	assert.Len(t, receiverStats.InboundRTPStreams, 1)
+	assert.Equal(t, senderStats.OutboundRTPStreams[0].SSRC, receiverStats.InboundRTPStreams[0].SSRC)
+
+	transportStats := offerPC.GetTransportStats()
+	assert.Equal(t, StatsTypeTransport, transportStats.Type)
+	assert.Equal(t, "iceTransport", transportStats.ID)
+	assert.NotZero(t, transportStats.BytesSent)
+	assert.NotZero(t, transportStats.BytesReceived)
+
+	closePairNow(t, offerPC, answerPC)
+}
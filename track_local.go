@@ -4,8 +4,11 @@
 package webrtc
 
 import (
+	"time"
+
 	"github.com/pion/interceptor"
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/internal/fmtp"
 )
 
 // TrackLocalWriter is the Writer for outbound RTP Packets
@@ -49,6 +52,15 @@ type baseTrackLocalContext struct {
 	ssrc            SSRC
 	writeStream     TrackLocalWriter
 	rtcpInterceptor interceptor.RTCPReader
+
+	// packetTime is the a=ptime of the remote, or 0 if it has none
+	packetTime time.Duration
+
+	// extMapAllowMixed is set if the remote allows two-byte header extensions
+	extMapAllowMixed bool
+
+	// fmtpParsers are the parsers registered with the MediaEngine
+	fmtpParsers fmtp.Parsers
 }
 
 // CodecParameters returns the negotiated RTPCodecParameters. These are the codecs supported by both
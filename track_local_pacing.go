@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/webrtc/v4/internal/util"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// samplePacingDefaultTolerance is how far the writes of samples may be ahead
+// or behind real time by default
+const samplePacingDefaultTolerance = 200 * time.Millisecond
+
+// SamplePacingPolicy decides what happens when the samples written to a
+// TrackLocalStaticSample aren't paced in real time.
+type SamplePacingPolicy int
+
+const (
+	// SamplePacingPolicyLog logs a warning for every violation. This is the
+	// default.
+	SamplePacingPolicyLog SamplePacingPolicy = iota
+
+	// SamplePacingPolicyError makes WriteSample return an error wrapping
+	// ErrSamplePacing. The sample is sent regardless.
+	SamplePacingPolicyError
+)
+
+// This is done this way because of a linter.
+const (
+	samplePacingPolicyLogStr   = "log"
+	samplePacingPolicyErrorStr = "error"
+)
+
+func (p SamplePacingPolicy) String() string {
+	switch p {
+	case SamplePacingPolicyLog:
+		return samplePacingPolicyLogStr
+	case SamplePacingPolicyError:
+		return samplePacingPolicyErrorStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// SamplePacingValidation configures the validation of the pacing of the
+// samples written to a TrackLocalStaticSample, see
+// TrackLocalStaticSample.SetPacingValidation. Samples have to be written in
+// real time, as their durations elapse, or the remote plays them back choppy.
+type SamplePacingValidation struct {
+	Policy SamplePacingPolicy
+
+	// MaxBurst is how far the written samples may be ahead of real time,
+	// like when the application writes many samples at once. The default is
+	// 200ms.
+	MaxBurst time.Duration
+
+	// MaxGap is how far the written samples may be behind real time, like
+	// when the application stalls or the durations are too short, which
+	// causes a timestamp discontinuity. The default is 200ms.
+	MaxGap time.Duration
+
+	// Logger logs the violations of SamplePacingPolicyLog. The default logs
+	// with the default logger factory.
+	Logger logging.LeveledLogger
+}
+
+// samplePacingValidator compares the duration of the samples written to a
+// track with the time elapsed while writing them
+type samplePacingValidator struct {
+	mu     sync.Mutex
+	config SamplePacingValidation
+
+	started bool
+	// The wall clock time and the duration of the samples written since,
+	// reset after every burst or gap
+	start     time.Time
+	mediaTime time.Duration
+
+	lastTimestamp time.Time
+}
+
+func newSamplePacingValidator(config SamplePacingValidation) *samplePacingValidator {
+	if config.MaxBurst == 0 {
+		config.MaxBurst = samplePacingDefaultTolerance
+	}
+	if config.MaxGap == 0 {
+		config.MaxGap = samplePacingDefaultTolerance
+	}
+	if config.Logger == nil {
+		config.Logger = logging.NewDefaultLoggerFactory().NewLogger("track")
+	}
+
+	return &samplePacingValidator{config: config}
+}
+
+// validate checks the pacing of a sample written at now, and returns the
+// violations if the policy is SamplePacingPolicyError
+func (v *samplePacingValidator) validate(sample media.Sample, now time.Time) error {
+	v.mu.Lock()
+	violations := v.check(sample, now)
+	v.mu.Unlock()
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if v.config.Policy == SamplePacingPolicyError {
+		return util.FlattenErrs(violations)
+	}
+
+	for _, violation := range violations {
+		v.config.Logger.Warnf("Bad pacing of samples: %v", violation)
+	}
+
+	return nil
+}
+
+// check returns the violations of a sample. v.mu must be held.
+func (v *samplePacingValidator) check(sample media.Sample, now time.Time) []error {
+	violations := []error{}
+
+	if sample.Duration <= 0 {
+		violations = append(violations, fmt.Errorf("%w: duration %v isn't positive", ErrSamplePacing, sample.Duration))
+	}
+
+	if !sample.Timestamp.IsZero() {
+		if !v.lastTimestamp.IsZero() && sample.Timestamp.Before(v.lastTimestamp) {
+			violations = append(violations, fmt.Errorf("%w: timestamp went back by %v", ErrSamplePacing, v.lastTimestamp.Sub(sample.Timestamp)))
+		}
+		v.lastTimestamp = sample.Timestamp
+	}
+
+	// Samples dropped before this one elapsed as well
+	if sample.Duration > 0 {
+		v.mediaTime += sample.Duration * time.Duration(sample.PrevDroppedPackets)
+	}
+
+	if !v.started {
+		v.started = true
+		v.start, v.mediaTime = now, 0
+	} else {
+		elapsed := now.Sub(v.start)
+		switch {
+		case v.mediaTime-elapsed > v.config.MaxBurst:
+			violations = append(violations, fmt.Errorf("%w: samples are written %v ahead of real time", ErrSamplePacing, v.mediaTime-elapsed))
+			v.start, v.mediaTime = now, 0
+		case elapsed-v.mediaTime > v.config.MaxGap:
+			violations = append(violations, fmt.Errorf("%w: samples are written %v behind real time", ErrSamplePacing, elapsed-v.mediaTime))
+			v.start, v.mediaTime = now, 0
+		}
+	}
+
+	if sample.Duration > 0 {
+		v.mediaTime += sample.Duration
+	}
+
+	return violations
+}
+
+// SetPacingValidation enables the validation of the pacing of the samples
+// written with WriteSample: timestamp discontinuities, durations which
+// aren't positive, timestamps going back and bursts of writes. Violations
+// are logged or returned by WriteSample depending on the policy. Pass nil to
+// disable the validation.
+func (s *TrackLocalStaticSample) SetPacingValidation(validation *SamplePacingValidation) {
+	s.rtpTrack.mu.Lock()
+	defer s.rtpTrack.mu.Unlock()
+
+	if validation == nil {
+		s.pacingValidator = nil
+		return
+	}
+
+	s.pacingValidator = newSamplePacingValidator(*validation)
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplePacingValidator(t *testing.T) {
+	start := time.Unix(1000, 0)
+	frame := 20 * time.Millisecond
+
+	type write struct {
+		at     time.Duration
+		sample media.Sample
+	}
+
+	for i, testCase := range []struct {
+		writes    []write
+		violation bool
+	}{
+		{[]write{{0, media.Sample{Duration: frame}}, {frame, media.Sample{Duration: frame}}, {2 * frame, media.Sample{Duration: frame}}}, false},
+		{[]write{{0, media.Sample{}}}, true},
+		{[]write{{0, media.Sample{Duration: frame, Timestamp: start}}, {frame, media.Sample{Duration: frame, Timestamp: start.Add(-frame)}}}, true},
+		// 20 samples written at once are 400ms ahead
+		{func() []write {
+			writes := []write{}
+			for j := 0; j < 20; j++ {
+				writes = append(writes, write{0, media.Sample{Duration: frame}})
+			}
+			return writes
+		}(), true},
+		// The application stalled for 500ms
+		{[]write{{0, media.Sample{Duration: frame}}, {500 * time.Millisecond, media.Sample{Duration: frame}}}, true},
+		// Dropped samples account for the gap
+		{[]write{{0, media.Sample{Duration: frame}}, {300 * time.Millisecond, media.Sample{Duration: frame, PrevDroppedPackets: 14}}}, false},
+	} {
+		v := newSamplePacingValidator(SamplePacingValidation{Policy: SamplePacingPolicyError})
+
+		var err error
+		for _, w := range testCase.writes {
+			if writeErr := v.validate(w.sample, start.Add(w.at)); writeErr != nil {
+				err = writeErr
+			}
+		}
+
+		if testCase.violation {
+			assert.ErrorIs(t, err, ErrSamplePacing, "testCase: %d %v", i, testCase)
+		} else {
+			assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestTrackLocalStaticSample_SetPacingValidation(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	track.SetPacingValidation(&SamplePacingValidation{Policy: SamplePacingPolicyError})
+	assert.ErrorIs(t, track.WriteSample(media.Sample{Data: []byte{0x00}}), ErrSamplePacing)
+
+	track.SetPacingValidation(nil)
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}}))
+}
@@ -9,8 +9,10 @@ package webrtc
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/internal/fmtp"
 	"github.com/pion/webrtc/v4/internal/util"
 	"github.com/pion/webrtc/v4/pkg/media"
 )
@@ -23,6 +25,13 @@ type trackBinding struct {
 	ssrc        SSRC
 	payloadType PayloadType
 	writeStream TrackLocalWriter
+
+	// dependencyDescriptorID is the negotiated ID of the dependency
+	// descriptor header extension, or 0
+	dependencyDescriptorID int
+
+	// extMapAllowMixed is set if the remote allows two-byte header extensions
+	extMapAllowMixed bool
 }
 
 // TrackLocalStaticRTP  is a TrackLocal that has a pre-set codec and accepts RTP Packets.
@@ -64,13 +73,24 @@ func (s *TrackLocalStaticRTP) Bind(t TrackLocalContext) (RTPCodecParameters, err
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// The contexts of the PeerConnection parse the fmtp lines with the
+	// parsers of its MediaEngine
+	var parsers fmtp.Parsers
+	base, _ := t.(*baseTrackLocalContext)
+	if base != nil {
+		parsers = base.fmtpParsers
+	}
+
 	parameters := RTPCodecParameters{RTPCodecCapability: s.codec}
-	if codec, matchType := codecParametersFuzzySearch(parameters, t.CodecParameters()); matchType != codecMatchNone {
+	if codec, matchType := codecParametersFuzzySearch(parsers, parameters, t.CodecParameters()); matchType != codecMatchNone {
 		s.bindings = append(s.bindings, trackBinding{
 			ssrc:        t.SSRC(),
 			payloadType: codec.PayloadType,
 			writeStream: t.WriteStream(),
 			id:          t.ID(),
+
+			dependencyDescriptorID: headerExtensionID(t.HeaderExtensions(), DependencyDescriptorURI),
+			extMapAllowMixed:       base != nil && base.extMapAllowMixed,
 		})
 		return codec, nil
 	}
@@ -146,17 +166,24 @@ func getPacketAllocationFromPool() *rtp.Packet {
 // all PeerConnections. The error message will contain the ID of the failed
 // PeerConnections so you can remove them
 func (s *TrackLocalStaticRTP) WriteRTP(p *rtp.Packet) error {
+	return s.writeRTPCopy(p, nil)
+}
+
+// writeRTPCopy is writeRTP on a copy of p
+func (s *TrackLocalStaticRTP) writeRTPCopy(p *rtp.Packet, dependencyDescriptor []byte) error {
 	packet := getPacketAllocationFromPool()
 
 	defer resetPacketPoolAllocation(packet)
 
 	*packet = *p
 
-	return s.writeRTP(packet)
+	return s.writeRTP(packet, dependencyDescriptor)
 }
 
-// writeRTP is like WriteRTP, except that it may modify the packet p
-func (s *TrackLocalStaticRTP) writeRTP(p *rtp.Packet) error {
+// writeRTP is like WriteRTP, except that it may modify the packet p. The
+// dependencyDescriptor is added to the packets of the bindings which
+// negotiated it.
+func (s *TrackLocalStaticRTP) writeRTP(p *rtp.Packet, dependencyDescriptor []byte) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -165,7 +192,17 @@ func (s *TrackLocalStaticRTP) writeRTP(p *rtp.Packet) error {
 	for _, b := range s.bindings {
 		p.Header.SSRC = uint32(b.ssrc)
 		p.Header.PayloadType = uint8(b.payloadType)
-		if _, err := b.writeStream.WriteRTP(&p.Header, p.Payload); err != nil {
+
+		header := &p.Header
+		if dependencyDescriptor != nil && b.dependencyDescriptorID != 0 {
+			withExtension := p.Header
+			withExtension.Extensions = append([]rtp.Extension{}, p.Header.Extensions...)
+			if err := setHeaderExtension(&withExtension, uint8(b.dependencyDescriptorID), dependencyDescriptor, b.extMapAllowMixed); err == nil {
+				header = &withExtension
+			}
+		}
+
+		if _, err := b.writeStream.WriteRTP(header, p.Payload); err != nil {
 			writeErrs = append(writeErrs, err)
 		}
 	}
@@ -186,7 +223,7 @@ func (s *TrackLocalStaticRTP) Write(b []byte) (n int, err error) {
 		return 0, err
 	}
 
-	return len(b), s.writeRTP(packet)
+	return len(b), s.writeRTP(packet, nil)
 }
 
 // TrackLocalStaticSample is a TrackLocal that has a pre-set codec and accepts Samples.
@@ -196,6 +233,16 @@ type TrackLocalStaticSample struct {
 	sequencer  rtp.Sequencer
 	rtpTrack   *TrackLocalStaticRTP
 	clockRate  float64
+
+	pacingValidator *samplePacingValidator
+
+	// packetTimes are the a=ptime of the remotes by binding, samples are
+	// merged into packets of the shortest one
+	packetTimes  map[string]time.Duration
+	repacketizer sampleRepacketizer
+
+	// dependencyDescriptor is set for AV1
+	dependencyDescriptor *av1DependencyDescriptorSender
 }
 
 // NewTrackLocalStaticSample returns a TrackLocalStaticSample
@@ -241,6 +288,13 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 	s.rtpTrack.mu.Lock()
 	defer s.rtpTrack.mu.Unlock()
 
+	if base, ok := t.(*baseTrackLocalContext); ok && base.packetTime > 0 {
+		if s.packetTimes == nil {
+			s.packetTimes = map[string]time.Duration{}
+		}
+		s.packetTimes[t.ID()] = base.packetTime
+	}
+
 	// We only need one packetizer
 	if s.packetizer != nil {
 		return codec, nil
@@ -261,15 +315,36 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 		codec.ClockRate,
 	)
 	s.clockRate = float64(codec.RTPCodecCapability.ClockRate)
+	s.repacketizer.mimeType = codec.MimeType
+	if strings.EqualFold(codec.MimeType, MimeTypeAV1) {
+		s.dependencyDescriptor = &av1DependencyDescriptorSender{}
+	}
 	return codec, nil
 }
 
 // Unbind implements the teardown logic when the track is no longer needed. This happens
 // because a track has been stopped.
 func (s *TrackLocalStaticSample) Unbind(t TrackLocalContext) error {
+	s.rtpTrack.mu.Lock()
+	delete(s.packetTimes, t.ID())
+	s.rtpTrack.mu.Unlock()
+
 	return s.rtpTrack.Unbind(t)
 }
 
+// packetTime returns the shortest a=ptime of the remotes, or 0 if none has
+// one. s.rtpTrack.mu must be held.
+func (s *TrackLocalStaticSample) packetTime() time.Duration {
+	var packetTime time.Duration
+	for _, bindingPacketTime := range s.packetTimes {
+		if packetTime == 0 || bindingPacketTime < packetTime {
+			packetTime = bindingPacketTime
+		}
+	}
+
+	return packetTime
+}
+
 // WriteSample writes a Sample to the TrackLocalStaticSample
 // If one PeerConnection fails the packets will still be sent to
 // all PeerConnections. The error message will contain the ID of the failed
@@ -278,27 +353,56 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 	s.rtpTrack.mu.RLock()
 	p := s.packetizer
 	clockRate := s.clockRate
+	pacingValidator := s.pacingValidator
+	packetTime := s.packetTime()
+	dependencyDescriptor := s.dependencyDescriptor
 	s.rtpTrack.mu.RUnlock()
 
-	if p == nil {
-		return nil
+	var pacingErr error
+	if pacingValidator != nil {
+		pacingErr = pacingValidator.validate(sample, time.Now())
 	}
 
-	// skip packets by the number of previously dropped packets
-	for i := uint16(0); i < sample.PrevDroppedPackets; i++ {
-		s.sequencer.NextSequenceNumber()
+	if p == nil {
+		return pacingErr
 	}
 
-	samples := uint32(sample.Duration.Seconds() * clockRate)
-	if sample.PrevDroppedPackets > 0 {
-		p.SkipSamples(samples * uint32(sample.PrevDroppedPackets))
+	// Samples are merged into packets of the a=ptime of the remote
+	samplesToSend := []media.Sample{sample}
+	if packetTime > 0 {
+		samplesToSend = s.repacketizer.push(sample, packetTime)
 	}
-	packets := p.Packetize(sample.Data, samples)
 
 	writeErrs := []error{}
-	for _, p := range packets {
-		if err := s.rtpTrack.WriteRTP(p); err != nil {
-			writeErrs = append(writeErrs, err)
+	if pacingErr != nil {
+		writeErrs = append(writeErrs, pacingErr)
+	}
+	for _, toSend := range samplesToSend {
+		// skip packets by the number of previously dropped packets
+		for i := uint16(0); i < toSend.PrevDroppedPackets; i++ {
+			s.sequencer.NextSequenceNumber()
+		}
+
+		samples := uint32(toSend.Duration.Seconds() * clockRate)
+		if toSend.PrevDroppedPackets > 0 {
+			p.SkipSamples(samples * uint32(toSend.PrevDroppedPackets))
+		}
+		packets := p.Packetize(toSend.Data, samples)
+
+		var descriptors [][]byte
+		if dependencyDescriptor != nil && len(packets) != 0 {
+			descriptors = dependencyDescriptor.descriptors(toSend.Data, len(packets))
+		}
+
+		for i, p := range packets {
+			var descriptor []byte
+			if descriptors != nil {
+				descriptor = descriptors[i]
+			}
+
+			if err := s.rtpTrack.writeRTPCopy(p, descriptor); err != nil {
+				writeErrs = append(writeErrs, err)
+			}
 		}
 	}
 
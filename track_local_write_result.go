@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+)
+
+// writeStartAttribute is the interceptor attribute holding the time a
+// TrackLocal wrote a packet, it is only set when write results are reported
+type writeStartAttributeKey struct{}
+
+var writeStartAttribute = writeStartAttributeKey{} //nolint:gochecknoglobals
+
+// TrackLocalWriteResult is the outcome of a RTP packet written by a TrackLocal,
+// as reported by RTPSender.OnWriteResult
+type TrackLocalWriteResult struct {
+	SSRC           SSRC
+	PayloadType    PayloadType
+	SequenceNumber uint16
+	Timestamp      uint32
+
+	// BytesSent is the size of the packet on the wire, after SRTP. It is 0 if
+	// the packet wasn't sent.
+	BytesSent int
+
+	// Latency is the time from the TrackLocal writing the packet to SRTP writing
+	// it to the transport, including the time spent in interceptors. It is 0
+	// for packets which weren't written by the TrackLocal, like retransmissions.
+	Latency time.Duration
+
+	// Queued is true if the packet was written before DTLS was connected, and
+	// is queued to be sent once it is. See SettingEngine.SetSRTPWriteQueueSize.
+	Queued bool
+
+	// Err is why the packet wasn't sent. It is ErrTrackLocalWriteNotConnected
+	// for packets dropped because DTLS isn't connected yet, for which WriteRTP
+	// doesn't return an error.
+	Err error
+}
+
+// OnWriteResult sets an event handler which is invoked with the outcome of
+// every RTP packet sent by the RTPSender. The handler is invoked on the
+// goroutine writing the packet, so it must return quickly.
+func (r *RTPSender) OnWriteResult(f func(TrackLocalWriteResult)) {
+	r.onWriteResultHandler.Store(f)
+}
+
+func (r *RTPSender) getOnWriteResultHandler() func(TrackLocalWriteResult) {
+	handler, _ := r.onWriteResultHandler.Load().(func(TrackLocalWriteResult))
+
+	return handler
+}
+
+// reportWrites returns true if the outcome of writes has to be reported
+func (r *RTPSender) reportWrites() bool {
+	return r.getOnWriteResultHandler() != nil
+}
+
+// writeRTP writes a packet of a track encoding to SRTP and reports the outcome
+func (r *RTPSender) writeRTP(srtpStream *srtpWriterFuture, header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	handler := r.getOnWriteResultHandler()
+	if handler == nil {
+		return srtpStream.WriteRTP(header, payload)
+	}
+
+	n, queued, err := srtpStream.writeRTP(header, payload)
+
+	result := TrackLocalWriteResult{
+		SSRC:           SSRC(header.SSRC),
+		PayloadType:    PayloadType(header.PayloadType),
+		SequenceNumber: header.SequenceNumber,
+		Timestamp:      header.Timestamp,
+		BytesSent:      n,
+		Queued:         queued,
+		Err:            err,
+	}
+	if err == nil && !queued && n == 0 && !srtpStream.ready() {
+		result.Err = ErrTrackLocalWriteNotConnected
+	}
+	if start, ok := attributes.Get(writeStartAttribute).(time.Time); ok {
+		result.Latency = time.Since(start)
+	}
+
+	handler(result)
+
+	return n, err
+}
@@ -18,8 +18,9 @@ import (
 type TrackRemote struct {
 	mu sync.RWMutex
 
-	id       string
-	streamID string
+	id        string
+	streamID  string
+	streamIDs []string
 
 	payloadType PayloadType
 	kind        RTPCodecType
@@ -32,6 +33,13 @@ type TrackRemote struct {
 	receiver         *RTPReceiver
 	peeked           []byte
 	peekedAttributes interceptor.Attributes
+
+	clockDrift clockDriftEstimator
+	clockSync  trackClockSync
+
+	// dependencyStructure is the structure last attached to a dependency
+	// descriptor of the track
+	dependencyStructure *FrameDependencyStructure
 }
 
 func newTrackRemote(kind RTPCodecType, ssrc, rtxSsrc SSRC, rid string, receiver *RTPReceiver) *TrackRemote {
@@ -223,3 +231,25 @@ func (t *TrackRemote) HasRTX() bool {
 	defer t.mu.RUnlock()
 	return t.rtxSsrc != 0
 }
+
+// ClockDrift returns how fast the RTP clock of the remote sender runs compared
+// to the local monotonic clock, in parts per million. A positive value means the
+// sender's clock runs fast. It is measured from the RTP timestamps and Sender
+// Reports read from the track, ok is false until enough were read to estimate it.
+func (t *TrackRemote) ClockDrift() (ppm float64, ok bool) {
+	return t.clockDrift.drift()
+}
+
+// LocalTime returns the local time which corresponds to a RTP timestamp of
+// the track. It is the time the sample was captured according to the sender,
+// converted to the local clock and delayed by the lowest network delay seen.
+// ok is false until a RTCP Sender Report was received for the track.
+func (t *TrackRemote) LocalTime(rtpTimestamp uint32) (at time.Time, ok bool) {
+	return t.clockSync.localTime(rtpTimestamp)
+}
+
+// RTPTimestampAt is the inverse of LocalTime, it returns the RTP timestamp of
+// the track which corresponds to a local time.
+func (t *TrackRemote) RTPTimestampAt(at time.Time) (rtpTimestamp uint32, ok bool) {
+	return t.clockSync.rtpTimestamp(at)
+}
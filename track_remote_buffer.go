@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/v3/packetio"
+)
+
+// srtpBufferSize and srtcpBufferSize are the limits of the buffers of SRTP
+// and SRTCP read streams, the same as the defaults of pion/srtp
+const (
+	srtpBufferSize  = 1000 * 1000
+	srtcpBufferSize = 100 * 1000
+)
+
+// newDefaultSRTPBuffer returns the buffer pion/srtp uses by default for the
+// read streams of packetType
+func newDefaultSRTPBuffer(packetType packetio.BufferPacketType) *packetio.Buffer {
+	buffer := packetio.NewBuffer()
+	if packetType == packetio.RTCPBufferPacket {
+		buffer.SetLimitSize(srtcpBufferSize)
+	} else {
+		buffer.SetLimitSize(srtpBufferSize)
+	}
+
+	return buffer
+}
+
+// TrackRemoteBufferWatermarkEvent reports that the packets buffered for a
+// TrackRemote crossed a watermark set with
+// SettingEngine.SetTrackRemoteBufferWatermarks
+type TrackRemoteBufferWatermarkEvent struct {
+	// Track is nil if the SSRC doesn't belong to a TrackRemote yet, like
+	// before the first packet of a simulcast stream was read
+	Track *TrackRemote
+	SSRC  SSRC
+
+	// BufferedBytes is the size of the buffered packets
+	BufferedBytes int
+
+	// High is true when BufferedBytes went above the high watermark, meaning
+	// the track isn't read fast enough, and false when it went back to the low
+	// watermark
+	High bool
+}
+
+// OnTrackRemoteBufferWatermark sets an event handler which is invoked when
+// the packets buffered for a TrackRemote cross a watermark set with
+// SettingEngine.SetTrackRemoteBufferWatermarks, so slow consumers can be
+// detected before the buffer is full and packets are dropped.
+func (pc *PeerConnection) OnTrackRemoteBufferWatermark(f func(TrackRemoteBufferWatermarkEvent)) {
+	pc.onTrackRemoteBufferWatermarkHandler.Store(f)
+}
+
+func (pc *PeerConnection) onTrackRemoteBufferWatermark(ssrc SSRC, bufferedBytes int, high bool) {
+	handler, ok := pc.onTrackRemoteBufferWatermarkHandler.Load().(func(TrackRemoteBufferWatermarkEvent))
+	if !ok || handler == nil {
+		return
+	}
+
+	event := TrackRemoteBufferWatermarkEvent{SSRC: ssrc, BufferedBytes: bufferedBytes, High: high}
+	for _, receiver := range pc.GetReceivers() {
+		for _, track := range receiver.Tracks() {
+			if track.SSRC() == ssrc {
+				event.Track = track
+			}
+		}
+	}
+
+	handler(event)
+}
+
+// srtpReadStreamBuffer is a buffer of a SRTP read stream which can be
+// measured, a *packetio.Buffer or a *srtpReadBuffer
+type srtpReadStreamBuffer interface {
+	io.ReadWriteCloser
+	Size() int
+	SetReadDeadline(time.Time) error
+}
+
+// watermarkBuffer is the buffer of a SRTP read stream, reporting when its
+// size crosses the watermarks
+type watermarkBuffer struct {
+	srtpReadStreamBuffer
+
+	high, low int
+	onCross   func(bufferedBytes int, high bool)
+
+	mu    sync.Mutex
+	above bool
+	// crossings wait to be reported by a single goroutine, so they are
+	// reported in order. reporting is set while it runs.
+	crossings []watermarkCrossing
+	reporting bool
+}
+
+type watermarkCrossing struct {
+	bufferedBytes int
+	high          bool
+}
+
+func (b *watermarkBuffer) Write(p []byte) (int, error) {
+	n, err := b.srtpReadStreamBuffer.Write(p)
+	b.check()
+
+	return n, err
+}
+
+func (b *watermarkBuffer) Read(p []byte) (int, error) {
+	n, err := b.srtpReadStreamBuffer.Read(p)
+	b.check()
+
+	return n, err
+}
+
+func (b *watermarkBuffer) check() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := b.srtpReadStreamBuffer.Size()
+	switch {
+	case !b.above && size >= b.high:
+		b.above = true
+	case b.above && size <= b.low:
+		b.above = false
+	default:
+		return
+	}
+
+	b.crossings = append(b.crossings, watermarkCrossing{bufferedBytes: size, high: b.above})
+	if !b.reporting {
+		b.reporting = true
+		go b.report()
+	}
+}
+
+// report calls onCross for the crossings until none is left
+func (b *watermarkBuffer) report() {
+	for {
+		b.mu.Lock()
+		if len(b.crossings) == 0 {
+			b.reporting = false
+			b.mu.Unlock()
+			return
+		}
+		crossing := b.crossings[0]
+		b.crossings = b.crossings[1:]
+		b.mu.Unlock()
+
+		b.onCross(crossing.bufferedBytes, crossing.high)
+	}
+}
+
+// srtpBufferFactory returns the BufferFactory of the SRTP sessions. The
+// buffers of RTP read streams have the size and policy of
+// SettingEngine.SetSRTPReadBuffer, reporting drops to onDrop, and report
+// their watermarks to onCross if SettingEngine.SetTrackRemoteBufferWatermarks
+// is set.
+func (e *SettingEngine) srtpBufferFactory(
+	onCross func(ssrc SSRC, bufferedBytes int, high bool),
+	onDrop func(ssrc SSRC, dropped, totalDropped uint64),
+) func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+	factory := e.BufferFactory
+	if e.trackRemoteBufferWatermarks.high == 0 && (!e.srtpReadBuffer.enabled || factory != nil) {
+		return factory
+	}
+
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		var buffer io.ReadWriteCloser
+		switch {
+		case factory != nil:
+			buffer = factory(packetType, ssrc)
+		case e.srtpReadBuffer.enabled && packetType == packetio.RTPBufferPacket:
+			size := e.srtpReadBuffer.size
+			if size == 0 {
+				size = srtpBufferSize
+			}
+			buffer = newSRTPReadBuffer(size, e.srtpReadBuffer.policy, func(dropped, totalDropped uint64) {
+				if onDrop != nil {
+					onDrop(SSRC(ssrc), dropped, totalDropped)
+				}
+			})
+		default:
+			buffer = newDefaultSRTPBuffer(packetType)
+		}
+
+		if packetType != packetio.RTPBufferPacket || e.trackRemoteBufferWatermarks.high == 0 {
+			return buffer
+		}
+
+		// Custom buffers can't be measured
+		var measured srtpReadStreamBuffer
+		switch b := buffer.(type) {
+		case *packetio.Buffer:
+			measured = b
+		case *srtpReadBuffer:
+			measured = b
+		default:
+			return buffer
+		}
+
+		return &watermarkBuffer{
+			srtpReadStreamBuffer: measured,
+			high:                 e.trackRemoteBufferWatermarks.high,
+			low:                  e.trackRemoteBufferWatermarks.low,
+			onCross: func(bufferedBytes int, high bool) {
+				if onCross != nil {
+					onCross(SSRC(ssrc), bufferedBytes, high)
+				}
+			},
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/packetio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingEngine_SRTPBufferFactory(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.srtpBufferFactory(nil, nil))
+
+	type crossing struct {
+		ssrc          SSRC
+		bufferedBytes int
+		high          bool
+	}
+	crossings := make(chan crossing, 2)
+
+	s.SetTrackRemoteBufferWatermarks(300, 100)
+	factory := s.srtpBufferFactory(func(ssrc SSRC, bufferedBytes int, high bool) {
+		crossings <- crossing{ssrc, bufferedBytes, high}
+	}, nil)
+
+	_, ok := factory(packetio.RTCPBufferPacket, 5000).(*packetio.Buffer)
+	assert.True(t, ok)
+
+	buffer, ok := factory(packetio.RTPBufferPacket, 5000).(*watermarkBuffer)
+	assert.True(t, ok)
+
+	// Every packet is buffered with a 2 bytes header
+	packet := make([]byte, 98)
+	for i := 0; i < 2; i++ {
+		_, err := buffer.Write(packet)
+		assert.NoError(t, err)
+	}
+	select {
+	case <-crossings:
+		assert.Fail(t, "high watermark crossed too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err := buffer.Write(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, crossing{5000, 300, true}, <-crossings)
+
+	_, err = buffer.Write(packet)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = buffer.Read(packet)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, crossing{5000, 100, false}, <-crossings)
+
+	assert.NoError(t, buffer.Close())
+}
+
+func TestWatermarkBuffer_ReportsInOrder(t *testing.T) {
+	crossings := make(chan bool, 64)
+	unblock := make(chan struct{})
+
+	buffer := &watermarkBuffer{
+		srtpReadStreamBuffer: packetio.NewBuffer(),
+		high:                 100,
+		low:                  0,
+		onCross: func(_ int, high bool) {
+			<-unblock
+			crossings <- high
+		},
+	}
+
+	// The handler is blocked while the watermarks are crossed many times
+	packet := make([]byte, 98)
+	for i := 0; i < 10; i++ {
+		_, err := buffer.Write(packet)
+		assert.NoError(t, err)
+		_, err = buffer.Read(packet)
+		assert.NoError(t, err)
+	}
+	close(unblock)
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, i%2 == 0, <-crossings, "crossing: %d", i)
+	}
+
+	assert.NoError(t, buffer.Close())
+}
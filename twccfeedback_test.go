@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/logging"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTWCCSenderFactory_FeedbackInterval(t *testing.T) {
+	interceptorSettingEngines.Store("twcc", &SettingEngine{twccFeedbackInterval: 20 * time.Millisecond})
+	defer interceptorSettingEngines.Delete("twcc")
+
+	i, err := (&twccSenderFactory{}).NewInterceptor("twcc")
+	assert.NoError(t, err)
+	sender, ok := i.(*twccSenderInterceptor)
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Millisecond, sender.interval)
+	assert.Equal(t, sender, takeTWCCSender("twcc"))
+	assert.Nil(t, takeTWCCSender("twcc"))
+	assert.NoError(t, i.Close())
+}
+
+func TestTWCCSenderInterceptor_Observe(t *testing.T) {
+	sender := newTWCCSenderInterceptor(0, logging.NewDefaultLoggerFactory().NewLogger("test"))
+	assert.Equal(t, twccDefaultFeedbackInterval, sender.interval)
+
+	packet := func(ssrc uint32, transportSequence uint16) []byte {
+		header := rtp.Header{Version: 2, SSRC: ssrc}
+		ext, err := (&rtp.TransportCCExtension{TransportSequence: transportSequence}).Marshal()
+		assert.NoError(t, err)
+		assert.NoError(t, header.SetExtension(5, ext))
+		b, err := header.Marshal()
+		assert.NoError(t, err)
+		return b
+	}
+
+	// Packets of streams which aren't bound are ignored
+	sender.observe(packet(1, 0), time.Now())
+	assert.Equal(t, 0, sender.recorder.PacketsHeld())
+
+	sender.BindRemoteStream(&interceptor.StreamInfo{
+		SSRC:                1,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{{URI: sdp.TransportCCURI, ID: 5}},
+	}, nil)
+	sender.observe(packet(1, 1), time.Now())
+	sender.observe(packet(1, 2), time.Now())
+	sender.observe([]byte{0x80}, time.Now())
+	assert.Equal(t, 2, sender.recorder.PacketsHeld())
+
+	sender.UnbindRemoteStream(&interceptor.StreamInfo{SSRC: 1})
+	sender.observe(packet(1, 3), time.Now())
+	assert.Equal(t, 2, sender.recorder.PacketsHeld())
+
+	assert.NoError(t, sender.Close())
+}
+
+// Assert that a recvonly PeerConnection sends TWCC feedback for the media it
+// receives, even if the application doesn't read it
+func TestPeerConnection_TWCCFeedbackRecvonly(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerMediaEngine := &MediaEngine{}
+	assert.NoError(t, offerMediaEngine.RegisterDefaultCodecs())
+	offerRegistry := &interceptor.Registry{}
+	assert.NoError(t, ConfigureTWCCHeaderExtensionSender(offerMediaEngine, offerRegistry))
+	offerMediaEngine.RegisterFeedback(RTCPFeedback{Type: TypeRTCPFBTransportCC}, RTPCodecTypeVideo)
+
+	pcOffer, err := NewAPI(WithMediaEngine(offerMediaEngine), WithInterceptorRegistry(offerRegistry)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerSettings := SettingEngine{}
+	answerSettings.SetTWCCFeedbackInterval(20 * time.Millisecond)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	transceiver, err := pcOffer.AddTransceiverFromTrack(track, RTPTransceiverInit{Direction: RTPTransceiverDirectionSendonly})
+	assert.NoError(t, err)
+
+	feedbackReceived := make(chan struct{})
+	go func() {
+		defer close(feedbackReceived)
+		for {
+			packets, _, readErr := transceiver.Sender().ReadRTCP()
+			if readErr != nil {
+				return
+			}
+			for _, packet := range packets {
+				if _, ok := packet.(*rtcp.TransportLayerCC); ok {
+					return
+				}
+			}
+		}
+	}()
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	assert.Equal(t, RTPTransceiverDirectionRecvonly, pcAnswer.GetTransceivers()[0].Direction())
+
+	sendVideoUntilDone(feedbackReceived, t, []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
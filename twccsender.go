@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/logging"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4/internal/util"
+)
+
+// twccDefaultFeedbackInterval is the interval of TWCC feedback when none is
+// set with SettingEngine.SetTWCCFeedbackInterval
+const twccDefaultFeedbackInterval = 100 * time.Millisecond
+
+// twccSenderInterceptor sends TWCC feedback for the packets of the remote
+// streams. Unlike the twcc.SenderInterceptor the packets are accounted for
+// when they arrive on the SRTP connection of the DTLSTransport, so the
+// feedback reflects the network even if the application reads late or
+// doesn't read at all.
+type twccSenderInterceptor struct {
+	interceptor.NoOp
+
+	interval  time.Duration
+	startTime time.Time
+	log       logging.LeveledLogger
+
+	mu       sync.Mutex
+	recorder *twcc.Recorder
+	// extensionIDs are the IDs of the transport-cc header extension of the
+	// bound remote streams by SSRC
+	extensionIDs map[uint32]uint8
+	started      bool
+
+	close     chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newTWCCSenderInterceptor(interval time.Duration, log logging.LeveledLogger) *twccSenderInterceptor {
+	if interval == 0 {
+		interval = twccDefaultFeedbackInterval
+	}
+
+	return &twccSenderInterceptor{
+		interval:     interval,
+		startTime:    time.Now(),
+		log:          log,
+		recorder:     twcc.NewRecorder(util.RandUint32()),
+		extensionIDs: map[uint32]uint8{},
+		close:        make(chan struct{}),
+	}
+}
+
+// BindRTCPWriter starts sending the feedback with writer
+func (i *twccSenderInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	select {
+	case <-i.close:
+		return writer
+	default:
+	}
+
+	if !i.started {
+		i.started = true
+		i.wg.Add(1)
+		go i.loop(writer)
+	}
+
+	return writer
+}
+
+// BindRemoteStream accounts for the packets of the stream once they arrive
+// if it uses the transport-cc header extension
+func (i *twccSenderInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	for _, e := range info.RTPHeaderExtensions {
+		if e.URI == sdp.TransportCCURI && e.ID > 0 {
+			i.mu.Lock()
+			i.extensionIDs[info.SSRC] = uint8(e.ID)
+			i.mu.Unlock()
+			break
+		}
+	}
+
+	return reader
+}
+
+// UnbindRemoteStream stops accounting for the packets of the stream
+func (i *twccSenderInterceptor) UnbindRemoteStream(info *interceptor.StreamInfo) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.extensionIDs, info.SSRC)
+}
+
+// Close stops sending the feedback
+func (i *twccSenderInterceptor) Close() error {
+	i.closeOnce.Do(func() {
+		close(i.close)
+	})
+	i.wg.Wait()
+
+	return nil
+}
+
+// observe records the transport wide sequence number of the SRTP packet b
+// which arrived at arrival. The RTP header of SRTP packets isn't encrypted.
+func (i *twccSenderInterceptor) observe(b []byte, arrival time.Time) {
+	header := &rtp.Header{}
+	if _, err := header.Unmarshal(b); err != nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	id, ok := i.extensionIDs[header.SSRC]
+	if !ok {
+		return
+	}
+
+	ext := header.GetExtension(id)
+	if ext == nil {
+		return
+	}
+
+	var tccExt rtp.TransportCCExtension
+	if err := tccExt.Unmarshal(ext); err != nil {
+		return
+	}
+
+	i.recorder.Record(header.SSRC, tccExt.TransportSequence, arrival.Sub(i.startTime).Microseconds())
+}
+
+func (i *twccSenderInterceptor) loop(writer interceptor.RTCPWriter) {
+	defer i.wg.Done()
+
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.close:
+			return
+		case <-ticker.C:
+			i.mu.Lock()
+			pkts := i.recorder.BuildFeedbackPacket()
+			i.mu.Unlock()
+
+			if len(pkts) == 0 {
+				continue
+			}
+			if _, err := writer.Write(pkts, nil); err != nil {
+				i.log.Error(err.Error())
+			}
+		}
+	}
+}
+
+// srtpConn returns the connection of the SRTP session over conn, which
+// accounts for the packets arriving on it
+func (i *twccSenderInterceptor) srtpConn(conn net.Conn) net.Conn {
+	if i == nil {
+		return conn
+	}
+
+	return &twccSRTPConn{Conn: conn, sender: i}
+}
+
+// twccSRTPConn is the connection of a SRTP session accounting for the
+// arrival of the packets read from it
+type twccSRTPConn struct {
+	net.Conn
+
+	sender *twccSenderInterceptor
+}
+
+func (c *twccSRTPConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err == nil {
+		c.sender.observe(b[:n], time.Now())
+	}
+
+	return n, err
+}
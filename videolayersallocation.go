@@ -0,0 +1,358 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// VideoLayersAllocationURI is the URI of the video layers allocation RTP
+	// header extension, see ConfigureVideoLayersAllocation
+	VideoLayersAllocationURI = "http://www.webrtc.org/experiments/rtp-hdrext/video-layers-allocation00"
+
+	videoLayersAllocationMaxStreams        = 4
+	videoLayersAllocationMaxSpatialLayers  = 4
+	videoLayersAllocationMaxTemporalLayers = 4
+	videoLayersAllocationResolutionSize    = 5
+
+	// videoLayersAllocationInterval is how often an unchanged allocation is
+	// repeated
+	videoLayersAllocationInterval = time.Second
+)
+
+// VideoLayersAllocation is the content of the video layers allocation RTP
+// header extension, which tells the receiver the layers a simulcast or SVC
+// sender is sending with their target bitrates and resolutions.
+// https://webrtc.googlesource.com/src/+/refs/heads/main/docs/native-code/rtp-hdrext/video-layers-allocation00
+type VideoLayersAllocation struct {
+	// RTPStreamIndex is the index of the RTP stream of the packet carrying
+	// the allocation among the simulcast streams. It is set by the RTPSender.
+	RTPStreamIndex int
+
+	// ActiveSpatialLayers are sorted by RTP stream index and spatial ID. No
+	// layer means that nothing is sent.
+	ActiveSpatialLayers []VideoLayersAllocationSpatialLayer
+}
+
+// VideoLayersAllocationSpatialLayer is an active spatial layer of a
+// VideoLayersAllocation
+type VideoLayersAllocationSpatialLayer struct {
+	RTPStreamIndex int
+	SpatialID      int
+
+	// TargetBitratesKbps are the cumulative target bitrates of the temporal
+	// layers, at least one and at most four
+	TargetBitratesKbps []uint64
+
+	// Width, Height and FrameRate are sent only if they are set for every
+	// layer
+	Width     uint16
+	Height    uint16
+	FrameRate uint8
+}
+
+// Marshal serializes the allocation as the payload of the header extension
+func (a VideoLayersAllocation) Marshal() ([]byte, error) {
+	layers := a.ActiveSpatialLayers
+	if len(layers) == 0 {
+		return []byte{0}, nil
+	}
+
+	numStreams := a.RTPStreamIndex + 1
+	hasResolution := true
+	bitmasks := [videoLayersAllocationMaxStreams]byte{}
+	for i, layer := range layers {
+		switch {
+		case layer.RTPStreamIndex < 0 || layer.RTPStreamIndex >= videoLayersAllocationMaxStreams,
+			layer.SpatialID < 0 || layer.SpatialID >= videoLayersAllocationMaxSpatialLayers,
+			len(layer.TargetBitratesKbps) == 0 || len(layer.TargetBitratesKbps) > videoLayersAllocationMaxTemporalLayers:
+			return nil, fmt.Errorf("%w: layer %d is out of range", errVideoLayersAllocationInvalid, i)
+		case i > 0 && (layer.RTPStreamIndex < layers[i-1].RTPStreamIndex ||
+			(layer.RTPStreamIndex == layers[i-1].RTPStreamIndex && layer.SpatialID <= layers[i-1].SpatialID)):
+			return nil, fmt.Errorf("%w: layers aren't sorted", errVideoLayersAllocationInvalid)
+		}
+
+		bitmasks[layer.RTPStreamIndex] |= 1 << layer.SpatialID
+		if layer.RTPStreamIndex >= numStreams {
+			numStreams = layer.RTPStreamIndex + 1
+		}
+		hasResolution = hasResolution && layer.Width != 0 && layer.Height != 0
+	}
+	if a.RTPStreamIndex < 0 || numStreams > videoLayersAllocationMaxStreams {
+		return nil, fmt.Errorf("%w: RTP stream index is out of range", errVideoLayersAllocationInvalid)
+	}
+
+	sharedBitmask := true
+	for i := 1; i < numStreams; i++ {
+		sharedBitmask = sharedBitmask && bitmasks[i] == bitmasks[0]
+	}
+
+	buf := []byte{byte(a.RTPStreamIndex<<6 | (numStreams-1)<<4)}
+	if sharedBitmask {
+		buf[0] |= bitmasks[0]
+	} else {
+		for i := 0; i < numStreams; i += 2 {
+			b := bitmasks[i] << 4
+			if i+1 < numStreams {
+				b |= bitmasks[i+1]
+			}
+			buf = append(buf, b)
+		}
+	}
+
+	// Number of temporal layers minus one, 2 bits per layer
+	for i := 0; i < len(layers); i += 4 {
+		var b byte
+		for j := 0; j < 4 && i+j < len(layers); j++ {
+			b |= byte(len(layers[i+j].TargetBitratesKbps)-1) << (6 - 2*j)
+		}
+		buf = append(buf, b)
+	}
+
+	for _, layer := range layers {
+		for _, bitrate := range layer.TargetBitratesKbps {
+			buf = appendLEB128(buf, bitrate)
+		}
+	}
+
+	if hasResolution {
+		for _, layer := range layers {
+			buf = append(buf, 0, 0, 0, 0, layer.FrameRate)
+			binary.BigEndian.PutUint16(buf[len(buf)-5:], layer.Width-1)
+			binary.BigEndian.PutUint16(buf[len(buf)-3:], layer.Height-1)
+		}
+	}
+
+	return buf, nil
+}
+
+// Unmarshal parses the payload of the header extension
+func (a *VideoLayersAllocation) Unmarshal(buf []byte) error { //nolint:gocognit
+	*a = VideoLayersAllocation{}
+	if len(buf) == 0 {
+		return errVideoLayersAllocationTooShort
+	}
+	if len(buf) == 1 && buf[0] == 0 {
+		return nil
+	}
+
+	a.RTPStreamIndex = int(buf[0] >> 6)
+	numStreams := int(buf[0]>>4&0x3) + 1
+	offset := 1
+
+	bitmasks := [videoLayersAllocationMaxStreams]byte{}
+	if sharedBitmask := buf[0] & 0x0F; sharedBitmask != 0 {
+		for i := 0; i < numStreams; i++ {
+			bitmasks[i] = sharedBitmask
+		}
+	} else {
+		size := (numStreams + 1) / 2
+		if len(buf) < offset+size {
+			return errVideoLayersAllocationTooShort
+		}
+		for i := 0; i < numStreams; i++ {
+			bitmasks[i] = buf[offset+i/2] >> (4 * (1 - i%2)) & 0x0F
+		}
+		offset += size
+	}
+
+	for stream := 0; stream < numStreams; stream++ {
+		for spatialID := 0; spatialID < videoLayersAllocationMaxSpatialLayers; spatialID++ {
+			if bitmasks[stream]&(1<<spatialID) != 0 {
+				a.ActiveSpatialLayers = append(a.ActiveSpatialLayers, VideoLayersAllocationSpatialLayer{
+					RTPStreamIndex: stream,
+					SpatialID:      spatialID,
+				})
+			}
+		}
+	}
+	layers := a.ActiveSpatialLayers
+
+	size := (len(layers) + 3) / 4
+	if len(buf) < offset+size {
+		return errVideoLayersAllocationTooShort
+	}
+	for i := range layers {
+		temporalLayers := int(buf[offset+i/4]>>(6-2*(i%4))&0x3) + 1
+		layers[i].TargetBitratesKbps = make([]uint64, temporalLayers)
+	}
+	offset += size
+
+	for i := range layers {
+		for j := range layers[i].TargetBitratesKbps {
+			bitrate, n := readLEB128(buf[offset:])
+			if n == 0 {
+				return errVideoLayersAllocationTooShort
+			}
+			layers[i].TargetBitratesKbps[j] = bitrate
+			offset += n
+		}
+	}
+
+	switch len(buf) - offset {
+	case 0:
+	case videoLayersAllocationResolutionSize * len(layers):
+		for i := range layers {
+			layers[i].Width = binary.BigEndian.Uint16(buf[offset:]) + 1
+			layers[i].Height = binary.BigEndian.Uint16(buf[offset+2:]) + 1
+			layers[i].FrameRate = buf[offset+4]
+			offset += videoLayersAllocationResolutionSize
+		}
+	default:
+		return fmt.Errorf("%w: %d trailing bytes", errVideoLayersAllocationInvalid, len(buf)-offset)
+	}
+
+	return nil
+}
+
+func appendLEB128(buf []byte, value uint64) []byte {
+	for value >= 0x80 {
+		buf = append(buf, byte(value)|0x80)
+		value >>= 7
+	}
+
+	return append(buf, byte(value))
+}
+
+// readLEB128 returns the value at the start of buf and its size, which is 0
+// if buf is truncated
+func readLEB128(buf []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(buf) && i < 10; i++ {
+		value |= uint64(buf[i]&0x7F) << (7 * i)
+		if buf[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+// videoLayersAllocationSender adds the allocation set with
+// RTPSender.SetVideoLayersAllocation to the first packet of a frame of every
+// RTP stream, when it changed and once per interval
+type videoLayersAllocationSender struct {
+	mu         sync.Mutex
+	allocation *VideoLayersAllocation
+	version    uint64
+	streams    map[int]*videoLayersAllocationStream
+}
+
+type videoLayersAllocationStream struct {
+	version       uint64
+	sentAt        time.Time
+	lastTimestamp uint32
+	started       bool
+}
+
+// SetVideoLayersAllocation sets the video layers allocation header extension
+// sent on the RTP streams of the RTPSender, if it was negotiated. It is sent
+// on the first packet of the next frame of every stream, and repeated every
+// second. Pass nil to stop sending it.
+func (r *RTPSender) SetVideoLayersAllocation(allocation *VideoLayersAllocation) {
+	r.videoLayersAllocation.mu.Lock()
+	defer r.videoLayersAllocation.mu.Unlock()
+
+	if allocation != nil {
+		copied := *allocation
+		copied.ActiveSpatialLayers = append([]VideoLayersAllocationSpatialLayer{}, allocation.ActiveSpatialLayers...)
+		allocation = &copied
+	}
+
+	r.videoLayersAllocation.allocation = allocation
+	r.videoLayersAllocation.version++
+}
+
+// withVideoLayersAllocation returns header with the allocation added if it
+// is due on the RTP stream streamIndex. header is copied, since it may be
+// shared with other PeerConnections. allowMixed is set if the remote allows
+// two-byte header extensions.
+func (s *videoLayersAllocationSender) withVideoLayersAllocation(header *rtp.Header, streamIndex, extensionID int, allowMixed bool) *rtp.Header {
+	if extensionID == 0 {
+		return header
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.allocation == nil {
+		return header
+	}
+	if s.streams == nil {
+		s.streams = map[int]*videoLayersAllocationStream{}
+	}
+	stream, ok := s.streams[streamIndex]
+	if !ok {
+		stream = &videoLayersAllocationStream{}
+		s.streams[streamIndex] = stream
+	}
+
+	newFrame := !stream.started || header.Timestamp != stream.lastTimestamp
+	stream.started, stream.lastTimestamp = true, header.Timestamp
+	if !newFrame || (stream.version == s.version && time.Since(stream.sentAt) < videoLayersAllocationInterval) {
+		return header
+	}
+
+	allocation := *s.allocation
+	allocation.RTPStreamIndex = streamIndex
+	payload, err := allocation.Marshal()
+	if err != nil {
+		return header
+	}
+
+	withExtension := *header
+	withExtension.Extensions = append([]rtp.Extension{}, header.Extensions...)
+	if err = setHeaderExtension(&withExtension, uint8(extensionID), payload, allowMixed); err != nil {
+		return header
+	}
+	stream.version, stream.sentAt = s.version, time.Now()
+
+	return &withExtension
+}
+
+// headerExtensionID returns the negotiated ID of the header extension uri, or
+// 0 if it wasn't negotiated
+func headerExtensionID(headerExtensions []RTPHeaderExtensionParameter, uri string) int {
+	for _, extension := range headerExtensions {
+		if extension.URI == uri {
+			return extension.ID
+		}
+	}
+
+	return 0
+}
+
+// VideoLayersAllocation returns the video layers allocation header extension
+// of a packet of the track, and false if it has none or it wasn't negotiated
+func (t *TrackRemote) VideoLayersAllocation(header *rtp.Header) (VideoLayersAllocation, bool, error) {
+	t.mu.RLock()
+	headerExtensions := t.params.HeaderExtensions
+	t.mu.RUnlock()
+
+	allocation := VideoLayersAllocation{}
+	id := headerExtensionID(headerExtensions, VideoLayersAllocationURI)
+	if id == 0 {
+		return allocation, false, nil
+	}
+
+	payload := header.GetExtension(uint8(id))
+	if payload == nil {
+		return allocation, false, nil
+	}
+
+	if err := allocation.Unmarshal(payload); err != nil {
+		return allocation, false, err
+	}
+
+	return allocation, true, nil
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVideoLayersAllocation_Marshal(t *testing.T) {
+	for i, testCase := range []struct {
+		allocation VideoLayersAllocation
+		raw        []byte
+	}{
+		{VideoLayersAllocation{}, []byte{0x00}},
+		{
+			VideoLayersAllocation{
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+					{RTPStreamIndex: 0, SpatialID: 0, TargetBitratesKbps: []uint64{100}},
+				},
+			},
+			[]byte{0x01, 0x00, 0x64},
+		},
+		{
+			VideoLayersAllocation{
+				RTPStreamIndex: 1,
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+					{RTPStreamIndex: 0, SpatialID: 0, TargetBitratesKbps: []uint64{100, 150}, Width: 320, Height: 180, FrameRate: 30},
+					{RTPStreamIndex: 1, SpatialID: 0, TargetBitratesKbps: []uint64{500}, Width: 640, Height: 360, FrameRate: 30},
+				},
+			},
+			[]byte{
+				0x51, 0x40, 0x64, 0x96, 0x01, 0xf4, 0x03,
+				0x01, 0x3f, 0x00, 0xb3, 0x1e,
+				0x02, 0x7f, 0x01, 0x67, 0x1e,
+			},
+		},
+		{
+			VideoLayersAllocation{
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+					{RTPStreamIndex: 0, SpatialID: 0, TargetBitratesKbps: []uint64{100}},
+					{RTPStreamIndex: 0, SpatialID: 1, TargetBitratesKbps: []uint64{300}},
+					{RTPStreamIndex: 1, SpatialID: 0, TargetBitratesKbps: []uint64{500}},
+				},
+			},
+			[]byte{0x10, 0x31, 0x00, 0x64, 0xac, 0x02, 0xf4, 0x03},
+		},
+	} {
+		raw, err := testCase.allocation.Marshal()
+		assert.NoError(t, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.raw, raw, "testCase: %d %v", i, testCase)
+
+		allocation := VideoLayersAllocation{}
+		assert.NoError(t, allocation.Unmarshal(raw), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.allocation, allocation, "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestVideoLayersAllocation_Invalid(t *testing.T) {
+	for i, layers := range [][]VideoLayersAllocationSpatialLayer{
+		{{RTPStreamIndex: 4, TargetBitratesKbps: []uint64{100}}},
+		{{SpatialID: 4, TargetBitratesKbps: []uint64{100}}},
+		{{TargetBitratesKbps: []uint64{}}},
+		{{TargetBitratesKbps: []uint64{1, 2, 3, 4, 5}}},
+		{{SpatialID: 1, TargetBitratesKbps: []uint64{100}}, {SpatialID: 0, TargetBitratesKbps: []uint64{100}}},
+	} {
+		_, err := VideoLayersAllocation{ActiveSpatialLayers: layers}.Marshal()
+		assert.ErrorIs(t, err, errVideoLayersAllocationInvalid, "testCase: %d %v", i, layers)
+	}
+
+	for i, raw := range [][]byte{
+		{},
+		{0x01},
+		{0x01, 0x00},
+		{0x01, 0x00, 0x80},
+		{0x10, 0x31, 0x00},
+	} {
+		assert.ErrorIs(t, (&VideoLayersAllocation{}).Unmarshal(raw), errVideoLayersAllocationTooShort, "testCase: %d %v", i, raw)
+	}
+
+	assert.ErrorIs(t, (&VideoLayersAllocation{}).Unmarshal([]byte{0x01, 0x00, 0x64, 0x00}), errVideoLayersAllocationInvalid)
+}
+
+func TestRTPSender_VideoLayersAllocation(t *testing.T) {
+	allocation := &VideoLayersAllocation{
+		ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+			{RTPStreamIndex: 0, SpatialID: 0, TargetBitratesKbps: []uint64{100}},
+			{RTPStreamIndex: 1, SpatialID: 0, TargetBitratesKbps: []uint64{500}},
+		},
+	}
+
+	r := &RTPSender{}
+	sender := &r.videoLayersAllocation
+
+	header := &rtp.Header{Timestamp: 1}
+	assert.Equal(t, header, sender.withVideoLayersAllocation(header, 0, 5, false), "nothing is sent until an allocation is set")
+
+	r.SetVideoLayersAllocation(allocation)
+	assert.Equal(t, header, sender.withVideoLayersAllocation(header, 0, 0, false), "nothing is sent if the extension wasn't negotiated")
+
+	header = &rtp.Header{Timestamp: 2}
+	withExtension := sender.withVideoLayersAllocation(header, 1, 5, false)
+	assert.Nil(t, header.GetExtension(5), "the header is copied")
+
+	sent := VideoLayersAllocation{}
+	assert.NoError(t, sent.Unmarshal(withExtension.GetExtension(5)))
+	assert.Equal(t, 1, sent.RTPStreamIndex)
+	assert.Equal(t, allocation.ActiveSpatialLayers, sent.ActiveSpatialLayers)
+
+	header = &rtp.Header{Timestamp: 3}
+	assert.Equal(t, header, sender.withVideoLayersAllocation(header, 1, 5, false), "an unchanged allocation isn't repeated before the interval")
+
+	r.SetVideoLayersAllocation(allocation)
+	header = &rtp.Header{Timestamp: 3}
+	assert.Equal(t, header, sender.withVideoLayersAllocation(header, 1, 5, false), "the allocation is only sent on the first packet of a frame")
+	header = &rtp.Header{Timestamp: 4}
+	assert.NotNil(t, sender.withVideoLayersAllocation(header, 1, 5, false).GetExtension(5), "a changed allocation is sent on the next frame")
+}
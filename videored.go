@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isVideoRedundancyCodec returns true for the video codecs which aren't
+// primary codecs, but repair or protect their packets
+func isVideoRedundancyCodec(mimeType string) bool {
+	return strings.EqualFold(mimeType, MimeTypeRTX) ||
+		strings.EqualFold(mimeType, MimeTypeRED) ||
+		strings.EqualFold(mimeType, MimeTypeULPFEC)
+}
+
+// RegisterVideoRED registers video/red with redPayloadType, which wraps the
+// packets of the primary video codecs and their ULPFEC packets as browsers
+// do, RFC 2198 and RFC 5109. If rtxPayloadType isn't 0 a video/rtx codec with
+// apt=redPayloadType is registered to retransmit RED packets, and if
+// ulpfecPayloadType isn't 0 video/ulpfec is registered. A primary video codec
+// must be registered first. The payload types are remapped to the ones of
+// the remote when negotiated, like for RTX.
+// RegisterVideoRED is not safe for concurrent use.
+func (m *MediaEngine) RegisterVideoRED(redPayloadType, rtxPayloadType, ulpfecPayloadType PayloadType) error {
+	m.mu.RLock()
+	hasPrimary := false
+	for _, codec := range m.videoCodecs {
+		hasPrimary = hasPrimary || !isVideoRedundancyCodec(codec.MimeType)
+	}
+	m.mu.RUnlock()
+
+	if !hasPrimary {
+		return ErrNoCodecsAvailable
+	}
+
+	codecs := []RTPCodecParameters{{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeRED, 90000, 0, "", nil},
+		PayloadType:        redPayloadType,
+	}}
+	if rtxPayloadType != 0 {
+		codecs = append(codecs, RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, fmt.Sprintf("apt=%d", redPayloadType), nil},
+			PayloadType:        rtxPayloadType,
+		})
+	}
+	if ulpfecPayloadType != 0 {
+		codecs = append(codecs, RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeULPFEC, 90000, 0, "", nil},
+			PayloadType:        ulpfecPayloadType,
+		})
+	}
+
+	for _, codec := range codecs {
+		if err := m.RegisterCodec(codec, RTPCodecTypeVideo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaEngine_RegisterVideoRED(t *testing.T) {
+	m := &MediaEngine{}
+	assert.ErrorIs(t, m.RegisterVideoRED(116, 117, 118), ErrNoCodecsAvailable)
+
+	assert.NoError(t, m.RegisterDefaultCodecs())
+	assert.NoError(t, m.RegisterVideoRED(116, 117, 118))
+
+	pc, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	assert.Contains(t, offer.SDP, "a=rtpmap:116 red/90000")
+	assert.Contains(t, offer.SDP, "a=rtpmap:117 rtx/90000")
+	assert.Contains(t, offer.SDP, "a=fmtp:117 apt=116")
+	assert.Contains(t, offer.SDP, "a=rtpmap:118 ulpfec/90000")
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestMediaEngine_VideoREDRemoteDescription(t *testing.T) {
+	const browserOffer = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 9 UDP/TLS/RTP/SAVPF 96 97 121 122 123
+a=rtpmap:96 VP8/90000
+a=rtpmap:97 rtx/90000
+a=fmtp:97 apt=96
+a=rtpmap:121 red/90000
+a=rtpmap:122 rtx/90000
+a=fmtp:122 apt=121
+a=rtpmap:123 ulpfec/90000
+`
+
+	testCases := []struct {
+		registerRED bool
+		expectedRED bool
+	}{
+		{true, true},
+		{false, false},
+	}
+
+	for i, testCase := range testCases {
+		m := &MediaEngine{}
+		assert.NoError(t, m.RegisterDefaultCodecs(), "testCase: %d %v", i, testCase)
+		if testCase.registerRED {
+			assert.NoError(t, m.RegisterVideoRED(116, 117, 118), "testCase: %d %v", i, testCase)
+		}
+
+		s := sdp.SessionDescription{}
+		assert.NoError(t, s.Unmarshal([]byte(browserOffer)))
+		assert.NoError(t, m.updateFromRemoteDescription(s), "testCase: %d %v", i, testCase)
+
+		// The payload types of the remote are used
+		for _, payloadType := range []PayloadType{121, 122, 123} {
+			_, _, err := m.getCodecByPayload(payloadType)
+			assert.Equal(t, testCase.expectedRED, err == nil, "testCase: %d %v", i, testCase)
+		}
+
+		if testCase.expectedRED {
+			rtx, _, err := m.getCodecByPayload(122)
+			assert.NoError(t, err, "testCase: %d %v", i, testCase)
+			assert.Equal(t, "apt=121", rtx.SDPFmtpLine, "testCase: %d %v", i, testCase)
+		}
+	}
+}